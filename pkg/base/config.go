@@ -540,6 +540,11 @@ type ExternalIODirConfig struct {
 	// This turns off implicit credentials, and requires the user to provide
 	// necessary access keys.
 	DisableImplicitCredentials bool
+	// LocalKMSKeysDir is the directory holding the master key files of the
+	// local-kms KMS. It must not be inside the external IO directory, so that
+	// the keys cannot be read through nodelocal storage. local-kms is disabled
+	// if it is empty.
+	LocalKMSKeysDir string
 }
 
 // TempStorageConfigFromEnv creates a TempStorageConfig.
@@ -450,7 +450,9 @@ func (b *backupResumer) Resume(
 	if desc, err := readBackupManifest(ctx, defaultStore, BackupManifestCheckpointName, details.Encryption); err == nil {
 		// If the checkpoint is from a different cluster, it's meaningless to us.
 		// More likely though are dummy/lock-out checkpoints with no ClusterID.
-		if desc.ClusterID.Equal(p.ExecCfg().ClusterID()) {
+		// The checkpoints of a compaction have the ClusterID of the compacted
+		// chain.
+		if desc.ClusterID.Equal(backupManifest.ClusterID) {
 			checkpointDesc = &desc
 		}
 	} else {
@@ -467,23 +469,30 @@ func (b *backupResumer) Resume(
 		return err
 	}
 
-	statsCache := p.ExecCfg().TableStatsCache
-	res, err := backup(
-		ctx,
-		p,
-		details.URI,
-		details.URIsByLocalityKV,
-		p.ExecCfg().DB,
-		p.ExecCfg().Settings,
-		defaultStore,
-		storageByLocalityKV,
-		b.job,
-		&backupManifest,
-		checkpointDesc,
-		p.ExecCfg().DistSQLSrv.ExternalStorage,
-		details.Encryption,
-		statsCache,
-	)
+	var res RowCount
+	if len(details.CompactFromURIs) > 0 {
+		res, err = compactBackups(
+			ctx, p, details.CompactFromURIs, defaultStore, b.job, &backupManifest, checkpointDesc, details.Encryption,
+		)
+	} else {
+		statsCache := p.ExecCfg().TableStatsCache
+		res, err = backup(
+			ctx,
+			p,
+			details.URI,
+			details.URIsByLocalityKV,
+			p.ExecCfg().DB,
+			p.ExecCfg().Settings,
+			defaultStore,
+			storageByLocalityKV,
+			b.job,
+			&backupManifest,
+			checkpointDesc,
+			p.ExecCfg().DistSQLSrv.ExternalStorage,
+			details.Encryption,
+			statsCache,
+		)
+	}
 	if err != nil {
 		return err
 	}
@@ -502,6 +511,12 @@ func (b *backupResumer) Resume(
 		}
 	}
 
+	// Failing to delete expired backups should not fail the backup itself: we
+	// will try again the next time the schedule runs.
+	if err := deleteExpiredBackups(ctx, p.ExecCfg(), p.User(), *b.job.ID(), details); err != nil {
+		log.Warningf(ctx, "failed to delete expired backups: %v", err)
+	}
+
 	resultsCh <- tree.Datums{
 		tree.NewDInt(tree.DInt(*b.job.ID())),
 		tree.NewDString(string(jobs.StatusSucceeded)),
@@ -511,8 +526,11 @@ func (b *backupResumer) Resume(
 		tree.NewDInt(tree.DInt(res.DataSize)),
 	}
 
-	// Collect telemetry.
-	{
+	// Collect telemetry. A compaction does not count as a backup, since it does
+	// not read from the cluster.
+	if len(details.CompactFromURIs) > 0 {
+		telemetry.Count("backup.compaction.succeeded")
+	} else {
 		telemetry.Count("backup.total.succeeded")
 		const mb = 1 << 20
 		sizeMb := res.DataSize / mb
@@ -557,9 +575,13 @@ func (b *backupResumer) clearStats(ctx context.Context, DB *kv.DB) error {
 
 // OnFailOrCancel is part of the jobs.Resumer interface.
 func (b *backupResumer) OnFailOrCancel(ctx context.Context, phs interface{}) error {
-	telemetry.Count("backup.total.failed")
-	telemetry.CountBucketed("backup.duration-sec.failed",
-		int64(timeutil.Since(timeutil.FromUnixMicros(b.job.Payload().StartedMicros)).Seconds()))
+	if len(b.job.Details().(jobspb.BackupDetails).CompactFromURIs) > 0 {
+		telemetry.Count("backup.compaction.failed")
+	} else {
+		telemetry.Count("backup.total.failed")
+		telemetry.CountBucketed("backup.duration-sec.failed",
+			int64(timeutil.Since(timeutil.FromUnixMicros(b.job.Payload().StartedMicros)).Seconds()))
+	}
 
 	p := phs.(sql.PlanHookState)
 	cfg := p.ExecCfg()
@@ -48,6 +48,7 @@ const (
 	backupOptRevisionHistory = "revision_history"
 	backupOptEncPassphrase   = "encryption_passphrase"
 	backupOptWithPrivileges  = "privileges"
+	backupOptCheckFiles      = "check_files"
 	localityURLParam         = "COCKROACH_LOCALITY"
 	defaultLocalityValue     = "default"
 )
@@ -2797,6 +2797,130 @@ func TestBackupRestoreChecksum(t *testing.T) {
 	sqlDB.ExpectErr(t, "checksum mismatch", `RESTORE data.* FROM $1`, LocalFoo)
 }
 
+func TestBackupVerification(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const numAccounts = 1000
+	_, _, sqlDB, dir, cleanupFn := BackupRestoreTestSetup(t, MultiNode, numAccounts, InitNone)
+	defer cleanupFn()
+	dir = filepath.Join(dir, "foo")
+
+	sqlDB.Exec(t, `BACKUP DATABASE data TO $1`, LocalFoo)
+	sqlDB.Exec(t, `UPDATE data.bank SET balance = balance + 1 WHERE id < 10`)
+	sqlDB.Exec(t, `BACKUP DATABASE data TO $1`, LocalFoo)
+	sqlDB.Exec(t, `CREATE DATABASE restore`)
+
+	sqlDB.Exec(t, `SHOW BACKUP $1 WITH check_files`, LocalFoo)
+
+	// A verify_only RESTORE reports the counts of the data it would restore,
+	// without a job, and restores nothing.
+	var jobID gosql.NullInt64
+	var status string
+	var fractionCompleted float32
+	var rows, indexEntries, dataSize int64
+	sqlDB.QueryRow(t, `RESTORE data.bank FROM $1 WITH into_db = 'restore', verify_only`, LocalFoo).Scan(
+		&jobID, &status, &fractionCompleted, &rows, &indexEntries, &dataSize,
+	)
+	require.False(t, jobID.Valid)
+	require.Equal(t, string(jobs.StatusSucceeded), status)
+	require.Equal(t, int64(numAccounts), rows)
+	require.NotZero(t, dataSize)
+	sqlDB.CheckQueryResults(t,
+		`SELECT count(*) FROM [SHOW TABLES FROM restore]`, [][]string{{"0"}},
+	)
+	sqlDB.CheckQueryResults(t,
+		`SELECT count(*) FROM [SHOW JOBS] WHERE job_type = 'RESTORE'`, [][]string{{"0"}},
+	)
+
+	backupManifestBytes, err := ioutil.ReadFile(filepath.Join(dir, BackupManifestName))
+	require.NoError(t, err)
+	if fileType := http.DetectContentType(backupManifestBytes); fileType == ZipType {
+		backupManifestBytes, err = DecompressData(backupManifestBytes)
+		require.NoError(t, err)
+	}
+	var backupManifest BackupManifest
+	require.NoError(t, protoutil.Unmarshal(backupManifestBytes, &backupManifest))
+	var paths []string
+	for _, file := range backupManifest.Files {
+		if file.Path != "" {
+			paths = append(paths, file.Path)
+		}
+	}
+	require.True(t, len(paths) >= 2)
+
+	// Corrupt one of the files of the full backup. The last eight bytes of an
+	// SST file store a nonzero magic number, so nulling them out changes the
+	// checksum.
+	corruptPath := paths[0]
+	f, err := os.OpenFile(filepath.Join(dir, corruptPath), os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.Seek(-8, io.SeekEnd)
+	require.NoError(t, err)
+	_, err = f.Write(make([]byte, 8))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	sqlDB.ExpectErr(t, fmt.Sprintf("1 backup file.* failed verification, including %s: checksum mismatch", corruptPath),
+		`SHOW BACKUP $1 WITH check_files`, LocalFoo)
+	sqlDB.ExpectErr(t, "checksum mismatch",
+		`RESTORE data.bank FROM $1 WITH into_db = 'restore', verify_only`, LocalFoo)
+
+	// Remove another file.
+	require.NoError(t, os.Remove(filepath.Join(dir, paths[1])))
+	sqlDB.ExpectErr(t, "2 backup file.* failed verification",
+		`SHOW BACKUP $1 WITH check_files`, LocalFoo)
+	sqlDB.ExpectErr(t, "failed verification",
+		`RESTORE data.bank FROM $1 WITH into_db = 'restore', verify_only`, LocalFoo)
+}
+
+func TestBackupCompaction(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const numAccounts = 1000
+	_, _, sqlDB, _, cleanupFn := BackupRestoreTestSetup(t, MultiNode, numAccounts, InitNone)
+	defer cleanupFn()
+
+	const compacted = "nodelocal://0/compacted"
+	const other = "nodelocal://0/other"
+
+	sqlDB.Exec(t, `BACKUP DATABASE data TO $1`, LocalFoo)
+	sqlDB.Exec(t, `UPDATE data.bank SET balance = balance + 1 WHERE id < 100`)
+	sqlDB.Exec(t, `DELETE FROM data.bank WHERE id >= 900`)
+	sqlDB.Exec(t, `BACKUP DATABASE data TO $1`, LocalFoo)
+	sqlDB.Exec(t, `INSERT INTO data.bank VALUES (1000, 1, 'new')`)
+	sqlDB.Exec(t, `BACKUP DATABASE data TO $1`, LocalFoo)
+	expected := sqlDB.QueryStr(t, `SELECT * FROM data.bank ORDER BY id`)
+
+	sqlDB.Exec(t, `BACKUP DATABASE data TO $1`, other)
+	sqlDB.ExpectErr(t, "does not have any incremental backups to compact",
+		`COMPACT BACKUP FROM $1 TO $2`, other, compacted)
+	sqlDB.ExpectErr(t, "already contains a BACKUP file",
+		`COMPACT BACKUP FROM $1 TO $2`, LocalFoo, other)
+
+	var unused string
+	var rows int64
+	sqlDB.QueryRow(t, `COMPACT BACKUP FROM $1 TO $2`, LocalFoo, compacted).Scan(
+		&unused, &unused, &unused, &rows, &unused, &unused,
+	)
+	require.Equal(t, int64(len(expected)), rows)
+
+	// The compacted backup is a full backup as of the end time of the chain.
+	sqlDB.CheckQueryResults(t,
+		`SELECT start_time IS NULL, end_time = (SELECT max(end_time) FROM [SHOW BACKUP $1])
+		   FROM [SHOW BACKUP $2 WITH check_files] WHERE table_name = 'bank'`,
+		[][]string{{"true", "true"}},
+	)
+
+	sqlDB.Exec(t, `CREATE DATABASE restore`)
+	sqlDB.Exec(t, `RESTORE data.bank FROM $1 WITH into_db = 'restore'`, compacted)
+	sqlDB.CheckQueryResults(t, `SELECT * FROM restore.bank ORDER BY id`, expected)
+
+	// The chain of backups is left untouched.
+	sqlDB.Exec(t, `SHOW BACKUP $1 WITH check_files`, LocalFoo)
+}
+
 func TestTimestampMismatch(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -3285,6 +3409,70 @@ func TestRestoreDatabaseVersusTable(t *testing.T) {
 	})
 }
 
+func TestRestoreDatabaseWithNewName(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const numAccounts = 10
+	_, _, sqlDB, _, cleanupFn := BackupRestoreTestSetup(t, singleNode, numAccounts, InitNone)
+	defer cleanupFn()
+
+	sqlDB.Exec(t, `
+SET experimental_enable_enums = true;
+CREATE TYPE data.greeting AS ENUM ('hello', 'hi');
+CREATE TABLE data.greetings (id INT PRIMARY KEY, g data.greeting);
+INSERT INTO data.greetings VALUES (1, 'hello'), (2, 'hi');
+CREATE VIEW data.bank_ids AS SELECT id FROM data.public.bank;
+CREATE DATABASE other;
+`)
+	sqlDB.Exec(t, `BACKUP DATABASE data, other TO $1`, LocalFoo)
+
+	// Restoring the database next to the live one leaves the live one alone.
+	sqlDB.Exec(t, `RESTORE DATABASE data FROM $1 WITH new_db_name = 'data_copy'`, LocalFoo)
+	sqlDB.CheckQueryResults(t, `SELECT count(*) FROM data_copy.bank`, [][]string{{"10"}})
+	sqlDB.CheckQueryResults(t, `SELECT count(*) FROM data_copy.bank_ids`, [][]string{{"10"}})
+	sqlDB.CheckQueryResults(t,
+		`SELECT id, g FROM data_copy.greetings WHERE g = 'hi'::data_copy.greeting`,
+		[][]string{{"2", "hi"}})
+
+	// The restored objects belong to the new database.
+	sqlDB.Exec(t, `UPDATE data_copy.bank SET balance = balance + 1`)
+	sqlDB.CheckQueryResults(t,
+		`SELECT count(*) FROM data.bank b JOIN data_copy.bank c USING (id) WHERE b.balance = c.balance`,
+		[][]string{{"0"}})
+
+	// The restored type is rewritten to belong to the new database as well.
+	sqlDB.CheckQueryResults(t,
+		`SELECT database_name, schema_name, descriptor_name, enum_members
+FROM data_copy.crdb_internal.create_type_statements`,
+		[][]string{{"data_copy", "public", "greeting", "{hello,hi}"}})
+	sqlDB.CheckQueryResults(t, `SELECT ARRAY['hi', 'hello']::data_copy._greeting`,
+		[][]string{{"{hi,hello}"}})
+
+	sqlDB.ExpectErr(t, `database "data_copy" already exists`,
+		`RESTORE DATABASE data FROM $1 WITH new_db_name = 'data_copy'`, LocalFoo)
+	sqlDB.ExpectErr(t, `database "data_copy" already exists`,
+		`RESTORE DATABASE other FROM $1 WITH new_db_name = 'data_copy'`, LocalFoo)
+	sqlDB.ExpectErr(t, `"new_db_name" option can only be used when restoring a single database`,
+		`RESTORE DATABASE data, other FROM $1 WITH new_db_name = 'both'`, LocalFoo)
+	sqlDB.ExpectErr(t, `"new_db_name" option can only be used when restoring a single database`,
+		`RESTORE TABLE data.bank FROM $1 WITH new_db_name = 'bank_only'`, LocalFoo)
+
+	// Schema descriptors are not backed up, so a database with user-defined
+	// schemas could not be restored under any name.
+	sqlDB.Exec(t, `
+SET experimental_enable_enums = true;
+SET experimental_enable_user_defined_schemas = true;
+USE other;
+CREATE SCHEMA sc;
+CREATE TABLE sc.t (a INT);
+CREATE TYPE sc.typ AS ENUM ('a');
+USE data;
+`)
+	sqlDB.ExpectErr(t, `objects in user-defined schema "sc" are not supported`,
+		`BACKUP DATABASE other TO $1`, LocalFoo+"/sc")
+}
+
 func TestBackupAzureAccountName(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -0,0 +1,268 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/storage/cloud"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+	gogotypes "github.com/gogo/protobuf/types"
+)
+
+// All of the files written by the processor are streamed to the coordinator
+// through metadata, so it has an empty result stream.
+var compactBackupDataOutputTypes = []*types.T{}
+
+type compactBackupDataProcessor struct {
+	flowCtx *execinfra.FlowCtx
+	spec    execinfrapb.CompactBackupDataSpec
+	output  execinfra.RowReceiver
+}
+
+var _ execinfra.Processor = &compactBackupDataProcessor{}
+
+// OutputTypes implements the execinfra.Processor interface.
+func (cp *compactBackupDataProcessor) OutputTypes() []*types.T {
+	return compactBackupDataOutputTypes
+}
+
+func newCompactBackupDataProcessor(
+	flowCtx *execinfra.FlowCtx,
+	_ int32,
+	spec execinfrapb.CompactBackupDataSpec,
+	output execinfra.RowReceiver,
+) (execinfra.Processor, error) {
+	cp := &compactBackupDataProcessor{
+		flowCtx: flowCtx,
+		spec:    spec,
+		output:  output,
+	}
+	return cp, nil
+}
+
+// Run implements the execinfra.Processor interface.
+func (cp *compactBackupDataProcessor) Run(ctx context.Context) {
+	ctx, span := tracing.ChildSpan(ctx, "compactBackupDataProcessor")
+	defer tracing.FinishSpan(span)
+	defer cp.output.ProducerDone()
+
+	if err := runCompactBackupData(ctx, cp.flowCtx, &cp.spec, cp.output); err != nil {
+		cp.output.Push(nil, &execinfrapb.ProducerMetadata{Err: err})
+	}
+}
+
+func runCompactBackupData(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	spec *execinfrapb.CompactBackupDataSpec,
+	output execinfra.RowReceiver,
+) error {
+	dest, err := flowCtx.Cfg.ExternalStorage(ctx, spec.Destination)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	targetSize := int64(storageccl.ExportRequestTargetFileSize.Get(&flowCtx.Cfg.Settings.SV))
+	instanceID := flowCtx.EvalCtx.NodeID.SQLInstanceID()
+	for _, entry := range spec.Entries {
+		log.VEventf(ctx, 1 /* level */, "compacting span %v", entry.Span)
+		files, err := compactRestoreSpanEntry(
+			ctx, flowCtx.Cfg.ExternalStorage, dest, instanceID, entry, spec.Encryption, spec.PKIDs, targetSize,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "compacting span %v", entry.Span)
+		}
+
+		// The files of an entry are sent together, so that the coordinator only
+		// checkpoints entries that were entirely compacted.
+		progDetails := BackupManifest_Progress{Files: files}
+		details, err := gogotypes.MarshalAny(&progDetails)
+		if err != nil {
+			return err
+		}
+		var prog execinfrapb.RemoteProducerMetadata_BulkProcessorProgress
+		prog.ProgressDetails = *details
+		if output.Push(nil, &execinfrapb.ProducerMetadata{BulkProcessorProgress: &prog}) != execinfra.NeedMoreRows {
+			return nil
+		}
+	}
+	return nil
+}
+
+// compactRestoreSpanEntry merges the files of the given entry, keeping the
+// latest revision of every key that was not deleted, and writes the result to
+// new files of dest. A file is written out as soon as its data reaches
+// targetSize, so that at most one file is buffered at a time. The spans of the
+// returned files cover the span of the entry. If the entry has no live data, a
+// single file without a path is returned.
+func compactRestoreSpanEntry(
+	ctx context.Context,
+	makeExternalStorage cloud.ExternalStorageFactory,
+	dest cloud.ExternalStorage,
+	instanceID base.SQLInstanceID,
+	entry execinfrapb.RestoreSpanEntry,
+	encryption *roachpb.FileEncryptionOptions,
+	pkIDs map[uint64]bool,
+	targetSize int64,
+) ([]BackupManifest_File, error) {
+	var iters []storage.SimpleIterator
+	defer func() {
+		for _, iter := range iters {
+			iter.Close()
+		}
+	}()
+	for _, file := range entry.Files {
+		fileContents, err := readBackupDataFile(ctx, makeExternalStorage, file, encryption)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", file.Path)
+		}
+		iter, err := storage.NewMemSSTIterator(fileContents, false /* verify */)
+		if err != nil {
+			return nil, err
+		}
+		iters = append(iters, iter)
+	}
+
+	var files []BackupManifest_File
+	w := compactedFileWriter{
+		dest:       dest,
+		instanceID: instanceID,
+		encryption: encryption,
+		pkIDs:      pkIDs,
+	}
+	w.reset(entry.Span.Key)
+	defer func() { w.sst.Close() }()
+
+	startKeyMVCC, endKeyMVCC := storage.MVCCKey{Key: entry.Span.Key}, storage.MVCCKey{Key: entry.Span.EndKey}
+	iter := storage.MakeMultiIterator(iters)
+	defer iter.Close()
+	for iter.SeekGE(startKeyMVCC); ; {
+		ok, err := iter.Valid()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		if !iter.UnsafeKey().Less(endKeyMVCC) {
+			break
+		}
+		// The backups of the chain only contain revisions up to the end time of
+		// the chain, so the first revision of a key is its latest one.
+		unsafeKey, unsafeValue := iter.UnsafeKey(), iter.UnsafeValue()
+		if len(unsafeValue) == 0 {
+			// Value is deleted.
+			iter.NextKey()
+			continue
+		}
+		if w.rows.BulkOpSummary.DataSize >= targetSize {
+			file, err := w.flush(ctx, append(roachpb.Key(nil), unsafeKey.Key...))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, file)
+		}
+		if err := w.put(unsafeKey, unsafeValue); err != nil {
+			return nil, err
+		}
+		iter.NextKey()
+	}
+	if w.rows.BulkOpSummary.DataSize == 0 {
+		return []BackupManifest_File{{Span: entry.Span}}, nil
+	}
+	file, err := w.flush(ctx, entry.Span.EndKey)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, file), nil
+}
+
+// compactedFileWriter buffers the data of a compacted file in memory until it
+// is written out to dest.
+type compactedFileWriter struct {
+	dest       cloud.ExternalStorage
+	instanceID base.SQLInstanceID
+	encryption *roachpb.FileEncryptionOptions
+	pkIDs      map[uint64]bool
+
+	startKey roachpb.Key
+	sstFile  *storage.MemFile
+	sst      storage.SSTWriter
+	rows     storage.RowCounter
+}
+
+// reset starts a new file whose span starts at startKey.
+func (w *compactedFileWriter) reset(startKey roachpb.Key) {
+	w.startKey = startKey
+	w.sstFile = &storage.MemFile{}
+	w.sst = storage.MakeBackupSSTWriter(w.sstFile)
+	w.rows = storage.RowCounter{}
+}
+
+func (w *compactedFileWriter) put(key storage.MVCCKey, value []byte) error {
+	if err := w.rows.Count(key.Key); err != nil {
+		return errors.Wrapf(err, "decoding %s", key)
+	}
+	if err := w.sst.Put(key, value); err != nil {
+		return errors.Wrapf(err, "adding key %s", key)
+	}
+	w.rows.BulkOpSummary.DataSize += int64(len(key.Key) + len(value))
+	return nil
+}
+
+// flush writes the current file to dest, with a span that ends at endKey, and
+// starts a new file at endKey.
+func (w *compactedFileWriter) flush(
+	ctx context.Context, endKey roachpb.Key,
+) (BackupManifest_File, error) {
+	if err := w.sst.Finish(); err != nil {
+		return BackupManifest_File{}, err
+	}
+	data := w.sstFile.Data()
+	checksum, err := storageccl.SHA512ChecksumData(data)
+	if err != nil {
+		return BackupManifest_File{}, err
+	}
+	if w.encryption != nil {
+		if data, err = storageccl.EncryptFile(data, w.encryption.Key); err != nil {
+			return BackupManifest_File{}, err
+		}
+	}
+	path := fmt.Sprintf("%d.sst", builtins.GenerateUniqueInt(w.instanceID))
+	if err := w.dest.WriteFile(ctx, path, bytes.NewReader(data)); err != nil {
+		return BackupManifest_File{}, err
+	}
+	file := BackupManifest_File{
+		Span:        roachpb.Span{Key: w.startKey, EndKey: endKey},
+		Path:        path,
+		Sha512:      checksum,
+		EntryCounts: countRows(w.rows.BulkOpSummary, w.pkIDs),
+	}
+	w.reset(endKey)
+	return file, nil
+}
+
+func init() {
+	rowexec.NewCompactBackupDataProcessor = newCompactBackupDataProcessor
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/physicalplan"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/logtags"
+)
+
+// distCompactBackup is used to plan the processors that merge the files of
+// the given entries into new files written to dest, which are distributed
+// across all the nodes of the cluster. The processors stream the files they
+// wrote for each entry over progCh, which is closed when distCompactBackup
+// returns.
+func distCompactBackup(
+	ctx context.Context,
+	phs sql.PlanHookState,
+	entries []importEntry,
+	dest roachpb.ExternalStorage,
+	encryption *roachpb.FileEncryptionOptions,
+	pkIDs map[uint64]bool,
+	progCh chan *execinfrapb.RemoteProducerMetadata_BulkProcessorProgress,
+) error {
+	ctx = logtags.AddTag(ctx, "compact-backup-distsql", nil)
+	defer close(progCh)
+	var noTxn *kv.Txn
+
+	dsp := phs.DistSQLPlanner()
+	evalCtx := phs.ExtendedEvalContext()
+
+	planCtx, nodes, err := dsp.SetupAllNodesPlanning(ctx, evalCtx, phs.ExecCfg())
+	if err != nil {
+		return err
+	}
+
+	// The entries are assigned round-robin to the nodes, since the files they
+	// read and write are not local to any of them.
+	specs := make([]*execinfrapb.CompactBackupDataSpec, len(nodes))
+	for i, entry := range entries {
+		spec := specs[i%len(nodes)]
+		if spec == nil {
+			spec = &execinfrapb.CompactBackupDataSpec{
+				Destination: dest,
+				Encryption:  encryption,
+				PKIDs:       pkIDs,
+			}
+			specs[i%len(nodes)] = spec
+		}
+		spec.Entries = append(spec.Entries, execinfrapb.RestoreSpanEntry{
+			Span:        entry.Span,
+			Files:       entry.files,
+			ProgressIdx: int64(i),
+		})
+	}
+
+	// Setup a one-stage plan with one proc per node with entries to compact.
+	var corePlacement []physicalplan.ProcessorCorePlacement
+	for i, spec := range specs {
+		if spec == nil {
+			continue
+		}
+		corePlacement = append(corePlacement, physicalplan.ProcessorCorePlacement{
+			NodeID: nodes[i],
+			Core:   execinfrapb.ProcessorCoreUnion{CompactBackupData: spec},
+		})
+	}
+	if len(corePlacement) == 0 {
+		return nil
+	}
+
+	gatewayNodeID, err := evalCtx.ExecCfg.NodeID.OptionalNodeIDErr(47970)
+	if err != nil {
+		return err
+	}
+	p := sql.MakePhysicalPlan(gatewayNodeID)
+	p.AddNoInputStage(corePlacement, execinfrapb.PostProcessSpec{}, compactBackupDataOutputTypes, execinfrapb.Ordering{})
+	p.PlanToStreamColMap = []int{}
+
+	dsp.FinalizePlan(planCtx, &p)
+
+	metaFn := func(_ context.Context, meta *execinfrapb.ProducerMetadata) error {
+		if meta.BulkProcessorProgress != nil {
+			// Send the progress up a level to be written to the checkpoint.
+			progCh <- meta.BulkProcessorProgress
+		}
+		return nil
+	}
+
+	rowResultWriter := sql.NewRowResultWriter(nil)
+
+	recv := sql.MakeDistSQLReceiver(
+		ctx,
+		sql.NewMetadataCallbackWriter(rowResultWriter, metaFn),
+		tree.Rows,
+		nil,   /* rangeCache */
+		noTxn, /* txn - the flow does not read or write the database */
+		func(ts hlc.Timestamp) {},
+		evalCtx.Tracing,
+	)
+	defer recv.Release()
+
+	// Copy the evalCtx, as dsp.Run() might change it.
+	evalCtxCopy := *evalCtx
+	dsp.Run(planCtx, noTxn, &p, recv, &evalCtxCopy, nil /* finishedSetupFn */)()
+	return rowResultWriter.Err()
+}
@@ -0,0 +1,260 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/cloud"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+	gogotypes "github.com/gogo/protobuf/types"
+)
+
+// compactBackups merges the data of the given chain of backups into new files
+// written to defaultStore, verifies them, and finally writes the manifest of
+// the new full backup, so that it is only usable once all of its files were
+// verified. The backups of the chain are only read.
+//
+// The files written for the spans that were entirely compacted are
+// periodically checkpointed. A resumed compaction keeps the files of the
+// checkpoint, deletes the other files written by the previous attempts and
+// compacts the remaining spans.
+func compactBackups(
+	ctx context.Context,
+	p sql.PlanHookState,
+	fromURIs []string,
+	defaultStore cloud.ExternalStorage,
+	job *jobs.Job,
+	backupManifest *BackupManifest,
+	checkpointDesc *BackupManifest,
+	encryption *roachpb.FileEncryptionOptions,
+) (RowCount, error) {
+	execCfg := p.ExecCfg()
+	manifests := make([]BackupManifest, len(fromURIs))
+	for i, uri := range fromURIs {
+		var err error
+		manifests[i], err = ReadBackupManifestFromURI(
+			ctx, uri, p.User(), execCfg.DistSQLSrv.ExternalStorageFromURI, encryption,
+		)
+		if err != nil {
+			return RowCount{}, errors.Wrapf(err, "reading backup %d of the chain", i)
+		}
+	}
+
+	entries, _, err := makeImportSpans(
+		backupManifest.Spans,
+		manifests,
+		nil, /* backupLocalityInfo */
+		keys.MinKey,
+		p.User(),
+		errOnMissingRange,
+	)
+	if err != nil {
+		return RowCount{}, err
+	}
+
+	var files []BackupManifest_File
+	if checkpointDesc != nil {
+		files = checkpointDesc.Files
+	}
+	if err := deleteUncheckpointedFiles(ctx, defaultStore, files); err != nil {
+		return RowCount{}, err
+	}
+	entries = filterCompactedEntries(entries, files)
+
+	pkIDs := make(map[uint64]bool)
+	for _, desc := range backupManifest.Descriptors {
+		if t := desc.Table(hlc.Timestamp{}); t != nil {
+			pkIDs[roachpb.BulkOpSummaryID(uint64(t.ID), uint64(t.PrimaryIndex.ID))] = true
+		}
+	}
+
+	progressLogger := jobs.NewChunkProgressLogger(job, len(entries), job.FractionCompleted(), jobs.ProgressUpdateOnly)
+	requestFinishedCh := make(chan struct{}, len(entries)) // enough buffer to never block
+	g := ctxgroup.WithContext(ctx)
+	if len(entries) > 0 {
+		g.GoCtx(func(ctx context.Context) error {
+			return progressLogger.Loop(ctx, requestFinishedCh)
+		})
+	}
+
+	var lastCheckpoint time.Time
+	progCh := make(chan *execinfrapb.RemoteProducerMetadata_BulkProcessorProgress)
+	g.GoCtx(func(ctx context.Context) error {
+		// When a processor is done compacting an entry, it sends the files that
+		// it wrote for it to progCh.
+		for progress := range progCh {
+			var progDetails BackupManifest_Progress
+			if err := gogotypes.UnmarshalAny(&progress.ProgressDetails, &progDetails); err != nil {
+				log.Errorf(ctx, "unable to unmarshal compaction progress details: %+v", err)
+			}
+			files = append(files, progDetails.Files...)
+
+			// Signal that an entry was compacted to update job progress.
+			requestFinishedCh <- struct{}{}
+			if timeutil.Since(lastCheckpoint) > BackupCheckpointInterval {
+				checkpoint := *backupManifest
+				checkpoint.Files = append([]BackupManifest_File(nil), files...)
+				if err := writeBackupManifest(
+					ctx, execCfg.Settings, defaultStore, BackupManifestCheckpointName, encryption, &checkpoint,
+				); err != nil {
+					log.Errorf(ctx, "unable to checkpoint compacted backup descriptor: %+v", err)
+				}
+				lastCheckpoint = timeutil.Now()
+			}
+		}
+		return nil
+	})
+
+	if err := distCompactBackup(
+		ctx, p, entries, defaultStore.Conf(), encryption, pkIDs, progCh,
+	); err != nil {
+		return RowCount{}, err
+	}
+	if err := g.Wait(); err != nil {
+		return RowCount{}, errors.Wrapf(err, "compacting %d spans", errors.Safe(len(entries)))
+	}
+
+	var compacted RowCount
+	var verifyEntries []importEntry
+	backupManifest.Files = nil
+	for _, file := range files {
+		// Spans without any live data do not have a file.
+		if len(file.Path) == 0 {
+			continue
+		}
+		backupManifest.Files = append(backupManifest.Files, file)
+		compacted.add(file.EntryCounts)
+		verifyEntries = append(verifyEntries, importEntry{
+			Span:      file.Span,
+			entryType: request,
+			files: []roachpb.ImportRequest_File{
+				{Dir: defaultStore.Conf(), Path: file.Path, Sha512: file.Sha512},
+			},
+		})
+	}
+	backupManifest.EntryCounts = compacted
+
+	if _, err := verifyBackupData(
+		ctx, p, verifyEntries, hlc.Timestamp{}, encryption, nil /* rekeys */, nil /* pkIDs */, true, /* checkSpans */
+	); err != nil {
+		return RowCount{}, errors.Wrap(err, "verifying compacted backup")
+	}
+
+	// The table statistics of the last backup of the chain are carried over.
+	if len(backupManifest.StatisticsFilenames) > 0 {
+		if err := copyTableStatistics(
+			ctx, execCfg.DistSQLSrv.ExternalStorage, manifests[len(manifests)-1].Dir, defaultStore,
+			backupManifest.StatisticsFilenames, encryption,
+		); err != nil {
+			return RowCount{}, err
+		}
+	}
+
+	backupManifest.ID = uuid.MakeV4()
+	if err := writeBackupManifest(
+		ctx, execCfg.Settings, defaultStore, BackupManifestName, encryption, backupManifest,
+	); err != nil {
+		return RowCount{}, err
+	}
+	return compacted, nil
+}
+
+// deleteUncheckpointedFiles deletes the data files of defaultStore that are
+// not part of the given checkpointed files. These were written by a previous
+// attempt of the compaction after its last checkpoint. The destination of a
+// compaction is locked by its checkpoint, so no other job writes to it.
+func deleteUncheckpointedFiles(
+	ctx context.Context, defaultStore cloud.ExternalStorage, checkpointed []BackupManifest_File,
+) error {
+	keep := make(map[string]bool, len(checkpointed))
+	for _, file := range checkpointed {
+		keep[file.Path] = true
+	}
+	names, err := defaultStore.ListFiles(ctx, "*.sst")
+	if err != nil {
+		return errors.Wrap(err, "listing files of the previous attempts")
+	}
+	for _, name := range names {
+		if keep[name] {
+			continue
+		}
+		if err := defaultStore.Delete(ctx, name); err != nil {
+			return errors.Wrapf(err, "deleting file %s of a previous attempt", name)
+		}
+	}
+	return nil
+}
+
+// filterCompactedEntries returns the entries whose span is not covered by the
+// spans of the given files. The files written for an entry always cover its
+// span, including a file without a path if it had no live data.
+func filterCompactedEntries(entries []importEntry, files []BackupManifest_File) []importEntry {
+	if len(files) == 0 {
+		return entries
+	}
+	var compacted roachpb.SpanGroup
+	for _, file := range files {
+		compacted.Add(file.Span)
+	}
+	var remaining []importEntry
+	for _, entry := range entries {
+		// The entries do not overlap, so adding the span of an entry which is
+		// not covered yet does not change the outcome for the other entries.
+		if compacted.Add(entry.Span) {
+			remaining = append(remaining, entry)
+		}
+	}
+	return remaining
+}
+
+// copyTableStatistics copies the given table statistics files of the backup
+// stored in src to dest.
+func copyTableStatistics(
+	ctx context.Context,
+	makeExternalStorage cloud.ExternalStorageFactory,
+	src roachpb.ExternalStorage,
+	dest cloud.ExternalStorage,
+	filenames map[sqlbase.ID]string,
+	encryption *roachpb.FileEncryptionOptions,
+) error {
+	srcStore, err := makeExternalStorage(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer srcStore.Close()
+
+	copied := make(map[string]bool)
+	for _, filename := range filenames {
+		if copied[filename] {
+			continue
+		}
+		stats, err := readTableStatistics(ctx, srcStore, filename, encryption)
+		if err != nil {
+			return errors.Wrapf(err, "reading table statistics from %s", filename)
+		}
+		if err := writeTableStatistics(ctx, dest, filename, encryption, stats); err != nil {
+			return err
+		}
+		copied[filename] = true
+	}
+	return nil
+}
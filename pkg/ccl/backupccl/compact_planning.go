@@ -0,0 +1,294 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/build"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/cloud"
+	"github.com/cockroachdb/cockroach/pkg/storage/cloudimpl"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+)
+
+const compactOptDetached = "detached"
+
+var compactOptionExpectValues = map[string]sql.KVStringOptValidate{
+	backupOptEncPassphrase: sql.KVStringOptRequireValue,
+	compactOptDetached:     sql.KVStringOptRequireNoValue,
+}
+
+func compactJobDescription(
+	p sql.PlanHookState, from []string, to string, opts map[string]string,
+) (string, error) {
+	c := &tree.CompactBackup{
+		Options: optsToKVOptions(opts),
+	}
+	for _, uri := range from {
+		sf, err := cloudimpl.SanitizeExternalStorageURI(uri, nil /* extraParams */)
+		if err != nil {
+			return "", err
+		}
+		c.From = append(c.From, tree.NewDString(sf))
+	}
+	st, err := cloudimpl.SanitizeExternalStorageURI(to, nil /* extraParams */)
+	if err != nil {
+		return "", err
+	}
+	c.To = tree.NewDString(st)
+
+	ann := p.ExtendedEvalContext().Annotations
+	return tree.AsStringWithFQNames(c, ann), nil
+}
+
+// compactPlanHook implements sql.PlanHookFn.
+func compactPlanHook(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (sql.PlanHookRowFn, sqlbase.ResultColumns, []sql.PlanNode, bool, error) {
+	compactStmt, ok := stmt.(*tree.CompactBackup)
+	if !ok {
+		return nil, nil, nil, false, nil
+	}
+
+	fromFn, err := p.TypeAsStringArray(ctx, compactStmt.From, "COMPACT BACKUP")
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	toFn, err := p.TypeAsString(ctx, compactStmt.To, "COMPACT BACKUP")
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	optsFn, err := p.TypeAsStringOpts(ctx, compactStmt.Options, compactOptionExpectValues)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+
+	detached := false
+	for _, opt := range compactStmt.Options {
+		if opt.Key == compactOptDetached {
+			detached = true
+		}
+	}
+
+	fn := func(ctx context.Context, _ []sql.PlanNode, resultsCh chan<- tree.Datums) error {
+		// TODO(dan): Move this span into sql.
+		ctx, span := tracing.ChildSpan(ctx, stmt.StatementTag())
+		defer tracing.FinishSpan(span)
+
+		if err := utilccl.CheckEnterpriseEnabled(
+			p.ExecCfg().Settings, p.ExecCfg().ClusterID(), p.ExecCfg().Organization(), "COMPACT BACKUP",
+		); err != nil {
+			return err
+		}
+
+		if err := p.RequireAdminRole(ctx, "COMPACT BACKUP"); err != nil {
+			return err
+		}
+
+		if !(p.ExtendedEvalContext().TxnImplicit || detached) {
+			return errors.Errorf("COMPACT BACKUP cannot be used inside a transaction without DETACHED option")
+		}
+
+		from, err := fromFn()
+		if err != nil {
+			return err
+		}
+		to, err := toFn()
+		if err != nil {
+			return err
+		}
+		opts, err := optsFn()
+		if err != nil {
+			return err
+		}
+		return doCompactPlan(ctx, p, from, to, opts, detached, resultsCh)
+	}
+
+	if detached {
+		return fn, utilccl.DetachedJobExecutionResultHeader, nil, false, nil
+	}
+	return fn, utilccl.BulkJobExecutionResultHeader, nil, false, nil
+}
+
+func doCompactPlan(
+	ctx context.Context,
+	p sql.PlanHookState,
+	from []string,
+	to string,
+	opts map[string]string,
+	detached bool,
+	resultsCh chan<- tree.Datums,
+) error {
+	makeCloudStorage := p.ExecCfg().DistSQLSrv.ExternalStorageFromURI
+
+	baseStore, err := makeCloudStorage(ctx, from[0], p.User())
+	if err != nil {
+		return errors.Wrapf(err, "failed to open backup storage location")
+	}
+	defer baseStore.Close()
+
+	var encryption *roachpb.FileEncryptionOptions
+	var encInfo *EncryptionInfo
+	if passphrase, ok := opts[backupOptEncPassphrase]; ok {
+		if encInfo, err = readEncryptionOptions(ctx, baseStore); err != nil {
+			return err
+		}
+		encryptionKey := storageccl.GenerateKey([]byte(passphrase), encInfo.Salt)
+		encryption = &roachpb.FileEncryptionOptions{Key: encryptionKey}
+	}
+
+	fromURIs := make([][]string, len(from))
+	for i := range from {
+		fromURIs[i] = []string{from[i]}
+	}
+	defaultURIs, manifests, _, err := resolveBackupManifests(
+		ctx, []cloud.ExternalStorage{baseStore}, makeCloudStorage, fromURIs, hlc.Timestamp{}, encryption,
+		p.User(),
+	)
+	if err != nil {
+		return err
+	}
+	for i := range manifests {
+		if len(manifests[i].PartitionDescriptorFilenames) > 0 {
+			return errors.Errorf("COMPACT BACKUP is not supported for locality-aware backups")
+		}
+	}
+	if len(manifests) < 2 {
+		return errors.Errorf("%s does not have any incremental backups to compact", from[0])
+	}
+
+	// The compacted backup contains the latest revision of the data of the last
+	// backup of the chain, and can only be restored as of its end time.
+	last := manifests[len(manifests)-1]
+	if _, coveredEnd, err := makeImportSpans(
+		last.Spans,
+		manifests,
+		nil, /* backupLocalityInfo */
+		keys.MinKey,
+		p.User(),
+		errOnMissingRange,
+	); err != nil {
+		return errors.Wrap(err, "invalid chain of backups")
+	} else if coveredEnd != last.EndTime {
+		return errors.Errorf("expected the chain of backups to cover to %v, not %v", last.EndTime, coveredEnd)
+	}
+
+	nodeID, err := p.ExecCfg().NodeID.OptionalNodeIDErr(47970)
+	if err != nil {
+		return err
+	}
+
+	backupManifest := BackupManifest{
+		EndTime:              last.EndTime,
+		MVCCFilter:           MVCCFilter_Latest,
+		Descriptors:          last.Descriptors,
+		Tenants:              last.Tenants,
+		CompleteDbs:          last.CompleteDbs,
+		Spans:                last.Spans,
+		FormatVersion:        BackupFormatDescriptorTrackingVersion,
+		BuildInfo:            build.GetInfo(),
+		NodeID:               nodeID,
+		ClusterID:            last.ClusterID,
+		DeprecatedStatistics: last.DeprecatedStatistics,
+		StatisticsFilenames:  last.StatisticsFilenames,
+		DescriptorCoverage:   last.DescriptorCoverage,
+	}
+	descBytes, err := protoutil.Marshal(&backupManifest)
+	if err != nil {
+		return err
+	}
+
+	description, err := compactJobDescription(p, from, to, opts)
+	if err != nil {
+		return err
+	}
+
+	destStore, err := makeCloudStorage(ctx, to, p.User())
+	if err != nil {
+		return err
+	}
+	defer destStore.Close()
+	if err := VerifyUsableExportTarget(
+		ctx, p.ExecCfg().Settings, destStore, to, encryption,
+	); err != nil {
+		return err
+	}
+	// The compacted backup is encrypted with the same key as the chain, so it
+	// can be restored with the same passphrase.
+	if encInfo != nil {
+		if err := writeEncryptionOptions(ctx, encInfo, destStore); err != nil {
+			return err
+		}
+	}
+
+	jr := jobs.Record{
+		Description: description,
+		Username:    p.User(),
+		DescriptorIDs: func() (sqlDescIDs []sqlbase.ID) {
+			for _, sqlDesc := range backupManifest.Descriptors {
+				sqlDescIDs = append(sqlDescIDs, sqlDesc.GetID())
+			}
+			return sqlDescIDs
+		}(),
+		Details: jobspb.BackupDetails{
+			EndTime:         last.EndTime,
+			URI:             to,
+			BackupManifest:  descBytes,
+			Encryption:      encryption,
+			CompactFromURIs: defaultURIs,
+		},
+		Progress: jobspb.BackupProgress{},
+	}
+
+	telemetry.Count("backup.compaction.started")
+
+	if detached {
+		// When running in detached mode, we simply create the job record.
+		// We do not wait for the job to finish.
+		return utilccl.StartAsyncJob(ctx, p, &jr, resultsCh)
+	}
+
+	var sj *jobs.StartableJob
+	if err := p.ExecCfg().DB.Txn(ctx, func(ctx context.Context, txn *kv.Txn) (err error) {
+		sj, err = p.ExecCfg().JobRegistry.CreateStartableJobWithTxn(ctx, jr, txn, resultsCh)
+		return err
+	}); err != nil {
+		if sj != nil {
+			if cleanupErr := sj.CleanupOnRollback(ctx); cleanupErr != nil {
+				log.Warningf(ctx, "failed to cleanup StartableJob: %v", cleanupErr)
+			}
+		}
+		return err
+	}
+
+	errCh, err := sj.Start(ctx)
+	if err != nil {
+		return err
+	}
+	return <-errCh
+}
+
+func init() {
+	sql.AddPlanHook(compactPlanHook)
+}
@@ -11,6 +11,7 @@ package backupccl
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,18 +25,23 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/errors"
 	pbtypes "github.com/gogo/protobuf/types"
+	"github.com/gorhill/cronexpr"
 )
 
 const (
 	optFirstRun          = "first_run"
 	optOnExecFailure     = "on_execution_failure"
 	optOnPreviousRunning = "on_previous_running"
+	optKeepFullBackups   = "keep_full_backups"
+	optKeepFor           = "keep_for"
 )
 
 var scheduledBackupOptionExpectValues = map[string]sql.KVStringOptValidate{
 	optFirstRun:          sql.KVStringOptRequireValue,
 	optOnExecFailure:     sql.KVStringOptRequireValue,
 	optOnPreviousRunning: sql.KVStringOptRequireValue,
+	optKeepFullBackups:   sql.KVStringOptRequireValue,
+	optKeepFor:           sql.KVStringOptRequireValue,
 }
 
 // scheduledBackupEval is a representation of tree.ScheduledBackup, prepared
@@ -48,9 +54,10 @@ type scheduledBackupEval struct {
 	recurrence   func() (string, error)
 	scheduleOpts func() (map[string]string, error)
 
-	// TODO(yevgeniy): Support full backup recurrence. For now, assume recurrence is
-	// the full backup.
-	// fullBackupRecurrence func() (string, error)
+	// fullBackupRecurrence is set if the FULL BACKUP clause specifies a crontab.
+	// It is only honored by schedules with a retention policy; other schedules
+	// assume that the recurrence is the full backup.
+	fullBackupRecurrence func() (string, error)
 
 	// Backup specific properties that get evaluated.
 	// We need to evaluate anything in the tree.Backup node that allows
@@ -93,13 +100,8 @@ func parseWaitBehavior(wait string, details *jobspb.ScheduleDetails) error {
 }
 
 func setScheduleOptions(
-	eval *scheduledBackupEval, evalCtx *tree.EvalContext, sj *jobs.ScheduledJob,
+	opts map[string]string, evalCtx *tree.EvalContext, sj *jobs.ScheduledJob,
 ) error {
-	opts, err := eval.scheduleOpts()
-	if err != nil {
-		return err
-	}
-
 	if v, ok := opts[optFirstRun]; ok {
 		firstRun, _, err := tree.ParseDTimestampTZ(evalCtx, v, time.Microsecond)
 		if err != nil {
@@ -128,6 +130,42 @@ func setScheduleOptions(
 	return nil
 }
 
+// setRetentionPolicy records the retention policy of the schedule, if one
+// was specified, in the scheduled backup arguments.
+func setRetentionPolicy(
+	eval *scheduledBackupEval, opts map[string]string, args *ScheduledBackupExecutionArgs,
+) error {
+	if v, ok := opts[optKeepFullBackups]; ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 {
+			return errors.Newf(
+				"%q is not a valid %s; expected a positive number of full backups", v, optKeepFullBackups)
+		}
+		args.KeepFullBackups = n
+	}
+	if v, ok := opts[optKeepFor]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return errors.Newf(
+				"%q is not a valid %s; expected a positive duration (e.g. '720h')", v, optKeepFor)
+		}
+		args.KeepFor = d
+	}
+
+	if !hasRetentionPolicy(args) || eval.fullBackupRecurrence == nil {
+		return nil
+	}
+	recurrence, err := eval.fullBackupRecurrence()
+	if err != nil {
+		return err
+	}
+	if _, err := cronexpr.Parse(recurrence); err != nil {
+		return errors.Wrapf(err, "invalid FULL BACKUP recurrence %q", recurrence)
+	}
+	args.FullBackupRecurrence = recurrence
+	return nil
+}
+
 // doCreateBackupSchedule creates requested schedule (or schedules).
 // It is a plan hook implementation responsible for the creating of scheduled backup.
 func doCreateBackupSchedule(
@@ -175,7 +213,14 @@ func doCreateBackupSchedule(
 		}
 	}
 
-	if err := setScheduleOptions(eval, &p.ExtendedEvalContext().EvalContext, sj); err != nil {
+	opts, err := eval.scheduleOpts()
+	if err != nil {
+		return err
+	}
+	if err := setScheduleOptions(opts, &p.ExtendedEvalContext().EvalContext, sj); err != nil {
+		return err
+	}
+	if err := setRetentionPolicy(eval, opts, args); err != nil {
 		return err
 	}
 
@@ -276,6 +321,13 @@ func makeScheduledBackupEval(
 		}
 	}
 
+	if schedule.FullBackup != nil && !schedule.FullBackup.AlwaysFull {
+		eval.fullBackupRecurrence, err = p.TypeAsString(ctx, schedule.FullBackup.Recurrence, scheduleBackupOp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	eval.scheduleOpts, err = p.TypeAsStringOpts(
 		ctx, schedule.ScheduleOptions, scheduledBackupOptionExpectValues)
 	if err != nil {
@@ -11,6 +11,7 @@ package backupccl
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"sort"
 	"testing"
 	"time"
@@ -41,6 +42,7 @@ const allSchedules = 0
 // via executeSchedules callback.
 type execSchedulesFn = func(ctx context.Context, maxSchedules int64, txn *kv.Txn) error
 type testHelper struct {
+	iodir            string
 	server           serverutils.TestServerInterface
 	env              *jobstest.JobSchedulerTestEnv
 	cfg              *scheduledjobs.JobExecutionConfig
@@ -54,7 +56,8 @@ func newTestHelper(t *testing.T) (*testHelper, func()) {
 	dir, dirCleanupFn := testutils.TempDir(t)
 
 	th := &testHelper{
-		env: jobstest.NewJobSchedulerTestEnv(jobstest.UseSystemTables, timeutil.Now()),
+		iodir: dir,
+		env:   jobstest.NewJobSchedulerTestEnv(jobstest.UseSystemTables, timeutil.Now()),
 	}
 
 	knobs := &jobs.TestingKnobs{
@@ -339,3 +342,282 @@ INSERT INTO t1 values (-1), (10), (-100);
 		})
 	}
 }
+
+func TestScheduledBackupRetentionPolicyArgs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	th, cleanup := newTestHelper(t)
+	defer cleanup()
+
+	testCases := []struct {
+		name                 string
+		query                string
+		keepFullBackups      int64
+		keepFor              time.Duration
+		fullBackupRecurrence string
+		errMsg               string
+	}{
+		{
+			name:  "no-retention",
+			query: "CREATE SCHEDULE FOR BACKUP TO 'somewhere' RECURRING '@hourly' FULL BACKUP '@daily'",
+		},
+		{
+			name: "keep-full-backups",
+			query: `
+CREATE SCHEDULE FOR BACKUP TO 'somewhere' RECURRING '@daily'
+WITH EXPERIMENTAL SCHEDULE OPTIONS keep_full_backups=3`,
+			keepFullBackups: 3,
+		},
+		{
+			name: "keep-for-with-full-backup-recurrence",
+			query: `
+CREATE SCHEDULE FOR BACKUP TO 'somewhere' RECURRING '@hourly' FULL BACKUP '@daily'
+WITH EXPERIMENTAL SCHEDULE OPTIONS keep_for='168h'`,
+			keepFor:              168 * time.Hour,
+			fullBackupRecurrence: "@daily",
+		},
+		{
+			name: "always-full",
+			query: `
+CREATE SCHEDULE FOR BACKUP TO 'somewhere' RECURRING '@daily' FULL BACKUP ALWAYS
+WITH EXPERIMENTAL SCHEDULE OPTIONS keep_full_backups=1, keep_for='24h'`,
+			keepFullBackups: 1,
+			keepFor:         24 * time.Hour,
+		},
+		{
+			name: "invalid-keep-full-backups",
+			query: `
+CREATE SCHEDULE FOR BACKUP TO 'somewhere' RECURRING '@daily'
+WITH EXPERIMENTAL SCHEDULE OPTIONS keep_full_backups=0`,
+			errMsg: `"0" is not a valid keep_full_backups`,
+		},
+		{
+			name: "invalid-keep-for",
+			query: `
+CREATE SCHEDULE FOR BACKUP TO 'somewhere' RECURRING '@daily'
+WITH EXPERIMENTAL SCHEDULE OPTIONS keep_for='forever'`,
+			errMsg: `"forever" is not a valid keep_for`,
+		},
+		{
+			name: "invalid-full-backup-recurrence",
+			query: `
+CREATE SCHEDULE FOR BACKUP TO 'somewhere' RECURRING '@hourly' FULL BACKUP 'sometimes'
+WITH EXPERIMENTAL SCHEDULE OPTIONS keep_full_backups=2`,
+			errMsg: "invalid FULL BACKUP recurrence",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer th.clearSchedules(t)
+
+			schedules, err := th.createBackupSchedule(tc.query)
+			if len(tc.errMsg) > 0 {
+				require.True(t, testutils.IsError(err, tc.errMsg), "%v", err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, 1, len(schedules))
+
+			var arg ScheduledBackupExecutionArgs
+			require.NoError(t, types.UnmarshalAny(schedules[0].ExecutionArgs().Args, &arg))
+			require.Equal(t, tc.keepFullBackups, arg.KeepFullBackups)
+			require.Equal(t, tc.keepFor, arg.KeepFor)
+			require.Equal(t, tc.fullBackupRecurrence, arg.FullBackupRecurrence)
+		})
+	}
+}
+
+func TestExpiredBackupChains(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	chains := []string{
+		"20200101-000000.00",
+		"20200102-000000.00",
+		"20200103-000000.00",
+		"20200104-000000.00",
+	}
+	now := time.Date(2020, 1, 4, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name    string
+		chains  []string
+		args    ScheduledBackupExecutionArgs
+		expired []string
+	}{
+		{
+			name:   "no-chains",
+			chains: nil,
+			args:   ScheduledBackupExecutionArgs{KeepFullBackups: 1},
+		},
+		{
+			name:   "latest-chain-is-retained",
+			chains: chains[3:],
+			args:   ScheduledBackupExecutionArgs{KeepFullBackups: 1, KeepFor: time.Hour},
+		},
+		{
+			name:    "keep-one",
+			chains:  chains,
+			args:    ScheduledBackupExecutionArgs{KeepFullBackups: 1},
+			expired: chains[:3],
+		},
+		{
+			name:    "keep-three",
+			chains:  chains,
+			args:    ScheduledBackupExecutionArgs{KeepFullBackups: 3},
+			expired: chains[:1],
+		},
+		{
+			name:   "keep-more-than-exist",
+			chains: chains,
+			args:   ScheduledBackupExecutionArgs{KeepFullBackups: 10},
+		},
+		{
+			// Restoring to any time in the last 36 hours requires the chain that
+			// started on the 3rd, which covers the 3rd until the 4th.
+			name:    "keep-for",
+			chains:  chains,
+			args:    ScheduledBackupExecutionArgs{KeepFor: 36 * time.Hour},
+			expired: chains[:2],
+		},
+		{
+			name:    "keep-for-and-keep-full-backups",
+			chains:  chains,
+			args:    ScheduledBackupExecutionArgs{KeepFullBackups: 3, KeepFor: 36 * time.Hour},
+			expired: chains[:1],
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expired, expiredBackupChains(tc.chains, &tc.args, now))
+		})
+	}
+}
+
+func TestFullBackupDue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const chain = "20200101-000000.00"
+	testCases := []struct {
+		name    string
+		args    ScheduledBackupExecutionArgs
+		runTime time.Time
+		due     bool
+	}{
+		{
+			name:    "no-chain",
+			args:    ScheduledBackupExecutionArgs{FullBackupRecurrence: "@daily"},
+			runTime: time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC),
+			due:     true,
+		},
+		{
+			name:    "no-recurrence",
+			args:    ScheduledBackupExecutionArgs{CurrentBackupChain: chain},
+			runTime: time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC),
+			due:     true,
+		},
+		{
+			name: "append-to-chain",
+			args: ScheduledBackupExecutionArgs{
+				CurrentBackupChain: chain, FullBackupRecurrence: "@daily",
+			},
+			runTime: time.Date(2020, 1, 1, 23, 0, 0, 0, time.UTC),
+			due:     false,
+		},
+		{
+			name: "recurrence-is-due",
+			args: ScheduledBackupExecutionArgs{
+				CurrentBackupChain: chain, FullBackupRecurrence: "@daily",
+			},
+			runTime: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+			due:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			due, err := fullBackupDue(&tc.args, tc.runTime)
+			require.NoError(t, err)
+			require.Equal(t, tc.due, due)
+		})
+	}
+}
+
+func TestScheduledBackupDeletesExpiredBackups(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	th, cleanup := newTestHelper(t)
+	defer cleanup()
+
+	th.sqlDB.Exec(t, `
+CREATE DATABASE db;
+CREATE TABLE db.t(a int);
+INSERT INTO db.t VALUES (1), (2), (3);
+`)
+
+	th.cfg.TestingKnobs.(*jobs.TestingKnobs).OverrideAsOfClause = func(clause *tree.AsOfClause) {
+		expr, err := tree.MakeDTimestampTZ(th.cfg.DB.Clock().PhysicalTime(), time.Microsecond)
+		require.NoError(t, err)
+		clause.Expr = expr
+	}
+
+	const destination = "nodelocal://0/backup/retention"
+	schedules, err := th.createBackupSchedule(`
+CREATE SCHEDULE FOR BACKUP TABLE db.t TO $1 RECURRING '@hourly'
+WITH EXPERIMENTAL SCHEDULE OPTIONS keep_full_backups=1`, destination)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, len(schedules))
+	scheduleID := schedules[0].ScheduleID()
+
+	// The chains are listed as the entries of the destination, so that a chain
+	// only counts as deleted once its directory is gone.
+	listChains := func() []string {
+		matches, err := filepath.Glob(filepath.Join(th.iodir, "backup", "retention", "*"))
+		require.NoError(t, err)
+		var chains []string
+		for _, m := range matches {
+			chains = append(chains, filepath.Base(m))
+		}
+		sort.Strings(chains)
+		return chains
+	}
+
+	// Every run writes a new full backup. Once the backup of a run completes,
+	// the chain of the previous run is no longer retained, and it is deleted
+	// before the backup job finishes.
+	const numRuns = 3
+	var chains []string
+	th.env.SetTime(schedules[0].NextRun().Add(time.Second))
+	for i := 1; i <= numRuns; i++ {
+		require.NoError(t,
+			th.cfg.DB.Txn(context.Background(), func(ctx context.Context, txn *kv.Txn) error {
+				return th.executeSchedules(ctx, allSchedules, txn)
+			}))
+
+		// Wait for the backup of this run to complete.
+		th.server.JobRegistry().(*jobs.Registry).TestingNudgeAdoptionQueue()
+		testutils.SucceedsSoon(t, func() error {
+			var succeeded int
+			th.sqlDB.QueryRow(t, "SELECT count(*) FROM "+th.env.SystemJobsTableName()+
+				" WHERE status=$1 AND created_by_type=$2 AND created_by_id=$3",
+				jobs.StatusSucceeded, jobs.CreatedByScheduledJobs, scheduleID).Scan(&succeeded)
+			if succeeded != i {
+				return fmt.Errorf("expected %d successful backups, found %d", i, succeeded)
+			}
+			return nil
+		})
+
+		prevChains := chains
+		chains = listChains()
+		require.Equal(t, 1, len(chains), "chains after run %d: %v", i, chains)
+		require.NotEqual(t, prevChains, chains, "chains after run %d: %v", i, chains)
+		th.env.AdvanceTime(time.Hour)
+	}
+
+	// The remaining chains can be restored.
+	for _, chain := range chains {
+		th.sqlDB.Exec(t, "DROP DATABASE IF EXISTS restored CASCADE; CREATE DATABASE restored")
+		th.sqlDB.Exec(t, "RESTORE db.t FROM $1 WITH into_db='restored'", destination+"/"+chain)
+		th.sqlDB.CheckQueryResults(t, "SELECT * FROM restored.t ORDER BY a",
+			[][]string{{"1"}, {"2"}, {"3"}})
+	}
+}
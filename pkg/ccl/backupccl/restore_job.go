@@ -583,6 +583,38 @@ func rewriteBackupSpanKey(kr *storageccl.KeyRewriter, key roachpb.Key) (roachpb.
 	return newKey, nil
 }
 
+// makeImportRekeys returns the TableRekeys that rewrite the keys of the given
+// tables, as they appear in the backup under oldTableIDs, into the keyspace of
+// their restored descriptors.
+func makeImportRekeys(
+	tables []sqlbase.TableDescriptorInterface, oldTableIDs []sqlbase.ID,
+) ([]roachpb.ImportRequest_TableRekey, error) {
+	var rekeys []roachpb.ImportRequest_TableRekey
+	for i := range tables {
+		tableToSerialize := tables[i]
+		newDescBytes, err := protoutil.Marshal(tableToSerialize.DescriptorProto())
+		if err != nil {
+			return nil, errors.NewAssertionErrorWithWrappedErrf(err,
+				"marshaling descriptor")
+		}
+		rekeys = append(rekeys, roachpb.ImportRequest_TableRekey{
+			OldID:   uint32(oldTableIDs[i]),
+			NewDesc: newDescBytes,
+		})
+	}
+	return rekeys, nil
+}
+
+// makePKIDs returns the BulkOpSummary IDs of the primary indexes of the given
+// tables, which are used to tell rows apart from index entries in the counts.
+func makePKIDs(tables []sqlbase.TableDescriptorInterface) map[uint64]bool {
+	pkIDs := make(map[uint64]bool)
+	for _, tbl := range tables {
+		pkIDs[roachpb.BulkOpSummaryID(uint64(tbl.GetID()), uint64(tbl.TableDesc().PrimaryIndex.ID))] = true
+	}
+	return pkIDs
+}
+
 // restore imports a SQL table (or tables) from sets of non-overlapping sstable
 // files.
 func restore(
@@ -614,18 +646,9 @@ func restore(
 	}
 
 	// Get TableRekeys to use when importing raw data.
-	var rekeys []roachpb.ImportRequest_TableRekey
-	for i := range tables {
-		tableToSerialize := tables[i]
-		newDescBytes, err := protoutil.Marshal(tableToSerialize.DescriptorProto())
-		if err != nil {
-			return mu.res, errors.NewAssertionErrorWithWrappedErrf(err,
-				"marshaling descriptor")
-		}
-		rekeys = append(rekeys, roachpb.ImportRequest_TableRekey{
-			OldID:   uint32(oldTableIDs[i]),
-			NewDesc: newDescBytes,
-		})
+	rekeys, err := makeImportRekeys(tables, oldTableIDs)
+	if err != nil {
+		return mu.res, err
 	}
 	kr, err := storageccl.MakeKeyRewriterFromRekeys(rekeys)
 	if err != nil {
@@ -660,10 +683,7 @@ func restore(
 			}
 		})
 
-	pkIDs := make(map[uint64]bool)
-	for _, tbl := range tables {
-		pkIDs[roachpb.BulkOpSummaryID(uint64(tbl.GetID()), uint64(tbl.TableDesc().PrimaryIndex.ID))] = true
-	}
+	pkIDs := makePKIDs(tables)
 
 	// We're already limiting these on the server-side, but sending all the
 	// Import requests at once would fill up distsender/grpc/something and cause
@@ -955,8 +975,12 @@ func createImportingDescriptors(
 		}
 		if dbDesc := desc.GetDatabase(); dbDesc != nil {
 			if rewrite, ok := details.DescriptorRewrites[dbDesc.GetID()]; ok {
+				name := dbDesc.GetName()
+				if details.NewDBName != "" {
+					name = details.NewDBName
+				}
 				rewriteDesc := sqlbase.NewInitialDatabaseDescriptorWithPrivileges(
-					rewrite.ID, dbDesc.GetName(), dbDesc.Privileges)
+					rewrite.ID, name, dbDesc.Privileges)
 				databases = append(databases, rewriteDesc)
 			}
 		}
@@ -987,7 +1011,11 @@ func createImportingDescriptors(
 	for i, table := range tables {
 		tableDescs[i] = table.TableDesc()
 	}
-	if err := RewriteTableDescs(tableDescs, details.DescriptorRewrites, details.OverrideDB); err != nil {
+	overrideDB := details.OverrideDB
+	if details.NewDBName != "" {
+		overrideDB = details.NewDBName
+	}
+	if err := RewriteTableDescs(tableDescs, details.DescriptorRewrites, overrideDB); err != nil {
 		return nil, nil, nil, nil, err
 	}
 
@@ -42,10 +42,12 @@ type DescRewriteMap map[sqlbase.ID]*jobspb.RestoreDetails_DescriptorRewrite
 
 const (
 	restoreOptIntoDB                    = "into_db"
+	restoreOptNewDBName                 = "new_db_name"
 	restoreOptSkipMissingFKs            = "skip_missing_foreign_keys"
 	restoreOptSkipMissingSequences      = "skip_missing_sequences"
 	restoreOptSkipMissingSequenceOwners = "skip_missing_sequence_owners"
 	restoreOptSkipMissingViews          = "skip_missing_views"
+	restoreOptVerifyOnly                = "verify_only"
 
 	// The temporary database system tables will be restored into for full
 	// cluster backups.
@@ -54,10 +56,12 @@ const (
 
 var restoreOptionExpectValues = map[string]sql.KVStringOptValidate{
 	restoreOptIntoDB:                    sql.KVStringOptRequireValue,
+	restoreOptNewDBName:                 sql.KVStringOptRequireValue,
 	restoreOptSkipMissingFKs:            sql.KVStringOptRequireNoValue,
 	restoreOptSkipMissingSequences:      sql.KVStringOptRequireNoValue,
 	restoreOptSkipMissingSequenceOwners: sql.KVStringOptRequireNoValue,
 	restoreOptSkipMissingViews:          sql.KVStringOptRequireNoValue,
+	restoreOptVerifyOnly:                sql.KVStringOptRequireNoValue,
 	backupOptEncPassphrase:              sql.KVStringOptRequireValue,
 }
 
@@ -149,7 +153,8 @@ func maybeFilterMissingViews(
 // for each table in sqlDescs and returns a mapping from old ID to said
 // TableRewrite. It first validates that the provided sqlDescs can be restored
 // into their original database (or the database specified in opts) to avoid
-// leaking table IDs if we can be sure the restore would fail.
+// leaking table IDs if we can be sure the restore would fail. If opts renames
+// the database being restored, the new name is the one that must be free.
 func allocateDescriptorRewrites(
 	ctx context.Context,
 	p sql.PlanHookState,
@@ -172,6 +177,17 @@ func allocateDescriptorRewrites(
 		return nil, errors.Errorf("cannot use %q option when restoring database(s)", restoreOptIntoDB)
 	}
 
+	newDBName, renamingDB := opts[restoreOptNewDBName]
+	if renamingDB {
+		if len(restoreDBNames) != 1 || descriptorCoverage == tree.AllDescriptors {
+			return nil, errors.Errorf("%q option can only be used when restoring a single database",
+				restoreOptNewDBName)
+		}
+		if newDBName == "" {
+			return nil, errors.Errorf("%q option cannot be empty", restoreOptNewDBName)
+		}
+	}
+
 	// The logic at the end of this function leaks table IDs, so fail fast if
 	// we can be certain the restore will fail.
 
@@ -293,6 +309,9 @@ func allocateDescriptorRewrites(
 	if err := p.ExecCfg().DB.Txn(ctx, func(ctx context.Context, txn *kv.Txn) error {
 		// Check that any DBs being restored do _not_ exist.
 		for name := range restoreDBNames {
+			if renamingDB {
+				name = newDBName
+			}
 			found, _, err := sqlbase.LookupDatabaseID(ctx, txn, p.ExecCfg().Codec, name)
 			if err != nil {
 				return err
@@ -1096,15 +1115,48 @@ func doRestorePlan(
 		types = append(types, desc)
 	}
 
+	// A verify_only RESTORE reads the tables as they appear in the backup, so
+	// their spans and IDs are collected before the IDs are rewritten.
+	_, verifyOnly := opts[restoreOptVerifyOnly]
+	var verifySpans []roachpb.Span
+	var oldTableIDs []sqlbase.ID
+	if verifyOnly {
+		backupTables := make([]sqlbase.TableDescriptorInterface, len(tables))
+		for i, table := range tables {
+			backupTables[i] = sqlbase.NewImmutableTableDescriptor(*table)
+			oldTableIDs = append(oldTableIDs, table.ID)
+		}
+		verifySpans = spansForAllTableIndexes(p.ExecCfg().Codec, backupTables, nil)
+		for _, tenant := range tenants {
+			prefix := keys.MakeTenantPrefix(roachpb.MakeTenantID(tenant.ID))
+			verifySpans = append(verifySpans, roachpb.Span{Key: prefix, EndKey: prefix.PrefixEnd()})
+		}
+	}
+
+	// When the database is renamed, every table it contains is restored into
+	// it, so the database qualifiers in its views are rewritten like they are
+	// for into_db.
+	overrideDB := opts[restoreOptIntoDB]
+	if newDBName, ok := opts[restoreOptNewDBName]; ok {
+		overrideDB = newDBName
+	}
+
 	// We attempt to rewrite ID's in the collected type and table descriptors
 	// to catch errors during this process here, rather than in the job itself.
-	if err := RewriteTableDescs(tables, descriptorRewrites, opts[restoreOptIntoDB]); err != nil {
+	if err := RewriteTableDescs(tables, descriptorRewrites, overrideDB); err != nil {
 		return err
 	}
 	if err := rewriteTypeDescs(types, descriptorRewrites); err != nil {
 		return err
 	}
 
+	if verifyOnly {
+		return verifyRestore(
+			ctx, p, mainBackupManifests, localityInfo, endTime, tables, oldTableIDs, verifySpans,
+			encryption, resultsCh,
+		)
+	}
+
 	// Collect telemetry.
 	{
 		telemetry.Count("restore.total.started")
@@ -1129,6 +1181,7 @@ func doRestorePlan(
 			BackupLocalityInfo: localityInfo,
 			TableDescs:         tables,
 			OverrideDB:         opts[restoreOptIntoDB],
+			NewDBName:          opts[restoreOptNewDBName],
 			DescriptorCoverage: restoreStmt.DescriptorCoverage,
 			Encryption:         encryption,
 			Tenants:            tenants,
@@ -1141,6 +1194,55 @@ func doRestorePlan(
 	return <-errCh
 }
 
+// verifyRestore runs the data phase of a RESTORE of the given tables, whose
+// descriptors have been rewritten, without ingesting anything: every file
+// that the RESTORE would read is fetched, decrypted and checksummed, and its
+// keys are rewritten, but nothing is split, scattered or written to the
+// cluster. It returns a result row like the one of a RESTORE job, without a
+// job ID, with the counts of the verified data.
+func verifyRestore(
+	ctx context.Context,
+	p sql.PlanHookState,
+	backupManifests []BackupManifest,
+	backupLocalityInfo []jobspb.RestoreDetails_BackupLocalityInfo,
+	endTime hlc.Timestamp,
+	tableDescs []*sqlbase.TableDescriptor,
+	oldTableIDs []sqlbase.ID,
+	spans []roachpb.Span,
+	encryption *roachpb.FileEncryptionOptions,
+	resultsCh chan<- tree.Datums,
+) error {
+	tables := make([]sqlbase.TableDescriptorInterface, len(tableDescs))
+	for i, table := range tableDescs {
+		tables[i] = sqlbase.NewImmutableTableDescriptor(*table)
+	}
+	rekeys, err := makeImportRekeys(tables, oldTableIDs)
+	if err != nil {
+		return err
+	}
+	importSpans, _, err := makeImportSpans(spans, backupManifests, backupLocalityInfo,
+		keys.MinKey, p.User(), errOnMissingRange)
+	if err != nil {
+		return errors.Wrapf(err, "making import requests for %d backups", len(backupManifests))
+	}
+
+	res, err := verifyBackupData(
+		ctx, p, importSpans, endTime, encryption, rekeys, makePKIDs(tables), false, /* checkSpans */
+	)
+	if err != nil {
+		return err
+	}
+	resultsCh <- tree.Datums{
+		tree.DNull,
+		tree.NewDString(string(jobs.StatusSucceeded)),
+		tree.NewDFloat(tree.DFloat(1.0)),
+		tree.NewDInt(tree.DInt(res.Rows)),
+		tree.NewDInt(tree.DInt(res.IndexEntries)),
+		tree.NewDInt(tree.DInt(res.DataSize)),
+	}
+	return nil
+}
+
 func init() {
 	sql.AddPlanHook(restorePlanHook)
 }
@@ -10,24 +10,42 @@ package backupccl
 
 import (
 	"context"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/scheduledjobs"
 	"github.com/cockroachdb/cockroach/pkg/security"
 	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/storage/cloud"
 	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/errors"
 	pbtypes "github.com/gogo/protobuf/types"
+	"github.com/gorhill/cronexpr"
 )
 
 const scheduledBackupExecutorName = "scheduled-backup-executor"
 
+// Schedules with a retention policy store each full backup, along with the
+// incremental backups appended to it, in its own subdirectory of the
+// destination. Such a subdirectory is called a chain and is named after the
+// time at which its full backup was scheduled.
+const (
+	backupChainNameFormat = "20060102-150405.00"
+	backupChainPattern    = "[0-9]*-[0-9]*.[0-9][0-9]/" + BackupManifestName
+)
+
 type scheduledBackupExecutor struct{}
 
 var _ jobs.ScheduledJobExecutor = &scheduledBackupExecutor{}
@@ -40,7 +58,7 @@ func (se *scheduledBackupExecutor) ExecuteJob(
 	sj *jobs.ScheduledJob,
 	txn *kv.Txn,
 ) error {
-	backupStmt, err := extractBackupStatement(sj)
+	backupStmt, args, err := extractBackupStatement(sj)
 	if err != nil {
 		return err
 	}
@@ -66,17 +84,25 @@ func (se *scheduledBackupExecutor) ExecuteJob(
 	}
 	backupStmt.AsOf = tree.AsOfClause{Expr: endTime}
 
+	// Invoke backup plan hook.
+	// TODO(yevgeniy): Invoke backup as the owner of the schedule.
+	hook, cleanup := cfg.PlanHookMaker("exec-backup", txn, security.RootUser)
+	defer cleanup()
+	p := hook.(sql.PlanHookState)
+
+	if hasRetentionPolicy(args) {
+		if err := setBackupChain(ctx, p, sj, args, backupStmt); err != nil {
+			return errors.Wrapf(err, "choosing backup chain of schedule %d", sj.ScheduleID())
+		}
+	}
+
 	if knobs, ok := cfg.TestingKnobs.(*jobs.TestingKnobs); ok {
 		if knobs.OverrideAsOfClause != nil {
 			knobs.OverrideAsOfClause(&backupStmt.AsOf)
 		}
 	}
 
-	// Invoke backup plan hook.
-	// TODO(yevgeniy): Invoke backup as the owner of the schedule.
-	hook, cleanup := cfg.PlanHookMaker("exec-backup", txn, security.RootUser)
-	defer cleanup()
-	planBackup, cols, _, _, err := backupPlanHook(ctx, backupStmt, hook.(sql.PlanHookState))
+	planBackup, cols, _, _, err := backupPlanHook(ctx, backupStmt, p)
 
 	if err != nil {
 		return errors.Wrapf(err, "backup eval: %q", tree.AsString(backupStmt))
@@ -126,16 +152,274 @@ func (se *scheduledBackupExecutor) NotifyJobTermination(
 	return errors.New("unimplemented yet")
 }
 
-// extractBackupStatement returns tree.Backup node encoded inside scheduled job.
-func extractBackupStatement(sj *jobs.ScheduledJob) (*annotatedBackupStatement, error) {
+// hasRetentionPolicy returns true if the schedule garbage collects its backups.
+func hasRetentionPolicy(args *ScheduledBackupExecutionArgs) bool {
+	return args.KeepFullBackups > 0 || args.KeepFor > 0
+}
+
+// setBackupChain points the backup statement at the chain this run of the
+// schedule should write to: either a new chain, which starts with a full
+// backup, or the current chain, to which an incremental backup is appended.
+// The chain is chosen from the schedule's arguments alone, since listing the
+// destination here would hold the scheduler's transaction open. If the full
+// backup of the current chain did not complete, the next backup written to it
+// is a full backup. The chains that expired are deleted once the backup
+// succeeds, see deleteExpiredBackups.
+func setBackupChain(
+	ctx context.Context,
+	p sql.PlanHookState,
+	sj *jobs.ScheduledJob,
+	args *ScheduledBackupExecutionArgs,
+	backupStmt *annotatedBackupStatement,
+) error {
+	toFn, err := p.TypeAsStringArray(ctx, tree.Exprs(backupStmt.To), "BACKUP")
+	if err != nil {
+		return err
+	}
+	to, err := toFn()
+	if err != nil {
+		return err
+	}
+
+	runTime := sj.ScheduledRunTime()
+	startNewChain, err := fullBackupDue(args, runTime)
+	if err != nil {
+		return err
+	}
+	if startNewChain {
+		args.CurrentBackupChain = runTime.UTC().Format(backupChainNameFormat)
+		any, err := pbtypes.MarshalAny(args)
+		if err != nil {
+			return err
+		}
+		sj.SetExecutionDetails(sj.ExecutorType(), jobspb.ExecutionArguments{Args: any})
+	}
+
+	backupStmt.To = nil
+	for _, uri := range to {
+		chainURI, err := appendPathToURI(uri, args.CurrentBackupChain)
+		if err != nil {
+			return err
+		}
+		backupStmt.To = append(backupStmt.To, tree.NewDString(chainURI))
+	}
+	return nil
+}
+
+// fullBackupDue returns true if the schedule should start a new chain at the
+// given run time: either it has no chain yet, or it has no FULL BACKUP
+// recurrence, or the recurrence is due since its current chain started.
+func fullBackupDue(args *ScheduledBackupExecutionArgs, runTime time.Time) (bool, error) {
+	if args.CurrentBackupChain == "" || args.FullBackupRecurrence == "" {
+		return true, nil
+	}
+	start, err := time.Parse(backupChainNameFormat, args.CurrentBackupChain)
+	if err != nil {
+		return false, err
+	}
+	expr, err := cronexpr.Parse(args.FullBackupRecurrence)
+	if err != nil {
+		return false, errors.Wrapf(err, "parsing FULL BACKUP recurrence %q", args.FullBackupRecurrence)
+	}
+	return !expr.Next(start).After(runTime), nil
+}
+
+// deleteExpiredBackups deletes the chains of backups that are no longer
+// retained by the schedule that created the given backup job, if any. It is
+// called once the backup has succeeded, since a chain is only ever deleted
+// once a newer full backup exists. It runs outside of any transaction, as
+// deleting the chains can take a while.
+func deleteExpiredBackups(
+	ctx context.Context, execCfg *sql.ExecutorConfig, user string, jobID int64, details jobspb.BackupDetails,
+) error {
+	row, err := execCfg.InternalExecutor.QueryRow(
+		ctx, "backup-lookup-schedule", nil, /* txn */
+		`SELECT s.schedule_id, s.execution_args FROM system.jobs AS j
+JOIN system.scheduled_jobs AS s ON s.schedule_id = j.created_by_id
+WHERE j.id = $1 AND j.created_by_type = $2`,
+		jobID, jobs.CreatedByScheduledJobs,
+	)
+	if err != nil {
+		return errors.Wrap(err, "looking up the schedule of the backup")
+	}
+	if row == nil {
+		// The backup was not created by a schedule, or its schedule was dropped.
+		return nil
+	}
+	scheduleID := int64(tree.MustBeDInt(row[0]))
+	var execArgs jobspb.ExecutionArguments
+	if err := protoutil.Unmarshal([]byte(tree.MustBeDBytes(row[1])), &execArgs); err != nil {
+		return err
+	}
+	args := &ScheduledBackupExecutionArgs{}
+	if err := pbtypes.UnmarshalAny(execArgs.Args, args); err != nil {
+		return errors.Wrap(err, "un-marshaling args")
+	}
+	if !hasRetentionPolicy(args) {
+		return nil
+	}
+
+	// The backup was written to a chain of each of the destinations of the
+	// schedule. The default location, which holds the manifests, is cleaned up
+	// last so that the chain is still found if its deletion has to be retried.
+	var stores []string
+	for _, uri := range details.URIsByLocalityKV {
+		stores = append(stores, uri)
+	}
+	stores = append(stores, details.URI)
+	for i := range stores {
+		if stores[i], err = backupChainParentURI(stores[i]); err != nil {
+			return err
+		}
+	}
+
+	makeCloudStorage := execCfg.DistSQLSrv.ExternalStorageFromURI
+	store, err := makeCloudStorage(ctx, stores[len(stores)-1], user)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open backup storage location")
+	}
+	chains, err := listBackupChains(ctx, store)
+	store.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, chain := range expiredBackupChains(chains, args, timeutil.Now()) {
+		if err := deleteBackupChain(ctx, makeCloudStorage, user, stores, chain); err != nil {
+			return errors.Wrapf(err, "deleting expired backups %s of schedule %d", chain, scheduleID)
+		}
+		log.Infof(ctx, "deleted expired backups %s of schedule %d", chain, scheduleID)
+	}
+	return nil
+}
+
+// backupChainParentURI returns the URI of the destination of a schedule, given
+// the URI of one of its chains.
+func backupChainParentURI(uri string) (string, error) {
+	parsedURI, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	chain := path.Base(parsedURI.Path)
+	if _, err := time.Parse(backupChainNameFormat, chain); err != nil {
+		return "", errors.Newf("backup location %s is not a chain of backups", uri)
+	}
+	parsedURI.Path = path.Dir(parsedURI.Path)
+	return parsedURI.String(), nil
+}
+
+// listBackupChains returns the names of the chains of backups whose full
+// backup has completed, oldest first.
+func listBackupChains(ctx context.Context, store cloud.ExternalStorage) ([]string, error) {
+	manifests, err := store.ListFiles(ctx, backupChainPattern)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing backups of schedule")
+	}
+	chains := make([]string, 0, len(manifests))
+	for _, m := range manifests {
+		chain := path.Dir(m)
+		if _, err := time.Parse(backupChainNameFormat, chain); err != nil {
+			continue
+		}
+		chains = append(chains, chain)
+	}
+	sort.Strings(chains)
+	return chains, nil
+}
+
+// expiredBackupChains returns the chains, which must be sorted oldest first,
+// that are no longer retained by the retention policy of the schedule. A chain
+// is retained if it is one of the KeepFullBackups latest chains, or if it is
+// needed to restore to some time in the last KeepFor; that is, if the next
+// chain starts after now - KeepFor. The latest chain is always retained, so a
+// chain is only ever deleted once a newer full backup exists.
+func expiredBackupChains(
+	chains []string, args *ScheduledBackupExecutionArgs, now time.Time,
+) []string {
+	var expired []string
+	for i := 0; i < len(chains)-1; i++ {
+		if args.KeepFullBackups > 0 && int64(len(chains)-i) <= args.KeepFullBackups {
+			break
+		}
+		if args.KeepFor > 0 {
+			next, err := time.Parse(backupChainNameFormat, chains[i+1])
+			if err != nil || next.After(now.Add(-args.KeepFor)) {
+				break
+			}
+		}
+		expired = append(expired, chains[i])
+	}
+	return expired
+}
+
+// deleteBackupChain deletes all the files of the chain in each of the stores.
+func deleteBackupChain(
+	ctx context.Context,
+	makeCloudStorage cloud.ExternalStorageFromURIFactory,
+	user string,
+	stores []string,
+	chain string,
+) error {
+	for _, uri := range stores {
+		store, err := makeCloudStorage(ctx, uri, user)
+		if err != nil {
+			return err
+		}
+		err = deleteBackupChainFiles(ctx, store, chain)
+		store.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteBackupChainFiles deletes all the files of the chain in the store. The
+// full backup is at the root of the chain and the incremental backups are in
+// its subdirectories, whatever their depth.
+func deleteBackupChainFiles(ctx context.Context, store cloud.ExternalStorage, chain string) error {
+	// Listing the chain name as a prefix also lists the chain directory itself
+	// on storage that has directories, so that it is deleted last. Only the
+	// chain and its contents are deleted, and not other files that happen to
+	// share its prefix.
+	names, err := store.ListFilesWithPrefix(ctx, chain)
+	if err != nil {
+		return errors.Wrapf(err, "listing backup files in %s", chain)
+	}
+	for _, name := range names {
+		if name != chain && !strings.HasPrefix(name, chain+"/") {
+			continue
+		}
+		if err := store.Delete(ctx, name); err != nil {
+			return errors.Wrapf(err, "deleting backup file %s", name)
+		}
+	}
+	return nil
+}
+
+// appendPathToURI appends the specified path to the path of the URI.
+func appendPathToURI(uri string, suffix string) (string, error) {
+	parsedURI, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	parsedURI.Path = path.Join(parsedURI.Path, suffix)
+	return parsedURI.String(), nil
+}
+
+// extractBackupStatement returns tree.Backup node encoded inside scheduled job,
+// along with the arguments of the scheduled backup.
+func extractBackupStatement(
+	sj *jobs.ScheduledJob,
+) (*annotatedBackupStatement, *ScheduledBackupExecutionArgs, error) {
 	args := &ScheduledBackupExecutionArgs{}
 	if err := pbtypes.UnmarshalAny(sj.ExecutionArgs().Args, args); err != nil {
-		return nil, errors.Wrap(err, "un-marshaling args")
+		return nil, nil, errors.Wrap(err, "un-marshaling args")
 	}
 
 	node, err := parser.ParseOne(args.BackupStatement)
 	if err != nil {
-		return nil, errors.Wrap(err, "parsing backup statement")
+		return nil, nil, errors.Wrap(err, "parsing backup statement")
 	}
 
 	if backupStmt, ok := node.AST.(*tree.Backup); ok {
@@ -145,10 +429,10 @@ func extractBackupStatement(sj *jobs.ScheduledJob) (*annotatedBackupStatement, e
 				Name: jobs.CreatedByScheduledJobs,
 				ID:   sj.ScheduleID(),
 			},
-		}, nil
+		}, args, nil
 	}
 
-	return nil, errors.Newf("unexpect node type %T", node)
+	return nil, nil, errors.Newf("unexpect node type %T", node)
 }
 
 func init() {
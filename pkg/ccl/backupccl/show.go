@@ -10,6 +10,8 @@ package backupccl
 
 import (
 	"context"
+	"net/url"
+	"path"
 	"strings"
 	"time"
 
@@ -56,6 +58,7 @@ func showBackupPlanHook(
 	expected := map[string]sql.KVStringOptValidate{
 		backupOptEncPassphrase:  sql.KVStringOptRequireValue,
 		backupOptWithPrivileges: sql.KVStringOptRequireNoValue,
+		backupOptCheckFiles:     sql.KVStringOptRequireNoValue,
 	}
 	optsFn, err := p.TypeAsStringOpts(ctx, backup.Options, expected)
 	if err != nil {
@@ -140,6 +143,12 @@ func showBackupPlanHook(
 			return err
 		}
 
+		if _, ok := opts[backupOptCheckFiles]; ok {
+			if err := checkBackupFiles(ctx, p, str, incPaths, manifests, encryption); err != nil {
+				return err
+			}
+		}
+
 		datums, err := shower.fn(manifests)
 		if err != nil {
 			return err
@@ -157,6 +166,59 @@ func showBackupPlanHook(
 	return fn, shower.header, nil, false, nil
 }
 
+// checkBackupFiles reads every file of the given manifests, which are the base
+// backup at the given URI followed by the incremental backups appended to it
+// at incPaths, and checks them against their checksums and spans in the
+// manifests. An error listing the files that failed verification is returned,
+// if any.
+func checkBackupFiles(
+	ctx context.Context,
+	p sql.PlanHookState,
+	uri string,
+	incPaths []string,
+	manifests []BackupManifest,
+	encryption *roachpb.FileEncryptionOptions,
+) error {
+	if len(manifests[0].PartitionDescriptorFilenames) > 0 {
+		return errors.Errorf(
+			"%s is not supported for locality-aware backups, use RESTORE with the %s option instead",
+			backupOptCheckFiles, restoreOptVerifyOnly)
+	}
+	baseURI, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+
+	var entries []importEntry
+	for i, m := range manifests {
+		// The files of the appended incremental backups are in the directory of
+		// their manifest.
+		dir := m.Dir
+		if i > 0 {
+			u := *baseURI // NB: makes a copy to avoid mutating the baseURI.
+			u.Path = path.Join(u.Path, path.Dir(incPaths[i-1]))
+			if dir, err = cloudimpl.ExternalStorageConfFromURI(u.String(), p.User()); err != nil {
+				return err
+			}
+		}
+		for _, f := range m.Files {
+			if len(f.Path) == 0 {
+				continue
+			}
+			entries = append(entries, importEntry{
+				Span:      f.Span,
+				entryType: request,
+				files:     []roachpb.ImportRequest_File{{Dir: dir, Path: f.Path, Sha512: f.Sha512}},
+			})
+		}
+	}
+
+	_, err = verifyBackupData(
+		ctx, p, entries, hlc.Timestamp{}, encryption, nil /* rekeys */, nil /* pkIDs */, true, /* checkSpans */
+	)
+	return err
+}
+
 type backupShower struct {
 	header sqlbase.ResultColumns
 	fn     func([]BackupManifest) ([]tree.Datums, error)
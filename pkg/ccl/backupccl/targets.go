@@ -337,6 +337,9 @@ func descriptorsMatchingTargets(
 	for dbID := range alreadyExpandedDBs {
 		for _, id := range resolver.objsByName[dbID] {
 			desc := resolver.descByID[id]
+			if err := checkNotInUserDefinedSchema(resolver, desc); err != nil {
+				return ret, err
+			}
 			if table := desc.Table(hlc.Timestamp{}); table != nil {
 				// TODO (lucy): The immutable wrapper here can be dispensed with once we
 				// start using catalog.Descriptor everywhere.
@@ -366,6 +369,24 @@ func descriptorsMatchingTargets(
 	return ret, nil
 }
 
+// checkNotInUserDefinedSchema returns an error if the given table or type
+// belongs to a user-defined schema. Schema descriptors are not backed up, so
+// such an object would be restored into a schema that does not exist. Objects
+// in temporary schemas, which have no descriptor, are not affected.
+func checkNotInUserDefinedSchema(r *descriptorResolver, desc sqlbase.Descriptor) error {
+	var schemaID sqlbase.ID
+	if table := desc.Table(hlc.Timestamp{}); table != nil {
+		schemaID = table.GetParentSchemaID()
+	} else if typ := desc.GetType(); typ != nil {
+		schemaID = typ.ParentSchemaID
+	}
+	if schemaDesc, ok := r.descByID[schemaID]; ok && schemaDesc.GetSchema() != nil {
+		return errors.Errorf("cannot back up %q: objects in user-defined schema %q are not supported",
+			desc.GetName(), schemaDesc.GetName())
+	}
+	return nil
+}
+
 // getRelevantDescChanges finds the changes between start and end time to the
 // SQL descriptors matching `descs` or `expandedDBs`, ordered by time. A
 // descriptor revision matches if it is an earlier revision of a descriptor in
@@ -0,0 +1,291 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/storage/cloud"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+	gogotypes "github.com/gogo/protobuf/types"
+)
+
+// The processor outputs a row with the path of each file that failed
+// verification and the error. The counts of the verified data are streamed to
+// the coordinator through metadata.
+var verifyBackupDataOutputTypes = []*types.T{types.String, types.String}
+
+type verifyBackupDataProcessor struct {
+	flowCtx *execinfra.FlowCtx
+	spec    execinfrapb.VerifyBackupDataSpec
+	output  execinfra.RowReceiver
+}
+
+var _ execinfra.Processor = &verifyBackupDataProcessor{}
+
+// OutputTypes implements the execinfra.Processor interface.
+func (vp *verifyBackupDataProcessor) OutputTypes() []*types.T {
+	return verifyBackupDataOutputTypes
+}
+
+func newVerifyBackupDataProcessor(
+	flowCtx *execinfra.FlowCtx,
+	_ int32,
+	spec execinfrapb.VerifyBackupDataSpec,
+	output execinfra.RowReceiver,
+) (execinfra.Processor, error) {
+	vp := &verifyBackupDataProcessor{
+		flowCtx: flowCtx,
+		spec:    spec,
+		output:  output,
+	}
+	return vp, nil
+}
+
+// Run implements the execinfra.Processor interface.
+func (vp *verifyBackupDataProcessor) Run(ctx context.Context) {
+	ctx, span := tracing.ChildSpan(ctx, "verifyBackupDataProcessor")
+	defer tracing.FinishSpan(span)
+	defer vp.output.ProducerDone()
+
+	if err := runVerifyBackupData(ctx, vp.flowCtx, &vp.spec, vp.output); err != nil {
+		vp.output.Push(nil, &execinfrapb.ProducerMetadata{Err: err})
+	}
+}
+
+func runVerifyBackupData(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	spec *execinfrapb.VerifyBackupDataSpec,
+	output execinfra.RowReceiver,
+) error {
+	var kr *storageccl.KeyRewriter
+	if len(spec.Rekeys) > 0 {
+		var err error
+		if kr, err = storageccl.MakeKeyRewriterFromRekeys(spec.Rekeys); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range spec.Entries {
+		log.VEventf(ctx, 1 /* level */, "verifying span %v", entry.Span)
+		summary, failures, err := verifyRestoreSpanEntry(ctx, flowCtx, spec, kr, entry)
+		if err != nil {
+			return errors.Wrapf(err, "verifying span %v", entry.Span)
+		}
+		for _, f := range failures {
+			row := sqlbase.EncDatumRow{
+				sqlbase.DatumToEncDatum(types.String, tree.NewDString(f.path)),
+				sqlbase.DatumToEncDatum(types.String, tree.NewDString(f.err.Error())),
+			}
+			if output.Push(row, nil) != execinfra.NeedMoreRows {
+				return nil
+			}
+		}
+
+		progDetails := RestoreProgress{}
+		progDetails.Summary = countRows(summary, spec.PKIDs)
+		progDetails.ProgressIdx = entry.ProgressIdx
+		details, err := gogotypes.MarshalAny(&progDetails)
+		if err != nil {
+			return err
+		}
+		var prog execinfrapb.RemoteProducerMetadata_BulkProcessorProgress
+		prog.ProgressDetails = *details
+		if output.Push(nil, &execinfrapb.ProducerMetadata{BulkProcessorProgress: &prog}) != execinfra.NeedMoreRows {
+			return nil
+		}
+	}
+	return nil
+}
+
+// backupFileFailure is a file of a backup that failed verification.
+type backupFileFailure struct {
+	path string
+	err  error
+}
+
+// verifyRestoreSpanEntry reads the files of the given entry and checks them
+// against their checksums. If the spec asks to check spans, every key of the
+// files must also lie within the span of the entry. If the spec has rekeys and
+// all the files were read, the data of the entry is then iterated over and
+// rewritten the way an Import request of a RESTORE would, without ingesting
+// it, and its counts are returned.
+func verifyRestoreSpanEntry(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	spec *execinfrapb.VerifyBackupDataSpec,
+	kr *storageccl.KeyRewriter,
+	entry execinfrapb.RestoreSpanEntry,
+) (roachpb.BulkOpSummary, []backupFileFailure, error) {
+	var summary roachpb.BulkOpSummary
+	var failures []backupFileFailure
+
+	var iters []storage.SimpleIterator
+	defer func() {
+		for _, iter := range iters {
+			iter.Close()
+		}
+	}()
+	for _, file := range entry.Files {
+		fileContents, err := readBackupDataFile(ctx, flowCtx.Cfg.ExternalStorage, file, spec.Encryption)
+		if err == nil && spec.CheckSpans {
+			err = checkBackupDataFileSpan(fileContents, entry.Span)
+		}
+		if err != nil {
+			failures = append(failures, backupFileFailure{path: file.Path, err: err})
+			continue
+		}
+		iter, err := storage.NewMemSSTIterator(fileContents, false /* verify */)
+		if err != nil {
+			failures = append(failures, backupFileFailure{path: file.Path, err: err})
+			continue
+		}
+		iters = append(iters, iter)
+	}
+	if kr == nil || len(failures) > 0 {
+		return summary, failures, nil
+	}
+
+	var rowCounter storage.RowCounter
+	startKeyMVCC, endKeyMVCC := storage.MVCCKey{Key: entry.Span.Key}, storage.MVCCKey{Key: entry.Span.EndKey}
+	iter := storage.MakeMultiIterator(iters)
+	defer iter.Close()
+	for iter.SeekGE(startKeyMVCC); ; {
+		ok, err := iter.Valid()
+		if err != nil {
+			return summary, nil, err
+		}
+		if !ok {
+			break
+		}
+		if spec.EndTime != (hlc.Timestamp{}) && spec.EndTime.Less(iter.UnsafeKey().Timestamp) {
+			iter.Next()
+			continue
+		}
+		if !iter.UnsafeKey().Less(endKeyMVCC) {
+			break
+		}
+		if len(iter.UnsafeValue()) == 0 {
+			// Value is deleted.
+			iter.NextKey()
+			continue
+		}
+
+		key, ok, err := kr.RewriteKey(append([]byte(nil), iter.UnsafeKey().Key...), false /* isFromSpan */)
+		if err != nil {
+			return summary, nil, err
+		}
+		valueSize := len(iter.UnsafeValue())
+		iter.NextKey()
+		if !ok {
+			// If the key rewriter didn't match this key, it's not data for the
+			// table(s) being restored.
+			continue
+		}
+		if err := rowCounter.Count(key); err != nil {
+			return summary, nil, err
+		}
+		summary.DataSize += int64(len(key) + valueSize)
+	}
+	summary.Add(rowCounter.BulkOpSummary)
+	return summary, nil, nil
+}
+
+// readBackupDataFile reads, decrypts and checksums the given file of a backup.
+func readBackupDataFile(
+	ctx context.Context,
+	makeExternalStorage cloud.ExternalStorageFactory,
+	file roachpb.ImportRequest_File,
+	encryption *roachpb.FileEncryptionOptions,
+) ([]byte, error) {
+	dir, err := makeExternalStorage(ctx, file.Dir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := dir.Close(); err != nil {
+			log.Warningf(ctx, "close export storage failed %v", err)
+		}
+	}()
+
+	const maxAttempts = 3
+	var fileContents []byte
+	if err := retry.WithMaxAttempts(ctx, base.DefaultRetryOptions(), maxAttempts, func() error {
+		f, err := dir.ReadFile(ctx, file.Path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fileContents, err = ioutil.ReadAll(f)
+		return err
+	}); err != nil {
+		return nil, errors.Wrap(err, "fetching file")
+	}
+
+	if encryption != nil {
+		if fileContents, err = storageccl.DecryptFile(fileContents, encryption.Key); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(file.Sha512) > 0 {
+		checksum, err := storageccl.SHA512ChecksumData(fileContents)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(checksum, file.Sha512) {
+			return nil, errors.New("checksum mismatch")
+		}
+	}
+	return fileContents, nil
+}
+
+// checkBackupDataFileSpan iterates over every version of every key of the
+// given file, verifying the checksums of the values, and checks that all the
+// keys lie within the given span.
+func checkBackupDataFileSpan(fileContents []byte, span roachpb.Span) error {
+	iter, err := storage.NewMemSSTIterator(fileContents, true /* verify */)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	for iter.SeekGE(storage.MVCCKey{}); ; iter.Next() {
+		ok, err := iter.Valid()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if key := iter.UnsafeKey().Key; !span.ContainsKey(key) {
+			return errors.Errorf("key %s is outside of the span %s of the file", key, span)
+		}
+	}
+}
+
+func init() {
+	rowexec.NewVerifyBackupDataProcessor = newVerifyBackupDataProcessor
+}
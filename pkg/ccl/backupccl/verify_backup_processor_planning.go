@@ -0,0 +1,162 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package backupccl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/physicalplan"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/logtags"
+	gogotypes "github.com/gogo/protobuf/types"
+)
+
+// maxListedBackupFileFailures is the maximum number of files that failed
+// verification listed in the details of the error returned by
+// verifyBackupData.
+const maxListedBackupFileFailures = 100
+
+// verifyBackupData is used to plan the processors that verify the files of
+// the given entries, which are distributed across all the nodes of the
+// cluster. It returns the counts of the verified data, or an error listing the
+// files that failed verification, if any.
+func verifyBackupData(
+	ctx context.Context,
+	phs sql.PlanHookState,
+	entries []importEntry,
+	endTime hlc.Timestamp,
+	encryption *roachpb.FileEncryptionOptions,
+	rekeys []roachpb.ImportRequest_TableRekey,
+	pkIDs map[uint64]bool,
+	checkSpans bool,
+) (RowCount, error) {
+	ctx = logtags.AddTag(ctx, "verify-backup-distsql", nil)
+	var noTxn *kv.Txn
+
+	dsp := phs.DistSQLPlanner()
+	evalCtx := phs.ExtendedEvalContext()
+
+	planCtx, nodes, err := dsp.SetupAllNodesPlanning(ctx, evalCtx, phs.ExecCfg())
+	if err != nil {
+		return RowCount{}, err
+	}
+
+	// The entries are assigned round-robin to the nodes, since the files they
+	// read are not local to any of them.
+	specs := make([]*execinfrapb.VerifyBackupDataSpec, len(nodes))
+	for i, entry := range entries {
+		spec := specs[i%len(nodes)]
+		if spec == nil {
+			spec = &execinfrapb.VerifyBackupDataSpec{
+				EndTime:    endTime,
+				Encryption: encryption,
+				Rekeys:     rekeys,
+				PKIDs:      pkIDs,
+				CheckSpans: checkSpans,
+			}
+			specs[i%len(nodes)] = spec
+		}
+		spec.Entries = append(spec.Entries, execinfrapb.RestoreSpanEntry{
+			Span:        entry.Span,
+			Files:       entry.files,
+			ProgressIdx: int64(i),
+		})
+	}
+
+	// Setup a one-stage plan with one proc per node with entries to verify.
+	var corePlacement []physicalplan.ProcessorCorePlacement
+	for i, spec := range specs {
+		if spec == nil {
+			continue
+		}
+		corePlacement = append(corePlacement, physicalplan.ProcessorCorePlacement{
+			NodeID: nodes[i],
+			Core:   execinfrapb.ProcessorCoreUnion{VerifyBackupData: spec},
+		})
+	}
+	if len(corePlacement) == 0 {
+		return RowCount{}, nil
+	}
+
+	gatewayNodeID, err := evalCtx.ExecCfg.NodeID.OptionalNodeIDErr(47970)
+	if err != nil {
+		return RowCount{}, err
+	}
+	p := sql.MakePhysicalPlan(gatewayNodeID)
+	p.AddNoInputStage(corePlacement, execinfrapb.PostProcessSpec{}, verifyBackupDataOutputTypes, execinfrapb.Ordering{})
+	p.PlanToStreamColMap = []int{0, 1}
+
+	dsp.FinalizePlan(planCtx, &p)
+
+	var res RowCount
+	metaFn := func(_ context.Context, meta *execinfrapb.ProducerMetadata) error {
+		if meta.BulkProcessorProgress != nil {
+			var progDetails RestoreProgress
+			if err := gogotypes.UnmarshalAny(&meta.BulkProcessorProgress.ProgressDetails, &progDetails); err != nil {
+				return err
+			}
+			res.add(progDetails.Summary)
+		}
+		return nil
+	}
+
+	// The rows are the files that failed verification.
+	failures := rowcontainer.NewRowContainer(
+		evalCtx.Mon.MakeBoundAccount(),
+		sqlbase.ColTypeInfoFromColTypes(verifyBackupDataOutputTypes),
+		0, /* rowCapacity */
+	)
+	defer failures.Close(ctx)
+	rowResultWriter := sql.NewRowResultWriter(failures)
+
+	recv := sql.MakeDistSQLReceiver(
+		ctx,
+		sql.NewMetadataCallbackWriter(rowResultWriter, metaFn),
+		tree.Rows,
+		nil,   /* rangeCache */
+		noTxn, /* txn - the flow does not read or write the database */
+		func(ts hlc.Timestamp) {},
+		evalCtx.Tracing,
+	)
+	defer recv.Release()
+
+	// Copy the evalCtx, as dsp.Run() might change it.
+	evalCtxCopy := *evalCtx
+	dsp.Run(planCtx, noTxn, &p, recv, &evalCtxCopy, nil /* finishedSetupFn */)()
+	if err := rowResultWriter.Err(); err != nil {
+		return RowCount{}, err
+	}
+
+	if failures.Len() > 0 {
+		var buf strings.Builder
+		for i := 0; i < failures.Len() && i < maxListedBackupFileFailures; i++ {
+			row := failures.At(i)
+			fmt.Fprintf(&buf, "%s: %s\n", string(tree.MustBeDString(row[0])), string(tree.MustBeDString(row[1])))
+		}
+		if failures.Len() > maxListedBackupFileFailures {
+			fmt.Fprintf(&buf, "and %d more\n", failures.Len()-maxListedBackupFileFailures)
+		}
+		first := failures.At(0)
+		return RowCount{}, errors.WithDetail(
+			errors.Newf("%d backup file(s) failed verification, including %s: %s",
+				failures.Len(), string(tree.MustBeDString(first[0])), string(tree.MustBeDString(first[1]))),
+			buf.String())
+	}
+	return res, nil
+}
@@ -218,6 +218,7 @@ func createBenchmarkChangefeed(
 	kvfeedCfg := kvfeed.Config{
 		Settings:         settings,
 		DB:               s.DB(),
+		Codec:            keys.SystemSQLCodec,
 		Clock:            feedClock,
 		Gossip:           gossip.MakeExposedGossip(s.GossipI().(*gossip.Gossip)),
 		Spans:            spans,
@@ -232,10 +233,10 @@ func createBenchmarkChangefeed(
 	}
 
 	rowsFn := kvsToRows(s.ExecutorConfig().(sql.ExecutorConfig).Codec,
-		s.LeaseManager().(*lease.Manager), details, buf.Get)
+		s.LeaseManager().(*lease.Manager), s.DB(), details, buf.Get)
 	sf := span.MakeFrontier(spans...)
 	tickFn := emitEntries(s.ClusterSettings(), details, hlc.Timestamp{}, sf,
-		encoder, sink, rowsFn, TestingKnobs{}, metrics)
+		encoder, nil /* sel */, sink, rowsFn, TestingKnobs{}, metrics)
 
 	ctx, cancel := context.WithCancel(ctx)
 	go func() { _ = kvfeed.Run(ctx, kvfeedCfg) }()
@@ -197,11 +197,18 @@ func (c *sinklessFeed) Resume() error {
 	if !c.latestResolved.IsEmpty() {
 		// NB: The TODO in Next means c.latestResolved is currently never set for
 		// non-json feeds.
+		//
+		// The options of a changefeed with a query precede its AS SELECT clause.
+		var query string
+		if i := strings.Index(create, ` AS SELECT `); i >= 0 {
+			create, query = create[:i], create[i:]
+		}
 		if strings.Contains(create, `WITH`) {
 			create += fmt.Sprintf(`, cursor='%s'`, c.latestResolved.AsOfSystemTime())
 		} else {
 			create += fmt.Sprintf(` WITH cursor='%s'`, c.latestResolved.AsOfSystemTime())
 		}
+		create += query
 	}
 	c.rows, err = c.conn.Query(create, c.args...)
 	return err
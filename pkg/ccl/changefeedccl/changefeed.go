@@ -10,6 +10,7 @@ package changefeedccl
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
@@ -53,16 +54,99 @@ type emitEntry struct {
 // kvsToRows gets changed kvs from a closure and converts them into sql rows. It
 // returns a closure that may be repeatedly called to advance the changefeed.
 // The returned closure is not threadsafe.
+//
+// A kv of a table with multiple column families only contains the columns of
+// one family. With split_column_families, it is emitted on its own, as a row
+// of the table restricted to the family (see familyTableDesc). Otherwise, the
+// kvs of a row are collected until the span containing the row is resolved at
+// their timestamp (see pendingRow), at which point all of the families written
+// at that timestamp have been received.
 func kvsToRows(
 	codec keys.SQLCodec,
 	leaseMgr *lease.Manager,
+	db *kv.DB,
 	details jobspb.ChangefeedDetails,
 	inputFn func(context.Context) (kvfeed.Event, error),
 ) func(context.Context) ([]emitEntry, error) {
 	_, withDiff := details.Opts[changefeedbase.OptDiff]
+	_, splitFamilies := details.Opts[changefeedbase.OptSplitColumnFamilies]
 	rfCache := newRowFetcherCache(codec, leaseMgr)
 
+	// pendingRows are the rows of tables with multiple column families that
+	// have not been emitted yet.
+	pendingRows := make(map[pendingRowID]*pendingRow)
+
 	var kvs row.SpanKVFetcher
+	// fetchRow decodes the row made of the given kvs with the given Fetcher.
+	fetchRow := func(
+		ctx context.Context, rf *row.Fetcher, rowKVs []roachpb.KeyValue,
+	) (datums sqlbase.EncDatumRow, desc *sqlbase.TableDescriptor, deleted bool, _ error) {
+		kvs.KVs = rowKVs
+		if err := rf.StartScanFrom(ctx, &kvs); err != nil {
+			return nil, nil, false, err
+		}
+
+		datums, desc, _, err := rf.NextRow(ctx)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if datums == nil {
+			return nil, nil, false, errors.AssertionFailedf("unexpected empty datums")
+		}
+		datums = append(sqlbase.EncDatumRow(nil), datums...)
+		deleted = rf.RowIsDeleted()
+
+		// Assert that we don't get a second row from the row.Fetcher. We
+		// fed it the kvs of a single row, so that would be surprising.
+		nextDatums, _, _, err := rf.NextRow(ctx)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if nextDatums != nil {
+			return nil, nil, false, errors.AssertionFailedf("unexpected non-empty datums")
+		}
+		return datums, desc, deleted, nil
+	}
+
+	// prevFetcher returns the table descriptor and the row.Fetcher with which
+	// the previous value of a row of the given table is decoded. The row.Fetcher
+	// is nil if the given family did not exist under the previous version of
+	// the schema.
+	prevFetcher := func(
+		ctx context.Context,
+		key roachpb.Key,
+		desc *sqlbase.TableDescriptor,
+		family *sqlbase.ColumnFamilyDescriptor,
+		rf *row.Fetcher,
+		schemaTimestamp hlc.Timestamp,
+		prevSchemaTimestamp hlc.Timestamp,
+	) (*sqlbase.TableDescriptor, *sqlbase.ColumnFamilyDescriptor, *row.Fetcher, error) {
+		if prevSchemaTimestamp == schemaTimestamp {
+			return desc, family, rf, nil
+		}
+		// If the previous value is being interpreted under a different version
+		// of the schema, fetch the correct table descriptor and create a new
+		// row.Fetcher with it.
+		prevDesc, err := rfCache.TableDescForKey(ctx, key, prevSchemaTimestamp)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		var prevFamily *sqlbase.ColumnFamilyDescriptor
+		if family != nil {
+			// The family did not exist under the previous version of the
+			// schema if it was just created.
+			if prevFamily, _ = prevDesc.FindFamilyByID(family.ID); prevFamily == nil {
+				return prevDesc, nil, nil, nil
+			}
+		}
+		prevRF, err := rfCache.RowFetcherForTableDesc(prevDesc, prevFamily)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return prevDesc, prevFamily, prevRF, nil
+	}
+
+	var singleKV [1]roachpb.KeyValue
 	appendEmitEntryForKV := func(
 		ctx context.Context,
 		output []emitEntry,
@@ -70,6 +154,7 @@ func kvsToRows(
 		prevVal roachpb.Value,
 		schemaTimestamp hlc.Timestamp,
 		prevSchemaTimestamp hlc.Timestamp,
+		backfill bool,
 		bufferGetTimestamp time.Time,
 	) ([]emitEntry, error) {
 
@@ -85,7 +170,34 @@ func kvsToRows(
 			return nil, nil
 		}
 
-		rf, err := rfCache.RowFetcherForTableDesc(desc)
+		var family *sqlbase.ColumnFamilyDescriptor
+		if splitFamilies {
+			if family, err = familyForKey(desc, kv.Key); err != nil {
+				return nil, err
+			}
+		} else if len(desc.Families) > 1 {
+			rowKey, err := keys.EnsureSafeSplitKey(kv.Key)
+			if err != nil {
+				return nil, err
+			}
+			id := pendingRowID{key: string(rowKey), timestamp: schemaTimestamp, backfill: backfill}
+			r, ok := pendingRows[id]
+			if !ok {
+				r = &pendingRow{
+					key:                 rowKey,
+					desc:                desc,
+					schemaTimestamp:     schemaTimestamp,
+					prevSchemaTimestamp: prevSchemaTimestamp,
+					backfill:            backfill,
+					bufferGetTimestamp:  bufferGetTimestamp,
+				}
+				pendingRows[id] = r
+			}
+			r.add(kv, prevVal)
+			return output, nil
+		}
+
+		rf, err := rfCache.RowFetcherForTableDesc(desc, family)
 		if err != nil {
 			return nil, err
 		}
@@ -93,90 +205,123 @@ func kvsToRows(
 		// Get new value.
 		var r emitEntry
 		r.bufferGetTimestamp = bufferGetTimestamp
-		{
-			// TODO(dan): Handle tables with multiple column families.
-			// Reuse kvs to save allocations.
-			kvs.KVs = kvs.KVs[:0]
-			kvs.KVs = append(kvs.KVs, kv)
-			if err := rf.StartScanFrom(ctx, &kvs); err != nil {
-				return nil, err
-			}
-
-			r.row.datums, r.row.tableDesc, _, err = rf.NextRow(ctx)
-			if err != nil {
-				return nil, err
-			}
-			if r.row.datums == nil {
-				return nil, errors.AssertionFailedf("unexpected empty datums")
-			}
-			r.row.datums = append(sqlbase.EncDatumRow(nil), r.row.datums...)
-			r.row.deleted = rf.RowIsDeleted()
-			r.row.updated = schemaTimestamp
-
-			// Assert that we don't get a second row from the row.Fetcher. We
-			// fed it a single KV, so that would be surprising.
-			var nextRow emitEntry
-			nextRow.row.datums, nextRow.row.tableDesc, _, err = rf.NextRow(ctx)
-			if err != nil {
-				return nil, err
-			}
-			if nextRow.row.datums != nil {
-				return nil, errors.AssertionFailedf("unexpected non-empty datums")
-			}
+		singleKV[0] = kv
+		r.row.datums, r.row.tableDesc, r.row.deleted, err = fetchRow(ctx, rf, singleKV[:])
+		if err != nil {
+			return nil, err
+		}
+		r.row.updated = schemaTimestamp
+		if family != nil {
+			familyDesc := rfCache.FamilyTableDesc(desc, family)
+			r.row.datums = familyDesc.project(r.row.datums)
+			r.row.tableDesc = familyDesc.desc
+			r.row.familyID = family.ID
 		}
 
 		// Get prev value, if necessary.
 		if withDiff {
-			prevRF := rf
-			if prevSchemaTimestamp != schemaTimestamp {
-				// If the previous value is being interpreted under a different
-				// version of the schema, fetch the correct table descriptor and
-				// create a new row.Fetcher with it.
-				prevDesc, err := rfCache.TableDescForKey(ctx, kv.Key, prevSchemaTimestamp)
+			prevDesc, prevFamily, prevRF, err := prevFetcher(
+				ctx, kv.Key, desc, family, rf, schemaTimestamp, prevSchemaTimestamp)
+			if err != nil {
+				return nil, err
+			}
+			if prevRF == nil {
+				r.row.prevDeleted = true
+			} else {
+				singleKV[0] = roachpb.KeyValue{Key: kv.Key, Value: prevVal}
+				r.row.prevDatums, r.row.prevTableDesc, r.row.prevDeleted, err = fetchRow(
+					ctx, prevRF, singleKV[:])
 				if err != nil {
 					return nil, err
 				}
-
-				prevRF, err = rfCache.RowFetcherForTableDesc(prevDesc)
-				if err != nil {
-					return nil, err
+				if prevFamily != nil {
+					familyDesc := rfCache.FamilyTableDesc(prevDesc, prevFamily)
+					r.row.prevDatums = familyDesc.project(r.row.prevDatums)
+					r.row.prevTableDesc = familyDesc.desc
 				}
 			}
+		}
 
-			prevKV := roachpb.KeyValue{Key: kv.Key, Value: prevVal}
-			// TODO(dan): Handle tables with multiple column families.
-			// Reuse kvs to save allocations.
-			kvs.KVs = kvs.KVs[:0]
-			kvs.KVs = append(kvs.KVs, prevKV)
-			if err := prevRF.StartScanFrom(ctx, &kvs); err != nil {
-				return nil, err
-			}
-			r.row.prevDatums, r.row.prevTableDesc, _, err = prevRF.NextRow(ctx)
+		output = append(output, r)
+		return output, nil
+	}
+
+	// appendEmitEntryForPendingRow emits a row of a table with multiple column
+	// families, whose kvs have been set by readPendingRows.
+	appendEmitEntryForPendingRow := func(
+		ctx context.Context, output []emitEntry, pr *pendingRow,
+	) ([]emitEntry, error) {
+		rf, err := rfCache.RowFetcherForTableDesc(pr.desc, nil /* family */)
+		if err != nil {
+			return nil, err
+		}
+
+		// Get new value. If the row does not exist at its timestamp, the first
+		// kv, a deletion, is decoded on its own.
+		var r emitEntry
+		r.bufferGetTimestamp = pr.bufferGetTimestamp
+		rowKVs := pr.rowKVs
+		if len(rowKVs) == 0 {
+			rowKVs = pr.kvs[:1]
+		}
+		r.row.datums, r.row.tableDesc, r.row.deleted, err = fetchRow(ctx, rf, rowKVs)
+		if err != nil {
+			return nil, err
+		}
+		r.row.updated = pr.schemaTimestamp
+
+		// Get prev value, if necessary.
+		if withDiff {
+			_, _, prevRF, err := prevFetcher(
+				ctx, pr.key, pr.desc, nil /* family */, rf, pr.schemaTimestamp, pr.prevSchemaTimestamp)
 			if err != nil {
 				return nil, err
 			}
-			if r.row.prevDatums == nil {
-				return nil, errors.AssertionFailedf("unexpected empty datums")
+			prevKVs := pr.prevKVs()
+			if len(prevKVs) == 0 {
+				prevKVs = []roachpb.KeyValue{{Key: pr.kvs[0].Key}}
 			}
-			r.row.prevDatums = append(sqlbase.EncDatumRow(nil), r.row.prevDatums...)
-			r.row.prevDeleted = prevRF.RowIsDeleted()
-
-			// Assert that we don't get a second row from the row.Fetcher. We
-			// fed it a single KV, so that would be surprising.
-			var nextRow emitEntry
-			nextRow.row.prevDatums, nextRow.row.prevTableDesc, _, err = prevRF.NextRow(ctx)
+			r.row.prevDatums, r.row.prevTableDesc, r.row.prevDeleted, err = fetchRow(
+				ctx, prevRF, prevKVs)
 			if err != nil {
 				return nil, err
 			}
-			if nextRow.row.prevDatums != nil {
-				return nil, errors.AssertionFailedf("unexpected non-empty datums")
-			}
 		}
 
 		output = append(output, r)
 		return output, nil
 	}
 
+	// appendEmitEntriesForResolved emits the pending rows that the given
+	// resolved span completes, ordered by timestamp and key.
+	appendEmitEntriesForResolved := func(
+		ctx context.Context, output []emitEntry, resolved *jobspb.ResolvedSpan,
+	) ([]emitEntry, error) {
+		var rows []*pendingRow
+		for id, r := range pendingRows {
+			if r.schemaTimestamp.LessEq(resolved.Timestamp) && resolved.Span.ContainsKey(r.key) {
+				rows = append(rows, r)
+				delete(pendingRows, id)
+			}
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].schemaTimestamp != rows[j].schemaTimestamp {
+				return rows[i].schemaTimestamp.Less(rows[j].schemaTimestamp)
+			}
+			return rows[i].key.Compare(rows[j].key) < 0
+		})
+		if err := readPendingRows(ctx, db, rows); err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			var err error
+			if output, err = appendEmitEntryForPendingRow(ctx, output, r); err != nil {
+				return nil, err
+			}
+		}
+		return output, nil
+	}
+
 	var output []emitEntry
 	return func(ctx context.Context) ([]emitEntry, error) {
 		// Reuse output to save allocations.
@@ -194,18 +339,25 @@ func kvsToRows(
 				}
 				schemaTimestamp := kv.Value.Timestamp
 				prevSchemaTimestamp := schemaTimestamp
-				if backfillTs := input.BackfillTimestamp(); backfillTs != (hlc.Timestamp{}) {
+				backfillTs := input.BackfillTimestamp()
+				if backfillTs != (hlc.Timestamp{}) {
 					schemaTimestamp = backfillTs
 					prevSchemaTimestamp = schemaTimestamp.Prev()
 				}
 				output, err = appendEmitEntryForKV(
 					ctx, output, kv, input.PrevValue(),
-					schemaTimestamp, prevSchemaTimestamp,
+					schemaTimestamp, prevSchemaTimestamp, backfillTs != (hlc.Timestamp{}),
 					input.BufferGetTimestamp())
 				if err != nil {
 					return nil, err
 				}
 			case kvfeed.ResolvedEvent:
+				// The pending rows are emitted before the resolved timestamp,
+				// which would otherwise cause them to be dropped.
+				output, err = appendEmitEntriesForResolved(ctx, output, input.Resolved())
+				if err != nil {
+					return nil, err
+				}
 				output = append(output, emitEntry{
 					resolved:           input.Resolved(),
 					bufferGetTimestamp: input.BufferGetTimestamp(),
@@ -218,18 +370,153 @@ func kvsToRows(
 	}
 }
 
+// pendingRowID identifies a pendingRow.
+type pendingRowID struct {
+	key       string
+	timestamp hlc.Timestamp
+	backfill  bool
+}
+
+// pendingRow collects the kvs of a row of a table with multiple column
+// families, which were either written at the same timestamp or returned by
+// the same backfill. The row is emitted once the span containing it is
+// resolved at its timestamp.
+//
+// A backfill returns all of the families of the row. A transaction only
+// writes some of them, in which case the others are read when the row is
+// emitted (see readPendingRows). Since they did not change at the timestamp
+// of the row, they are also part of the previous value of the row.
+type pendingRow struct {
+	key                 roachpb.Key
+	desc                *sqlbase.TableDescriptor
+	schemaTimestamp     hlc.Timestamp
+	prevSchemaTimestamp hlc.Timestamp
+	backfill            bool
+	bufferGetTimestamp  time.Time
+
+	// kvs are the received kvs, sorted by key, and prevVals their previous
+	// values.
+	kvs      []roachpb.KeyValue
+	prevVals []roachpb.Value
+	// rowKVs are the kvs of all of the families of the row which exist at its
+	// timestamp.
+	rowKVs []roachpb.KeyValue
+}
+
+// add adds a received kv to the row. A kv may be received more than once,
+// e.g. when a rangefeed is restarted.
+func (r *pendingRow) add(kv roachpb.KeyValue, prevVal roachpb.Value) {
+	i := sort.Search(len(r.kvs), func(i int) bool { return r.kvs[i].Key.Compare(kv.Key) >= 0 })
+	if i < len(r.kvs) && r.kvs[i].Key.Equal(kv.Key) {
+		r.kvs[i], r.prevVals[i] = kv, prevVal
+		return
+	}
+	r.kvs = append(r.kvs, roachpb.KeyValue{})
+	copy(r.kvs[i+1:], r.kvs[i:])
+	r.kvs[i] = kv
+	r.prevVals = append(r.prevVals, roachpb.Value{})
+	copy(r.prevVals[i+1:], r.prevVals[i:])
+	r.prevVals[i] = prevVal
+}
+
+// needsRead returns whether some of the families of the row were not
+// received.
+func (r *pendingRow) needsRead() bool {
+	return !r.backfill && len(r.kvs) < len(r.desc.Families)
+}
+
+// setReceivedRowKVs sets the kvs of the row to the received ones which are
+// not deletions.
+func (r *pendingRow) setReceivedRowKVs() {
+	r.rowKVs = r.rowKVs[:0]
+	for _, kv := range r.kvs {
+		if kv.Value.IsPresent() {
+			r.rowKVs = append(r.rowKVs, kv)
+		}
+	}
+}
+
+// prevKVs returns the kvs of the previous value of the row: the previous
+// values of the received kvs, and the kvs of the other families.
+func (r *pendingRow) prevKVs() []roachpb.KeyValue {
+	var prevKVs []roachpb.KeyValue
+	i := 0
+	for _, kv := range r.rowKVs {
+		for i < len(r.kvs) && r.kvs[i].Key.Compare(kv.Key) < 0 {
+			if r.prevVals[i].IsPresent() {
+				prevKVs = append(prevKVs, roachpb.KeyValue{Key: r.kvs[i].Key, Value: r.prevVals[i]})
+			}
+			i++
+		}
+		if i < len(r.kvs) && r.kvs[i].Key.Equal(kv.Key) {
+			continue
+		}
+		prevKVs = append(prevKVs, kv)
+	}
+	for ; i < len(r.kvs); i++ {
+		if r.prevVals[i].IsPresent() {
+			prevKVs = append(prevKVs, roachpb.KeyValue{Key: r.kvs[i].Key, Value: r.prevVals[i]})
+		}
+	}
+	return prevKVs
+}
+
+// readPendingRows sets the kvs of the given rows, which are sorted by
+// timestamp. The rows whose families were not all received are read with one
+// batch of scans per timestamp.
+func readPendingRows(ctx context.Context, db *kv.DB, rows []*pendingRow) error {
+	var toRead []*pendingRow
+	for i, r := range rows {
+		if r.needsRead() {
+			toRead = append(toRead, r)
+		} else {
+			r.setReceivedRowKVs()
+		}
+		if len(toRead) == 0 || (i+1 < len(rows) && rows[i+1].schemaTimestamp == r.schemaTimestamp) {
+			continue
+		}
+
+		txn := db.NewTxn(ctx, "changefeed row read")
+		txn.SetFixedTimestamp(ctx, toRead[0].schemaTimestamp)
+		b := txn.NewBatch()
+		for _, r := range toRead {
+			b.Scan(r.key, r.key.PrefixEnd())
+		}
+		if err := txn.Run(ctx, b); err != nil {
+			return err
+		}
+		for j, r := range toRead {
+			r.rowKVs = r.rowKVs[:0]
+			for _, res := range b.Results[j].Rows {
+				// Skip the rows of the tables interleaved into this one.
+				if resRowKey, err := keys.EnsureSafeSplitKey(res.Key); err != nil {
+					return err
+				} else if !resRowKey.Equal(r.key) {
+					continue
+				}
+				r.rowKVs = append(r.rowKVs, roachpb.KeyValue{Key: res.Key, Value: *res.Value})
+			}
+		}
+		toRead = toRead[:0]
+	}
+	return nil
+}
+
 // emitEntries connects to a sink, receives rows from a closure, and repeatedly
 // emits them to the sink. It returns a closure that may be repeatedly called to
 // advance the changefeed and which returns span-level resolved timestamp
 // updates. The returned closure is not threadsafe. Note that rows read from
 // `inputFn` which precede or equal the Frontier of `sf` will not be emitted
-// because they're provably duplicates.
+// because they're provably duplicates. If `sel` is not nil, the values of the
+// rows are replaced with the results of the query of the changefeed, and the
+// rows that the query filters out are not emitted.
 func emitEntries(
 	settings *cluster.Settings,
 	details jobspb.ChangefeedDetails,
 	cursor hlc.Timestamp,
 	sf *span.Frontier,
 	encoder Encoder,
+	sel *changefeedSelect,
 	sink Sink,
 	inputFn func(context.Context) ([]emitEntry, error),
 	knobs TestingKnobs,
@@ -249,6 +536,13 @@ func emitEntries(
 				cloudStorageFormatTime(sf.Frontier()))
 			return nil
 		}
+		if sel != nil {
+			var matched bool
+			var err error
+			if row, matched, err = sel.eval(ctx, row); err != nil || !matched {
+				return err
+			}
+		}
 		var keyCopy, valueCopy []byte
 		encodedKey, err := encoder.EncodeKey(ctx, row)
 		if err != nil {
@@ -382,6 +676,7 @@ func emitResolvedTimestamp(
 // updated to refer to this new protected timestamp record.
 func createProtectedTimestampRecord(
 	ctx context.Context,
+	codec keys.SQLCodec,
 	pts protectedts.Storage,
 	txn *kv.Txn,
 	jobID int64,
@@ -392,19 +687,19 @@ func createProtectedTimestampRecord(
 	progress.ProtectedTimestampRecord = uuid.MakeV4()
 	log.VEventf(ctx, 2, "creating protected timestamp %v at %v",
 		progress.ProtectedTimestampRecord, resolved)
-	spansToProtect := makeSpansToProtect(targets)
+	spansToProtect := makeSpansToProtect(codec, targets)
 	rec := jobsprotectedts.MakeRecord(
 		progress.ProtectedTimestampRecord, jobID, resolved, spansToProtect)
 	return pts.Protect(ctx, txn, rec)
 }
 
-func makeSpansToProtect(targets jobspb.ChangefeedTargets) []roachpb.Span {
+func makeSpansToProtect(codec keys.SQLCodec, targets jobspb.ChangefeedTargets) []roachpb.Span {
 	// NB: We add 1 because we're also going to protect system.descriptors.
 	// We protect system.descriptors because a changefeed needs all of the history
 	// of table descriptors to version data.
 	spansToProtect := make([]roachpb.Span, 0, len(targets)+1)
 	addTablePrefix := func(id uint32) {
-		tablePrefix := keys.TODOSQLCodec.TablePrefix(id)
+		tablePrefix := codec.TablePrefix(id)
 		spansToProtect = append(spansToProtect, roachpb.Span{
 			Key:    tablePrefix,
 			EndKey: tablePrefix.PrefixEnd(),
@@ -423,5 +718,6 @@ func initialScanFromOptions(opts map[string]string) bool {
 	_, cursor := opts[changefeedbase.OptCursor]
 	_, initialScan := opts[changefeedbase.OptInitialScan]
 	_, noInitialScan := opts[changefeedbase.OptNoInitialScan]
-	return (cursor && initialScan) || (!cursor && !noInitialScan)
+	_, initialScanOnly := opts[changefeedbase.OptInitialScanOnly]
+	return (cursor && (initialScan || initialScanOnly)) || (!cursor && !noInitialScan)
 }
@@ -99,6 +99,13 @@ func distChangefeedFlow(
 		spansTS = initialHighWater
 	}
 
+	// The spans that the first scan of the changefeed already scanned before it
+	// was resumed, if any.
+	var checkpoint []roachpb.Span
+	if cp := progress.GetChangefeed(); cp != nil {
+		checkpoint = cp.Checkpoint
+	}
+
 	execCfg := phs.ExecCfg()
 	trackedSpans, err := fetchSpansForTargets(ctx, execCfg.DB, execCfg.Codec, details.Targets, spansTS)
 	if err != nil {
@@ -107,10 +114,9 @@ func distChangefeedFlow(
 
 	// Changefeed flows handle transactional consistency themselves.
 	var noTxn *kv.Txn
-	gatewayNodeID, err := execCfg.NodeID.OptionalNodeIDErr(48274)
-	if err != nil {
-		return err
-	}
+	// NB: Secondary tenants have no node ID, but their flows are planned on the
+	// gateway, which DistSQL identifies by its SQL instance ID.
+	gatewayNodeID := roachpb.NodeID(execCfg.NodeID.SQLInstanceID())
 	dsp := phs.DistSQLPlanner()
 	evalCtx := phs.ExtendedEvalContext()
 	planCtx := dsp.NewPlanningCtx(ctx, evalCtx, nil /* planner */, noTxn, true /* distribute */)
@@ -141,9 +147,10 @@ func distChangefeedFlow(
 
 		corePlacement[i].NodeID = sp.Node
 		corePlacement[i].Core.ChangeAggregator = &execinfrapb.ChangeAggregatorSpec{
-			Watches: watches,
-			Feed:    details,
-			User:    phs.User(),
+			Watches:    watches,
+			Feed:       details,
+			User:       phs.User(),
+			Checkpoint: checkpoint,
 		}
 	}
 	// NB: This SpanFrontier processor depends on the set of tracked spans being
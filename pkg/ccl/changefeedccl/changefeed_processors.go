@@ -153,12 +153,9 @@ func (ca *changeAggregator) Start(ctx context.Context) context.Context {
 
 	spans, sf := ca.setupSpans()
 	timestampOracle := &changeAggregatorLowerBoundOracle{sf: sf, initialInclusiveLowerBound: ca.spec.Feed.StatementTime}
-	nodeID, err := ca.flowCtx.EvalCtx.NodeID.OptionalNodeIDErr(48274)
-	if err != nil {
-		ca.MoveToDraining(err)
-		return ctx
-	}
+	nodeID := roachpb.NodeID(ca.flowCtx.EvalCtx.NodeID.SQLInstanceID())
 
+	var err error
 	if ca.sink, err = getSink(
 		ctx, ca.spec.Feed.SinkURI, nodeID, ca.spec.Feed.Opts, ca.spec.Feed.Targets,
 		ca.flowCtx.Cfg.Settings, timestampOracle, ca.flowCtx.Cfg.ExternalStorageFromURI, ca.spec.User,
@@ -205,9 +202,15 @@ func (ca *changeAggregator) Start(ctx context.Context) context.Context {
 	_, withDiff := ca.spec.Feed.Opts[changefeedbase.OptDiff]
 	kvfeedCfg := makeKVFeedCfg(ca.flowCtx.Cfg, leaseMgr, ca.kvFeedMemMon, ca.spec,
 		spans, withDiff, buf, metrics)
-	rowsFn := kvsToRows(ca.flowCtx.Codec(), leaseMgr, ca.spec.Feed, buf.Get)
+	rowsFn := kvsToRows(ca.flowCtx.Codec(), leaseMgr, ca.flowCtx.Cfg.DB, ca.spec.Feed, buf.Get)
+	// The query of the changefeed, if any, is evaluated over the rows before
+	// they are emitted.
+	var sel *changefeedSelect
+	if ca.spec.Feed.Select != `` {
+		sel = newChangefeedSelect(ca.spec.Feed.Select, ca.flowCtx.NewEvalCtx())
+	}
 	ca.tickFn = emitEntries(ca.flowCtx.Cfg.Settings, ca.spec.Feed,
-		kvfeedCfg.InitialHighWater, sf, ca.encoder, ca.sink, rowsFn, knobs, metrics)
+		kvfeedCfg.InitialHighWater, sf, ca.encoder, sel, ca.sink, rowsFn, knobs, metrics)
 	ca.startKVFeed(ctx, kvfeedCfg)
 
 	return ctx
@@ -254,6 +257,7 @@ func makeKVFeedCfg(
 		Settings:           cfg.Settings,
 		DB:                 cfg.DB,
 		Clock:              cfg.DB.Clock(),
+		Codec:              cfg.Codec,
 		Gossip:             cfg.Gossip,
 		Spans:              spans,
 		Targets:            spec.Feed.Targets,
@@ -261,6 +265,8 @@ func makeKVFeedCfg(
 		Metrics:            &metrics.KVFeedMetrics,
 		MM:                 mm,
 		InitialHighWater:   initialHighWater,
+		EndTime:            spec.Feed.EndTime,
+		Checkpoint:         spec.Checkpoint,
 		WithDiff:           withDiff,
 		NeedsInitialScan:   needsInitialScan,
 		SchemaChangeEvents: schemaChangeEvents,
@@ -273,11 +279,8 @@ func makeKVFeedCfg(
 // whether or not an initial scan is needed. The need for an initial scan is
 // determined by whether the watched in the spec have a resolved timestamp. The
 // higher layers mark each watch with the checkpointed resolved timestamp if no
-// initial scan is needed.
-//
-// TODO(ajwerner): Utilize this partial checkpointing, especially in the face of
-// of logical backfills of a single table while progress is made on others or
-// get rid of it. See https://github.com/cockroachdb/cockroach/issues/43896.
+// initial scan is needed. The spans that the first scan does not need to scan
+// again are passed separately, in the Checkpoint of the spec.
 func getKVFeedInitialParameters(
 	spec execinfrapb.ChangeAggregatorSpec,
 ) (initialHighWater hlc.Timestamp, needsInitialScan bool) {
@@ -457,6 +460,12 @@ type changeFrontier struct {
 	// CHANGEFEED statement was run at. It's used in an assertion that we never
 	// regress the job high-water.
 	highWaterAtStart hlc.Timestamp
+	// persistedHighWater is the high-water of the job as of its last update.
+	// The span level checkpoints are relative to it, see checkpointTimestamp.
+	persistedHighWater hlc.Timestamp
+	// lastSpanCheckpoint is the last time the spans scanned by a backfill in
+	// progress were checkpointed in the job progress.
+	lastSpanCheckpoint time.Time
 	// passthroughBuf, in some but not all flows, contains changed row data to
 	// pass through unchanged to the gateway node.
 	passthroughBuf encDatumRowBuffer
@@ -538,14 +547,11 @@ func (cf *changeFrontier) Start(ctx context.Context) context.Context {
 	// early returns if errors are detected.
 	ctx = cf.StartInternal(ctx, changeFrontierProcName)
 
-	nodeID, err := cf.flowCtx.EvalCtx.NodeID.OptionalNodeIDErr(48274)
-	if err != nil {
-		cf.MoveToDraining(err)
-		return ctx
-	}
+	nodeID := roachpb.NodeID(cf.flowCtx.EvalCtx.NodeID.SQLInstanceID())
 	// Pass a nil oracle because this sink is only used to emit resolved timestamps
 	// but the oracle is only used when emitting row updates.
 	var nilOracle timestampLowerBoundOracle
+	var err error
 	if cf.sink, err = getSink(
 		ctx, cf.spec.Feed.SinkURI, nodeID, cf.spec.Feed.Opts, cf.spec.Feed.Targets,
 		cf.flowCtx.Cfg.Settings, nilOracle, cf.flowCtx.Cfg.ExternalStorageFromURI, cf.spec.User,
@@ -578,6 +584,7 @@ func (cf *changeFrontier) Start(ctx context.Context) context.Context {
 		p := job.Progress()
 		if ts := p.GetHighWater(); ts != nil {
 			cf.highWaterAtStart.Forward(*ts)
+			cf.persistedHighWater = *ts
 		}
 	}
 
@@ -632,6 +639,14 @@ func (cf *changeFrontier) schemaChangeBoundaryReached() (r bool) {
 	return !cf.schemaChangeBoundary.IsEmpty() && cf.schemaChangeBoundary.Equal(cf.sf.Frontier())
 }
 
+// endTimeReached returns true if the changefeed has an end time and the
+// spanFrontier is at the boundary the kvfeeds resolve all the spans at once they
+// reach it.
+func (cf *changeFrontier) endTimeReached() bool {
+	return !cf.spec.Feed.EndTime.IsEmpty() && cf.schemaChangeBoundaryReached() &&
+		cf.schemaChangeBoundary.Equal(cf.spec.Feed.EndTime)
+}
+
 // shouldFailOnSchemaChange checks the job's spec to determine whether it should
 // failed on schema change events after all spans have been resolved.
 func (cf *changeFrontier) shouldFailOnSchemaChange() bool {
@@ -640,8 +655,12 @@ func (cf *changeFrontier) shouldFailOnSchemaChange() bool {
 }
 
 // shouldFailOnSchemaChange checks the job's spec to determine whether it should
-// install protected timestamps when encountering scan boundaries.
+// install protected timestamps when encountering scan boundaries. Secondary
+// tenants never do, since the KV layer does not consult their records.
 func (cf *changeFrontier) shouldProtectBoundaries() bool {
+	if !cf.flowCtx.Codec().ForSystemTenant() {
+		return false
+	}
 	policy := changefeedbase.SchemaChangePolicy(cf.spec.Feed.Opts[changefeedbase.OptSchemaChangePolicy])
 	return policy == changefeedbase.OptSchemaChangePolicyBackfill
 }
@@ -655,6 +674,14 @@ func (cf *changeFrontier) Next() (sqlbase.EncDatumRow, *execinfrapb.ProducerMeta
 			return cf.ProcessRowHelper(cf.resolvedBuf.Pop()), nil
 		}
 
+		if cf.endTimeReached() {
+			// All the changes up to the end time have been emitted and flushed, and
+			// so has the final resolved timestamp: the changefeed is done.
+			log.Infof(cf.Ctx, "changefeed reached its end time %v", cf.spec.Feed.EndTime)
+			cf.MoveToDraining(nil /* err */)
+			break
+		}
+
 		if cf.schemaChangeBoundaryReached() && cf.shouldFailOnSchemaChange() {
 			// TODO(ajwerner): make this more useful by at least informing the client
 			// of which tables changed.
@@ -736,6 +763,8 @@ func (cf *changeFrontier) noteResolvedSpan(d sqlbase.EncDatum) error {
 		if err := cf.handleFrontierChanged(isBehind); err != nil {
 			return err
 		}
+	} else if err := cf.maybeCheckpointSpans(resolved.Timestamp); err != nil {
+		return err
 	}
 	return nil
 }
@@ -770,14 +799,91 @@ func (cf *changeFrontier) checkpointResolvedTimestamp(
 	if cf.jobProgressedFn == nil {
 		return nil
 	}
-	return cf.jobProgressedFn(cf.Ctx, func(
+	if err := cf.jobProgressedFn(cf.Ctx, func(
 		ctx context.Context, txn *kv.Txn, details jobspb.ProgressDetails,
 	) (hlc.Timestamp, error) {
 		progress := details.(*jobspb.Progress_Changefeed).Changefeed
 		if err := cf.manageProtectedTimestamps(ctx, progress, txn, resolved, isBehind); err != nil {
 			return hlc.Timestamp{}, err
 		}
+		// The span level checkpoint was relative to the previous high-water.
+		progress.Checkpoint = nil
 		return resolved, nil
+	}); err != nil {
+		return err
+	}
+	cf.persistedHighWater = resolved
+	return nil
+}
+
+// checkpointTimestamp returns the timestamp of the first scan the changefeed
+// performs when it is resumed from its persisted high-water: the initial scan
+// at the statement time if there is no high-water and an initial scan was
+// requested, or the backfill due to a schema change right after the high-water
+// otherwise. The span level checkpoints only apply to this scan.
+func (cf *changeFrontier) checkpointTimestamp() hlc.Timestamp {
+	if cf.persistedHighWater.IsEmpty() {
+		if initialScanFromOptions(cf.spec.Feed.Opts) {
+			return cf.spec.Feed.StatementTime
+		}
+		// The changefeed starts from the statement time, see distChangefeedFlow.
+		return cf.spec.Feed.StatementTime.Next()
+	}
+	return cf.persistedHighWater.Next()
+}
+
+// maybeCheckpointSpans persists the spans which the scan at the checkpoint
+// timestamp (see checkpointTimestamp) is done with, so that a resumed
+// changefeed does not scan them and emit their rows again. It is called with
+// the resolved timestamps which do not move the frontier, and only does
+// anything for the ones at the checkpoint timestamp: the scanner resolves each
+// span it scanned at the scan timestamp, once its rows have been flushed to the
+// sink.
+func (cf *changeFrontier) maybeCheckpointSpans(resolved hlc.Timestamp) error {
+	// NB: Sinkless changefeeds cannot be resumed.
+	if cf.jobProgressedFn == nil {
+		return nil
+	}
+	sv := &cf.flowCtx.Cfg.Settings.SV
+	freq := changefeedbase.FrontierCheckpointFrequency.Get(sv)
+	if freq == 0 || timeutil.Since(cf.lastSpanCheckpoint) < freq {
+		return nil
+	}
+	scanTime := cf.checkpointTimestamp()
+	if !resolved.Equal(scanTime) {
+		return nil
+	}
+
+	var scanned []roachpb.Span
+	cf.sf.Entries(func(sp roachpb.Span, ts hlc.Timestamp) {
+		if scanTime.LessEq(ts) {
+			scanned = append(scanned, sp)
+		}
+	})
+	scanned, _ = roachpb.MergeSpans(scanned)
+	// Any subset of the scanned spans is a valid checkpoint, so the checkpoint
+	// is truncated to its maximum size.
+	maxBytes := changefeedbase.FrontierCheckpointMaxBytes.Get(sv)
+	var checkpoint []roachpb.Span
+	var size int64
+	for _, sp := range scanned {
+		if size += int64(len(sp.Key) + len(sp.EndKey)); size > maxBytes {
+			break
+		}
+		checkpoint = append(checkpoint, sp)
+	}
+
+	cf.lastSpanCheckpoint = timeutil.Now()
+	highWater := cf.persistedHighWater
+	if log.V(2) {
+		log.Infof(cf.Ctx, "checkpointing %d spans scanned at %s", len(checkpoint), scanTime)
+	}
+	return cf.jobProgressedFn(cf.Ctx, func(
+		ctx context.Context, txn *kv.Txn, details jobspb.ProgressDetails,
+	) (hlc.Timestamp, error) {
+		progress := details.(*jobspb.Progress_Changefeed).Changefeed
+		progress.Checkpoint = checkpoint
+		return highWater, nil
 	})
 }
 
@@ -848,10 +954,16 @@ func (cf *changeFrontier) maybeProtectTimestamp(
 	if cf.isSinkless() || !cf.schemaChangeBoundaryReached() || !cf.shouldProtectBoundaries() {
 		return nil
 	}
+	// There is nothing left to backfill once the changefeed reached its end time.
+	if cf.endTimeReached() {
+		return nil
+	}
 
 	jobID := cf.spec.JobID
 	targets := cf.spec.Feed.Targets
-	return createProtectedTimestampRecord(ctx, pts, txn, jobID, targets, resolved, progress)
+	return createProtectedTimestampRecord(
+		ctx, cf.flowCtx.Codec(), pts, txn, jobID, targets, resolved, progress,
+	)
 }
 
 func (cf *changeFrontier) maybeEmitResolved(newResolved hlc.Timestamp) error {
@@ -0,0 +1,461 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// changefeedSelectContext is the context of the errors returned for the
+// expressions of the query of a changefeed that are not row-local and
+// immutable.
+const changefeedSelectContext = `CHANGEFEED`
+
+// cdcFunctions are the functions that can only be called in the query of a
+// changefeed, with their return types. They return information about the
+// change being emitted rather than about the row.
+var cdcFunctions = map[string]*types.T{
+	// cdc_prev returns the previous value of the row as a JSON object, or NULL
+	// if the row did not exist. It requires the diff option.
+	`cdc_prev`: types.Jsonb,
+	// cdc_is_delete returns whether the change is a deletion. Only the primary
+	// key columns of a deleted row are set; its other columns are NULL.
+	`cdc_is_delete`: types.Bool,
+	// cdc_mvcc_timestamp returns the MVCC timestamp of the change.
+	`cdc_mvcc_timestamp`: types.Decimal,
+}
+
+// changefeedSelect evaluates the query of a changefeed created with CREATE
+// CHANGEFEED ... AS SELECT over the changes to the rows of its table. The
+// changes that don't satisfy the WHERE clause are filtered out, and the others
+// are replaced with the rows of the projection. Only the values are replaced:
+// the keys of the messages are still the primary keys of the rows (see
+// encodeRow.keySource).
+//
+// A changefeedSelect is not threadsafe.
+type changefeedSelect struct {
+	query   string
+	evalCtx *tree.EvalContext
+	alloc   sqlbase.DatumAlloc
+
+	// compiled caches the query, type checked against each version of the
+	// table descriptor.
+	compiled map[idVersion]*compiledSelect
+
+	// row is the change the query is being evaluated for. It is used by the
+	// cdcFunctions.
+	row encodeRow
+}
+
+// compiledSelect is the query of a changefeed, type checked against a version
+// of the descriptor of its table.
+type compiledSelect struct {
+	// desc describes the rows of the projection. It is a copy of the table
+	// descriptor with one column per expression of the projection, so that the
+	// rows are emitted to the topic of the table. Only its name and its columns
+	// are meaningful.
+	desc  *sqlbase.TableDescriptor
+	exprs []tree.TypedExpr
+	// where is nil if the query has no WHERE clause.
+	where tree.TypedExpr
+	// usesPrev is true if the query calls cdc_prev.
+	usesPrev bool
+
+	ivars selectRow
+}
+
+func newChangefeedSelect(query string, evalCtx *tree.EvalContext) *changefeedSelect {
+	return &changefeedSelect{
+		query:    query,
+		evalCtx:  evalCtx,
+		compiled: make(map[idVersion]*compiledSelect),
+	}
+}
+
+// validateChangefeedSelect checks that the query of a changefeed only uses
+// row-local, immutable expressions over the columns of its table, and that it
+// is compatible with the options of the changefeed.
+func validateChangefeedSelect(
+	ctx context.Context,
+	evalCtx *tree.EvalContext,
+	query string,
+	tableDesc *sqlbase.TableDescriptor,
+	opts map[string]string,
+) error {
+	if _, ok := opts[changefeedbase.OptSplitColumnFamilies]; ok {
+		return errors.Errorf(`%s is not supported for changefeeds with a query`,
+			changefeedbase.OptSplitColumnFamilies)
+	}
+	c, err := newChangefeedSelect(query, evalCtx).compile(ctx, tableDesc)
+	if err != nil {
+		return err
+	}
+	if _, withDiff := opts[changefeedbase.OptDiff]; c.usesPrev && !withDiff {
+		return pgerror.Newf(pgcode.InvalidParameterValue,
+			`cdc_prev() requires the %s option`, changefeedbase.OptDiff)
+	}
+	return nil
+}
+
+// compile returns the query type checked against the given table descriptor.
+func (s *changefeedSelect) compile(
+	ctx context.Context, tableDesc *sqlbase.TableDescriptor,
+) (*compiledSelect, error) {
+	idVer := idVersion{id: tableDesc.ID, version: tableDesc.Version, family: allFamilies}
+	if c, ok := s.compiled[idVer]; ok {
+		return c, nil
+	}
+
+	// The query is parsed again for every version of the descriptor, because
+	// type checking annotates the expressions in place.
+	stmt, err := parser.ParseOne(s.query)
+	if err != nil {
+		return nil, err
+	}
+	sel, ok := stmt.AST.(*tree.Select)
+	if !ok {
+		return nil, errors.AssertionFailedf(`unexpected changefeed query: %s`, s.query)
+	}
+	clause, ok := sel.Select.(*tree.SelectClause)
+	if !ok || len(clause.From.Tables) != 1 {
+		return nil, errors.AssertionFailedf(`unexpected changefeed query: %s`, s.query)
+	}
+	tn, ok := clause.From.Tables[0].(*tree.TableName)
+	if !ok {
+		return nil, errors.AssertionFailedf(`unexpected changefeed query: %s`, s.query)
+	}
+
+	c := &compiledSelect{ivars: selectRow{cols: tableDesc.Columns}}
+	ivarHelper := tree.MakeIndexedVarHelper(&c.ivars, len(tableDesc.Columns))
+	source := sqlbase.NewSourceInfoForSingleTable(
+		*tn, sqlbase.ResultColumnsFromColDescs(tableDesc.ID, tableDesc.Columns))
+	semaCtx := tree.MakeSemaContext()
+	semaCtx.IVarContainer = &c.ivars
+	semaCtx.Properties.Require(changefeedSelectContext,
+		tree.RejectSpecial|tree.RejectStableOperators|tree.RejectVolatileFunctions|
+			tree.RejectSubqueries)
+	searchPath := s.evalCtx.SessionData.SearchPath
+
+	resolveNames := func(expr tree.Expr) (tree.Expr, error) {
+		expr, err := s.replaceCDCFunctions(c, expr)
+		if err != nil {
+			return nil, err
+		}
+		return sqlbase.ResolveNames(expr, source, ivarHelper, searchPath)
+	}
+
+	desc := *tableDesc
+	desc.Columns = nil
+	addColumn := func(name string, expr tree.TypedExpr) error {
+		for i := range desc.Columns {
+			if desc.Columns[i].Name == name {
+				return pgerror.Newf(pgcode.DuplicateColumn,
+					`duplicate column name %q in changefeed query`, name)
+			}
+		}
+		desc.Columns = append(desc.Columns, sqlbase.ColumnDescriptor{
+			ID:       sqlbase.ColumnID(len(desc.Columns) + 1),
+			Name:     name,
+			Type:     expr.ResolvedType(),
+			Nullable: true,
+		})
+		c.exprs = append(c.exprs, expr)
+		return nil
+	}
+	for _, target := range clause.Exprs {
+		if isStar(target.Expr) {
+			for i := range tableDesc.Columns {
+				if col := &tableDesc.Columns[i]; !col.Hidden {
+					if err := addColumn(col.Name, tree.NewTypedOrdinalReference(i, col.Type)); err != nil {
+						return nil, err
+					}
+				}
+			}
+			continue
+		}
+		name, err := tree.GetRenderColName(searchPath, target)
+		if err != nil {
+			return nil, err
+		}
+		expr, err := resolveNames(target.Expr)
+		if err != nil {
+			return nil, err
+		}
+		typedExpr, err := tree.TypeCheck(ctx, expr, &semaCtx, types.Any)
+		if err != nil {
+			return nil, err
+		}
+		if err := addColumn(name, typedExpr); err != nil {
+			return nil, err
+		}
+	}
+	if clause.Where != nil {
+		expr, err := resolveNames(clause.Where.Expr)
+		if err != nil {
+			return nil, err
+		}
+		if c.where, err = tree.TypeCheckAndRequire(ctx, expr, &semaCtx, types.Bool, `WHERE`); err != nil {
+			return nil, err
+		}
+	}
+	c.desc = &desc
+
+	s.compiled[idVer] = c
+	return c, nil
+}
+
+// isStar returns whether the given expression of a projection is `*` or
+// `<table>.*`.
+func isStar(expr tree.Expr) bool {
+	v, ok := expr.(tree.VarName)
+	if !ok {
+		return false
+	}
+	v, err := v.NormalizeVarName()
+	if err != nil {
+		return false
+	}
+	switch v.(type) {
+	case tree.UnqualifiedStar, *tree.AllColumnsSelector:
+		return true
+	}
+	return false
+}
+
+// replaceCDCFunctions replaces the calls to the cdcFunctions in the given
+// expression with cdcFuncExprs.
+func (s *changefeedSelect) replaceCDCFunctions(
+	c *compiledSelect, expr tree.Expr,
+) (tree.Expr, error) {
+	return tree.SimpleVisit(expr, func(expr tree.Expr) (recurse bool, newExpr tree.Expr, err error) {
+		f, ok := expr.(*tree.FuncExpr)
+		if !ok {
+			return true, expr, nil
+		}
+		n, ok := f.Func.FunctionReference.(*tree.UnresolvedName)
+		if !ok || n.NumParts != 1 {
+			return true, expr, nil
+		}
+		name := strings.ToLower(n.Parts[0])
+		typ, ok := cdcFunctions[name]
+		if !ok {
+			return true, expr, nil
+		}
+		if len(f.Exprs) > 0 {
+			return false, nil, pgerror.Newf(pgcode.UndefinedFunction,
+				`%s() does not take arguments`, name)
+		}
+		if name == `cdc_prev` {
+			c.usesPrev = true
+		}
+		return false, &cdcFuncExpr{name: name, typ: typ, sel: s}, nil
+	})
+}
+
+// eval evaluates the query for the given change. It returns false if the
+// change is filtered out by the WHERE clause.
+func (s *changefeedSelect) eval(ctx context.Context, row encodeRow) (encodeRow, bool, error) {
+	c, err := s.compile(ctx, row.tableDesc)
+	if err != nil {
+		return encodeRow{}, false, err
+	}
+	s.row = row
+	if err := c.ivars.setDatums(row.datums, &s.alloc); err != nil {
+		return encodeRow{}, false, err
+	}
+	s.evalCtx.PushIVarContainer(&c.ivars)
+	defer s.evalCtx.PopIVarContainer()
+
+	if c.where != nil {
+		d, err := c.where.Eval(s.evalCtx)
+		if err != nil {
+			return encodeRow{}, false, err
+		}
+		if d != tree.DBoolTrue {
+			return encodeRow{}, false, nil
+		}
+	}
+
+	projected := row
+	projected.tableDesc = c.desc
+	projected.keyDatums, projected.keyTableDesc = row.datums, row.tableDesc
+	projected.keyPrevDatums, projected.keyPrevTableDesc = row.prevDatums, row.prevTableDesc
+	if projected.datums, err = c.project(s.evalCtx, row.deleted); err != nil {
+		return encodeRow{}, false, err
+	}
+
+	// The previous value of the row is projected as well, but the cdcFunctions
+	// still return information about the current change.
+	if row.prevDatums != nil {
+		prev, err := s.compile(ctx, row.prevTableDesc)
+		if err != nil {
+			return encodeRow{}, false, err
+		}
+		projected.prevTableDesc = prev.desc
+		if !row.prevDeleted {
+			if err := prev.ivars.setDatums(row.prevDatums, &s.alloc); err != nil {
+				return encodeRow{}, false, err
+			}
+			s.evalCtx.PushIVarContainer(&prev.ivars)
+			projected.prevDatums, err = prev.project(s.evalCtx, false /* deleted */)
+			s.evalCtx.PopIVarContainer()
+			if err != nil {
+				return encodeRow{}, false, err
+			}
+		}
+	}
+	return projected, true, nil
+}
+
+// project evaluates the projection over the current row. The columns of a
+// deleted row are all NULL.
+func (c *compiledSelect) project(
+	evalCtx *tree.EvalContext, deleted bool,
+) (sqlbase.EncDatumRow, error) {
+	datums := make(sqlbase.EncDatumRow, len(c.exprs))
+	for i, expr := range c.exprs {
+		d := tree.DNull
+		if !deleted {
+			var err error
+			if d, err = expr.Eval(evalCtx); err != nil {
+				return nil, err
+			}
+		}
+		datums[i] = sqlbase.DatumToEncDatum(c.desc.Columns[i].Type, d)
+	}
+	return datums, nil
+}
+
+// evalFunc returns the result of the given cdcFunction for the current change.
+func (s *changefeedSelect) evalFunc(name string) (tree.Datum, error) {
+	switch name {
+	case `cdc_prev`:
+		if s.row.prevDatums == nil || s.row.prevDeleted {
+			return tree.DNull, nil
+		}
+		cols := s.row.prevTableDesc.Columns
+		b := json.NewObjectBuilder(len(cols))
+		for i := range cols {
+			datum := &s.row.prevDatums[i]
+			if err := datum.EnsureDecoded(cols[i].Type, &s.alloc); err != nil {
+				return nil, err
+			}
+			j, err := tree.AsJSON(datum.Datum, time.UTC)
+			if err != nil {
+				return nil, err
+			}
+			b.Add(cols[i].Name, j)
+		}
+		return tree.NewDJSON(b.Build()), nil
+	case `cdc_is_delete`:
+		return tree.MakeDBool(tree.DBool(s.row.deleted)), nil
+	case `cdc_mvcc_timestamp`:
+		return tree.TimestampToDecimalDatum(s.row.updated), nil
+	default:
+		return nil, errors.AssertionFailedf(`unknown changefeed function %s`, name)
+	}
+}
+
+// selectRow is the tree.IndexedVarContainer of the columns of the table of a
+// changefeed query.
+type selectRow struct {
+	cols   []sqlbase.ColumnDescriptor
+	datums tree.Datums
+}
+
+var _ tree.IndexedVarContainer = &selectRow{}
+
+// setDatums decodes the given row. The columns of a deleted row that are not
+// part of the primary key may be unset, in which case they are NULL.
+func (r *selectRow) setDatums(datums sqlbase.EncDatumRow, a *sqlbase.DatumAlloc) error {
+	r.datums = r.datums[:0]
+	for i := range datums {
+		if datums[i].IsUnset() {
+			r.datums = append(r.datums, tree.DNull)
+			continue
+		}
+		if err := datums[i].EnsureDecoded(r.cols[i].Type, a); err != nil {
+			return err
+		}
+		r.datums = append(r.datums, datums[i].Datum)
+	}
+	return nil
+}
+
+// IndexedVarEval implements the tree.IndexedVarContainer interface.
+func (r *selectRow) IndexedVarEval(idx int, _ *tree.EvalContext) (tree.Datum, error) {
+	return r.datums[idx], nil
+}
+
+// IndexedVarResolvedType implements the tree.IndexedVarContainer interface.
+func (r *selectRow) IndexedVarResolvedType(idx int) *types.T {
+	return r.cols[idx].Type
+}
+
+// IndexedVarNodeFormatter implements the tree.IndexedVarContainer interface.
+func (r *selectRow) IndexedVarNodeFormatter(idx int) tree.NodeFormatter {
+	n := tree.Name(r.cols[idx].Name)
+	return &n
+}
+
+// cdcFuncExpr is a call to one of the cdcFunctions. These functions are not
+// builtins, so their calls are replaced with cdcFuncExprs before the query is
+// type checked.
+type cdcFuncExpr struct {
+	name string
+	typ  *types.T
+	sel  *changefeedSelect
+}
+
+var _ tree.TypedExpr = &cdcFuncExpr{}
+
+// String implements the Stringer interface.
+func (f *cdcFuncExpr) String() string {
+	return tree.AsString(f)
+}
+
+// Format implements the NodeFormatter interface.
+func (f *cdcFuncExpr) Format(ctx *tree.FmtCtx) {
+	ctx.WriteString(f.name)
+	ctx.WriteString(`()`)
+}
+
+// Walk implements the Expr interface.
+func (f *cdcFuncExpr) Walk(_ tree.Visitor) tree.Expr {
+	return f
+}
+
+// TypeCheck implements the Expr interface.
+func (f *cdcFuncExpr) TypeCheck(
+	_ context.Context, _ *tree.SemaContext, _ *types.T,
+) (tree.TypedExpr, error) {
+	return f, nil
+}
+
+// Eval implements the TypedExpr interface.
+func (f *cdcFuncExpr) Eval(_ *tree.EvalContext) (tree.Datum, error) {
+	return f.sel.evalFunc(f.name)
+}
+
+// ResolvedType implements the TypedExpr interface.
+func (f *cdcFuncExpr) ResolvedType() *types.T {
+	return f.typ
+}
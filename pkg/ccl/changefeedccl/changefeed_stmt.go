@@ -30,6 +30,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/resolver"
 	"github.com/cockroachdb/cockroach/pkg/sql/flowinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -120,6 +121,13 @@ func changefeedPlanHook(
 		if err != nil {
 			return err
 		}
+		// The KV layer does not consult the protected timestamp records of
+		// secondary tenants, so their changefeeds cannot protect data from GC.
+		if _, ok := opts[changefeedbase.OptProtectDataFromGCOnPause]; ok &&
+			!p.ExecCfg().Codec.ForSystemTenant() {
+			return errors.Errorf(`%s is not supported in secondary tenants`,
+				changefeedbase.OptProtectDataFromGCOnPause)
+		}
 
 		jobDescription, err := changefeedJobDescription(p, changefeedStmt, sinkURI, opts)
 		if err != nil {
@@ -138,6 +146,26 @@ func changefeedPlanHook(
 			}
 			statementTime = initialHighWater
 		}
+		// The end time may be in the future, in which case the changefeed runs
+		// until its resolved timestamp reaches it.
+		var endTime hlc.Timestamp
+		if e, ok := opts[changefeedbase.OptEndTime]; ok {
+			evalCtx := &p.ExtendedEvalContext().EvalContext
+			var err error
+			if endTime, err = tree.DatumToHLC(
+				evalCtx, evalCtx.GetStmtTimestamp(), tree.NewDString(e),
+			); err != nil {
+				return errors.Wrapf(err, `invalid %s`, changefeedbase.OptEndTime)
+			}
+			if endTime.LessEq(statementTime) {
+				return errors.Errorf(`%s must be after the statement time or the %s`,
+					changefeedbase.OptEndTime, changefeedbase.OptCursor)
+			}
+		}
+		if _, ok := opts[changefeedbase.OptInitialScanOnly]; ok {
+			// The changefeed ends once it has scanned its targets.
+			endTime = statementTime
+		}
 
 		// For now, disallow targeting a database or wildcard table selection.
 		// Getting it right as tables enter and leave the set over time is
@@ -162,15 +190,52 @@ func changefeedPlanHook(
 		if err != nil {
 			return err
 		}
+		// The query of a changefeed created with the AS SELECT syntax is
+		// validated against its only target.
+		var query string
+		if changefeedStmt.Select != nil {
+			query = tree.AsStringWithFlags(changefeedStmt.Select, tree.FmtParsable)
+		}
+		keyColumns, err := parseKeyColumns(opts)
+		if err != nil {
+			return err
+		}
+		// The names of the databases and the schemas of the targets are
+		// recorded for the topic_name templates of the Kafka sink.
+		databaseNames := make(map[sqlbase.ID]string)
+		for _, desc := range targetDescs {
+			if dbDesc := desc.GetDatabase(); dbDesc != nil {
+				databaseNames[dbDesc.ID] = dbDesc.Name
+			}
+		}
 		targets := make(jobspb.ChangefeedTargets, len(targetDescs))
 		for _, desc := range targetDescs {
 			if tableDesc := desc.Table(hlc.Timestamp{}); tableDesc != nil {
+				schemaName, err := resolver.ResolveSchemaNameByID(
+					ctx, p.ExtendedEvalContext().Txn, p.ExecCfg().Codec,
+					tableDesc.ParentID, tableDesc.GetParentSchemaID(),
+				)
+				if err != nil {
+					return err
+				}
 				targets[tableDesc.ID] = jobspb.ChangefeedTarget{
-					StatementTimeName: tableDesc.Name,
+					StatementTimeName:         tableDesc.Name,
+					StatementTimeDatabaseName: databaseNames[tableDesc.ParentID],
+					StatementTimeSchemaName:   schemaName,
 				}
 				if err := validateChangefeedTable(targets, tableDesc); err != nil {
 					return err
 				}
+				if err := validateKeyColumns(tableDesc, keyColumns, opts); err != nil {
+					return err
+				}
+				if query != `` {
+					if err := validateChangefeedSelect(
+						ctx, &p.ExtendedEvalContext().EvalContext, query, tableDesc, opts,
+					); err != nil {
+						return err
+					}
+				}
 			}
 		}
 
@@ -179,6 +244,8 @@ func changefeedPlanHook(
 			Opts:          opts,
 			SinkURI:       sinkURI,
 			StatementTime: statementTime,
+			Select:        query,
+			EndTime:       endTime,
 		}
 		progress := jobspb.Progress{
 			Progress: &jobspb.Progress_HighWater{},
@@ -200,9 +267,13 @@ func changefeedPlanHook(
 		//   is not in the value, then for DELETEs there is no way to recover which
 		//   key was deleted. We could make the user explicitly pass this option for
 		//   every cloud storage sink and error if they don't, but that seems
-		//   user-hostile for insufficient reason. We can't do this any earlier,
-		//   because we might return errors about `key_in_value` being incompatible
-		//   which is confusing when the user didn't type that option.
+		//   user-hostile for insufficient reason. The same goes for webhook
+		//   and Pub/Sub sinks, whose requests and messages only carry the
+		//   values. This is only needed with `envelope=wrapped`: the values of
+		//   `envelope=bare` hold every column of the row. We can't do this any
+		//   earlier, because we might return errors about `key_in_value` being
+		//   incompatible which is confusing when the user didn't type that
+		//   option.
 		// - Finally, we create a "canary" sink to test sink configuration and
 		//   connectivity. This has to go last because it is strange to return sink
 		//   connectivity errors before we've finished validating all the other
@@ -223,7 +294,8 @@ func changefeedPlanHook(
 		if _, err := getEncoder(details.Opts); err != nil {
 			return err
 		}
-		if isCloudStorageSink(parsedSink) {
+		if (isCloudStorageSink(parsedSink) || isWebhookSink(parsedSink) || isPubsubSink(parsedSink)) &&
+			changefeedbase.EnvelopeType(details.Opts[changefeedbase.OptEnvelope]) == changefeedbase.OptEnvelopeWrapped {
 			details.Opts[changefeedbase.OptKeyInValue] = ``
 		}
 
@@ -254,10 +326,7 @@ func changefeedPlanHook(
 		// the CREATE CHANGEFEED statement. To do this, we create a "canary" sink,
 		// which will be immediately closed, only to check for errors.
 		{
-			nodeID, err := p.ExtendedEvalContext().NodeID.OptionalNodeIDErr(48274)
-			if err != nil {
-				return err
-			}
+			nodeID := roachpb.NodeID(p.ExtendedEvalContext().NodeID.SQLInstanceID())
 			var nilOracle timestampLowerBoundOracle
 			canarySink, err := getSink(
 				ctx, details.SinkURI, nodeID, details.Opts, details.Targets,
@@ -288,9 +357,12 @@ func changefeedPlanHook(
 		{
 			var protectedTimestampID uuid.UUID
 			var spansToProtect []roachpb.Span
-			if hasInitialScan := initialScanFromOptions(details.Opts); hasInitialScan {
+			// Secondary tenants cannot protect the data of the initial scan. If it
+			// is garbage collected, the scan fails instead.
+			if hasInitialScan := initialScanFromOptions(details.Opts); hasInitialScan &&
+				p.ExecCfg().Codec.ForSystemTenant() {
 				protectedTimestampID = uuid.MakeV4()
-				spansToProtect = makeSpansToProtect(details.Targets)
+				spansToProtect = makeSpansToProtect(p.ExecCfg().Codec, details.Targets)
 				progress.GetChangefeed().ProtectedTimestampRecord = protectedTimestampID
 			}
 
@@ -372,9 +444,13 @@ func changefeedJobDescription(
 	c := &tree.CreateChangefeed{
 		Targets: changefeed.Targets,
 		SinkURI: tree.NewDString(cleanedSinkURI),
+		Select:  changefeed.Select,
 	}
 	for k, v := range opts {
 		opt := tree.KVOption{Key: tree.Name(k)}
+		if k == changefeedbase.OptWebhookAuthHeader {
+			v = `redacted`
+		}
 		if len(v) > 0 {
 			opt.Value = tree.NewDString(v)
 		}
@@ -438,6 +514,23 @@ func validateDetails(details jobspb.ChangefeedDetails) (jobspb.ChangefeedDetails
 				changefeedbase.OptNoInitialScan)
 		}
 	}
+	if _, ok := details.Opts[changefeedbase.OptKeyColumns]; ok {
+		// The column families of a table do not contain the key columns which
+		// are not part of the primary key.
+		if _, ok := details.Opts[changefeedbase.OptSplitColumnFamilies]; ok {
+			return jobspb.ChangefeedDetails{}, errors.Errorf(
+				`cannot specify both %s and %s`, changefeedbase.OptKeyColumns,
+				changefeedbase.OptSplitColumnFamilies)
+		}
+	}
+	if _, ok := details.Opts[changefeedbase.OptInitialScanOnly]; ok {
+		for _, opt := range []string{changefeedbase.OptNoInitialScan, changefeedbase.OptEndTime} {
+			if _, ok := details.Opts[opt]; ok {
+				return jobspb.ChangefeedDetails{}, errors.Errorf(
+					`cannot specify both %s and %s`, changefeedbase.OptInitialScanOnly, opt)
+			}
+		}
+	}
 	{
 		const opt = changefeedbase.OptEnvelope
 		switch v := changefeedbase.EnvelopeType(details.Opts[opt]); v {
@@ -445,6 +538,8 @@ func validateDetails(details jobspb.ChangefeedDetails) (jobspb.ChangefeedDetails
 			details.Opts[opt] = string(changefeedbase.OptEnvelopeRow)
 		case changefeedbase.OptEnvelopeKeyOnly:
 			details.Opts[opt] = string(changefeedbase.OptEnvelopeKeyOnly)
+		case changefeedbase.OptEnvelopeBare:
+			details.Opts[opt] = string(changefeedbase.OptEnvelopeBare)
 		case ``, changefeedbase.OptEnvelopeWrapped:
 			details.Opts[opt] = string(changefeedbase.OptEnvelopeWrapped)
 		default:
@@ -457,7 +552,7 @@ func validateDetails(details jobspb.ChangefeedDetails) (jobspb.ChangefeedDetails
 		switch v := changefeedbase.FormatType(details.Opts[opt]); v {
 		case ``, changefeedbase.OptFormatJSON:
 			details.Opts[opt] = string(changefeedbase.OptFormatJSON)
-		case changefeedbase.OptFormatAvro:
+		case changefeedbase.OptFormatAvro, changefeedbase.OptFormatParquet, changefeedbase.OptFormatCSV:
 			// No-op.
 		default:
 			return jobspb.ChangefeedDetails{}, errors.Errorf(
@@ -492,11 +587,6 @@ func validateChangefeedTable(
 	if tableDesc.IsSequence() {
 		return errors.Errorf(`CHANGEFEED cannot target sequences: %s`, tableDesc.Name)
 	}
-	if len(tableDesc.Families) != 1 {
-		return errors.Errorf(
-			`CHANGEFEEDs are currently supported on tables with exactly 1 column family: %s has %d`,
-			tableDesc.Name, len(tableDesc.Families))
-	}
 
 	if tableDesc.State == sqlbase.TableDescriptor_DROP {
 		return errors.Errorf(`"%s" was dropped or truncated`, t.StatementTimeName)
@@ -674,7 +764,8 @@ func (b *changefeedResumer) OnPauseRequest(
 		return nil
 	}
 
-	pts := planHookState.(sql.PlanHookState).ExecCfg().ProtectedTimestampProvider
-	return createProtectedTimestampRecord(ctx, pts, txn, *b.job.ID(),
+	execCfg := planHookState.(sql.PlanHookState).ExecCfg()
+	pts := execCfg.ProtectedTimestampProvider
+	return createProtectedTimestampRecord(ctx, execCfg.Codec, pts, txn, *b.job.ID(),
 		details.Targets, *resolved, cp)
 }
@@ -109,6 +109,82 @@ func TestChangefeedBasics(t *testing.T) {
 	// cloudStorageTest is a regression test for #36994.
 }
 
+func TestChangefeedTenant(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, `SET CLUSTER SETTING kv.rangefeed.enabled = true`)
+	sqlDB.Exec(t, `SET CLUSTER SETTING kv.closed_timestamp.target_duration = '1s'`)
+
+	pgAddr, err := s.StartTenant(base.TestTenantArgs{
+		TenantID:                    roachpb.MakeTenantID(10),
+		AllowSettingClusterSettings: true,
+	})
+	require.NoError(t, err)
+	sink, cleanup := sqlutils.PGUrl(t, pgAddr, t.Name(), url.User(security.RootUser))
+	defer cleanup()
+	tenantDB, err := gosql.Open(`postgres`, sink.String())
+	require.NoError(t, err)
+	defer tenantDB.Close()
+	tenantSQL := sqlutils.MakeSQLRunner(tenantDB)
+	tenantSQL.Exec(t, `SET CLUSTER SETTING changefeed.experimental_poll_interval = '10ms'`)
+	tenantSQL.Exec(t, `CREATE DATABASE d`)
+	tenantSQL.Exec(t, `CREATE TABLE d.foo (a INT PRIMARY KEY, b STRING)`)
+	tenantSQL.Exec(t, `INSERT INTO d.foo VALUES (0, 'initial')`)
+
+	// The initial scan and the rangefeeds of the tenant's changefeed go through
+	// its DistSender, which proxies range lookups to the KV layer.
+	f := cdctest.MakeSinklessFeedFactory(s, sink)
+	foo := feed(t, f, `CREATE CHANGEFEED FOR foo`)
+	defer closeFeed(t, foo)
+	assertPayloads(t, foo, []string{
+		`foo: [0]->{"after": {"a": 0, "b": "initial"}}`,
+	})
+
+	tenantSQL.Exec(t, `UPSERT INTO d.foo VALUES (0, 'updated'), (1, 'a')`)
+	assertPayloads(t, foo, []string{
+		`foo: [0]->{"after": {"a": 0, "b": "updated"}}`,
+		`foo: [1]->{"after": {"a": 1, "b": "a"}}`,
+	})
+
+	tenantSQL.Exec(t, `ALTER TABLE d.foo ADD COLUMN c INT`)
+	tenantSQL.Exec(t, `INSERT INTO d.foo VALUES (2, 'b', 3)`)
+	assertPayloads(t, foo, []string{
+		`foo: [2]->{"after": {"a": 2, "b": "b", "c": 3}}`,
+	})
+
+	// Enterprise changefeeds run as jobs, which the tenant's registry adopts.
+	// The tenant has no testing knobs, so the feed polls without waiting for
+	// sink flush notifications.
+	ef := cdctest.MakeTableFeedFactory(s, tenantDB, nil /* flushCh */, sink)
+	enterpriseFoo := feed(t, ef, `CREATE CHANGEFEED FOR d.foo`)
+	defer closeFeed(t, enterpriseFoo)
+	assertPayloads(t, enterpriseFoo, []string{
+		`foo: [0]->{"after": {"a": 0, "b": "updated", "c": null}}`,
+		`foo: [1]->{"after": {"a": 1, "b": "a", "c": null}}`,
+		`foo: [2]->{"after": {"a": 2, "b": "b", "c": 3}}`,
+	})
+	tenantSQL.Exec(t, `DELETE FROM d.foo WHERE a = 1`)
+	assertPayloads(t, enterpriseFoo, []string{
+		`foo: [1]->{"after": null}`,
+	})
+	var status string
+	tenantSQL.QueryRow(t,
+		`SELECT status FROM [SHOW JOBS] WHERE job_id = $1`,
+		enterpriseFoo.(*cdctest.TableFeed).JobID,
+	).Scan(&status)
+	require.Equal(t, `running`, status)
+
+	// The KV layer does not consult the protected timestamp records of tenants.
+	tenantSQL.ExpectErr(t,
+		`protect_data_from_gc_on_pause is not supported in secondary tenants`,
+		`CREATE CHANGEFEED FOR d.foo INTO 'kafka://nope' WITH protect_data_from_gc_on_pause`)
+}
+
 func TestChangefeedDiff(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -220,6 +296,47 @@ func TestChangefeedMultiTable(t *testing.T) {
 	t.Run(`enterprise`, enterpriseTest(testFn))
 }
 
+func TestChangefeedKeyColumns(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testFn := func(t *testing.T, db *gosql.DB, f cdctest.TestFeedFactory) {
+		sqlDB := sqlutils.MakeSQLRunner(db)
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY, b STRING, c INT)`)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (1, 'a', 10)`)
+
+		foo := feed(t, f, `CREATE CHANGEFEED FOR foo WITH key_columns='c,b', diff`)
+		defer closeFeed(t, foo)
+		assertPayloads(t, foo, []string{
+			`foo: [10, "a"]->{"after": {"a": 1, "b": "a", "c": 10}, "before": null}`,
+		})
+
+		// The keys of deletions are encoded from the previous values of the
+		// rows.
+		sqlDB.Exec(t, `DELETE FROM foo WHERE a = 1`)
+		assertPayloads(t, foo, []string{
+			`foo: [10, "a"]->{"after": null, "before": {"a": 1, "b": "a", "c": 10}}`,
+		})
+
+		// The key columns that are part of the primary key do not require the
+		// diff option.
+		sqlDB.Exec(t, `CREATE TABLE bar (a INT, b STRING, c INT, PRIMARY KEY (a, b))`)
+		sqlDB.Exec(t, `INSERT INTO bar VALUES (1, 'a', 10)`)
+		bar := feed(t, f, `CREATE CHANGEFEED FOR bar WITH key_columns='b'`)
+		defer closeFeed(t, bar)
+		assertPayloads(t, bar, []string{
+			`bar: ["a"]->{"after": {"a": 1, "b": "a", "c": 10}}`,
+		})
+		sqlDB.Exec(t, `DELETE FROM bar WHERE a = 1`)
+		assertPayloads(t, bar, []string{
+			`bar: ["a"]->{"after": null}`,
+		})
+	}
+
+	t.Run(`sinkless`, sinklessTest(testFn))
+	t.Run(`enterprise`, enterpriseTest(testFn))
+}
+
 func TestChangefeedCursor(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -420,6 +537,65 @@ func TestChangefeedInitialScan(t *testing.T) {
 	t.Run(`enterprise`, enterpriseTest(testFn))
 }
 
+func TestChangefeedEndTime(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testFn := func(t *testing.T, db *gosql.DB, f cdctest.TestFeedFactory) {
+		sqlDB := sqlutils.MakeSQLRunner(db)
+		sqlDB.Exec(t, `SET CLUSTER SETTING kv.closed_timestamp.target_duration = '10ms'`)
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY, b STRING)`)
+
+		var cursor, endTime string
+		sqlDB.QueryRow(t, `SELECT cluster_logical_timestamp()`).Scan(&cursor)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (1, 'before')`)
+		sqlDB.QueryRow(t, `SELECT cluster_logical_timestamp()`).Scan(&endTime)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (2, 'after')`)
+
+		foo := feed(t, f, `CREATE CHANGEFEED FOR foo WITH cursor=$1, end_time=$2, resolved='10ms'`,
+			cursor, endTime)
+		defer closeFeed(t, foo)
+		assertPayloads(t, foo, []string{
+			`foo: [1]->{"after": {"a": 1, "b": "before"}}`,
+		})
+		// The last resolved timestamp is the end time.
+		for end := parseTimeToHLC(t, endTime); ; {
+			resolved := expectResolvedTimestamp(t, foo)
+			if resolved == end {
+				break
+			}
+			require.True(t, resolved.Less(end), `%s is after the end time %s`, resolved, end)
+		}
+		waitForFeedEnd(t, sqlDB, foo)
+	}
+
+	t.Run(`sinkless`, sinklessTest(testFn))
+	t.Run(`enterprise`, enterpriseTest(testFn))
+}
+
+func TestChangefeedInitialScanOnly(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testFn := func(t *testing.T, db *gosql.DB, f cdctest.TestFeedFactory) {
+		sqlDB := sqlutils.MakeSQLRunner(db)
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY)`)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (1), (2)`)
+
+		foo := feed(t, f, `CREATE CHANGEFEED FOR foo WITH initial_scan_only`)
+		defer closeFeed(t, foo)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (3)`)
+		assertPayloads(t, foo, []string{
+			`foo: [1]->{"after": {"a": 1}}`,
+			`foo: [2]->{"after": {"a": 2}}`,
+		})
+		waitForFeedEnd(t, sqlDB, foo)
+	}
+
+	t.Run(`sinkless`, sinklessTest(testFn))
+	t.Run(`enterprise`, enterpriseTest(testFn))
+}
+
 // Test how Changefeeds react to schema changes that do not require a backfill
 // operation.
 func TestChangefeedSchemaChangeNoBackfill(t *testing.T) {
@@ -952,12 +1128,54 @@ func TestChangefeedColumnFamily(t *testing.T) {
 		sqlDB := sqlutils.MakeSQLRunner(db)
 
 		// Table with 2 column families.
-		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY, b STRING, FAMILY (a), FAMILY (b))`)
-		if strings.Contains(t.Name(), `enterprise`) {
-			sqlDB.ExpectErr(t, `exactly 1 column family`, `CREATE CHANGEFEED FOR foo`)
-		} else {
-			sqlDB.ExpectErr(t, `exactly 1 column family`, `EXPERIMENTAL CHANGEFEED FOR foo`)
-		}
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY, b STRING, FAMILY f_a (a), FAMILY f_b (b))`)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (0, 'dog')`)
+
+		// By default, the families of a row are re-assembled into one message.
+		foo := feed(t, f, `CREATE CHANGEFEED FOR foo`)
+		defer closeFeed(t, foo)
+		// With split_column_families, one message is emitted per family, to a
+		// topic named after the table and the family.
+		fooSplit := feed(t, f, `CREATE CHANGEFEED FOR foo WITH split_column_families`)
+		defer closeFeed(t, fooSplit)
+		// The previous value of a row includes the families that were not
+		// modified.
+		fooDiff := feed(t, f, `CREATE CHANGEFEED FOR foo WITH diff`)
+		defer closeFeed(t, fooDiff)
+		assertPayloads(t, foo, []string{
+			`foo: [0]->{"after": {"a": 0, "b": "dog"}}`,
+		})
+		assertPayloads(t, fooSplit, []string{
+			`foo.f_a: [0]->{"after": {"a": 0}}`,
+			`foo.f_b: [0]->{"after": {"a": 0, "b": "dog"}}`,
+		})
+		assertPayloads(t, fooDiff, []string{
+			`foo: [0]->{"after": {"a": 0, "b": "dog"}, "before": null}`,
+		})
+
+		// Only the modified family is emitted in split mode.
+		sqlDB.Exec(t, `UPDATE foo SET b = 'cat' WHERE a = 0`)
+		assertPayloads(t, foo, []string{
+			`foo: [0]->{"after": {"a": 0, "b": "cat"}}`,
+		})
+		assertPayloads(t, fooSplit, []string{
+			`foo.f_b: [0]->{"after": {"a": 0, "b": "cat"}}`,
+		})
+		assertPayloads(t, fooDiff, []string{
+			`foo: [0]->{"after": {"a": 0, "b": "cat"}, "before": {"a": 0, "b": "dog"}}`,
+		})
+
+		sqlDB.Exec(t, `DELETE FROM foo WHERE a = 0`)
+		assertPayloads(t, foo, []string{
+			`foo: [0]->{"after": null}`,
+		})
+		assertPayloads(t, fooSplit, []string{
+			`foo.f_a: [0]->{"after": null}`,
+			`foo.f_b: [0]->{"after": null}`,
+		})
+		assertPayloads(t, fooDiff, []string{
+			`foo: [0]->{"after": null, "before": {"a": 0, "b": "cat"}}`,
+		})
 
 		// Table with a second column family added after the changefeed starts.
 		sqlDB.Exec(t, `CREATE TABLE bar (a INT PRIMARY KEY, FAMILY f_a (a))`)
@@ -968,10 +1186,63 @@ func TestChangefeedColumnFamily(t *testing.T) {
 			`bar: [0]->{"after": {"a": 0}}`,
 		})
 		sqlDB.Exec(t, `ALTER TABLE bar ADD COLUMN b STRING CREATE FAMILY f_b`)
-		sqlDB.Exec(t, `INSERT INTO bar VALUES (1)`)
-		if _, err := bar.Next(); !testutils.IsError(err, `exactly 1 column family`) {
-			t.Errorf(`expected "exactly 1 column family" error got: %+v`, err)
-		}
+		sqlDB.Exec(t, `INSERT INTO bar VALUES (1, 'dog')`)
+		assertPayloads(t, bar, []string{
+			`bar: [1]->{"after": {"a": 1, "b": "dog"}}`,
+		})
+	}
+
+	t.Run(`sinkless`, sinklessTest(testFn))
+	t.Run(`enterprise`, enterpriseTest(testFn))
+}
+
+func TestChangefeedSelect(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testFn := func(t *testing.T, db *gosql.DB, f cdctest.TestFeedFactory) {
+		sqlDB := sqlutils.MakeSQLRunner(db)
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY, b STRING, c INT)`)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (0, 'dog', 1), (1, 'cat', 2)`)
+
+		// The rows that don't satisfy the WHERE clause are filtered out, and the
+		// values of the others are replaced with the projection. The keys are
+		// still the primary keys of the rows.
+		foo := feed(t, f, `CREATE CHANGEFEED AS SELECT b, c * 10 AS c10 FROM foo WHERE c > 1`)
+		defer closeFeed(t, foo)
+		assertPayloads(t, foo, []string{
+			`foo: [1]->{"after": {"b": "cat", "c10": 20}}`,
+		})
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (2, 'mouse', 0), (3, 'bird', 3)`)
+		assertPayloads(t, foo, []string{
+			`foo: [3]->{"after": {"b": "bird", "c10": 30}}`,
+		})
+
+		// The previous value of the row is available with the diff option, and
+		// the previous value is projected as well.
+		fooPrev := feed(t, f, `CREATE CHANGEFEED WITH diff, no_initial_scan `+
+			`AS SELECT *, cdc_prev()->>'b' AS prev_b FROM foo WHERE NOT cdc_is_delete()`)
+		defer closeFeed(t, fooPrev)
+		sqlDB.Exec(t, `UPDATE foo SET b = 'lion' WHERE a = 1`)
+		assertPayloads(t, fooPrev, []string{
+			`foo: [1]->{"after": {"a": 1, "b": "lion", "c": 2, "prev_b": "cat"}, ` +
+				`"before": {"a": 1, "b": "cat", "c": 2, "prev_b": "cat"}}`,
+		})
+		// Deletions are filtered out by the WHERE clause.
+		sqlDB.Exec(t, `DELETE FROM foo WHERE a = 1`)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (4, 'fish', 4)`)
+		assertPayloads(t, fooPrev, []string{
+			`foo: [4]->{"after": {"a": 4, "b": "fish", "c": 4, "prev_b": null}, "before": null}`,
+		})
+
+		// The MVCC timestamp of the change is available.
+		fooTS := feed(t, f, `CREATE CHANGEFEED WITH updated `+
+			`AS SELECT a, cdc_mvcc_timestamp() AS ts FROM foo WHERE a = 0`)
+		defer closeFeed(t, fooTS)
+		msgs := readNextMessages(t, fooTS, 1, false /* stripTs */)
+		m := regexp.MustCompile(`"ts": ([0-9.]+)}, "updated": "([0-9.]+)"`).FindStringSubmatch(msgs[0])
+		require.NotNil(t, m, msgs[0])
+		require.Equal(t, m[2], m[1])
 	}
 
 	t.Run(`sinkless`, sinklessTest(testFn))
@@ -1569,6 +1840,68 @@ func TestChangefeedRetryableError(t *testing.T) {
 	t.Run(`enterprise`, enterpriseTest(testFn))
 }
 
+// TestChangefeedBackfillCheckpoint verifies that a changefeed which restarts
+// during its initial scan does not scan the spans it checkpointed again.
+func TestChangefeedBackfillCheckpoint(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	defer utilccl.TestingEnableEnterprise()()
+
+	testFn := func(t *testing.T, db *gosql.DB, f cdctest.TestFeedFactory) {
+		sqlDB := sqlutils.MakeSQLRunner(db)
+		sqlDB.Exec(t, `SET CLUSTER SETTING changefeed.frontier_checkpoint_frequency = '10ms'`)
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY)`)
+		sqlDB.Exec(t, `INSERT INTO foo SELECT generate_series(1, 1000)`)
+		sqlDB.Exec(t, `ALTER TABLE foo SPLIT AT SELECT generate_series(100, 900, 100)`)
+
+		knobs := f.Server().(*server.TestServer).Cfg.TestingKnobs.
+			DistSQL.(*execinfra.TestingKnobs).
+			Changefeed.(*TestingKnobs)
+		// Slow down the emission of the rows so that the changefeed restarts
+		// during its initial scan, and count the rows emitted since the restart.
+		var failEmit, emitted int64
+		knobs.BeforeEmitRow = func(_ context.Context) error {
+			if atomic.CompareAndSwapInt64(&failEmit, 1, 0) {
+				atomic.StoreInt64(&emitted, 0)
+				return MarkRetryableError(errors.New("synthetic retryable error"))
+			}
+			atomic.AddInt64(&emitted, 1)
+			time.Sleep(time.Millisecond)
+			return nil
+		}
+
+		foo := feed(t, f, `CREATE CHANGEFEED FOR foo WITH resolved='10ms'`)
+		defer closeFeed(t, foo)
+
+		// Wait for some spans to be checkpointed, then restart the changefeed.
+		jobID := foo.(*cdctest.TableFeed).JobID
+		testutils.SucceedsSoon(t, func() error {
+			var progressBytes []byte
+			sqlDB.QueryRow(t, `SELECT progress FROM system.jobs WHERE id = $1`, jobID).Scan(&progressBytes)
+			var progress jobspb.Progress
+			if err := protoutil.Unmarshal(progressBytes, &progress); err != nil {
+				return err
+			}
+			if len(progress.GetChangefeed().Checkpoint) == 0 {
+				return errors.New("no spans checkpointed yet")
+			}
+			return nil
+		})
+		atomic.StoreInt64(&failEmit, 1)
+
+		var expected []string
+		for i := 1; i <= 1000; i++ {
+			expected = append(expected, fmt.Sprintf(`foo: [%d]->{"after": {"a": %d}}`, i, i))
+		}
+		assertPayloads(t, foo, expected)
+		require.Less(t, atomic.LoadInt64(&emitted), int64(1000),
+			"the checkpointed spans were scanned again")
+	}
+
+	// Only the enterprise version uses jobs.
+	t.Run(`enterprise`, enterpriseTest(testFn))
+}
+
 // TestChangefeedDataTTL ensures that changefeeds fail with an error in the case
 // where the feed has fallen behind the GC TTL of the table data.
 func TestChangefeedDataTTL(t *testing.T) {
@@ -1855,6 +2188,11 @@ func TestChangefeedErrors(t *testing.T) {
 		`CREATE CHANGEFEED FOR foo INTO $1`, `kafka://nope/?schema_topic=foo`,
 	)
 
+	sqlDB.ExpectErr(
+		t, `param topic_name contains an unknown placeholder: {db}`,
+		`CREATE CHANGEFEED FOR foo INTO $1`, `kafka://nope/?topic_name={db}.{table}`,
+	)
+
 	// Sanity check kafka tls parameters.
 	sqlDB.ExpectErr(
 		t, `param tls_enabled must be a bool`,
@@ -1941,6 +2279,58 @@ func TestChangefeedErrors(t *testing.T) {
 		`CREATE CHANGEFEED FOR foo INTO $1 WITH envelope='key_only'`,
 		`experimental-nodelocal://0/bar`,
 	)
+	sqlDB.ExpectErr(
+		t, `diff is not supported with format=parquet`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH format='parquet', diff`,
+		`experimental-nodelocal://0/bar`,
+	)
+	sqlDB.ExpectErr(
+		t, `format=parquet is only supported by cloud storage sinks`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH format='parquet'`,
+		`kafka://nope`,
+	)
+
+	sqlDB.ExpectErr(
+		t, `format=csv is only supported by cloud storage and webhook sinks`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH format='csv', envelope='bare'`,
+		`kafka://nope`,
+	)
+	sqlDB.ExpectErr(
+		t, `envelope=wrapped is not supported with format=csv`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH format='csv'`,
+		`experimental-nodelocal://0/bar`,
+	)
+	sqlDB.ExpectErr(
+		t, `envelope=bare is only usable with format=csv`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH envelope='bare'`,
+		`experimental-nodelocal://0/bar`,
+	)
+
+	// So is the webhookSink.
+	sqlDB.ExpectErr(
+		t, `this sink is incompatible with envelope=key_only`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH envelope='key_only'`,
+		`webhook-https://nope/feed`,
+	)
+	sqlDB.ExpectErr(
+		t, `param insecure_tls_skip_verify must be a bool`,
+		`CREATE CHANGEFEED FOR foo INTO $1`, `webhook-https://nope/feed?insecure_tls_skip_verify=maybe`,
+	)
+
+	// And the pubsubSink.
+	sqlDB.ExpectErr(
+		t, `missing project in gcpubsub sink URI`,
+		`CREATE CHANGEFEED FOR foo INTO $1`, `gcpubsub://?AUTH=implicit`,
+	)
+	sqlDB.ExpectErr(
+		t, `this sink is incompatible with format=experimental_avro`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH format='experimental_avro', confluent_schema_registry=$2`,
+		`gcpubsub://nope?AUTH=implicit`, `schemareg-nope`,
+	)
+	sqlDB.ExpectErr(
+		t, `unsupported value nope for AUTH`,
+		`CREATE CHANGEFEED FOR foo INTO $1`, `gcpubsub://nope?AUTH=nope`,
+	)
 
 	// WITH key_in_value requires envelope=wrapped
 	sqlDB.ExpectErr(
@@ -1971,6 +2361,60 @@ func TestChangefeedErrors(t *testing.T) {
 		t, `cannot specify both initial_scan and no_initial_scan`,
 		`CREATE CHANGEFEED FOR foo INTO $1 WITH no_initial_scan, initial_scan`, `kafka://nope`,
 	)
+
+	// WITH end_time must be after the cursor.
+	sqlDB.ExpectErr(
+		t, `end_time must be after the statement time or the cursor`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH cursor='2020-01-02', end_time='2020-01-01'`, `kafka://nope`,
+	)
+	sqlDB.ExpectErr(
+		t, `invalid end_time`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH end_time='nope'`, `kafka://nope`,
+	)
+	sqlDB.ExpectErr(
+		t, `cannot specify both initial_scan_only and no_initial_scan`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH initial_scan_only, no_initial_scan`, `kafka://nope`,
+	)
+	sqlDB.ExpectErr(
+		t, `cannot specify both initial_scan_only and end_time`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH initial_scan_only, end_time='2100-01-01'`, `kafka://nope`,
+	)
+	sqlDB.ExpectErr(
+		t, `column "nope" of key_columns does not exist in table "foo"`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH key_columns='b,nope'`, `kafka://nope`,
+	)
+	sqlDB.ExpectErr(
+		t, `column "b" of key_columns is not part of the primary key of table "foo", which requires the diff option`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH key_columns='a,b'`, `kafka://nope`,
+	)
+	sqlDB.ExpectErr(
+		t, `cannot specify both key_columns and split_column_families`,
+		`CREATE CHANGEFEED FOR foo INTO $1 WITH key_columns='b', split_column_families`, `kafka://nope`,
+	)
+
+	// The query of a changefeed may only use row-local, immutable expressions.
+	for _, tc := range []struct {
+		query string
+		err   string
+	}{
+		{`SELECT a, random() FROM foo`, `volatile functions are not allowed in CHANGEFEED`},
+		{`SELECT a FROM foo WHERE now() > '2020-01-01'`,
+			`context-dependent operators are not allowed in CHANGEFEED`},
+		{`SELECT a FROM foo WHERE a IN (SELECT 1)`, `subqueries are not allowed in CHANGEFEED`},
+		{`SELECT max(a) FROM foo`, `aggregate functions are not allowed in CHANGEFEED`},
+		{`SELECT z FROM foo`, `column "z" does not exist`},
+		{`SELECT a FROM foo WHERE a`, `argument of WHERE must be type bool, not type int`},
+		{`SELECT a, b AS a FROM foo`, `duplicate column name "a" in changefeed query`},
+		{`SELECT cdc_is_delete(a) FROM foo`, `cdc_is_delete\(\) does not take arguments`},
+		{`SELECT cdc_prev() FROM foo`, `cdc_prev\(\) requires the diff option`},
+		{`SELECT a FROM bar`, `table "bar" does not exist`},
+	} {
+		sqlDB.ExpectErr(t, tc.err, `EXPERIMENTAL CHANGEFEED AS `+tc.query)
+	}
+	sqlDB.ExpectErr(
+		t, `split_column_families is not supported for changefeeds with a query`,
+		`EXPERIMENTAL CHANGEFEED WITH split_column_families AS SELECT a FROM foo`,
+	)
 }
 
 func TestChangefeedPermissions(t *testing.T) {
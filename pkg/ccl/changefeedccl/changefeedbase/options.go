@@ -28,8 +28,10 @@ type SchemaChangePolicy string
 const (
 	OptConfluentSchemaRegistry  = `confluent_schema_registry`
 	OptCursor                   = `cursor`
+	OptEndTime                  = `end_time`
 	OptEnvelope                 = `envelope`
 	OptFormat                   = `format`
+	OptKeyColumns               = `key_columns`
 	OptKeyInValue               = `key_in_value`
 	OptResolvedTimestamps       = `resolved`
 	OptUpdatedTimestamps        = `updated`
@@ -38,6 +40,10 @@ const (
 	OptSchemaChangeEvents       = `schema_change_events`
 	OptSchemaChangePolicy       = `schema_change_policy`
 	OptProtectDataFromGCOnPause = `protect_data_from_gc_on_pause`
+	OptSplitColumnFamilies      = `split_column_families`
+	OptWebhookAuthHeader        = `webhook_auth_header`
+	OptWebhookClientTimeout     = `webhook_client_timeout`
+	OptWebhookSinkConfig        = `webhook_sink_config`
 
 	// OptSchemaChangeEventClassColumnChange corresponds to all schema change
 	// events which add or remove any column.
@@ -67,25 +73,37 @@ const (
 	// cursor is specified. This option is useful to create a changefeed which
 	// subscribes only to new messages.
 	OptNoInitialScan = `no_initial_scan`
+	// OptInitialScanOnly enables an initial scan, after which the changefeed
+	// stops. Such a changefeed exports a consistent snapshot of its targets at
+	// the statement time, or at the cursor timestamp.
+	OptInitialScanOnly = `initial_scan_only`
 
+	OptEnvelopeBare          EnvelopeType = `bare`
 	OptEnvelopeKeyOnly       EnvelopeType = `key_only`
 	OptEnvelopeRow           EnvelopeType = `row`
 	OptEnvelopeDeprecatedRow EnvelopeType = `deprecated_row`
 	OptEnvelopeWrapped       EnvelopeType = `wrapped`
 
-	OptFormatJSON FormatType = `json`
-	OptFormatAvro FormatType = `experimental_avro`
+	OptFormatCSV     FormatType = `csv`
+	OptFormatJSON    FormatType = `json`
+	OptFormatAvro    FormatType = `experimental_avro`
+	OptFormatParquet FormatType = `parquet`
 
 	SinkParamCACert           = `ca_cert`
 	SinkParamClientCert       = `client_cert`
 	SinkParamClientKey        = `client_key`
 	SinkParamFileSize         = `file_size`
+	SinkParamRegion           = `region`
 	SinkParamSchemaTopic      = `schema_topic`
+	SinkParamSkipTLSVerify    = `insecure_tls_skip_verify`
 	SinkParamTLSEnabled       = `tls_enabled`
+	SinkParamTopicName        = `topic_name`
 	SinkParamTopicPrefix      = `topic_prefix`
 	SinkSchemeBuffer          = ``
 	SinkSchemeExperimentalSQL = `experimental-sql`
+	SinkSchemeGCPubsub        = `gcpubsub`
 	SinkSchemeKafka           = `kafka`
+	SinkSchemeWebhookHTTPS    = `webhook-https`
 	SinkParamSASLEnabled      = `sasl_enabled`
 	SinkParamSASLHandshake    = `sasl_handshake`
 	SinkParamSASLUser         = `sasl_user`
@@ -97,8 +115,10 @@ const (
 var ChangefeedOptionExpectValues = map[string]sql.KVStringOptValidate{
 	OptConfluentSchemaRegistry:  sql.KVStringOptRequireValue,
 	OptCursor:                   sql.KVStringOptRequireValue,
+	OptEndTime:                  sql.KVStringOptRequireValue,
 	OptEnvelope:                 sql.KVStringOptRequireValue,
 	OptFormat:                   sql.KVStringOptRequireValue,
+	OptKeyColumns:               sql.KVStringOptRequireValue,
 	OptKeyInValue:               sql.KVStringOptRequireNoValue,
 	OptResolvedTimestamps:       sql.KVStringOptAny,
 	OptUpdatedTimestamps:        sql.KVStringOptRequireNoValue,
@@ -108,5 +128,10 @@ var ChangefeedOptionExpectValues = map[string]sql.KVStringOptValidate{
 	OptSchemaChangePolicy:       sql.KVStringOptRequireValue,
 	OptInitialScan:              sql.KVStringOptRequireNoValue,
 	OptNoInitialScan:            sql.KVStringOptRequireNoValue,
+	OptInitialScanOnly:          sql.KVStringOptRequireNoValue,
 	OptProtectDataFromGCOnPause: sql.KVStringOptRequireNoValue,
+	OptSplitColumnFamilies:      sql.KVStringOptRequireNoValue,
+	OptWebhookAuthHeader:        sql.KVStringOptRequireValue,
+	OptWebhookClientTimeout:     sql.KVStringOptRequireValue,
+	OptWebhookSinkConfig:        sql.KVStringOptRequireValue,
 }
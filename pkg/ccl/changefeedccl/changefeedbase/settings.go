@@ -24,3 +24,20 @@ var TableDescriptorPollInterval = settings.RegisterNonNegativeDurationSetting(
 	"polling interval for the table descriptors",
 	1*time.Second,
 )
+
+// FrontierCheckpointFrequency controls how often the change frontier persists
+// the spans that have been scanned by a backfill in progress, so that a resumed
+// changefeed does not scan them again.
+var FrontierCheckpointFrequency = settings.RegisterNonNegativeDurationSetting(
+	"changefeed.frontier_checkpoint_frequency",
+	"controls the frequency with which span level checkpoints will be written when a changefeed is backfilling; if 0, disabled",
+	10*time.Second,
+)
+
+// FrontierCheckpointMaxBytes controls the maximum size of the span level
+// checkpoints of the changefeeds.
+var FrontierCheckpointMaxBytes = settings.RegisterByteSizeSetting(
+	"changefeed.frontier_checkpoint_max_bytes",
+	"controls the maximum size of the span level checkpoints, as the total size of the keys of their spans",
+	1<<20, // 1 MiB
+)
@@ -0,0 +1,123 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/errors"
+)
+
+// csvEncoder encodes the rows of the changefeeds with format=csv, which only
+// support envelope=bare. Values are CSV records with a field per column of the
+// table, followed by the metadata columns (see metadataColumns), without the
+// record terminator, which is up to the sink. NULLs are empty fields, and the
+// other datums are formatted like the CSV files of EXPORT. Keys are not
+// encoded since the primary key columns are part of the records.
+type csvEncoder struct {
+	updatedField bool
+
+	alloc  sqlbase.DatumAlloc
+	fmtCtx *tree.FmtCtx
+	record []string
+	buf    bytes.Buffer
+	w      *csv.Writer
+}
+
+var _ Encoder = &csvEncoder{}
+
+func newCSVEncoder(opts map[string]string) (*csvEncoder, error) {
+	if changefeedbase.EnvelopeType(opts[changefeedbase.OptEnvelope]) != changefeedbase.OptEnvelopeBare {
+		return nil, errors.Errorf(`%s=%s is not supported with %s=%s`,
+			changefeedbase.OptEnvelope, opts[changefeedbase.OptEnvelope],
+			changefeedbase.OptFormat, changefeedbase.OptFormatCSV)
+	}
+	for _, opt := range []string{
+		changefeedbase.OptDiff, changefeedbase.OptKeyColumns, changefeedbase.OptKeyInValue,
+	} {
+		if _, ok := opts[opt]; ok {
+			return nil, errors.Errorf(`%s is not supported with %s=%s`,
+				opt, changefeedbase.OptFormat, changefeedbase.OptFormatCSV)
+		}
+	}
+	e := &csvEncoder{fmtCtx: tree.NewFmtCtx(tree.FmtExport)}
+	e.w = csv.NewWriter(&e.buf)
+	_, e.updatedField = opts[changefeedbase.OptUpdatedTimestamps]
+	return e, nil
+}
+
+// EncodeKey implements the Encoder interface.
+func (e *csvEncoder) EncodeKey(context.Context, encodeRow) ([]byte, error) {
+	return nil, nil
+}
+
+// EncodeValue implements the Encoder interface.
+func (e *csvEncoder) EncodeValue(_ context.Context, row encodeRow) ([]byte, error) {
+	e.record = e.record[:0]
+	columns := row.tableDesc.Columns
+	for i := range columns {
+		datum := row.datums[i]
+		// Only the primary key columns of deleted rows are guaranteed to be set.
+		if (row.deleted && datum.IsUnset()) || datum.IsNull() {
+			e.record = append(e.record, ``)
+			continue
+		}
+		if err := datum.EnsureDecoded(columns[i].Type, &e.alloc); err != nil {
+			return nil, err
+		}
+		datum.Datum.Format(e.fmtCtx)
+		e.record = append(e.record, e.fmtCtx.String())
+		e.fmtCtx.Reset()
+	}
+	e.record = append(e.record, eventType(row))
+	if e.updatedField {
+		e.record = append(e.record, row.updated.AsOfSystemTime())
+	}
+	return e.writeRecord(e.record)
+}
+
+// writeRecord returns the given record in CSV, without its terminator.
+func (e *csvEncoder) writeRecord(record []string) ([]byte, error) {
+	e.buf.Reset()
+	if err := e.w.Write(record); err != nil {
+		return nil, err
+	}
+	e.w.Flush()
+	if err := e.w.Error(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(e.buf.Bytes(), []byte{'\n'}), nil
+}
+
+// EncodeResolvedTimestamp implements the Encoder interface. Resolved
+// timestamps are JSON, since they have no place in the CSV records.
+func (e *csvEncoder) EncodeResolvedTimestamp(
+	_ context.Context, _ string, resolved hlc.Timestamp,
+) ([]byte, error) {
+	return encodeResolvedTimestampJSON(resolved)
+}
+
+// csvHeader returns the header record of the CSV files that the cloud storage
+// sink writes the rows of the given table to, without its terminator.
+func csvHeader(tableDesc *sqlbase.TableDescriptor, withUpdated bool) ([]byte, error) {
+	var names []string
+	for i := range tableDesc.Columns {
+		names = append(names, tableDesc.Columns[i].Name)
+	}
+	names = append(names, metadataColumns(withUpdated)...)
+	e := &csvEncoder{}
+	e.w = csv.NewWriter(&e.buf)
+	return e.writeRecord(names)
+}
@@ -16,6 +16,7 @@ import (
 	"io/ioutil"
 	"net/url"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
@@ -37,6 +38,20 @@ const (
 	confluentAvroWireFormatMagic = byte(0)
 )
 
+// The formats with a column per column of the table, parquet and csv, append
+// metadata columns to the columns of the table (see metadataColumns).
+const (
+	// eventTypeColumn holds the type of the change of each row: eventUpsert
+	// or eventDelete.
+	eventTypeColumn = `__crdb__event_type`
+	// updatedColumn holds the updated timestamp of each row, with the updated
+	// option.
+	updatedColumn = `__crdb__updated`
+
+	eventUpsert = `upsert`
+	eventDelete = `delete`
+)
+
 // encodeRow holds all the pieces necessary to encode a row change into a key or
 // value.
 type encodeRow struct {
@@ -59,6 +74,35 @@ type encodeRow struct {
 	// prevTableDesc is a TableDescriptor for the table containing `prevDatums`.
 	// It's valid for interpreting the row at `updated.Prev()`.
 	prevTableDesc *sqlbase.TableDescriptor
+	// familyID is the column family that the row is restricted to, with
+	// split_column_families. In this case, tableDesc and prevTableDesc are
+	// restricted to the family as well (see familyTableDesc).
+	familyID sqlbase.FamilyID
+	// keyDatums and keyTableDesc are the row and the descriptor that the key is
+	// encoded from, if datums and tableDesc are the results of the query of the
+	// changefeed (see changefeedSelect). They are nil otherwise. Likewise,
+	// keyPrevDatums and keyPrevTableDesc are those of the previous value.
+	keyDatums, keyPrevDatums       sqlbase.EncDatumRow
+	keyTableDesc, keyPrevTableDesc *sqlbase.TableDescriptor
+}
+
+// keySource returns the row and the descriptor that the key of the row is
+// encoded from. Only the primary key columns of a deleted row are set, so with
+// the key_columns option, the key of a deleted row is encoded from its
+// previous value, if it is set (see validateKeyColumns).
+func (r *encodeRow) keySource(
+	withKeyColumns bool,
+) (sqlbase.EncDatumRow, *sqlbase.TableDescriptor) {
+	datums, tableDesc := r.datums, r.tableDesc
+	prevDatums, prevTableDesc := r.prevDatums, r.prevTableDesc
+	if r.keyTableDesc != nil {
+		datums, tableDesc = r.keyDatums, r.keyTableDesc
+		prevDatums, prevTableDesc = r.keyPrevDatums, r.keyPrevTableDesc
+	}
+	if withKeyColumns && r.deleted && !r.prevDeleted && prevDatums != nil {
+		return prevDatums, prevTableDesc
+	}
+	return datums, tableDesc
 }
 
 // Encoder turns a row into a serialized changefeed key, value, or resolved
@@ -86,17 +130,133 @@ func getEncoder(opts map[string]string) (Encoder, error) {
 		return makeJSONEncoder(opts)
 	case changefeedbase.OptFormatAvro:
 		return newConfluentAvroEncoder(opts)
+	case changefeedbase.OptFormatParquet:
+		return newParquetEncoder(opts)
+	case changefeedbase.OptFormatCSV:
+		return newCSVEncoder(opts)
 	default:
 		return nil, errors.Errorf(`unknown %s: %s`, changefeedbase.OptFormat, opts[changefeedbase.OptFormat])
 	}
 }
 
+// parseKeyColumns returns the names of the columns of the key_columns option,
+// which replace the primary key columns in the keys of the rows. It returns
+// nil if the option is not set.
+func parseKeyColumns(opts map[string]string) ([]string, error) {
+	o, ok := opts[changefeedbase.OptKeyColumns]
+	if !ok {
+		return nil, nil
+	}
+	names := strings.Split(o, `,`)
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+		if names[i] == `` {
+			return nil, errors.Errorf(`invalid %s: %q`, changefeedbase.OptKeyColumns, o)
+		}
+	}
+	return names, nil
+}
+
+// keyColumnIDs returns the IDs of the columns that the keys of the rows of the
+// given table are encoded from: the given key columns, if any, or else the
+// primary key columns.
+func keyColumnIDs(
+	tableDesc *sqlbase.TableDescriptor, keyColumns []string,
+) ([]sqlbase.ColumnID, error) {
+	if len(keyColumns) == 0 {
+		return tableDesc.PrimaryIndex.ColumnIDs, nil
+	}
+	ids := make([]sqlbase.ColumnID, len(keyColumns))
+	for i, name := range keyColumns {
+		col, err := tableDesc.FindActiveColumnByName(name)
+		if err != nil {
+			return nil, errors.Errorf(`column %q of %s does not exist in table %q`,
+				name, changefeedbase.OptKeyColumns, tableDesc.Name)
+		}
+		ids[i] = col.ID
+	}
+	return ids, nil
+}
+
+// validateKeyColumns checks that the given key columns exist in the given
+// table. Only the primary key columns of a deleted row are set, so the keys of
+// deletions are encoded from the previous values of the rows (see
+// encodeRow.keySource). The key columns that are not part of the primary key
+// thus require the diff option.
+func validateKeyColumns(
+	tableDesc *sqlbase.TableDescriptor, keyColumns []string, opts map[string]string,
+) error {
+	colIDs, err := keyColumnIDs(tableDesc, keyColumns)
+	if err != nil {
+		return err
+	}
+	if _, ok := opts[changefeedbase.OptDiff]; ok || len(keyColumns) == 0 {
+		return nil
+	}
+	for i, colID := range colIDs {
+		if !tableDesc.PrimaryIndex.ContainsColumnID(colID) {
+			return errors.Errorf(
+				`column %q of %s is not part of the primary key of table %q, which requires the %s option`,
+				keyColumns[i], changefeedbase.OptKeyColumns, tableDesc.Name, changefeedbase.OptDiff)
+		}
+	}
+	return nil
+}
+
+// unsetAsNull returns the given row, with NULLs in place of its unset datums.
+// The key of a deleted row whose previous value is missing is encoded from
+// the row itself, in which case its key columns that are not part of the
+// primary key are NULL.
+func unsetAsNull(datums sqlbase.EncDatumRow) sqlbase.EncDatumRow {
+	var ret sqlbase.EncDatumRow
+	for i := range datums {
+		if datums[i].IsUnset() {
+			if ret == nil {
+				ret = append(sqlbase.EncDatumRow(nil), datums...)
+			}
+			ret[i] = sqlbase.EncDatum{Datum: tree.DNull}
+		}
+	}
+	if ret == nil {
+		return datums
+	}
+	return ret
+}
+
+// metadataColumns returns the names of the metadata columns which follow the
+// columns of the table in the rows of the parquet and csv formats.
+func metadataColumns(withUpdated bool) []string {
+	if withUpdated {
+		return []string{eventTypeColumn, updatedColumn}
+	}
+	return []string{eventTypeColumn}
+}
+
+// eventType returns the value of the eventTypeColumn of the given row.
+func eventType(row encodeRow) string {
+	if row.deleted {
+		return eventDelete
+	}
+	return eventUpsert
+}
+
+// encodeResolvedTimestampJSON encodes a resolved timestamp payload like the
+// JSON encoder with envelope=wrapped. It is used by the formats whose rows
+// can't hold a resolved timestamp.
+func encodeResolvedTimestampJSON(resolved hlc.Timestamp) ([]byte, error) {
+	return gojson.Marshal(map[string]interface{}{
+		`resolved`: tree.TimestampToDecimalDatum(resolved).Decimal.String(),
+	})
+}
+
 // jsonEncoder encodes changefeed entries as JSON. Keys are the primary key
-// columns in a JSON array. Values are a JSON object mapping every column name
-// to its value. Updated timestamps in rows and resolved timestamp payloads are
-// stored in a sub-object under the `__crdb__` key in the top-level JSON object.
+// columns, or the columns of the key_columns option, in a JSON array. Values
+// are a JSON object mapping every column name to its value. Updated timestamps
+// in rows and resolved timestamp payloads are stored in a sub-object under the
+// `__crdb__` key in the top-level JSON object.
 type jsonEncoder struct {
 	updatedField, beforeField, wrapped, keyOnly, keyInValue bool
+	keyColumns                                              []string
 
 	alloc sqlbase.DatumAlloc
 	buf   bytes.Buffer
@@ -105,6 +265,11 @@ type jsonEncoder struct {
 var _ Encoder = &jsonEncoder{}
 
 func makeJSONEncoder(opts map[string]string) (*jsonEncoder, error) {
+	if changefeedbase.EnvelopeType(opts[changefeedbase.OptEnvelope]) == changefeedbase.OptEnvelopeBare {
+		return nil, errors.Errorf(`%s=%s is only usable with %s=%s`,
+			changefeedbase.OptEnvelope, changefeedbase.OptEnvelopeBare,
+			changefeedbase.OptFormat, changefeedbase.OptFormatCSV)
+	}
 	e := &jsonEncoder{
 		keyOnly: changefeedbase.EnvelopeType(opts[changefeedbase.OptEnvelope]) == changefeedbase.OptEnvelopeKeyOnly,
 		wrapped: changefeedbase.EnvelopeType(opts[changefeedbase.OptEnvelope]) == changefeedbase.OptEnvelopeWrapped,
@@ -120,6 +285,10 @@ func makeJSONEncoder(opts map[string]string) (*jsonEncoder, error) {
 		return nil, errors.Errorf(`%s is only usable with %s=%s`,
 			changefeedbase.OptKeyInValue, changefeedbase.OptEnvelope, changefeedbase.OptEnvelopeWrapped)
 	}
+	var err error
+	if e.keyColumns, err = parseKeyColumns(opts); err != nil {
+		return nil, err
+	}
 	return e, nil
 }
 
@@ -139,18 +308,25 @@ func (e *jsonEncoder) EncodeKey(_ context.Context, row encodeRow) ([]byte, error
 }
 
 func (e *jsonEncoder) encodeKeyRaw(row encodeRow) ([]interface{}, error) {
-	colIdxByID := row.tableDesc.ColumnIdxMap()
-	jsonEntries := make([]interface{}, len(row.tableDesc.PrimaryIndex.ColumnIDs))
-	for i, colID := range row.tableDesc.PrimaryIndex.ColumnIDs {
+	datums, tableDesc := row.keySource(len(e.keyColumns) > 0)
+	colIDs, err := keyColumnIDs(tableDesc, e.keyColumns)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.keyColumns) > 0 {
+		datums = unsetAsNull(datums)
+	}
+	colIdxByID := tableDesc.ColumnIdxMap()
+	jsonEntries := make([]interface{}, len(colIDs))
+	for i, colID := range colIDs {
 		idx, ok := colIdxByID[colID]
 		if !ok {
 			return nil, errors.Errorf(`unknown column id: %d`, colID)
 		}
-		datum, col := row.datums[idx], &row.tableDesc.Columns[idx]
+		datum, col := datums[idx], &tableDesc.Columns[idx]
 		if err := datum.EnsureDecoded(col.Type, &e.alloc); err != nil {
 			return nil, err
 		}
-		var err error
 		jsonEntries[i], err = tree.AsJSON(datum.Datum, time.UTC)
 		if err != nil {
 			return nil, err
@@ -265,22 +441,31 @@ func (e *jsonEncoder) EncodeResolvedTimestamp(
 }
 
 // confluentAvroEncoder encodes changefeed entries as Avro's binary or textual
-// JSON format. Keys are the primary key columns in a record. Values are all
-// columns in a record.
+// JSON format. Keys are the primary key columns, or the columns of the
+// key_columns option, in a record. Values are all columns in a record.
 type confluentAvroEncoder struct {
 	registryURL                        string
 	updatedField, beforeField, keyOnly bool
+	keyColumns                         []string
 
 	keyCache      map[tableIDAndVersion]confluentRegisteredKeySchema
 	valueCache    map[tableIDAndVersionPair]confluentRegisteredEnvelopeSchema
 	resolvedCache map[string]confluentRegisteredEnvelopeSchema
 }
 
-type tableIDAndVersion uint64
+type tableIDAndVersion struct {
+	id      sqlbase.ID
+	version sqlbase.DescriptorVersion
+	// family is the column family of the rows, with split_column_families,
+	// which have a schema per family.
+	family sqlbase.FamilyID
+}
 type tableIDAndVersionPair [2]tableIDAndVersion // [before, after]
 
-func makeTableIDAndVersion(id sqlbase.ID, version sqlbase.DescriptorVersion) tableIDAndVersion {
-	return tableIDAndVersion(id)<<32 + tableIDAndVersion(version)
+func makeTableIDAndVersion(
+	id sqlbase.ID, version sqlbase.DescriptorVersion, family sqlbase.FamilyID,
+) tableIDAndVersion {
+	return tableIDAndVersion{id: id, version: version, family: family}
 }
 
 type confluentRegisteredKeySchema struct {
@@ -326,6 +511,10 @@ func newConfluentAvroEncoder(opts map[string]string) (*confluentAvroEncoder, err
 		return nil, errors.Errorf(`WITH option %s is required for %s=%s`,
 			changefeedbase.OptConfluentSchemaRegistry, changefeedbase.OptFormat, changefeedbase.OptFormatAvro)
 	}
+	var err error
+	if e.keyColumns, err = parseKeyColumns(opts); err != nil {
+		return nil, err
+	}
 
 	e.keyCache = make(map[tableIDAndVersion]confluentRegisteredKeySchema)
 	e.valueCache = make(map[tableIDAndVersionPair]confluentRegisteredEnvelopeSchema)
@@ -335,18 +524,22 @@ func newConfluentAvroEncoder(opts map[string]string) (*confluentAvroEncoder, err
 
 // EncodeKey implements the Encoder interface.
 func (e *confluentAvroEncoder) EncodeKey(ctx context.Context, row encodeRow) ([]byte, error) {
-	cacheKey := makeTableIDAndVersion(row.tableDesc.ID, row.tableDesc.Version)
+	datums, tableDesc := row.keySource(len(e.keyColumns) > 0)
+	cacheKey := makeTableIDAndVersion(tableDesc.ID, tableDesc.Version, row.familyID)
 	registered, ok := e.keyCache[cacheKey]
 	if !ok {
-		var err error
-		registered.schema, err = indexToAvroSchema(row.tableDesc, &row.tableDesc.PrimaryIndex)
+		colIDs, err := keyColumnIDs(tableDesc, e.keyColumns)
+		if err != nil {
+			return nil, err
+		}
+		registered.schema, err = indexToAvroSchema(tableDesc, &sqlbase.IndexDescriptor{ColumnIDs: colIDs})
 		if err != nil {
 			return nil, err
 		}
 
 		// NB: This uses the kafka name escaper because it has to match the name
 		// of the kafka topic.
-		subject := SQLNameToKafkaName(row.tableDesc.Name) + confluentSubjectSuffixKey
+		subject := SQLNameToKafkaName(tableDesc.Name) + confluentSubjectSuffixKey
 		registered.registryID, err = e.register(ctx, &registered.schema.avroRecord, subject)
 		if err != nil {
 			return nil, err
@@ -361,7 +554,10 @@ func (e *confluentAvroEncoder) EncodeKey(ctx context.Context, row encodeRow) ([]
 		0, 0, 0, 0, // Placeholder for the ID.
 	}
 	binary.BigEndian.PutUint32(header[1:5], uint32(registered.registryID))
-	return registered.schema.BinaryFromRow(header, row.datums)
+	if len(e.keyColumns) > 0 {
+		datums = unsetAsNull(datums)
+	}
+	return registered.schema.BinaryFromRow(header, datums)
 }
 
 // EncodeValue implements the Encoder interface.
@@ -372,9 +568,9 @@ func (e *confluentAvroEncoder) EncodeValue(ctx context.Context, row encodeRow) (
 
 	var cacheKey tableIDAndVersionPair
 	if e.beforeField && row.prevTableDesc != nil {
-		cacheKey[0] = makeTableIDAndVersion(row.prevTableDesc.ID, row.prevTableDesc.Version)
+		cacheKey[0] = makeTableIDAndVersion(row.prevTableDesc.ID, row.prevTableDesc.Version, row.familyID)
 	}
-	cacheKey[1] = makeTableIDAndVersion(row.tableDesc.ID, row.tableDesc.Version)
+	cacheKey[1] = makeTableIDAndVersion(row.tableDesc.ID, row.tableDesc.Version, row.familyID)
 	registered, ok := e.valueCache[cacheKey]
 	if !ok {
 		var beforeDataSchema *avroDataRecord
@@ -257,6 +257,180 @@ func TestEncoders(t *testing.T) {
 	}
 }
 
+func TestEncodersKeyColumns(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	tableDesc, err := parseTableDesc(`CREATE TABLE foo (a INT PRIMARY KEY, b STRING, c INT)`)
+	require.NoError(t, err)
+	row := sqlbase.EncDatumRow{
+		sqlbase.EncDatum{Datum: tree.NewDInt(1)},
+		sqlbase.EncDatum{Datum: tree.NewDString(`bar`)},
+		sqlbase.EncDatum{Datum: tree.NewDInt(3)},
+	}
+	// Only the primary key columns of deleted rows are set.
+	deletedRow := sqlbase.EncDatumRow{
+		sqlbase.EncDatum{Datum: tree.NewDInt(1)}, {}, {},
+	}
+
+	tests := []struct {
+		format changefeedbase.FormatType
+		// deleteWithoutPrev is the key of a deletion whose previous value is
+		// missing.
+		insert, deleteWithoutPrev string
+	}{
+		{
+			format:            changefeedbase.OptFormatJSON,
+			insert:            `[3, "bar"]`,
+			deleteWithoutPrev: `[null, null]`,
+		},
+		{
+			format:            changefeedbase.OptFormatAvro,
+			insert:            `{"b":{"string":"bar"},"c":{"long":3}}`,
+			deleteWithoutPrev: `{"b":null,"c":null}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(string(test.format), func(t *testing.T) {
+			opts := map[string]string{
+				changefeedbase.OptFormat:     string(test.format),
+				changefeedbase.OptEnvelope:   string(changefeedbase.OptEnvelopeWrapped),
+				changefeedbase.OptKeyColumns: `c, b`,
+			}
+			keyStringFn := func(k []byte) string { return string(k) }
+			if test.format == changefeedbase.OptFormatAvro {
+				reg := makeTestSchemaRegistry()
+				defer reg.Close()
+				opts[changefeedbase.OptConfluentSchemaRegistry] = reg.server.URL
+				keyStringFn = func(k []byte) string { return string(avroToJSON(t, reg, k)) }
+			}
+			e, err := getEncoder(opts)
+			require.NoError(t, err)
+
+			key, err := e.EncodeKey(context.Background(), encodeRow{
+				datums: row, tableDesc: tableDesc,
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.insert, keyStringFn(key))
+
+			// The key of a deletion is encoded from the previous value of the
+			// row.
+			key, err = e.EncodeKey(context.Background(), encodeRow{
+				datums: deletedRow, deleted: true, tableDesc: tableDesc,
+				prevDatums: row, prevTableDesc: tableDesc,
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.insert, keyStringFn(key))
+
+			key, err = e.EncodeKey(context.Background(), encodeRow{
+				datums: deletedRow, deleted: true, tableDesc: tableDesc,
+				prevDeleted: true, prevTableDesc: tableDesc,
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.deleteWithoutPrev, keyStringFn(key))
+
+			opts[changefeedbase.OptKeyColumns] = `c,nope`
+			e, err = getEncoder(opts)
+			require.NoError(t, err)
+			_, err = e.EncodeKey(context.Background(), encodeRow{datums: row, tableDesc: tableDesc})
+			require.EqualError(t, err, `column "nope" of key_columns does not exist in table "foo"`)
+		})
+	}
+
+	_, err = getEncoder(map[string]string{changefeedbase.OptKeyColumns: `c,,b`})
+	require.EqualError(t, err, `invalid key_columns: "c,,b"`)
+}
+
+func TestCSVEncoder(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	tableDesc, err := parseTableDesc(`CREATE TABLE foo (a INT PRIMARY KEY, b STRING, c BYTES)`)
+	require.NoError(t, err)
+	opts := map[string]string{
+		changefeedbase.OptFormat:   string(changefeedbase.OptFormatCSV),
+		changefeedbase.OptEnvelope: string(changefeedbase.OptEnvelopeBare),
+	}
+	e, err := getEncoder(opts)
+	require.NoError(t, err)
+
+	key, err := e.EncodeKey(ctx, encodeRow{})
+	require.NoError(t, err)
+	require.Nil(t, key)
+
+	value, err := e.EncodeValue(ctx, encodeRow{
+		datums: sqlbase.EncDatumRow{
+			sqlbase.EncDatum{Datum: tree.NewDInt(1)},
+			sqlbase.EncDatum{Datum: tree.NewDString("a \"quoted\",\nmultiline string")},
+			sqlbase.EncDatum{Datum: tree.DNull},
+		},
+		tableDesc: tableDesc,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "1,\"a \"\"quoted\"\",\nmultiline string\",,upsert", string(value))
+
+	// Only the primary key columns of deleted rows are set.
+	value, err = e.EncodeValue(ctx, encodeRow{
+		datums:    sqlbase.EncDatumRow{sqlbase.EncDatum{Datum: tree.NewDInt(2)}, {}, {}},
+		deleted:   true,
+		tableDesc: tableDesc,
+	})
+	require.NoError(t, err)
+	require.Equal(t, `2,,,delete`, string(value))
+
+	resolved, err := e.EncodeResolvedTimestamp(ctx, `foo`, hlc.Timestamp{WallTime: 1, Logical: 2})
+	require.NoError(t, err)
+	require.Equal(t, `{"resolved":"1.0000000002"}`, string(resolved))
+
+	opts[changefeedbase.OptUpdatedTimestamps] = ``
+	e, err = getEncoder(opts)
+	require.NoError(t, err)
+	value, err = e.EncodeValue(ctx, encodeRow{
+		datums:    sqlbase.EncDatumRow{sqlbase.EncDatum{Datum: tree.NewDInt(2)}, {}, {}},
+		deleted:   true,
+		updated:   hlc.Timestamp{WallTime: 1, Logical: 2},
+		tableDesc: tableDesc,
+	})
+	require.NoError(t, err)
+	require.Equal(t, `2,,,delete,1.0000000002`, string(value))
+
+	header, err := csvHeader(tableDesc, true /* withUpdated */)
+	require.NoError(t, err)
+	require.Equal(t, `a,b,c,__crdb__event_type,__crdb__updated`, string(header))
+
+	for _, test := range []struct {
+		opts map[string]string
+		err  string
+	}{
+		{
+			opts: map[string]string{
+				changefeedbase.OptFormat:   string(changefeedbase.OptFormatCSV),
+				changefeedbase.OptEnvelope: string(changefeedbase.OptEnvelopeWrapped),
+			},
+			err: `envelope=wrapped is not supported with format=csv`,
+		},
+		{
+			opts: map[string]string{
+				changefeedbase.OptFormat:     string(changefeedbase.OptFormatCSV),
+				changefeedbase.OptEnvelope:   string(changefeedbase.OptEnvelopeBare),
+				changefeedbase.OptKeyInValue: ``,
+			},
+			err: `key_in_value is not supported with format=csv`,
+		},
+		{
+			opts: map[string]string{
+				changefeedbase.OptFormat:   string(changefeedbase.OptFormatJSON),
+				changefeedbase.OptEnvelope: string(changefeedbase.OptEnvelopeBare),
+			},
+			err: `envelope=bare is only usable with format=csv`,
+		},
+	} {
+		_, err := getEncoder(test.opts)
+		require.EqualError(t, err, test.err)
+	}
+}
+
 type testSchemaRegistry struct {
 	server *httptest.Server
 	mu     struct {
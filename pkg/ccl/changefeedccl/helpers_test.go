@@ -345,6 +345,30 @@ func closeFeed(t testing.TB, f cdctest.TestFeed) {
 	}
 }
 
+// waitForFeedEnd waits for a changefeed with an end time to end, and checks
+// that it succeeded.
+func waitForFeedEnd(t testing.TB, sqlDB *sqlutils.SQLRunner, f cdctest.TestFeed) {
+	t.Helper()
+	if e, ok := f.(*cdctest.TableFeed); ok {
+		testutils.SucceedsSoon(t, func() error {
+			var status string
+			sqlDB.QueryRow(t, `SELECT status FROM system.jobs WHERE id = $1`, e.JobID).Scan(&status)
+			if status != "succeeded" {
+				return fmt.Errorf("job %d had status %s, wanted 'succeeded'", e.JobID, status)
+			}
+			return nil
+		})
+		return
+	}
+	// The statement of a sinkless changefeed returns once the changefeed ended.
+	m, err := f.Next()
+	if err != nil {
+		t.Fatal(err)
+	} else if m != nil {
+		t.Fatalf(`unexpected message %s: %s -> %s`, m.Topic, m.Key, m.Value)
+	}
+}
+
 func forceTableGC(
 	t testing.TB,
 	tsi serverutils.TestServerInterface,
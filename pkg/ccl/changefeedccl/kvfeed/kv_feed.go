@@ -20,6 +20,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/schemafeed"
 	"github.com/cockroachdb/cockroach/pkg/gossip"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/kvcoord"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -37,6 +38,7 @@ import (
 type Config struct {
 	Settings           *cluster.Settings
 	DB                 *kv.DB
+	Codec              keys.SQLCodec
 	Clock              *hlc.Clock
 	Gossip             gossip.DeprecatedGossip
 	Spans              []roachpb.Span
@@ -58,6 +60,15 @@ type Config struct {
 	// InitialHighWater is the timestamp from which new events are guaranteed to
 	// be produced.
 	InitialHighWater hlc.Timestamp
+
+	// EndTime, if set, is the timestamp at which the feed stops: once all the
+	// events up to and including it have been produced, all the spans are
+	// resolved at EndTime as a boundary and the feed exits.
+	EndTime hlc.Timestamp
+
+	// Checkpoint is the set of spans that the first scan of the feed, if any,
+	// skips because they were scanned before the changefeed was resumed.
+	Checkpoint []roachpb.Span
 }
 
 // Run will run the kvfeed. The feed runs synchronously and returns an
@@ -72,18 +83,22 @@ func Run(ctx context.Context, cfg Config) error {
 		g.GoCtx(rawSF.Run)
 		sf = rawSF
 	}
+	// NB: The DistSender of a secondary tenant proxies its range lookups and
+	// rangefeeds to the KV layer, so the scans and rangefeeds below work the
+	// same way in tenants as in the system tenant.
+	sender := cfg.DB.NonTransactionalSender()
+	distSender := sender.(*kv.CrossRangeTxnWrapperSender).Wrapped().(*kvcoord.DistSender)
 	var sc kvScanner
 	{
 		sc = &scanRequestScanner{
 			settings: cfg.Settings,
 			gossip:   cfg.Gossip,
 			db:       cfg.DB,
+			ds:       distSender,
 		}
 	}
 	var pff physicalFeedFactory
 	{
-		sender := cfg.DB.NonTransactionalSender()
-		distSender := sender.(*kv.CrossRangeTxnWrapperSender).Wrapped().(*kvcoord.DistSender)
 		pff = rangefeedFactory(distSender.RangeFeed)
 	}
 	bf := func() EventBuffer {
@@ -93,7 +108,7 @@ func Run(ctx context.Context, cfg Config) error {
 		cfg.Sink, cfg.Spans,
 		cfg.SchemaChangeEvents, cfg.SchemaChangePolicy,
 		cfg.NeedsInitialScan, cfg.WithDiff,
-		cfg.InitialHighWater,
+		cfg.InitialHighWater, cfg.EndTime, cfg.Checkpoint,
 		sf, sc, pff, bf)
 	g.GoCtx(f.run)
 	err := g.Wait()
@@ -101,10 +116,15 @@ func Run(ctx context.Context, cfg Config) error {
 	// policy and tear everything down. Returning before the higher layers tear
 	// down the changefeed exposes synchronization challenges.
 	var scErr schemaChangeDetectedError
+	var etErr *errEndTimeReached
 	if errors.As(err, &scErr) {
 		log.Infof(ctx, "stopping changefeed due to schema change at %v", scErr.ts)
 		<-ctx.Done()
 		err = nil
+	} else if errors.As(err, &etErr) {
+		log.Infof(ctx, "stopping changefeed at its end time %v", etErr.endTime)
+		<-ctx.Done()
+		err = nil
 	}
 	return err
 }
@@ -130,6 +150,8 @@ type kvFeed struct {
 	withDiff            bool
 	withInitialBackfill bool
 	initialHighWater    hlc.Timestamp
+	endTime             hlc.Timestamp
+	checkpoint          []roachpb.Span
 	sink                EventBufferWriter
 
 	schemaChangeEvents changefeedbase.SchemaChangeEventClass
@@ -148,7 +170,8 @@ func newKVFeed(
 	schemaChangeEvents changefeedbase.SchemaChangeEventClass,
 	schemaChangePolicy changefeedbase.SchemaChangePolicy,
 	withInitialBackfill, withDiff bool,
-	initialHighWater hlc.Timestamp,
+	initialHighWater, endTime hlc.Timestamp,
+	checkpoint []roachpb.Span,
 	tf schemaFeed,
 	sc kvScanner,
 	pff physicalFeedFactory,
@@ -160,6 +183,8 @@ func newKVFeed(
 		withInitialBackfill: withInitialBackfill,
 		withDiff:            withDiff,
 		initialHighWater:    initialHighWater,
+		endTime:             endTime,
+		checkpoint:          checkpoint,
 		schemaChangeEvents:  schemaChangeEvents,
 		schemaChangePolicy:  schemaChangePolicy,
 		tableFeed:           tf,
@@ -178,8 +203,15 @@ func (f *kvFeed) run(ctx context.Context) (err error) {
 		if err = f.scanIfShould(ctx, initialScan, highWater); err != nil {
 			return err
 		}
+		// The feed may have reached its end time already, e.g. if it only runs
+		// the initial scan or if it is resumed after it was done.
+		if !f.endTime.IsEmpty() && f.endTime.LessEq(highWater) {
+			return f.resolveEndTime(ctx)
+		}
 		highWater, err = f.runUntilTableEvent(ctx, highWater)
-		if err != nil {
+		if tErr := (*errEndTimeReached)(nil); errors.As(err, &tErr) {
+			return f.resolveEndTime(ctx)
+		} else if err != nil {
 			return err
 		}
 
@@ -199,6 +231,18 @@ func (f *kvFeed) run(ctx context.Context) (err error) {
 	}
 }
 
+// resolveEndTime resolves all the spans at the end time of the feed as a
+// boundary, which lets the higher layers know that the feed is done, and
+// returns errEndTimeReached.
+func (f *kvFeed) resolveEndTime(ctx context.Context) error {
+	for _, span := range f.spans {
+		if err := f.sink.AddResolved(ctx, span, f.endTime, true); err != nil {
+			return err
+		}
+	}
+	return &errEndTimeReached{endTime: f.endTime}
+}
+
 func (f *kvFeed) scanIfShould(
 	ctx context.Context, initialScan bool, highWater hlc.Timestamp,
 ) error {
@@ -236,8 +280,26 @@ func (f *kvFeed) scanIfShould(
 		return nil
 	}
 
+	// The checkpoint only applies to the first scan after the changefeed was
+	// resumed. The checkpointed spans are resolved at the scan time right away,
+	// like the scanner does once it is done with a span, which lets the
+	// changeFrontier include them in the next checkpoint.
+	spans := f.spans
+	if initialScan && len(f.checkpoint) > 0 {
+		var done []roachpb.Span
+		spans, done = filterCheckpointSpans(f.spans, f.checkpoint)
+		for _, sp := range done {
+			if err := f.sink.AddResolved(ctx, sp, scanTime, false); err != nil {
+				return err
+			}
+		}
+		if len(spans) == 0 {
+			return nil
+		}
+	}
+
 	if err := f.scanner.Scan(ctx, f.sink, physicalConfig{
-		Spans:     f.spans,
+		Spans:     spans,
 		Timestamp: scanTime,
 		WithDiff:  !isInitialScan && f.withDiff,
 	}); err != nil {
@@ -250,6 +312,17 @@ func (f *kvFeed) scanIfShould(
 	return nil
 }
 
+// filterCheckpointSpans returns the parts of the spans that are not in the
+// checkpoint, which remain to be scanned, and the parts which are.
+func filterCheckpointSpans(spans, checkpoint []roachpb.Span) (todo, done []roachpb.Span) {
+	// SubtractSpans mutates and sorts its inputs.
+	todo = roachpb.SubtractSpans(
+		append(roachpb.Spans(nil), spans...), append(roachpb.Spans(nil), checkpoint...))
+	done = roachpb.SubtractSpans(
+		append(roachpb.Spans(nil), spans...), append(roachpb.Spans(nil), todo...))
+	return todo, done
+}
+
 func (f *kvFeed) runUntilTableEvent(
 	ctx context.Context, startFrom hlc.Timestamp,
 ) (resolvedUpTo hlc.Timestamp, err error) {
@@ -265,7 +338,8 @@ func (f *kvFeed) runUntilTableEvent(
 	g := ctxgroup.WithContext(ctx)
 	physicalCfg := physicalConfig{Spans: f.spans, Timestamp: startFrom, WithDiff: f.withDiff}
 	g.GoCtx(func(ctx context.Context) error {
-		return copyFromSourceToSinkUntilTableEvent(ctx, f.sink, memBuf, physicalCfg, f.tableFeed)
+		return copyFromSourceToSinkUntilTableEvent(
+			ctx, f.sink, memBuf, physicalCfg, f.tableFeed, f.endTime)
 	})
 	g.GoCtx(func(ctx context.Context) error {
 		return f.physicalFeed.Run(ctx, memBuf, physicalCfg)
@@ -297,17 +371,30 @@ func (e *errBoundaryReached) Error() string {
 	return "scan boundary reached: " + e.String()
 }
 
+// errEndTimeReached is returned once all the spans are resolved up to the end
+// time of the feed, before any table event.
+type errEndTimeReached struct {
+	endTime hlc.Timestamp
+}
+
+func (e *errEndTimeReached) Error() string {
+	return fmt.Sprintf("end time reached: %v", e.endTime)
+}
+
 // copyFromSourceToSinkUntilTableEvents will pull read entries from source and
 // publish them to sink if there is no table event from the schemaFeed. If a
 // tableEvent occurs then the function will return once all of the spans have
 // been resolved up to the event. The first such event will be returned as
-// *errBoundaryReached. A nil error will never be returned.
+// *errBoundaryReached. Similarly, if endTime is set and no table event occurs
+// at or before it, the function will return *errEndTimeReached once all of the
+// spans have been resolved up to endTime. A nil error will never be returned.
 func copyFromSourceToSinkUntilTableEvent(
 	ctx context.Context,
 	sink EventBufferWriter,
 	source EventBufferReader,
 	cfg physicalConfig,
 	tables schemaFeed,
+	endTime hlc.Timestamp,
 ) error {
 	// Maintain a local spanfrontier to tell when all the component rangefeeds
 	// being watched have reached the Scan boundary.
@@ -325,23 +412,37 @@ func copyFromSourceToSinkUntilTableEvent(
 			if err != nil {
 				return err
 			}
-			if len(nextEvents) > 0 {
+			// Table events after the end time are never reached.
+			if len(nextEvents) > 0 &&
+				(endTime.IsEmpty() || nextEvents[0].Timestamp().LessEq(endTime)) {
 				scanBoundary = &errBoundaryReached{nextEvents[0]}
 			}
 			return nil
 		}
+		// boundary returns the timestamp up to which the spans are resolved
+		// before the feed stops, either due to a table event or due to the end
+		// time, and the sentinel error to return then.
+		boundary = func() (boundaryResolvedTimestamp hlc.Timestamp, boundaryErr error) {
+			if scanBoundary != nil {
+				return scanBoundary.Timestamp().Prev(), scanBoundary
+			}
+			if !endTime.IsEmpty() {
+				return endTime, &errEndTimeReached{endTime: endTime}
+			}
+			return hlc.Timestamp{}, nil
+		}
 		applyScanBoundary = func(e Event) (skipEvent, reachedBoundary bool) {
-			if scanBoundary == nil {
+			boundaryResolvedTimestamp, boundaryErr := boundary()
+			if boundaryErr == nil {
 				return false, false
 			}
-			if e.Timestamp().Less(scanBoundary.Timestamp()) {
+			if e.Timestamp().LessEq(boundaryResolvedTimestamp) {
 				return false, false
 			}
 			switch e.Type() {
 			case KVEvent:
 				return true, false
 			case ResolvedEvent:
-				boundaryResolvedTimestamp := scanBoundary.Timestamp().Prev()
 				resolved := e.Resolved()
 				if resolved.Timestamp.LessEq(boundaryResolvedTimestamp) {
 					return false, false
@@ -383,7 +484,8 @@ func copyFromSourceToSinkUntilTableEvent(
 		if scanBoundaryReached {
 			// All component rangefeeds are now at the boundary.
 			// Break out of the ctxgroup by returning the sentinel error.
-			return scanBoundary
+			_, boundaryErr := boundary()
+			return boundaryErr
 		}
 		if skipEntry {
 			continue
@@ -397,6 +499,7 @@ func copyFromSourceToSinkUntilTableEvent(
 func makeTablefeedConfig(cfg Config) schemafeed.Config {
 	return schemafeed.Config{
 		DB:                 cfg.DB,
+		Codec:              cfg.Codec,
 		Clock:              cfg.Clock,
 		Settings:           cfg.Settings,
 		Targets:            cfg.Targets,
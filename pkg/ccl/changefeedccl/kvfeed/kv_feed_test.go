@@ -26,6 +26,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -79,6 +80,8 @@ func TestKVFeed(t *testing.T) {
 		schemaChangeEvents changefeedbase.SchemaChangeEventClass
 		schemaChangePolicy changefeedbase.SchemaChangePolicy
 		initialHighWater   hlc.Timestamp
+		endTime            hlc.Timestamp
+		checkpoint         []roachpb.Span
 		spans              []roachpb.Span
 		events             []roachpb.RangeFeedEvent
 
@@ -113,7 +116,7 @@ func TestKVFeed(t *testing.T) {
 		f := newKVFeed(buf, tc.spans,
 			tc.schemaChangeEvents, tc.schemaChangePolicy,
 			tc.needsInitialScan, tc.withDiff,
-			tc.initialHighWater,
+			tc.initialHighWater, tc.endTime, tc.checkpoint,
 			&tf, sf, rangefeedFactory(ref.run), bufferFactory)
 		ctx, cancel := context.WithCancel(context.Background())
 		g := ctxgroup.WithContext(ctx)
@@ -126,6 +129,11 @@ func TestKVFeed(t *testing.T) {
 				scan := <-scans
 				assert.Equal(t, expScans[0], scan.Timestamp)
 				assert.Equal(t, tc.withDiff, scan.WithDiff)
+				for _, sp := range tc.checkpoint {
+					for _, scanned := range scan.Spans {
+						assert.False(t, sp.Overlaps(scanned), "checkpointed span %s was scanned", sp)
+					}
+				}
 			}
 			return nil
 		})
@@ -137,7 +145,7 @@ func TestKVFeed(t *testing.T) {
 			return nil
 		})
 		// Wait for the feed to fail rather than canceling it.
-		if tc.schemaChangePolicy == changefeedbase.OptSchemaChangePolicyStop {
+		if tc.schemaChangePolicy == changefeedbase.OptSchemaChangePolicyStop || !tc.endTime.IsEmpty() {
 			testG.Go(func() error {
 				_ = g.Wait()
 				return nil
@@ -171,6 +179,28 @@ func TestKVFeed(t *testing.T) {
 			},
 			expEvents: 1,
 		},
+		{
+			name:               "initial scan with checkpoint",
+			schemaChangeEvents: changefeedbase.OptSchemaChangeEventClassDefault,
+			schemaChangePolicy: changefeedbase.OptSchemaChangePolicyBackfill,
+			needsInitialScan:   true,
+			initialHighWater:   ts(2),
+			checkpoint: []roachpb.Span{
+				tableSpan(42),
+			},
+			spans: []roachpb.Span{
+				tableSpan(42),
+				tableSpan(43),
+			},
+			events: []roachpb.RangeFeedEvent{
+				kvEvent(43, "a", "b", ts(3)),
+			},
+			expScans: []hlc.Timestamp{
+				ts(2),
+			},
+			// The resolved span of the checkpoint and the kv.
+			expEvents: 2,
+		},
 		{
 			name:               "one table event - backfill",
 			schemaChangeEvents: changefeedbase.OptSchemaChangeEventClassDefault,
@@ -247,6 +277,52 @@ func TestKVFeed(t *testing.T) {
 			expEvents: 2,
 			expErrRE:  "schema change ...",
 		},
+		{
+			name:               "end time",
+			schemaChangeEvents: changefeedbase.OptSchemaChangeEventClassDefault,
+			schemaChangePolicy: changefeedbase.OptSchemaChangePolicyBackfill,
+			needsInitialScan:   true,
+			initialHighWater:   ts(2),
+			endTime:            ts(4),
+			spans: []roachpb.Span{
+				tableSpan(42),
+			},
+			events: []roachpb.RangeFeedEvent{
+				kvEvent(42, "a", "b", ts(3)),
+				checkpointEvent(tableSpan(42), ts(4)),
+				kvEvent(42, "a", "b", ts(5)), // ensure that events are filtered
+				checkpointEvent(tableSpan(42), ts(6)),
+			},
+			expScans: []hlc.Timestamp{
+				ts(2),
+			},
+			descs: []*sqlbase.TableDescriptor{
+				makeTableDesc(42, 1, ts(1), 2),
+				// Table events after the end time are ignored.
+				addColumnDropBackfillMutation(makeTableDesc(42, 2, ts(5), 1)),
+			},
+			expEvents: 3,
+			expErrRE:  "end time reached",
+		},
+		{
+			name:               "end time - initial scan only",
+			schemaChangeEvents: changefeedbase.OptSchemaChangeEventClassDefault,
+			schemaChangePolicy: changefeedbase.OptSchemaChangePolicyBackfill,
+			needsInitialScan:   true,
+			initialHighWater:   ts(2),
+			endTime:            ts(2),
+			spans: []roachpb.Span{
+				tableSpan(42),
+			},
+			events: []roachpb.RangeFeedEvent{
+				kvEvent(42, "a", "b", ts(3)),
+			},
+			expScans: []hlc.Timestamp{
+				ts(2),
+			},
+			expEvents: 1,
+			expErrRE:  "end time reached",
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			runTest(t, tc)
@@ -360,3 +436,21 @@ func tableSpan(tableID uint32) roachpb.Span {
 		EndKey: keys.SystemSQLCodec.TablePrefix(tableID).PrefixEnd(),
 	}
 }
+
+func TestFilterCheckpointSpans(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	sp := func(start, end string) roachpb.Span {
+		return roachpb.Span{Key: roachpb.Key(start), EndKey: roachpb.Key(end)}
+	}
+	todo, done := filterCheckpointSpans(
+		[]roachpb.Span{sp("a", "c"), sp("d", "g")},
+		[]roachpb.Span{sp("b", "e"), sp("f", "z")},
+	)
+	require.Equal(t, []roachpb.Span{sp("a", "b"), sp("e", "f")}, todo)
+	require.Equal(t, []roachpb.Span{sp("b", "c"), sp("d", "e"), sp("f", "g")}, done)
+
+	todo, done = filterCheckpointSpans([]roachpb.Span{sp("a", "c")}, nil)
+	require.Equal(t, []roachpb.Span{sp("a", "c")}, todo)
+	require.Empty(t, done)
+}
@@ -16,10 +16,10 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/gossip"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/kvcoord"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
-	"github.com/cockroachdb/cockroach/pkg/sql/covering"
 	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
@@ -38,6 +38,7 @@ type scanRequestScanner struct {
 	settings *cluster.Settings
 	gossip   gossip.DeprecatedGossip
 	db       *kv.DB
+	ds       *kvcoord.DistSender
 }
 
 var _ kvScanner = (*scanRequestScanner)(nil)
@@ -50,7 +51,7 @@ func (p *scanRequestScanner) Scan(
 			cfg.Spans, cfg.Timestamp, cfg.WithDiff)
 	}
 
-	spans, err := getSpansToProcess(ctx, p.db, cfg.Spans)
+	spans, err := getSpansToProcess(ctx, p.ds, cfg.Spans)
 	if err != nil {
 		return err
 	}
@@ -58,10 +59,7 @@ func (p *scanRequestScanner) Scan(
 	// Export requests for the various watched spans are executed in parallel,
 	// with a semaphore-enforced limit based on a cluster setting.
 	// The spans here generally correspond with range boundaries.
-	approxNodeCount, err := clusterNodeCount(p.gossip)
-	if err != nil {
-		return err
-	}
+	approxNodeCount := clusterNodeCount(p.gossip)
 	maxConcurrentExports := approxNodeCount *
 		int(kvserver.ExportRequestsLimit.Get(&p.settings.SV))
 	exportsSem := make(chan struct{}, maxConcurrentExports)
@@ -146,51 +144,35 @@ func (p *scanRequestScanner) exportSpan(
 	return nil
 }
 
+// getSpansToProcess splits the target spans at the boundaries of the ranges
+// they overlap. The range descriptors are looked up through the DistSender's
+// range cache rather than by scanning meta2, which secondary tenants can't
+// read: their range lookups are proxied to the KV layer.
 func getSpansToProcess(
-	ctx context.Context, db *kv.DB, targetSpans []roachpb.Span,
+	ctx context.Context, ds *kvcoord.DistSender, targetSpans []roachpb.Span,
 ) ([]roachpb.Span, error) {
-	var ranges []roachpb.RangeDescriptor
-	if err := db.Txn(ctx, func(ctx context.Context, txn *kv.Txn) error {
-		var err error
-		ranges, err = allRangeDescriptors(ctx, txn)
-		return err
-	}); err != nil {
-		return nil, errors.Wrapf(err, "fetching range descriptors")
-	}
-
-	type spanMarker struct{}
-	type rangeMarker struct{}
-
-	var spanCovering covering.Covering
+	var spans []roachpb.Span
+	ri := kvcoord.NewRangeIterator(ds)
 	for _, span := range targetSpans {
-		spanCovering = append(spanCovering, covering.Range{
-			Start:   []byte(span.Key),
-			End:     []byte(span.EndKey),
-			Payload: spanMarker{},
-		})
-	}
-
-	var rangeCovering covering.Covering
-	for _, rangeDesc := range ranges {
-		rangeCovering = append(rangeCovering, covering.Range{
-			Start:   []byte(rangeDesc.StartKey),
-			End:     []byte(rangeDesc.EndKey),
-			Payload: rangeMarker{},
-		})
-	}
-
-	chunks := covering.OverlapCoveringMerge(
-		[]covering.Covering{spanCovering, rangeCovering},
-	)
-
-	var requests []roachpb.Span
-	for _, chunk := range chunks {
-		if _, ok := chunk.Payload.([]interface{})[0].(spanMarker); !ok {
-			continue
+		rs, err := keys.SpanAddr(span)
+		if err != nil {
+			return nil, err
+		}
+		for ri.Seek(ctx, rs.Key, kvcoord.Ascending); ri.Valid(); ri.Next(ctx) {
+			partial, err := rs.Intersect(ri.Desc())
+			if err != nil {
+				return nil, err
+			}
+			spans = append(spans, partial.AsRawSpanWithNoLocals())
+			if !ri.NeedAnother(rs) {
+				break
+			}
+		}
+		if err := ri.Error(); err != nil {
+			return nil, errors.Wrapf(err, "fetching range descriptors")
 		}
-		requests = append(requests, roachpb.Span{Key: chunk.Start, EndKey: chunk.End})
 	}
-	return requests, nil
+	return spans, nil
 }
 
 // slurpScanResponse iterates the ScanResponse and inserts the contained kvs into
@@ -227,32 +209,17 @@ func slurpScanResponse(
 	return nil
 }
 
-func allRangeDescriptors(ctx context.Context, txn *kv.Txn) ([]roachpb.RangeDescriptor, error) {
-	rows, err := txn.Scan(ctx, keys.Meta2Prefix, keys.MetaMax, 0)
-	if err != nil {
-		return nil, err
-	}
-
-	rangeDescs := make([]roachpb.RangeDescriptor, len(rows))
-	for i, row := range rows {
-		if err := row.ValueProto(&rangeDescs[i]); err != nil {
-			return nil, errors.NewAssertionErrorWithWrappedErrf(err,
-				"%s: unable to unmarshal range descriptor", row.Key)
-		}
-	}
-	return rangeDescs, nil
-}
-
 // clusterNodeCount returns the approximate number of nodes in the cluster.
-func clusterNodeCount(gw gossip.DeprecatedGossip) (int, error) {
-	g, err := gw.OptionalErr(47971)
-	if err != nil {
-		return 0, err
+// Secondary tenants have no access to gossip, in which case it returns 1.
+func clusterNodeCount(gw gossip.DeprecatedGossip) int {
+	g, ok := gw.Optional(47971)
+	if !ok {
+		return 1
 	}
 	var nodes int
 	_ = g.IterateInfos(gossip.KeyNodeIDPrefix, func(_ string, _ gossip.Info) error {
 		nodes++
 		return nil
 	})
-	return nodes, nil
+	return nodes
 }
@@ -14,6 +14,10 @@ import (
 	"strconv"
 	"strings"
 	"unicode/utf8"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/errors"
 )
 
 var escapeRE = regexp.MustCompile(`_u[0-9a-fA-F]{2,8}_`)
@@ -49,6 +53,64 @@ func SQLNameToKafkaName(s string) string {
 	return s
 }
 
+// The placeholders of the topic_name templates of the Kafka sink.
+const (
+	topicNameDatabasePlaceholder = `{database}`
+	topicNameSchemaPlaceholder   = `{schema}`
+	topicNameTablePlaceholder    = `{table}`
+)
+
+var topicNamePlaceholderRE = regexp.MustCompile(`\{[^}]*\}`)
+
+// validateKafkaTopicNameTemplate checks that the given topic_name template only
+// contains known placeholders and characters allowed in Kafka topic names.
+func validateKafkaTopicNameTemplate(template string) error {
+	for _, placeholder := range topicNamePlaceholderRE.FindAllString(template, -1) {
+		switch placeholder {
+		case topicNameDatabasePlaceholder, topicNameSchemaPlaceholder, topicNameTablePlaceholder:
+		default:
+			return errors.Errorf(`param %s contains an unknown placeholder: %s`,
+				changefeedbase.SinkParamTopicName, placeholder)
+		}
+	}
+	if literal := topicNamePlaceholderRE.ReplaceAllString(template, ``); kafkaDisallowedRE.MatchString(literal) {
+		return errors.Errorf(`param %s contains characters not allowed in Kafka topic names: %s`,
+			changefeedbase.SinkParamTopicName, template)
+	}
+	return nil
+}
+
+// renderKafkaTopicName returns the Kafka topic name of a table for the given
+// topic_name template, replacing its placeholders with the escaped names of
+// the database, the schema and the table. A template without placeholders
+// routes every table to the same topic.
+func renderKafkaTopicName(template, database, schema, table string) string {
+	s := strings.NewReplacer(
+		topicNameDatabasePlaceholder, SQLNameToKafkaName(database),
+		topicNameSchemaPlaceholder, SQLNameToKafkaName(schema),
+		topicNameTablePlaceholder, SQLNameToKafkaName(table),
+	).Replace(template)
+	if len(s) > 249 {
+		// See SQLNameToKafkaName.
+		return s[:249]
+	}
+	return s
+}
+
+// topicNameForTarget returns the topic that the rows of the given target are
+// emitted to, for the topic_prefix and topic_name params of the sink. The table
+// name differs from the name of the target for the column families of the
+// table, with split_column_families.
+func topicNameForTarget(
+	prefix, template string, target jobspb.ChangefeedTarget, tableName string,
+) string {
+	if template == `` {
+		return prefix + SQLNameToKafkaName(tableName)
+	}
+	return prefix + renderKafkaTopicName(template,
+		target.StatementTimeDatabaseName, target.StatementTimeSchemaName, tableName)
+}
+
 // KafkaNameToSQLName is the inverse of SQLNameToKafkaName except when
 // SQLNameToKafkaName had to truncate.
 func KafkaNameToSQLName(s string) string {
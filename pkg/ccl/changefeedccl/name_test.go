@@ -91,3 +91,31 @@ func TestSQLNameToAvroName(t *testing.T) {
 	// We don't produce capital letters in escapes but check them anyway.
 	require.Equal(t, `/`, KafkaNameToSQLName(`_u2F_`))
 }
+
+func TestRenderKafkaTopicName(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	tests := []struct {
+		template, topic string
+	}{
+		{`{table}`, `foo`},
+		{`{database}.{schema}.{table}`, `d.public.foo`},
+		{`cdc-{database}_{table}`, `cdc-d_foo`},
+		// A template without placeholders routes every table to the same topic.
+		{`all`, `all`},
+	}
+	for _, test := range tests {
+		require.NoError(t, validateKafkaTopicNameTemplate(test.template))
+		require.Equal(t, test.topic, renderKafkaTopicName(test.template, `d`, `public`, `foo`))
+	}
+	// The names are escaped, but not the rest of the template.
+	require.Equal(t, `_u2603_._u0021_`, renderKafkaTopicName(`{database}.{table}`, `☃`, `public`, `!`))
+
+	require.EqualError(t, validateKafkaTopicNameTemplate(`{db}.{table}`),
+		`param topic_name contains an unknown placeholder: {db}`)
+	require.EqualError(t, validateKafkaTopicNameTemplate(`{database}/{table}`),
+		`param topic_name contains characters not allowed in Kafka topic names: {database}/{table}`)
+	require.EqualError(t, validateKafkaTopicNameTemplate(`{table`),
+		`param topic_name contains characters not allowed in Kafka topic names: {table`)
+}
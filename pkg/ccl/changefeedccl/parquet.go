@@ -0,0 +1,174 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+	"io"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/parquet"
+	"github.com/cockroachdb/errors"
+)
+
+// parquetEncoder encodes the rows of the changefeeds with format=parquet,
+// which are only supported by the cloud storage sink. The sink buffers the
+// rows of each file and writes them as typed Parquet columns when the file is
+// flushed (see parquetFileWriter), so the values returned by the encoder are
+// not the final output: they are the datums of the row, followed by the
+// metadata columns (see metadataColumns), with the value encoding. Keys are
+// not encoded since the primary key columns are part of the rows.
+type parquetEncoder struct {
+	updatedField bool
+
+	alloc   sqlbase.DatumAlloc
+	buf     []byte
+	scratch []byte
+}
+
+var _ Encoder = &parquetEncoder{}
+
+func newParquetEncoder(opts map[string]string) (*parquetEncoder, error) {
+	if changefeedbase.EnvelopeType(opts[changefeedbase.OptEnvelope]) != changefeedbase.OptEnvelopeWrapped {
+		return nil, errors.Errorf(`%s=%s is not supported with %s=%s`,
+			changefeedbase.OptEnvelope, opts[changefeedbase.OptEnvelope],
+			changefeedbase.OptFormat, changefeedbase.OptFormatParquet)
+	}
+	for _, opt := range []string{changefeedbase.OptDiff, changefeedbase.OptKeyColumns} {
+		if _, ok := opts[opt]; ok {
+			return nil, errors.Errorf(`%s is not supported with %s=%s`,
+				opt, changefeedbase.OptFormat, changefeedbase.OptFormatParquet)
+		}
+	}
+	e := &parquetEncoder{}
+	_, e.updatedField = opts[changefeedbase.OptUpdatedTimestamps]
+	return e, nil
+}
+
+// EncodeKey implements the Encoder interface.
+func (e *parquetEncoder) EncodeKey(context.Context, encodeRow) ([]byte, error) {
+	return nil, nil
+}
+
+// EncodeValue implements the Encoder interface.
+func (e *parquetEncoder) EncodeValue(_ context.Context, row encodeRow) ([]byte, error) {
+	e.buf = e.buf[:0]
+	columns := row.tableDesc.Columns
+	for i := range columns {
+		datum := row.datums[i]
+		// Only the primary key columns of deleted rows are guaranteed to be set.
+		if row.deleted && datum.IsUnset() {
+			e.buf = encoding.EncodeNullValue(e.buf, encoding.NoColumnID)
+			continue
+		}
+		if err := datum.EnsureDecoded(columns[i].Type, &e.alloc); err != nil {
+			return nil, err
+		}
+		if err := e.appendDatum(datum.Datum); err != nil {
+			return nil, err
+		}
+	}
+	if err := e.appendDatum(tree.NewDString(eventType(row))); err != nil {
+		return nil, err
+	}
+	if e.updatedField {
+		if err := e.appendDatum(tree.NewDString(row.updated.AsOfSystemTime())); err != nil {
+			return nil, err
+		}
+	}
+	return e.buf, nil
+}
+
+func (e *parquetEncoder) appendDatum(d tree.Datum) error {
+	var err error
+	e.buf, err = sqlbase.EncodeTableValue(e.buf, sqlbase.ColumnID(encoding.NoColumnID), d, e.scratch)
+	return err
+}
+
+// EncodeResolvedTimestamp implements the Encoder interface. The resolved
+// timestamp files of the cloud storage sink are JSON, whatever the format of
+// the data files.
+func (e *parquetEncoder) EncodeResolvedTimestamp(
+	_ context.Context, _ string, resolved hlc.Timestamp,
+) ([]byte, error) {
+	return encodeResolvedTimestampJSON(resolved)
+}
+
+// parquetColumns returns the names and the types of the columns of the
+// Parquet files that the rows of the given table are written to: the columns
+// of the table, followed by the metadata columns.
+func parquetColumns(
+	tableDesc *sqlbase.TableDescriptor, withUpdated bool,
+) (names []string, typs []*types.T) {
+	for i := range tableDesc.Columns {
+		names = append(names, tableDesc.Columns[i].Name)
+		typs = append(typs, tableDesc.Columns[i].Type)
+	}
+	for _, name := range metadataColumns(withUpdated) {
+		names = append(names, name)
+		typs = append(typs, types.String)
+	}
+	return names, typs
+}
+
+// parquetFileWriter writes the rows encoded by a parquetEncoder to a Parquet
+// file. All the rows must belong to the same version of a table.
+type parquetFileWriter struct {
+	w      *parquet.Writer
+	types  []*types.T
+	datums tree.Datums
+	alloc  sqlbase.DatumAlloc
+}
+
+func newParquetFileWriter(
+	tableDesc *sqlbase.TableDescriptor,
+	withUpdated bool,
+	sink io.Writer,
+	compression parquet.CompressionCodec,
+) (*parquetFileWriter, error) {
+	names, typs := parquetColumns(tableDesc, withUpdated)
+	sch, err := parquet.NewSchema(names, typs)
+	if err != nil {
+		return nil, err
+	}
+	return &parquetFileWriter{
+		w:      parquet.NewWriter(sch, sink, compression),
+		types:  typs,
+		datums: make(tree.Datums, len(typs)),
+	}, nil
+}
+
+// addRow buffers a row encoded by a parquetEncoder.
+func (p *parquetFileWriter) addRow(value []byte) error {
+	for i, typ := range p.types {
+		var err error
+		if p.datums[i], value, err = sqlbase.DecodeTableValue(&p.alloc, typ, value); err != nil {
+			return err
+		}
+	}
+	if len(value) != 0 {
+		return errors.AssertionFailedf(`%d trailing bytes in encoded row`, len(value))
+	}
+	return p.w.AddRow(p.datums)
+}
+
+// bufferedBytes returns an estimate of the size of the buffered rows.
+func (p *parquetFileWriter) bufferedBytes() int64 {
+	return p.w.BufferedBytes()
+}
+
+// close writes the Parquet file to the sink.
+func (p *parquetFileWriter) close() error {
+	return p.w.Close()
+}
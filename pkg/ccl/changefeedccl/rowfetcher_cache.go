@@ -10,6 +10,7 @@ package changefeedccl
 
 import (
 	"context"
+	"math"
 
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -27,9 +28,10 @@ import (
 // StartScanFrom can be used to turn that key (or all the keys making up the
 // column families of one row) into a row.
 type rowFetcherCache struct {
-	codec    keys.SQLCodec
-	leaseMgr *lease.Manager
-	fetchers map[idVersion]*row.Fetcher
+	codec       keys.SQLCodec
+	leaseMgr    *lease.Manager
+	fetchers    map[idVersion]*row.Fetcher
+	familyDescs map[idVersion]*familyTableDesc
 
 	a sqlbase.DatumAlloc
 }
@@ -37,13 +39,41 @@ type rowFetcherCache struct {
 type idVersion struct {
 	id      sqlbase.ID
 	version sqlbase.DescriptorVersion
+	// family is the column family the Fetcher decodes, or allFamilies.
+	family sqlbase.FamilyID
+}
+
+// allFamilies is the family of the idVersion of the Fetchers that decode all
+// the columns of a table.
+const allFamilies = sqlbase.FamilyID(math.MaxUint32)
+
+// familyTableDesc is a TableDescriptor restricted to a column family, used to
+// emit one message per column family with split_column_families. It only has
+// the columns of the primary key and of the family, and it is named after the
+// family, as `<table>.<family>`, which is the topic of its messages.
+type familyTableDesc struct {
+	desc *sqlbase.TableDescriptor
+	// colIdxs are the ordinals in the columns of the full table of the columns
+	// of desc.
+	colIdxs []int
+}
+
+// project returns the datums of the columns of desc, given the datums of all
+// the columns of the table.
+func (d *familyTableDesc) project(datums sqlbase.EncDatumRow) sqlbase.EncDatumRow {
+	projected := make(sqlbase.EncDatumRow, len(d.colIdxs))
+	for i, idx := range d.colIdxs {
+		projected[i] = datums[idx]
+	}
+	return projected
 }
 
 func newRowFetcherCache(codec keys.SQLCodec, leaseMgr *lease.Manager) *rowFetcherCache {
 	return &rowFetcherCache{
-		codec:    codec,
-		leaseMgr: leaseMgr,
-		fetchers: make(map[idVersion]*row.Fetcher),
+		codec:       codec,
+		leaseMgr:    leaseMgr,
+		fetchers:    make(map[idVersion]*row.Fetcher),
+		familyDescs: make(map[idVersion]*familyTableDesc),
 	}
 }
 
@@ -94,19 +124,50 @@ func (c *rowFetcherCache) TableDescForKey(
 	return tableDesc, nil
 }
 
+// familyForKey returns the column family of the table that the given key
+// belongs to.
+func familyForKey(
+	tableDesc *sqlbase.ImmutableTableDescriptor, key roachpb.Key,
+) (*sqlbase.ColumnFamilyDescriptor, error) {
+	n, err := keys.GetRowPrefixLength(key)
+	if err != nil {
+		return nil, err
+	}
+	_, familyID, err := encoding.DecodeUvarintAscending(key[n:])
+	if err != nil {
+		return nil, err
+	}
+	return tableDesc.FindFamilyByID(sqlbase.FamilyID(familyID))
+}
+
+// RowFetcherForTableDesc returns a Fetcher for the given table. If family is
+// not nil, the Fetcher only decodes the columns of the primary key and of that
+// family, and it must only be given the key of that family.
 func (c *rowFetcherCache) RowFetcherForTableDesc(
-	tableDesc *sqlbase.ImmutableTableDescriptor,
+	tableDesc *sqlbase.ImmutableTableDescriptor, family *sqlbase.ColumnFamilyDescriptor,
 ) (*row.Fetcher, error) {
-	idVer := idVersion{id: tableDesc.ID, version: tableDesc.Version}
+	idVer := idVersion{id: tableDesc.ID, version: tableDesc.Version, family: allFamilies}
+	if family != nil {
+		idVer.family = family.ID
+	}
 	if rf, ok := c.fetchers[idVer]; ok {
 		return rf, nil
 	}
-	// TODO(dan): Allow for decoding a subset of the columns.
 	colIdxMap := make(map[sqlbase.ColumnID]int)
 	var valNeededForCol util.FastIntSet
 	for colIdx := range tableDesc.Columns {
 		colIdxMap[tableDesc.Columns[colIdx].ID] = colIdx
-		valNeededForCol.Add(colIdx)
+		if family == nil {
+			valNeededForCol.Add(colIdx)
+		}
+	}
+	if family != nil {
+		for _, id := range tableDesc.PrimaryIndex.ColumnIDs {
+			valNeededForCol.Add(colIdxMap[id])
+		}
+		for _, id := range family.ColumnIDs {
+			valNeededForCol.Add(colIdxMap[id])
+		}
 	}
 
 	var rf row.Fetcher
@@ -134,3 +195,35 @@ func (c *rowFetcherCache) RowFetcherForTableDesc(
 	c.fetchers[idVer] = &rf
 	return &rf, nil
 }
+
+// FamilyTableDesc returns the given table restricted to the given column
+// family.
+func (c *rowFetcherCache) FamilyTableDesc(
+	tableDesc *sqlbase.ImmutableTableDescriptor, family *sqlbase.ColumnFamilyDescriptor,
+) *familyTableDesc {
+	idVer := idVersion{id: tableDesc.ID, version: tableDesc.Version, family: family.ID}
+	if d, ok := c.familyDescs[idVer]; ok {
+		return d
+	}
+	var inFamily util.FastIntSet
+	for _, id := range tableDesc.PrimaryIndex.ColumnIDs {
+		inFamily.Add(int(id))
+	}
+	for _, id := range family.ColumnIDs {
+		inFamily.Add(int(id))
+	}
+
+	desc := tableDesc.TableDescriptor
+	desc.Name = tableDesc.Name + `.` + family.Name
+	desc.Columns = nil
+	desc.Families = []sqlbase.ColumnFamilyDescriptor{*family}
+	d := &familyTableDesc{desc: &desc}
+	for colIdx := range tableDesc.Columns {
+		if inFamily.Contains(int(tableDesc.Columns[colIdx].ID)) {
+			desc.Columns = append(desc.Columns, tableDesc.Columns[colIdx])
+			d.colIdxs = append(d.colIdxs, colIdx)
+		}
+	}
+	c.familyDescs[idVer] = d
+	return d
+}
@@ -49,6 +49,7 @@ func (e TableEvent) Timestamp() hlc.Timestamp {
 // Config configures a SchemaFeed.
 type Config struct {
 	DB       *kv.DB
+	Codec    keys.SQLCodec
 	Clock    *hlc.Clock
 	Settings *cluster.Settings
 	Targets  jobspb.ChangefeedTargets
@@ -88,6 +89,7 @@ type Config struct {
 type SchemaFeed struct {
 	filter   tableEventFilter
 	db       *kv.DB
+	codec    keys.SQLCodec
 	clock    *hlc.Clock
 	settings *cluster.Settings
 	targets  jobspb.ChangefeedTargets
@@ -132,6 +134,7 @@ func New(cfg Config) *SchemaFeed {
 	m := &SchemaFeed{
 		filter:   schemaChangeEventFilters[cfg.SchemaChangeEvents],
 		db:       cfg.DB,
+		codec:    cfg.Codec,
 		clock:    cfg.Clock,
 		settings: cfg.Settings,
 		targets:  cfg.Targets,
@@ -190,7 +193,7 @@ func (tf *SchemaFeed) primeInitialTableDescs(ctx context.Context) error {
 		txn.SetFixedTimestamp(ctx, initialTableDescTs)
 		// Note that all targets are currently guaranteed to be tables.
 		for tableID := range tf.targets {
-			tableDesc, err := sqlbase.GetTableDescFromID(ctx, txn, keys.SystemSQLCodec, tableID)
+			tableDesc, err := sqlbase.GetTableDescFromID(ctx, txn, tf.codec, tableID)
 			if err != nil {
 				return err
 			}
@@ -223,7 +226,7 @@ func (tf *SchemaFeed) updateTableHistory(ctx context.Context, endTS hlc.Timestam
 	if endTS.LessEq(startTS) {
 		return nil
 	}
-	descs, err := fetchTableDescriptorVersions(ctx, tf.db, startTS, endTS, tf.targets)
+	descs, err := fetchTableDescriptorVersions(ctx, tf.db, tf.codec, startTS, endTS, tf.targets)
 	if err != nil {
 		return err
 	}
@@ -446,13 +449,17 @@ func (tf *SchemaFeed) validateTable(ctx context.Context, desc *sqlbase.TableDesc
 }
 
 func fetchTableDescriptorVersions(
-	ctx context.Context, db *kv.DB, startTS, endTS hlc.Timestamp, targets jobspb.ChangefeedTargets,
+	ctx context.Context,
+	db *kv.DB,
+	codec keys.SQLCodec,
+	startTS, endTS hlc.Timestamp,
+	targets jobspb.ChangefeedTargets,
 ) ([]*sqlbase.TableDescriptor, error) {
 	if log.V(2) {
 		log.Infof(ctx, `fetching table descs (%s,%s]`, startTS, endTS)
 	}
 	start := timeutil.Now()
-	span := roachpb.Span{Key: keys.TODOSQLCodec.TablePrefix(keys.DescriptorTableID)}
+	span := roachpb.Span{Key: codec.TablePrefix(keys.DescriptorTableID)}
 	span.EndKey = span.Key.PrefixEnd()
 	header := roachpb.Header{Timestamp: endTS}
 	req := &roachpb.ExportRequest{
@@ -486,7 +493,7 @@ func fetchTableDescriptorVersions(
 					return nil
 				}
 				k := it.UnsafeKey()
-				remaining, _, _, err := keys.TODOSQLCodec.DecodeIndexPrefix(k.Key)
+				remaining, _, _, err := codec.DecodeIndexPrefix(k.Key)
 				if err != nil {
 					return err
 				}
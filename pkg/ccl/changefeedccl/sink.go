@@ -35,6 +35,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/storage/cloud"
+	"github.com/cockroachdb/cockroach/pkg/storage/cloudimpl"
 	"github.com/cockroachdb/cockroach/pkg/util/bufalloc"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
@@ -85,6 +86,21 @@ func getSink(
 	}
 	q := u.Query()
 
+	// The rows of Parquet files are only written once the files are complete,
+	// which the other sinks have no notion of.
+	if changefeedbase.FormatType(opts[changefeedbase.OptFormat]) == changefeedbase.OptFormatParquet &&
+		!isCloudStorageSink(u) {
+		return nil, errors.Errorf(`%s=%s is only supported by cloud storage sinks`,
+			changefeedbase.OptFormat, changefeedbase.OptFormatParquet)
+	}
+	// The CSV records don't carry keys, which the other sinks route and order
+	// the rows with.
+	if changefeedbase.FormatType(opts[changefeedbase.OptFormat]) == changefeedbase.OptFormatCSV &&
+		!isCloudStorageSink(u) && !isWebhookSink(u) {
+		return nil, errors.Errorf(`%s=%s is only supported by cloud storage and webhook sinks`,
+			changefeedbase.OptFormat, changefeedbase.OptFormatCSV)
+	}
+
 	// Use a function here to delay creation of the sink until after we've done
 	// all the parameter verification.
 	var makeSink func() (Sink, error)
@@ -95,6 +111,11 @@ func getSink(
 		var cfg kafkaSinkConfig
 		cfg.kafkaTopicPrefix = q.Get(changefeedbase.SinkParamTopicPrefix)
 		q.Del(changefeedbase.SinkParamTopicPrefix)
+		cfg.topicNameTemplate = q.Get(changefeedbase.SinkParamTopicName)
+		q.Del(changefeedbase.SinkParamTopicName)
+		if err := validateKafkaTopicNameTemplate(cfg.topicNameTemplate); err != nil {
+			return nil, err
+		}
 		if schemaTopic := q.Get(changefeedbase.SinkParamSchemaTopic); schemaTopic != `` {
 			return nil, errors.Errorf(`%s is not yet supported`, changefeedbase.SinkParamSchemaTopic)
 		}
@@ -173,6 +194,8 @@ func getSink(
 			}
 		}
 
+		_, cfg.splitColumnFamilies = opts[changefeedbase.OptSplitColumnFamilies]
+
 		makeSink = func() (Sink, error) {
 			return makeKafkaSink(cfg, u.Host, targets)
 		}
@@ -196,6 +219,62 @@ func getSink(
 				opts, timestampOracle, makeExternalStorageFromURI, user,
 			)
 		}
+	case isWebhookSink(u):
+		var tlsCfg webhookSinkTLSConfig
+		if caCertHex := q.Get(changefeedbase.SinkParamCACert); caCertHex != `` {
+			if tlsCfg.caCert, err = base64.StdEncoding.DecodeString(caCertHex); err != nil {
+				return nil, errors.Errorf(`param %s must be base 64 encoded: %s`, changefeedbase.SinkParamCACert, err)
+			}
+		}
+		q.Del(changefeedbase.SinkParamCACert)
+		if clientCertHex := q.Get(changefeedbase.SinkParamClientCert); clientCertHex != `` {
+			if tlsCfg.clientCert, err = base64.StdEncoding.DecodeString(clientCertHex); err != nil {
+				return nil, errors.Errorf(`param %s must be base 64 encoded: %s`, changefeedbase.SinkParamClientCert, err)
+			}
+		}
+		q.Del(changefeedbase.SinkParamClientCert)
+		if clientKeyHex := q.Get(changefeedbase.SinkParamClientKey); clientKeyHex != `` {
+			if tlsCfg.clientKey, err = base64.StdEncoding.DecodeString(clientKeyHex); err != nil {
+				return nil, errors.Errorf(`param %s must be base 64 encoded: %s`, changefeedbase.SinkParamClientKey, err)
+			}
+		}
+		q.Del(changefeedbase.SinkParamClientKey)
+		if skipVerify := q.Get(changefeedbase.SinkParamSkipTLSVerify); skipVerify != `` {
+			if tlsCfg.skipTLSVerify, err = strconv.ParseBool(skipVerify); err != nil {
+				return nil, errors.Errorf(`param %s must be a bool: %s`, changefeedbase.SinkParamSkipTLSVerify, err)
+			}
+		}
+		q.Del(changefeedbase.SinkParamSkipTLSVerify)
+		// The remaining query parameters are part of the webhook URL.
+		u.RawQuery = q.Encode()
+		q = url.Values{}
+		makeSink = func() (Sink, error) {
+			return makeWebhookSink(u, tlsCfg, opts)
+		}
+	case isPubsubSink(u):
+		cfg := pubsubSinkConfig{projectID: u.Host}
+		if cfg.projectID == `` {
+			return nil, errors.Errorf(`missing project in %s sink URI`, changefeedbase.SinkSchemeGCPubsub)
+		}
+		cfg.region = q.Get(changefeedbase.SinkParamRegion)
+		q.Del(changefeedbase.SinkParamRegion)
+		cfg.topicPrefix = q.Get(changefeedbase.SinkParamTopicPrefix)
+		q.Del(changefeedbase.SinkParamTopicPrefix)
+		cfg.topicNameTemplate = q.Get(changefeedbase.SinkParamTopicName)
+		q.Del(changefeedbase.SinkParamTopicName)
+		if err := validateKafkaTopicNameTemplate(cfg.topicNameTemplate); err != nil {
+			return nil, err
+		}
+		_, cfg.splitColumnFamilies = opts[changefeedbase.OptSplitColumnFamilies]
+		clientOpts, err := makePubsubClientOptions(ctx, q, cfg.region)
+		if err != nil {
+			return nil, err
+		}
+		q.Del(cloudimpl.AuthParam)
+		q.Del(cloudimpl.CredentialsParam)
+		makeSink = func() (Sink, error) {
+			return makePubsubSink(ctx, cfg, targets, opts, clientOpts...)
+		}
 	case u.Scheme == changefeedbase.SinkSchemeExperimentalSQL:
 		// Swap the changefeed prefix for the sql connection one that sqlSink
 		// expects.
@@ -203,8 +282,9 @@ func getSink(
 		// TODO(dan): Make tableName configurable or based on the job ID or
 		// something.
 		tableName := `sqlsink`
+		_, splitColumnFamilies := opts[changefeedbase.OptSplitColumnFamilies]
 		makeSink = func() (Sink, error) {
-			return makeSQLSink(u.String(), tableName, targets)
+			return makeSQLSink(u.String(), tableName, targets, splitColumnFamilies)
 		}
 		// Remove parameters we know about for the unknown parameter check.
 		q.Del(`sslcert`)
@@ -300,6 +380,13 @@ type kafkaSinkConfig struct {
 	saslHandshake    bool
 	saslUser         string
 	saslPassword     string
+
+	// topicNameTemplate is the topic_name template of the topics, see
+	// renderKafkaTopicName. The topic of a table is named after the table if it
+	// is empty.
+	topicNameTemplate string
+
+	splitColumnFamilies bool
 }
 
 // kafkaSink emits to Kafka asynchronously. It is not concurrency-safe; all
@@ -308,6 +395,7 @@ type kafkaSink struct {
 	cfg      kafkaSinkConfig
 	client   sarama.Client
 	producer sarama.AsyncProducer
+	targets  jobspb.ChangefeedTargets
 	topics   map[string]struct{}
 
 	lastMetadataRefresh time.Time
@@ -325,13 +413,19 @@ type kafkaSink struct {
 	}
 }
 
+// topicName returns the topic that the rows of the given target are emitted
+// to, see topicNameForTarget.
+func (cfg kafkaSinkConfig) topicName(target jobspb.ChangefeedTarget, tableName string) string {
+	return topicNameForTarget(cfg.kafkaTopicPrefix, cfg.topicNameTemplate, target, tableName)
+}
+
 func makeKafkaSink(
 	cfg kafkaSinkConfig, bootstrapServers string, targets jobspb.ChangefeedTargets,
 ) (Sink, error) {
-	sink := &kafkaSink{cfg: cfg}
+	sink := &kafkaSink{cfg: cfg, targets: targets}
 	sink.topics = make(map[string]struct{})
 	for _, t := range targets {
-		sink.topics[cfg.kafkaTopicPrefix+SQLNameToKafkaName(t.StatementTimeName)] = struct{}{}
+		sink.topics[cfg.topicName(t, t.StatementTimeName)] = struct{}{}
 	}
 
 	config := sarama.NewConfig()
@@ -457,9 +551,16 @@ func (s *kafkaSink) Close() error {
 func (s *kafkaSink) EmitRow(
 	ctx context.Context, table *sqlbase.TableDescriptor, key, value []byte, _ hlc.Timestamp,
 ) error {
-	topic := s.cfg.kafkaTopicPrefix + SQLNameToKafkaName(table.Name)
+	target, watched := s.targets[table.ID]
+	topic := s.cfg.topicName(target, table.Name)
 	if _, ok := s.topics[topic]; !ok {
-		return errors.Errorf(`cannot emit to undeclared topic: %s`, topic)
+		// With split_column_families, each column family of a watched table
+		// has its own topic, which is declared when a row is first emitted to
+		// it.
+		if !s.cfg.splitColumnFamilies || !watched {
+			return errors.Errorf(`cannot emit to undeclared topic: %s`, topic)
+		}
+		s.topics[topic] = struct{}{}
 	}
 
 	msg := &sarama.ProducerMessage{
@@ -653,14 +754,19 @@ type sqlSink struct {
 	db *gosql.DB
 
 	tableName string
+	targets   jobspb.ChangefeedTargets
 	topics    map[string]struct{}
 	hasher    hash.Hash32
 
+	splitColumnFamilies bool
+
 	rowBuf  []interface{}
 	scratch bufalloc.ByteAllocator
 }
 
-func makeSQLSink(uri, tableName string, targets jobspb.ChangefeedTargets) (*sqlSink, error) {
+func makeSQLSink(
+	uri, tableName string, targets jobspb.ChangefeedTargets, splitColumnFamilies bool,
+) (*sqlSink, error) {
 	if u, err := url.Parse(uri); err != nil {
 		return nil, err
 	} else if u.Path == `` {
@@ -678,8 +784,11 @@ func makeSQLSink(uri, tableName string, targets jobspb.ChangefeedTargets) (*sqlS
 	s := &sqlSink{
 		db:        db,
 		tableName: tableName,
+		targets:   targets,
 		topics:    make(map[string]struct{}),
 		hasher:    fnv.New32a(),
+
+		splitColumnFamilies: splitColumnFamilies,
 	}
 	for _, t := range targets {
 		s.topics[t.StatementTimeName] = struct{}{}
@@ -693,7 +802,11 @@ func (s *sqlSink) EmitRow(
 ) error {
 	topic := table.Name
 	if _, ok := s.topics[topic]; !ok {
-		return errors.Errorf(`cannot emit to undeclared topic: %s`, topic)
+		// See the comment in kafkaSink.EmitRow.
+		if _, watched := s.targets[table.ID]; !s.splitColumnFamilies || !watched {
+			return errors.Errorf(`cannot emit to undeclared topic: %s`, topic)
+		}
+		s.topics[topic] = struct{}{}
 	}
 
 	// Hashing logic copied from sarama.HashPartitioner.
@@ -26,6 +26,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/cloud"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/parquet"
 	"github.com/cockroachdb/errors"
 	"github.com/google/btree"
 )
@@ -55,10 +56,22 @@ type cloudStorageSinkFile struct {
 	codec   io.WriteCloser
 	rawSize int
 	buf     bytes.Buffer
+	// parquet buffers the rows of the file with format=parquet, and writes
+	// them to buf when the file is flushed.
+	parquet *parquetFileWriter
 }
 
 var _ io.Writer = &cloudStorageSinkFile{}
 
+// size returns the size of the file, which is estimated for Parquet files
+// since they are only written once they are flushed.
+func (f *cloudStorageSinkFile) size() int64 {
+	if f.parquet != nil {
+		return f.parquet.bufferedBytes()
+	}
+	return int64(f.buf.Len())
+}
+
 func (f *cloudStorageSinkFile) Write(p []byte) (int, error) {
 	f.rawSize += len(p)
 	if f.codec != nil {
@@ -160,9 +173,12 @@ func (f *cloudStorageSinkFile) Write(p []byte) (int, error) {
 // by a given `<sink_id>` and <session_id> is a unique identifying string for the job
 // session running the `changeAggregator` that owns this sink.
 //
-// `<ext>` implies the format of the file: currently the only option is
-// `ndjson`, which means a text file conforming to the "Newline Delimited JSON"
-// spec.
+// `<ext>` implies the format of the file: `ndjson`, which means a text file
+// conforming to the "Newline Delimited JSON" spec, or `parquet`, which means an
+// Apache Parquet file with a column per column of the table followed by the
+// `__crdb__event_type` column (and `__crdb__updated`, with the updated option),
+// or `csv`, which means a CSV file with the same columns, starting with a
+// header record.
 //
 // This naming convention of data files is carefully chosen in order to preserve
 // the external ordering guarantees of CDC. Naming output files in this fashion
@@ -275,6 +291,18 @@ type cloudStorageSink struct {
 
 	compression string
 
+	// parquet is true with format=parquet. In this case, the compression
+	// codec is applied to the pages of the Parquet files rather than to the
+	// whole files.
+	parquet            bool
+	parquetUpdated     bool
+	parquetCompression parquet.CompressionCodec
+
+	// csv is true with format=csv. In this case, every file starts with a
+	// header record.
+	csv        bool
+	csvUpdated bool
+
 	es cloud.ExternalStorage
 
 	// These are fields to track information needed to output files based on the naming
@@ -337,6 +365,19 @@ func makeCloudStorageSink(
 			_, err := w.Write([]byte{'\n'})
 			return err
 		}
+	case changefeedbase.OptFormatCSV:
+		s.ext = `.csv`
+		s.recordDelimFn = func(w io.Writer) error {
+			_, err := w.Write([]byte{'\n'})
+			return err
+		}
+		s.csv = true
+		_, s.csvUpdated = opts[changefeedbase.OptUpdatedTimestamps]
+	case changefeedbase.OptFormatParquet:
+		s.ext = `.parquet`
+		s.parquet = true
+		s.parquetCompression = parquet.CompressionSnappy
+		_, s.parquetUpdated = opts[changefeedbase.OptUpdatedTimestamps]
 	default:
 		return nil, errors.Errorf(`this sink is incompatible with %s=%s`,
 			changefeedbase.OptFormat, opts[changefeedbase.OptFormat])
@@ -344,21 +385,25 @@ func makeCloudStorageSink(
 
 	switch changefeedbase.EnvelopeType(opts[changefeedbase.OptEnvelope]) {
 	case changefeedbase.OptEnvelopeWrapped:
+		if _, ok := opts[changefeedbase.OptKeyInValue]; !ok {
+			return nil, errors.Errorf(`this sink requires the WITH %s option`, changefeedbase.OptKeyInValue)
+		}
+	case changefeedbase.OptEnvelopeBare:
+		// The primary key columns are part of the records.
 	default:
 		return nil, errors.Errorf(`this sink is incompatible with %s=%s`,
 			changefeedbase.OptEnvelope, opts[changefeedbase.OptEnvelope])
 	}
 
-	if _, ok := opts[changefeedbase.OptKeyInValue]; !ok {
-		return nil, errors.Errorf(`this sink requires the WITH %s option`, changefeedbase.OptKeyInValue)
-	}
-
 	if codec, ok := opts[changefeedbase.OptCompression]; ok && codec != "" {
-		if strings.EqualFold(codec, "gzip") {
+		if !strings.EqualFold(codec, "gzip") {
+			return nil, errors.Errorf(`unsupported compression codec %q`, codec)
+		}
+		if s.parquet {
+			s.parquetCompression = parquet.CompressionGZIP
+		} else {
 			s.compression = sinkCompressionGzip
 			s.ext = s.ext + ".gz"
-		} else {
-			return nil, errors.Errorf(`unsupported compression codec %q`, codec)
 		}
 	}
 
@@ -371,11 +416,11 @@ func makeCloudStorageSink(
 }
 
 func (s *cloudStorageSink) getOrCreateFile(
-	topic string, schemaID sqlbase.DescriptorVersion,
-) *cloudStorageSinkFile {
-	key := cloudStorageSinkKey{topic, schemaID}
+	table *sqlbase.TableDescriptor,
+) (*cloudStorageSinkFile, error) {
+	key := cloudStorageSinkKey{table.Name, table.Version}
 	if item := s.files.Get(key); item != nil {
-		return item.(*cloudStorageSinkFile)
+		return item.(*cloudStorageSinkFile), nil
 	}
 	f := &cloudStorageSinkFile{
 		cloudStorageSinkKey: key,
@@ -384,8 +429,27 @@ func (s *cloudStorageSink) getOrCreateFile(
 	case sinkCompressionGzip:
 		f.codec = gzip.NewWriter(&f.buf)
 	}
+	if s.parquet {
+		var err error
+		f.parquet, err = newParquetFileWriter(table, s.parquetUpdated, &f.buf, s.parquetCompression)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s.csv {
+		header, err := csvHeader(table, s.csvUpdated)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(header); err != nil {
+			return nil, err
+		}
+		if err := s.recordDelimFn(f); err != nil {
+			return nil, err
+		}
+	}
 	s.files.ReplaceOrInsert(f)
-	return f
+	return f, nil
 }
 
 // EmitRow implements the Sink interface.
@@ -396,17 +460,27 @@ func (s *cloudStorageSink) EmitRow(
 		return errors.New(`cannot EmitRow on a closed sink`)
 	}
 
-	file := s.getOrCreateFile(table.Name, table.Version)
-
-	// TODO(dan): Memory monitoring for this
-	if _, err := file.Write(value); err != nil {
+	file, err := s.getOrCreateFile(table)
+	if err != nil {
 		return err
 	}
-	if err := s.recordDelimFn(file); err != nil {
-		return err
+
+	// TODO(dan): Memory monitoring for this
+	if file.parquet != nil {
+		file.rawSize += len(value)
+		if err := file.parquet.addRow(value); err != nil {
+			return err
+		}
+	} else {
+		if _, err := file.Write(value); err != nil {
+			return err
+		}
+		if err := s.recordDelimFn(file); err != nil {
+			return err
+		}
 	}
 
-	if int64(file.buf.Len()) > s.targetMaxFileSize {
+	if file.size() > s.targetMaxFileSize {
 		if err := s.flushTopicVersions(ctx, file.topic, file.schemaID); err != nil {
 			return err
 		}
@@ -505,6 +579,14 @@ func (s *cloudStorageSink) flushFile(ctx context.Context, file *cloudStorageSink
 		return nil
 	}
 
+	// Parquet files are only written to the buffer once all their rows are
+	// known.
+	if file.parquet != nil {
+		if err := file.parquet.close(); err != nil {
+			return err
+		}
+	}
+
 	// If the file is written via compression codec, close the codec to ensure it
 	// has flushed to the underlying buffer.
 	if file.codec != nil {
@@ -27,7 +27,9 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/security"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/storage/cloud"
 	"github.com/cockroachdb/cockroach/pkg/storage/cloudimpl"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
@@ -535,4 +537,141 @@ func TestCloudStorageSink(t *testing.T) {
 			"w1\n",
 		}, slurpDir(t, dir))
 	})
+	t.Run(`parquet`, func(t *testing.T) {
+		t1 := &sqlbase.TableDescriptor{
+			Name: `t1`,
+			Columns: []sqlbase.ColumnDescriptor{
+				{ID: 1, Name: `a`, Type: types.Int},
+				{ID: 2, Name: `b`, Type: types.String},
+			},
+		}
+		testSpan := roachpb.Span{Key: []byte("a"), EndKey: []byte("b")}
+		sf := span.MakeFrontier(testSpan)
+		timestampOracle := &changeAggregatorLowerBoundOracle{sf: sf}
+		sinkDir := `parquet`
+		parquetOpts := map[string]string{
+			changefeedbase.OptFormat:            string(changefeedbase.OptFormatParquet),
+			changefeedbase.OptEnvelope:          string(changefeedbase.OptEnvelopeWrapped),
+			changefeedbase.OptUpdatedTimestamps: ``,
+		}
+		pe, err := newParquetEncoder(parquetOpts)
+		require.NoError(t, err)
+		s, err := makeCloudStorageSink(
+			ctx, `nodelocal://0/`+sinkDir, 1, unlimitedFileSize,
+			settings, parquetOpts, timestampOracle, externalStorageFromURI, user,
+		)
+		require.NoError(t, err)
+
+		for _, row := range []encodeRow{{
+			datums: sqlbase.EncDatumRow{
+				sqlbase.DatumToEncDatum(types.Int, tree.NewDInt(1)),
+				sqlbase.DatumToEncDatum(types.String, tree.NewDString(`cat`)),
+			},
+			updated:   ts(1),
+			tableDesc: t1,
+		}, {
+			// Only the primary key is set for deletes.
+			datums: sqlbase.EncDatumRow{
+				sqlbase.DatumToEncDatum(types.Int, tree.NewDInt(2)),
+				{},
+			},
+			updated:   ts(2),
+			deleted:   true,
+			tableDesc: t1,
+		}} {
+			value, err := pe.EncodeValue(ctx, row)
+			require.NoError(t, err)
+			require.NoError(t, s.EmitRow(ctx, t1, noKey, value, row.updated))
+		}
+		require.NoError(t, s.Flush(ctx))
+
+		var names []string
+		require.NoError(t, filepath.Walk(filepath.Join(dir, sinkDir),
+			func(path string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					names = append(names, path)
+				}
+				return err
+			}))
+		require.Len(t, names, 1)
+		require.True(t, strings.HasSuffix(names[0], `-t1-0.parquet`), names[0])
+		file := slurpDir(t, sinkDir)[0]
+		require.True(t, strings.HasPrefix(file, `PAR1`))
+		require.True(t, strings.HasSuffix(file, `PAR1`))
+		for _, column := range []string{`a`, `b`, eventTypeColumn, updatedColumn} {
+			require.Contains(t, file, column)
+		}
+
+		_, err = makeCloudStorageSink(
+			ctx, `nodelocal://0/`+sinkDir, 1, unlimitedFileSize, settings,
+			map[string]string{
+				changefeedbase.OptFormat:      string(changefeedbase.OptFormatParquet),
+				changefeedbase.OptCompression: `zstd`,
+			}, timestampOracle, externalStorageFromURI, user,
+		)
+		require.EqualError(t, err, `unsupported compression codec "zstd"`)
+	})
+	t.Run(`csv`, func(t *testing.T) {
+		t1 := &sqlbase.TableDescriptor{
+			Name: `t1`,
+			Columns: []sqlbase.ColumnDescriptor{
+				{ID: 1, Name: `a`, Type: types.Int},
+				{ID: 2, Name: `b`, Type: types.String},
+			},
+		}
+		testSpan := roachpb.Span{Key: []byte("a"), EndKey: []byte("b")}
+		sf := span.MakeFrontier(testSpan)
+		timestampOracle := &changeAggregatorLowerBoundOracle{sf: sf}
+		sinkDir := `csv`
+		csvOpts := map[string]string{
+			changefeedbase.OptFormat:            string(changefeedbase.OptFormatCSV),
+			changefeedbase.OptEnvelope:          string(changefeedbase.OptEnvelopeBare),
+			changefeedbase.OptUpdatedTimestamps: ``,
+		}
+		ce, err := newCSVEncoder(csvOpts)
+		require.NoError(t, err)
+		s, err := makeCloudStorageSink(
+			ctx, `nodelocal://0/`+sinkDir, 1, unlimitedFileSize,
+			settings, csvOpts, timestampOracle, externalStorageFromURI, user,
+		)
+		require.NoError(t, err)
+
+		for _, row := range []encodeRow{{
+			datums: sqlbase.EncDatumRow{
+				sqlbase.DatumToEncDatum(types.Int, tree.NewDInt(1)),
+				sqlbase.DatumToEncDatum(types.String, tree.NewDString(`c,at`)),
+			},
+			updated:   ts(1),
+			tableDesc: t1,
+		}, {
+			// Only the primary key is set for deletes.
+			datums: sqlbase.EncDatumRow{
+				sqlbase.DatumToEncDatum(types.Int, tree.NewDInt(2)),
+				{},
+			},
+			updated:   ts(2),
+			deleted:   true,
+			tableDesc: t1,
+		}} {
+			value, err := ce.EncodeValue(ctx, row)
+			require.NoError(t, err)
+			require.NoError(t, s.EmitRow(ctx, t1, noKey, value, row.updated))
+		}
+		require.NoError(t, s.Flush(ctx))
+
+		require.Equal(t, []string{
+			"a,b,__crdb__event_type,__crdb__updated\n" +
+				"1,\"c,at\",upsert," + ts(1).AsOfSystemTime() + "\n" +
+				"2,,delete," + ts(2).AsOfSystemTime() + "\n",
+		}, slurpDir(t, sinkDir))
+
+		_, err = makeCloudStorageSink(
+			ctx, `nodelocal://0/`+sinkDir, 1, unlimitedFileSize, settings,
+			map[string]string{
+				changefeedbase.OptFormat:   string(changefeedbase.OptFormatCSV),
+				changefeedbase.OptEnvelope: string(changefeedbase.OptEnvelopeKeyOnly),
+			}, timestampOracle, externalStorageFromURI, user,
+		)
+		require.EqualError(t, err, `this sink is incompatible with envelope=key_only`)
+	})
 }
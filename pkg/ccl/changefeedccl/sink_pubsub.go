@@ -0,0 +1,303 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/cloudimpl"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/errors"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+)
+
+const (
+	// pubsubMaxInflightBytes bounds the size of the messages which are
+	// published but not yet acknowledged. EmitRow flushes the sink when it is
+	// reached, which keeps the publisher well below its own buffer limit.
+	pubsubMaxInflightBytes = 32 << 20 // 32 MiB
+
+	defaultPubsubRetryMax     = 3
+	defaultPubsubRetryBackoff = 500 * time.Millisecond
+	maxPubsubRetryBackoff     = 30 * time.Second
+)
+
+func isPubsubSink(u *url.URL) bool {
+	return u.Scheme == changefeedbase.SinkSchemeGCPubsub
+}
+
+// pubsubSinkConfig is the configuration of a Pub/Sub sink, parsed from a sink
+// URI of the form
+//
+//   gcpubsub://<project>?region=<region>&AUTH=specified&CREDENTIALS=<base64 key>
+//
+// The topics are named like the topics of the Kafka sink, with the
+// topic_prefix and topic_name params.
+type pubsubSinkConfig struct {
+	projectID         string
+	region            string
+	topicPrefix       string
+	topicNameTemplate string
+
+	splitColumnFamilies bool
+}
+
+// makePubsubClientOptions returns the options of the Pub/Sub client for the
+// AUTH and CREDENTIALS params of a sink URI, which have the same meaning as for
+// the gs:// cloud storage URIs, and the region param. Ordering keys are only
+// guaranteed to be honored by a regional endpoint.
+func makePubsubClientOptions(
+	ctx context.Context, q url.Values, region string,
+) ([]option.ClientOption, error) {
+	var opts []option.ClientOption
+	if region != `` {
+		opts = append(opts, option.WithEndpoint(region+`-pubsub.googleapis.com:443`))
+	}
+	switch auth := q.Get(cloudimpl.AuthParam); auth {
+	case ``, cloudimpl.AuthParamSpecified:
+		creds := q.Get(cloudimpl.CredentialsParam)
+		if creds == `` {
+			return nil, errors.Errorf(`%s is set to '%s', but %s is not set`,
+				cloudimpl.AuthParam, cloudimpl.AuthParamSpecified, cloudimpl.CredentialsParam)
+		}
+		decodedKey, err := base64.StdEncoding.DecodeString(creds)
+		if err != nil {
+			return nil, errors.Wrapf(err, `decoding value of %s`, cloudimpl.CredentialsParam)
+		}
+		source, err := google.JWTConfigFromJSON(decodedKey, pubsub.ScopePubSub)
+		if err != nil {
+			return nil, errors.Wrap(err, `creating Pub/Sub oauth token source from specified credentials`)
+		}
+		opts = append(opts, option.WithTokenSource(source.TokenSource(ctx)))
+	case cloudimpl.AuthParamImplicit:
+		// Use the application default credentials:
+		// https://godoc.org/golang.org/x/oauth2/google#FindDefaultCredentials
+	default:
+		return nil, errors.Errorf(`unsupported value %s for %s`, auth, cloudimpl.AuthParam)
+	}
+	return opts, nil
+}
+
+// pubsubMessage is a message which has been published, and which is kept
+// until it is acknowledged so that it can be published again if it failed.
+type pubsubMessage struct {
+	topic *pubsub.Topic
+	msg   *pubsub.Message
+	res   *pubsub.PublishResult
+}
+
+// pubsubSink emits to Google Cloud Pub/Sub. The rows of a table are published
+// to its topic with the encoded key of the row as ordering key, so that the
+// messages of a row are delivered in order to the subscriptions that enable
+// message ordering. It is not concurrency-safe; all calls to Emit and Flush
+// should be from the same goroutine.
+type pubsubSink struct {
+	cfg       pubsubSinkConfig
+	retryOpts retry.Options
+	client    *pubsub.Client
+	targets   jobspb.ChangefeedTargets
+	topics    map[string]*pubsub.Topic
+
+	inflight      []pubsubMessage
+	inflightBytes int
+}
+
+func makePubsubSink(
+	ctx context.Context,
+	cfg pubsubSinkConfig,
+	targets jobspb.ChangefeedTargets,
+	opts map[string]string,
+	clientOpts ...option.ClientOption,
+) (Sink, error) {
+	switch changefeedbase.FormatType(opts[changefeedbase.OptFormat]) {
+	case changefeedbase.OptFormatJSON:
+	default:
+		return nil, errors.Errorf(`this sink is incompatible with %s=%s`,
+			changefeedbase.OptFormat, opts[changefeedbase.OptFormat])
+	}
+	switch changefeedbase.EnvelopeType(opts[changefeedbase.OptEnvelope]) {
+	case changefeedbase.OptEnvelopeWrapped:
+	default:
+		return nil, errors.Errorf(`this sink is incompatible with %s=%s`,
+			changefeedbase.OptEnvelope, opts[changefeedbase.OptEnvelope])
+	}
+	if _, ok := opts[changefeedbase.OptKeyInValue]; !ok {
+		return nil, errors.Errorf(`this sink requires the WITH %s option`, changefeedbase.OptKeyInValue)
+	}
+
+	client, err := pubsub.NewClient(ctx, cfg.projectID, clientOpts...)
+	if err != nil {
+		return nil, errors.Wrap(err, `creating Pub/Sub client`)
+	}
+	s := &pubsubSink{
+		cfg: cfg,
+		retryOpts: retry.Options{
+			InitialBackoff: defaultPubsubRetryBackoff,
+			MaxBackoff:     maxPubsubRetryBackoff,
+			Multiplier:     2,
+			MaxRetries:     defaultPubsubRetryMax,
+		},
+		client:  client,
+		targets: targets,
+		topics:  make(map[string]*pubsub.Topic),
+	}
+	for _, t := range targets {
+		if _, err := s.declareTopic(ctx, s.topicName(t, t.StatementTimeName)); err != nil {
+			_ = s.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *pubsubSink) topicName(target jobspb.ChangefeedTarget, tableName string) string {
+	return topicNameForTarget(s.cfg.topicPrefix, s.cfg.topicNameTemplate, target, tableName)
+}
+
+// declareTopic returns the topic with the given name, which is created if it
+// does not exist yet.
+func (s *pubsubSink) declareTopic(ctx context.Context, name string) (*pubsub.Topic, error) {
+	if t, ok := s.topics[name]; ok {
+		return t, nil
+	}
+	t := s.client.Topic(name)
+	exists, err := t.Exists(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, `checking Pub/Sub topic %s`, name)
+	}
+	if !exists {
+		if t, err = s.client.CreateTopic(ctx, name); err != nil {
+			return nil, errors.Wrapf(err, `creating Pub/Sub topic %s`, name)
+		}
+	}
+	t.EnableMessageOrdering = true
+	s.topics[name] = t
+	return t, nil
+}
+
+// EmitRow implements the Sink interface.
+func (s *pubsubSink) EmitRow(
+	ctx context.Context, table *sqlbase.TableDescriptor, key, value []byte, _ hlc.Timestamp,
+) error {
+	target, watched := s.targets[table.ID]
+	name := s.topicName(target, table.Name)
+	topic, ok := s.topics[name]
+	if !ok {
+		// See the comment in kafkaSink.EmitRow.
+		if !s.cfg.splitColumnFamilies || !watched {
+			return errors.Errorf(`cannot emit to undeclared topic: %s`, name)
+		}
+		var err error
+		if topic, err = s.declareTopic(ctx, name); err != nil {
+			return err
+		}
+	}
+	if s.inflightBytes >= pubsubMaxInflightBytes {
+		if err := s.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	// The key and the value are retained until the message is acknowledged,
+	// so they have to be copied.
+	s.publish(ctx, topic, &pubsub.Message{
+		Data:        append([]byte(nil), value...),
+		OrderingKey: string(key),
+	})
+	return nil
+}
+
+// EmitResolvedTimestamp implements the Sink interface.
+func (s *pubsubSink) EmitResolvedTimestamp(
+	ctx context.Context, encoder Encoder, resolved hlc.Timestamp,
+) error {
+	// Resolved timestamps are published without ordering key. The rows that
+	// they resolve have been flushed before they are emitted.
+	for name, topic := range s.topics {
+		payload, err := encoder.EncodeResolvedTimestamp(ctx, name, resolved)
+		if err != nil {
+			return err
+		}
+		s.publish(ctx, topic, &pubsub.Message{Data: append([]byte(nil), payload...)})
+	}
+	return nil
+}
+
+func (s *pubsubSink) publish(ctx context.Context, topic *pubsub.Topic, msg *pubsub.Message) {
+	s.inflight = append(s.inflight, pubsubMessage{
+		topic: topic,
+		msg:   msg,
+		res:   topic.Publish(ctx, msg),
+	})
+	s.inflightBytes += len(msg.Data)
+}
+
+// Flush implements the Sink interface. The messages that failed are published
+// again, in order, up to a few times.
+func (s *pubsubSink) Flush(ctx context.Context) error {
+	var err error
+	for r := retry.StartWithCtx(ctx, s.retryOpts); r.Next(); {
+		var failed []pubsubMessage
+		if failed, err = s.waitInflight(ctx); err == nil {
+			return nil
+		}
+		log.VEventf(ctx, 1, "pubsub sink failed to publish %d messages: %v", len(failed), err)
+		// The publishing of the messages with the same ordering key as a failed
+		// message is paused until it is resumed, so all the messages of the key
+		// after the failed one have failed as well, and are published again in
+		// order.
+		for _, m := range failed {
+			m.topic.ResumePublish(m.msg.OrderingKey)
+		}
+		for _, m := range failed {
+			s.publish(ctx, m.topic, m.msg)
+		}
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return err
+}
+
+// waitInflight waits for every inflight message to be acknowledged, and
+// returns the messages that failed along with the first error.
+func (s *pubsubSink) waitInflight(ctx context.Context) ([]pubsubMessage, error) {
+	var failed []pubsubMessage
+	var firstErr error
+	for _, m := range s.inflight {
+		if _, err := m.res.Get(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed = append(failed, pubsubMessage{topic: m.topic, msg: m.msg})
+		}
+	}
+	s.inflight, s.inflightBytes = nil, 0
+	return failed, firstErr
+}
+
+// Close implements the Sink interface.
+func (s *pubsubSink) Close() error {
+	for _, t := range s.topics {
+		t.Stop()
+	}
+	return s.client.Close()
+}
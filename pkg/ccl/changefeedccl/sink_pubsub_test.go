@@ -0,0 +1,213 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+const pubsubTestProject = `test-project`
+
+// startPubsubTestServer starts an in-process fake Pub/Sub server and returns
+// a client connected to it, along with the options to connect more clients.
+func startPubsubTestServer(t *testing.T) (*pubsub.Client, []option.ClientOption, func()) {
+	ctx := context.Background()
+	srv := pstest.NewServer()
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	clientOpts := []option.ClientOption{option.WithGRPCConn(conn)}
+	client, err := pubsub.NewClient(ctx, pubsubTestProject, clientOpts...)
+	require.NoError(t, err)
+	return client, clientOpts, func() {
+		require.NoError(t, client.Close())
+		require.NoError(t, conn.Close())
+		require.NoError(t, srv.Close())
+	}
+}
+
+// receivePubsubMessages receives and acknowledges n messages of the given
+// subscription, one at a time.
+func receivePubsubMessages(t *testing.T, sub *pubsub.Subscription, n int) []*pubsub.Message {
+	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
+	defer cancel()
+	sub.ReceiveSettings.Synchronous = true
+	sub.ReceiveSettings.MaxOutstandingMessages = 1
+	var mu syncutil.Mutex
+	var msgs []*pubsub.Message
+	require.NoError(t, sub.Receive(ctx, func(_ context.Context, m *pubsub.Message) {
+		m.Ack()
+		mu.Lock()
+		defer mu.Unlock()
+		msgs = append(msgs, m)
+		if len(msgs) == n {
+			cancel()
+		}
+	}))
+	require.Len(t, msgs, n)
+	return msgs
+}
+
+func pubsubTestSinkOpts() map[string]string {
+	return map[string]string{
+		changefeedbase.OptFormat:     string(changefeedbase.OptFormatJSON),
+		changefeedbase.OptEnvelope:   string(changefeedbase.OptEnvelopeWrapped),
+		changefeedbase.OptKeyInValue: ``,
+	}
+}
+
+func TestPubsubSink(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	client, clientOpts, cleanup := startPubsubTestServer(t)
+	defer cleanup()
+
+	targets := jobspb.ChangefeedTargets{
+		1: jobspb.ChangefeedTarget{StatementTimeName: `foo`},
+		2: jobspb.ChangefeedTarget{StatementTimeName: `bar`},
+	}
+	table := func(id sqlbase.ID) *sqlbase.TableDescriptor {
+		return &sqlbase.TableDescriptor{ID: id, Name: targets[id].StatementTimeName}
+	}
+
+	// Messages are only delivered to the subscriptions that exist when they
+	// are published, so the topic of foo is created ahead of the sink, which
+	// creates the topic of bar.
+	fooTopic, err := client.CreateTopic(ctx, `foo`)
+	require.NoError(t, err)
+	fooSub, err := client.CreateSubscription(ctx, `foo-sub`, pubsub.SubscriptionConfig{
+		Topic: fooTopic, EnableMessageOrdering: true,
+	})
+	require.NoError(t, err)
+
+	sink, err := makePubsubSink(ctx, pubsubSinkConfig{projectID: pubsubTestProject},
+		targets, pubsubTestSinkOpts(), clientOpts...)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, sink.Close()) }()
+
+	barExists, err := client.Topic(`bar`).Exists(ctx)
+	require.NoError(t, err)
+	require.True(t, barExists)
+	barSub, err := client.CreateSubscription(ctx, `bar-sub`, pubsub.SubscriptionConfig{
+		Topic: client.Topic(`bar`), EnableMessageOrdering: true,
+	})
+	require.NoError(t, err)
+
+	// Empty
+	require.NoError(t, sink.Flush(ctx))
+
+	// Undeclared topic
+	require.EqualError(t,
+		sink.EmitRow(ctx, &sqlbase.TableDescriptor{ID: 3, Name: `nope`}, nil, nil, zeroTS),
+		`cannot emit to undeclared topic: nope`)
+
+	// The rows are published with their keys as ordering keys.
+	require.NoError(t, sink.EmitRow(ctx, table(1), []byte(`[1]`), []byte(`v1`), zeroTS))
+	require.NoError(t, sink.EmitRow(ctx, table(1), []byte(`[2]`), []byte(`v2`), zeroTS))
+	require.NoError(t, sink.EmitRow(ctx, table(2), []byte(`[1]`), []byte(`v3`), zeroTS))
+	require.NoError(t, sink.EmitRow(ctx, table(1), []byte(`[1]`), []byte(`v4`), zeroTS))
+	require.NoError(t, sink.Flush(ctx))
+
+	type message struct{ key, value string }
+	received := func(sub *pubsub.Subscription, n int) []message {
+		var ret []message
+		for _, m := range receivePubsubMessages(t, sub, n) {
+			ret = append(ret, message{key: m.OrderingKey, value: string(m.Data)})
+		}
+		return ret
+	}
+	require.Equal(t, []message{{`[1]`, `v1`}, {`[2]`, `v2`}, {`[1]`, `v4`}}, received(fooSub, 3))
+	require.Equal(t, []message{{`[1]`, `v3`}}, received(barSub, 1))
+
+	// Resolved timestamps are published to every topic.
+	require.NoError(t, sink.EmitResolvedTimestamp(ctx, testEncoder{}, hlc.Timestamp{WallTime: 2}))
+	require.NoError(t, sink.Flush(ctx))
+	resolved := hlc.Timestamp{WallTime: 2}.String()
+	require.Equal(t, []message{{``, resolved}}, received(fooSub, 1))
+	require.Equal(t, []message{{``, resolved}}, received(barSub, 1))
+}
+
+func TestPubsubSinkTopicNames(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	client, clientOpts, cleanup := startPubsubTestServer(t)
+	defer cleanup()
+
+	targets := jobspb.ChangefeedTargets{
+		1: jobspb.ChangefeedTarget{
+			StatementTimeName:         `foo`,
+			StatementTimeDatabaseName: `d`,
+			StatementTimeSchemaName:   `public`,
+		},
+	}
+	cfg := pubsubSinkConfig{
+		projectID:         pubsubTestProject,
+		topicPrefix:       `cdc_`,
+		topicNameTemplate: `{database}.{schema}.{table}`,
+	}
+	sink, err := makePubsubSink(ctx, cfg, targets, pubsubTestSinkOpts(), clientOpts...)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, sink.Close()) }()
+
+	exists, err := client.Topic(`cdc_d.public.foo`).Exists(ctx)
+	require.NoError(t, err)
+	require.True(t, exists)
+}
+
+func TestPubsubSinkConfig(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	cfg := pubsubSinkConfig{projectID: pubsubTestProject}
+
+	opts := pubsubTestSinkOpts()
+	opts[changefeedbase.OptFormat] = string(changefeedbase.OptFormatAvro)
+	_, err := makePubsubSink(ctx, cfg, nil, opts)
+	require.EqualError(t, err, `this sink is incompatible with format=experimental_avro`)
+
+	opts = pubsubTestSinkOpts()
+	opts[changefeedbase.OptEnvelope] = string(changefeedbase.OptEnvelopeKeyOnly)
+	_, err = makePubsubSink(ctx, cfg, nil, opts)
+	require.EqualError(t, err, `this sink is incompatible with envelope=key_only`)
+
+	opts = pubsubTestSinkOpts()
+	delete(opts, changefeedbase.OptKeyInValue)
+	_, err = makePubsubSink(ctx, cfg, nil, opts)
+	require.EqualError(t, err, `this sink requires the WITH key_in_value option`)
+
+	_, err = makePubsubClientOptions(ctx, url.Values{}, ``)
+	require.EqualError(t, err, `AUTH is set to 'specified', but CREDENTIALS is not set`)
+	_, err = makePubsubClientOptions(ctx, url.Values{`AUTH`: {`nope`}}, ``)
+	require.EqualError(t, err, `unsupported value nope for AUTH`)
+	_, err = makePubsubClientOptions(ctx, url.Values{`CREDENTIALS`: {`!`}}, ``)
+	require.Error(t, err)
+	clientOpts, err := makePubsubClientOptions(ctx, url.Values{`AUTH`: {`implicit`}}, `us-east1`)
+	require.NoError(t, err)
+	require.Len(t, clientOpts, 1)
+}
@@ -168,6 +168,74 @@ func TestKafkaSinkEscaping(t *testing.T) {
 	require.Equal(t, sarama.ByteEncoder(`v☃`), m.Value)
 }
 
+func TestKafkaSinkTopicNameTemplate(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	targets := jobspb.ChangefeedTargets{
+		1: jobspb.ChangefeedTarget{
+			StatementTimeName:         `foo`,
+			StatementTimeDatabaseName: `d`,
+			StatementTimeSchemaName:   `public`,
+		},
+		2: jobspb.ChangefeedTarget{
+			StatementTimeName:         `bar`,
+			StatementTimeDatabaseName: `d`,
+			StatementTimeSchemaName:   `public`,
+		},
+	}
+	topics := func(cfg kafkaSinkConfig) map[string]struct{} {
+		topics := make(map[string]struct{})
+		for _, t := range targets {
+			topics[cfg.topicName(t, t.StatementTimeName)] = struct{}{}
+		}
+		return topics
+	}
+
+	tests := []struct {
+		cfg    kafkaSinkConfig
+		topics []string
+	}{
+		{
+			cfg:    kafkaSinkConfig{kafkaTopicPrefix: `p_`},
+			topics: []string{`p_foo`, `p_bar`},
+		},
+		{
+			cfg:    kafkaSinkConfig{topicNameTemplate: `{database}.{schema}.{table}`},
+			topics: []string{`d.public.foo`, `d.public.bar`},
+		},
+		{
+			cfg:    kafkaSinkConfig{kafkaTopicPrefix: `p_`, topicNameTemplate: `all`},
+			topics: []string{`p_all`, `p_all`},
+		},
+	}
+	for _, test := range tests {
+		p := asyncProducerMock{
+			inputCh:     make(chan *sarama.ProducerMessage, 1),
+			successesCh: make(chan *sarama.ProducerMessage, 1),
+			errorsCh:    make(chan *sarama.ProducerError, 1),
+		}
+		sink := &kafkaSink{
+			cfg:      test.cfg,
+			producer: p,
+			targets:  targets,
+			topics:   topics(test.cfg),
+		}
+		sink.start()
+		for i, id := range []sqlbase.ID{1, 2} {
+			table := &sqlbase.TableDescriptor{ID: id, Name: targets[id].StatementTimeName}
+			require.NoError(t, sink.EmitRow(ctx, table, []byte(`k`), []byte(`v`), zeroTS))
+			m := <-p.inputCh
+			require.Equal(t, test.topics[i], m.Topic)
+		}
+		require.EqualError(t,
+			sink.EmitRow(ctx, &sqlbase.TableDescriptor{ID: 3, Name: `baz`}, nil, nil, zeroTS),
+			`cannot emit to undeclared topic: `+test.cfg.topicName(jobspb.ChangefeedTarget{}, `baz`))
+		require.NoError(t, sink.Close())
+	}
+}
+
 type testEncoder struct{}
 
 func (testEncoder) EncodeKey(context.Context, encodeRow) ([]byte, error)   { panic(`unimplemented`) }
@@ -200,7 +268,7 @@ func TestSQLSink(t *testing.T) {
 		0: jobspb.ChangefeedTarget{StatementTimeName: `foo`},
 		1: jobspb.ChangefeedTarget{StatementTimeName: `bar`},
 	}
-	sink, err := makeSQLSink(sinkURL.String(), `sink`, targets)
+	sink, err := makeSQLSink(sinkURL.String(), `sink`, targets, false /* splitColumnFamilies */)
 	require.NoError(t, err)
 	defer func() { require.NoError(t, sink.Close()) }()
 
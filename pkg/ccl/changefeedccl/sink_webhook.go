@@ -0,0 +1,493 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	applicationTypeJSON = `application/json`
+	applicationTypeCSV  = `text/csv`
+	authorizationHeader = `Authorization`
+
+	defaultWebhookClientTimeout = 3 * time.Second
+	defaultWebhookRetryMax      = 3
+	defaultWebhookRetryBackoff  = 500 * time.Millisecond
+	maxWebhookRetryBackoff      = 30 * time.Second
+)
+
+func isWebhookSink(u *url.URL) bool {
+	return u.Scheme == changefeedbase.SinkSchemeWebhookHTTPS
+}
+
+// webhookSinkConfig is the JSON representation of the webhook_sink_config
+// option, e.g.
+//
+//   {"Flush": {"Messages": 100, "Frequency": "1s"}, "Retry": {"Max": 5, "Backoff": "1s"}}
+//
+// A batch of rows is sent as soon as it reaches Flush.Messages rows or
+// Flush.Bytes bytes, or Flush.Frequency after its first row was emitted,
+// whichever comes first. If none of them are set, every row is sent on its
+// own. A failed request is retried up to Retry.Max times, with an exponential
+// backoff starting at Retry.Backoff.
+type webhookSinkConfig struct {
+	Flush struct {
+		Messages  int
+		Bytes     int
+		Frequency string
+	}
+	Retry struct {
+		Max     int
+		Backoff string
+	}
+}
+
+// webhookBatchConfig is the parsed Flush section of a webhookSinkConfig.
+type webhookBatchConfig struct {
+	messages  int
+	bytes     int
+	frequency time.Duration
+}
+
+// shouldFlush returns whether a batch with the given number of rows and bytes
+// must be sent right away.
+func (c webhookBatchConfig) shouldFlush(messages, bytes int) bool {
+	switch {
+	case c.messages == 0 && c.bytes == 0 && c.frequency == 0:
+		return true
+	case c.messages > 0 && messages >= c.messages:
+		return true
+	case c.bytes > 0 && bytes >= c.bytes:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseWebhookSinkConfig parses the given webhook_sink_config option, filling
+// in the defaults for the settings that are not specified.
+func parseWebhookSinkConfig(opt string) (webhookBatchConfig, retry.Options, error) {
+	var cfg webhookSinkConfig
+	if opt != `` {
+		if err := json.Unmarshal([]byte(opt), &cfg); err != nil {
+			return webhookBatchConfig{}, retry.Options{}, errors.Wrapf(err,
+				`option %s must be a JSON object`, changefeedbase.OptWebhookSinkConfig)
+		}
+	}
+
+	parseDuration := func(name, s string, defaultValue time.Duration) (time.Duration, error) {
+		if s == `` {
+			return defaultValue, nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, errors.Wrapf(err, `%s %s must be a duration`,
+				changefeedbase.OptWebhookSinkConfig, name)
+		}
+		if d < 0 {
+			return 0, errors.Errorf(`%s %s must not be negative: %s`,
+				changefeedbase.OptWebhookSinkConfig, name, s)
+		}
+		return d, nil
+	}
+
+	if cfg.Flush.Messages < 0 || cfg.Flush.Bytes < 0 || cfg.Retry.Max < 0 {
+		return webhookBatchConfig{}, retry.Options{}, errors.Errorf(
+			`%s must not contain negative values`, changefeedbase.OptWebhookSinkConfig)
+	}
+	batchCfg := webhookBatchConfig{messages: cfg.Flush.Messages, bytes: cfg.Flush.Bytes}
+	var err error
+	if batchCfg.frequency, err = parseDuration(`Flush.Frequency`, cfg.Flush.Frequency, 0); err != nil {
+		return webhookBatchConfig{}, retry.Options{}, err
+	}
+
+	retryOpts := retry.Options{
+		MaxBackoff: maxWebhookRetryBackoff,
+		Multiplier: 2,
+		MaxRetries: cfg.Retry.Max,
+	}
+	if retryOpts.MaxRetries == 0 {
+		retryOpts.MaxRetries = defaultWebhookRetryMax
+	}
+	if retryOpts.InitialBackoff, err = parseDuration(
+		`Retry.Backoff`, cfg.Retry.Backoff, defaultWebhookRetryBackoff,
+	); err != nil {
+		return webhookBatchConfig{}, retry.Options{}, err
+	}
+	return batchCfg, retryOpts, nil
+}
+
+type webhookSinkTLSConfig struct {
+	caCert        []byte
+	clientCert    []byte
+	clientKey     []byte
+	skipTLSVerify bool
+}
+
+// makeTLSConfig returns the TLS configuration used to connect to the webhook.
+func (c webhookSinkTLSConfig) makeTLSConfig() (*tls.Config, error) {
+	tlsConf := &tls.Config{InsecureSkipVerify: c.skipTLSVerify}
+	if c.caCert != nil {
+		caCertPool, err := x509.SystemCertPool()
+		if err != nil || caCertPool == nil {
+			caCertPool = x509.NewCertPool()
+		}
+		if !caCertPool.AppendCertsFromPEM(c.caCert) {
+			return nil, errors.Errorf(`invalid %s provided`, changefeedbase.SinkParamCACert)
+		}
+		tlsConf.RootCAs = caCertPool
+	}
+	if c.clientCert != nil {
+		if c.clientKey == nil {
+			return nil, errors.Errorf(`%s requires %s to be set`,
+				changefeedbase.SinkParamClientCert, changefeedbase.SinkParamClientKey)
+		}
+		cert, err := tls.X509KeyPair(c.clientCert, c.clientKey)
+		if err != nil {
+			return nil, errors.Errorf(`invalid client certificate data provided: %s`, err)
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	} else if c.clientKey != nil {
+		return nil, errors.Errorf(`%s requires %s to be set`,
+			changefeedbase.SinkParamClientKey, changefeedbase.SinkParamClientCert)
+	}
+	return tlsConf, nil
+}
+
+// webhookSinkPayload is the body of the requests that deliver rows, with
+// format=json. With format=csv, the body is the CSV records of the rows
+// instead. Resolved timestamps are delivered in requests of their own, with
+// the encoded resolved timestamp as body.
+type webhookSinkPayload struct {
+	Payload []json.RawMessage `json:"payload"`
+	Length  int               `json:"length"`
+}
+
+// webhookMessage is a message queued for delivery by the webhook sink.
+type webhookMessage struct {
+	payload []byte
+	// resolved is set if the payload is a resolved timestamp, which is sent
+	// right after the rows that were queued before it.
+	resolved bool
+	// flushCh is set for the flush requests. The worker sends the queued rows,
+	// then the delivery error, if any, on the channel.
+	flushCh chan error
+}
+
+// webhookSink emits to an HTTPS endpoint, with batched POST requests. The
+// requests are sent by a worker goroutine, one at a time and in order. It is
+// not concurrency-safe; all calls to Emit and Flush should be from the same
+// goroutine.
+type webhookSink struct {
+	url        string
+	authHeader string
+	batchCfg   webhookBatchConfig
+	retryOpts  retry.Options
+	client     *http.Client
+	// csv is true with format=csv.
+	csv bool
+
+	// ctx is canceled when the sink is closed, which aborts any inflight
+	// request.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	eventCh chan webhookMessage
+	worker  chan struct{}
+
+	// The following fields are only accessed by the worker goroutine.
+	batch      []json.RawMessage
+	batchBytes int
+
+	mu struct {
+		syncutil.Mutex
+		// err is the first delivery error. Once it is set, no more messages
+		// are sent.
+		err error
+	}
+}
+
+func makeWebhookSink(
+	u *url.URL, tlsCfg webhookSinkTLSConfig, opts map[string]string,
+) (Sink, error) {
+	var csv bool
+	switch changefeedbase.FormatType(opts[changefeedbase.OptFormat]) {
+	case changefeedbase.OptFormatJSON:
+	case changefeedbase.OptFormatCSV:
+		csv = true
+	default:
+		return nil, errors.Errorf(`this sink is incompatible with %s=%s`,
+			changefeedbase.OptFormat, opts[changefeedbase.OptFormat])
+	}
+
+	switch changefeedbase.EnvelopeType(opts[changefeedbase.OptEnvelope]) {
+	case changefeedbase.OptEnvelopeWrapped:
+		if _, ok := opts[changefeedbase.OptKeyInValue]; !ok {
+			return nil, errors.Errorf(`this sink requires the WITH %s option`, changefeedbase.OptKeyInValue)
+		}
+	case changefeedbase.OptEnvelopeBare:
+		// The primary key columns are part of the records.
+	default:
+		return nil, errors.Errorf(`this sink is incompatible with %s=%s`,
+			changefeedbase.OptEnvelope, opts[changefeedbase.OptEnvelope])
+	}
+
+	batchCfg, retryOpts, err := parseWebhookSinkConfig(opts[changefeedbase.OptWebhookSinkConfig])
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := defaultWebhookClientTimeout
+	if t, ok := opts[changefeedbase.OptWebhookClientTimeout]; ok {
+		if timeout, err = time.ParseDuration(t); err != nil {
+			return nil, errors.Wrapf(err, `option %s must be a duration`,
+				changefeedbase.OptWebhookClientTimeout)
+		}
+		if timeout <= 0 {
+			return nil, errors.Errorf(`option %s must be positive: %s`,
+				changefeedbase.OptWebhookClientTimeout, t)
+		}
+	}
+
+	tlsConf, err := tlsCfg.makeTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	sinkURL := *u
+	sinkURL.Scheme = `https`
+
+	s := &webhookSink{
+		url:        sinkURL.String(),
+		authHeader: opts[changefeedbase.OptWebhookAuthHeader],
+		batchCfg:   batchCfg,
+		retryOpts:  retryOpts,
+		csv:        csv,
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				DialContext:     (&net.Dialer{Timeout: timeout}).DialContext,
+				TLSClientConfig: tlsConf,
+			},
+		},
+	}
+	s.start()
+	return s, nil
+}
+
+func (s *webhookSink) start() {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.eventCh = make(chan webhookMessage)
+	s.worker = make(chan struct{})
+	go s.workerLoop()
+}
+
+// EmitRow implements the Sink interface.
+func (s *webhookSink) EmitRow(
+	ctx context.Context, _ *sqlbase.TableDescriptor, _, value []byte, _ hlc.Timestamp,
+) error {
+	// The value is buffered by the worker, so it has to be copied.
+	payload := append([]byte(nil), value...)
+	return s.enqueue(ctx, webhookMessage{payload: payload})
+}
+
+// EmitResolvedTimestamp implements the Sink interface.
+func (s *webhookSink) EmitResolvedTimestamp(
+	ctx context.Context, encoder Encoder, resolved hlc.Timestamp,
+) error {
+	var noTopic string
+	payload, err := encoder.EncodeResolvedTimestamp(ctx, noTopic, resolved)
+	if err != nil {
+		return err
+	}
+	return s.enqueue(ctx, webhookMessage{payload: payload, resolved: true})
+}
+
+// Flush implements the Sink interface.
+func (s *webhookSink) Flush(ctx context.Context) error {
+	flushCh := make(chan error, 1)
+	if err := s.enqueue(ctx, webhookMessage{flushCh: flushCh}); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-flushCh:
+		return err
+	}
+}
+
+// Close implements the Sink interface.
+func (s *webhookSink) Close() error {
+	s.cancel()
+	<-s.worker
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// enqueue hands the given message to the worker goroutine. It returns the
+// delivery error of a previous message, if any.
+func (s *webhookSink) enqueue(ctx context.Context, m webhookMessage) error {
+	if err := s.err(); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.ctx.Done():
+		return errors.New(`webhook sink is closed`)
+	case s.eventCh <- m:
+		return nil
+	}
+}
+
+func (s *webhookSink) err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mu.err
+}
+
+func (s *webhookSink) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mu.err == nil {
+		s.mu.err = err
+	}
+}
+
+func (s *webhookSink) workerLoop() {
+	defer close(s.worker)
+	// The timer is reset when the first row of a batch is buffered. It may fire
+	// after the batch was already sent, in which case there is nothing to do.
+	timer := timeutil.NewTimer()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-timer.C:
+			timer.Read = true
+			s.sendBatch()
+		case m := <-s.eventCh:
+			switch {
+			case m.flushCh != nil:
+				s.sendBatch()
+				m.flushCh <- s.err()
+			case m.resolved:
+				s.sendBatch()
+				s.send(m.payload, applicationTypeJSON)
+			default:
+				if len(s.batch) == 0 && s.batchCfg.frequency > 0 {
+					timer.Reset(s.batchCfg.frequency)
+				}
+				s.batch = append(s.batch, m.payload)
+				s.batchBytes += len(m.payload)
+				if s.batchCfg.shouldFlush(len(s.batch), s.batchBytes) {
+					s.sendBatch()
+				}
+			}
+		}
+	}
+}
+
+// sendBatch sends the buffered rows, if any, in a single request.
+func (s *webhookSink) sendBatch() {
+	if len(s.batch) == 0 {
+		return
+	}
+	if s.csv {
+		payload := make([]byte, 0, s.batchBytes+len(s.batch))
+		for _, record := range s.batch {
+			payload = append(append(payload, record...), '\n')
+		}
+		s.batch, s.batchBytes = nil, 0
+		s.send(payload, applicationTypeCSV)
+		return
+	}
+	payload, err := json.Marshal(webhookSinkPayload{Payload: s.batch, Length: len(s.batch)})
+	s.batch, s.batchBytes = nil, 0
+	if err != nil {
+		s.setErr(err)
+		return
+	}
+	s.send(payload, applicationTypeJSON)
+}
+
+// send POSTs the given payload, retrying on failure. Nothing is sent once a
+// previous request has failed.
+func (s *webhookSink) send(payload []byte, contentType string) {
+	if s.err() != nil {
+		return
+	}
+	var err error
+	for r := retry.StartWithCtx(s.ctx, s.retryOpts); r.Next(); {
+		if err = s.sendOnce(payload, contentType); err == nil {
+			return
+		}
+		log.VEventf(s.ctx, 1, "webhook sink request failed: %v", err)
+	}
+	if err == nil {
+		err = s.ctx.Err()
+	}
+	s.setErr(err)
+}
+
+func (s *webhookSink) sendOnce(payload []byte, contentType string) error {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(`Content-Type`, contentType)
+	if s.authHeader != `` {
+		req.Header.Set(authorizationHeader, s.authHeader)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		const maxErrBodySize = 1 << 10
+		body, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxErrBodySize))
+		return errors.Errorf(`webhook sink: POST %s: %s: %s`, redactedWebhookURL(req.URL), res.Status, body)
+	}
+	// Drain the body so that the connection can be reused.
+	_, err = io.Copy(ioutil.Discard, res.Body)
+	return err
+}
+
+// redactedWebhookURL returns the given URL without its query parameters and
+// user info, which may contain secrets.
+func redactedWebhookURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = ``
+	return redacted.String()
+}
@@ -0,0 +1,326 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// mockWebhookServer is an HTTPS server that records the requests it receives.
+// The first failures requests are answered with an internal server error.
+type mockWebhookServer struct {
+	*httptest.Server
+	mu struct {
+		syncutil.Mutex
+		failures     int
+		bodies       []string
+		auth         []string
+		contentTypes []string
+	}
+}
+
+func makeMockWebhookServer() *mockWebhookServer {
+	s := &mockWebhookServer{}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.mu.failures > 0 {
+			s.mu.failures--
+			http.Error(w, `injected failure`, http.StatusInternalServerError)
+			return
+		}
+		s.mu.bodies = append(s.mu.bodies, string(body))
+		s.mu.auth = append(s.mu.auth, r.Header.Get(authorizationHeader))
+		s.mu.contentTypes = append(s.mu.contentTypes, r.Header.Get(`Content-Type`))
+	}))
+	return s
+}
+
+func (s *mockWebhookServer) setFailures(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mu.failures = n
+}
+
+// popBodies returns the bodies of the requests received since the last call.
+func (s *mockWebhookServer) popBodies() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bodies := s.mu.bodies
+	s.mu.bodies = nil
+	return bodies
+}
+
+func (s *mockWebhookServer) authHeaders() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.mu.auth...)
+}
+
+// popContentTypes returns the content types of the requests received since
+// the last call.
+func (s *mockWebhookServer) popContentTypes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	contentTypes := s.mu.contentTypes
+	s.mu.contentTypes = nil
+	return contentTypes
+}
+
+// sinkURI returns a webhook sink URI for the server with the given extra
+// query parameters.
+func (s *mockWebhookServer) sinkURI(t *testing.T, params url.Values) string {
+	u, err := url.Parse(s.URL)
+	require.NoError(t, err)
+	u.Scheme = changefeedbase.SinkSchemeWebhookHTTPS
+	u.Path = `/feed`
+	u.RawQuery = params.Encode()
+	return u.String()
+}
+
+// caCertParams returns the query parameters that make the sink trust the
+// certificate of the server.
+func (s *mockWebhookServer) caCertParams() url.Values {
+	caCert := pem.EncodeToMemory(&pem.Block{Type: `CERTIFICATE`, Bytes: s.Certificate().Raw})
+	return url.Values{
+		changefeedbase.SinkParamCACert: {base64.StdEncoding.EncodeToString(caCert)},
+	}
+}
+
+func webhookSinkOpts(sinkConfig string) map[string]string {
+	return map[string]string{
+		changefeedbase.OptFormat:            string(changefeedbase.OptFormatJSON),
+		changefeedbase.OptEnvelope:          string(changefeedbase.OptEnvelopeWrapped),
+		changefeedbase.OptKeyInValue:        ``,
+		changefeedbase.OptWebhookSinkConfig: sinkConfig,
+	}
+}
+
+func makeTestWebhookSink(t *testing.T, sinkURI string, opts map[string]string) Sink {
+	sink, err := getSink(
+		context.Background(), sinkURI, 1 /* nodeID */, opts, nil, /* targets */
+		nil /* settings */, nil /* timestampOracle */, nil /* makeExternalStorageFromURI */, ``,
+	)
+	require.NoError(t, err)
+	return sink
+}
+
+func TestWebhookSink(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	table := &sqlbase.TableDescriptor{Name: `foo`}
+	row := func(i string) []byte { return []byte(`{"after": {"a": ` + i + `}}`) }
+
+	srv := makeMockWebhookServer()
+	defer srv.Close()
+
+	t.Run("batches", func(t *testing.T) {
+		opts := webhookSinkOpts(`{"Flush": {"Messages": 2}, "Retry": {"Backoff": "1ms"}}`)
+		opts[changefeedbase.OptWebhookAuthHeader] = `Basic c2VjcmV0`
+		sink := makeTestWebhookSink(t, srv.sinkURI(t, srv.caCertParams()), opts)
+		defer func() { require.NoError(t, sink.Close()) }()
+
+		for _, i := range []string{`1`, `2`, `3`} {
+			require.NoError(t, sink.EmitRow(ctx, table, nil, row(i), hlc.Timestamp{}))
+		}
+		require.NoError(t, sink.Flush(ctx))
+		require.Equal(t, []string{
+			`{"payload":[{"after":{"a":1}},{"after":{"a":2}}],"length":2}`,
+			`{"payload":[{"after":{"a":3}}],"length":1}`,
+		}, srv.popBodies())
+
+		// Resolved timestamps are sent on their own, after the rows emitted
+		// before them.
+		encoder, err := makeJSONEncoder(opts)
+		require.NoError(t, err)
+		require.NoError(t, sink.EmitRow(ctx, table, nil, row(`4`), hlc.Timestamp{}))
+		require.NoError(t, sink.EmitResolvedTimestamp(ctx, encoder, hlc.Timestamp{WallTime: 1}))
+		require.NoError(t, sink.Flush(ctx))
+		require.Equal(t, []string{
+			`{"payload":[{"after":{"a":4}}],"length":1}`,
+			`{"resolved":"1.0000000000"}`,
+		}, srv.popBodies())
+
+		for _, auth := range srv.authHeaders() {
+			require.Equal(t, `Basic c2VjcmV0`, auth)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		opts := map[string]string{
+			changefeedbase.OptFormat:            string(changefeedbase.OptFormatCSV),
+			changefeedbase.OptEnvelope:          string(changefeedbase.OptEnvelopeBare),
+			changefeedbase.OptWebhookSinkConfig: `{"Flush": {"Messages": 2}}`,
+		}
+		sink := makeTestWebhookSink(t, srv.sinkURI(t, srv.caCertParams()), opts)
+		defer func() { require.NoError(t, sink.Close()) }()
+		srv.popContentTypes()
+
+		// The records of a batch are sent one per line.
+		encoder, err := newCSVEncoder(opts)
+		require.NoError(t, err)
+		require.NoError(t, sink.EmitRow(ctx, table, nil, []byte(`1,upsert`), hlc.Timestamp{}))
+		require.NoError(t, sink.EmitRow(ctx, table, nil, []byte(`2,delete`), hlc.Timestamp{}))
+		require.NoError(t, sink.EmitResolvedTimestamp(ctx, encoder, hlc.Timestamp{WallTime: 1}))
+		require.NoError(t, sink.Flush(ctx))
+		require.Equal(t, []string{
+			"1,upsert\n2,delete\n",
+			`{"resolved":"1.0000000000"}`,
+		}, srv.popBodies())
+		require.Equal(t, []string{applicationTypeCSV, applicationTypeJSON}, srv.popContentTypes())
+	})
+
+	t.Run("frequency", func(t *testing.T) {
+		opts := webhookSinkOpts(`{"Flush": {"Messages": 100, "Frequency": "10ms"}}`)
+		sink := makeTestWebhookSink(t, srv.sinkURI(t, srv.caCertParams()), opts)
+		defer func() { require.NoError(t, sink.Close()) }()
+
+		// The row is sent without a call to Flush.
+		require.NoError(t, sink.EmitRow(ctx, table, nil, row(`1`), hlc.Timestamp{}))
+		testutils.SucceedsSoon(t, func() error {
+			if bodies := srv.popBodies(); len(bodies) != 1 {
+				return errors.Errorf(`expected 1 request, got %d`, len(bodies))
+			}
+			return nil
+		})
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		opts := webhookSinkOpts(`{"Retry": {"Max": 2, "Backoff": "1ms"}}`)
+		sink := makeTestWebhookSink(t, srv.sinkURI(t, srv.caCertParams()), opts)
+		defer func() { require.NoError(t, sink.Close()) }()
+
+		srv.setFailures(2)
+		require.NoError(t, sink.EmitRow(ctx, table, nil, row(`1`), hlc.Timestamp{}))
+		require.NoError(t, sink.Flush(ctx))
+		require.Equal(t, []string{`{"payload":[{"after":{"a":1}}],"length":1}`}, srv.popBodies())
+
+		// Once the retries are exhausted, the error is returned and nothing
+		// else is sent.
+		srv.setFailures(3)
+		require.NoError(t, sink.EmitRow(ctx, table, nil, row(`2`), hlc.Timestamp{}))
+		require.Regexp(t, `500 Internal Server Error: injected failure`, sink.Flush(ctx))
+		require.Regexp(t, `injected failure`,
+			sink.EmitRow(ctx, table, nil, row(`3`), hlc.Timestamp{}))
+		require.Empty(t, srv.popBodies())
+	})
+
+	t.Run("tls", func(t *testing.T) {
+		opts := webhookSinkOpts(`{"Retry": {"Backoff": "1ms"}}`)
+
+		// The certificate of the server is not trusted by default.
+		sink := makeTestWebhookSink(t, srv.sinkURI(t, nil), opts)
+		require.NoError(t, sink.EmitRow(ctx, table, nil, row(`1`), hlc.Timestamp{}))
+		require.Regexp(t, `certificate`, sink.Flush(ctx))
+		require.NoError(t, sink.Close())
+
+		sink = makeTestWebhookSink(t, srv.sinkURI(t, url.Values{
+			changefeedbase.SinkParamSkipTLSVerify: {`true`},
+		}), opts)
+		require.NoError(t, sink.EmitRow(ctx, table, nil, row(`1`), hlc.Timestamp{}))
+		require.NoError(t, sink.Flush(ctx))
+		require.NoError(t, sink.Close())
+		require.Len(t, srv.popBodies(), 1)
+	})
+}
+
+func TestWebhookSinkConfigErrors(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	for _, tc := range []struct {
+		uri  string
+		opts map[string]string
+		err  string
+	}{
+		{
+			uri:  `webhook-https://nope/?ca_cert=!`,
+			opts: webhookSinkOpts(``),
+			err:  `param ca_cert must be base 64 encoded`,
+		},
+		{
+			uri:  `webhook-https://nope/?ca_cert=Zm9v`,
+			opts: webhookSinkOpts(``),
+			err:  `invalid ca_cert provided`,
+		},
+		{
+			uri:  `webhook-https://nope/?client_cert=Zm9v`,
+			opts: webhookSinkOpts(``),
+			err:  `client_cert requires client_key to be set`,
+		},
+		{
+			uri:  `webhook-https://nope/?insecure_tls_skip_verify=maybe`,
+			opts: webhookSinkOpts(``),
+			err:  `param insecure_tls_skip_verify must be a bool`,
+		},
+		{
+			uri:  `webhook-https://nope/`,
+			opts: webhookSinkOpts(`{"Flush": {"Frequency": "soon"}}`),
+			err:  `webhook_sink_config Flush.Frequency must be a duration`,
+		},
+		{
+			uri:  `webhook-https://nope/`,
+			opts: webhookSinkOpts(`{"Retry": {"Max": -1}}`),
+			err:  `webhook_sink_config must not contain negative values`,
+		},
+		{
+			uri:  `webhook-https://nope/`,
+			opts: webhookSinkOpts(`[]`),
+			err:  `option webhook_sink_config must be a JSON object`,
+		},
+		{
+			uri: `webhook-https://nope/`,
+			opts: map[string]string{
+				changefeedbase.OptFormat:   string(changefeedbase.OptFormatAvro),
+				changefeedbase.OptEnvelope: string(changefeedbase.OptEnvelopeWrapped),
+			},
+			err: `this sink is incompatible with format=experimental_avro`,
+		},
+		{
+			uri: `webhook-https://nope/`,
+			opts: map[string]string{
+				changefeedbase.OptFormat:               string(changefeedbase.OptFormatJSON),
+				changefeedbase.OptEnvelope:             string(changefeedbase.OptEnvelopeWrapped),
+				changefeedbase.OptKeyInValue:           ``,
+				changefeedbase.OptWebhookClientTimeout: `-1s`,
+			},
+			err: `option webhook_client_timeout must be positive`,
+		},
+	} {
+		_, err := getSink(
+			context.Background(), tc.uri, 1 /* nodeID */, tc.opts, nil, /* targets */
+			nil /* settings */, nil /* timestampOracle */, nil /* makeExternalStorageFromURI */, ``,
+		)
+		require.Regexp(t, tc.err, err, tc.uri)
+	}
+}
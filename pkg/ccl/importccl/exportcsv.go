@@ -32,6 +32,17 @@ import (
 const exportFilePatternPart = "%part%"
 const exportFilePatternDefault = exportFilePatternPart + ".csv"
 
+// exporter buffers the rows of an exported file, which is written to the
+// external storage once it is closed.
+type exporter interface {
+	Flush() error
+	Close() error
+	ResetBuffer()
+	Bytes() []byte
+	Len() int
+	FileName(spec execinfrapb.CSVWriterSpec, part string) string
+}
+
 // csvExporter data structure to augment the compression
 // and csv writer, encapsulating the internals to make
 // exporting oblivious for the consumers
@@ -84,6 +95,8 @@ func (c *csvExporter) Len() int {
 	return c.buf.Len()
 }
 
+var _ exporter = &csvExporter{}
+
 func (c *csvExporter) FileName(spec execinfrapb.CSVWriterSpec, part string) string {
 	pattern := exportFilePatternDefault
 	if spec.NamePattern != "" {
@@ -185,7 +198,20 @@ func (sp *csvWriter) Run(ctx context.Context) {
 
 		alloc := &sqlbase.DatumAlloc{}
 
-		writer := newCSVExporter(sp.spec)
+		var writer exporter
+		var csvWriter *csvExporter
+		var parquetWriter *parquetExporter
+		switch sp.spec.Format {
+		case execinfrapb.ExportFormat_Parquet:
+			var err error
+			if parquetWriter, err = newParquetExporter(sp.spec, typs); err != nil {
+				return err
+			}
+			writer = parquetWriter
+		default:
+			csvWriter = newCSVExporter(sp.spec)
+			writer = csvWriter
+		}
 
 		nullsAs := ""
 		if sp.spec.Options.NullEncoding != nil {
@@ -195,6 +221,7 @@ func (sp *csvWriter) Run(ctx context.Context) {
 		defer f.Close()
 
 		csvRow := make([]string, len(typs))
+		datums := make(tree.Datums, len(typs))
 
 		chunk := 0
 		done := false
@@ -215,6 +242,18 @@ func (sp *csvWriter) Run(ctx context.Context) {
 				}
 				rows++
 
+				if parquetWriter != nil {
+					for i, ed := range row {
+						if err := ed.EnsureDecoded(typs[i], alloc); err != nil {
+							return err
+						}
+						datums[i] = ed.Datum
+					}
+					if err := parquetWriter.Write(datums); err != nil {
+						return err
+					}
+					continue
+				}
 				for i, ed := range row {
 					if ed.IsNull() {
 						csvRow[i] = nullsAs
@@ -227,7 +266,7 @@ func (sp *csvWriter) Run(ctx context.Context) {
 					csvRow[i] = f.String()
 					f.Reset()
 				}
-				if err := writer.Write(csvRow); err != nil {
+				if err := csvWriter.Write(csvRow); err != nil {
 					return err
 				}
 			}
@@ -235,7 +274,7 @@ func (sp *csvWriter) Run(ctx context.Context) {
 				break
 			}
 			if err := writer.Flush(); err != nil {
-				return errors.Wrap(err, "failed to flush csv writer")
+				return errors.Wrap(err, "failed to flush export writer")
 			}
 
 			conf, err := cloudimpl.ExternalStorageConfFromURI(sp.spec.Destination, sp.spec.User)
@@ -297,6 +297,59 @@ func TestExportOrderCompressed(t *testing.T) {
 	}
 }
 
+func TestExportParquet(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	dir, cleanupDir := testutils.TempDir(t)
+	defer cleanupDir()
+
+	srv, db, _ := serverutils.StartServer(t, base.TestServerArgs{ExternalIODir: dir})
+	defer srv.Stopper().Stop(context.Background())
+	sqlDB := sqlutils.MakeSQLRunner(db)
+
+	sqlDB.Exec(t, `CREATE TABLE foo (
+		i INT PRIMARY KEY, d DECIMAL(10,2), ts TIMESTAMPTZ, a STRING[], j JSONB
+	)`)
+	sqlDB.Exec(t, `INSERT INTO foo VALUES
+		(1, 1.5, '2020-01-01 00:00:00+00', ARRAY['a', NULL], '{"x": "y"}'),
+		(2, NULL, NULL, NULL, NULL),
+		(3, -3.25, '2020-01-03 00:00:00+00', ARRAY[], '[]')`)
+
+	for _, tc := range []struct {
+		name, opts string
+	}{
+		{name: "snappy", opts: "chunk_rows = '2'"},
+		{name: "gzip", opts: "chunk_rows = '2', compression = gzip"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rows := sqlDB.QueryStr(t, fmt.Sprintf(
+				`EXPORT INTO PARQUET 'nodelocal://0/%s' WITH %s FROM SELECT * FROM foo ORDER BY i`,
+				tc.name, tc.opts))
+			require.Len(t, rows, 2)
+			for i, expected := range []struct{ file, rows string }{
+				{file: "n1.0.parquet", rows: "2"},
+				{file: "n1.1.parquet", rows: "1"},
+			} {
+				require.Equal(t, expected.file, rows[i][0])
+				require.Equal(t, expected.rows, rows[i][1])
+				content, err := ioutil.ReadFile(filepath.Join(dir, tc.name, expected.file))
+				require.NoError(t, err)
+				require.Equal(t, rows[i][2], fmt.Sprint(len(content)))
+				require.True(t, strings.HasPrefix(string(content), "PAR1"))
+				require.True(t, strings.HasSuffix(string(content), "PAR1"))
+				for _, column := range []string{"i", "d", "ts", "a", "j"} {
+					require.Contains(t, string(content), column)
+				}
+			}
+		})
+	}
+
+	sqlDB.ExpectErr(t, "delimiter option is not supported with PARQUET export",
+		`EXPORT INTO PARQUET 'nodelocal://0/err' WITH delimiter = '|' FROM SELECT * FROM foo`)
+	sqlDB.ExpectErr(t, "nullas option is not supported with PARQUET export",
+		`EXPORT INTO PARQUET 'nodelocal://0/err' WITH nullas = '' FROM SELECT * FROM foo`)
+}
+
 func TestExportShow(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -0,0 +1,90 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package importccl
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/parquet"
+)
+
+const exportFilePatternParquet = exportFilePatternPart + ".parquet"
+
+// parquetExporter writes the exported rows to Parquet files. Unlike CSV, the
+// compression is part of the format: the pages of the files are compressed
+// with Snappy, or with gzip if requested, and the files keep their extension.
+type parquetExporter struct {
+	buf         *bytes.Buffer
+	schema      *parquet.Schema
+	compression parquet.CompressionCodec
+	writer      *parquet.Writer
+}
+
+var _ exporter = &parquetExporter{}
+
+func newParquetExporter(sp execinfrapb.CSVWriterSpec, typs []*types.T) (*parquetExporter, error) {
+	schema, err := parquet.NewSchema(sp.ColumnNames, typs)
+	if err != nil {
+		return nil, err
+	}
+	compression := parquet.CompressionSnappy
+	if sp.CompressionCodec == execinfrapb.FileCompression_Gzip {
+		compression = parquet.CompressionGZIP
+	}
+	buf := bytes.NewBuffer([]byte{})
+	return &parquetExporter{
+		buf:         buf,
+		schema:      schema,
+		compression: compression,
+		writer:      parquet.NewWriter(schema, buf, compression),
+	}, nil
+}
+
+// Write buffers a row of the current file.
+func (p *parquetExporter) Write(row tree.Datums) error {
+	return p.writer.AddRow(row)
+}
+
+// Flush is a no-op: the rows are only written to the buffer by Close.
+func (p *parquetExporter) Flush() error {
+	return nil
+}
+
+// Close writes the buffered rows and the footer of the file to the buffer.
+func (p *parquetExporter) Close() error {
+	return p.writer.Close()
+}
+
+// ResetBuffer resets the buffer and starts a new file.
+func (p *parquetExporter) ResetBuffer() {
+	p.buf.Reset()
+	p.writer = parquet.NewWriter(p.schema, p.buf, p.compression)
+}
+
+// Bytes returns the content of the file.
+func (p *parquetExporter) Bytes() []byte {
+	return p.buf.Bytes()
+}
+
+// Len returns the size of the file.
+func (p *parquetExporter) Len() int {
+	return p.buf.Len()
+}
+
+func (p *parquetExporter) FileName(spec execinfrapb.CSVWriterSpec, part string) string {
+	pattern := exportFilePatternParquet
+	if spec.NamePattern != "" {
+		pattern = spec.NamePattern
+	}
+	return strings.Replace(pattern, exportFilePatternPart, part, -1)
+}
@@ -270,6 +270,12 @@ func (es *generatorExternalStorage) ListFiles(ctx context.Context, _ string) ([]
 	return nil, errors.New("unsupported")
 }
 
+func (es *generatorExternalStorage) ListFilesWithPrefix(
+	ctx context.Context, _ string,
+) ([]string, error) {
+	return nil, errors.New("unsupported")
+}
+
 func (es *generatorExternalStorage) Delete(ctx context.Context, basename string) error {
 	return errors.New("unsupported")
 }
@@ -682,6 +682,15 @@ Disable use of implicit credentials when accessing external data.
 Instead, require the user to always specify access keys.`,
 	}
 
+	LocalKMSKeysDir = FlagInfo{
+		Name: "local-kms-keys-dir",
+		Description: `
+Directory holding the master key files which local-kms:/// URIs refer to.
+The path of such a URI is relative to this directory. It must not be inside
+the external IO directory, so that the keys cannot be read through nodelocal
+storage. If empty, local-kms is disabled.`,
+	}
+
 	// KeySize, CertificateLifetime, AllowKeyReuse, and OverwriteFiles are used for
 	// certificate generation functions.
 	KeySize = FlagInfo{
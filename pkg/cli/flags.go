@@ -410,6 +410,7 @@ func init() {
 		// Enable/disable various external storage endpoints.
 		boolFlag(f, &serverCfg.ExternalIODirConfig.DisableHTTP, cliflags.ExternalIODisableHTTP)
 		boolFlag(f, &serverCfg.ExternalIODirConfig.DisableImplicitCredentials, cliflags.ExternalIODisableImplicitCredentials)
+		stringFlag(f, &serverCfg.ExternalIODirConfig.LocalKMSKeysDir, cliflags.LocalKMSKeysDir)
 
 		// Certificates directory. Use a server-specific flag and value to ignore environment
 		// variables, but share the same default.
@@ -73,6 +73,11 @@ const (
 	VersionNodeMembershipStatus
 	VersionRangeStatsRespHasDesc
 	VersionMinPasswordLength
+	VersionNotificationsTable
+	VersionLockWaitPolicy
+	VersionDeferrableConstraints
+	VersionTriggers
+	VersionSharedLocks
 
 	// Add new versions here (step one of two).
 )
@@ -551,6 +556,36 @@ var versionsSingleton = keyedVersions([]keyedVersion{
 		Key:     VersionMinPasswordLength,
 		Version: roachpb.Version{Major: 20, Minor: 1, Unstable: 13},
 	},
+	{
+		// VersionNotificationsTable adds the system.notifications table, which
+		// backs LISTEN and NOTIFY.
+		Key:     VersionNotificationsTable,
+		Version: roachpb.Version{Major: 20, Minor: 1, Unstable: 14},
+	},
+	{
+		// VersionLockWaitPolicy adds the WaitPolicy field to the BatchRequest
+		// header, which lets requests fail instead of waiting on conflicting
+		// locks.
+		Key:     VersionLockWaitPolicy,
+		Version: roachpb.Version{Major: 20, Minor: 1, Unstable: 15},
+	},
+	{
+		// VersionDeferrableConstraints adds the deferrability of foreign key
+		// constraints to their descriptor.
+		Key:     VersionDeferrableConstraints,
+		Version: roachpb.Version{Major: 20, Minor: 1, Unstable: 16},
+	},
+	{
+		// VersionTriggers adds the triggers of a table to its descriptor.
+		Key:     VersionTriggers,
+		Version: roachpb.Version{Major: 20, Minor: 1, Unstable: 17},
+	},
+	{
+		// VersionSharedLocks lets SELECT ... FOR SHARE acquire Shared locks,
+		// which nodes running an older version would acquire as Exclusive locks.
+		Key:     VersionSharedLocks,
+		Version: roachpb.Version{Major: 20, Minor: 1, Unstable: 18},
+	},
 
 	// Add new versions here (step two of two).
 
@@ -27,6 +27,7 @@ var _ Details = SchemaChangeDetails{}
 var _ Details = ChangefeedDetails{}
 var _ Details = CreateStatsDetails{}
 var _ Details = SchemaChangeGCDetails{}
+var _ Details = RowLevelTTLDetails{}
 
 // ProgressDetails is a marker interface for job progress details proto structs.
 type ProgressDetails interface{}
@@ -37,6 +38,7 @@ var _ ProgressDetails = SchemaChangeProgress{}
 var _ ProgressDetails = ChangefeedProgress{}
 var _ ProgressDetails = CreateStatsProgress{}
 var _ ProgressDetails = SchemaChangeGCProgress{}
+var _ ProgressDetails = RowLevelTTLProgress{}
 
 // Type returns the payload's job type.
 func (p *Payload) Type() Type {
@@ -66,6 +68,8 @@ func DetailsType(d isPayload_Details) Type {
 		return TypeSchemaChangeGC
 	case *Payload_TypeSchemaChange:
 		return TypeTypeSchemaChange
+	case *Payload_RowLevelTTL:
+		return TypeRowLevelTTL
 	default:
 		panic(fmt.Sprintf("Payload.Type called on a payload with an unknown details type: %T", d))
 	}
@@ -96,6 +100,8 @@ func WrapProgressDetails(details ProgressDetails) interface {
 		return &Progress_SchemaChangeGC{SchemaChangeGC: &d}
 	case TypeSchemaChangeProgress:
 		return &Progress_TypeSchemaChange{TypeSchemaChange: &d}
+	case RowLevelTTLProgress:
+		return &Progress_RowLevelTTL{RowLevelTTL: &d}
 	default:
 		panic(fmt.Sprintf("WrapProgressDetails: unknown details type %T", d))
 	}
@@ -121,6 +127,8 @@ func (p *Payload) UnwrapDetails() Details {
 		return *d.SchemaChangeGC
 	case *Payload_TypeSchemaChange:
 		return *d.TypeSchemaChange
+	case *Payload_RowLevelTTL:
+		return *d.RowLevelTTL
 	default:
 		return nil
 	}
@@ -146,6 +154,8 @@ func (p *Progress) UnwrapDetails() ProgressDetails {
 		return *d.SchemaChangeGC
 	case *Progress_TypeSchemaChange:
 		return *d.TypeSchemaChange
+	case *Progress_RowLevelTTL:
+		return *d.RowLevelTTL
 	default:
 		return nil
 	}
@@ -184,6 +194,8 @@ func WrapPayloadDetails(details Details) interface {
 		return &Payload_SchemaChangeGC{SchemaChangeGC: &d}
 	case TypeSchemaChangeDetails:
 		return &Payload_TypeSchemaChange{TypeSchemaChange: &d}
+	case RowLevelTTLDetails:
+		return &Payload_RowLevelTTL{RowLevelTTL: &d}
 	default:
 		panic(fmt.Sprintf("jobs.WrapPayloadDetails: unknown details type %T", d))
 	}
@@ -18,7 +18,8 @@ import (
 
 // Metrics are for production monitoring of each job type.
 type Metrics struct {
-	Changefeed metric.Struct
+	Changefeed  metric.Struct
+	RowLevelTTL metric.Struct
 }
 
 // MetricStruct implements the metric.Struct interface.
@@ -29,8 +30,15 @@ func (m *Metrics) InitHooks(histogramWindowInterval time.Duration) {
 	if MakeChangefeedMetricsHook != nil {
 		m.Changefeed = MakeChangefeedMetricsHook(histogramWindowInterval)
 	}
+	if MakeRowLevelTTLMetricsHook != nil {
+		m.RowLevelTTL = MakeRowLevelTTLMetricsHook(histogramWindowInterval)
+	}
 }
 
 // MakeChangefeedMetricsHook allows for registration of changefeed metrics from
 // ccl code.
 var MakeChangefeedMetricsHook func(time.Duration) metric.Struct
+
+// MakeRowLevelTTLMetricsHook allows for registration of row-level TTL job
+// metrics from the package implementing the job.
+var MakeRowLevelTTLMetricsHook func(time.Duration) metric.Struct
@@ -379,6 +379,12 @@ const (
 	StatementDiagnosticsTableID         = 36
 	ScheduledJobsTableID                = 37
 	TenantsRangesID                     = 38 // pseudo
+	NotificationsTableID                = 39
+	// AdvisoryLocksID is the ID of the keyspace in which advisory locks are
+	// acquired. There is no descriptor with this ID and no data is ever
+	// committed to this keyspace: an advisory lock is an intent written by a
+	// transaction that is rolled back when the lock is released.
+	AdvisoryLocksID = 40
 
 	// CommentType is type for system.comments
 	DatabaseCommentType = 0
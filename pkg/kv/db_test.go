@@ -17,10 +17,13 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/testutils/kvclientutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
 )
 
 func setup(t *testing.T) (serverutils.TestServerInterface, *kv.DB) {
@@ -421,6 +424,35 @@ func TestTxn_Commit(t *testing.T) {
 	checkResults(t, expected, b.Results)
 }
 
+// TestTxn_PutWithErrorWaitPolicy verifies that a write that conflicts with an
+// intent fails with a WriteIntentError instead of waiting when it uses the
+// Error wait policy.
+func TestTxn_PutWithErrorWaitPolicy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	s, db := setup(t)
+	ctx := context.Background()
+	defer s.Stopper().Stop(ctx)
+
+	txn1 := kv.NewTxn(ctx, db, 0 /* gatewayNodeID */)
+	if err := txn1.Put(ctx, "a", "1"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = txn1.Rollback(ctx) }()
+
+	txn2 := kv.NewTxn(ctx, db, 0 /* gatewayNodeID */)
+	b := txn2.NewBatch()
+	b.Header.WaitPolicy = lock.WaitPolicy_Error
+	b.Put("a", "2")
+	err := txn2.Run(ctx, b)
+	if !errors.HasType(err, (*roachpb.WriteIntentError)(nil)) {
+		t.Fatalf("expected WriteIntentError, got %v", err)
+	}
+	if err := txn2.Rollback(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestDB_Put_insecure(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
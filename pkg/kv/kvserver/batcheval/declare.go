@@ -14,6 +14,7 @@ import (
 	"context"
 
 	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/spanset"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
@@ -87,7 +88,16 @@ func DefaultDeclareIsolatedKeys(
 		}
 	}
 	latchSpans.AddMVCC(access, req.Header().Span(), timestamp)
-	lockSpans.AddNonMVCC(access, req.Header().Span())
+	lockAccess := access
+	if roachpb.IsReadOnly(req) && roachpb.IsLocking(req) &&
+		roachpb.LockingStrength(req) == lock.Shared {
+		// Reads that acquire Shared locks conflict with each other in the latch
+		// manager, like writes, but not in the lockTable, where they are
+		// declared as reads. The concurrency.Request's ReadLockStrength tells
+		// the lockTable that these reads acquire Shared locks.
+		lockAccess = spanset.SpanReadOnly
+	}
+	lockSpans.AddNonMVCC(lockAccess, req.Header().Span())
 }
 
 // DeclareKeysForBatch adds all keys that the batch with the provided header
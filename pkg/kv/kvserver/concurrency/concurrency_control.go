@@ -326,6 +326,10 @@ type Request struct {
 	// The consistency level of the request. Only set if Txn is nil.
 	ReadConsistency roachpb.ReadConsistencyType
 
+	// The policy used by the request to wait on conflicting locks held by
+	// other active transactions.
+	WaitPolicy lock.WaitPolicy
+
 	// The individual requests in the batch.
 	Requests []roachpb.RequestUnion
 
@@ -346,6 +350,15 @@ type Request struct {
 	// (Txn == nil), all reads and writes are considered to take place at
 	// Timestamp.
 	LockSpans *spanset.SpanSet
+
+	// The strength of the locks that the request acquires on the keys in its
+	// read-only lock spans. It is lock.None for reads under optimistic
+	// concurrency control, which only conflict with Exclusive locks held at or
+	// below their read timestamp. It is lock.Shared for locking reads (e.g.
+	// SELECT ... FOR SHARE), which conflict with Exclusive locks held at any
+	// timestamp and queue behind waiters that intend to acquire Exclusive
+	// locks. Only transactional requests can acquire Shared locks.
+	ReadLockStrength lock.Strength
 }
 
 // Guard is returned from Manager.SequenceReq. The guard is passed back in to
@@ -498,9 +511,10 @@ type lockTable interface {
 	// the lockTable initially. It must only be called in the evaluation phase
 	// before calling Dequeue, which means all the latches needed by the request
 	// are held. The key must be in the request's SpanSet with the appropriate
-	// SpanAccess: currently the strength is always Exclusive, so the span
-	// containing this key must be SpanReadWrite. This contract ensures that the
-	// lock is not held in a conflicting manner by a different transaction.
+	// SpanAccess: for Exclusive locks the span containing this key must be
+	// SpanReadWrite, and for Shared locks it must be SpanReadOnly in a request
+	// with a ReadLockStrength of Shared. This contract ensures that the lock is
+	// not held in a conflicting manner by a different transaction.
 	// Acquiring a lock that is already held by this transaction upgrades the
 	// lock's timestamp and strength, if necessary.
 	//
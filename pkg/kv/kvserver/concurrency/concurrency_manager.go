@@ -306,7 +306,7 @@ func (m *managerImpl) HandleTransactionPushError(
 
 // OnLockAcquired implements the LockManager interface.
 func (m *managerImpl) OnLockAcquired(ctx context.Context, acq *roachpb.LockAcquisition) {
-	if err := m.lt.AcquireLock(&acq.Txn, acq.Key, lock.Exclusive, acq.Durability); err != nil {
+	if err := m.lt.AcquireLock(&acq.Txn, acq.Key, acq.Strength, acq.Durability); err != nil {
 		log.Fatalf(ctx, "%v", err)
 	}
 }
@@ -435,6 +435,16 @@ func (r *Request) writeConflictTimestamp() hlc.Timestamp {
 	return ts
 }
 
+// readLockStrength returns the strength of the locks that the request acquires
+// on the keys in its read-only lock spans. Non-transactional requests never
+// acquire locks.
+func (r *Request) readLockStrength() lock.Strength {
+	if r.Txn == nil {
+		return lock.None
+	}
+	return r.ReadLockStrength
+}
+
 func (r *Request) isSingle(m roachpb.Method) bool {
 	if len(r.Requests) != 1 {
 		return false
@@ -301,8 +301,10 @@ func TestConcurrencyManagerBasic(t *testing.T) {
 					dur = scanLockDurability(t, d)
 				}
 
-				// Confirm that the request has a corresponding write request.
+				// Confirm that the request has a corresponding write request. The
+				// lock is acquired with the strength of that request.
 				found := false
+				var str lock.Strength
 				for _, ru := range guard.Req.Requests {
 					req := ru.GetInner()
 					keySpan := roachpb.Span{Key: roachpb.Key(key)}
@@ -310,6 +312,7 @@ func TestConcurrencyManagerBasic(t *testing.T) {
 						req.Header().Span().Contains(keySpan) &&
 						req.Header().Sequence == seqNum {
 						found = true
+						str = roachpb.LockingStrength(req)
 						break
 					}
 				}
@@ -322,7 +325,7 @@ func TestConcurrencyManagerBasic(t *testing.T) {
 
 				mon.runSync("acquire lock", func(ctx context.Context) {
 					log.Eventf(ctx, "txn %s @ %s", txn.ID.Short(), key)
-					acq := roachpb.MakeLockAcquisition(txnAcquire, roachpb.Key(key), dur)
+					acq := roachpb.MakeLockAcquisition(txnAcquire, roachpb.Key(key), str, dur)
 					m.OnLockAcquired(ctx, &acq)
 				})
 				return c.waitAndCollect(t, mon)
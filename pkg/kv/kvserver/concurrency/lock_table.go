@@ -87,6 +87,10 @@ type waitingState struct {
 	// Represents the action that the request was trying to perform when
 	// it hit the conflict. E.g. was it trying to read or write?
 	guardAccess spanset.SpanAccess
+	// Represents the strength of the lock that the request was trying to
+	// acquire when it hit the conflict. It is lock.None for non-locking reads,
+	// lock.Shared for locking reads and lock.Exclusive for writes.
+	guardStrength lock.Strength
 }
 
 // Implementation
@@ -262,6 +266,9 @@ type lockTableGuardImpl struct {
 	spans   *spanset.SpanSet
 	readTS  hlc.Timestamp
 	writeTS hlc.Timestamp
+	// The strength of the locks acquired on keys in SpanReadOnly spans. See
+	// Request.ReadLockStrength.
+	readStr lock.Strength
 
 	// Snapshots of the trees for which this request has some spans. Note that
 	// the lockStates in these snapshots may have been removed from
@@ -429,6 +436,15 @@ func (g *lockTableGuardImpl) isSameTxnAsReservation(ws waitingState) bool {
 	return !ws.held && g.isSameTxn(ws.txn)
 }
 
+// strengthForAccess returns the strength of the lock that the request intends
+// to acquire on a key that it accesses with the given access.
+func (g *lockTableGuardImpl) strengthForAccess(sa spanset.SpanAccess) lock.Strength {
+	if sa == spanset.SpanReadWrite {
+		return lock.Exclusive
+	}
+	return g.readStr
+}
+
 // Finds the next lock, after the current one, to actively wait at. If it
 // finds the next lock the request starts actively waiting there, else it is
 // told that it is done waiting.
@@ -483,14 +499,17 @@ func (g *lockTableGuardImpl) findNextLockAfter(notify bool) {
 	}
 }
 
-// Waiting writers in a lockState are wrapped in a queuedGuard. A waiting
-// writer is typically waiting in an active state, i.e., the
-// lockTableGuardImpl.key refers to this lockState. However, breaking of
+// Waiting writers and locking readers in a lockState are wrapped in a
+// queuedGuard. A waiting writer is typically waiting in an active state, i.e.,
+// the lockTableGuardImpl.key refers to this lockState. However, breaking of
 // reservations (see the comment on reservations below, in lockState) can
-// cause a writer to be an inactive waiter.
+// cause a writer to be an inactive waiter. Locking readers, which intend to
+// acquire Shared locks, never make reservations so they are always active
+// waiters.
 type queuedGuard struct {
 	guard  *lockTableGuardImpl
-	active bool // protected by lockState.mu
+	str    lock.Strength // Exclusive or Shared
+	active bool          // protected by lockState.mu
 }
 
 // Information about a lock holder.
@@ -538,10 +557,14 @@ type lockState struct {
 
 	// Invariant summary (see detailed comments below):
 	// - both holder.locked and waitQ.reservation != nil cannot be true.
+	// - both holder.locked and len(sharedHolders) > 0 cannot be true.
+	// - both len(sharedHolders) > 0 and waitQ.reservation != nil cannot be
+	//   true.
 	// - if holder.locked and multiple holderInfos have txn != nil: all the
 	//   txns must have the same txn.ID.
 	// - !holder.locked => waitingReaders.Len() == 0. That is, readers wait
-	//   only if the lock is held. They do not wait for a reservation.
+	//   only if the lock is held with Exclusive strength. They do not wait for
+	//   a reservation or for Shared locks.
 	// - If reservation != nil, that request is not in queuedWriters.
 
 	// Information about whether the lock is held and the holder. We track
@@ -554,6 +577,15 @@ type lockState struct {
 		holder [lock.MaxDurability + 1]lockHolderInfo
 	}
 
+	// Information about the transactions holding Shared locks on the key, in
+	// the order in which they acquired them. Shared locks are compatible with
+	// each other, so any number of transactions can hold them concurrently.
+	// They are only ever acquired by locking reads, so they always have
+	// Unreplicated durability. A transaction holding a Shared lock that goes
+	// on to acquire the Exclusive lock upgrades its lock: it is removed from
+	// this list and becomes the holder above.
+	sharedHolders []*lockHolderInfo
+
 	// Information about the requests waiting on the lock.
 	lockWaitQueue
 }
@@ -588,8 +620,8 @@ type lockWaitQueue struct {
 	// seqnums but at another key req2 wants to read and req1 wants to write and
 	// since req2 does not wait in the queue it acquires a read reservation
 	// before req1. See the discussion at the end of this comment section on how
-	// the behavior will extend when we start supporting Shared and Upgrade
-	// locks.
+	// requests that acquire Shared locks, which do wait in the queue, are
+	// handled.
 	//
 	// Non-transactional requests can do both reads and writes but cannot be
 	// depended on since they don't have a transaction that can be pushed.
@@ -651,44 +683,58 @@ type lockWaitQueue struct {
 	//   This is a deadlock caused by the lock table unless req2 partially
 	//   breaks the reservation at A.
 	//
-	// Extension for Shared and Upgrade locks:
-	// There are 3 aspects to consider: holders; reservers; the dependencies
-	// that need to be captured when waiting.
+	// Shared locks:
+	// Shared locks are compatible with each other, so a lock can be (a) not
+	// held, (b) held with Exclusive strength by one transaction or (c) held
+	// with Shared strength by any number of transactions (see
+	// lockState.sharedHolders). Non-locking reads only wait in waitingReaders
+	// for an Exclusive holder, so they are not affected by Shared locks.
+	//
+	// Requests that intend to acquire a Shared lock on a key (locking reads)
+	// wait in queuedWriters alongside writers, ordered by seqnum. Like
+	// non-transactional writers, they never make reservations: when they get
+	// to the front of the queue of a lock that is not held they remove
+	// themselves from the lockState and proceed, and they ignore reservations
+	// made by requests with a higher seqnum. Acquiring a Shared lock breaks a
+	// reservation held by a different transaction, since the reserver intends
+	// to acquire an incompatible Exclusive lock.
 	//
-	// - Holders: only shared locks are compatible with themselves, so there can
-	//   be one of (a) no holder (b) multiple shared lock holders, (c) one
-	//   exclusive holder, (d) one upgrade holder. Non-locking reads will
-	//   wait in waitingReaders for only an incompatible exclusive holder.
+	// While the lock is held with Shared strength, there is no reservation and
+	// the dependencies of waiters are computed individually:
+	// - a waiter desiring an Exclusive lock depends on the first Shared holder
+	//   from a different transaction. If its own transaction is the only
+	//   holder it does not wait, and upgrades the lock to Exclusive strength
+	//   when acquiring it.
+	// - a waiter desiring a Shared lock does not conflict with the holders,
+	//   but cannot jump ahead of a transactional waiter desiring an Exclusive
+	//   lock with a lower seqnum, since that would allow a stream of Shared
+	//   lockers to starve the writer. It depends on the first such waiter. It
+	//   does not wait at all if its own transaction is already a holder.
 	//
-	// - Reservers: This follows the same pattern as holders. Non-locking reads
-	//   do not wait on reservers.
+	// Upgrades: a transaction holding a Shared lock that goes on to write the
+	// key (or to lock it FOR UPDATE) never waits on itself. If it is the only
+	// holder, the lock is upgraded in place to an Unreplicated Exclusive lock
+	// when the write acquires it (see upgradeSharedLock). If other
+	// transactions also hold the Shared lock, the writer waits on the first of
+	// them like any other writer.
 	//
-	// - Queueing and dependencies: All potential lockers and non-transactional
-	//   writers will wait in the same queue. A sequence of consecutive requests
-	//   that have the potential to acquire a shared lock will jointly reserve
-	//   that shared lock. Such requests cannot jump ahead of requests with a
-	//   lower seqnum just because there is currently a shared lock reservation
-	//   (this can cause lockTable induced deadlocks). Such joint reservations
-	//   can be partially broken by a waiter desiring an exclusive or upgrade
-	//   lock. Like the current code, non-transactional writes will wait for
-	//   reservations that have a lower sequence num, but not make their own
-	//   reservation. Additionally, they can partially break joint reservations.
+	// Deadlocks: two transactions that both hold a Shared lock on a key and
+	// both try to upgrade it wait on each other, e.g.
+	//   txn1: SELECT ... FOR SHARE   (Shared holders: txn1)
+	//   txn2: SELECT ... FOR SHARE   (Shared holders: txn1, txn2)
+	//   txn1: UPDATE                 (waits on txn2)
+	//   txn2: UPDATE                 (waits on txn1)
+	// The lockTable does not try to prevent this. Both waiters push the
+	// transaction they depend on with PUSH_ABORT, since pushing the timestamp
+	// of the holder of a conflicting lock does not let a locking request
+	// proceed, and the txnWaitQueue detects the cycle and aborts one of them
+	// like for any other deadlock.
 	//
-	//   Reservations that are (partially or fully) broken cause requests to
-	//   reenter the queue as inactive waiters. This is no different than the
-	//   current behavior. Each request can specify the same key in spans for
-	//   ReadOnly, ReadShared, ReadUpgrade, ReadWrite. The spans will be
-	//   iterated over in decreasing order of strength, to only wait at a lock
-	//   at the highest strength (this is similar to the current behavior using
-	//   accessDecreasingStrength).
+	// Shared locks are always Unreplicated, like the Exclusive locks acquired
+	// by SELECT ... FOR UPDATE, so they are lost on lease transfers and range
+	// merges.
 	//
-	//   For dependencies, a waiter desiring an exclusive or upgrade lock always
-	//   conflicts with the holder(s) or reserver(s) so that is the dependency
-	//   that will be captured. A waiter desiring a shared lock may encounter a
-	//   situation where it does not conflict with the holder(s) or reserver(s)
-	//   since those are also shared lockers. In that case it will depend on the
-	//   first waiter since that waiter must be desiring a lock that is
-	//   incompatible with a shared lock.
+	// Upgrade locks are not supported.
 
 	reservation *lockTableGuardImpl
 
@@ -713,11 +759,12 @@ type lockWaitQueue struct {
 	// waiting for.
 
 	// List of *queuedGuard. A subset of these are actively waiting. If
-	// non-empty, either the lock is held or there is a reservation.
+	// non-empty, either the lock is held (with Exclusive or Shared strength)
+	// or there is a reservation.
 	queuedWriters list.List
 
 	// List of *lockTableGuardImpl. All of these are actively waiting. If
-	// non-empty, the lock must be held. By definition these cannot be in
+	// non-empty, the lock must be held with Exclusive strength. By definition these cannot be in
 	// waitSelf state since that state is only used when there is a reservation.
 	waitingReaders list.List
 
@@ -781,12 +828,24 @@ func (l *lockState) Format(buf *strings.Builder) {
 		}
 		fmt.Fprintln(b, "")
 	}
+	writeSharedHolderInfo := func(b *strings.Builder, h *lockHolderInfo) {
+		fmt.Fprintf(b, "  shared holder: txn: %v, ts: %v, info: unrepl epoch: %d, seqs: [%d",
+			h.txn.ID, h.ts, h.txn.Epoch, h.seqs[0])
+		for j := 1; j < len(h.seqs); j++ {
+			fmt.Fprintf(b, ", %d", h.seqs[j])
+		}
+		fmt.Fprintln(b, "]")
+	}
 	txn, ts := l.getLockHolder()
-	if txn == nil {
+	if txn != nil {
+		writeHolderInfo(buf, txn, ts)
+	} else if len(l.sharedHolders) > 0 {
+		for _, h := range l.sharedHolders {
+			writeSharedHolderInfo(buf, h)
+		}
+	} else {
 		fmt.Fprintf(buf, "  res: req: %d, ", l.reservation.seqNum)
 		writeResInfo(buf, l.reservation.txn, l.reservation.writeTS)
-	} else {
-		writeHolderInfo(buf, txn, ts)
 	}
 	// TODO(sumeer): Add an optional `description string` field to Request and
 	// lockTableGuardImpl that tests can set to avoid relying on the seqNum to
@@ -808,8 +867,11 @@ func (l *lockState) Format(buf *strings.Builder) {
 		for e := l.queuedWriters.Front(); e != nil; e = e.Next() {
 			qg := e.Value.(*queuedGuard)
 			g := qg.guard
-			fmt.Fprintf(buf, "    active: %t req: %d, txn: ",
-				qg.active, qg.guard.seqNum)
+			fmt.Fprintf(buf, "    active: %t req: %d, ", qg.active, qg.guard.seqNum)
+			if qg.str == lock.Shared {
+				fmt.Fprintf(buf, "str: shared, ")
+			}
+			fmt.Fprintf(buf, "txn: ")
 			if g.txn == nil {
 				fmt.Fprintln(buf, "none")
 			} else {
@@ -829,6 +891,7 @@ func (l *lockState) tryBreakReservation(seqNum uint64) bool {
 	if l.reservation.seqNum > seqNum {
 		qg := &queuedGuard{
 			guard:  l.reservation,
+			str:    lock.Exclusive,
 			active: false,
 		}
 		l.queuedWriters.PushFront(qg)
@@ -840,14 +903,20 @@ func (l *lockState) tryBreakReservation(seqNum uint64) bool {
 
 // Informs active waiters about reservation or lock holder. The reservation
 // may have changed so this needs to fix any inconsistencies wrt waitSelf and
-// waitForDistinguished states.
+// waitForDistinguished states. If the lock is held with Shared strength, the
+// waiters may also have stopped conflicting with the lock, in which case they
+// are told that they are done waiting here.
 // REQUIRES: l.mu is locked.
 func (l *lockState) informActiveWaiters() {
 	waitForState := waitingState{kind: waitFor, key: l.key}
 	findDistinguished := l.distinguishedWaiter == nil
+	heldShared := false
 	if lockHolderTxn, _ := l.getLockHolder(); lockHolderTxn != nil {
 		waitForState.txn = lockHolderTxn
 		waitForState.held = true
+	} else if len(l.sharedHolders) > 0 {
+		// Each waiter computes whom it is waiting for below.
+		heldShared = true
 	} else {
 		waitForState.txn = l.reservation.txn
 		if !findDistinguished && l.distinguishedWaiter.isSameTxnAsReservation(waitForState) {
@@ -859,6 +928,7 @@ func (l *lockState) informActiveWaiters() {
 	for e := l.waitingReaders.Front(); e != nil; e = e.Next() {
 		state := waitForState
 		state.guardAccess = spanset.SpanReadOnly
+		state.guardStrength = lock.None
 		// Since there are waiting readers we could not have transitioned out of
 		// or into a state with a reservation, since readers do not wait for
 		// reservations.
@@ -875,18 +945,41 @@ func (l *lockState) informActiveWaiters() {
 		g.notify()
 		g.mu.Unlock()
 	}
-	for e := l.queuedWriters.Front(); e != nil; e = e.Next() {
+	distinguishedRemoved := false
+	for e := l.queuedWriters.Front(); e != nil; {
 		qg := e.Value.(*queuedGuard)
+		curr := e
+		e = e.Next()
 		if !qg.active {
 			continue
 		}
 		g := qg.guard
-		var state waitingState
-		if g.isSameTxnAsReservation(waitForState) {
+		state := waitForState
+		if heldShared {
+			state.txn, state.held = l.sharedLockConflict(g, qg.str)
+		} else if qg.str == lock.Shared && !state.held && l.reservation.seqNum > g.seqNum {
+			// Requests that intend to acquire a Shared lock ignore reservations
+			// with a higher seqNum.
+			state.txn = nil
+		}
+		if state.txn == nil {
+			// No longer conflicts with the lock.
+			l.queuedWriters.Remove(curr)
+			if g == l.distinguishedWaiter {
+				distinguishedRemoved = true
+				l.distinguishedWaiter = nil
+			}
+			g.doneWaitingAtLock(false, l)
+			continue
+		}
+		if g.isSameTxnAsReservation(state) {
 			state = waitingState{kind: waitSelf}
 		} else {
-			state = waitForState
 			state.guardAccess = spanset.SpanReadWrite
+			if qg.str == lock.Shared {
+				state.guardAccess = spanset.SpanReadOnly
+			}
+			state.guardStrength = qg.str
 			if findDistinguished {
 				l.distinguishedWaiter = g
 				findDistinguished = false
@@ -900,6 +993,48 @@ func (l *lockState) informActiveWaiters() {
 		g.notify()
 		g.mu.Unlock()
 	}
+	if distinguishedRemoved && l.distinguishedWaiter == nil {
+		l.tryMakeNewDistinguished()
+	}
+}
+
+// Returns the transaction that the request g, which intends to acquire a lock
+// with strength str, conflicts with while the lock is held with Shared
+// strength, and whether that transaction is a lock holder. A request desiring
+// an Exclusive lock conflicts with the first holder from a different
+// transaction. A request desiring a Shared lock conflicts with the first
+// transactional waiter desiring an Exclusive lock that is ahead of it in the
+// queue, unless its own transaction already holds a Shared lock. Returns a
+// nil transaction if there is no conflict.
+// REQUIRES: l.mu is locked and len(l.sharedHolders) > 0.
+func (l *lockState) sharedLockConflict(
+	g *lockTableGuardImpl, str lock.Strength,
+) (_ *enginepb.TxnMeta, held bool) {
+	if str == lock.Exclusive {
+		for _, h := range l.sharedHolders {
+			if !g.isSameTxn(h.txn) {
+				return h.txn, true
+			}
+		}
+		// The lock is only held by the request's own transaction, which can
+		// upgrade it.
+		return nil, false
+	}
+	for _, h := range l.sharedHolders {
+		if g.isSameTxn(h.txn) {
+			return nil, false
+		}
+	}
+	for e := l.queuedWriters.Front(); e != nil; e = e.Next() {
+		qg := e.Value.(*queuedGuard)
+		if qg.guard.seqNum >= g.seqNum {
+			break
+		}
+		if qg.str == lock.Exclusive && qg.guard.txn != nil && !g.isSameTxn(qg.guard.txn) {
+			return qg.guard.txn, false
+		}
+	}
+	return nil, false
 }
 
 // releaseWritersFromTxn removes all waiting writers for the lockState that are
@@ -954,11 +1089,11 @@ func (l *lockState) tryMakeNewDistinguished() {
 	}
 }
 
-// Returns true iff the lockState is empty, i.e., there is no lock holder or
-// reservation.
+// Returns true iff the lockState is empty, i.e., there is no lock holder
+// (with either strength) or reservation.
 // REQUIRES: l.mu is locked.
 func (l *lockState) isEmptyLock() bool {
-	if !l.holder.locked && l.reservation == nil {
+	if !l.holder.locked && l.reservation == nil && len(l.sharedHolders) == 0 {
 		for i := range l.holder.holder {
 			if !l.holder.holder[i].isEmpty() {
 				panic("lockState with !locked but non-zero lockHolderInfo")
@@ -1047,14 +1182,18 @@ func (l *lockState) tryActiveWait(g *lockTableGuardImpl, sa spanset.SpanAccess,
 		return false
 	}
 
+	str := g.strengthForAccess(sa)
 	if sa == spanset.SpanReadOnly {
-		if lockHolderTxn == nil {
-			// Reads only care about locker, not a reservation.
-			return false
-		}
-		// Locked by some other txn.
-		if g.readTS.Less(lockHolderTS) {
-			return false
+		if str == lock.None {
+			if lockHolderTxn == nil {
+				// Non-locking reads only care about an Exclusive locker, not a
+				// reservation or Shared lockers.
+				return false
+			}
+			// Locked by some other txn.
+			if g.readTS.Less(lockHolderTS) {
+				return false
+			}
 		}
 		g.mu.Lock()
 		_, alsoHasStrongerAccess := g.mu.locks[l]
@@ -1081,18 +1220,28 @@ func (l *lockState) tryActiveWait(g *lockTableGuardImpl, sa spanset.SpanAccess,
 	if lockHolderTxn != nil {
 		waitForState.txn = lockHolderTxn
 		waitForState.held = true
+	} else if len(l.sharedHolders) > 0 {
+		// Held with Shared strength.
+		txn, held := l.sharedLockConflict(g, str)
+		if txn == nil {
+			return false
+		}
+		waitForState.txn = txn
+		waitForState.held = held
 	} else {
 		if l.reservation == g {
 			// Already reserved by this request.
 			return false
 		}
-		// A non-transactional write request never makes or breaks reservations,
-		// and only waits for a reservation if the reservation has a lower
-		// seqNum. Note that `sa == spanset.SpanRead && lockHolderTxn == nil`
-		// was already checked above.
-		if g.txn == nil && l.reservation.seqNum > g.seqNum {
+		// A non-transactional write request or a request that intends to
+		// acquire a Shared lock never makes or breaks reservations, and only
+		// waits for a reservation if the reservation has a lower seqNum. Note
+		// that `str == lock.None && lockHolderTxn == nil` was already checked
+		// above.
+		if (g.txn == nil || str == lock.Shared) && l.reservation.seqNum > g.seqNum {
 			// Reservation is held by a request with a higher seqNum and g is a
-			// non-transactional request. Ignore the reservation.
+			// non-transactional request or a locking read. Ignore the
+			// reservation.
 			return false
 		}
 		waitForState.txn = l.reservation.txn
@@ -1116,10 +1265,12 @@ func (l *lockState) tryActiveWait(g *lockTableGuardImpl, sa spanset.SpanAccess,
 
 	g.mu.Lock()
 	defer g.mu.Unlock()
-	if sa == spanset.SpanReadWrite {
+	if str != lock.None {
 		if _, inQueue := g.mu.locks[l]; inQueue {
 			// Already in queue and must be in the right position, so mark as active
-			// waiter there. We expect this to be rare.
+			// waiter there. We expect this to be rare. This can only happen for a
+			// writer, since a locking read that is already in the queue has
+			// returned above.
 			var qg *queuedGuard
 			for e := l.queuedWriters.Front(); e != nil; e = e.Next() {
 				qqg := e.Value.(*queuedGuard)
@@ -1136,6 +1287,7 @@ func (l *lockState) tryActiveWait(g *lockTableGuardImpl, sa spanset.SpanAccess,
 			// Not in queue so insert as active waiter.
 			qg := &queuedGuard{
 				guard:  g,
+				str:    str,
 				active: true,
 			}
 			if l.queuedWriters.Len() == 0 {
@@ -1168,6 +1320,7 @@ func (l *lockState) tryActiveWait(g *lockTableGuardImpl, sa spanset.SpanAccess,
 	} else {
 		state := waitForState
 		state.guardAccess = sa
+		state.guardStrength = str
 		if l.distinguishedWaiter == nil {
 			l.distinguishedWaiter = g
 			state.kind = waitForDistinguished
@@ -1185,10 +1338,24 @@ func (l *lockState) tryActiveWait(g *lockTableGuardImpl, sa spanset.SpanAccess,
 // that is acquiring the lock.
 // Acquires l.mu.
 func (l *lockState) acquireLock(
-	_ lock.Strength, durability lock.Durability, txn *enginepb.TxnMeta, ts hlc.Timestamp,
+	str lock.Strength, durability lock.Durability, txn *enginepb.TxnMeta, ts hlc.Timestamp,
 ) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	if str == lock.Shared {
+		return l.acquireSharedLock(durability, txn, ts)
+	}
+	if len(l.sharedHolders) > 0 {
+		// Held with Shared strength, which can only be the case if this
+		// transaction is the only holder and is upgrading its lock.
+		if err := l.upgradeSharedLock(txn); err != nil {
+			return err
+		}
+		// If there are waiting requests from the same txn, they no longer need
+		// to wait. The other active waiters now wait for this txn.
+		l.releaseWritersFromTxn(txn)
+		l.informActiveWaiters()
+	}
 	if l.holder.locked {
 		// Already held.
 		beforeTxn, beforeTs := l.getLockHolder()
@@ -1275,6 +1442,7 @@ func (l *lockState) acquireLock(
 			// Reservation is broken.
 			qg := &queuedGuard{
 				guard:  l.reservation,
+				str:    lock.Exclusive,
 				active: false,
 			}
 			l.queuedWriters.PushFront(qg)
@@ -1311,6 +1479,93 @@ func (l *lockState) acquireLock(
 	return nil
 }
 
+// Acquires this lock with Shared strength. Shared locks are only acquired by
+// locking reads, so they are always Unreplicated.
+// REQUIRES: l.mu is locked.
+func (l *lockState) acquireSharedLock(
+	durability lock.Durability, txn *enginepb.TxnMeta, ts hlc.Timestamp,
+) error {
+	if durability != lock.Unreplicated {
+		return errors.AssertionFailedf("lock with Shared strength must be Unreplicated")
+	}
+	if l.holder.locked {
+		if !l.isLockedBy(txn.ID) {
+			return errors.AssertionFailedf("existing lock cannot be acquired by different transaction")
+		}
+		// Already held with Exclusive strength, which is stronger.
+		return nil
+	}
+	for _, h := range l.sharedHolders {
+		if h.txn.ID != txn.ID {
+			continue
+		}
+		// Already held with Shared strength by this transaction.
+		if txn.Epoch < h.txn.Epoch {
+			return nil
+		}
+		if h.txn.Epoch < txn.Epoch {
+			// Clear the sequences for the older epoch.
+			h.seqs = h.seqs[:0]
+		}
+		h.txn = txn
+		h.ts.Forward(ts)
+		if i := sort.Search(len(h.seqs), func(i int) bool {
+			return h.seqs[i] >= txn.Sequence
+		}); i == len(h.seqs) || h.seqs[i] != txn.Sequence {
+			h.seqs = append(h.seqs, 0)
+			copy(h.seqs[i+1:], h.seqs[i:])
+			h.seqs[i] = txn.Sequence
+		}
+		return nil
+	}
+	// Not held by this transaction, so may be reserved. Since the reserver
+	// intends to acquire an Exclusive lock, which is incompatible with the
+	// Shared lock, the reservation is broken. As in acquireLock, the
+	// reservation may also be held by a different request from the same
+	// transaction, in which case that request no longer needs to be tracked
+	// here.
+	if l.reservation != nil {
+		if l.reservation.txn.ID != txn.ID {
+			qg := &queuedGuard{
+				guard:  l.reservation,
+				str:    lock.Exclusive,
+				active: false,
+			}
+			l.queuedWriters.PushFront(qg)
+		} else {
+			l.reservation.mu.Lock()
+			delete(l.reservation.mu.locks, l)
+			l.reservation.mu.Unlock()
+		}
+		l.reservation = nil
+	}
+	l.sharedHolders = append(l.sharedHolders, &lockHolderInfo{
+		txn:  txn,
+		ts:   ts,
+		seqs: []enginepb.TxnSeq{txn.Sequence},
+	})
+
+	// Inform active waiters since the lock is now held. This also releases the
+	// waiters that do not conflict with it, including those from this txn.
+	l.informActiveWaiters()
+	return nil
+}
+
+// Upgrades the Shared lock held by txn to an Exclusive lock. The transaction
+// must be the only holder of the lock. The Shared lock becomes the
+// Unreplicated Exclusive lock, which is stronger.
+// REQUIRES: l.mu is locked.
+func (l *lockState) upgradeSharedLock(txn *enginepb.TxnMeta) error {
+	if len(l.sharedHolders) > 1 || l.sharedHolders[0].txn.ID != txn.ID {
+		return errors.AssertionFailedf(
+			"lock held with Shared strength by different transaction cannot be upgraded")
+	}
+	l.holder.locked = true
+	l.holder.holder[lock.Unreplicated] = *l.sharedHolders[0]
+	l.sharedHolders = nil
+	return nil
+}
+
 // A replicated lock held by txn with timestamp ts was discovered by guard g
 // where g is trying to access this key with access sa.
 // Acquires l.mu.
@@ -1320,6 +1575,13 @@ func (l *lockState) discoveredLock(
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	if len(l.sharedHolders) > 0 {
+		// The discovered lock can only be held by the transaction holding the
+		// Shared lock, which upgraded it.
+		if err := l.upgradeSharedLock(txn); err != nil {
+			return err
+		}
+	}
 	if l.holder.locked {
 		if !l.isLockedBy(txn.ID) {
 			return errors.AssertionFailedf("discovered lock by different transaction than existing lock")
@@ -1342,6 +1604,7 @@ func (l *lockState) discoveredLock(
 	if l.reservation != nil {
 		qg := &queuedGuard{
 			guard:  l.reservation,
+			str:    lock.Exclusive,
 			active: false,
 		}
 		l.queuedWriters.PushFront(qg)
@@ -1356,8 +1619,9 @@ func (l *lockState) discoveredLock(
 		// Confirm that the guard will wait on the lock the next time it scans
 		// the lock table. If not then it shouldn't have discovered the lock in
 		// the first place. Bugs here would cause infinite loops where the same
-		// lock is repeatedly re-discovered.
-		if g.readTS.Less(ts) {
+		// lock is repeatedly re-discovered. Locking reads conflict with the lock
+		// at any timestamp.
+		if g.readStr == lock.None && g.readTS.Less(ts) {
 			return errors.AssertionFailedf("discovered non-conflicting lock")
 		}
 
@@ -1375,6 +1639,7 @@ func (l *lockState) discoveredLock(
 			// Put self in queue as inactive waiter.
 			qg := &queuedGuard{
 				guard:  g,
+				str:    lock.Exclusive,
 				active: false,
 			}
 			// g is not necessarily first in the queue in the (rare) case (a) above.
@@ -1411,8 +1676,9 @@ func (l *lockState) tryClearLock(force bool) bool {
 		return false
 	}
 
-	// Remove unreplicated holder.
+	// Remove unreplicated holder, including the holders of Shared locks.
 	l.holder.holder[lock.Unreplicated] = lockHolderInfo{}
+	l.sharedHolders = nil
 	var waitState waitingState
 	if replicatedHeld && !force {
 		lockHolderTxn, _ := l.getLockHolder()
@@ -1451,7 +1717,6 @@ func (l *lockState) tryClearLock(force bool) bool {
 		g.mu.Unlock()
 	}
 
-	waitState.guardAccess = spanset.SpanReadWrite
 	for e := l.queuedWriters.Front(); e != nil; {
 		qg := e.Value.(*queuedGuard)
 		curr := e
@@ -1462,6 +1727,11 @@ func (l *lockState) tryClearLock(force bool) bool {
 		g.mu.Lock()
 		if qg.active {
 			g.mu.state = waitState
+			g.mu.state.guardAccess = spanset.SpanReadWrite
+			if qg.str == lock.Shared {
+				g.mu.state.guardAccess = spanset.SpanReadOnly
+			}
+			g.mu.state.guardStrength = qg.str
 			g.notify()
 		}
 		delete(g.mu.locks, l)
@@ -1496,6 +1766,9 @@ func removeIgnored(
 func (l *lockState) tryUpdateLock(up *roachpb.LockUpdate) (gc bool, err error) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	if len(l.sharedHolders) > 0 {
+		return l.tryUpdateSharedLock(up), nil
+	}
 	if !l.isLockedBy(up.Txn.ID) {
 		return false, nil
 	}
@@ -1572,6 +1845,51 @@ func (l *lockState) tryUpdateLock(up *roachpb.LockUpdate) (gc bool, err error) {
 	return false, nil
 }
 
+// Tries to update the lock held with Shared strength: noop if the transaction
+// is not one of the holders, else its Shared lock is updated or released.
+// Returns whether the lockState can be garbage collected.
+// REQUIRES: l.mu is locked.
+func (l *lockState) tryUpdateSharedLock(up *roachpb.LockUpdate) (gc bool) {
+	i := 0
+	for ; i < len(l.sharedHolders); i++ {
+		if l.sharedHolders[i].txn.ID == up.Txn.ID {
+			break
+		}
+	}
+	if i == len(l.sharedHolders) {
+		return false
+	}
+	h := l.sharedHolders[i]
+	txn := &up.Txn
+	// Shared locks are Unreplicated, so this mirrors the handling of the
+	// Unreplicated holder in tryUpdateLock.
+	release := up.Status.IsFinalized() || txn.Epoch > h.txn.Epoch
+	if !release && txn.Epoch == h.txn.Epoch {
+		h.seqs = removeIgnored(h.seqs, up.IgnoredSeqNums)
+		release = len(h.seqs) == 0
+	}
+	if !release {
+		if h.ts.Less(txn.WriteTimestamp) {
+			h.ts = txn.WriteTimestamp
+			if txn.Epoch == h.txn.Epoch {
+				h.txn = txn
+			}
+		}
+		// Non-locking reads do not wait for Shared locks, so no waiter is
+		// affected by the timestamp increase.
+		return false
+	}
+
+	l.sharedHolders = append(l.sharedHolders[:i], l.sharedHolders[i+1:]...)
+	if len(l.sharedHolders) == 0 {
+		l.sharedHolders = nil
+		return l.lockIsFree()
+	}
+	// Waiters may have been waiting for the released holder.
+	l.informActiveWaiters()
+	return false
+}
+
 // The lock holder timestamp has increased. Some of the waiters may no longer
 // need to wait.
 // REQUIRES: l.mu is locked.
@@ -1623,6 +1941,7 @@ func (l *lockState) requestDone(g *lockTableGuardImpl) (gc bool) {
 	// May be in queuedWriters or waitingReaders.
 	distinguishedRemoved := false
 	doneRemoval := false
+	removedExclusiveWaiter := false
 	for e := l.queuedWriters.Front(); e != nil; e = e.Next() {
 		qg := e.Value.(*queuedGuard)
 		if qg.guard == g {
@@ -1632,6 +1951,7 @@ func (l *lockState) requestDone(g *lockTableGuardImpl) (gc bool) {
 				l.distinguishedWaiter = nil
 			}
 			doneRemoval = true
+			removedExclusiveWaiter = qg.str == lock.Exclusive
 			break
 		}
 	}
@@ -1652,6 +1972,12 @@ func (l *lockState) requestDone(g *lockTableGuardImpl) (gc bool) {
 	if !doneRemoval {
 		panic("lockTable bug")
 	}
+	if removedExclusiveWaiter && len(l.sharedHolders) > 0 {
+		// Waiters that intend to acquire a Shared lock may have been waiting
+		// for the removed writer. This also picks a new distinguished waiter.
+		l.informActiveWaiters()
+		return false
+	}
 	if distinguishedRemoved {
 		l.tryMakeNewDistinguished()
 	}
@@ -1708,6 +2034,9 @@ func (l *lockState) lockIsFree() (gc bool) {
 	if l.reservation != nil {
 		panic("called lockIsFree on lock with reservation")
 	}
+	if len(l.sharedHolders) > 0 {
+		panic("called lockIsFree on lock with shared holders")
+	}
 
 	// All waiting readers don't need to wait here anymore.
 	for e := l.waitingReaders.Front(); e != nil; {
@@ -1721,12 +2050,13 @@ func (l *lockState) lockIsFree() (gc bool) {
 		g.doneWaitingAtLock(false, l)
 	}
 
-	// The prefix of the queue that is non-transactional writers is done
-	// waiting.
+	// The prefix of the queue that is non-transactional writers or requests
+	// that intend to acquire Shared locks is done waiting, since neither of
+	// them makes reservations.
 	for e := l.queuedWriters.Front(); e != nil; {
 		qg := e.Value.(*queuedGuard)
 		g := qg.guard
-		if g.txn == nil {
+		if g.txn == nil || qg.str == lock.Shared {
 			curr := e
 			e = e.Next()
 			l.queuedWriters.Remove(curr)
@@ -1743,7 +2073,8 @@ func (l *lockState) lockIsFree() (gc bool) {
 		return true
 	}
 
-	// First waiting writer (it must be transactional) gets the reservation.
+	// First waiting writer (it must be transactional and desire an Exclusive
+	// lock) gets the reservation.
 	e := l.queuedWriters.Front()
 	qg := e.Value.(*queuedGuard)
 	g := qg.guard
@@ -1783,6 +2114,7 @@ func (t *lockTableImpl) ScanAndEnqueue(req Request, guard lockTableGuard) lockTa
 		g.spans = req.LockSpans
 		g.readTS = req.readConflictTimestamp()
 		g.writeTS = req.writeConflictTimestamp()
+		g.readStr = req.readLockStrength()
 		g.sa = spanset.NumSpanAccess - 1
 		g.index = -1
 	} else {
@@ -1901,8 +2233,8 @@ func (t *lockTableImpl) AcquireLock(
 		// If not enabled, don't track any locks.
 		return nil
 	}
-	if strength != lock.Exclusive {
-		return errors.AssertionFailedf("lock strength not Exclusive")
+	if strength != lock.Exclusive && strength != lock.Shared {
+		return errors.AssertionFailedf("lock strength not Exclusive or Shared")
 	}
 	ss := spanset.SpanGlobal
 	if keys.IsLocal(key) {
@@ -52,10 +52,11 @@ new-txn txn=<name> ts=<int>[,<int>] epoch=<int> [seq=<int>]
 
  Creates a TxnMeta.
 
-new-request r=<name> txn=<name>|none ts=<int>[,<int>] spans=r|w@<start>[,<end>]+...
+new-request r=<name> txn=<name>|none ts=<int>[,<int>] spans=r|w@<start>[,<end>]+... [shared]
 ----
 
- Creates a Request.
+ Creates a Request. If shared is specified, the request acquires Shared locks
+ on the keys in its read spans.
 
 scan r=<name>
 ----
@@ -64,11 +65,12 @@ scan r=<name>
  Calls lockTable.ScanAndEnqueue. If the request has an existing guard, uses it.
  If a guard is returned, stores it for later use.
 
-acquire r=<name> k=<key> durability=r|u
+acquire r=<name> k=<key> durability=r|u [strength=shared|exclusive]
 ----
 <error string>
 
- Acquires lock for the request, using the existing guard for that request.
+ Acquires lock for the request, using the existing guard for that request. The
+ lock is acquired with Exclusive strength unless specified otherwise.
 
 release txn=<name> span=<start>[,<end>]
 ----
@@ -204,6 +206,9 @@ func TestLockTableBasic(t *testing.T) {
 					LatchSpans: spans,
 					LockSpans:  spans,
 				}
+				if d.HasArg("shared") {
+					req.ReadLockStrength = lock.Shared
+				}
 				if txnMeta != nil {
 					// Update the transaction's timestamp, if necessary. The transaction
 					// may have needed to move its timestamp for any number of reasons.
@@ -246,7 +251,18 @@ func TestLockTableBasic(t *testing.T) {
 				if s[0] == 'r' {
 					durability = lock.Replicated
 				}
-				if err := lt.AcquireLock(&req.Txn.TxnMeta, roachpb.Key(key), lock.Exclusive, durability); err != nil {
+				strength := lock.Exclusive
+				if d.HasArg("strength") {
+					d.ScanArgs(t, "strength", &s)
+					switch s {
+					case "shared":
+						strength = lock.Shared
+					case "exclusive":
+					default:
+						d.Fatalf(t, "incorrect strength: %s", s)
+					}
+				}
+				if err := lt.AcquireLock(&req.Txn.TxnMeta, roachpb.Key(key), strength, durability); err != nil {
 					return err.Error()
 				}
 				return lt.(*lockTableImpl).String()
@@ -405,8 +421,12 @@ func TestLockTableBasic(t *testing.T) {
 				if txnS == "" {
 					txnS = fmt.Sprintf("unknown txn with ID: %v", state.txn.ID)
 				}
-				return fmt.Sprintf("%sstate=%s txn=%s key=%s held=%t guard-access=%s",
+				str = fmt.Sprintf("%sstate=%s txn=%s key=%s held=%t guard-access=%s",
 					str, typeStr, txnS, state.key, state.held, state.guardAccess)
+				if state.guardStrength == lock.Shared {
+					str += " guard-strength=shared"
+				}
+				return str
 
 			case "enable":
 				seq := int(1)
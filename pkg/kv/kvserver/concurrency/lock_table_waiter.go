@@ -15,6 +15,7 @@ import (
 	"math"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/intentresolver"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/spanset"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -174,13 +175,33 @@ func (w *lockTableWaiterImpl) WaitOn(
 				// out the longer deadlock detection delay before recognizing and
 				// recovering from the failure of a transaction coordinator for
 				// *each* of that transaction's previously written intents.
+				// If the request has an Error wait policy, it does not wait on
+				// the conflict. If the conflict is a held lock then the request
+				// pushes its holder with a PUSH_TOUCH to find out whether the lock
+				// is abandoned, in which case it is removed and the request can
+				// proceed, or whether its holder is still active. If the conflict
+				// is a reservation holder, it is known to be active and the
+				// request raises an error immediately.
+				if req.WaitPolicy == lock.WaitPolicy_Error {
+					if state.held {
+						err = w.pushLockTxn(ctx, req, state)
+					} else {
+						err = newWriteIntentErr(state)
+					}
+					if err != nil {
+						return err
+					}
+					continue
+				}
+
 				livenessPush := state.kind == waitForDistinguished
 				deadlockPush := true
 
-				// If the conflict is a reservation holder and not a held lock then
-				// there's no need to perform a liveness push - the request must be
-				// alive or its context would have been canceled and it would have
-				// exited its lock wait-queues.
+				// If the conflict is a reservation holder (or, for a locking read,
+				// a writer queued ahead of it) and not a held lock then there's no
+				// need to perform a liveness push - the request must be alive or
+				// its context would have been canceled and it would have exited
+				// its lock wait-queues.
 				if !state.held {
 					livenessPush = false
 				}
@@ -386,12 +407,17 @@ func (w *lockTableWaiterImpl) WaitOnLock(
 	if err != nil {
 		return roachpb.NewError(err)
 	}
+	str := lock.Exclusive
+	if sa == spanset.SpanReadOnly {
+		str = req.readLockStrength()
+	}
 	return w.pushLockTxn(ctx, req, waitingState{
-		kind:        waitFor,
-		txn:         &intent.Txn,
-		key:         intent.Key,
-		held:        true,
-		guardAccess: sa,
+		kind:          waitFor,
+		txn:           &intent.Txn,
+		key:           intent.Key,
+		held:          true,
+		guardAccess:   sa,
+		guardStrength: str,
 	})
 }
 
@@ -411,29 +437,43 @@ func (w *lockTableWaiterImpl) pushLockTxn(
 	ctx context.Context, req Request, ws waitingState,
 ) *Error {
 	if w.disableTxnPushing {
-		return roachpb.NewError(&roachpb.WriteIntentError{
-			Intents: []roachpb.Intent{roachpb.MakeIntent(ws.txn, ws.key)},
-		})
+		return newWriteIntentErr(ws)
 	}
 
 	// Determine which form of push to use. For read-write conflicts, try to
 	// push the lock holder's timestamp forward so the read request can read
 	// under the lock. For write-write conflicts, try to abort the lock holder
 	// entirely so the write request can revoke and replace the lock with its
-	// own lock.
+	// own lock. Locking reads conflict with locks at any timestamp, so pushing
+	// the lock holder's timestamp would not help them either and they also try
+	// to abort the lock holder.
+	//
+	// Requests with an Error wait policy don't wait for the lock holder. They
+	// only push it to determine whether it is abandoned and its lock can be
+	// removed, so the push fails immediately if the lock holder is active.
 	h := w.pushHeader(req)
 	var pushType roachpb.PushTxnType
-	switch ws.guardAccess {
-	case spanset.SpanReadOnly:
+	switch {
+	case req.WaitPolicy == lock.WaitPolicy_Error:
+		pushType = roachpb.PUSH_TOUCH
+		log.VEventf(ctx, 3, "pushing txn %s to check if abandoned", ws.txn.ID.Short())
+	case ws.guardAccess == spanset.SpanReadOnly && ws.guardStrength == lock.None:
 		pushType = roachpb.PUSH_TIMESTAMP
 		log.VEventf(ctx, 3, "pushing timestamp of txn %s above %s", ws.txn.ID.Short(), h.Timestamp)
-	case spanset.SpanReadWrite:
+	default:
 		pushType = roachpb.PUSH_ABORT
 		log.VEventf(ctx, 3, "pushing txn %s to abort", ws.txn.ID.Short())
 	}
 
 	pusheeTxn, err := w.ir.PushTransaction(ctx, ws.txn, h, pushType)
 	if err != nil {
+		// If the push failed because the lock holder is active and the request
+		// has an Error wait policy, return an error for the conflicting lock.
+		if req.WaitPolicy == lock.WaitPolicy_Error &&
+			errors.HasType(err.GetDetail(), (*roachpb.TransactionPushError)(nil)) {
+			log.VEventf(ctx, 3, "failed to push txn %s, not waiting", ws.txn.ID.Short())
+			return newWriteIntentErr(ws)
+		}
 		return err
 	}
 
@@ -476,6 +516,14 @@ func (w *lockTableWaiterImpl) pushLockTxn(
 	return w.ir.ResolveIntent(ctx, resolve, opts)
 }
 
+// newWriteIntentErr returns a WriteIntentError for the lock in the provided
+// waitingState.
+func newWriteIntentErr(ws waitingState) *Error {
+	return roachpb.NewError(&roachpb.WriteIntentError{
+		Intents: []roachpb.Intent{roachpb.MakeIntent(ws.txn, ws.key)},
+	})
+}
+
 // pushRequestTxn pushes the owner of the provided request.
 //
 // The method blocks until either the pusher's transaction is aborted or the
@@ -16,6 +16,7 @@ import (
 	"math/rand"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/intentresolver"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/spanset"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -248,6 +249,106 @@ func TestLockTableWaiterWithNonTxn(t *testing.T) {
 	})
 }
 
+// TestLockTableWaiterWithErrorWaitPolicy tests the lockTableWaiter's behavior
+// under different waiting states with an Error wait policy.
+func TestLockTableWaiterWithErrorWaitPolicy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	keyA := roachpb.Key("keyA")
+	makeReq := func() Request {
+		txn := makeTxnProto("request")
+		return Request{
+			Txn:        &txn,
+			Timestamp:  txn.ReadTimestamp,
+			WaitPolicy: lock.WaitPolicy_Error,
+		}
+	}
+
+	testutils.RunTrueAndFalse(t, "pusheeActive", func(t *testing.T, pusheeActive bool) {
+		for name, k := range map[string]waitKind{
+			"waitFor":              waitFor,
+			"waitForDistinguished": waitForDistinguished,
+			"waitElsewhere":        waitElsewhere,
+		} {
+			t.Run(name, func(t *testing.T) {
+				w, ir, g := setupLockTableWaiterTest()
+				defer w.stopper.Stop(ctx)
+				pusheeTxn := makeTxnProto("pushee")
+
+				g.state = waitingState{
+					kind:        k,
+					txn:         &pusheeTxn.TxnMeta,
+					key:         keyA,
+					held:        true,
+					guardAccess: spanset.SpanReadWrite,
+				}
+				g.notify()
+
+				ir.pushTxn = func(
+					_ context.Context,
+					pusheeArg *enginepb.TxnMeta,
+					_ roachpb.Header,
+					pushType roachpb.PushTxnType,
+				) (*roachpb.Transaction, *Error) {
+					require.Equal(t, &pusheeTxn.TxnMeta, pusheeArg)
+					require.Equal(t, roachpb.PUSH_TOUCH, pushType)
+					if pusheeActive {
+						return nil, roachpb.NewError(&roachpb.TransactionPushError{
+							PusheeTxn: *pusheeTxn.Clone(),
+						})
+					}
+
+					// The pushee is abandoned, so the lock is resolved and the
+					// request can proceed.
+					ir.resolveIntent = func(_ context.Context, intent roachpb.LockUpdate) *Error {
+						require.Equal(t, keyA, intent.Key)
+						require.Equal(t, pusheeTxn.ID, intent.Txn.ID)
+						require.Equal(t, roachpb.ABORTED, intent.Status)
+						g.state = waitingState{kind: doneWaiting}
+						g.notify()
+						return nil
+					}
+					return &roachpb.Transaction{TxnMeta: *pusheeArg, Status: roachpb.ABORTED}, nil
+				}
+
+				err := w.WaitOn(ctx, makeReq(), g)
+				if pusheeActive {
+					require.NotNil(t, err)
+					wiErr, ok := err.GetDetail().(*roachpb.WriteIntentError)
+					require.True(t, ok)
+					require.Len(t, wiErr.Intents, 1)
+					require.Equal(t, keyA, wiErr.Intents[0].Key)
+					require.Equal(t, pusheeTxn.ID, wiErr.Intents[0].Txn.ID)
+				} else {
+					require.Nil(t, err)
+				}
+			})
+		}
+	})
+
+	t.Run("reservation", func(t *testing.T) {
+		w, _, g := setupLockTableWaiterTest()
+		defer w.stopper.Stop(ctx)
+		pusheeTxn := makeTxnProto("pushee")
+
+		// The reservation holder is known to be active, so it is not pushed.
+		g.state = waitingState{
+			kind:        waitForDistinguished,
+			txn:         &pusheeTxn.TxnMeta,
+			key:         keyA,
+			held:        false,
+			guardAccess: spanset.SpanReadWrite,
+		}
+		g.notify()
+
+		err := w.WaitOn(ctx, makeReq(), g)
+		require.NotNil(t, err)
+		require.IsType(t, &roachpb.WriteIntentError{}, err.GetDetail())
+	})
+}
+
 func testWaitPush(t *testing.T, k waitKind, makeReq func() Request, expPushTS hlc.Timestamp) {
 	ctx := context.Background()
 	keyA := roachpb.Key("keyA")
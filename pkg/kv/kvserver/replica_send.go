@@ -17,6 +17,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/clusterversion"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/batcheval"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/spanset"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/txnwait"
@@ -277,13 +278,15 @@ func (r *Replica) executeBatchWithConcurrencyRetries(
 		// returns a request guard that must be eventually released.
 		var resp []roachpb.ResponseUnion
 		g, resp, pErr = r.concMgr.SequenceReq(ctx, g, concurrency.Request{
-			Txn:             ba.Txn,
-			Timestamp:       ba.Timestamp,
-			Priority:        ba.UserPriority,
-			ReadConsistency: ba.ReadConsistency,
-			Requests:        ba.Requests,
-			LatchSpans:      latchSpans,
-			LockSpans:       lockSpans,
+			Txn:              ba.Txn,
+			Timestamp:        ba.Timestamp,
+			Priority:         ba.UserPriority,
+			ReadConsistency:  ba.ReadConsistency,
+			WaitPolicy:       ba.WaitPolicy,
+			Requests:         ba.Requests,
+			LatchSpans:       latchSpans,
+			LockSpans:        lockSpans,
+			ReadLockStrength: readLockStrength(ba),
 		})
 		if pErr != nil {
 			return nil, pErr
@@ -666,6 +669,25 @@ func (r *Replica) collectSpans(
 	return latchSpans, lockSpans, nil
 }
 
+// readLockStrength returns the strength of the locks that the batch acquires
+// on the keys that it declares as read-only lock spans. These are Shared locks
+// if any of its requests is a read that acquires Shared locks. In that case
+// the other reads in the batch are also treated as if they acquired Shared
+// locks by the lockTable, which is conservative.
+func readLockStrength(ba *roachpb.BatchRequest) lock.Strength {
+	if ba.Txn == nil {
+		return lock.None
+	}
+	for _, union := range ba.Requests {
+		inner := union.GetInner()
+		if roachpb.IsReadOnly(inner) && roachpb.IsLocking(inner) &&
+			roachpb.LockingStrength(inner) == lock.Shared {
+			return lock.Shared
+		}
+	}
+	return lock.None
+}
+
 // limitTxnMaxTimestamp limits the batch transaction's max timestamp
 // so that it respects any timestamp already observed on this node.
 // This prevents unnecessary uncertainty interval restarts caused by
@@ -151,6 +151,18 @@ func LockingDurability(args Request) lock.Durability {
 	return lock.Replicated
 }
 
+// LockingStrength returns the strength of the locks acquired by the request.
+// The function assumes that IsLocking(args).
+func LockingStrength(args Request) lock.Strength {
+	switch t := args.(type) {
+	case *ScanRequest:
+		return t.KeyLocking
+	case *ReverseScanRequest:
+		return t.KeyLocking
+	}
+	return lock.Exclusive
+}
+
 // IsIntentWrite returns true if the request produces write intents at
 // the request's sequence number when used within a transaction.
 func IsIntentWrite(args Request) bool {
@@ -1929,9 +1929,11 @@ func AsIntents(txn *enginepb.TxnMeta, keys []Key) []Intent {
 }
 
 // MakeLockAcquisition makes a lock acquisition message from the given
-// txn, key, and durability level.
-func MakeLockAcquisition(txn *Transaction, key Key, dur lock.Durability) LockAcquisition {
-	return LockAcquisition{Span: Span{Key: key}, Txn: txn.TxnMeta, Durability: dur}
+// txn, key, strength, and durability level.
+func MakeLockAcquisition(
+	txn *Transaction, key Key, str lock.Strength, dur lock.Durability,
+) LockAcquisition {
+	return LockAcquisition{Span: Span{Key: key}, Txn: txn.TxnMeta, Durability: dur, Strength: str}
 }
 
 // MakeLockUpdate makes a lock update from the given txn and span.
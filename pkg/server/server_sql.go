@@ -42,6 +42,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/distsql"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/notify"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire"
 	"github.com/cockroachdb/cockroach/pkg/sql/querycache"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -85,6 +86,7 @@ type sqlServer struct {
 	// sqlMemMetrics are used to track memory usage of sql sessions.
 	sqlMemMetrics           sql.MemoryMetrics
 	stmtDiagnosticsRegistry *stmtdiagnostics.Registry
+	notificationRegistry    *notify.Registry
 }
 
 // sqlServerOptionalArgs are the arguments supplied to newSQLServer which
@@ -558,6 +560,16 @@ func newSQLServer(ctx context.Context, cfg sqlServerArgs) (*sqlServer, error) {
 		cfg.Settings,
 	)
 	execCfg.StmtDiagnosticsRecorder = stmtDiagnosticsRegistry
+	notificationRegistry := notify.NewRegistry(
+		cfg.Settings,
+		codec,
+		cfg.clock,
+		cfg.circularInternalExecutor,
+		cfg.distSender.RangeFeed,
+		cfg.isMeta1Leaseholder,
+	)
+	execCfg.NotificationRegistry = notificationRegistry
+	cfg.registry.AddMetricStruct(notificationRegistry.Metrics())
 
 	temporaryObjectCleaner := sql.NewTemporaryObjectCleaner(
 		cfg.Settings,
@@ -586,6 +598,7 @@ func newSQLServer(ctx context.Context, cfg sqlServerArgs) (*sqlServer, error) {
 		adminMemMetrics:         adminMemMetrics,
 		sqlMemMetrics:           sqlMemMetrics,
 		stmtDiagnosticsRegistry: stmtDiagnosticsRegistry,
+		notificationRegistry:    notificationRegistry,
 	}, nil
 }
 
@@ -613,6 +626,7 @@ func (s *sqlServer) start(
 		return err
 	}
 	s.stmtDiagnosticsRegistry.Start(ctx, stopper)
+	s.notificationRegistry.Start(ctx, stopper)
 
 	// Before serving SQL requests, we have to make sure the database is
 	// in an acceptable form for this version of the software.
@@ -1877,6 +1877,39 @@ func (s *statusServer) CancelQuery(
 	return output, nil
 }
 
+// CancelQueryByKey responds to a pgwire CancelRequest, forwarded here by the
+// pgwire server of the node that received it. The request is routed to the
+// node that owns the session, as identified by the SQLInstanceID embedded in
+// the cancel key, which then cancels the queries running on the session.
+//
+// Unlike CancelQuery, no user is checked: knowing the secret key is what
+// authorizes the cancellation.
+func (s *statusServer) CancelQueryByKey(
+	ctx context.Context, req *serverpb.CancelQueryByKeyRequest,
+) (*serverpb.CancelQueryByKeyResponse, error) {
+	ctx = propagateGatewayMetadata(ctx)
+	ctx = s.AnnotateCtx(ctx)
+
+	// A zero SQLInstanceID means that the key did not have room for it, in
+	// which case only the local node can serve the request.
+	nodeID := roachpb.NodeID(req.SQLInstanceID)
+	if nodeID != 0 && nodeID != s.gossip.NodeID.Get() {
+		status, err := s.dialNode(ctx, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		return status.CancelQueryByKey(ctx, req)
+	}
+
+	output := &serverpb.CancelQueryByKeyResponse{}
+	canceled, err := s.sessionRegistry.CancelQueryByKey(req.CancelQueryKey)
+	if err != nil {
+		output.Error = err.Error()
+	}
+	output.Canceled = canceled
+	return output, nil
+}
+
 // SpanStats requests the total statistics stored on a node for a given key
 // span, which may include multiple ranges.
 func (s *statusServer) SpanStats(
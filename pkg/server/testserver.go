@@ -430,12 +430,15 @@ func (ts *TestServer) Start(params base.TestServerArgs) error {
 	return ts.Server.Start(ctx)
 }
 
+// dummyProtectedTSProvider is the protectedts.Provider of secondary tenants.
+// The KV layer does not consult the protected timestamp records of tenants, so
+// it refuses to write them rather than pretend to protect data from GC.
 type dummyProtectedTSProvider struct {
 	protectedts.Provider
 }
 
 func (d dummyProtectedTSProvider) Protect(context.Context, *kv.Txn, *ptpb.Record) error {
-	return errors.New("fake protectedts.Provider")
+	return errors.New("protected timestamps are not supported in secondary tenants")
 }
 
 const fakeNodeID = roachpb.NodeID(123456789)
@@ -0,0 +1,310 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+// advisoryLock identifies an advisory lock.
+type advisoryLock struct {
+	dbID sqlbase.ID
+	key  tree.AdvisoryLockKey
+}
+
+// heldAdvisoryLock is an advisory lock held by a session.
+type heldAdvisoryLock struct {
+	// txn is the transaction that holds the lock in the KV layer.
+	txn *kv.Txn
+	// sessionCount is the number of times the lock was acquired at the
+	// session level and not released yet.
+	sessionCount int
+	// xactCount is the number of times the lock was acquired at the
+	// transaction level in the current transaction.
+	xactCount int
+}
+
+// advisoryLockSet is the set of advisory locks held by a session.
+//
+// Advisory locks are acquired in the KV layer: acquiring a lock writes an
+// intent on a key of the AdvisoryLocksID keyspace that encodes the lock's
+// database and key. Each lock is held by its own transaction, which is never
+// committed, and is released by rolling back that transaction. Sessions
+// trying to acquire the lock meanwhile wait on the intent in the lock table of
+// the concurrency manager, like for any other conflicting write, and the
+// heartbeats of the transaction prevent the lock from being considered
+// abandoned while the session is alive. The try variants use the Error wait
+// policy, so that they fail instead of waiting.
+//
+// Like in Postgres, locks are reentrant: a lock held by a session can be
+// acquired again by that session, and a session-level lock is only released
+// once it has been released as many times as it was acquired.
+//
+// Since the transactions holding advisory locks are not the ones waiting for
+// advisory locks, the KV layer does not detect deadlocks between sessions
+// waiting for each other's advisory locks. Such sessions wait until their
+// statement is canceled or times out.
+type advisoryLockSet struct {
+	db     *kv.DB
+	codec  keys.SQLCodec
+	nodeID roachpb.NodeID
+	// pid identifies the session in pg_locks.
+	pid int32
+
+	// locks is only accessed from the connExecutor's goroutine.
+	locks map[advisoryLock]*heldAdvisoryLock
+}
+
+func (s *advisoryLockSet) init(db *kv.DB, codec keys.SQLCodec, nodeID roachpb.NodeID, pid int32) {
+	s.db = db
+	s.codec = codec
+	s.nodeID = nodeID
+	s.pid = pid
+}
+
+// acquire acquires the given lock, at the session level or, if xact is set, at
+// the transaction level. If try is set, it returns false if the lock is held
+// by another session instead of waiting for the lock to be released.
+func (s *advisoryLockSet) acquire(
+	ctx context.Context, l advisoryLock, xact, try bool,
+) (bool, error) {
+	h, ok := s.locks[l]
+	if !ok {
+		txn := kv.NewTxn(ctx, s.db, s.nodeID)
+		b := txn.NewBatch()
+		if try {
+			b.Header.WaitPolicy = lock.WaitPolicy_Error
+		}
+		b.Put(makeAdvisoryLockKey(s.codec, l), int64(s.pid))
+		if err := txn.Run(ctx, b); err != nil {
+			s.rollback(ctx, txn)
+			if try && errors.HasType(err, (*roachpb.WriteIntentError)(nil)) {
+				return false, nil
+			}
+			return false, err
+		}
+		h = &heldAdvisoryLock{txn: txn}
+		if s.locks == nil {
+			s.locks = make(map[advisoryLock]*heldAdvisoryLock)
+		}
+		s.locks[l] = h
+	}
+	if xact {
+		h.xactCount++
+	} else {
+		h.sessionCount++
+	}
+	return true, nil
+}
+
+// release releases the given session-level lock once. It returns false if
+// the session does not hold the lock at the session level.
+func (s *advisoryLockSet) release(ctx context.Context, l advisoryLock) bool {
+	h, ok := s.locks[l]
+	if !ok || h.sessionCount == 0 {
+		return false
+	}
+	h.sessionCount--
+	s.maybeReleaseLock(ctx, l, h)
+	return true
+}
+
+// releaseAll releases all the session-level locks. The transaction-level
+// locks are released when the current transaction ends.
+func (s *advisoryLockSet) releaseAll(ctx context.Context) {
+	for l, h := range s.locks {
+		h.sessionCount = 0
+		s.maybeReleaseLock(ctx, l, h)
+	}
+}
+
+// onTxnFinish releases the transaction-level locks when the current
+// transaction commits or rolls back.
+func (s *advisoryLockSet) onTxnFinish(ctx context.Context, ev txnEvent) {
+	if ev != txnCommit && ev != txnRollback {
+		return
+	}
+	for l, h := range s.locks {
+		h.xactCount = 0
+		s.maybeReleaseLock(ctx, l, h)
+	}
+}
+
+// close releases all the locks held by the session.
+func (s *advisoryLockSet) close(ctx context.Context) {
+	for l, h := range s.locks {
+		delete(s.locks, l)
+		s.rollback(ctx, h.txn)
+	}
+}
+
+// maybeReleaseLock releases the lock in the KV layer if the session does not
+// hold it anymore.
+func (s *advisoryLockSet) maybeReleaseLock(
+	ctx context.Context, l advisoryLock, h *heldAdvisoryLock,
+) {
+	if h.sessionCount == 0 && h.xactCount == 0 {
+		delete(s.locks, l)
+		s.rollback(ctx, h.txn)
+	}
+}
+
+// rollback rolls back a transaction holding a lock. Failures are only logged:
+// the lock is eventually released once the transaction's record expires.
+func (s *advisoryLockSet) rollback(ctx context.Context, txn *kv.Txn) {
+	if err := txn.Rollback(ctx); err != nil {
+		log.Warningf(ctx, "unable to release advisory lock: %v", err)
+	}
+}
+
+// makeAdvisoryLockKey returns the key written to acquire the given lock.
+func makeAdvisoryLockKey(codec keys.SQLCodec, l advisoryLock) roachpb.Key {
+	k := codec.TablePrefix(keys.AdvisoryLocksID)
+	k = encoding.EncodeUvarintAscending(k, uint64(l.dbID))
+	k = encoding.EncodeUvarintAscending(k, uint64(l.key.ClassID))
+	k = encoding.EncodeUvarintAscending(k, uint64(l.key.ObjID))
+	return encoding.EncodeUvarintAscending(k, uint64(l.key.ObjSubID))
+}
+
+// decodeAdvisoryLockKey decodes a key returned by makeAdvisoryLockKey.
+func decodeAdvisoryLockKey(codec keys.SQLCodec, key roachpb.Key) (advisoryLock, error) {
+	rem, id, err := codec.DecodeTablePrefix(key)
+	if err != nil {
+		return advisoryLock{}, err
+	}
+	if id != keys.AdvisoryLocksID {
+		return advisoryLock{}, errors.AssertionFailedf("invalid advisory lock key %s", key)
+	}
+	var vals [4]uint64
+	for i := range vals {
+		if rem, vals[i], err = encoding.DecodeUvarintAscending(rem); err != nil {
+			return advisoryLock{}, err
+		}
+	}
+	return advisoryLock{
+		dbID: sqlbase.ID(vals[0]),
+		key: tree.AdvisoryLockKey{
+			ClassID:  uint32(vals[1]),
+			ObjID:    uint32(vals[2]),
+			ObjSubID: uint16(vals[3]),
+		},
+	}, nil
+}
+
+// grantedAdvisoryLock is an advisory lock held by a session of the cluster.
+type grantedAdvisoryLock struct {
+	advisoryLock
+	pid int32
+}
+
+// listAdvisoryLocks returns the advisory locks held by all the sessions of the
+// cluster, which are the intents in the AdvisoryLocksID keyspace. Locks being
+// released at the same time may or may not be listed.
+func listAdvisoryLocks(
+	ctx context.Context, db *kv.DB, codec keys.SQLCodec,
+) ([]grantedAdvisoryLock, error) {
+	span := roachpb.Span{Key: codec.TablePrefix(keys.AdvisoryLocksID)}
+	span.EndKey = span.Key.PrefixEnd()
+	var ba roachpb.BatchRequest
+	ba.ReadConsistency = roachpb.READ_UNCOMMITTED
+	ba.Add(&roachpb.ScanRequest{RequestHeader: roachpb.RequestHeaderFromSpan(span)})
+	br, pErr := db.NonTransactionalSender().Send(ctx, ba)
+	if pErr != nil {
+		return nil, pErr.GoError()
+	}
+	// No data is ever committed to the keyspace, so all the keys with a value
+	// are intents.
+	rows := br.Responses[0].GetScan().IntentRows
+	res := make([]grantedAdvisoryLock, 0, len(rows))
+	for _, row := range rows {
+		l, err := decodeAdvisoryLockKey(codec, row.Key)
+		if err != nil {
+			return nil, err
+		}
+		pid, err := row.Value.GetInt()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, grantedAdvisoryLock{advisoryLock: l, pid: int32(pid)})
+	}
+	return res, nil
+}
+
+// currentAdvisoryLock returns the lock with the given key in the current
+// database, after checking that advisory locks can be used.
+func (p *planner) currentAdvisoryLock(
+	ctx context.Context, key tree.AdvisoryLockKey,
+) (advisoryLock, error) {
+	if p.advisoryLocks == nil {
+		return advisoryLock{}, pgerror.New(pgcode.FeatureNotSupported,
+			"advisory locks are not supported in this context")
+	}
+	var dbID sqlbase.ID
+	if dbName := p.CurrentDatabase(); dbName != "" {
+		db, err := p.ResolveUncachedDatabaseByName(ctx, dbName, true /* required */)
+		if err != nil {
+			return advisoryLock{}, err
+		}
+		dbID = db.GetID()
+	}
+	return advisoryLock{dbID: dbID, key: key}, nil
+}
+
+// AcquireAdvisoryLock is part of the tree.EvalPlanner interface. It implements
+// pg_advisory_lock() and its variants.
+func (p *planner) AcquireAdvisoryLock(
+	ctx context.Context, key tree.AdvisoryLockKey, xact, try bool,
+) (bool, error) {
+	if try && !p.ExecCfg().Settings.Version.IsActive(ctx, clusterversion.VersionLockWaitPolicy) {
+		return false, pgerror.Newf(pgcode.ObjectNotInPrerequisiteState,
+			`trying to acquire advisory locks requires all nodes to be upgraded to %s`,
+			clusterversion.VersionByKey(clusterversion.VersionLockWaitPolicy))
+	}
+	l, err := p.currentAdvisoryLock(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return p.advisoryLocks.acquire(ctx, l, xact, try)
+}
+
+// ReleaseAdvisoryLock is part of the tree.EvalPlanner interface. It implements
+// pg_advisory_unlock().
+func (p *planner) ReleaseAdvisoryLock(ctx context.Context, key tree.AdvisoryLockKey) (bool, error) {
+	l, err := p.currentAdvisoryLock(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return p.advisoryLocks.release(ctx, l), nil
+}
+
+// ReleaseAllAdvisoryLocks is part of the tree.EvalPlanner interface. It
+// implements pg_advisory_unlock_all().
+func (p *planner) ReleaseAllAdvisoryLocks(ctx context.Context) error {
+	if p.advisoryLocks == nil {
+		return pgerror.New(pgcode.FeatureNotSupported,
+			"advisory locks are not supported in this context")
+	}
+	p.advisoryLocks.releaseAll(ctx)
+	return nil
+}
@@ -222,15 +222,12 @@ func (a *applyJoinNode) Next(params runParams) (bool, error) {
 func (a *applyJoinNode) runRightSidePlan(params runParams, plan *planTop) error {
 	a.run.curRightRow = 0
 	a.run.rightRows.Clear(params.ctx)
-	return runPlanInsidePlan(params, plan, a.run.rightRows)
+	return runPlanInsidePlan(params, plan, NewRowResultWriter(a.run.rightRows))
 }
 
-// runPlanInsidePlan is used to run a plan and gather the results in a row
-// container, as part of the execution of an "outer" plan.
-func runPlanInsidePlan(
-	params runParams, plan *planTop, rowContainer *rowcontainer.RowContainer,
-) error {
-	rowResultWriter := NewRowResultWriter(rowContainer)
+// runPlanInsidePlan is used to run a plan and gather the results in a result
+// writer, as part of the execution of an "outer" plan.
+func runPlanInsidePlan(params runParams, plan *planTop, rowResultWriter rowResultWriter) error {
 	recv := MakeDistSQLReceiver(
 		params.ctx, rowResultWriter, tree.Rows,
 		params.extendedEvalCtx.ExecCfg.RangeDescriptorCache,
@@ -105,6 +105,7 @@ func unwrapDescriptor(
 	// TODO(ajwerner): Fill in the ModificationTime field for the descriptor.
 	desc.MaybeSetModificationTimeFromMVCCTimestamp(ctx, ts)
 	table, database, typ, schema := desc.Table(hlc.Timestamp{}), desc.GetDatabase(), desc.GetType(), desc.GetSchema()
+	function := desc.GetFunction()
 	switch {
 	case table != nil:
 		if err := table.MaybeFillInDescriptor(ctx, txn, codec); err != nil {
@@ -124,6 +125,12 @@ func unwrapDescriptor(
 		return sqlbase.NewImmutableTypeDescriptor(*typ), nil
 	case schema != nil:
 		return sqlbase.NewImmutableSchemaDescriptor(*schema), nil
+	case function != nil:
+		fnDesc := sqlbase.NewImmutableFunctionDescriptor(*function)
+		if err := fnDesc.Validate(); err != nil {
+			return nil, err
+		}
+		return fnDesc, nil
 	default:
 		return nil, nil
 	}
@@ -155,6 +162,7 @@ func unwrapDescriptorMutable(
 ) (catalog.MutableDescriptor, error) {
 	desc.MaybeSetModificationTimeFromMVCCTimestamp(ctx, ts)
 	table, database, typ, schema := desc.Table(hlc.Timestamp{}), desc.GetDatabase(), desc.GetType(), desc.GetSchema()
+	function := desc.GetFunction()
 	switch {
 	case table != nil:
 		if err := table.MaybeFillInDescriptor(ctx, txn, codec); err != nil {
@@ -174,6 +182,12 @@ func unwrapDescriptorMutable(
 		return sqlbase.NewMutableExistingTypeDescriptor(*typ), nil
 	case schema != nil:
 		return sqlbase.NewMutableExistingSchemaDescriptor(*schema), nil
+	case function != nil:
+		fnDesc := sqlbase.NewMutableExistingFunctionDescriptor(*function)
+		if err := fnDesc.Validate(); err != nil {
+			return nil, err
+		}
+		return fnDesc, nil
 	default:
 		return nil, nil
 	}
@@ -291,6 +291,14 @@ func (a UncachedPhysicalAccessor) GetObjectDesc(
 			return sqlbase.NewMutableExistingTypeDescriptor(*desc.TypeDesc()), nil
 		}
 		return desc, nil
+	case *sqlbase.ImmutableFunctionDescriptor:
+		if desc.Dropped() {
+			return nil, nil
+		}
+		if flags.RequireMutable {
+			return sqlbase.NewMutableExistingFunctionDescriptor(*desc.FunctionDesc()), nil
+		}
+		return desc, nil
 	}
 	return nil, nil
 }
@@ -828,6 +828,50 @@ func (tc *Collection) GetTypeVersionByID(
 	return typ, nil
 }
 
+// User-defined function accessors.
+
+// GetMutableFunctionDescriptor is the equivalent of GetMutableTableDescriptor
+// but for accessing user-defined functions. Nil is returned if the name does
+// not refer to a function, unless flags.Required is set.
+func (tc *Collection) GetMutableFunctionDescriptor(
+	ctx context.Context, txn *kv.Txn, tn *tree.TableName, flags tree.ObjectLookupFlags,
+) (*sqlbase.MutableFunctionDescriptor, error) {
+	desc, err := tc.getMutableObjectDescriptor(ctx, txn, tn, flags)
+	if err != nil {
+		return nil, err
+	}
+	mutDesc, ok := desc.(*sqlbase.MutableFunctionDescriptor)
+	if !ok {
+		if flags.Required {
+			return nil, pgerror.Newf(pgcode.UndefinedFunction,
+				"function %s does not exist", tree.ErrString(tn))
+		}
+		return nil, nil
+	}
+	return mutDesc, nil
+}
+
+// GetFunctionVersion is the equivalent of GetTableVersion but for accessing
+// user-defined functions. Nil is returned if the name does not refer to a
+// function, unless flags.Required is set.
+func (tc *Collection) GetFunctionVersion(
+	ctx context.Context, txn *kv.Txn, tn *tree.TableName, flags tree.ObjectLookupFlags,
+) (*sqlbase.ImmutableFunctionDescriptor, error) {
+	desc, err := tc.getObjectVersion(ctx, txn, tn, flags)
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := desc.(*sqlbase.ImmutableFunctionDescriptor)
+	if !ok {
+		if flags.Required {
+			return nil, pgerror.Newf(pgcode.UndefinedFunction,
+				"function %s does not exist", tree.ErrString(tn))
+		}
+		return nil, nil
+	}
+	return fn, nil
+}
+
 // DBAction is an operation to an uncommitted database.
 type DBAction bool
 
@@ -32,6 +32,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirecancel"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -461,6 +462,13 @@ func (h ConnectionHandler) GetUnqualifiedIntSize() *types.T {
 	}
 }
 
+// GetQueryCancelKey returns the key that can be used in a pgwire
+// CancelRequest to cancel the queries running on this session. It is sent to
+// the client in the BackendKeyData message during the session set-up.
+func (h ConnectionHandler) GetQueryCancelKey() pgwirecancel.BackendKeyData {
+	return h.ex.queryCancelKey
+}
+
 // GetParamStatus retrieves the configured value of the session
 // variable identified by varName. This is used for the initial
 // message sent to a client during a session set-up.
@@ -608,6 +616,7 @@ func (s *Server) newConnExecutor(
 		executorType:              executorTypeExec,
 		hasCreatedTemporarySchema: false,
 		stmtDiagnosticsRecorder:   s.cfg.StmtDiagnosticsRecorder,
+		queryCancelKey:            pgwirecancel.MakeBackendKeyData(s.cfg.NodeID.SQLInstanceID()),
 	}
 
 	ex.state.txnAbortCount = ex.metrics.EngineMetrics.TxnAbortCount
@@ -649,6 +658,12 @@ func (s *Server) newConnExecutor(
 	ex.transitionCtx.sessionTracing = &ex.sessionTracing
 	ex.statsCollector = ex.newStatsCollector()
 
+	processID, _ := ex.queryCancelKey.GetPGCompatibleParts()
+	ex.sessionListener.init(
+		s.cfg.NotificationRegistry, clientComm, &s.cfg.Settings.SV, int32(processID),
+	)
+	ex.advisoryLocks.init(s.cfg.DB, s.cfg.Codec, nodeIDOrZero, int32(processID))
+
 	ex.initPlanner(ctx, &ex.planner)
 
 	return ex
@@ -706,6 +721,9 @@ func (s *Server) newConnExecutorWithTxn(
 	if tcModifier != nil {
 		tcModifier.CopyModifiedObjects(&ex.extraTxnState.descCollection)
 	}
+	// The transaction is committed by the parent executor, which does not know
+	// about the constraints deferred by this executor.
+	ex.extraTxnState.deferredConstraints.immediateOnly = true
 	return ex
 }
 
@@ -856,7 +874,11 @@ func (ex *connExecutor) close(ctx context.Context, closeType closeType) {
 		// Close all statements and prepared portals.
 		ex.extraTxnState.prepStmtsNamespace.resetTo(ctx, prepStmtNamespace{})
 		ex.extraTxnState.prepStmtsNamespaceAtTxnRewindPos.resetTo(ctx, prepStmtNamespace{})
+		// Close all cursors, including the ones declared WITH HOLD.
+		ex.extraTxnState.sqlCursors.closeAll(ctx)
 	}
+	ex.sessionListener.close()
+	ex.advisoryLocks.close(ctx)
 
 	if ex.sessionTracing.Enabled() {
 		if err := ex.sessionTracing.StopTracing(); err != nil {
@@ -990,6 +1012,15 @@ type connExecutor struct {
 		// collections, but these collections are periodically reconciled.
 		prepStmtsNamespaceAtTxnRewindPos prepStmtNamespace
 
+		// sqlCursors contains the cursors declared in the session. Cursors are
+		// closed when the transaction that declared them finishes, unless they
+		// were declared WITH HOLD and the transaction commits.
+		sqlCursors cursorMap
+
+		// deferredConstraints contains the constraint checks deferred until the
+		// transaction commits or the statement completes.
+		deferredConstraints deferredConstraints
+
 		// onTxnFinish (if non-nil) will be called when txn is finished (either
 		// committed or aborted). It is set when txn is started but can remain
 		// unset when txn is executed within another higher-level txn.
@@ -1067,6 +1098,18 @@ type connExecutor struct {
 
 	sessionID ClusterWideID
 
+	// queryCancelKey is the key that was sent to the client in the pgwire
+	// BackendKeyData message. A CancelRequest carrying this key cancels the
+	// queries currently running on this session.
+	queryCancelKey pgwirecancel.BackendKeyData
+
+	// sessionListener holds the channels the session listens on and the
+	// notifications waiting to be delivered to the client.
+	sessionListener sessionListener
+
+	// advisoryLocks holds the advisory locks acquired by the session.
+	advisoryLocks advisoryLockSet
+
 	// activated determines whether activate() was called already.
 	// When this is set, close() must be called to release resources.
 	activated bool
@@ -1197,6 +1240,14 @@ func (ex *connExecutor) resetExtraTxnState(
 		delete(ex.extraTxnState.prepStmtsNamespace.portals, name)
 	}
 
+	switch ev {
+	case txnCommit, txnRollback, txnRestart:
+		ex.extraTxnState.sqlCursors.onTxnFinish(ctx, ev)
+		ex.extraTxnState.deferredConstraints.reset()
+		ex.sessionListener.onTxnFinish(ev)
+		ex.advisoryLocks.onTxnFinish(ctx, ev)
+	}
+
 	switch ev {
 	case txnCommit, txnRollback:
 		ex.extraTxnState.savepoints.clear()
@@ -1308,9 +1359,9 @@ func (ex *connExecutor) run(
 	ex.onCancelSession = onCancel
 
 	ex.sessionID = ex.generateID()
-	ex.server.cfg.SessionRegistry.register(ex.sessionID, ex)
+	ex.server.cfg.SessionRegistry.register(ex.sessionID, ex.queryCancelKey, ex)
 	ex.planner.extendedEvalCtx.setSessionID(ex.sessionID)
-	defer ex.server.cfg.SessionRegistry.deregister(ex.sessionID)
+	defer ex.server.cfg.SessionRegistry.deregister(ex.sessionID, ex.queryCancelKey)
 
 	for {
 		ex.curStmt = nil
@@ -1343,7 +1394,16 @@ var errDrainingComplete = fmt.Errorf("draining done. this is a good time to fini
 // complete (i.e. we received a DrainRequest - possibly previously - and the
 // connection is found to be idle).
 func (ex *connExecutor) execCmd(ctx context.Context) error {
+	// Notifications are delivered to the client while the session waits for
+	// a command outside of a transaction.
+	_, idle := ex.machine.CurState().(stateNoTxn)
+	if idle {
+		ex.sessionListener.setIdle()
+	}
 	cmd, pos, err := ex.stmtBuf.CurCmd()
+	if idle {
+		ex.sessionListener.setBusy()
+	}
 	if err != nil {
 		return err // err could be io.EOF
 	}
@@ -2093,6 +2153,10 @@ func (ex *connExecutor) initPlanner(ctx context.Context, p *planner) {
 	p.sessionDataMutator = ex.dataMutator
 	p.noticeSender = nil
 	p.preparedStatements = ex.getPrepStmtsAccessor()
+	p.sqlCursors = connExCursorAccessor{ex: ex}
+	p.sessionListener = &ex.sessionListener
+	p.advisoryLocks = &ex.advisoryLocks
+	p.deferredConstraints = &ex.extraTxnState.deferredConstraints
 
 	p.queryCacheSession.Init()
 	p.optPlanningCtx.init(p)
@@ -2111,6 +2175,7 @@ func (ex *connExecutor) resetPlanner(
 	p.semaCtx.AsOfTimestamp = nil
 	p.semaCtx.Annotations = nil
 	p.semaCtx.TypeResolver = p
+	p.semaCtx.FunctionResolver = p
 
 	ex.resetEvalCtx(&p.extendedEvalCtx, txn, stmtTS)
 
@@ -2119,6 +2184,7 @@ func (ex *connExecutor) resetPlanner(
 	p.avoidCachedDescriptors = false
 	p.discardRows = false
 	p.collectBundle = false
+	p.functionBodies = nil
 }
 
 // txnStateTransitionsApplyWrapper is a wrapper on top of Machine built with the
@@ -2242,6 +2308,21 @@ func (ex *connExecutor) initStatementResult(
 			return err
 		}
 	}
+	if cp, ok := stmt.AST.(*tree.CopyTo); ok {
+		// The columns listed in the FORCE_QUOTE option can only be checked once
+		// the result columns are known.
+		opts, err := tree.ParseCopyOptions(cp.Options)
+		if err != nil {
+			return err
+		}
+		names := make([]string, len(cols))
+		for i := range cols {
+			names[i] = cols[i].Name
+		}
+		if _, err := opts.ForceQuoteColumns(names); err != nil {
+			return err
+		}
+	}
 	if stmt.AST.StatementType() == tree.Rows {
 		// Note that this call is necessary even if cols is nil.
 		res.SetColumns(ctx, cols)
@@ -2266,6 +2347,18 @@ func (ex *connExecutor) cancelQuery(queryID ClusterWideID) bool {
 	return false
 }
 
+// cancelCurrentQueries is part of the registrySession interface.
+func (ex *connExecutor) cancelCurrentQueries() bool {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	canceled := false
+	for _, queryMeta := range ex.mu.ActiveQueries {
+		queryMeta.cancel()
+		canceled = true
+	}
+	return canceled
+}
+
 // cancelSession is part of the registrySession interface.
 func (ex *connExecutor) cancelSession() {
 	if ex.onCancelSession == nil {
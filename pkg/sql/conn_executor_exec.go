@@ -680,6 +680,12 @@ func (ex *connExecutor) commitSQLTransaction(
 func (ex *connExecutor) commitSQLTransactionInternal(
 	ctx context.Context, stmt tree.Statement,
 ) error {
+	if err := ex.extraTxnState.deferredConstraints.checkAll(
+		ctx, ex.server.cfg.InternalExecutor, ex.state.mu.txn,
+	); err != nil {
+		return err
+	}
+
 	if err := validatePrimaryKeys(&ex.extraTxnState.descCollection); err != nil {
 		return err
 	}
@@ -829,7 +835,17 @@ func (ex *connExecutor) dispatchToExecutionEngine(
 		planner.curPlan.flags.Set(planFlagDistSQLLocal)
 	}
 	ex.sessionTracing.TraceExecStart(ctx, "distributed")
+	// Forget the keys written by a previous statement which failed.
+	ex.extraTxnState.deferredConstraints.stmtUniqueKeys = nil
 	bytesRead, rowsRead, err := ex.execWithDistSQLEngine(ctx, planner, stmt.AST.StatementType(), res, distributePlan, progAtomic)
+	if err == nil && res.Err() == nil {
+		// Check the DEFERRABLE UNIQUE constraints that are not deferred.
+		if err := ex.extraTxnState.deferredConstraints.checkStatement(
+			ctx, ex.server.cfg.InternalExecutor, planner.txn,
+		); err != nil {
+			res.SetError(err)
+		}
+	}
 	ex.sessionTracing.TraceExecEnd(ctx, res.Err(), res.RowsAffected())
 	ex.statsCollector.phaseTimes[plannerEndExecStmt] = timeutil.Now()
 
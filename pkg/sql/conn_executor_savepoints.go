@@ -212,6 +212,7 @@ func (ex *connExecutor) execRollbackToSavepointInOpenState(
 	}
 
 	ex.extraTxnState.savepoints.popToIdx(idx)
+	ex.extraTxnState.sqlCursors.onRollbackToSavepoint(ctx, idx)
 
 	if entry.kvToken.Initial() {
 		return eventTxnRestart{}, nil
@@ -286,6 +287,7 @@ func (ex *connExecutor) execRollbackToSavepointInAbortedState(
 	}
 
 	ex.extraTxnState.savepoints.popToIdx(idx)
+	ex.extraTxnState.sqlCursors.onRollbackToSavepoint(ctx, idx)
 
 	// Special case for mixed-cluster versions, where regular savepoints
 	// are not yet enabled but we still support cockroach_restart. In
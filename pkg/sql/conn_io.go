@@ -17,6 +17,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/notify"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirebase"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -630,6 +631,18 @@ type ClientComm interface {
 	// Flush delivers all the previous results to the client. The results might
 	// have been buffered, in which case this flushes the buffer.
 	Flush(pos CmdPos) error
+
+	// SendNotification sends a notification received on a channel the session
+	// listens on to the client. It is called from a goroutine other than the
+	// connExecutor's, while the connExecutor is waiting for a command, and is
+	// never called concurrently with itself.
+	SendNotification(n notify.Notification) error
+
+	// SendNotice sends a notice to the client outside of the results of a
+	// command. Like SendNotification, it is only called while the
+	// connExecutor is waiting for a command, and never concurrently with
+	// SendNotification.
+	SendNotice(ctx context.Context, noticeErr error) error
 }
 
 // CommandResult represents the result of a statement. It which needs to be
@@ -34,8 +34,8 @@ func (n *controlSchedulesNode) FastPathResults() (int, bool) {
 }
 
 // jobSchedulerEnv returns JobSchedulerEnv.
-func jobSchedulerEnv(params runParams) scheduledjobs.JobSchedulerEnv {
-	if knobs, ok := params.ExecCfg().DistSQLSrv.TestingKnobs.JobsTestingKnobs.(*jobs.TestingKnobs); ok {
+func jobSchedulerEnv(execCfg *ExecutorConfig) scheduledjobs.JobSchedulerEnv {
+	if knobs, ok := execCfg.DistSQLSrv.TestingKnobs.JobsTestingKnobs.(*jobs.TestingKnobs); ok {
 		if knobs.JobSchedulerEnv != nil {
 			return knobs.JobSchedulerEnv
 		}
@@ -45,7 +45,7 @@ func jobSchedulerEnv(params runParams) scheduledjobs.JobSchedulerEnv {
 
 // loadSchedule loads schedule information.
 func loadSchedule(params runParams, scheduleID tree.Datum) (*jobs.ScheduledJob, error) {
-	env := jobSchedulerEnv(params)
+	env := jobSchedulerEnv(params.ExecCfg())
 	schedule := jobs.NewScheduledJob(env)
 
 	// Load schedule expression.  This is needed for resume command, but we
@@ -85,7 +85,7 @@ func updateSchedule(params runParams, schedule *jobs.ScheduledJob) error {
 
 // deleteSchedule deletes specified schedule.
 func deleteSchedule(params runParams, scheduleID int64) error {
-	env := jobSchedulerEnv(params)
+	env := jobSchedulerEnv(params.ExecCfg())
 	_, err := params.ExecCfg().InternalExecutor.ExecEx(
 		params.ctx,
 		"delete-schedule",
@@ -2037,11 +2037,43 @@ CREATE TABLE crdb_internal.forward_dependencies (
 		viewDep := tree.NewDString("view")
 		interleaveDep := tree.NewDString("interleave")
 		sequenceDep := tree.NewDString("sequence")
+		functionDep := tree.NewDString("function")
+
+		// User-defined functions do not leave back-references in the
+		// descriptors of the tables they depend on, so collect them first.
+		descs, err := p.Descriptors().GetAllDescriptors(ctx, p.txn)
+		if err != nil {
+			return err
+		}
+		functionDeps := make(map[sqlbase.ID][]*sqlbase.ImmutableFunctionDescriptor)
+		for _, desc := range descs {
+			if fnDesc, ok := desc.(*sqlbase.ImmutableFunctionDescriptor); ok && !fnDesc.Dropped() {
+				for _, id := range fnDesc.DependsOn {
+					functionDeps[id] = append(functionDeps[id], fnDesc)
+				}
+			}
+		}
+
 		return forEachTableDescAll(ctx, p, dbContext, hideVirtual, /* virtual tables have no backward/forward dependencies*/
 			func(db *sqlbase.ImmutableDatabaseDescriptor, _ string, table *ImmutableTableDescriptor) error {
 				tableID := tree.NewDInt(tree.DInt(table.ID))
 				tableName := tree.NewDString(table.Name)
 
+				// Record the function dependencies.
+				for _, fnDesc := range functionDeps[table.ID] {
+					if err := addRow(
+						tableID, tableName,
+						tree.DNull,
+						tree.NewDInt(tree.DInt(fnDesc.ID)),
+						functionDep,
+						tree.DNull,
+						tree.NewDString(fnDesc.Name),
+						tree.DNull,
+					); err != nil {
+						return err
+					}
+				}
+
 				reportIdxDeps := func(idx *sqlbase.IndexDescriptor) error {
 					for _, interleaveRef := range idx.InterleavedBy {
 						if err := addRow(
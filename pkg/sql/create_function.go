@@ -0,0 +1,169 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catalogkv"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+type createFunctionNode struct {
+	n *tree.CreateFunction
+	// body is the body of the function with fully qualified data sources.
+	body       string
+	dbDesc     *sqlbase.ImmutableDatabaseDescriptor
+	schema     sqlbase.ResolvedSchema
+	schemaName string
+	// dependsOn contains the IDs of the tables and views used by the body.
+	dependsOn []sqlbase.ID
+}
+
+func (n *createFunctionNode) startExec(params runParams) error {
+	name := n.n.Name.Object()
+	fnName := tree.MakeTableNameWithSchema(
+		tree.Name(n.dbDesc.GetName()), tree.Name(n.schemaName), tree.Name(name),
+	)
+
+	if n.dbDesc.GetID() == keys.SystemDatabaseID {
+		return pgerror.New(pgcode.InvalidObjectDefinition,
+			"cannot create functions in the system database")
+	}
+	if n.schema.Kind == sqlbase.SchemaTemporary {
+		return pgerror.Newf(pgcode.FeatureNotSupported,
+			"cannot create function %q in a temporary schema", name)
+	}
+	// Builtins are resolved before user-defined functions, so a function with
+	// the name of a builtin could never be called.
+	if _, ok := tree.FunDefs[name]; ok {
+		return pgerror.Newf(pgcode.DuplicateFunction,
+			"function %q conflicts with a builtin function", name)
+	}
+
+	fnParams := make([]sqlbase.FunctionDescriptor_Param, len(n.n.Params))
+	for i := range n.n.Params {
+		typ, err := tree.ResolveType(params.ctx, n.n.Params[i].Type, params.p.semaCtx.GetTypeResolver())
+		if err != nil {
+			return err
+		}
+		fnParams[i] = sqlbase.FunctionDescriptor_Param{Name: string(n.n.Params[i].Name), Type: typ}
+	}
+	returnType, err := tree.ResolveType(params.ctx, n.n.ReturnType, params.p.semaCtx.GetTypeResolver())
+	if err != nil {
+		return err
+	}
+	volatility := sqlbase.FunctionDescriptor_VOLATILE
+	if n.n.Options.Volatility != 0 {
+		if volatility, err = sqlbase.FunctionVolatilityFromTree(n.n.Options.Volatility); err != nil {
+			return err
+		}
+	}
+
+	key := sqlbase.MakeObjectNameKey(
+		params.ctx, params.ExecCfg().Settings, n.dbDesc.GetID(), n.schema.ID, name,
+	)
+	exists, id, err := sqlbase.LookupObjectID(
+		params.ctx, params.p.txn, params.ExecCfg().Codec, n.dbDesc.GetID(), n.schema.ID, name,
+	)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		fnDesc, err := params.p.Descriptors().GetMutableFunctionDescriptor(
+			params.ctx, params.p.txn, &fnName, params.p.ObjectLookupFlags(false /* required */, true /* requireMutable */),
+		)
+		if err != nil {
+			return err
+		}
+		if fnDesc == nil || !n.n.Replace {
+			existing, err := catalogkv.GetDescriptorByID(params.ctx, params.p.txn, params.ExecCfg().Codec, id)
+			if err != nil {
+				return sqlbase.WrapErrorWhileConstructingObjectAlreadyExistsErr(err)
+			}
+			return sqlbase.MakeObjectAlreadyExistsError(existing.DescriptorProto(), fnName.FQString())
+		}
+		if err := params.p.CheckPrivilege(params.ctx, fnDesc, privilege.DROP); err != nil {
+			return err
+		}
+		// Like in Postgres, OR REPLACE cannot change the signature of the
+		// function.
+		sameParams := len(fnDesc.Params) == len(fnParams)
+		for i := 0; sameParams && i < len(fnParams); i++ {
+			sameParams = fnDesc.Params[i].Type.Identical(fnParams[i].Type)
+		}
+		if !sameParams {
+			return pgerror.Newf(pgcode.InvalidFunctionDefinition,
+				"cannot change the parameter types of function %q", fnName.FQString())
+		}
+		if !fnDesc.ReturnType.Identical(returnType) {
+			return pgerror.Newf(pgcode.InvalidFunctionDefinition,
+				"cannot change the return type of function %q", fnName.FQString())
+		}
+		fnDesc.Params = fnParams
+		fnDesc.Volatility = volatility
+		fnDesc.Body = n.body
+		fnDesc.DependsOn = n.dependsOn
+		if err := fnDesc.Validate(); err != nil {
+			return err
+		}
+		return params.p.writeFunctionChange(
+			params.ctx, fnDesc, tree.AsStringWithFQNames(n.n, params.Ann()),
+		)
+	}
+
+	id, err = catalogkv.GenerateUniqueDescID(params.ctx, params.ExecCfg().DB, params.ExecCfg().Codec)
+	if err != nil {
+		return err
+	}
+	// The creator of the function gets all the privileges on it.
+	privs := sqlbase.NewCustomSuperuserPrivilegeDescriptor(privilege.FunctionPrivileges)
+	privs.Grant(params.SessionData().User, privilege.List{privilege.ALL})
+	desc := sqlbase.NewMutableCreatedFunctionDescriptor(sqlbase.FunctionDescriptor{
+		Name:           name,
+		ID:             id,
+		Version:        1,
+		ParentID:       n.dbDesc.GetID(),
+		ParentSchemaID: n.schema.ID,
+		Privileges:     privs,
+		Params:         fnParams,
+		ReturnType:     returnType,
+		Volatility:     volatility,
+		Body:           n.body,
+		DependsOn:      n.dependsOn,
+	})
+	if err := desc.Validate(); err != nil {
+		return err
+	}
+	return params.p.createDescriptorWithID(
+		params.ctx,
+		key.Key(params.ExecCfg().Codec),
+		id,
+		desc,
+		params.ExecCfg().Settings,
+		tree.AsStringWithFQNames(n.n, params.Ann()),
+	)
+}
+
+// ReadingOwnWrites implements the planNodeReadingOwnWrites interface.
+// This is because CREATE FUNCTION performs multiple KV operations on
+// descriptors and expects to see its own writes.
+func (n *createFunctionNode) ReadingOwnWrites() {}
+
+func (*createFunctionNode) Next(runParams) (bool, error) { return false, nil }
+func (*createFunctionNode) Values() tree.Datums          { return tree.Datums{} }
+func (n *createFunctionNode) Close(ctx context.Context)  {}
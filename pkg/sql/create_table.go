@@ -79,9 +79,21 @@ const (
 	storageParamBool storageParamType = iota
 	storageParamInt
 	storageParamFloat
+	storageParamInterval
+	storageParamString
 	storageParamUnimplemented
 )
 
+// storageParamSQLTypes maps the implemented storage parameter types to the
+// SQL types their values are required to have.
+var storageParamSQLTypes = map[storageParamType]*types.T{
+	storageParamBool:     types.Bool,
+	storageParamInt:      types.Int,
+	storageParamFloat:    types.Float,
+	storageParamInterval: types.Interval,
+	storageParamString:   types.String,
+}
+
 var storageParamExpectedTypes = map[string]storageParamType{
 	`fillfactor`:                                  storageParamInt,
 	`toast_tuple_target`:                          storageParamUnimplemented,
@@ -112,6 +124,11 @@ var storageParamExpectedTypes = map[string]storageParamType{
 	`log_autovacuum_min_duration`:                 storageParamUnimplemented,
 	`toast.log_autovacuum_min_duration`:           storageParamUnimplemented,
 	`user_catalog_table`:                          storageParamUnimplemented,
+	`ttl_expire_after`:                            storageParamInterval,
+	`ttl_select_batch_size`:                       storageParamInt,
+	`ttl_delete_batch_size`:                       storageParamInt,
+	`ttl_delete_rate_limit`:                       storageParamInt,
+	`ttl_job_cron`:                                storageParamString,
 }
 
 // minimumTypeUsageVersions defines the minimum version needed for a new
@@ -353,6 +370,12 @@ func (n *createTableNode) startExec(params runParams) error {
 		}
 	}
 
+	if desc.RowLevelTTL != nil {
+		if err := params.p.createRowLevelTTLSchedule(params.ctx, &desc); err != nil {
+			return err
+		}
+	}
+
 	// Descriptor written to store here.
 	if err := params.p.createDescriptorWithID(
 		params.ctx, tKey.Key(params.ExecCfg().Codec), id, &desc, params.EvalContext().Settings,
@@ -848,6 +871,13 @@ func ResolveFK(
 		}
 	}
 
+	if d.Deferrability != tree.NotDeferrable && evalCtx.Settings != nil &&
+		!evalCtx.Settings.Version.IsActive(ctx, clusterversion.VersionDeferrableConstraints) {
+		return pgerror.Newf(pgcode.ObjectNotInPrerequisiteState,
+			"deferrable constraints require all nodes to be upgraded to %s",
+			clusterversion.VersionByKey(clusterversion.VersionDeferrableConstraints))
+	}
+
 	ref := sqlbase.ForeignKeyConstraint{
 		OriginTableID:       tbl.ID,
 		OriginColumnIDs:     originColumnIDs,
@@ -858,6 +888,8 @@ func ResolveFK(
 		OnDelete:            sqlbase.ForeignKeyReferenceActionValue[d.Actions.Delete],
 		OnUpdate:            sqlbase.ForeignKeyReferenceActionValue[d.Actions.Update],
 		Match:               sqlbase.CompositeKeyMatchMethodValue[d.Match],
+		Deferrable:          d.Deferrability != tree.NotDeferrable,
+		InitiallyDeferred:   d.Deferrability == tree.DeferrableInitiallyDeferred,
 	}
 
 	if ts == NewTable {
@@ -1248,6 +1280,11 @@ func MakeTableDesc(
 	if err := checkStorageParameters(ctx, semaCtx, n.StorageParams, storageParamExpectedTypes); err != nil {
 		return desc, err
 	}
+	ttl, err := evalRowLevelTTLStorageParams(ctx, semaCtx, evalCtx, n.StorageParams)
+	if err != nil {
+		return desc, err
+	}
+	desc.RowLevelTTL = ttl
 
 	// If all nodes in the cluster know how to handle secondary indexes with column families,
 	// write the new version into new index descriptors.
@@ -1361,6 +1398,16 @@ func MakeTableDesc(
 		}
 	}
 
+	// Tables with a row-level TTL get a hidden column holding the expiration
+	// time of each row.
+	if desc.RowLevelTTL != nil {
+		col, err := makeRowLevelTTLExpirationColumn(ctx, semaCtx, evalCtx, desc.RowLevelTTL)
+		if err != nil {
+			return desc, err
+		}
+		desc.AddColumn(col)
+	}
+
 	// Now that we've constructed our columns, we pop into any of our computed
 	// columns so that we can dequalify any column references.
 	sourceInfo := sqlbase.NewSourceInfoForSingleTable(
@@ -1511,6 +1558,23 @@ func MakeTableDesc(
 				}
 				idx.Partitioning = partitioning
 			}
+			if d.Deferrability != tree.NotDeferrable {
+				if !version.IsActive(clusterversion.VersionDeferrableConstraints) {
+					return desc, pgerror.Newf(pgcode.ObjectNotInPrerequisiteState,
+						"deferrable constraints require all nodes to be upgraded to %s",
+						clusterversion.VersionByKey(clusterversion.VersionDeferrableConstraints))
+				}
+				if d.Predicate != nil {
+					return desc, unimplemented.New("deferrable partial unique",
+						"DEFERRABLE UNIQUE constraints cannot have a WHERE clause")
+				}
+				// The index of a DEFERRABLE UNIQUE constraint uses the encoding of a
+				// non-unique index, so that the constraint can be violated until it
+				// is checked. See deferredConstraints.
+				idx.Unique = false
+				idx.DeferrableUnique = true
+				idx.InitiallyDeferred = d.Deferrability == tree.DeferrableInitiallyDeferred
+			}
 			if d.Predicate != nil {
 				// TODO(mgartner): remove this once partial indexes are fully supported.
 				if !sessionData.PartialIndexes {
@@ -1771,14 +1835,8 @@ func checkStorageParameters(
 		if sp.Value == nil {
 			return errors.Errorf("storage parameter %q requires a value", k)
 		}
-		var expectedType *types.T
-		if validate == storageParamBool {
-			expectedType = types.Bool
-		} else if validate == storageParamInt {
-			expectedType = types.Int
-		} else if validate == storageParamFloat {
-			expectedType = types.Float
-		} else {
+		expectedType, ok := storageParamSQLTypes[validate]
+		if !ok {
 			return unimplemented.NewWithIssuef(43299, "storage parameter %q", k)
 		}
 
@@ -2006,6 +2064,15 @@ func replaceLikeTableOpts(n *tree.CreateTable, params runParams) (tree.TableDefs
 						IndexTableDef: indexDef,
 						PrimaryKey:    isPK,
 					}
+				} else if idx.DeferrableUnique {
+					deferrability := tree.DeferrableInitiallyImmediate
+					if idx.InitiallyDeferred {
+						deferrability = tree.DeferrableInitiallyDeferred
+					}
+					def = &tree.UniqueConstraintTableDef{
+						IndexTableDef: indexDef,
+						Deferrability: deferrability,
+					}
 				}
 				defs = append(defs, def)
 			}
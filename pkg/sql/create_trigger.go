@@ -0,0 +1,237 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/errors"
+)
+
+type createTriggerNode struct {
+	n         *tree.CreateTrigger
+	tableDesc *sqlbase.MutableTableDescriptor
+	fnDesc    *sqlbase.ImmutableFunctionDescriptor
+	// fnName is the fully qualified name of the function.
+	fnName tree.TableName
+}
+
+// CreateTrigger creates a trigger on a table.
+// Privileges: CREATE on table and EXECUTE on function.
+//   notes: postgres requires TRIGGER on the table and EXECUTE on the
+//          function.
+//
+// Triggers are experimental. Unlike in Postgres, the trigger function is a SQL
+// function whose arguments reference the new and old rows explicitly, so a
+// BEFORE trigger can skip a row but cannot modify it, and the function cannot
+// modify data. UPSERT and INSERT ... ON CONFLICT DO UPDATE are rejected on
+// tables with triggers.
+func (p *planner) CreateTrigger(ctx context.Context, n *tree.CreateTrigger) (planNode, error) {
+	if !p.SessionData().TriggersEnabled {
+		return nil, errors.WithTelemetry(
+			pgerror.WithCandidateCode(
+				errors.WithHint(
+					errors.WithDetail(
+						errors.New("triggers are only supported experimentally"),
+						"Trigger functions cannot modify the new row or modify data, their arguments "+
+							"are passed explicitly, and UPSERT and INSERT ... ON CONFLICT DO UPDATE "+
+							"are not supported on tables with triggers.",
+					),
+					"You can enable triggers by running `SET experimental_enable_triggers = 'on'`.",
+				),
+				pgcode.FeatureNotSupported,
+			),
+			"sql.schema.triggers_disabled",
+		)
+	}
+	if !p.ExecCfg().Settings.Version.IsActive(ctx, clusterversion.VersionTriggers) {
+		return nil, pgerror.Newf(pgcode.ObjectNotInPrerequisiteState,
+			"triggers require all nodes to be upgraded to %s",
+			clusterversion.VersionByKey(clusterversion.VersionTriggers))
+	}
+
+	tableDesc, err := p.ResolveMutableTableDescriptor(ctx, &n.Table, true /* required */, tree.ResolveRequireTableDesc)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.CheckPrivilege(ctx, tableDesc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+
+	fnName := n.FuncName
+	fnDesc, err := p.lookupFunction(ctx, &fnName, p.CurrentSearchPath())
+	if err != nil {
+		return nil, err
+	}
+	if fnDesc == nil {
+		return nil, pgerror.Newf(pgcode.UndefinedFunction,
+			"function %s does not exist", tree.ErrString(&n.FuncName))
+	}
+	if err := p.CheckPrivilege(ctx, fnDesc, privilege.EXECUTE); err != nil {
+		return nil, err
+	}
+
+	if n.When != nil {
+		if err := checkTriggerExpr(n, n.When); err != nil {
+			return nil, err
+		}
+	}
+	for _, arg := range n.FuncArgs {
+		if err := checkTriggerExpr(n, arg); err != nil {
+			return nil, err
+		}
+	}
+
+	return &createTriggerNode{n: n, tableDesc: tableDesc, fnDesc: fnDesc, fnName: fnName}, nil
+}
+
+// checkTriggerExpr returns an error if the given expression of a trigger
+// (either the WHEN condition or an argument of the function) contains a
+// subquery, or references the columns of a row that the trigger does not
+// have.
+func checkTriggerExpr(n *tree.CreateTrigger, expr tree.Expr) error {
+	_, err := tree.SimpleVisit(expr, func(e tree.Expr) (recurse bool, newExpr tree.Expr, err error) {
+		switch t := e.(type) {
+		case *tree.Subquery:
+			return false, e, pgerror.New(pgcode.FeatureNotSupported,
+				"cannot use subquery in trigger")
+
+		case *tree.UnresolvedName:
+			if !n.ForEachRow {
+				return false, e, pgerror.New(pgcode.InvalidObjectDefinition,
+					"statement trigger cannot reference column values")
+			}
+			if t.NumParts < 2 {
+				return false, e, nil
+			}
+			switch t.Parts[1] {
+			case "new":
+				if n.Events.Contains(tree.TriggerEventDelete) {
+					return false, e, pgerror.New(pgcode.InvalidObjectDefinition,
+						"DELETE trigger cannot reference NEW values")
+				}
+			case "old":
+				if n.Events.Contains(tree.TriggerEventInsert) {
+					return false, e, pgerror.New(pgcode.InvalidObjectDefinition,
+						"INSERT trigger cannot reference OLD values")
+				}
+			}
+		}
+		return true, e, nil
+	})
+	return err
+}
+
+// ReadingOwnWrites implements the planNodeReadingOwnWrites interface.
+func (n *createTriggerNode) ReadingOwnWrites() {}
+
+func (n *createTriggerNode) startExec(params runParams) error {
+	name := string(n.n.Name)
+	for i := range n.tableDesc.Triggers {
+		if n.tableDesc.Triggers[i].Name == name {
+			return pgerror.Newf(pgcode.DuplicateObject,
+				"trigger %q for relation %q already exists", name, n.tableDesc.Name)
+		}
+	}
+
+	trigger := sqlbase.TableDescriptor_Trigger{
+		Name:         name,
+		Before:       n.n.ActionTime == tree.TriggerBefore,
+		Events:       uint32(n.n.Events),
+		ForEachRow:   n.n.ForEachRow,
+		FunctionID:   n.fnDesc.ID,
+		FunctionName: n.fnName.FQString(),
+	}
+	if n.n.When != nil {
+		trigger.WhenExpr = tree.Serialize(n.n.When)
+	}
+	for _, arg := range n.n.FuncArgs {
+		trigger.Args = append(trigger.Args, tree.Serialize(arg))
+	}
+
+	// Check the WHEN condition and the arguments semantically by running a
+	// query that evaluates them on no rows.
+	if err := validateTrigger(params, n.tableDesc.ID, &trigger); err != nil {
+		return err
+	}
+
+	// Keep the triggers sorted by name, which is the order in which they fire.
+	triggers := append(n.tableDesc.Triggers, trigger)
+	sort.Slice(triggers, func(i, j int) bool { return triggers[i].Name < triggers[j].Name })
+	n.tableDesc.Triggers = triggers
+
+	if err := n.tableDesc.Validate(params.ctx, params.p.txn, params.ExecCfg().Codec); err != nil {
+		return err
+	}
+	return params.p.writeSchemaChange(
+		params.ctx, n.tableDesc, sqlbase.InvalidMutationID, tree.AsStringWithFQNames(n.n, params.Ann()),
+	)
+}
+
+// validateTrigger runs a query that calls the function of the given trigger
+// under its WHEN condition, on no rows. This checks that the condition is
+// boolean, that the arguments match the parameters of the function, and that
+// they only reference existing columns.
+func validateTrigger(
+	params runParams, tableID sqlbase.ID, trigger *sqlbase.TableDescriptor_Trigger,
+) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "SELECT %s(", trigger.FunctionName)
+	for i, arg := range trigger.Args {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(arg)
+	}
+	buf.WriteByte(')')
+	if trigger.ForEachRow {
+		// The NEW row is available to the triggers that don't fire on DELETE,
+		// and the OLD row to the triggers that don't fire on INSERT.
+		events := tree.TriggerEvents(trigger.Events)
+		sep := " FROM "
+		if !events.Contains(tree.TriggerEventDelete) {
+			fmt.Fprintf(&buf, "%s[%d AS new]", sep, tableID)
+			sep = ", "
+		}
+		if !events.Contains(tree.TriggerEventInsert) {
+			fmt.Fprintf(&buf, "%s[%d AS old]", sep, tableID)
+		}
+	}
+	if trigger.WhenExpr != "" {
+		fmt.Fprintf(&buf, " WHERE %s", trigger.WhenExpr)
+	}
+	buf.WriteString(" LIMIT 0")
+
+	_, err := params.ExecCfg().InternalExecutor.ExecEx(
+		params.ctx, "validate-trigger", params.p.txn,
+		sqlbase.InternalExecutorSessionDataOverride{
+			User:       security.RootUser,
+			Database:   params.p.CurrentDatabase(),
+			SearchPath: &params.p.SessionData().SearchPath,
+		},
+		buf.String(),
+	)
+	return err
+}
+
+func (n *createTriggerNode) Next(runParams) (bool, error) { return false, nil }
+func (n *createTriggerNode) Values() tree.Datums          { return tree.Datums{} }
+func (n *createTriggerNode) Close(context.Context)        {}
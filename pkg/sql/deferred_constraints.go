@@ -0,0 +1,452 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/exec"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+// deferredCheckBatchSize is the maximum number of deferred violations that
+// are rechecked by a single query.
+const deferredCheckBatchSize = 1000
+
+// deferrableFKCheck is a foreign key check for a DEFERRABLE constraint. It is
+// the planNode counterpart of exec.DeferrableFKCheck.
+type deferrableFKCheck struct {
+	initiallyDeferred bool
+
+	originTableID     sqlbase.ID
+	referencedTableID sqlbase.ID
+	originColumns     tree.NameList
+	referencedColumns tree.NameList
+
+	// keyCols contains, for each foreign key column, the ordinal of its value in
+	// the rows returned by the check.
+	keyCols []exec.NodeColumnOrdinal
+}
+
+func makeDeferrableFKCheck(c *exec.DeferrableFKCheck) *deferrableFKCheck {
+	res := &deferrableFKCheck{
+		initiallyDeferred: c.InitiallyDeferred,
+		originTableID:     sqlbase.ID(c.OriginTable.ID()),
+		referencedTableID: sqlbase.ID(c.ReferencedTable.ID()),
+		originColumns:     make(tree.NameList, len(c.OriginColumns)),
+		referencedColumns: make(tree.NameList, len(c.ReferencedColumns)),
+		keyCols:           c.KeyCols,
+	}
+	for i, ord := range c.OriginColumns {
+		res.originColumns[i] = c.OriginTable.Column(ord).ColName()
+	}
+	for i, ord := range c.ReferencedColumns {
+		res.referencedColumns[i] = c.ReferencedTable.Column(ord).ColName()
+	}
+	return res
+}
+
+// deferrableUniqueCheck is the check of a DEFERRABLE UNIQUE constraint. The
+// index of such a constraint uses the encoding of a non-unique index, so the
+// keys written to it by a statement have to be checked for duplicates either
+// when the statement completes or, if the constraint is deferred, when the
+// transaction commits.
+type deferrableUniqueCheck struct {
+	initiallyDeferred bool
+
+	tableID   sqlbase.ID
+	indexID   sqlbase.IndexID
+	indexName string
+	columnIDs []sqlbase.ColumnID
+	columns   tree.NameList
+}
+
+// makeDeferrableUniqueChecks returns the checks of the DEFERRABLE UNIQUE
+// constraints of the given table.
+func makeDeferrableUniqueChecks(desc *sqlbase.ImmutableTableDescriptor) []*deferrableUniqueCheck {
+	var res []*deferrableUniqueCheck
+	for i := range desc.Indexes {
+		idx := &desc.Indexes[i]
+		if !idx.DeferrableUnique {
+			continue
+		}
+		c := &deferrableUniqueCheck{
+			initiallyDeferred: idx.InitiallyDeferred,
+			tableID:           desc.ID,
+			indexID:           idx.ID,
+			indexName:         idx.Name,
+			columnIDs:         idx.ColumnIDs,
+			columns:           make(tree.NameList, len(idx.ColumnNames)),
+		}
+		for j := range idx.ColumnNames {
+			c.columns[j] = tree.Name(idx.ColumnNames[j])
+		}
+		res = append(res, c)
+	}
+	return res
+}
+
+// violationError returns the error reported when the given key is duplicated.
+// It is the same as the error reported for the violation of a UNIQUE
+// constraint that is not DEFERRABLE.
+func (c *deferrableUniqueCheck) violationError(keyVals tree.Datums) error {
+	valStrs := make([]string, len(keyVals))
+	for i := range keyVals {
+		valStrs[i] = keyVals[i].String()
+	}
+	return pgerror.Newf(pgcode.UniqueViolation,
+		"duplicate key value (%s)=(%s) violates unique constraint %q",
+		strings.Join(c.columns.ToStrings(), ","),
+		strings.Join(valStrs, ","),
+		c.indexName)
+}
+
+// constraintsMode is the mode set by SET CONSTRAINTS ALL in a transaction.
+type constraintsMode int
+
+const (
+	// constraintsDefault defers the constraints declared INITIALLY DEFERRED.
+	constraintsDefault constraintsMode = iota
+	// constraintsAllDeferred defers all the DEFERRABLE constraints.
+	constraintsAllDeferred
+	// constraintsAllImmediate does not defer any constraint.
+	constraintsAllImmediate
+)
+
+// deferredViolation is a potential violation of a foreign key constraint
+// found by a deferred check.
+type deferredViolation struct {
+	check   *deferrableFKCheck
+	keyVals tree.Datums
+	// err is the error returned if the violation still exists when the
+	// constraint is checked.
+	err error
+}
+
+// deferredUniqueKey is a key written to the index of a DEFERRABLE UNIQUE
+// constraint.
+type deferredUniqueKey struct {
+	check   *deferrableUniqueCheck
+	keyVals tree.Datums
+}
+
+// deferredConstraints contains the checks deferred by a transaction.
+//
+// The rows returned by a deferred foreign key check are not reported as
+// violations right away. Instead, the foreign key values they contain are
+// rechecked when the transaction commits: a value is still a violation if the
+// origin table still contains a row with the value, and the referenced table
+// still does not. No violation can appear meanwhile without being found by the
+// check of the statement that introduced it, so this covers all the violations
+// of the deferred constraints.
+//
+// Similarly, the keys written to the index of a deferred UNIQUE constraint are
+// checked for duplicates when the transaction commits.
+type deferredConstraints struct {
+	mode       constraintsMode
+	violations []deferredViolation
+	// uniqueKeys contains the keys written to the indexes of the deferred
+	// UNIQUE constraints.
+	uniqueKeys []deferredUniqueKey
+	// stmtUniqueKeys contains the keys written by the current statement to the
+	// indexes of the DEFERRABLE UNIQUE constraints that are not deferred. They
+	// are checked when the statement completes.
+	stmtUniqueKeys []deferredUniqueKey
+	// immediateOnly is set for executors that run statements in a transaction
+	// committed by another executor, which cannot defer any constraint.
+	immediateOnly bool
+}
+
+// isDeferred returns whether the given check must be deferred.
+func (d *deferredConstraints) isDeferred(c *deferrableFKCheck) bool {
+	if c == nil {
+		return false
+	}
+	return d.deferred(c.initiallyDeferred)
+}
+
+// deferred returns whether a DEFERRABLE constraint, declared INITIALLY
+// DEFERRED or not, must be deferred.
+func (d *deferredConstraints) deferred(initiallyDeferred bool) bool {
+	if d == nil || d.immediateOnly {
+		return false
+	}
+	switch d.mode {
+	case constraintsAllDeferred:
+		return true
+	case constraintsAllImmediate:
+		return false
+	default:
+		return initiallyDeferred
+	}
+}
+
+// add records a row returned by a deferred check. It returns the violation
+// error right away if the row contains a NULL, which only happens for
+// violations of MATCH FULL that cannot be resolved by later statements.
+func (d *deferredConstraints) add(
+	c *deferrableFKCheck, row tree.Datums, mkErr func(tree.Datums) error,
+) error {
+	keyVals := make(tree.Datums, len(c.keyCols))
+	for i, ord := range c.keyCols {
+		if row[ord] == tree.DNull {
+			return mkErr(row)
+		}
+		keyVals[i] = row[ord]
+	}
+	d.violations = append(d.violations, deferredViolation{
+		check:   c,
+		keyVals: keyVals,
+		err:     mkErr(row),
+	})
+	return nil
+}
+
+// addUniqueKey records a key written to the index of a DEFERRABLE UNIQUE
+// constraint.
+func (d *deferredConstraints) addUniqueKey(c *deferrableUniqueCheck, keyVals tree.Datums) {
+	k := deferredUniqueKey{check: c, keyVals: keyVals}
+	if d.deferred(c.initiallyDeferred) {
+		d.uniqueKeys = append(d.uniqueKeys, k)
+	} else {
+		d.stmtUniqueKeys = append(d.stmtUniqueKeys, k)
+	}
+}
+
+// checkStatement checks the keys written by the current statement to the
+// indexes of the DEFERRABLE UNIQUE constraints that are not deferred.
+func (d *deferredConstraints) checkStatement(
+	ctx context.Context, ie *InternalExecutor, txn *kv.Txn,
+) error {
+	keys := d.stmtUniqueKeys
+	d.stmtUniqueKeys = nil
+	return checkUniqueKeys(ctx, ie, txn, keys)
+}
+
+// checkAll rechecks the violations found by the deferred checks, and the keys
+// written to the indexes of the deferred UNIQUE constraints. It returns the
+// error of a violation that still exists, if any.
+func (d *deferredConstraints) checkAll(
+	ctx context.Context, ie *InternalExecutor, txn *kv.Txn,
+) error {
+	if err := checkUniqueKeys(ctx, ie, txn, d.uniqueKeys); err != nil {
+		return err
+	}
+	d.uniqueKeys = nil
+	if len(d.violations) == 0 {
+		return nil
+	}
+	log.VEventf(ctx, 2, "checking %d deferred foreign key violations", len(d.violations))
+	// Group the violations by constraint, so that each query checks a single
+	// constraint.
+	groups := make(map[string][]deferredViolation)
+	var order []string
+	for _, v := range d.violations {
+		key := fmt.Sprintf("%d%s/%d%s",
+			v.check.originTableID, v.check.originColumns, v.check.referencedTableID, v.check.referencedColumns)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], v)
+	}
+	for _, key := range order {
+		violations := groups[key]
+		for len(violations) > 0 {
+			batch := violations
+			if len(batch) > deferredCheckBatchSize {
+				batch = batch[:deferredCheckBatchSize]
+			}
+			violations = violations[len(batch):]
+			if err := checkDeferredViolations(ctx, ie, txn, batch); err != nil {
+				return err
+			}
+		}
+	}
+	d.violations = nil
+	return nil
+}
+
+// checkDeferredViolations checks whether any of the given violations of a
+// constraint still exists, and returns its error if so.
+func checkDeferredViolations(
+	ctx context.Context, ie *InternalExecutor, txn *kv.Txn, violations []deferredViolation,
+) error {
+	c := violations[0].check
+	var buf bytes.Buffer
+	writeKeyValues(&buf, len(violations), func(i int) tree.Datums { return violations[i].keyVals })
+	fmt.Fprintf(&buf, " WHERE EXISTS (SELECT 1 FROM [%d AS o] WHERE ", c.originTableID)
+	writeKeyFilter(&buf, "o", c.originColumns)
+	fmt.Fprintf(&buf, ") AND NOT EXISTS (SELECT 1 FROM [%d AS r] WHERE ", c.referencedTableID)
+	writeKeyFilter(&buf, "r", c.referencedColumns)
+	buf.WriteString(") LIMIT 1")
+
+	row, err := ie.QueryRowEx(
+		ctx, "deferred-fk-check", txn,
+		sqlbase.InternalExecutorSessionDataOverride{User: security.RootUser},
+		buf.String(),
+	)
+	if err != nil || row == nil {
+		return err
+	}
+	i := int(tree.MustBeDInt(row[0]))
+	if i < 0 || i >= len(violations) {
+		return errors.AssertionFailedf("invalid deferred violation %d", i)
+	}
+	return violations[i].err
+}
+
+// checkUniqueKeys checks whether any of the given keys written to the indexes
+// of DEFERRABLE UNIQUE constraints is duplicated, and returns its error if so.
+func checkUniqueKeys(
+	ctx context.Context, ie *InternalExecutor, txn *kv.Txn, keys []deferredUniqueKey,
+) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	log.VEventf(ctx, 2, "checking %d keys of deferrable unique constraints", len(keys))
+	// Group the keys by constraint, so that each query checks a single
+	// constraint.
+	groups := make(map[[2]int64][]deferredUniqueKey)
+	var order [][2]int64
+	for _, k := range keys {
+		key := [2]int64{int64(k.check.tableID), int64(k.check.indexID)}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], k)
+	}
+	for _, key := range order {
+		group := groups[key]
+		for len(group) > 0 {
+			batch := group
+			if len(batch) > deferredCheckBatchSize {
+				batch = batch[:deferredCheckBatchSize]
+			}
+			group = group[len(batch):]
+			if err := checkDuplicateUniqueKeys(ctx, ie, txn, batch); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkDuplicateUniqueKeys checks whether any of the given keys written to
+// the index of a DEFERRABLE UNIQUE constraint is duplicated, and returns its
+// error if so.
+func checkDuplicateUniqueKeys(
+	ctx context.Context, ie *InternalExecutor, txn *kv.Txn, keys []deferredUniqueKey,
+) error {
+	c := keys[0].check
+	var buf bytes.Buffer
+	writeKeyValues(&buf, len(keys), func(i int) tree.Datums { return keys[i].keyVals })
+	fmt.Fprintf(&buf, " WHERE (SELECT count(*) FROM [%d AS t] WHERE ", c.tableID)
+	writeKeyFilter(&buf, "t", c.columns)
+	buf.WriteString(") > 1 LIMIT 1")
+
+	row, err := ie.QueryRowEx(
+		ctx, "deferred-unique-check", txn,
+		sqlbase.InternalExecutorSessionDataOverride{User: security.RootUser},
+		buf.String(),
+	)
+	if err != nil || row == nil {
+		return err
+	}
+	i := int(tree.MustBeDInt(row[0]))
+	if i < 0 || i >= len(keys) {
+		return errors.AssertionFailedf("invalid deferred unique key %d", i)
+	}
+	return c.violationError(keys[i].keyVals)
+}
+
+// writeKeyValues writes the beginning of a query which returns the index i of
+// the keys matched by its filter, with the values of the keys available as
+// v.k0, v.k1, etc.
+func writeKeyValues(buf *bytes.Buffer, n int, keyVals func(i int) tree.Datums) {
+	buf.WriteString("SELECT v.i FROM (VALUES ")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(buf, "(%d", i)
+		for _, d := range keyVals(i) {
+			buf.WriteString(", ")
+			buf.WriteString(tree.AsStringWithFlags(d, tree.FmtParsable))
+		}
+		buf.WriteByte(')')
+	}
+	buf.WriteString(") AS v (i")
+	for i := range keyVals(0) {
+		fmt.Fprintf(buf, ", k%d", i)
+	}
+	buf.WriteByte(')')
+}
+
+// writeKeyFilter writes a filter matching the values of the given columns of
+// the table with the given alias to the key values of the rows of v.
+func writeKeyFilter(buf *bytes.Buffer, alias string, cols tree.NameList) {
+	for i := range cols {
+		if i > 0 {
+			buf.WriteString(" AND ")
+		}
+		fmt.Fprintf(buf, "%s.%s = v.k%d", alias, cols[i].String(), i)
+	}
+}
+
+// reset forgets the deferred violations and keys, and the mode set by SET
+// CONSTRAINTS.
+func (d *deferredConstraints) reset() {
+	d.mode = constraintsDefault
+	d.violations = nil
+	d.uniqueKeys = nil
+	d.stmtUniqueKeys = nil
+}
+
+// SetConstraints implements the SET CONSTRAINTS statement.
+// See https://www.postgresql.org/docs/current/sql-set-constraints.html for
+// details.
+func (p *planner) SetConstraints(ctx context.Context, n *tree.SetConstraints) (planNode, error) {
+	return &setConstraintsNode{n: n}, nil
+}
+
+// setConstraintsNode runs a SET CONSTRAINTS statement.
+type setConstraintsNode struct {
+	n *tree.SetConstraints
+}
+
+func (n *setConstraintsNode) startExec(params runParams) error {
+	d := params.p.deferredConstraints
+	if d == nil {
+		return nil
+	}
+	if n.n.Deferred {
+		d.mode = constraintsAllDeferred
+		return nil
+	}
+	// Like in Postgres, the violations found while the constraints were
+	// deferred are checked right away.
+	d.mode = constraintsAllImmediate
+	return d.checkAll(params.ctx, params.ExecCfg().InternalExecutor, params.p.txn)
+}
+
+func (n *setConstraintsNode) Next(runParams) (bool, error) { return false, nil }
+func (n *setConstraintsNode) Values() tree.Datums          { return nil }
+func (n *setConstraintsNode) Close(context.Context)        {}
@@ -0,0 +1,43 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package delegate
+
+import "github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+
+// delegateCopyTo implements COPY TO STDOUT. The rows to copy are produced by
+// an equivalent query; pgwire takes care of sending them in the requested
+// format.
+func (d *delegator) delegateCopyTo(n *tree.CopyTo) (tree.Statement, error) {
+	// The options are only used by pgwire, but are validated here so that
+	// invalid options are reported before any row is sent.
+	if _, err := tree.ParseCopyOptions(n.Options); err != nil {
+		return nil, err
+	}
+	if n.Query != nil {
+		return n.Query, nil
+	}
+
+	// COPY t (a, b) TO STDOUT is equivalent to SELECT a, b FROM t.
+	exprs := tree.SelectExprs{tree.StarSelectExpr()}
+	if len(n.Columns) > 0 {
+		exprs = make(tree.SelectExprs, len(n.Columns))
+		for i := range n.Columns {
+			exprs[i] = tree.SelectExpr{Expr: tree.NewUnresolvedName(string(n.Columns[i]))}
+		}
+	}
+	table := n.Table
+	return &tree.Select{
+		Select: &tree.SelectClause{
+			Exprs: exprs,
+			From:  tree.From{Tables: tree.TableExprs{&table}},
+		},
+	}, nil
+}
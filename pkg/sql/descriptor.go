@@ -150,6 +150,15 @@ func (p *planner) createDescriptorWithID(
 		}
 	}
 
+	if mutFn, ok := descriptor.(*sqlbase.MutableFunctionDescriptor); ok {
+		if err := mutFn.Validate(); err != nil {
+			return err
+		}
+		if err := p.Descriptors().AddUncommittedDescriptor(mutFn); err != nil {
+			return err
+		}
+	}
+
 	mutDesc, isTable := descriptor.(*sqlbase.MutableTableDescriptor)
 	if isTable {
 		if err := mutDesc.ValidateTable(); err != nil {
@@ -36,6 +36,19 @@ func (p *planner) Discard(ctx context.Context, s *tree.Discard) (planNode, error
 
 		// DEALLOCATE ALL
 		p.preparedStatements.DeleteAll(ctx)
+
+		// CLOSE ALL
+		p.sqlCursors.closeAll(ctx)
+
+		// UNLISTEN *
+		if p.sessionListener != nil {
+			p.sessionListener.listen(listenOp{unlisten: true, all: true})
+		}
+
+		// SELECT pg_advisory_unlock_all()
+		if p.advisoryLocks != nil {
+			p.advisoryLocks.releaseAll(ctx)
+		}
 	default:
 		return nil, errors.AssertionFailedf("unknown mode for DISCARD: %d", s.Mode)
 	}
@@ -3404,7 +3404,8 @@ func (dsp *DistSQLPlanner) createPlanForWindow(
 }
 
 // createPlanForExport creates a physical plan for EXPORT.
-// We add a new stage of CSVWriter processors to the input plan.
+// We add a new stage of CSVWriter processors to the input plan, which write
+// either CSV or Parquet files.
 func (dsp *DistSQLPlanner) createPlanForExport(
 	planCtx *PlanningCtx, n *exportNode,
 ) (*PhysicalPlan, error) {
@@ -3413,13 +3414,22 @@ func (dsp *DistSQLPlanner) createPlanForExport(
 		return nil, err
 	}
 
-	core := execinfrapb.ProcessorCoreUnion{CSVWriter: &execinfrapb.CSVWriterSpec{
+	spec := &execinfrapb.CSVWriterSpec{
 		Destination:      n.fileName,
 		NamePattern:      exportFilePatternDefault,
 		Options:          n.csvOpts,
 		ChunkRows:        int64(n.chunkSize),
 		CompressionCodec: n.fileCompression,
-	}}
+		Format:           n.fileFormat,
+	}
+	if n.fileFormat == execinfrapb.ExportFormat_Parquet {
+		spec.NamePattern = exportFilePatternParquet
+		// The column names are part of the schema of Parquet files.
+		for _, col := range planColumns(n.source) {
+			spec.ColumnNames = append(spec.ColumnNames, col.Name)
+		}
+	}
+	core := execinfrapb.ProcessorCoreUnion{CSVWriter: spec}
 
 	resTypes := make([]*types.T, len(sqlbase.ExportColumns))
 	for i := range sqlbase.ExportColumns {
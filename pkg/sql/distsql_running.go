@@ -1047,8 +1047,13 @@ func (dsp *DistSQLPlanner) PlanAndRunCascadesAndChecks(
 		}
 		cp := cascadePlan.(*planTop)
 		plan.cascades[i].plan = cp.main
-		if len(cp.subqueryPlans) > 0 {
-			recv.SetError(errors.AssertionFailedf("cascades should not have subqueries"))
+		plan.cascades[i].subqueryPlans = cp.subqueryPlans
+
+		// The cascading query can have subqueries (for example, for the BEFORE
+		// statement-level triggers of the child table); they run before it.
+		if len(cp.subqueryPlans) > 0 && !dsp.PlanAndRunSubqueries(
+			ctx, planner, evalCtxFactory, cp.subqueryPlans, recv, maybeDistribute,
+		) {
 			return false
 		}
 
@@ -250,7 +250,7 @@ func (e *distSQLSpecExecFactory) ConstructScan(
 		return nil, err
 	}
 	if params.Locking != nil {
-		trSpec.LockingStrength = sqlbase.ToScanLockingStrength(params.Locking.Strength)
+		trSpec.LockingStrength = e.planner.scanLockingStrength(params.Locking.Strength)
 		trSpec.LockingWaitPolicy = sqlbase.ToScanLockingWaitPolicy(params.Locking.WaitPolicy)
 		if trSpec.LockingStrength != sqlbase.ScanLockingStrength_FOR_NONE {
 			// Scans that are performing row-level locking cannot currently be
@@ -845,6 +845,12 @@ func (e *distSQLSpecExecFactory) ConstructCreateView(
 	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: create view")
 }
 
+func (e *distSQLSpecExecFactory) ConstructCreateFunction(
+	schema cat.Schema, cf *tree.CreateFunction, body string, deps opt.ViewDeps,
+) (exec.Node, error) {
+	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: create function")
+}
+
 func (e *distSQLSpecExecFactory) ConstructSequenceSelect(sequence cat.Sequence) (exec.Node, error) {
 	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: sequence select")
 }
@@ -856,7 +862,7 @@ func (e *distSQLSpecExecFactory) ConstructSaveTable(
 }
 
 func (e *distSQLSpecExecFactory) ConstructErrorIfRows(
-	input exec.Node, mkErr func(tree.Datums) error,
+	input exec.Node, mkErr func(tree.Datums) error, deferrable *exec.DeferrableFKCheck,
 ) (exec.Node, error) {
 	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: error if rows")
 }
@@ -0,0 +1,137 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catalogkv"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/errors"
+)
+
+type dropFunctionNode struct {
+	n  *tree.DropFunction
+	fd []*sqlbase.MutableFunctionDescriptor
+}
+
+// Use to satisfy the linter.
+var _ planNode = &dropFunctionNode{n: nil}
+
+// DropFunction drops user-defined functions.
+// Privileges: DROP on function.
+//   Notes: postgres allows only the function owner to DROP a function.
+func (p *planner) DropFunction(ctx context.Context, n *tree.DropFunction) (planNode, error) {
+	node := &dropFunctionNode{n: n}
+	seen := make(map[sqlbase.ID]struct{})
+	for i := range n.Names {
+		tn := &n.Names[i]
+		fnDesc, err := p.lookupMutableFunction(ctx, tn, p.CurrentSearchPath())
+		if err != nil {
+			return nil, err
+		}
+		if fnDesc == nil {
+			if n.IfExists {
+				continue
+			}
+			return nil, pgerror.Newf(pgcode.UndefinedFunction,
+				"function %s does not exist", tree.ErrString(tn))
+		}
+		if _, ok := seen[fnDesc.ID]; ok {
+			continue
+		}
+		seen[fnDesc.ID] = struct{}{}
+		if err := p.CheckPrivilege(ctx, fnDesc, privilege.DROP); err != nil {
+			return nil, err
+		}
+		if err := p.checkNoTriggerDependents(ctx, &fnDesc.ImmutableFunctionDescriptor); err != nil {
+			return nil, err
+		}
+		node.fd = append(node.fd, fnDesc)
+	}
+	return node, nil
+}
+
+func (n *dropFunctionNode) startExec(params runParams) error {
+	codec := params.ExecCfg().Codec
+	kvTrace := params.p.ExtendedEvalContext().Tracing.KVTracingEnabled()
+	for _, fnDesc := range n.fd {
+		// The only objects that can depend on a function are triggers, which
+		// were checked above, so its name can be removed right away. The
+		// descriptor is marked as dropped, and removed by the job once no
+		// statement holds a lease on it anymore.
+		if err := sqlbase.RemoveObjectNamespaceEntry(
+			params.ctx, params.p.txn, codec, fnDesc.ParentID, fnDesc.ParentSchemaID, fnDesc.Name, kvTrace,
+		); err != nil {
+			return err
+		}
+		fnDesc.State = sqlbase.FunctionDescriptor_DROP
+		if err := params.p.writeFunctionChange(
+			params.ctx, fnDesc, tree.AsStringWithFQNames(n.n, params.Ann()),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *dropFunctionNode) Next(params runParams) (bool, error) { return false, nil }
+func (n *dropFunctionNode) Values() tree.Datums                 { return tree.Datums{} }
+func (n *dropFunctionNode) Close(ctx context.Context)           {}
+func (n *dropFunctionNode) ReadingOwnWrites()                   {}
+
+// checkNoFunctionDependents returns an error if a user-defined function
+// depends on the given table or view. Functions are never dropped implicitly,
+// so the error is returned even if CASCADE was specified.
+func (p *planner) checkNoFunctionDependents(
+	ctx context.Context, desc *sqlbase.MutableTableDescriptor,
+) error {
+	names, err := functionDependents(ctx, p.txn, p.ExecCfg().Codec, desc.ID)
+	if err != nil || len(names) == 0 {
+		return err
+	}
+	return errors.WithHint(
+		pgerror.Newf(pgcode.DependentObjectsStillExist,
+			"cannot drop %s %q because function %q depends on it", desc.TypeName(), desc.Name, names[0]),
+		"you can drop the function first.",
+	)
+}
+
+// functionDependents returns the names of the user-defined functions whose
+// body depends on the table with the given ID.
+func functionDependents(
+	ctx context.Context, txn *kv.Txn, codec keys.SQLCodec, id sqlbase.ID,
+) ([]string, error) {
+	descs, err := catalogkv.GetAllDescriptors(ctx, txn, codec)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, desc := range descs {
+		fnDesc, ok := desc.(*sqlbase.ImmutableFunctionDescriptor)
+		if !ok || fnDesc.Dropped() {
+			continue
+		}
+		for _, dep := range fnDesc.DependsOn {
+			if dep == id {
+				names = append(names, fnDesc.Name)
+				break
+			}
+		}
+	}
+	return names, nil
+}
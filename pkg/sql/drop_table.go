@@ -88,6 +88,9 @@ func (p *planner) DropTable(ctx context.Context, n *tree.DropTable) (planNode, e
 		if err := p.canRemoveAllTableOwnedSequences(ctx, droppedDesc, n.DropBehavior); err != nil {
 			return nil, err
 		}
+		if err := p.checkNoFunctionDependents(ctx, droppedDesc); err != nil {
+			return nil, err
+		}
 
 	}
 
@@ -285,6 +288,11 @@ func (p *planner) dropTableImpl(
 		}
 	}
 
+	// Remove the schedule of the row-level TTL job.
+	if err := p.dropRowLevelTTLSchedule(ctx, tableDesc); err != nil {
+		return droppedViews, err
+	}
+
 	// Drop sequences that the columns of the table own
 	for _, col := range tableDesc.Columns {
 		if err := p.dropSequencesOwnedByCol(ctx, &col, queueJob); err != nil {
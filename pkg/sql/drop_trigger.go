@@ -0,0 +1,118 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catalogkv"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/errors"
+)
+
+type dropTriggerNode struct {
+	n         *tree.DropTrigger
+	tableDesc *sqlbase.MutableTableDescriptor
+	// idx is the index of the trigger in tableDesc.Triggers.
+	idx int
+}
+
+// DropTrigger drops a trigger of a table.
+// Privileges: CREATE on table.
+//   notes: postgres allows only the table owner to DROP a trigger.
+func (p *planner) DropTrigger(ctx context.Context, n *tree.DropTrigger) (planNode, error) {
+	tableDesc, err := p.ResolveMutableTableDescriptor(ctx, &n.Table, !n.IfExists, tree.ResolveRequireTableDesc)
+	if err != nil {
+		return nil, err
+	}
+	if tableDesc == nil {
+		// IfExists specified and table did not exist -- noop.
+		return newZeroNode(nil /* columns */), nil
+	}
+	if err := p.CheckPrivilege(ctx, tableDesc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+	for i := range tableDesc.Triggers {
+		if tableDesc.Triggers[i].Name == string(n.Name) {
+			return &dropTriggerNode{n: n, tableDesc: tableDesc, idx: i}, nil
+		}
+	}
+	if n.IfExists {
+		return newZeroNode(nil /* columns */), nil
+	}
+	return nil, pgerror.Newf(pgcode.UndefinedObject,
+		"trigger %q for table %q does not exist", string(n.Name), tableDesc.Name)
+}
+
+// ReadingOwnWrites implements the planNodeReadingOwnWrites interface.
+func (n *dropTriggerNode) ReadingOwnWrites() {}
+
+func (n *dropTriggerNode) startExec(params runParams) error {
+	// No object can depend on a trigger, so RESTRICT and CASCADE behave the
+	// same.
+	triggers := n.tableDesc.Triggers
+	n.tableDesc.Triggers = append(triggers[:n.idx:n.idx], triggers[n.idx+1:]...)
+	return params.p.writeSchemaChange(
+		params.ctx, n.tableDesc, sqlbase.InvalidMutationID, tree.AsStringWithFQNames(n.n, params.Ann()),
+	)
+}
+
+func (n *dropTriggerNode) Next(runParams) (bool, error) { return false, nil }
+func (n *dropTriggerNode) Values() tree.Datums          { return tree.Datums{} }
+func (n *dropTriggerNode) Close(context.Context)        {}
+
+// checkNoTriggerDependents returns an error if a trigger invokes the given
+// function. Triggers are never dropped implicitly, so the error is returned
+// even if CASCADE was specified.
+func (p *planner) checkNoTriggerDependents(
+	ctx context.Context, fnDesc *sqlbase.ImmutableFunctionDescriptor,
+) error {
+	tableName, triggerName, err := triggerDependent(ctx, p.txn, p.ExecCfg().Codec, fnDesc.ID)
+	if err != nil || triggerName == "" {
+		return err
+	}
+	return errors.WithHint(
+		pgerror.Newf(pgcode.DependentObjectsStillExist,
+			"cannot drop function %q because trigger %q on table %q depends on it",
+			fnDesc.Name, triggerName, tableName),
+		"you can drop the trigger first.",
+	)
+}
+
+// triggerDependent returns the name of a trigger that invokes the function
+// with the given ID and the name of its table, or empty strings if there is
+// none.
+func triggerDependent(
+	ctx context.Context, txn *kv.Txn, codec keys.SQLCodec, id sqlbase.ID,
+) (tableName, triggerName string, _ error) {
+	descs, err := catalogkv.GetAllDescriptors(ctx, txn, codec)
+	if err != nil {
+		return "", "", err
+	}
+	for _, desc := range descs {
+		tableDesc, ok := desc.(*sqlbase.ImmutableTableDescriptor)
+		if !ok || tableDesc.Dropped() {
+			continue
+		}
+		for i := range tableDesc.Triggers {
+			if tableDesc.Triggers[i].FunctionID == id {
+				return tableDesc.Name, tableDesc.Triggers[i].Name, nil
+			}
+		}
+	}
+	return "", "", nil
+}
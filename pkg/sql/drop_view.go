@@ -63,6 +63,9 @@ func (p *planner) DropView(ctx context.Context, n *tree.DropView) (planNode, err
 				return nil, err
 			}
 		}
+		if err := p.checkNoFunctionDependents(ctx, droppedDesc); err != nil {
+			return nil, err
+		}
 	}
 
 	if len(td) == 0 {
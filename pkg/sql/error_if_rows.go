@@ -22,9 +22,14 @@ type errorIfRowsNode struct {
 	plan planNode
 
 	// mkErr creates the error message, given the values of the first row
-	// produced.
+	// produced. If nil, the rows produced are discarded without error.
 	mkErr func(values tree.Datums) error
 
+	// deferrable is set if the wrapped node is a foreign key check for a
+	// DEFERRABLE constraint. When the constraint is deferred, all the rows
+	// produced are recorded to be rechecked when the transaction commits.
+	deferrable *deferrableFKCheck
+
 	nexted bool
 }
 
@@ -38,14 +43,22 @@ func (n *errorIfRowsNode) Next(params runParams) (bool, error) {
 	}
 	n.nexted = true
 
-	ok, err := n.plan.Next(params)
-	if err != nil {
-		return false, err
-	}
-	if ok {
-		return false, n.mkErr(n.plan.Values())
+	deferred := params.p.deferredConstraints.isDeferred(n.deferrable)
+	for {
+		ok, err := n.plan.Next(params)
+		if err != nil || !ok {
+			return false, err
+		}
+		if n.mkErr == nil {
+			continue
+		}
+		if !deferred {
+			return false, n.mkErr(n.plan.Values())
+		}
+		if err := params.p.deferredConstraints.add(n.deferrable, n.plan.Values(), n.mkErr); err != nil {
+			return false, err
+		}
 	}
-	return false, nil
 }
 
 func (n *errorIfRowsNode) Values() tree.Datums {
@@ -50,11 +50,13 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/distsql"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/lex"
+	"github.com/cockroachdb/cockroach/pkg/sql/notify"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgnotice"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirecancel"
 	"github.com/cockroachdb/cockroach/pkg/sql/physicalplan"
 	"github.com/cockroachdb/cockroach/pkg/sql/querycache"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -182,6 +184,12 @@ var hashShardedIndexesEnabledClusterMode = settings.RegisterBoolSetting(
 	false,
 )
 
+var triggersEnabledClusterMode = settings.RegisterBoolSetting(
+	"sql.defaults.experimental_triggers.enabled",
+	"default value for experimental_enable_triggers; allows for creation of triggers by default",
+	false,
+)
+
 var enumsEnabledClusterMode = settings.RegisterBoolSetting(
 	"sql.defaults.experimental_enums.enabled",
 	"default value for experimental_enable_enums; allows for creation and use of ENUM types",
@@ -697,6 +705,10 @@ type ExecutorConfig struct {
 
 	// StmtDiagnosticsRecorder deals with recording statement diagnostics.
 	StmtDiagnosticsRecorder *stmtdiagnostics.Registry
+
+	// NotificationRegistry delivers the notifications sent with NOTIFY to the
+	// sessions listening on their channel.
+	NotificationRegistry *notify.Registry
 }
 
 // Organization returns the value of cluster.organization.
@@ -1260,29 +1272,42 @@ type SessionArgs struct {
 type SessionRegistry struct {
 	syncutil.Mutex
 	sessions map[ClusterWideID]registrySession
+	// sessionsByCancelKey indexes the same sessions by the key that was handed
+	// out to their clients for use in pgwire CancelRequest messages.
+	sessionsByCancelKey map[pgwirecancel.BackendKeyData]registrySession
 }
 
 // NewSessionRegistry creates a new SessionRegistry with an empty set
 // of sessions.
 func NewSessionRegistry() *SessionRegistry {
-	return &SessionRegistry{sessions: make(map[ClusterWideID]registrySession)}
+	return &SessionRegistry{
+		sessions:            make(map[ClusterWideID]registrySession),
+		sessionsByCancelKey: make(map[pgwirecancel.BackendKeyData]registrySession),
+	}
 }
 
-func (r *SessionRegistry) register(id ClusterWideID, s registrySession) {
+func (r *SessionRegistry) register(
+	id ClusterWideID, queryCancelKey pgwirecancel.BackendKeyData, s registrySession,
+) {
 	r.Lock()
 	r.sessions[id] = s
+	r.sessionsByCancelKey[queryCancelKey] = s
 	r.Unlock()
 }
 
-func (r *SessionRegistry) deregister(id ClusterWideID) {
+func (r *SessionRegistry) deregister(id ClusterWideID, queryCancelKey pgwirecancel.BackendKeyData) {
 	r.Lock()
 	delete(r.sessions, id)
+	delete(r.sessionsByCancelKey, queryCancelKey)
 	r.Unlock()
 }
 
 type registrySession interface {
 	user() string
 	cancelQuery(queryID ClusterWideID) bool
+	// cancelCurrentQueries cancels all the queries running on the session and
+	// returns whether there were any.
+	cancelCurrentQueries() bool
 	cancelSession()
 	// serialize serializes a Session into a serverpb.Session
 	// that can be served over RPC.
@@ -1313,6 +1338,21 @@ func (r *SessionRegistry) CancelQuery(queryIDStr string, username string) (bool,
 	return false, fmt.Errorf("query ID %s not found", queryID)
 }
 
+// CancelQueryByKey looks up the session associated with the given pgwire
+// cancel key and cancels the queries currently running on it. No user check is
+// performed: knowing the key is what authorizes the cancellation, just like in
+// Postgres.
+func (r *SessionRegistry) CancelQueryByKey(
+	queryCancelKey pgwirecancel.BackendKeyData,
+) (canceled bool, err error) {
+	r.Lock()
+	defer r.Unlock()
+	if session, ok := r.sessionsByCancelKey[queryCancelKey]; ok {
+		return session.cancelCurrentQueries(), nil
+	}
+	return false, fmt.Errorf("session for %s not found", queryCancelKey)
+}
+
 // CancelSession looks up the specified session in the session registry and cancels it.
 func (r *SessionRegistry) CancelSession(sessionIDBytes []byte, username string) (bool, error) {
 	sessionID := BytesToClusterWideID(sessionIDBytes)
@@ -2164,6 +2204,10 @@ func (m *sessionDataMutator) SetHashShardedIndexesEnabled(val bool) {
 	m.data.HashShardedIndexesEnabled = val
 }
 
+func (m *sessionDataMutator) SetTriggersEnabled(val bool) {
+	m.data.TriggersEnabled = val
+}
+
 func (m *sessionDataMutator) SetAlterColumnTypeGeneral(val bool) {
 	m.data.AlterColumnTypeGeneralEnabled = val
 }
@@ -31,6 +31,7 @@ type exportNode struct {
 	source planNode
 
 	fileName        string
+	fileFormat      execinfrapb.ExportFormat
 	csvOpts         roachpb.CSVOptions
 	chunkSize       int
 	fileCompression execinfrapb.FileCompression
@@ -71,6 +72,7 @@ var exportOptionExpectValues = map[string]KVStringOptValidate{
 const exportChunkSizeDefault = 100000
 const exportFilePatternPart = "%part%"
 const exportFilePatternDefault = exportFilePatternPart + ".csv"
+const exportFilePatternParquet = exportFilePatternPart + ".parquet"
 const exportCompressionCodec = "gzip"
 
 // ConstructExport is part of the exec.Factory interface.
@@ -81,7 +83,13 @@ func (ef *execFactory) ConstructExport(
 		return nil, errors.Errorf("EXPORT cannot be used inside a transaction")
 	}
 
-	if fileFormat != "CSV" {
+	var format execinfrapb.ExportFormat
+	switch fileFormat {
+	case "CSV":
+		format = execinfrapb.ExportFormat_CSV
+	case "PARQUET":
+		format = execinfrapb.ExportFormat_Parquet
+	default:
 		return nil, errors.Errorf("unsupported export format: %q", fileFormat)
 	}
 
@@ -99,6 +107,15 @@ func (ef *execFactory) ConstructExport(
 		return nil, err
 	}
 
+	if format == execinfrapb.ExportFormat_Parquet {
+		for _, opt := range []string{exportOptionDelimiter, exportOptionNullAs} {
+			if _, ok := optVals[opt]; ok {
+				return nil, pgerror.Newf(pgcode.InvalidParameterValue,
+					"%s option is not supported with PARQUET export", opt)
+			}
+		}
+	}
+
 	csvOpts := roachpb.CSVOptions{}
 
 	if override, ok := optVals[exportOptionDelimiter]; ok {
@@ -138,6 +155,7 @@ func (ef *execFactory) ConstructExport(
 	return &exportNode{
 		source:          input.(planNode),
 		fileName:        string(*fileNameStr),
+		fileFormat:      format,
 		csvOpts:         csvOpts,
 		chunkSize:       chunkSize,
 		fileCompression: codec,
@@ -0,0 +1,199 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/exec/execbuilder"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/optbuilder"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/xform"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+)
+
+// functionBodyCache caches the memos of the bodies of the user-defined
+// functions which are evaluated by a statement, i.e. the functions that the
+// optimizer could not inline. The body of such a function is built once per
+// statement, with the arguments represented by placeholders. Every call only
+// assigns the arguments to the placeholders and finishes the optimization,
+// like the execution of a prepared statement does.
+type functionBodyCache struct {
+	mu struct {
+		syncutil.Mutex
+		bodies map[sqlbase.ID]*functionBody
+	}
+}
+
+// functionBody is the cached memo of the body of a user-defined function.
+type functionBody struct {
+	version sqlbase.DescriptorVersion
+	memo    *memo.Memo
+}
+
+// functionBodyInfo describes the body of a user-defined function.
+type functionBodyInfo struct {
+	id      sqlbase.ID
+	version sqlbase.DescriptorVersion
+	// query is the query which evaluates the body, see
+	// sqlbase.MakeFunctionQuery.
+	query      *tree.Select
+	paramTypes tree.ArgTypes
+	returnType *types.T
+}
+
+// get returns the cached memo of the body of the given function, building it
+// if needed. The lock is not held while building the memo, so the memo of a
+// body may be built more than once if it is evaluated concurrently.
+func (c *functionBodyCache) get(
+	ctx context.Context, p *planner, info *functionBodyInfo,
+) (*memo.Memo, error) {
+	c.mu.Lock()
+	body, ok := c.mu.bodies[info.id]
+	c.mu.Unlock()
+	if ok && body.version == info.version {
+		return body.memo, nil
+	}
+
+	m, err := buildFunctionBodyMemo(ctx, p, info)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mu.bodies == nil {
+		c.mu.bodies = make(map[sqlbase.ID]*functionBody)
+	}
+	c.mu.bodies[info.id] = &functionBody{version: info.version, memo: m}
+	return m, nil
+}
+
+// buildFunctionBodyMemo builds the query which evaluates the body of a
+// user-defined function into a memo that is detached from the optimizer, with
+// the arguments of the function kept as placeholders.
+func buildFunctionBodyMemo(
+	ctx context.Context, p *planner, info *functionBodyInfo,
+) (*memo.Memo, error) {
+	semaCtx := tree.MakeSemaContext()
+	semaCtx.SearchPath = p.semaCtx.SearchPath
+	semaCtx.TypeResolver = p
+	semaCtx.FunctionResolver = p
+	typeHints := make(tree.PlaceholderTypes, len(info.paramTypes))
+	for i := range info.paramTypes {
+		typeHints[i] = info.paramTypes[i].Typ
+	}
+	if err := semaCtx.Placeholders.Init(len(typeHints), typeHints); err != nil {
+		return nil, err
+	}
+
+	var o xform.Optimizer
+	o.Init(p.EvalContext(), &p.optPlanningCtx.catalog)
+	bld := optbuilder.New(ctx, &semaCtx, p.EvalContext(), &p.optPlanningCtx.catalog, o.Factory(), info.query)
+	bld.KeepPlaceholders = true
+	if err := bld.Build(); err != nil {
+		return nil, err
+	}
+	return o.DetachMemo(), nil
+}
+
+// evalFunctionBody evaluates the body of a user-defined function with the
+// given arguments. The result is the first column of the first row returned
+// by the body, or NULL if it returns no rows.
+func evalFunctionBody(
+	evalCtx *tree.EvalContext, info *functionBodyInfo, args tree.Datums,
+) (tree.Datum, error) {
+	p, ok := evalCtx.Planner.(*planner)
+	if !ok || p.functionBodies == nil {
+		return nil, errors.AssertionFailedf(
+			"user-defined function %d evaluated outside of the planner which resolved it", info.id)
+	}
+	ctx := evalCtx.Ctx()
+	cachedMemo, err := p.functionBodies.get(ctx, p, info)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assign the arguments to the placeholders of the cached memo, and finish
+	// optimizing it.
+	bodyEvalCtx := evalCtx.Copy()
+	placeholders := tree.PlaceholderInfo{Values: make(tree.QueryArguments, len(args))}
+	placeholders.Types = make(tree.PlaceholderTypes, len(args))
+	for i := range args {
+		placeholders.Values[i] = args[i]
+		placeholders.Types[i] = info.paramTypes[i].Typ
+	}
+	bodyEvalCtx.Placeholders = &placeholders
+	var o xform.Optimizer
+	o.Init(bodyEvalCtx, &p.optPlanningCtx.catalog)
+	f := o.Factory()
+	f.FoldingControl().AllowStableFolds()
+	if err := f.AssignPlaceholders(cachedMemo); err != nil {
+		return nil, err
+	}
+	root, err := o.Optimize()
+	if err != nil {
+		return nil, err
+	}
+	plan, err := execbuilder.New(
+		newExecFactory(p), f.Memo(), &p.optPlanningCtx.catalog, root, bodyEvalCtx,
+	).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	var w functionResultWriter
+	params := runParams{ctx: ctx, extendedEvalCtx: &p.extendedEvalCtx, p: p}
+	if err := runPlanInsidePlan(params, plan.(*planTop), &w); err != nil {
+		return nil, err
+	}
+	if len(w.row) == 0 || w.row[0] == tree.DNull {
+		return tree.DNull, nil
+	}
+	res := w.row[0]
+	if !res.ResolvedType().Identical(info.returnType) {
+		return tree.PerformCast(evalCtx, res, info.returnType)
+	}
+	return res, nil
+}
+
+// functionResultWriter is a rowResultWriter which keeps the first row of the
+// results of the body of a user-defined function.
+type functionResultWriter struct {
+	row tree.Datums
+	err error
+}
+
+var _ rowResultWriter = &functionResultWriter{}
+
+// AddRow implements the rowResultWriter interface.
+func (w *functionResultWriter) AddRow(ctx context.Context, row tree.Datums) error {
+	if w.row == nil {
+		w.row = append(tree.Datums{}, row...)
+	}
+	return nil
+}
+
+// IncrementRowsAffected implements the rowResultWriter interface.
+func (w *functionResultWriter) IncrementRowsAffected(n int) {}
+
+// SetError implements the rowResultWriter interface.
+func (w *functionResultWriter) SetError(err error) {
+	w.err = err
+}
+
+// Err implements the rowResultWriter interface.
+func (w *functionResultWriter) Err() error {
+	return w.err
+}
@@ -15,6 +15,8 @@ import (
 	"fmt"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catalogkv"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -24,11 +26,11 @@ import (
 
 // Grant adds privileges to users.
 // Current status:
-// - Target: single database, table, or view.
+// - Target: single database, table, view, or function.
 // TODO(marc): open questions:
 // - should we have root always allowed and not present in the permissions list?
 // - should we make users case-insensitive?
-// Privileges: GRANT on database/table/view.
+// Privileges: GRANT on database/table/view/function.
 //   Notes: postgres requires the object owner.
 //          mysql requires the "grant option" and the same privileges, and sometimes superuser.
 func (p *planner) Grant(ctx context.Context, n *tree.Grant) (planNode, error) {
@@ -50,11 +52,11 @@ func (p *planner) Grant(ctx context.Context, n *tree.Grant) (planNode, error) {
 
 // Revoke removes privileges from users.
 // Current status:
-// - Target: single database, table, or view.
+// - Target: single database, table, view, or function.
 // TODO(marc): open questions:
 // - should we have root always allowed and not present in the permissions list?
 // - should we make users case-insensitive?
-// Privileges: GRANT on database/table/view.
+// Privileges: GRANT on database/table/view/function.
 //   Notes: postgres requires the object owner.
 //          mysql requires the "grant option" and the same privileges, and sometimes superuser.
 func (p *planner) Revoke(ctx context.Context, n *tree.Revoke) (planNode, error) {
@@ -69,6 +71,10 @@ func (p *planner) Revoke(ctx context.Context, n *tree.Revoke) (planNode, error)
 		grantees:     n.Grantees,
 		desiredprivs: n.Privileges,
 		changePrivilege: func(privDesc *sqlbase.PrivilegeDescriptor, grantee string) {
+			if n.Targets.Functions != nil {
+				privDesc.RevokeFunction(grantee, n.Privileges)
+				return
+			}
 			privDesc.Revoke(grantee, n.Privileges)
 		},
 	}, nil
@@ -122,6 +128,9 @@ func (n *changePrivilegesNode) startExec(params runParams) error {
 		if err := p.CheckPrivilege(ctx, descriptor, privilege.GRANT); err != nil {
 			return err
 		}
+		if err := validatePrivilegesForDescriptor(descriptor, n.desiredprivs); err != nil {
+			return err
+		}
 
 		// Only allow granting/revoking privileges that the requesting
 		// user themselves have on the descriptor.
@@ -159,6 +168,16 @@ func (n *changePrivilegesNode) startExec(params runParams) error {
 				return err
 			}
 
+		case *sqlbase.MutableFunctionDescriptor:
+			if err := d.Validate(); err != nil {
+				return err
+			}
+			if err := p.writeFunctionChange(
+				ctx, d, fmt.Sprintf("updating privileges for function %d", d.ID),
+			); err != nil {
+				return err
+			}
+
 		case *sqlbase.MutableTableDescriptor:
 			// TODO (lucy): This should probably have a single consolidated job like
 			// DROP DATABASE.
@@ -181,6 +200,28 @@ func (n *changePrivilegesNode) startExec(params runParams) error {
 	return p.txn.Run(ctx, b)
 }
 
+// validatePrivilegesForDescriptor checks that the given privileges apply to
+// the kind of object described by the descriptor. Functions only support
+// EXECUTE and the privileges needed to manage them, and EXECUTE only applies
+// to functions.
+func validatePrivilegesForDescriptor(
+	descriptor sqlbase.DescriptorInterface, privs privilege.List,
+) error {
+	_, isFunction := descriptor.(*sqlbase.MutableFunctionDescriptor)
+	allowed := privilege.FunctionPrivileges.ToBitField()
+	for _, priv := range privs {
+		valid := priv != privilege.EXECUTE
+		if isFunction {
+			valid = allowed&priv.Mask() != 0
+		}
+		if !valid {
+			return pgerror.Newf(pgcode.InvalidGrantOperation,
+				"invalid privilege type %s for %s", priv, descriptor.TypeName())
+		}
+	}
+	return nil
+}
+
 func (*changePrivilegesNode) Next(runParams) (bool, error) { return false, nil }
 func (*changePrivilegesNode) Values() tree.Datums          { return tree.Datums{} }
 func (*changePrivilegesNode) Close(context.Context)        {}
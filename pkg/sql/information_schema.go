@@ -1232,6 +1232,12 @@ CREATE TABLE information_schema.table_constraints (
 				tbNameStr := tree.NewDString(table.Name)
 
 				for conName, c := range conInfo {
+					deferrable, initiallyDeferred := false, false
+					if c.FK != nil {
+						deferrable, initiallyDeferred = c.FK.Deferrable, c.FK.InitiallyDeferred
+					} else if c.Kind == sqlbase.ConstraintTypeUnique {
+						deferrable, initiallyDeferred = c.Index.DeferrableUnique, c.Index.InitiallyDeferred
+					}
 					if err := addRow(
 						dbNameStr,                       // constraint_catalog
 						scNameStr,                       // constraint_schema
@@ -1240,8 +1246,8 @@ CREATE TABLE information_schema.table_constraints (
 						scNameStr,                       // table_schema
 						tbNameStr,                       // table_name
 						tree.NewDString(string(c.Kind)), // constraint_type
-						yesOrNoDatum(false),             // is_deferrable
-						yesOrNoDatum(false),             // initially_deferred
+						yesOrNoDatum(deferrable),        // is_deferrable
+						yesOrNoDatum(initiallyDeferred), // initially_deferred
 					); err != nil {
 						return err
 					}
@@ -200,7 +200,10 @@ func (n *insertNode) startExec(params runParams) error {
 
 	n.run.initRowContainer(params, n.columns, 0 /* rowCapacity */)
 
-	return n.run.ti.init(params.ctx, params.p.txn, params.EvalContext())
+	if err := n.run.ti.init(params.ctx, params.p.txn, params.EvalContext()); err != nil {
+		return err
+	}
+	return n.run.ti.initDeferrableUniqueChecks(params.p.deferredConstraints)
 }
 
 // Next is required because batchedPlanNode inherits from planNode, but
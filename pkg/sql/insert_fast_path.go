@@ -254,7 +254,10 @@ func (n *insertFastPathNode) startExec(params runParams) error {
 		}
 	}
 
-	return n.run.ti.init(params.ctx, params.p.txn, params.EvalContext())
+	if err := n.run.ti.init(params.ctx, params.p.txn, params.EvalContext()); err != nil {
+		return err
+	}
+	return n.run.ti.initDeferrableUniqueChecks(params.p.deferredConstraints)
 }
 
 // Next is required because batchedPlanNode inherits from planNode, but
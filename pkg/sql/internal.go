@@ -20,6 +20,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/security"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descs"
+	"github.com/cockroachdb/cockroach/pkg/sql/notify"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirebase"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -585,6 +586,17 @@ func (icc *internalClientComm) Flush(pos CmdPos) error {
 	return nil
 }
 
+// SendNotification is part of the ClientComm interface. Notifications are
+// not delivered to internal executors.
+func (icc *internalClientComm) SendNotification(notify.Notification) error {
+	return nil
+}
+
+// SendNotice is part of the ClientComm interface.
+func (icc *internalClientComm) SendNotice(context.Context, error) error {
+	return nil
+}
+
 // CreateDescribeResult is part of the ClientComm interface.
 func (icc *internalClientComm) CreateDescribeResult(pos CmdPos) DescribeResult {
 	return icc.createRes(pos, nil /* onClose */)
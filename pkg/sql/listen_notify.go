@@ -0,0 +1,360 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/notify"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgnotice"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+const (
+	// maxChannelNameLength is the maximum length of a channel name. Postgres
+	// limits channel names to NAMEDATALEN-1 bytes.
+	maxChannelNameLength = 63
+	// maxPayloadLength is the maximum length of the payload of a
+	// notification, which is the same as in Postgres.
+	maxPayloadLength = 7999
+	// maxQueuedNotifications is the maximum number of notifications waiting to
+	// be delivered to a session. Notifications received while the queue is
+	// full are dropped, and the client is sent a notice saying how many were
+	// dropped.
+	maxQueuedNotifications = 10000
+)
+
+// listenOp is a LISTEN or UNLISTEN statement run in a transaction. It only
+// takes effect when the transaction commits.
+type listenOp struct {
+	channel string
+	// unlisten is set for UNLISTEN.
+	unlisten bool
+	// all is set for UNLISTEN *.
+	all bool
+}
+
+// sessionListener is the state of a session that runs LISTEN. It is
+// registered with the node's notify.Registry on the channels the session
+// listens on, and queues the notifications it receives until they can be
+// delivered to the client. Like in Postgres, notifications are only delivered
+// while the session is idle outside of a transaction, i.e. when it waits for
+// the next command of the client.
+type sessionListener struct {
+	registry *notify.Registry
+	comm     ClientComm
+	sv       *settings.Values
+	// pid identifies the session in the notifications it sends.
+	pid int32
+
+	// channels is the set of channels the session listens on. Like pendingOps,
+	// it is only accessed from the connExecutor's goroutine.
+	channels map[string]struct{}
+	// pendingOps are the LISTEN and UNLISTEN statements run by the current
+	// transaction.
+	pendingOps []listenOp
+
+	mu struct {
+		syncutil.Mutex
+		// queue contains the notifications that have not been delivered yet.
+		queue []notify.Notification
+		// dropped is the number of notifications dropped because the queue was
+		// full, which the client has not been told about yet.
+		dropped int
+		// idle is set while the connExecutor waits for a command outside of a
+		// transaction, during which notifications can be written to the client.
+		idle bool
+		// deliveryDone is non-nil while a goroutine delivers the queued
+		// notifications, and is closed when that goroutine stops.
+		deliveryDone chan struct{}
+		// closed is set once the session is closed.
+		closed bool
+	}
+}
+
+var _ notify.Listener = &sessionListener{}
+
+func (l *sessionListener) init(
+	registry *notify.Registry, comm ClientComm, sv *settings.Values, pid int32,
+) {
+	l.registry = registry
+	l.comm = comm
+	l.sv = sv
+	l.pid = pid
+}
+
+// listen queues a LISTEN or UNLISTEN, to be applied when the current
+// transaction commits.
+func (l *sessionListener) listen(op listenOp) {
+	l.pendingOps = append(l.pendingOps, op)
+}
+
+// onTxnFinish applies the LISTEN and UNLISTEN statements of a transaction
+// when it commits, and forgets them when it rolls back or restarts.
+func (l *sessionListener) onTxnFinish(ev txnEvent) {
+	ops := l.pendingOps
+	l.pendingOps = nil
+	if ev != txnCommit {
+		return
+	}
+	for _, op := range ops {
+		switch {
+		case op.all:
+			l.unlistenAll()
+		case op.unlisten:
+			if _, ok := l.channels[op.channel]; ok {
+				delete(l.channels, op.channel)
+				l.registry.Unlisten(op.channel, l)
+			}
+		default:
+			if _, ok := l.channels[op.channel]; !ok {
+				if l.channels == nil {
+					l.channels = make(map[string]struct{})
+				}
+				l.channels[op.channel] = struct{}{}
+				l.registry.Listen(op.channel, l)
+			}
+		}
+	}
+}
+
+// unlistenAll unregisters the session from all the channels it listens on.
+func (l *sessionListener) unlistenAll() {
+	for channel := range l.channels {
+		l.registry.Unlisten(channel, l)
+		delete(l.channels, channel)
+	}
+}
+
+// Notify implements the notify.Listener interface.
+func (l *sessionListener) Notify(n notify.Notification) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.mu.closed {
+		return true
+	}
+	if len(l.mu.queue) >= maxQueuedNotifications {
+		l.mu.dropped++
+		return false
+	}
+	l.mu.queue = append(l.mu.queue, n)
+	l.maybeStartDeliveryLocked()
+	return true
+}
+
+// setIdle is called by the connExecutor before it waits for the next command
+// outside of a transaction. The queued notifications, and the ones received
+// until setBusy is called, are delivered to the client.
+func (l *sessionListener) setIdle() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.mu.idle = true
+	l.maybeStartDeliveryLocked()
+}
+
+// setBusy is called by the connExecutor when it receives a command. It waits
+// for the notification being written to the client, if any, so that the
+// connExecutor can write to the client again.
+func (l *sessionListener) setBusy() {
+	l.mu.Lock()
+	l.mu.idle = false
+	done := l.mu.deliveryDone
+	l.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// close stops the delivery of notifications and unregisters the session from
+// all its channels.
+func (l *sessionListener) close() {
+	l.mu.Lock()
+	l.mu.closed = true
+	l.mu.idle = false
+	l.mu.queue = nil
+	l.mu.dropped = 0
+	done := l.mu.deliveryDone
+	l.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+	l.pendingOps = nil
+	l.unlistenAll()
+}
+
+// maybeStartDeliveryLocked starts a goroutine delivering the queued
+// notifications if the session is idle and no such goroutine is running.
+// The notifications are delivered asynchronously since Notify must not block
+// and the connExecutor is blocked waiting for a command.
+func (l *sessionListener) maybeStartDeliveryLocked() {
+	if !l.mu.idle || l.mu.deliveryDone != nil || (len(l.mu.queue) == 0 && l.mu.dropped == 0) {
+		return
+	}
+	l.mu.deliveryDone = make(chan struct{})
+	go l.deliver()
+}
+
+// deliver writes the queued notifications to the client until the queue is
+// empty or the session is no longer idle. Once the queue is empty, the client
+// is sent a notice if notifications were dropped in the meantime.
+func (l *sessionListener) deliver() {
+	ctx := context.Background()
+	for {
+		l.mu.Lock()
+		if !l.mu.idle || (len(l.mu.queue) == 0 && l.mu.dropped == 0) {
+			close(l.mu.deliveryDone)
+			l.mu.deliveryDone = nil
+			l.mu.Unlock()
+			return
+		}
+		var err error
+		if len(l.mu.queue) > 0 {
+			n := l.mu.queue[0]
+			l.mu.queue = l.mu.queue[1:]
+			l.mu.Unlock()
+			err = l.comm.SendNotification(n)
+		} else {
+			dropped := l.mu.dropped
+			l.mu.dropped = 0
+			l.mu.Unlock()
+			if NoticesEnabled.Get(l.sv) {
+				err = l.comm.SendNotice(ctx, pgnotice.NewWithSeverityf("WARNING",
+					"%d notification(s) were dropped because too many notifications "+
+						"were waiting to be delivered to this session", dropped))
+			}
+		}
+		if err != nil {
+			log.VEventf(ctx, 2, "unable to deliver notifications: %v", err)
+			// The connection is broken, which the connExecutor finds out when
+			// it reads the next command. The remaining notifications are lost.
+			l.mu.Lock()
+			l.mu.queue = nil
+			l.mu.dropped = 0
+			l.mu.Unlock()
+		}
+	}
+}
+
+// checkNotificationsSupported returns an error if LISTEN and NOTIFY cannot be
+// used yet.
+func (p *planner) checkNotificationsSupported(ctx context.Context, stmt string) error {
+	if !p.ExecCfg().Settings.Version.IsActive(ctx, clusterversion.VersionNotificationsTable) {
+		return pgerror.Newf(pgcode.ObjectNotInPrerequisiteState,
+			`%s requires all nodes to be upgraded to %s`,
+			stmt, clusterversion.VersionByKey(clusterversion.VersionNotificationsTable))
+	}
+	if p.ExecCfg().NotificationRegistry == nil {
+		return pgerror.Newf(pgcode.FeatureNotSupported, "%s is not supported in this context", stmt)
+	}
+	return nil
+}
+
+// checkChannelName returns an error if the given channel name is invalid.
+func checkChannelName(channel string) error {
+	if channel == "" {
+		return pgerror.New(pgcode.InvalidParameterValue, "channel name cannot be empty")
+	}
+	if len(channel) > maxChannelNameLength {
+		return pgerror.New(pgcode.InvalidParameterValue, "channel name too long")
+	}
+	return nil
+}
+
+// Listen implements the LISTEN statement.
+// See https://www.postgresql.org/docs/current/sql-listen.html for details.
+func (p *planner) Listen(ctx context.Context, n *tree.Listen) (planNode, error) {
+	if err := p.checkNotificationsSupported(ctx, "LISTEN"); err != nil {
+		return nil, err
+	}
+	if p.sessionListener == nil {
+		return nil, pgerror.New(pgcode.FeatureNotSupported, "LISTEN is not supported in this context")
+	}
+	if err := checkChannelName(string(n.ChannelName)); err != nil {
+		return nil, err
+	}
+	return &listenNode{op: listenOp{channel: string(n.ChannelName)}}, nil
+}
+
+// Unlisten implements the UNLISTEN statement.
+// See https://www.postgresql.org/docs/current/sql-unlisten.html for details.
+func (p *planner) Unlisten(ctx context.Context, n *tree.Unlisten) (planNode, error) {
+	if p.sessionListener == nil {
+		return nil, pgerror.New(pgcode.FeatureNotSupported, "UNLISTEN is not supported in this context")
+	}
+	return &listenNode{op: listenOp{channel: string(n.ChannelName), unlisten: true, all: n.All}}, nil
+}
+
+// listenNode runs a LISTEN or UNLISTEN statement. Like in Postgres, the
+// statement takes effect when the transaction commits.
+type listenNode struct {
+	op listenOp
+}
+
+func (n *listenNode) startExec(params runParams) error {
+	params.p.sessionListener.listen(n.op)
+	return nil
+}
+
+func (n *listenNode) Next(runParams) (bool, error) { return false, nil }
+func (n *listenNode) Values() tree.Datums          { return nil }
+func (n *listenNode) Close(context.Context)        {}
+
+// Notify implements the NOTIFY statement.
+// See https://www.postgresql.org/docs/current/sql-notify.html for details.
+func (p *planner) Notify(ctx context.Context, n *tree.Notify) (planNode, error) {
+	return &notifyNode{n: n}, nil
+}
+
+// notifyNode sends a notification.
+type notifyNode struct {
+	n *tree.Notify
+}
+
+func (n *notifyNode) startExec(params runParams) error {
+	return params.p.SendNotification(params.ctx, string(n.n.ChannelName), n.n.Payload)
+}
+
+func (n *notifyNode) Next(runParams) (bool, error) { return false, nil }
+func (n *notifyNode) Values() tree.Datums          { return nil }
+func (n *notifyNode) Close(context.Context)        {}
+
+// SendNotification is part of the tree.EvalPlanner interface. It implements
+// NOTIFY and pg_notify(). The notification is delivered to the listening
+// sessions if the current transaction commits.
+func (p *planner) SendNotification(ctx context.Context, channel, payload string) error {
+	if err := p.checkNotificationsSupported(ctx, "NOTIFY"); err != nil {
+		return err
+	}
+	if err := checkChannelName(channel); err != nil {
+		return err
+	}
+	if len(payload) > maxPayloadLength {
+		return pgerror.New(pgcode.InvalidParameterValue, "payload string too long")
+	}
+	if p.EvalContext().TxnReadOnly {
+		return readOnlyError("NOTIFY")
+	}
+	var pid int32
+	if p.sessionListener != nil {
+		pid = p.sessionListener.pid
+	}
+	return p.ExecCfg().NotificationRegistry.Publish(ctx, p.txn, notify.Notification{
+		Channel: channel,
+		Payload: payload,
+		PID:     pid,
+	})
+}
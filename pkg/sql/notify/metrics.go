@@ -0,0 +1,44 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package notify
+
+import "github.com/cockroachdb/cockroach/pkg/util/metric"
+
+var (
+	metaNotificationsDelivered = metric.Metadata{
+		Name:        "sql.notifications.delivered",
+		Help:        "Notifications dispatched to the listening sessions of this node",
+		Measurement: "Notifications",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaNotificationsDropped = metric.Metadata{
+		Name:        "sql.notifications.dropped",
+		Help:        "Notifications dropped because the queue of a listening session was full",
+		Measurement: "Notifications",
+		Unit:        metric.Unit_COUNT,
+	}
+)
+
+// Metrics are for production monitoring of the delivery of notifications.
+type Metrics struct {
+	Delivered *metric.Counter
+	Dropped   *metric.Counter
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (*Metrics) MetricStruct() {}
+
+func makeMetrics() Metrics {
+	return Metrics{
+		Delivered: metric.NewCounter(metaNotificationsDelivered),
+		Dropped:   metric.NewCounter(metaNotificationsDropped),
+	}
+}
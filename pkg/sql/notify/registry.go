@@ -0,0 +1,423 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Package notify implements the delivery of the notifications sent with
+// NOTIFY to the sessions that LISTEN on their channel, across the cluster.
+//
+// Sending a notification inserts a row in system.notifications in the
+// notifying transaction, so the notification only becomes visible if that
+// transaction commits. Every node with listening sessions runs a rangefeed
+// over the table and dispatches the rows it receives to its local listeners.
+// Rows are deleted once they are older than sql.notifications.ttl by the node
+// holding the lease on the meta1 range. A rangefeed that lags behind the
+// deletions still delivers the deleted notifications, which it reads from the
+// MVCC history of the table, unless that history was garbage collected.
+//
+// A notification is delivered at most once to each listening session: when
+// the rangefeed fails and is restarted from the last resolved timestamp, the
+// notifications it emits again are recognized and skipped. A notification is
+// dropped, and counted in sql.notifications.dropped, if the session it is
+// delivered to already has too many notifications waiting to be sent to its
+// client.
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/span"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+var notificationTTL = settings.RegisterValidatedDurationSetting(
+	"sql.notifications.ttl",
+	"amount of time notifications are kept in system.notifications before being deleted",
+	10*time.Minute,
+	func(v time.Duration) error {
+		if v <= 0 {
+			return errors.Errorf("sql.notifications.ttl must be positive")
+		}
+		return nil
+	},
+)
+
+// droppedLogLimiter limits the rate at which dropped notifications are
+// logged.
+var droppedLogLimiter = log.Every(10 * time.Second)
+
+// deleteBatchSize is the maximum number of expired notifications deleted by
+// a single statement.
+const deleteBatchSize = 1000
+
+// Notification is a message sent on a channel with NOTIFY or pg_notify().
+type Notification struct {
+	// Channel is the name of the channel the notification was sent on.
+	Channel string
+	// Payload is the, possibly empty, payload of the notification.
+	Payload string
+	// PID identifies the notifying session. It is the "process ID" that was
+	// sent to the session's client in the BackendKeyData message.
+	PID int32
+}
+
+// Listener is notified of the notifications sent on the channels it listens
+// on.
+type Listener interface {
+	// Notify is called for every notification sent on a channel the listener
+	// listens on. It must not block. It returns false if the notification was
+	// dropped because the listener cannot keep up.
+	Notify(Notification) bool
+}
+
+// IsMeta1LeaseholderFn returns whether this node holds the lease on the meta1
+// range at the given timestamp. Only that node deletes expired notifications.
+type IsMeta1LeaseholderFn func(context.Context, hlc.Timestamp) (bool, error)
+
+// RangeFeedFn starts a rangefeed over the given span and sends its events on
+// eventC until the context is canceled or an error occurs. It is implemented
+// by kvcoord.DistSender.RangeFeed.
+type RangeFeedFn func(
+	ctx context.Context,
+	span roachpb.Span,
+	startFrom hlc.Timestamp,
+	withDiff bool,
+	eventC chan<- *roachpb.RangeFeedEvent,
+) error
+
+// Registry tracks the listeners of the sessions running on this node and
+// delivers them the notifications sent on their channels from any node.
+type Registry struct {
+	st        *cluster.Settings
+	codec     keys.SQLCodec
+	clock     *hlc.Clock
+	ie        sqlutil.InternalExecutor
+	rangeFeed RangeFeedFn
+	// isMeta1Leaseholder is used to only delete expired notifications from
+	// one node at a time.
+	isMeta1Leaseholder IsMeta1LeaseholderFn
+	metrics            Metrics
+
+	// listening is closed when the first listener is registered. The rangefeed
+	// over system.notifications is only started on nodes that have, or had,
+	// listening sessions.
+	listening chan struct{}
+
+	mu struct {
+		syncutil.Mutex
+		// listeners maps channel names to the listeners registered on them.
+		listeners map[string]map[Listener]struct{}
+		// startTS is the timestamp from which the rangefeed delivers
+		// notifications. It is set when the first listener is registered.
+		startTS hlc.Timestamp
+	}
+}
+
+// NewRegistry constructs a new Registry.
+func NewRegistry(
+	st *cluster.Settings,
+	codec keys.SQLCodec,
+	clock *hlc.Clock,
+	ie sqlutil.InternalExecutor,
+	rangeFeed RangeFeedFn,
+	isMeta1Leaseholder IsMeta1LeaseholderFn,
+) *Registry {
+	r := &Registry{
+		st:                 st,
+		codec:              codec,
+		clock:              clock,
+		ie:                 ie,
+		rangeFeed:          rangeFeed,
+		isMeta1Leaseholder: isMeta1Leaseholder,
+		metrics:            makeMetrics(),
+		listening:          make(chan struct{}),
+	}
+	r.mu.listeners = make(map[string]map[Listener]struct{})
+	return r
+}
+
+// Metrics returns the metrics of the registry.
+func (r *Registry) Metrics() *Metrics {
+	return &r.metrics
+}
+
+// Start starts the rangefeed, once there are listeners, and the loop deleting
+// expired notifications.
+func (r *Registry) Start(ctx context.Context, stopper *stop.Stopper) {
+	ctx, _ = stopper.WithCancelOnQuiesce(ctx)
+	// NB: The only error that should occur here would be if the server were
+	// shutting down so let's swallow it.
+	_ = stopper.RunAsyncTask(ctx, "notifications-rangefeed", r.watchNotifications)
+	_ = stopper.RunAsyncTask(ctx, "notifications-cleanup", r.deleteExpiredNotifications)
+}
+
+// Publish sends a notification. The notification is written by the given
+// transaction and is only delivered to the listeners if it commits.
+func (r *Registry) Publish(ctx context.Context, txn *kv.Txn, n Notification) error {
+	_, err := r.ie.ExecEx(
+		ctx, "notify", txn,
+		sqlbase.InternalExecutorSessionDataOverride{User: security.RootUser},
+		`INSERT INTO system.notifications (channel, payload, pid) VALUES ($1, $2, $3)`,
+		n.Channel, n.Payload, n.PID,
+	)
+	return err
+}
+
+// Listen registers the listener on the given channel. Registering the same
+// listener twice on a channel is a no-op.
+func (r *Registry) Listen(channel string, l Listener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	listeners, ok := r.mu.listeners[channel]
+	if !ok {
+		listeners = make(map[Listener]struct{})
+		r.mu.listeners[channel] = listeners
+	}
+	listeners[l] = struct{}{}
+	if r.mu.startTS.IsEmpty() {
+		// A notification sent from another node after this point may have been
+		// assigned a timestamp that is lower than our clock's reading by up to
+		// the maximum clock offset, so start the rangefeed that far back.
+		r.mu.startTS = r.clock.Now().Add(-r.clock.MaxOffset().Nanoseconds(), 0)
+		close(r.listening)
+	}
+}
+
+// Unlisten unregisters the listener from the given channel. It is a no-op if
+// the listener is not registered on the channel.
+func (r *Registry) Unlisten(channel string, l Listener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	listeners := r.mu.listeners[channel]
+	delete(listeners, l)
+	if len(listeners) == 0 {
+		delete(r.mu.listeners, channel)
+	}
+}
+
+func (r *Registry) dispatch(ctx context.Context, n Notification) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var dropped int64
+	for l := range r.mu.listeners[n.Channel] {
+		if l.Notify(n) {
+			r.metrics.Delivered.Inc(1)
+		} else {
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		r.metrics.Dropped.Inc(dropped)
+		if droppedLogLimiter.ShouldLog() {
+			log.Warningf(ctx, "dropped notification on channel %q for %d session(s) "+
+				"with too many undelivered notifications", n.Channel, dropped)
+		}
+	}
+}
+
+// watchNotifications waits for the first listener to be registered and then
+// runs a rangefeed over system.notifications for as long as the node is up,
+// restarting it when it fails.
+func (r *Registry) watchNotifications(ctx context.Context) {
+	select {
+	case <-r.listening:
+	case <-ctx.Done():
+		return
+	}
+	tableSpan := roachpb.Span{Key: r.codec.TablePrefix(keys.NotificationsTableID)}
+	tableSpan.EndKey = tableSpan.Key.PrefixEnd()
+	// The frontier tracks the timestamp up to which all notifications have
+	// been delivered, so that a restarted rangefeed picks up where the failed
+	// one left off.
+	frontier := span.MakeFrontier(tableSpan)
+	r.mu.Lock()
+	frontier.Forward(tableSpan, r.mu.startTS)
+	r.mu.Unlock()
+	// A restarted rangefeed emits again the notifications above the frontier
+	// that were already delivered. delivered holds their keys so that they can
+	// be skipped. A key is never written twice, since every notification is
+	// inserted with a new unique id.
+	delivered := make(map[string]hlc.Timestamp)
+
+	opts := retry.Options{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 10 * time.Second}
+	for re := retry.StartWithCtx(ctx, opts); re.Next(); {
+		err := r.runRangeFeed(ctx, tableSpan, frontier, delivered)
+		if ctx.Err() != nil {
+			return
+		}
+		var gcErr *roachpb.BatchTimestampBeforeGCError
+		if errors.As(err, &gcErr) {
+			// The rangefeed lagged so far behind that the history of the table
+			// below the frontier was garbage collected, so the notifications
+			// written there can no longer be delivered. Restarting from the
+			// frontier would fail forever, so skip ahead of the GC threshold.
+			log.Warningf(ctx, "notifications rangefeed fell behind the GC threshold %s, "+
+				"notifications sent since %s may not have been delivered", gcErr.Threshold, frontier.Frontier())
+			frontier.Forward(tableSpan, gcErr.Threshold.Next())
+			continue
+		}
+		log.Warningf(ctx, "notifications rangefeed failed, restarting: %v", err)
+	}
+}
+
+func (r *Registry) runRangeFeed(
+	ctx context.Context,
+	tableSpan roachpb.Span,
+	frontier *span.Frontier,
+	delivered map[string]hlc.Timestamp,
+) error {
+	eventC := make(chan *roachpb.RangeFeedEvent, 128)
+	g := ctxgroup.WithContext(ctx)
+	g.GoCtx(func(ctx context.Context) error {
+		return r.rangeFeed(ctx, tableSpan, frontier.Frontier(), false /* withDiff */, eventC)
+	})
+	g.GoCtx(func(ctx context.Context) error {
+		var alloc sqlbase.DatumAlloc
+		for {
+			select {
+			case e := <-eventC:
+				switch t := e.GetValue().(type) {
+				case *roachpb.RangeFeedValue:
+					if !t.Value.IsPresent() {
+						// The notification was deleted because it expired.
+						continue
+					}
+					if t.Value.Timestamp.LessEq(frontier.Frontier()) {
+						// The notification was delivered before the rangefeed restarted.
+						continue
+					}
+					if _, ok := delivered[string(t.Key)]; ok {
+						continue
+					}
+					delivered[string(t.Key)] = t.Value.Timestamp
+					n, err := decodeNotification(&alloc, t.Value)
+					if err != nil {
+						log.Warningf(ctx, "unable to decode notification %s: %v", t.Key, err)
+						continue
+					}
+					r.dispatch(ctx, n)
+				case *roachpb.RangeFeedCheckpoint:
+					if frontier.Forward(t.Span, t.ResolvedTS) {
+						// The notifications at or below the new frontier are not emitted
+						// again, so there is no need to remember them anymore.
+						resolved := frontier.Frontier()
+						for k, ts := range delivered {
+							if ts.LessEq(resolved) {
+								delete(delivered, k)
+							}
+						}
+					}
+				}
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+	return g.Wait()
+}
+
+// decodeNotification decodes the value of a row of system.notifications. The
+// id column, which is only stored in the key, is not needed.
+func decodeNotification(alloc *sqlbase.DatumAlloc, value roachpb.Value) (Notification, error) {
+	tbl := sqlbase.NotificationsTable.TableDesc()
+	b, err := value.GetTuple()
+	if err != nil {
+		return Notification{}, err
+	}
+	var n Notification
+	var lastColID sqlbase.ColumnID
+	for len(b) > 0 {
+		_, _, colIDDiff, _, err := encoding.DecodeValueTag(b)
+		if err != nil {
+			return Notification{}, err
+		}
+		colID := lastColID + sqlbase.ColumnID(colIDDiff)
+		lastColID = colID
+		col, err := tbl.FindColumnByID(colID)
+		if err != nil {
+			return Notification{}, err
+		}
+		var d tree.Datum
+		d, b, err = sqlbase.DecodeTableValue(alloc, col.Type, b)
+		if err != nil {
+			return Notification{}, err
+		}
+		switch col.Name {
+		case "channel":
+			n.Channel = string(tree.MustBeDString(d))
+		case "payload":
+			n.Payload = string(tree.MustBeDString(d))
+		case "pid":
+			n.PID = int32(tree.MustBeDInt(d))
+		}
+	}
+	return n, nil
+}
+
+// deleteExpiredNotifications periodically deletes the notifications that are
+// older than sql.notifications.ttl. Every node runs this loop, since
+// notifications are written whether or not anybody listens to them, but only
+// the node holding the meta1 lease deletes anything so that the nodes do not
+// contend on the table.
+func (r *Registry) deleteExpiredNotifications(ctx context.Context) {
+	var timer timeutil.Timer
+	defer timer.Stop()
+	for {
+		timer.Reset(notificationTTL.Get(&r.st.SV))
+		select {
+		case <-timer.C:
+			timer.Read = true
+		case <-ctx.Done():
+			return
+		}
+		isLeaseholder, err := r.isMeta1Leaseholder(ctx, r.clock.Now())
+		if err != nil {
+			log.Warningf(ctx, "unable to check whether to delete expired notifications: %v", err)
+			continue
+		}
+		if !isLeaseholder {
+			log.VEventf(ctx, 2, "skipping deletion of expired notifications as this node is not the meta1 leaseholder")
+			continue
+		}
+		for {
+			n, err := r.ie.ExecEx(
+				ctx, "delete-expired-notifications", nil, /* txn */
+				sqlbase.InternalExecutorSessionDataOverride{User: security.RootUser},
+				`DELETE FROM system.notifications WHERE created < now() - $1::INTERVAL LIMIT $2`,
+				notificationTTL.Get(&r.st.SV), deleteBatchSize,
+			)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Warningf(ctx, "unable to delete expired notifications: %v", err)
+				}
+				break
+			}
+			if n < deleteBatchSize {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type testListener struct {
+	notifications chan Notification
+	drop          bool
+}
+
+func (l *testListener) Notify(n Notification) bool {
+	if l.drop {
+		return false
+	}
+	l.notifications <- n
+	return true
+}
+
+// makeNotificationEvent returns the rangefeed event for the insertion of the
+// given notification in system.notifications.
+func makeNotificationEvent(id int64, n Notification, ts hlc.Timestamp) *roachpb.RangeFeedEvent {
+	key := keys.SystemSQLCodec.IndexPrefix(keys.NotificationsTableID, 1)
+	key = encoding.EncodeVarintAscending(key, id)
+	var b []byte
+	b = encoding.EncodeBytesValue(b, 2 /* colIDDiff */, []byte(n.Channel))
+	b = encoding.EncodeBytesValue(b, 1 /* colIDDiff */, []byte(n.Payload))
+	b = encoding.EncodeIntValue(b, 1 /* colIDDiff */, int64(n.PID))
+	var v roachpb.Value
+	v.SetTuple(b)
+	v.Timestamp = ts
+	return &roachpb.RangeFeedEvent{Val: &roachpb.RangeFeedValue{Key: key, Value: v}}
+}
+
+// TestRegistryRangeFeedRestart checks that the notifications emitted again by
+// a restarted rangefeed are not delivered twice.
+func TestRegistryRangeFeedRestart(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tableSpan := roachpb.Span{Key: keys.SystemSQLCodec.TablePrefix(keys.NotificationsTableID)}
+	tableSpan.EndKey = tableSpan.Key.PrefixEnd()
+	ts := func(nanos int64) hlc.Timestamp { return hlc.Timestamp{WallTime: nanos} }
+	a := Notification{Channel: "c", Payload: "a", PID: 1}
+	b := Notification{Channel: "c", Payload: "b", PID: 1}
+
+	var startFroms []hlc.Timestamp
+	rangeFeed := func(
+		ctx context.Context,
+		span roachpb.Span,
+		startFrom hlc.Timestamp,
+		withDiff bool,
+		eventC chan<- *roachpb.RangeFeedEvent,
+	) error {
+		startFroms = append(startFroms, startFrom)
+		var events []*roachpb.RangeFeedEvent
+		if len(startFroms) == 1 {
+			events = []*roachpb.RangeFeedEvent{
+				makeNotificationEvent(1, a, ts(120)),
+				{Checkpoint: &roachpb.RangeFeedCheckpoint{Span: tableSpan, ResolvedTS: ts(110)}},
+			}
+		} else {
+			// The restarted rangefeed emits a again, since it was written above
+			// the resolved timestamp.
+			events = []*roachpb.RangeFeedEvent{
+				makeNotificationEvent(1, a, ts(120)),
+				makeNotificationEvent(2, b, ts(130)),
+				{Checkpoint: &roachpb.RangeFeedCheckpoint{Span: tableSpan, ResolvedTS: ts(140)}},
+			}
+		}
+		for _, e := range events {
+			select {
+			case eventC <- e:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if len(startFroms) == 1 {
+			// Fail once the events were consumed, so that the checkpoint is
+			// taken into account when the rangefeed restarts.
+			for len(eventC) > 0 {
+				time.Sleep(time.Millisecond)
+			}
+			return errors.New("injected rangefeed error")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	clock := hlc.NewClock(hlc.NewManualClock(100).UnixNano, 0 /* maxOffset */)
+	r := NewRegistry(cluster.MakeTestingClusterSettings(), keys.SystemSQLCodec, clock, nil, rangeFeed, nil)
+	l := &testListener{notifications: make(chan Notification, 10)}
+	r.Listen("c", l)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.watchNotifications(ctx)
+	}()
+	require.Equal(t, a, <-l.notifications)
+	require.Equal(t, b, <-l.notifications)
+	cancel()
+	<-done
+
+	select {
+	case n := <-l.notifications:
+		t.Fatalf("unexpected notification %+v", n)
+	default:
+	}
+	require.Equal(t, []hlc.Timestamp{ts(100), ts(110)}, startFroms)
+	require.Equal(t, int64(2), r.Metrics().Delivered.Count())
+}
+
+// TestRegistryLaggingRangeFeed checks that a rangefeed that lags behind the
+// deletion of expired notifications still delivers them, and that a rangefeed
+// that lags behind the GC threshold of the table skips ahead of it.
+func TestRegistryLaggingRangeFeed(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tableSpan := roachpb.Span{Key: keys.SystemSQLCodec.TablePrefix(keys.NotificationsTableID)}
+	tableSpan.EndKey = tableSpan.Key.PrefixEnd()
+	ts := func(nanos int64) hlc.Timestamp { return hlc.Timestamp{WallTime: nanos} }
+	a := Notification{Channel: "c", Payload: "a", PID: 1}
+	c := Notification{Channel: "c", Payload: "c", PID: 1}
+
+	var startFroms []hlc.Timestamp
+	rangeFeed := func(
+		ctx context.Context,
+		span roachpb.Span,
+		startFrom hlc.Timestamp,
+		withDiff bool,
+		eventC chan<- *roachpb.RangeFeedEvent,
+	) error {
+		startFroms = append(startFroms, startFrom)
+		var events []*roachpb.RangeFeedEvent
+		switch len(startFroms) {
+		case 1:
+			// The catch-up scan emits a, followed by its deletion by the cleanup
+			// loop, which ran before the rangefeed got to it.
+			deleted := makeNotificationEvent(1, a, ts(130))
+			deleted.Val.Value.RawBytes = nil
+			events = []*roachpb.RangeFeedEvent{
+				makeNotificationEvent(1, a, ts(120)),
+				deleted,
+				{Checkpoint: &roachpb.RangeFeedCheckpoint{Span: tableSpan, ResolvedTS: ts(140)}},
+			}
+		case 2:
+			// The rangefeed restarted after the table was garbage collected up
+			// to ts 200. The notifications written since ts 140 are lost.
+			return &roachpb.BatchTimestampBeforeGCError{Timestamp: startFrom, Threshold: ts(200)}
+		default:
+			events = []*roachpb.RangeFeedEvent{
+				makeNotificationEvent(3, c, ts(210)),
+			}
+		}
+		for _, e := range events {
+			select {
+			case eventC <- e:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if len(startFroms) == 1 {
+			// Fail once the events were consumed, so that the checkpoint is
+			// taken into account when the rangefeed restarts.
+			for len(eventC) > 0 {
+				time.Sleep(time.Millisecond)
+			}
+			return errors.New("injected rangefeed error")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	clock := hlc.NewClock(hlc.NewManualClock(100).UnixNano, 0 /* maxOffset */)
+	r := NewRegistry(cluster.MakeTestingClusterSettings(), keys.SystemSQLCodec, clock, nil, rangeFeed, nil)
+	l := &testListener{notifications: make(chan Notification, 10)}
+	r.Listen("c", l)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.watchNotifications(ctx)
+	}()
+	require.Equal(t, a, <-l.notifications)
+	require.Equal(t, c, <-l.notifications)
+	cancel()
+	<-done
+
+	select {
+	case n := <-l.notifications:
+		t.Fatalf("unexpected notification %+v", n)
+	default:
+	}
+	require.Equal(t, []hlc.Timestamp{ts(100), ts(140), ts(200).Next()}, startFroms)
+}
+
+// TestRegistryDroppedNotifications checks that the notifications dropped by a
+// listener are counted.
+func TestRegistryDroppedNotifications(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	clock := hlc.NewClock(hlc.NewManualClock(100).UnixNano, 0 /* maxOffset */)
+	r := NewRegistry(cluster.MakeTestingClusterSettings(), keys.SystemSQLCodec, clock, nil, nil, nil)
+	ok := &testListener{notifications: make(chan Notification, 10)}
+	full := &testListener{drop: true}
+	r.Listen("c", ok)
+	r.Listen("c", full)
+	r.Listen("d", full)
+
+	r.dispatch(context.Background(), Notification{Channel: "c"})
+	r.dispatch(context.Background(), Notification{Channel: "d"})
+	require.Equal(t, int64(1), r.Metrics().Delivered.Count())
+	require.Equal(t, int64(2), r.Metrics().Dropped.Count())
+}
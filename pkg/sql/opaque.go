@@ -79,30 +79,48 @@ func buildOpaque(
 		plan, err = p.CreateRole(ctx, n)
 	case *tree.CreateSequence:
 		plan, err = p.CreateSequence(ctx, n)
+	case *tree.CloseCursor:
+		plan, err = p.CloseCursor(ctx, n)
 	case *tree.CreateStats:
 		plan, err = p.CreateStatistics(ctx, n)
+	case *tree.CreateTrigger:
+		plan, err = p.CreateTrigger(ctx, n)
 	case *tree.Deallocate:
 		plan, err = p.Deallocate(ctx, n)
+	case *tree.DeclareCursor:
+		plan, err = p.DeclareCursor(ctx, n)
 	case *tree.Discard:
 		plan, err = p.Discard(ctx, n)
 	case *tree.DropDatabase:
 		plan, err = p.DropDatabase(ctx, n)
+	case *tree.DropFunction:
+		plan, err = p.DropFunction(ctx, n)
 	case *tree.DropIndex:
 		plan, err = p.DropIndex(ctx, n)
 	case *tree.DropRole:
 		plan, err = p.DropRole(ctx, n)
 	case *tree.DropTable:
 		plan, err = p.DropTable(ctx, n)
+	case *tree.DropTrigger:
+		plan, err = p.DropTrigger(ctx, n)
 	case *tree.DropType:
 		plan, err = p.DropType(ctx, n)
 	case *tree.DropView:
 		plan, err = p.DropView(ctx, n)
 	case *tree.DropSequence:
 		plan, err = p.DropSequence(ctx, n)
+	case *tree.FetchCursor:
+		plan, err = p.FetchCursor(ctx, n)
 	case *tree.Grant:
 		plan, err = p.Grant(ctx, n)
 	case *tree.GrantRole:
 		plan, err = p.GrantRole(ctx, n)
+	case *tree.Listen:
+		plan, err = p.Listen(ctx, n)
+	case *tree.MoveCursor:
+		plan, err = p.MoveCursor(ctx, n)
+	case *tree.Notify:
+		plan, err = p.Notify(ctx, n)
 	case *tree.RenameColumn:
 		plan, err = p.RenameColumn(ctx, n)
 	case *tree.RenameDatabase:
@@ -125,6 +143,8 @@ func buildOpaque(
 		plan, err = p.SetZoneConfig(ctx, n)
 	case *tree.SetVar:
 		plan, err = p.SetVar(ctx, n)
+	case *tree.SetConstraints:
+		plan, err = p.SetConstraints(ctx, n)
 	case *tree.SetTransaction:
 		plan, err = p.SetTransaction(ctx, n)
 	case *tree.SetSessionAuthorizationDefault:
@@ -145,6 +165,8 @@ func buildOpaque(
 		plan, err = p.ShowFingerprints(ctx, n)
 	case *tree.Truncate:
 		plan, err = p.Truncate(ctx, n)
+	case *tree.Unlisten:
+		plan, err = p.Unlisten(ctx, n)
 	case tree.CCLOnlyStatement:
 		plan, err = p.maybePlanHook(ctx, stmt)
 		if plan == nil && err == nil {
@@ -172,6 +194,7 @@ func init() {
 		&tree.AlterSequence{},
 		&tree.AlterRole{},
 		&tree.Analyze{},
+		&tree.CloseCursor{},
 		&tree.CommentOnColumn{},
 		&tree.CommentOnDatabase{},
 		&tree.CommentOnIndex{},
@@ -181,19 +204,27 @@ func init() {
 		&tree.CreateSchema{},
 		&tree.CreateSequence{},
 		&tree.CreateStats{},
+		&tree.CreateTrigger{},
 		&tree.CreateType{},
 		&tree.CreateRole{},
 		&tree.Deallocate{},
+		&tree.DeclareCursor{},
 		&tree.Discard{},
 		&tree.DropDatabase{},
+		&tree.DropFunction{},
 		&tree.DropIndex{},
 		&tree.DropTable{},
+		&tree.DropTrigger{},
 		&tree.DropType{},
 		&tree.DropView{},
 		&tree.DropRole{},
 		&tree.DropSequence{},
+		&tree.FetchCursor{},
 		&tree.Grant{},
 		&tree.GrantRole{},
+		&tree.Listen{},
+		&tree.MoveCursor{},
+		&tree.Notify{},
 		&tree.RenameColumn{},
 		&tree.RenameDatabase{},
 		&tree.RenameIndex{},
@@ -205,6 +236,7 @@ func init() {
 		&tree.SetClusterSetting{},
 		&tree.SetZoneConfig{},
 		&tree.SetVar{},
+		&tree.SetConstraints{},
 		&tree.SetTransaction{},
 		&tree.SetSessionAuthorizationDefault{},
 		&tree.SetSessionCharacteristics{},
@@ -215,6 +247,7 @@ func init() {
 		&tree.ShowZoneConfig{},
 		&tree.ShowFingerprints{},
 		&tree.Truncate{},
+		&tree.Unlisten{},
 
 		// CCL statements (without Export which has an optimizer operator).
 		&tree.Backup{},
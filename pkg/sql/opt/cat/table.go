@@ -126,6 +126,13 @@ type Table interface {
 
 	// InboundForeignKey returns the ith inbound foreign key reference.
 	InboundForeignKey(i int) ForeignKeyConstraint
+
+	// TriggerCount returns the number of triggers defined on the table.
+	TriggerCount() int
+
+	// Trigger returns the ith trigger, where i < TriggerCount. Triggers are
+	// returned in the order in which they fire.
+	Trigger(i int) Trigger
 }
 
 // CheckConstraint contains the SQL text and the validity status for a check
@@ -140,6 +147,31 @@ type CheckConstraint struct {
 	Validated  bool
 }
 
+// Trigger contains the definition of a trigger on a table. A trigger invokes
+// a user-defined function when rows of the table are inserted, updated or
+// deleted. For example, this trigger calls f with the new value of column a
+// for each row inserted into the table, before the row is inserted:
+//
+//   CREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW EXECUTE FUNCTION f(new.a)
+//
+type Trigger struct {
+	Name       string
+	ActionTime tree.TriggerActionTime
+	Events     tree.TriggerEvents
+	// ForEachRow is true for row-level triggers, and false for statement-level
+	// triggers.
+	ForEachRow bool
+	// When is the SQL text of the condition under which the trigger fires, or
+	// the empty string if the trigger always fires.
+	When string
+	// Function is the fully qualified name of the function.
+	Function string
+	// Args contains the SQL text of the arguments of the function. Like When,
+	// they can reference the columns of the new and old rows as new.<column>
+	// and old.<column>.
+	Args []string
+}
+
 // TableStatistic is an interface to a table statistic. Each statistic is
 // associated with a set of columns.
 type TableStatistic interface {
@@ -239,4 +271,12 @@ type ForeignKeyConstraint interface {
 	// UpdateReferenceAction returns the action to be performed if the foreign key
 	// constraint would be violated by an update.
 	UpdateReferenceAction() tree.ReferenceAction
+
+	// Deferrable is true if the constraint can be checked when the transaction
+	// commits instead of after each statement (see SET CONSTRAINTS).
+	Deferrable() bool
+
+	// InitiallyDeferred is true if the constraint is deferrable and is checked
+	// when the transaction commits unless SET CONSTRAINTS says otherwise.
+	InitiallyDeferred() bool
 }
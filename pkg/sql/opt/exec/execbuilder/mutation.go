@@ -142,16 +142,25 @@ func (b *Builder) tryBuildFastPathInsert(ins *memo.InsertExpr) (_ execPlan, ok b
 	md := b.mem.Metadata()
 	tab := md.Table(ins.Table)
 
-	//  - there are no self-referencing foreign keys;
+	//  - there are no self-referencing or deferrable foreign keys, and no AFTER
+	//    triggers;
 	//  - all FK checks can be performed using direct lookups into unique indexes.
 	fkChecks := make([]exec.InsertFastPathFKCheck, len(ins.Checks))
 	for i := range ins.Checks {
 		c := &ins.Checks[i]
+		if c.TriggerName != "" {
+			// AFTER trigger.
+			return execPlan{}, false, nil
+		}
 		if md.Table(c.ReferencedTable).ID() == md.Table(ins.Table).ID() {
 			// Self-referencing FK.
 			return execPlan{}, false, nil
 		}
 		fk := tab.OutboundForeignKey(c.FKOrdinal)
+		if fk.Deferrable() {
+			// The check may have to be deferred until the transaction commits.
+			return execPlan{}, false, nil
+		}
 		lookupJoin, isLookupJoin := c.Check.(*memo.LookupJoinExpr)
 		if !isLookupJoin || lookupJoin.JoinType != opt.AntiJoinOp {
 			// Not a lookup anti-join.
@@ -493,6 +502,13 @@ func (b *Builder) tryBuildDeleteRange(del *memo.DeleteExpr) (_ execPlan, ok bool
 		return execPlan{}, false, nil
 	}
 
+	// AFTER triggers need the values of the deleted rows.
+	for i := range del.Checks {
+		if del.Checks[i].TriggerName != "" {
+			return execPlan{}, false, nil
+		}
+	}
+
 	tab := b.mem.Metadata().Table(del.Table)
 	if tab.DeletableIndexCount() > 1 {
 		// Any secondary index prevents fast path, because separate delete batches
@@ -735,6 +751,15 @@ func (b *Builder) buildFKChecks(checks memo.FKChecksExpr) error {
 		if err != nil {
 			return err
 		}
+		if c.TriggerName != "" {
+			// The query runs an AFTER trigger; its rows are discarded.
+			node, err := b.factory.ConstructErrorIfRows(query.root, nil /* mkErr */, nil /* deferrable */)
+			if err != nil {
+				return err
+			}
+			b.checks = append(b.checks, node)
+			continue
+		}
 		// Wrap the query in an error node.
 		mkErr := func(row tree.Datums) error {
 			keyVals := make(tree.Datums, len(c.KeyCols))
@@ -743,7 +768,7 @@ func (b *Builder) buildFKChecks(checks memo.FKChecksExpr) error {
 			}
 			return mkFKCheckErr(md, c, keyVals)
 		}
-		node, err := b.factory.ConstructErrorIfRows(query.root, mkErr)
+		node, err := b.factory.ConstructErrorIfRows(query.root, mkErr, b.deferrableFKCheck(c, query))
 		if err != nil {
 			return err
 		}
@@ -752,6 +777,50 @@ func (b *Builder) buildFKChecks(checks memo.FKChecksExpr) error {
 	return nil
 }
 
+// deferrableFKCheck returns the information needed to defer the given check
+// until the transaction commits, or nil if the check cannot be deferred. Like
+// in Postgres, checks of constraints that are not DEFERRABLE, and checks that
+// enforce a RESTRICT action, cannot be deferred.
+func (b *Builder) deferrableFKCheck(
+	c *memo.FKChecksItem, query execPlan,
+) *exec.DeferrableFKCheck {
+	md := b.mem.Metadata()
+	origin := md.Table(c.OriginTable)
+	referenced := md.Table(c.ReferencedTable)
+	var fk cat.ForeignKeyConstraint
+	if c.FKOutbound {
+		fk = origin.OutboundForeignKey(c.FKOrdinal)
+	} else {
+		fk = referenced.InboundForeignKey(c.FKOrdinal)
+		action := fk.UpdateReferenceAction()
+		if c.OpName == "delete" {
+			action = fk.DeleteReferenceAction()
+		}
+		if action == tree.Restrict {
+			return nil
+		}
+	}
+	if !fk.Deferrable() {
+		return nil
+	}
+	res := &exec.DeferrableFKCheck{
+		InitiallyDeferred: fk.InitiallyDeferred(),
+		OriginTable:       origin,
+		ReferencedTable:   referenced,
+		OriginColumns:     make([]int, fk.ColumnCount()),
+		ReferencedColumns: make([]int, fk.ColumnCount()),
+		KeyCols:           make([]exec.NodeColumnOrdinal, len(c.KeyCols)),
+	}
+	for i := range res.OriginColumns {
+		res.OriginColumns[i] = fk.OriginColumnOrdinal(origin, i)
+		res.ReferencedColumns[i] = fk.ReferencedColumnOrdinal(referenced, i)
+	}
+	for i, col := range c.KeyCols {
+		res.KeyCols[i] = query.getNodeColumnOrdinal(col)
+	}
+	return res
+}
+
 // mkFKCheckErr generates a user-friendly error describing a foreign key
 // violation. The keyVals are the values that correspond to the
 // cat.ForeignKeyConstraint columns.
@@ -74,6 +74,17 @@ func (b *Builder) buildCreateView(cv *memo.CreateViewExpr) (execPlan, error) {
 	return execPlan{root: root}, err
 }
 
+func (b *Builder) buildCreateFunction(cf *memo.CreateFunctionExpr) (execPlan, error) {
+	schema := b.mem.Metadata().Schema(cf.Schema)
+	root, err := b.factory.ConstructCreateFunction(
+		schema,
+		cf.Syntax.(*tree.CreateFunction),
+		cf.Body,
+		cf.Deps,
+	)
+	return execPlan{root: root}, err
+}
+
 func (b *Builder) buildExplainOpt(explain *memo.ExplainExpr) (execPlan, error) {
 	fmtFlags := memo.ExprFmtHideAll
 	switch {
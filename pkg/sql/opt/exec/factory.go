@@ -522,6 +522,15 @@ type Factory interface {
 		deps opt.ViewDeps,
 	) (Node, error)
 
+	// ConstructCreateFunction returns a node that implements a CREATE FUNCTION
+	// statement. The body of the function has fully qualified data sources.
+	ConstructCreateFunction(
+		schema cat.Schema,
+		cf *tree.CreateFunction,
+		body string,
+		deps opt.ViewDeps,
+	) (Node, error)
+
 	// ConstructSequenceSelect creates a node that implements a scan of a sequence
 	// as a data source.
 	ConstructSequenceSelect(sequence cat.Sequence) (Node, error)
@@ -532,8 +541,15 @@ type Factory interface {
 
 	// ConstructErrorIfRows wraps the input into a node which itself returns no
 	// results, but errors out if the input returns any rows. The mkErr function
-	// is used to create the error.
-	ConstructErrorIfRows(input Node, mkErr func(tree.Datums) error) (Node, error)
+	// is used to create the error. If mkErr is nil, the rows returned by the
+	// input are discarded instead; this is used to run AFTER triggers.
+	//
+	// If deferrable is non-nil, the input is a foreign key check for a
+	// DEFERRABLE constraint. When the constraint is deferred, the rows returned
+	// by the input are rechecked when the transaction commits instead.
+	ConstructErrorIfRows(
+		input Node, mkErr func(tree.Datums) error, deferrable *DeferrableFKCheck,
+	) (Node, error)
 
 	// ConstructOpaque creates a node for an opaque operator.
 	ConstructOpaque(metadata opt.OpaqueMetadata) (Node, error)
@@ -804,6 +820,29 @@ type Cascade struct {
 // insert fast path.
 const InsertFastPathMaxRows = 10000
 
+// DeferrableFKCheck contains information about a foreign key check for a
+// DEFERRABLE constraint (see ConstructErrorIfRows). The rows returned by the
+// check are potential violations of the constraint: when the constraint is
+// deferred, they are only reported if they still are violations when the
+// transaction commits.
+type DeferrableFKCheck struct {
+	// InitiallyDeferred is set if the constraint is deferred unless SET
+	// CONSTRAINTS says otherwise.
+	InitiallyDeferred bool
+
+	OriginTable     cat.Table
+	ReferencedTable cat.Table
+
+	// OriginColumns and ReferencedColumns contain the ordinals of the foreign
+	// key columns in OriginTable and ReferencedTable.
+	OriginColumns     []int
+	ReferencedColumns []int
+
+	// KeyCols contains, for each foreign key column, the ordinal of its value in
+	// the rows returned by the check.
+	KeyCols []NodeColumnOrdinal
+}
+
 // InsertFastPathFKCheck contains information about a foreign key check to be
 // performed by the insert fast-path (see ConstructInsertFastPath). It
 // identifies the index into which we can perform the lookup.
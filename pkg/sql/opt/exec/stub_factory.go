@@ -367,7 +367,9 @@ func (StubFactory) ConstructSaveTable(
 }
 
 // ConstructErrorIfRows is part of the exec.Factory interface.
-func (StubFactory) ConstructErrorIfRows(input Node, mkErr func(tree.Datums) error) (Node, error) {
+func (StubFactory) ConstructErrorIfRows(
+	input Node, mkErr func(tree.Datums) error, deferrable *DeferrableFKCheck,
+) (Node, error) {
 	return struct{}{}, nil
 }
 
@@ -453,6 +455,13 @@ func (StubFactory) ConstructCreateView(
 	return struct{}{}, nil
 }
 
+// ConstructCreateFunction is part of the exec.Factory interface.
+func (StubFactory) ConstructCreateFunction(
+	schema cat.Schema, cf *tree.CreateFunction, body string, deps opt.ViewDeps,
+) (Node, error) {
+	return struct{}{}, nil
+}
+
 // ConstructExport is part of the exec.Factory interface.
 func (StubFactory) ConstructExport(
 	input Node, fileName tree.TypedExpr, fileFormat string, options []KVOption,
@@ -207,7 +207,7 @@ func (f *ExprFmtCtx) formatRelational(e RelExpr, tp treeprinter.Node) {
 		*WindowExpr, *OpaqueRelExpr, *OpaqueMutationExpr, *OpaqueDDLExpr,
 		*AlterTableSplitExpr, *AlterTableUnsplitExpr, *AlterTableUnsplitAllExpr,
 		*AlterTableRelocateExpr, *ControlJobsExpr, *CancelQueriesExpr,
-		*CancelSessionsExpr, *CreateViewExpr, *ExportExpr:
+		*CancelSessionsExpr, *CreateViewExpr, *CreateFunctionExpr, *ExportExpr:
 		fmt.Fprintf(f.Buffer, "%v", e.Op())
 		FormatPrivate(f, e.Private(), required)
 
@@ -602,6 +602,15 @@ func (f *ExprFmtCtx) formatRelational(e RelExpr, tp treeprinter.Node) {
 			n.Child(f.Buffer.String())
 		}
 
+	case *CreateFunctionExpr:
+		tp.Child(t.Body)
+
+		n := tp.Child("dependencies")
+		for _, dep := range t.Deps {
+			name := dep.DataSource.Name()
+			n.Child(name.String())
+		}
+
 	case *ExportExpr:
 		tp.Childf("format: %s", t.FileFormat)
 
@@ -996,6 +1005,10 @@ func (f *ExprFmtCtx) formatScalarPrivate(scalar opt.ScalarExpr) {
 		fmt.Fprintf(f.Buffer, " %s", t.Key)
 
 	case *FKChecksItem:
+		if t.TriggerName != "" {
+			fmt.Fprintf(f.Buffer, ": trigger %s", t.TriggerName)
+			break
+		}
 		origin := f.Memo.metadata.TableMeta(t.OriginTable)
 		referenced := f.Memo.metadata.TableMeta(t.ReferencedTable)
 		var fk cat.ForeignKeyConstraint
@@ -1335,6 +1348,10 @@ func FormatPrivate(f *ExprFmtCtx, private interface{}, physProps *physical.Requi
 		schema := f.Memo.Metadata().Schema(t.Schema)
 		fmt.Fprintf(f.Buffer, " %s.%s", schema.Name(), t.ViewName)
 
+	case *CreateFunctionPrivate:
+		schema := f.Memo.Metadata().Schema(t.Schema)
+		fmt.Fprintf(f.Buffer, " %s.%s", schema.Name(), t.Syntax.(*tree.CreateFunction).Name.Object())
+
 	case *JoinPrivate:
 		// Nothing to show; flags are shown separately.
 
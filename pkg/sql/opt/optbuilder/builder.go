@@ -235,6 +235,7 @@ func (b *Builder) buildStmt(
 		// A blocklist of statements that can't be used from inside a view.
 		switch stmt := stmt.(type) {
 		case *tree.Delete, *tree.Insert, *tree.Update, *tree.CreateTable, *tree.CreateView,
+			*tree.CreateFunction,
 			*tree.Split, *tree.Unsplit, *tree.Relocate,
 			*tree.ControlJobs, *tree.ControlSchedules, *tree.CancelQueries, *tree.CancelSessions:
 			panic(pgerror.Newf(
@@ -271,6 +272,9 @@ func (b *Builder) buildStmt(
 	case *tree.CreateView:
 		return b.buildCreateView(stmt, inScope)
 
+	case *tree.CreateFunction:
+		return b.buildCreateFunction(stmt, inScope)
+
 	case *tree.Explain:
 		return b.buildExplain(stmt, inScope)
 
@@ -0,0 +1,120 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package optbuilder
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
+	"github.com/cockroachdb/errors"
+)
+
+func (b *Builder) buildCreateFunction(cf *tree.CreateFunction, inScope *scope) (outScope *scope) {
+	b.DisableMemoReuse = true
+	sch, _ := b.resolveSchemaForCreate(&cf.Name)
+	schID := b.factory.Metadata().AddSchema(sch)
+
+	params := make([]sqlbase.FunctionDescriptor_Param, len(cf.Params))
+	paramTypes := make(tree.PlaceholderTypes, len(cf.Params))
+	for i := range cf.Params {
+		typ := b.resolveFunctionType(cf.Params[i].Type)
+		params[i] = sqlbase.FunctionDescriptor_Param{Name: string(cf.Params[i].Name), Type: typ}
+		paramTypes[i] = typ
+	}
+	returnType := b.resolveFunctionType(cf.ReturnType)
+
+	stmt, err := parser.ParseOne(cf.Body())
+	if err != nil {
+		panic(pgerror.Wrap(err, pgcode.InvalidFunctionDefinition, "invalid function body"))
+	}
+	body, ok := stmt.AST.(*tree.Select)
+	if !ok {
+		panic(pgerror.Newf(pgcode.InvalidFunctionDefinition,
+			"function body must be a SELECT statement, found %s", stmt.AST.StatementTag()))
+	}
+	if stmt.NumPlaceholders > len(params) {
+		panic(pgerror.Newf(pgcode.UndefinedParameter,
+			"there is no parameter $%d", stmt.NumPlaceholders))
+	}
+
+	// We build the query that evaluates the body to:
+	//  - check the body semantically,
+	//  - get the fully resolved names into the AST of the body, and
+	//  - collect the dependencies of the function in b.viewDeps.
+	// The arguments of the function are represented by placeholders with the
+	// types of the parameters. The result is not otherwise used.
+	b.insideViewDef = true
+	b.trackViewDeps = true
+	b.qualifyDataSourceNamesInAST = true
+	b.KeepPlaceholders = true
+	defer func(placeholders tree.PlaceholderInfo, keepPlaceholders bool) {
+		b.insideViewDef = false
+		b.trackViewDeps = false
+		b.viewDeps = nil
+		b.qualifyDataSourceNamesInAST = false
+		b.KeepPlaceholders = keepPlaceholders
+		b.semaCtx.Placeholders = placeholders
+	}(b.semaCtx.Placeholders, b.KeepPlaceholders)
+	if err := b.semaCtx.Placeholders.Init(len(params), paramTypes); err != nil {
+		panic(err)
+	}
+
+	b.pushWithFrame()
+	defScope := b.buildStmtAtRoot(sqlbase.MakeFunctionQuery(params, body), nil /* desiredTypes */, inScope)
+	b.popWithFrame(defScope)
+
+	p := defScope.makePhysicalProps().Presentation
+	if len(p) != 1 {
+		panic(errors.WithDetail(
+			pgerror.Newf(pgcode.InvalidFunctionDefinition,
+				"return type mismatch in function declared to return %s", returnType.SQLString()),
+			"Function body must return exactly one column.",
+		))
+	}
+	colType := b.factory.Metadata().ColumnMeta(p[0].ID).Type
+	if colType.Family() != types.UnknownFamily && !colType.Equivalent(returnType) {
+		panic(errors.WithDetailf(
+			pgerror.Newf(pgcode.InvalidFunctionDefinition,
+				"return type mismatch in function declared to return %s", returnType.SQLString()),
+			"Actual return type is %s.", colType.SQLString(),
+		))
+	}
+
+	outScope = b.allocScope()
+	outScope.expr = b.factory.ConstructCreateFunction(
+		&memo.CreateFunctionPrivate{
+			Schema: schID,
+			Syntax: cf,
+			Body:   tree.AsStringWithFlags(body, tree.FmtParsable),
+			Deps:   b.viewDeps,
+		},
+	)
+	return outScope
+}
+
+// resolveFunctionType resolves the type of a parameter or of the result of a
+// user-defined function.
+func (b *Builder) resolveFunctionType(ref tree.ResolvableTypeReference) *types.T {
+	typ, err := tree.ResolveType(b.ctx, ref, b.semaCtx.GetTypeResolver())
+	if err != nil {
+		panic(err)
+	}
+	if typ.UserDefined() {
+		panic(unimplemented.NewWithIssuef(17511,
+			"user-defined type %s cannot be used in a function signature", typ.SQLString()))
+	}
+	return typ
+}
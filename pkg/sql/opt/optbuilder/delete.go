@@ -74,10 +74,15 @@ func (b *Builder) buildDelete(del *tree.Delete, inScope *scope) (outScope *scope
 // buildDelete constructs a Delete operator, possibly wrapped by a Project
 // operator that corresponds to the given RETURNING clause.
 func (mb *mutationBuilder) buildDelete(returning tree.ReturningExprs) {
+	// Fire the BEFORE row-level triggers, which can skip rows.
+	mb.buildBeforeRowTriggers(tree.TriggerEventDelete)
+
 	mb.buildFKChecksAndCascadesForDelete()
+	mb.buildAfterTriggers(tree.TriggerEventDelete)
 
 	private := mb.makeMutationPrivate(returning != nil)
 	mb.outScope.expr = mb.b.factory.ConstructDelete(mb.outScope.expr, mb.checks, private)
+	mb.buildBeforeStatementTriggers(tree.TriggerEventDelete)
 
 	mb.buildReturning(returning)
 }
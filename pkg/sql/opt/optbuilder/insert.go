@@ -24,6 +24,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
 	"github.com/cockroachdb/errors"
 )
 
@@ -641,6 +642,9 @@ func (mb *mutationBuilder) buildInsert(returning tree.ReturningExprs) {
 	// check constraint, refer to the correct columns.
 	mb.disambiguateColumns()
 
+	// Fire the BEFORE row-level triggers, which can skip rows.
+	mb.buildBeforeRowTriggers(tree.TriggerEventInsert)
+
 	// Keep a reference to the scope before the check constraint columns are
 	// projected. We use this scope when projecting the partial index put
 	// columns because the check columns are not in-scope for those expressions.
@@ -653,9 +657,11 @@ func (mb *mutationBuilder) buildInsert(returning tree.ReturningExprs) {
 	mb.projectPartialIndexPutCols(preCheckScope)
 
 	mb.buildFKChecksForInsert()
+	mb.buildAfterTriggers(tree.TriggerEventInsert)
 
 	private := mb.makeMutationPrivate(returning != nil)
 	mb.outScope.expr = mb.b.factory.ConstructInsert(mb.outScope.expr, mb.checks, private)
+	mb.buildBeforeStatementTriggers(tree.TriggerEventInsert)
 
 	mb.buildReturning(returning)
 }
@@ -942,6 +948,11 @@ func (mb *mutationBuilder) setUpsertCols(insertCols tree.NameList) {
 // buildUpsert constructs an Upsert operator, possibly wrapped by a Project
 // operator that corresponds to the given RETURNING clause.
 func (mb *mutationBuilder) buildUpsert(returning tree.ReturningExprs) {
+	if mb.tab.TriggerCount() > 0 {
+		panic(unimplemented.NewWithIssuef(28296,
+			"UPSERT and INSERT ... ON CONFLICT DO UPDATE are not supported on tables with triggers"))
+	}
+
 	// Merge input insert and update columns using CASE expressions.
 	mb.projectUpsertColumns()
 
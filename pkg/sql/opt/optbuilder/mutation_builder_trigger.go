@@ -0,0 +1,324 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package optbuilder
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/opt"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/cat"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// This file contains methods that plan the triggers of the mutated table. A
+// trigger calls a user-defined function, either once per statement or once
+// per modified row, and either before or after the mutation. The triggers of
+// each kind fire in the order of their names.
+//
+// -- BEFORE row-level triggers --
+//
+// BEFORE row-level triggers are planned as a projection of the function call
+// on the mutation input, followed by a filter that discards the rows for
+// which the function returned NULL (or false, for a BOOL function). The
+// columns of the new and old rows are available to the arguments of the call
+// and to the WHEN condition as NEW.<colname> and OLD.<colname>:
+//
+//   insert t
+//    └── select
+//         ├── project
+//         │    ├── values
+//         │    └── projections
+//         │         └── CASE WHEN new.a > 0 THEN f(new.a) IS NOT NULL ELSE true END
+//         └── filters
+//              └── column5
+//
+// -- BEFORE statement-level triggers --
+//
+// BEFORE statement-level triggers are planned as materialized With bindings
+// around the mutation, which are run before the main query.
+//
+// -- AFTER triggers --
+//
+// AFTER triggers are planned like foreign key checks, as queries that run
+// after the statement completes. The query of a row-level trigger scans the
+// buffered mutation input; the query of a statement-level trigger has a
+// single row. The rows returned by the queries are discarded.
+//
+// Triggers compose with cascades: the cascading mutations are planned by the
+// same methods, so the triggers of the child tables fire for the rows modified
+// by a cascade.
+//
+// Unlike in Postgres, the result of a BEFORE row-level trigger function only
+// decides whether the row is skipped; it cannot replace the new row. UPSERT
+// and INSERT ... ON CONFLICT DO UPDATE are rejected on tables with triggers
+// (see buildUpsert), and INSERT ... ON CONFLICT DO NOTHING only fires the
+// INSERT triggers for the rows that do not conflict.
+
+// triggerNew and triggerOld are the names under which the columns of the new
+// and old rows are available to row-level triggers.
+var (
+	triggerNew = tree.MakeUnqualifiedTableName("new")
+	triggerOld = tree.MakeUnqualifiedTableName("old")
+)
+
+// buildBeforeRowTriggers adds a projection and a filter to the mutation input
+// for each BEFORE row-level trigger that fires for the given event. Rows for
+// which a trigger function returns NULL (or false) are skipped, and do not
+// fire the triggers that follow.
+func (mb *mutationBuilder) buildBeforeRowTriggers(event tree.TriggerEvents) {
+	for i, n := 0, mb.tab.TriggerCount(); i < n; i++ {
+		t := mb.tab.Trigger(i)
+		if t.ActionTime != tree.TriggerBefore || !t.ForEachRow || !t.Events.Contains(event) {
+			continue
+		}
+		mb.b.DisableMemoReuse = true
+
+		newCols, oldCols := mb.triggerCols(event)
+		triggerScope := mb.buildTriggerScope(newCols, oldCols)
+
+		call := triggerScope.resolveType(parseTriggerCall(&t), types.Any)
+		var cond tree.TypedExpr
+		if call.ResolvedType().Family() == types.BoolFamily {
+			cond = tree.NewTypedComparisonExpr(tree.IsNotDistinctFrom, call, tree.DBoolTrue)
+		} else {
+			cond = tree.NewTypedIsNotNullExpr(call)
+		}
+		if when := parseTriggerWhen(&t); when != nil {
+			// The function is only called for the rows that satisfy the WHEN
+			// condition; the other rows are not skipped.
+			var err error
+			cond, err = tree.NewTypedCaseExpr(
+				nil, /* expr */
+				[]*tree.When{{Cond: triggerScope.resolveAndRequireType(when, types.Bool), Val: cond}},
+				tree.DBoolTrue,
+				types.Bool,
+			)
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		projectionsScope := mb.outScope.replace()
+		projectionsScope.appendColumnsFromScope(mb.outScope)
+		scopeCol := mb.b.addColumn(projectionsScope, "", cond)
+		mb.b.buildScalar(cond, triggerScope, projectionsScope, scopeCol, nil)
+		mb.b.constructProjectForScope(mb.outScope, projectionsScope)
+		mb.outScope = projectionsScope
+
+		f := mb.b.factory
+		mb.outScope.expr = f.ConstructSelect(
+			mb.outScope.expr,
+			memo.FiltersExpr{f.ConstructFiltersItem(f.ConstructVariable(scopeCol.id))},
+		)
+	}
+}
+
+// buildBeforeStatementTriggers wraps the mutation in a materialized With
+// binding for each BEFORE statement-level trigger that fires for the given
+// event. The bindings are not referenced; they only ensure that the trigger
+// functions are called before the mutation runs.
+func (mb *mutationBuilder) buildBeforeStatementTriggers(event tree.TriggerEvents) {
+	// Wrap in reverse order, so that the first trigger is the outermost
+	// binding and fires first.
+	for i := mb.tab.TriggerCount() - 1; i >= 0; i-- {
+		t := mb.tab.Trigger(i)
+		if t.ActionTime != tree.TriggerBefore || t.ForEachRow || !t.Events.Contains(event) {
+			continue
+		}
+		mb.b.DisableMemoReuse = true
+
+		binding := mb.buildTriggerQuery(&t, mb.buildSingleRow(), mb.b.allocScope())
+		mb.outScope.expr = mb.b.factory.ConstructWith(
+			binding,
+			mb.outScope.expr,
+			&memo.WithPrivate{
+				ID:   mb.b.factory.Memo().NextWithID(),
+				Name: t.Name,
+				Mtr:  tree.MaterializeClause{Set: true, Materialize: true},
+			},
+		)
+	}
+}
+
+// buildAfterTriggers adds a query to mb.checks for each AFTER trigger that
+// fires for the given event. It must be called after the foreign key checks
+// are built, right before the mutation is constructed.
+func (mb *mutationBuilder) buildAfterTriggers(event tree.TriggerEvents) {
+	for i, n := 0, mb.tab.TriggerCount(); i < n; i++ {
+		t := mb.tab.Trigger(i)
+		if t.ActionTime != tree.TriggerAfter || !t.Events.Contains(event) {
+			continue
+		}
+		mb.b.DisableMemoReuse = true
+
+		var input memo.RelExpr
+		triggerScope := mb.b.allocScope()
+		if t.ForEachRow {
+			newCols, oldCols := mb.triggerCols(event)
+			input, newCols, oldCols = mb.buildTriggerInputScan(newCols, oldCols)
+			triggerScope = mb.buildTriggerScope(newCols, oldCols)
+		} else {
+			input = mb.buildSingleRow()
+		}
+
+		query := mb.buildTriggerQuery(&t, input, triggerScope)
+		mb.checks = append(mb.checks, mb.b.factory.ConstructFKChecksItem(query, &memo.FKChecksItemPrivate{
+			OriginTable:     mb.tabID,
+			ReferencedTable: mb.tabID,
+			OpName:          mb.opName,
+			TriggerName:     t.Name,
+		}))
+	}
+}
+
+// triggerCols returns the columns of the mutation input that contain the new
+// and old values of the table columns, for the given event. The lists are nil
+// when the event has no new or old rows.
+func (mb *mutationBuilder) triggerCols(event tree.TriggerEvents) (newCols, oldCols opt.ColList) {
+	switch event {
+	case tree.TriggerEventInsert:
+		newCols = mb.insertColIDs
+
+	case tree.TriggerEventUpdate:
+		newCols = make(opt.ColList, len(mb.fetchColIDs))
+		for i := range newCols {
+			newCols[i] = mb.mapToReturnColID(i)
+		}
+		oldCols = mb.fetchColIDs
+
+	case tree.TriggerEventDelete:
+		oldCols = mb.fetchColIDs
+	}
+	return newCols, oldCols
+}
+
+// buildTriggerScope returns a scope in which the table columns are available
+// as NEW.<colname> and OLD.<colname>, using the given columns.
+func (mb *mutationBuilder) buildTriggerScope(newCols, oldCols opt.ColList) *scope {
+	s := mb.b.allocScope()
+	add := func(cols opt.ColList, table tree.TableName) {
+		for i, id := range cols {
+			if id == 0 || mb.tab.ColumnKind(i) != cat.Ordinary {
+				continue
+			}
+			s.cols = append(s.cols, scopeColumn{
+				name:  mb.tab.Column(i).ColName(),
+				table: table,
+				typ:   mb.md.ColumnMeta(id).Type,
+				id:    id,
+			})
+		}
+	}
+	add(newCols, triggerNew)
+	add(oldCols, triggerOld)
+	return s
+}
+
+// buildTriggerInputScan builds a WithScan of the buffered mutation input that
+// returns the given new and old columns (or rather, copies of them with new
+// IDs, which are returned).
+func (mb *mutationBuilder) buildTriggerInputScan(
+	newCols, oldCols opt.ColList,
+) (scan memo.RelExpr, newOutCols, oldOutCols opt.ColList) {
+	if mb.withID == 0 {
+		mb.withID = mb.b.factory.Memo().NextWithID()
+		mb.md.AddWithBinding(mb.withID, mb.outScope.expr)
+	}
+
+	var inCols, outCols opt.ColList
+	mapCols := func(cols opt.ColList) opt.ColList {
+		if cols == nil {
+			return nil
+		}
+		res := make(opt.ColList, len(cols))
+		for i, id := range cols {
+			if id == 0 || mb.tab.ColumnKind(i) != cat.Ordinary {
+				continue
+			}
+			// The same input column can be both a new and an old column.
+			if ord, ok := inCols.Find(id); ok {
+				res[i] = outCols[ord]
+				continue
+			}
+			c := mb.md.ColumnMeta(id)
+			res[i] = mb.md.AddColumn(c.Alias, c.Type)
+			inCols = append(inCols, id)
+			outCols = append(outCols, res[i])
+		}
+		return res
+	}
+	newOutCols = mapCols(newCols)
+	oldOutCols = mapCols(oldCols)
+
+	scan = mb.b.factory.ConstructWithScan(&memo.WithScanPrivate{
+		With:    mb.withID,
+		InCols:  inCols,
+		OutCols: outCols,
+		ID:      mb.md.NextUniqueID(),
+	})
+	return scan, newOutCols, oldOutCols
+}
+
+// buildSingleRow builds a Values expression with a single row and no columns.
+func (mb *mutationBuilder) buildSingleRow() memo.RelExpr {
+	return mb.b.factory.ConstructValues(memo.ScalarListWithEmptyTuple, &memo.ValuesPrivate{
+		Cols: opt.ColList{},
+		ID:   mb.md.NextUniqueID(),
+	})
+}
+
+// buildTriggerQuery builds a query that calls the function of the given
+// trigger for each row of the input that satisfies the WHEN condition. The
+// columns of triggerScope are available to the condition and the arguments.
+func (mb *mutationBuilder) buildTriggerQuery(
+	t *cat.Trigger, input memo.RelExpr, triggerScope *scope,
+) memo.RelExpr {
+	f := mb.b.factory
+	triggerScope.expr = input
+	if when := parseTriggerWhen(t); when != nil {
+		texpr := triggerScope.resolveAndRequireType(when, types.Bool)
+		filter := mb.b.buildScalar(texpr, triggerScope, nil, nil, nil)
+		input = f.ConstructSelect(input, memo.FiltersExpr{f.ConstructFiltersItem(filter)})
+		triggerScope.expr = input
+	}
+
+	call := triggerScope.resolveType(parseTriggerCall(t), types.Any)
+	projectionsScope := triggerScope.replace()
+	scopeCol := mb.b.addColumn(projectionsScope, t.Name, call)
+	mb.b.buildScalar(call, triggerScope, projectionsScope, scopeCol, nil)
+	mb.b.constructProjectForScope(triggerScope, projectionsScope)
+	return projectionsScope.expr
+}
+
+// parseTriggerCall returns the call to the function of the given trigger.
+func parseTriggerCall(t *cat.Trigger) tree.Expr {
+	expr, err := parser.ParseExpr(t.Function + "(" + strings.Join(t.Args, ", ") + ")")
+	if err != nil {
+		panic(err)
+	}
+	return expr
+}
+
+// parseTriggerWhen returns the WHEN condition of the given trigger, or nil if
+// it has none.
+func parseTriggerWhen(t *cat.Trigger) tree.Expr {
+	if t.When == "" {
+		return nil
+	}
+	expr, err := parser.ParseExpr(t.When)
+	if err != nil {
+		panic(err)
+	}
+	return expr
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/opt"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/norm"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
@@ -484,7 +485,7 @@ func (b *Builder) buildFunction(
 		}
 	}
 
-	def, err := f.Func.Resolve(b.semaCtx.SearchPath)
+	def, err := f.Func.ResolveWith(b.ctx, b.semaCtx.SearchPath, b.semaCtx.FunctionResolver)
 	if err != nil {
 		panic(err)
 	}
@@ -497,6 +498,22 @@ func (b *Builder) buildFunction(
 		panic(errors.AssertionFailedf("window function should have been replaced"))
 	}
 
+	if f.ResolvedOverload().IsUDF() {
+		if b.insideViewDef {
+			panic(pgerror.Newf(pgcode.FeatureNotSupported,
+				"%s(): user-defined functions cannot be used inside a view or function definition",
+				def.Name,
+			))
+		}
+		// The definition of the function can change without the data sources
+		// of the query changing.
+		b.DisableMemoReuse = true
+		if inlined := b.inlineFunction(f); inlined != nil {
+			texpr := inScope.resolveType(inlined, f.ResolvedType())
+			return b.buildScalar(texpr, inScope, outScope, outCol, colRefs)
+		}
+	}
+
 	args := make(memo.ScalarListExpr, len(f.Exprs))
 	for i, pexpr := range f.Exprs {
 		args[i] = b.buildScalar(pexpr.(tree.TypedExpr), inScope, nil, nil, colRefs)
@@ -533,6 +550,97 @@ func (b *Builder) buildFunction(
 	return b.finishBuildScalar(f, out, inScope, outScope, outCol)
 }
 
+// inlineFunction returns the body of the given call to a user-defined
+// function with the arguments substituted for the parameters, or nil if the
+// function cannot be inlined. A function can be inlined if its body is a
+// single expression without subqueries, aggregates, window or generator
+// functions, e.g.:
+//
+//   CREATE FUNCTION add(a INT, b INT) RETURNS INT LANGUAGE SQL AS 'SELECT a + b'
+//   SELECT add(x, 1) FROM t  ->  SELECT (x::INT + 1::INT)::INT FROM t
+//
+// Arguments that are referenced more than once must be constants or
+// variables, so that they are not evaluated more than once.
+func (b *Builder) inlineFunction(f *tree.FuncExpr) tree.Expr {
+	overload := f.ResolvedOverload()
+	stmt, err := parser.ParseOne(overload.Body)
+	if err != nil {
+		panic(err)
+	}
+	sel, ok := stmt.AST.(*tree.Select)
+	if !ok || sel.With != nil || sel.OrderBy != nil || sel.Limit != nil || sel.Locking != nil {
+		return nil
+	}
+	clause, ok := sel.Select.(*tree.SelectClause)
+	if !ok || len(clause.Exprs) != 1 || len(clause.From.Tables) != 0 || clause.Where != nil ||
+		clause.GroupBy != nil || clause.Having != nil || clause.Window != nil ||
+		clause.Distinct || clause.DistinctOn != nil {
+		return nil
+	}
+
+	params := overload.Types.(tree.ArgTypes)
+	uses := make([]int, len(params))
+	inlinable := true
+	body, err := tree.SimpleVisit(clause.Exprs[0].Expr, func(e tree.Expr) (bool, tree.Expr, error) {
+		if !inlinable {
+			return false, e, nil
+		}
+		idx := -1
+		switch t := e.(type) {
+		case *tree.UnresolvedName:
+			if t.NumParts == 1 && !t.Star {
+				for i := range params {
+					if params[i].Name == t.Parts[0] {
+						idx = i
+						break
+					}
+				}
+			}
+		case *tree.Placeholder:
+			if int(t.Idx) < len(params) {
+				idx = int(t.Idx)
+			}
+		case *tree.FuncExpr:
+			def, err := t.Func.Resolve(b.semaCtx.SearchPath)
+			if err != nil || def.Class != tree.NormalClass || t.WindowDef != nil {
+				inlinable = false
+			}
+			return inlinable, e, nil
+		case *tree.Subquery:
+			inlinable = false
+			return false, e, nil
+		default:
+			return true, e, nil
+		}
+		if idx == -1 {
+			inlinable = false
+			return false, e, nil
+		}
+		uses[idx]++
+		return false, &tree.CastExpr{Expr: f.Exprs[idx], Type: params[idx].Typ, SyntaxMode: tree.CastShort}, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	if !inlinable {
+		return nil
+	}
+	for i, n := range uses {
+		if n > 1 {
+			switch f.Exprs[i].(type) {
+			case tree.Datum, *scopeColumn:
+			default:
+				return nil
+			}
+		}
+	}
+	return &tree.CastExpr{
+		Expr:       &tree.ParenExpr{Expr: body},
+		Type:       overload.FixedReturnType(),
+		SyntaxMode: tree.CastShort,
+	}
+}
+
 // buildRangeCond builds a RANGE clause as a simpler expression. Examples:
 // x BETWEEN a AND b                ->  x >= a AND x <= b
 // x NOT BETWEEN a AND b            ->  NOT (x >= a AND x <= b)
@@ -901,7 +901,9 @@ func (s *scope) VisitPre(expr tree.Expr) (recurse bool, newExpr tree.Expr) {
 		return false, colI.(*scopeColumn)
 
 	case *tree.FuncExpr:
-		def, err := t.Func.Resolve(s.builder.semaCtx.SearchPath)
+		def, err := t.Func.ResolveWith(
+			s.builder.ctx, s.builder.semaCtx.SearchPath, s.builder.semaCtx.FunctionResolver,
+		)
 		if err != nil {
 			panic(err)
 		}
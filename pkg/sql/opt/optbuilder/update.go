@@ -323,6 +323,9 @@ func (mb *mutationBuilder) buildUpdate(returning tree.ReturningExprs) {
 	// check constraint, refer to the correct columns.
 	mb.disambiguateColumns()
 
+	// Fire the BEFORE row-level triggers, which can skip rows.
+	mb.buildBeforeRowTriggers(tree.TriggerEventUpdate)
+
 	// Keep a reference to the scope before the check constraint columns are
 	// projected. We use this scope when projecting the partial index put
 	// columns because the check columns are not in-scope for those expressions.
@@ -334,6 +337,7 @@ func (mb *mutationBuilder) buildUpdate(returning tree.ReturningExprs) {
 	mb.projectPartialIndexPutCols(preCheckScope)
 
 	mb.buildFKChecksForUpdate()
+	mb.buildAfterTriggers(tree.TriggerEventUpdate)
 
 	private := mb.makeMutationPrivate(returning != nil)
 	for _, col := range mb.extraAccessibleCols {
@@ -342,5 +346,6 @@ func (mb *mutationBuilder) buildUpdate(returning tree.ReturningExprs) {
 		}
 	}
 	mb.outScope.expr = mb.b.factory.ConstructUpdate(mb.outScope.expr, mb.checks, private)
+	mb.buildBeforeStatementTriggers(tree.TriggerEventUpdate)
 	mb.buildReturning(returning)
 }
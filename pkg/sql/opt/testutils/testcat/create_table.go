@@ -388,6 +388,7 @@ func (tc *Catalog) resolveFK(tab *Table, d *tree.ForeignKeyConstraintTableDef) {
 		matchMethod:              d.Match,
 		deleteAction:             d.Actions.Delete,
 		updateAction:             d.Actions.Update,
+		deferrability:            d.Deferrability,
 	}
 	tab.outboundFKs = append(tab.outboundFKs, fk)
 	targetTable.inboundFKs = append(targetTable.inboundFKs, fk)
@@ -536,6 +536,7 @@ type Table struct {
 	Stats         TableStats
 	Checks        []cat.CheckConstraint
 	Families      []*Family
+	Triggers      []cat.Trigger
 	IsVirtual     bool
 	Catalog       cat.Catalog
 
@@ -696,6 +697,16 @@ func (tt *Table) InboundForeignKey(i int) cat.ForeignKeyConstraint {
 	return &tt.inboundFKs[i]
 }
 
+// TriggerCount is part of the cat.Table interface.
+func (tt *Table) TriggerCount() int {
+	return len(tt.Triggers)
+}
+
+// Trigger is part of the cat.Table interface.
+func (tt *Table) Trigger(i int) cat.Trigger {
+	return tt.Triggers[i]
+}
+
 // FindOrdinal returns the ordinal of the column with the given name.
 func (tt *Table) FindOrdinal(name string) int {
 	for i, col := range tt.Columns {
@@ -1088,10 +1099,11 @@ type ForeignKeyConstraint struct {
 	originColumnOrdinals     []int
 	referencedColumnOrdinals []int
 
-	validated    bool
-	matchMethod  tree.CompositeKeyMatchMethod
-	deleteAction tree.ReferenceAction
-	updateAction tree.ReferenceAction
+	validated     bool
+	matchMethod   tree.CompositeKeyMatchMethod
+	deleteAction  tree.ReferenceAction
+	updateAction  tree.ReferenceAction
+	deferrability tree.ConstraintDeferrability
 }
 
 var _ cat.ForeignKeyConstraint = &ForeignKeyConstraint{}
@@ -1159,6 +1171,16 @@ func (fk *ForeignKeyConstraint) UpdateReferenceAction() tree.ReferenceAction {
 	return fk.updateAction
 }
 
+// Deferrable is part of the cat.ForeignKeyConstraint interface.
+func (fk *ForeignKeyConstraint) Deferrable() bool {
+	return fk.deferrability != tree.NotDeferrable
+}
+
+// InitiallyDeferred is part of the cat.ForeignKeyConstraint interface.
+func (fk *ForeignKeyConstraint) InitiallyDeferred() bool {
+	return fk.deferrability == tree.DeferrableInitiallyDeferred
+}
+
 // Sequence implements the cat.Sequence interface for testing purposes.
 type Sequence struct {
 	SeqID      cat.StableID
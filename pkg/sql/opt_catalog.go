@@ -648,6 +648,8 @@ func newOptTable(
 			match:             fk.Match,
 			deleteAction:      fk.OnDelete,
 			updateAction:      fk.OnUpdate,
+			deferrable:        fk.Deferrable,
+			initiallyDeferred: fk.InitiallyDeferred,
 		})
 	}
 	for i := range ot.desc.InboundFKs {
@@ -662,6 +664,8 @@ func newOptTable(
 			match:             fk.Match,
 			deleteAction:      fk.OnDelete,
 			updateAction:      fk.OnUpdate,
+			deferrable:        fk.Deferrable,
+			initiallyDeferred: fk.InitiallyDeferred,
 		})
 	}
 
@@ -923,6 +927,29 @@ func (ot *optTable) InboundForeignKey(i int) cat.ForeignKeyConstraint {
 	return &ot.inboundFKs[i]
 }
 
+// TriggerCount is part of the cat.Table interface.
+func (ot *optTable) TriggerCount() int {
+	return len(ot.desc.Triggers)
+}
+
+// Trigger is part of the cat.Table interface.
+func (ot *optTable) Trigger(i int) cat.Trigger {
+	t := &ot.desc.Triggers[i]
+	actionTime := tree.TriggerAfter
+	if t.Before {
+		actionTime = tree.TriggerBefore
+	}
+	return cat.Trigger{
+		Name:       t.Name,
+		ActionTime: actionTime,
+		Events:     tree.TriggerEvents(t.Events),
+		ForEachRow: t.ForEachRow,
+		When:       t.WhenExpr,
+		Function:   t.FunctionName,
+		Args:       t.Args,
+	}
+}
+
 // lookupColumnOrdinal returns the ordinal of the column with the given ID. A
 // cache makes the lookup O(1).
 func (ot *optTable) lookupColumnOrdinal(colID sqlbase.ColumnID) (int, error) {
@@ -1293,6 +1320,9 @@ type optForeignKeyConstraint struct {
 	match        sqlbase.ForeignKeyReference_Match
 	deleteAction sqlbase.ForeignKeyReference_Action
 	updateAction sqlbase.ForeignKeyReference_Action
+
+	deferrable        bool
+	initiallyDeferred bool
 }
 
 var _ cat.ForeignKeyConstraint = &optForeignKeyConstraint{}
@@ -1364,6 +1394,16 @@ func (fk *optForeignKeyConstraint) UpdateReferenceAction() tree.ReferenceAction
 	return sqlbase.ForeignKeyReferenceActionType[fk.updateAction]
 }
 
+// Deferrable is part of the cat.ForeignKeyConstraint interface.
+func (fk *optForeignKeyConstraint) Deferrable() bool {
+	return fk.deferrable
+}
+
+// InitiallyDeferred is part of the cat.ForeignKeyConstraint interface.
+func (fk *optForeignKeyConstraint) InitiallyDeferred() bool {
+	return fk.initiallyDeferred
+}
+
 // optVirtualTable is similar to optTable but is used with virtual tables.
 type optVirtualTable struct {
 	desc *sqlbase.ImmutableTableDescriptor
@@ -1616,6 +1656,16 @@ func (ot *optVirtualTable) InboundForeignKey(i int) cat.ForeignKeyConstraint {
 	panic("no FKs")
 }
 
+// TriggerCount is part of the cat.Table interface.
+func (ot *optVirtualTable) TriggerCount() int {
+	return 0
+}
+
+// Trigger is part of the cat.Table interface.
+func (ot *optVirtualTable) Trigger(i int) cat.Trigger {
+	panic("no triggers")
+}
+
 type optDummyVirtualPKColumn struct{}
 
 var _ cat.Column = optDummyVirtualPKColumn{}
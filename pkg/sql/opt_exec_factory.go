@@ -125,7 +125,7 @@ func (ef *execFactory) ConstructScan(
 	scan.reqOrdering = ReqOrdering(reqOrdering)
 	scan.estimatedRowCount = uint64(params.EstimatedRowCount)
 	if params.Locking != nil {
-		scan.lockingStrength = sqlbase.ToScanLockingStrength(params.Locking.Strength)
+		scan.lockingStrength = ef.planner.scanLockingStrength(params.Locking.Strength)
 		scan.lockingWaitPolicy = sqlbase.ToScanLockingWaitPolicy(params.Locking.WaitPolicy)
 	}
 	return scan, nil
@@ -1664,6 +1664,29 @@ func (ef *execFactory) ConstructCreateView(
 	}, nil
 }
 
+// ConstructCreateFunction is part of the exec.Factory interface.
+func (ef *execFactory) ConstructCreateFunction(
+	schema cat.Schema, cf *tree.CreateFunction, body string, deps opt.ViewDeps,
+) (exec.Node, error) {
+	var dependsOn []sqlbase.ID
+	for _, d := range deps {
+		desc, err := getDescForDataSource(d.DataSource)
+		if err != nil {
+			return nil, err
+		}
+		dependsOn = append(dependsOn, desc.ID)
+	}
+
+	return &createFunctionNode{
+		n:          cf,
+		body:       body,
+		dbDesc:     schema.(*optSchema).database,
+		schema:     schema.(*optSchema).schema,
+		schemaName: schema.(*optSchema).name.Schema(),
+		dependsOn:  dependsOn,
+	}, nil
+}
+
 // ConstructSequenceSelect is part of the exec.Factory interface.
 func (ef *execFactory) ConstructSequenceSelect(sequence cat.Sequence) (exec.Node, error) {
 	return ef.planner.SequenceSelectNode(sequence.(*optSequence).desc)
@@ -1678,12 +1701,16 @@ func (ef *execFactory) ConstructSaveTable(
 
 // ConstructErrorIfRows is part of the exec.Factory interface.
 func (ef *execFactory) ConstructErrorIfRows(
-	input exec.Node, mkErr func(tree.Datums) error,
+	input exec.Node, mkErr func(tree.Datums) error, deferrable *exec.DeferrableFKCheck,
 ) (exec.Node, error) {
-	return &errorIfRowsNode{
+	n := &errorIfRowsNode{
 		plan:  input.(planNode),
 		mkErr: mkErr,
-	}, nil
+	}
+	if deferrable != nil {
+		n.deferrable = makeDeferrableFKCheck(deferrable)
+	}
+	return n, nil
 }
 
 // ConstructOpaque is part of the exec.Factory interface.
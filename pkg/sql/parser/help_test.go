@@ -119,6 +119,13 @@ func TestContextualHelp(t *testing.T) {
 		{`CREATE ROLE bleh ??`, `CREATE ROLE`},
 		{`CREATE ROLE bleh ?? WITH CREATEROLE`, `CREATE ROLE`},
 
+		{`CREATE FUNCTION ??`, `CREATE FUNCTION`},
+		{`CREATE OR REPLACE FUNCTION f(??`, `CREATE FUNCTION`},
+		{`CREATE FUNCTION f(a INT) RETURNS INT ??`, `CREATE FUNCTION`},
+
+		{`CREATE TRIGGER ??`, `CREATE TRIGGER`},
+		{`CREATE TRIGGER t BEFORE INSERT ON ??`, `CREATE TRIGGER`},
+
 		{`CREATE VIEW blah (??`, `CREATE VIEW`},
 		{`CREATE VIEW blah AS (SELECT c FROM x) ??`, `CREATE VIEW`},
 		{`CREATE VIEW blah AS SELECT c FROM x ??`, `SELECT`},
@@ -175,6 +182,12 @@ func TestContextualHelp(t *testing.T) {
 		{`DROP TABLE IF ??`, `DROP TABLE`},
 		{`DROP TABLE IF EXISTS blih, bloh ??`, `DROP TABLE`},
 
+		{`DROP FUNCTION ??`, `DROP FUNCTION`},
+		{`DROP FUNCTION IF EXISTS f ??`, `DROP FUNCTION`},
+
+		{`DROP TRIGGER ??`, `DROP TRIGGER`},
+		{`DROP TRIGGER IF EXISTS t ON ??`, `DROP TRIGGER`},
+
 		{`DROP VIEW blah ??`, `DROP VIEW`},
 		{`DROP VIEW IF ??`, `DROP VIEW`},
 		{`DROP VIEW IF EXISTS blih, bloh ??`, `DROP VIEW`},
@@ -206,6 +219,22 @@ func TestContextualHelp(t *testing.T) {
 		{`DEALLOCATE ALL ??`, `DEALLOCATE`},
 		{`DEALLOCATE PREPARE ??`, `DEALLOCATE`},
 
+		{`DECLARE ??`, `DECLARE`},
+		{`DECLARE foo ??`, `DECLARE`},
+
+		{`FETCH ??`, `FETCH`},
+		{`FETCH 3 ??`, `FETCH`},
+
+		{`MOVE ??`, `MOVE`},
+		{`MOVE ALL ??`, `MOVE`},
+
+		{`CLOSE ??`, `CLOSE`},
+
+		{`LISTEN ??`, `LISTEN`},
+		{`NOTIFY ??`, `NOTIFY`},
+		{`NOTIFY foo, ??`, `NOTIFY`},
+		{`UNLISTEN ??`, `UNLISTEN`},
+
 		{`INSERT INTO ??`, `INSERT`},
 		{`INSERT INTO blah (??`, `<SELECTCLAUSE>`},
 		{`INSERT INTO blah VALUES (1) RETURNING ??`, `INSERT`},
@@ -365,6 +394,8 @@ func TestContextualHelp(t *testing.T) {
 		{`SET SESSION blah TO ??`, `SET SESSION`},
 		{`SET SESSION blah TO 42 ??`, `SET SESSION`},
 
+		{`SET CONSTRAINTS ALL ??`, `SET CONSTRAINTS`},
+
 		{`SET TRANSACTION ??`, `SET TRANSACTION`},
 		{`SET TRANSACTION ISOLATION LEVEL SNAPSHOT ??`, `SET TRANSACTION`},
 		{`SET TIME ??`, `SET SESSION`},
@@ -408,6 +439,9 @@ func TestContextualHelp(t *testing.T) {
 		{`RESTORE foo FROM 'bar' ??`, `RESTORE`},
 		{`RESTORE DATABASE ??`, `RESTORE`},
 
+		{`COMPACT ??`, `COMPACT BACKUP`},
+		{`COMPACT BACKUP FROM 'foo' TO ??`, `COMPACT BACKUP`},
+
 		{`IMPORT TABLE foo CREATE USING 'foo.sql' CSV DATA ('foo') ??`, `IMPORT`},
 		{`IMPORT TABLE ??`, `IMPORT`},
 
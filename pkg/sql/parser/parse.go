@@ -27,6 +27,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
 	"github.com/cockroachdb/errors"
 )
 
@@ -219,6 +220,36 @@ func unaryNegation(e tree.Expr) tree.Expr {
 	return &tree.UnaryExpr{Operator: tree.UnaryMinus, Expr: e}
 }
 
+// makeCreateFunction constructs an AST node for a CREATE FUNCTION statement,
+// checking that the options specify a SQL-language function with a body.
+func makeCreateFunction(
+	name *tree.UnresolvedObjectName,
+	replace bool,
+	params tree.FuncParams,
+	returnType tree.ResolvableTypeReference,
+	opts *tree.FunctionOptions,
+) (*tree.CreateFunction, error) {
+	if opts.Body == nil {
+		return nil, pgerror.New(pgcode.InvalidFunctionDefinition, "no function body specified")
+	}
+	if opts.Language == "" {
+		return nil, pgerror.New(pgcode.InvalidFunctionDefinition, "no language specified")
+	}
+	if !strings.EqualFold(opts.Language, "sql") {
+		return nil, unimplemented.NewWithIssueDetailf(17511, "create function language",
+			"language %q is not supported", opts.Language)
+	}
+	// Normalize the spelling of the language for formatting purposes.
+	opts.Language = "sql"
+	return &tree.CreateFunction{
+		Name:       name.ToTableName(),
+		Replace:    replace,
+		Params:     params,
+		ReturnType: returnType,
+		Options:    *opts,
+	}, nil
+}
+
 // Parse parses a sql statement string and returns a list of Statements.
 func Parse(sql string) (Statements, error) {
 	var p Parser
@@ -189,6 +189,12 @@ func TestParse(t *testing.T) {
 		{`CREATE TABLE a (b INT8, c STRING, CONSTRAINT s FOREIGN KEY (b, c) REFERENCES other (x, y) MATCH FULL ON UPDATE SET NULL)`},
 		{`CREATE TABLE a (b INT8, c STRING, CONSTRAINT s FOREIGN KEY (b, c) REFERENCES other (x, y) MATCH FULL ON DELETE SET DEFAULT)`},
 		{`CREATE TABLE a (b INT8, c STRING, CONSTRAINT s FOREIGN KEY (b, c) REFERENCES other (x, y) MATCH FULL ON DELETE SET DEFAULT ON UPDATE SET NULL)`},
+		{`CREATE TABLE a (b INT8, c STRING, FOREIGN KEY (b) REFERENCES other DEFERRABLE INITIALLY IMMEDIATE)`},
+		{`CREATE TABLE a (b INT8, c STRING, FOREIGN KEY (b) REFERENCES other DEFERRABLE INITIALLY DEFERRED)`},
+		{`CREATE TABLE a (b INT8, c STRING, FOREIGN KEY (b) REFERENCES other (x) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED)`},
+		{`CREATE TABLE a (b INT8 REFERENCES other DEFERRABLE INITIALLY DEFERRED)`},
+		{`CREATE TABLE a (b INT8, UNIQUE (b) DEFERRABLE INITIALLY IMMEDIATE)`},
+		{`CREATE TABLE a (b INT8, CONSTRAINT c UNIQUE (b) DEFERRABLE INITIALLY DEFERRED)`},
 		{`CREATE TABLE a (b INT8, c STRING, INDEX (b, c))`},
 		{`CREATE TABLE a (b INT8, c STRING, INDEX d (b, c))`},
 		{`CREATE TABLE a (b INT8, c STRING, CONSTRAINT d UNIQUE (b, c))`},
@@ -308,6 +314,13 @@ func TestParse(t *testing.T) {
 		{`CREATE VIEW a AS (SELECT c, d FROM b WHERE c > 0 ORDER BY c)`},
 		{`CREATE VIEW a (x, y) AS SELECT c, d FROM b`},
 		{`CREATE VIEW a AS VALUES (1, 'one'), (2, 'two')`},
+
+		{`CREATE FUNCTION a() RETURNS INT8 LANGUAGE sql AS 'SELECT 1'`},
+		{`CREATE FUNCTION a.b(c INT8, STRING) RETURNS STRING LANGUAGE sql IMMUTABLE AS 'SELECT $2 || c::STRING'`},
+		{`CREATE OR REPLACE FUNCTION a(b INT8) RETURNS INT8 LANGUAGE sql STABLE AS e'SELECT \'b\''`},
+		{`CREATE TRIGGER a BEFORE INSERT ON b FOR EACH ROW EXECUTE FUNCTION c()`},
+		{`CREATE TRIGGER a AFTER INSERT OR UPDATE OR DELETE ON b.c FOR EACH STATEMENT EXECUTE FUNCTION d.e(1, 'f')`},
+		{`CREATE TRIGGER a BEFORE UPDATE ON b FOR EACH ROW WHEN (new.c > old.c) EXECUTE FUNCTION d(new.c, old.c)`},
 		{`CREATE VIEW a (x, y) AS VALUES (1, 'one'), (2, 'two')`},
 		{`CREATE VIEW a AS TABLE b`},
 		{`CREATE TEMPORARY VIEW a AS SELECT b`},
@@ -413,6 +426,11 @@ func TestParse(t *testing.T) {
 		{`DROP VIEW IF EXISTS a, b RESTRICT`},
 		{`DROP VIEW a.b CASCADE`},
 		{`DROP VIEW a, b CASCADE`},
+		{`DROP FUNCTION a`},
+		{`DROP FUNCTION IF EXISTS a.b, c RESTRICT`},
+		{`DROP FUNCTION a CASCADE`},
+		{`DROP TRIGGER a ON b`},
+		{`DROP TRIGGER IF EXISTS a ON b.c CASCADE`},
 		{`DROP SEQUENCE a`},
 		{`EXPLAIN DROP SEQUENCE a`},
 		{`DROP SEQUENCE a.b`},
@@ -629,6 +647,25 @@ func TestParse(t *testing.T) {
 		{`DEALLOCATE a`},
 		{`DEALLOCATE ALL`},
 
+		{`DECLARE a CURSOR FOR SELECT 1`},
+		{`DECLARE a CURSOR WITH HOLD FOR SELECT * FROM t ORDER BY k`},
+		{`DECLARE a INSENSITIVE NO SCROLL CURSOR FOR SELECT * FROM t`},
+		{`FETCH a`},
+		{`FETCH 3 a`},
+		{`FETCH -1 a`},
+		{`FETCH ALL a`},
+		{`MOVE a`},
+		{`MOVE 3 a`},
+		{`MOVE ALL a`},
+		{`CLOSE a`},
+		{`CLOSE ALL`},
+
+		{`LISTEN foo`},
+		{`UNLISTEN foo`},
+		{`UNLISTEN *`},
+		{`NOTIFY foo`},
+		{`NOTIFY foo, 'bar'`},
+
 		// Tables are the default, but can also be specified with
 		// GRANT x ON TABLE y. However, the stringer does not output TABLE.
 		{`GRANT SELECT ON TABLE foo TO root`},
@@ -638,6 +675,7 @@ func TestParse(t *testing.T) {
 		{`GRANT SELECT, INSERT ON DATABASE bar TO foo, bar, baz`},
 		{`GRANT SELECT, INSERT ON DATABASE db1, db2 TO foo, bar, baz`},
 		{`GRANT SELECT, INSERT ON DATABASE db1, db2 TO "test-user"`},
+		{`GRANT EXECUTE ON FUNCTION foo, db.bar TO root`},
 		{`GRANT rolea, roleb TO usera, userb`},
 		{`GRANT rolea, roleb TO usera, userb WITH ADMIN OPTION`},
 
@@ -649,6 +687,7 @@ func TestParse(t *testing.T) {
 		{`REVOKE ALL ON DATABASE foo FROM root, test`},
 		{`REVOKE SELECT, INSERT ON DATABASE bar FROM foo, bar, baz`},
 		{`REVOKE SELECT, INSERT ON DATABASE db1, db2 FROM foo, bar, baz`},
+		{`REVOKE ALL ON FUNCTION foo FROM root, test`},
 		{`REVOKE rolea, roleb FROM usera, userb`},
 		{`REVOKE ADMIN OPTION FOR rolea, roleb FROM usera, userb`},
 
@@ -1115,6 +1154,8 @@ func TestParse(t *testing.T) {
 		{`SET a = $1`},
 		{`SET a = off`},
 		{`SET TRANSACTION READ ONLY`},
+		{`SET CONSTRAINTS ALL DEFERRED`},
+		{`SET CONSTRAINTS ALL IMMEDIATE`},
 		{`SET TRANSACTION READ WRITE`},
 		{`SET TRANSACTION ISOLATION LEVEL SERIALIZABLE`},
 		{`SET TRANSACTION PRIORITY LOW`},
@@ -1278,6 +1319,13 @@ func TestParse(t *testing.T) {
 		{`COPY t FROM STDIN`},
 		{`COPY t (a, b, c) FROM STDIN`},
 		{`COPY crdb_internal.file_upload FROM STDIN WITH destination = 'filename'`},
+		{`COPY t TO STDOUT`},
+		{`COPY t (a, b, c) TO STDOUT`},
+		{`COPY t TO STDOUT WITH (format 'csv', delimiter '|', null 'NULL', header)`},
+		{`COPY t TO STDOUT WITH (format 'csv', header 'false', quote '''', escape e'\\', force_quote (a, b))`},
+		{`COPY t TO STDOUT WITH (format 'csv', force_quote *, encoding 'UTF8')`},
+		{`COPY (SELECT a, b FROM t WHERE a > 1) TO STDOUT`},
+		{`COPY (SELECT 1 UNION SELECT 2) TO STDOUT WITH (format 'binary')`},
 
 		{`ALTER TABLE a SPLIT AT VALUES (1)`},
 		{`EXPLAIN ALTER TABLE a SPLIT AT VALUES (1)`},
@@ -1491,6 +1539,10 @@ func TestParse(t *testing.T) {
 		{`BACKUP TABLE foo TO 'bar' WITH revision_history, detached`},
 		{`RESTORE TABLE foo FROM 'bar' WITH key1, key2 = 'value'`},
 
+		{`COMPACT BACKUP FROM 'bar' TO 'baz'`},
+		{`COMPACT BACKUP FROM 'bar', 'bar/inc1', $1 TO $2`},
+		{`COMPACT BACKUP FROM 'bar' TO 'baz' WITH encryption_passphrase = 'secret', detached`},
+
 		{`IMPORT TABLE foo CREATE USING 'nodelocal://0/some/file' CSV DATA ('path/to/some/file', $1) WITH temp = 'path/to/temp'`},
 		{`EXPLAIN IMPORT TABLE foo CREATE USING 'nodelocal://0/some/file' CSV DATA ('path/to/some/file', $1) WITH temp = 'path/to/temp'`},
 		{`IMPORT TABLE foo CREATE USING 'nodelocal://0/some/file' DELIMITED DATA ('path/to/some/file', $1)`},
@@ -1519,6 +1571,9 @@ func TestParse(t *testing.T) {
 		// {`CREATE CHANGEFEED FOR TABLE foo PARTITION bar, baz INTO 'sink'`},
 		// {`CREATE CHANGEFEED FOR DATABASE foo INTO 'sink'`},
 		{`CREATE CHANGEFEED FOR TABLE foo INTO 'sink' WITH bar = 'baz'`},
+		{`EXPERIMENTAL CHANGEFEED AS SELECT a FROM foo`},
+		{`CREATE CHANGEFEED INTO 'sink' AS SELECT a, b + 1 AS c FROM foo WHERE a > 1`},
+		{`CREATE CHANGEFEED INTO 'sink' WITH diff AS SELECT * FROM db.foo WHERE cdc_is_delete()`},
 
 		// Regression for #15926
 		{`SELECT * FROM ((t1 NATURAL JOIN t2 WITH ORDINALITY AS o1)) WITH ORDINALITY AS o2`},
@@ -1560,8 +1615,32 @@ func TestParse2(t *testing.T) {
 			`CREATE DATABASE a TEMPLATE = 'template0'`},
 		{`CREATE DATABASE a TEMPLATE = invalid`,
 			`CREATE DATABASE a TEMPLATE = 'invalid'`},
+		{`CREATE FUNCTION a(INT) RETURNS INT AS 'SELECT $1' LANGUAGE SQL`,
+			`CREATE FUNCTION a(INT8) RETURNS INT8 LANGUAGE sql AS 'SELECT $1'`},
+		{`CREATE FUNCTION a(b INT) RETURNS INT VOLATILE LANGUAGE 'SQL' AS 'SELECT b'`,
+			`CREATE FUNCTION a(b INT8) RETURNS INT8 LANGUAGE sql VOLATILE AS 'SELECT b'`},
+		{`CREATE TRIGGER a AFTER DELETE OR INSERT ON b EXECUTE PROCEDURE c()`,
+			`CREATE TRIGGER a AFTER INSERT OR DELETE ON b FOR EACH STATEMENT EXECUTE FUNCTION c()`},
+		{`CREATE TRIGGER a BEFORE UPDATE ON b FOR ROW EXECUTE FUNCTION c()`,
+			`CREATE TRIGGER a BEFORE UPDATE ON b FOR EACH ROW EXECUTE FUNCTION c()`},
+		{`COPY t TO STDOUT WITH (FORMAT CSV, HEADER TRUE, FORCE_QUOTE (a))`,
+			`COPY t TO STDOUT WITH (format 'csv', header 'true', force_quote (a))`},
+		{`COPY t TO STDOUT (DELIMITER '|')`,
+			`COPY t TO STDOUT WITH (delimiter '|')`},
+		{`COPY t TO STDOUT CSV HEADER`,
+			`COPY t TO STDOUT WITH (format 'csv', header)`},
+		{`COPY t TO STDOUT WITH BINARY`,
+			`COPY t TO STDOUT WITH (format 'binary')`},
+		{`COPY t TO STDOUT WITH CSV DELIMITER AS '|' NULL 'x' QUOTE AS '''' ESCAPE '\' FORCE QUOTE a, b`,
+			`COPY t TO STDOUT WITH (format 'csv', delimiter '|', null 'x', quote '''', escape e'\\', force_quote (a, b))`},
+		{`COPY t TO STDOUT CSV FORCE QUOTE * ENCODING 'UTF8'`,
+			`COPY t TO STDOUT WITH (format 'csv', force_quote *, encoding 'UTF8')`},
 		{`CREATE TABLE a (b INT) WITH (fillfactor=100)`,
 			`CREATE TABLE a (b INT8)`},
+		{`CREATE TABLE a (b INT) WITH (fillfactor=100, ttl_expire_after='1 day', ttl_delete_batch_size=10)`,
+			`CREATE TABLE a (b INT8) WITH (ttl_expire_after = '1 day', ttl_delete_batch_size = 10)`},
+		{`CREATE TABLE a WITH (ttl_expire_after='1 day') AS SELECT 1`,
+			`CREATE TABLE a WITH (ttl_expire_after = '1 day') AS SELECT 1`},
 		{`CREATE TABLE a (b INT, UNIQUE INDEX foo (b))`,
 			`CREATE TABLE a (b INT8, CONSTRAINT foo UNIQUE (b))`},
 		{`CREATE TABLE a (b INT, UNIQUE INDEX foo (b) WHERE c > 3)`,
@@ -2020,6 +2099,19 @@ $function$`,
 		{`DEALLOCATE PREPARE ALL`,
 			`DEALLOCATE ALL`},
 
+		{`DECLARE a CURSOR WITHOUT HOLD FOR SELECT 1`,
+			`DECLARE a CURSOR FOR SELECT 1`},
+		{`FETCH FROM a`, `FETCH a`},
+		{`NOTIFY foo, ''`, `NOTIFY foo`},
+		{`FETCH NEXT IN a`, `FETCH a`},
+		{`FETCH FORWARD a`, `FETCH a`},
+		{`FETCH 1 a`, `FETCH a`},
+		{`FETCH FORWARD 3 FROM a`, `FETCH 3 a`},
+		{`FETCH FORWARD ALL FROM a`, `FETCH ALL a`},
+		{`MOVE NEXT FROM a`, `MOVE a`},
+		{`MOVE FORWARD 3 IN a`, `MOVE 3 a`},
+		{`MOVE ALL FROM a`, `MOVE ALL a`},
+
 		{`CANCEL JOB a`, `CANCEL JOBS VALUES (a)`},
 		{`EXPLAIN CANCEL JOB a`, `EXPLAIN CANCEL JOBS VALUES (a)`},
 		{`RESUME JOB a`, `RESUME JOBS VALUES (a)`},
@@ -2173,6 +2265,7 @@ $function$`,
 		{`RESTORE foo FROM 'bar' WITH key1, key2 = 'value'`,
 			`RESTORE TABLE foo FROM 'bar' WITH key1, key2 = 'value'`},
 		{`CREATE CHANGEFEED FOR foo INTO 'sink'`, `CREATE CHANGEFEED FOR TABLE foo INTO 'sink'`},
+		{`CREATE CHANGEFEED AS SELECT a FROM foo`, `EXPERIMENTAL CHANGEFEED AS SELECT a FROM foo`},
 
 		{`GRANT SELECT ON foo TO root`,
 			`GRANT SELECT ON TABLE foo TO root`},
@@ -2247,6 +2340,26 @@ $function$`,
 			`CREATE TABLE a (b INT8, FOREIGN KEY (b) REFERENCES other ON UPDATE NO ACTION ON DELETE NO ACTION)`,
 			`CREATE TABLE a (b INT8, FOREIGN KEY (b) REFERENCES other)`,
 		},
+		{
+			`CREATE TABLE a (b INT8, FOREIGN KEY (b) REFERENCES other DEFERRABLE)`,
+			`CREATE TABLE a (b INT8, FOREIGN KEY (b) REFERENCES other DEFERRABLE INITIALLY IMMEDIATE)`,
+		},
+		{
+			`CREATE TABLE a (b INT8, FOREIGN KEY (b) REFERENCES other INITIALLY DEFERRED)`,
+			`CREATE TABLE a (b INT8, FOREIGN KEY (b) REFERENCES other DEFERRABLE INITIALLY DEFERRED)`,
+		},
+		{
+			`CREATE TABLE a (b INT8, UNIQUE (b) INITIALLY DEFERRED)`,
+			`CREATE TABLE a (b INT8, UNIQUE (b) DEFERRABLE INITIALLY DEFERRED)`,
+		},
+		{
+			`CREATE TABLE a (b INT8, FOREIGN KEY (b) REFERENCES other INITIALLY IMMEDIATE)`,
+			`CREATE TABLE a (b INT8, FOREIGN KEY (b) REFERENCES other)`,
+		},
+		{
+			`CREATE TABLE a (b INT8, UNIQUE (b) INITIALLY IMMEDIATE)`,
+			`CREATE TABLE a (b INT8, UNIQUE (b))`,
+		},
 		{
 			`CREATE TABLE a (b INT8, FOREIGN KEY (b) REFERENCES other ON UPDATE RESTRICT ON DELETE RESTRICT)`,
 			`CREATE TABLE a (b INT8, FOREIGN KEY (b) REFERENCES other ON DELETE RESTRICT ON UPDATE RESTRICT)`,
@@ -2695,6 +2808,34 @@ SELECT a FROM foo@{FORCE_INDEX=}
                                ^
 HINT: try \h <SOURCE>`,
 		},
+		{
+			`CREATE FUNCTION f() RETURNS INT8 LANGUAGE SQL`,
+			`at or near "EOF": syntax error: no function body specified
+DETAIL: source SQL:
+CREATE FUNCTION f() RETURNS INT8 LANGUAGE SQL
+                                             ^`,
+		},
+		{
+			`CREATE FUNCTION f() RETURNS INT8 AS 'SELECT 1'`,
+			`at or near "EOF": syntax error: no language specified
+DETAIL: source SQL:
+CREATE FUNCTION f() RETURNS INT8 AS 'SELECT 1'
+                                              ^`,
+		},
+		{
+			`CREATE FUNCTION f() RETURNS INT8 LANGUAGE SQL AS 'SELECT 1' AS 'SELECT 2'`,
+			`at or near "EOF": syntax error: conflicting or redundant options
+DETAIL: source SQL:
+CREATE FUNCTION f() RETURNS INT8 LANGUAGE SQL AS 'SELECT 1' AS 'SELECT 2'
+                                                                         ^`,
+		},
+		{
+			`CREATE TRIGGER a BEFORE INSERT OR INSERT ON b EXECUTE FUNCTION c()`,
+			`at or near "on": syntax error: duplicate trigger events specified
+DETAIL: source SQL:
+CREATE TRIGGER a BEFORE INSERT OR INSERT ON b EXECUTE FUNCTION c()
+                                         ^`,
+		},
 		{
 			`SELECT a FROM foo@{FORCE_INDEX=bar,FORCE_INDEX=baz}`,
 			`at or near "baz": syntax error: FORCE_INDEX specified multiple times
@@ -3329,8 +3470,7 @@ func TestUnimplementedSyntax(t *testing.T) {
 		{`CREATE EXTENSION a`, 0, `create extension a`, ``},
 		{`CREATE FOREIGN DATA WRAPPER a`, 0, `create fdw`, ``},
 		{`CREATE FOREIGN TABLE a`, 0, `create foreign table`, ``},
-		{`CREATE FUNCTION a`, 17511, `create`, ``},
-		{`CREATE OR REPLACE FUNCTION a`, 17511, `create`, ``},
+		{`CREATE FUNCTION a() RETURNS INT8 LANGUAGE plpgsql AS 'SELECT 1'`, 17511, `create function language`, ``},
 		{`CREATE LANGUAGE a`, 17511, `create language a`, ``},
 		{`CREATE MATERIALIZED VIEW a`, 41649, ``, ``},
 		{`CREATE OPERATOR a`, 0, `create operator`, ``},
@@ -3339,7 +3479,11 @@ func TestUnimplementedSyntax(t *testing.T) {
 		{`CREATE SERVER a`, 0, `create server`, ``},
 		{`CREATE SUBSCRIPTION a`, 0, `create subscription`, ``},
 		{`CREATE TEXT SEARCH a`, 7821, `create text`, ``},
-		{`CREATE TRIGGER a`, 28296, `create`, ``},
+		{`CREATE TRIGGER a BEFORE TRUNCATE ON b EXECUTE FUNCTION c()`, 28296, `truncate`, ``},
+		{`CREATE TRIGGER a BEFORE UPDATE OF c ON b EXECUTE FUNCTION d()`, 28296, `update of`, ``},
+
+		{`DECLARE a BINARY CURSOR FOR SELECT 1`, 41412, `binary`, ``},
+		{`DECLARE a SCROLL CURSOR FOR SELECT 1`, 41412, `scroll`, ``},
 
 		{`DROP AGGREGATE a`, 0, `drop aggregate`, ``},
 		{`DROP CAST a`, 0, `drop cast`, ``},
@@ -3349,7 +3493,6 @@ func TestUnimplementedSyntax(t *testing.T) {
 		{`DROP EXTENSION a`, 0, `drop extension a`, ``},
 		{`DROP FOREIGN TABLE a`, 0, `drop foreign table`, ``},
 		{`DROP FOREIGN DATA WRAPPER a`, 0, `drop fdw`, ``},
-		{`DROP FUNCTION a`, 17511, `drop `, ``},
 		{`DROP LANGUAGE a`, 17511, `drop language a`, ``},
 		{`DROP OPERATOR a`, 0, `drop operator`, ``},
 		{`DROP PUBLICATION a`, 0, `drop publication`, ``},
@@ -3358,7 +3501,6 @@ func TestUnimplementedSyntax(t *testing.T) {
 		{`DROP SERVER a`, 0, `drop server`, ``},
 		{`DROP SUBSCRIPTION a`, 0, `drop subscription`, ``},
 		{`DROP TEXT SEARCH a`, 7821, `drop text`, ``},
-		{`DROP TRIGGER a`, 28296, `drop`, ``},
 
 		{`DISCARD PLANS`, 0, `discard plans`, ``},
 		{`DISCARD SEQUENCES`, 0, `discard sequences`, ``},
@@ -3383,13 +3525,7 @@ func TestUnimplementedSyntax(t *testing.T) {
 		{`CREATE TABLE a(b INT8 REFERENCES c(x) MATCH PARTIAL`, 20305, `match partial`, ``},
 		{`CREATE TABLE a(b INT8, FOREIGN KEY (b) REFERENCES c(x) MATCH PARTIAL)`, 20305, `match partial`, ``},
 
-		{`CREATE TABLE a(b INT8, FOREIGN KEY (b) REFERENCES c(x) DEFERRABLE)`, 31632, `deferrable`, ``},
-		{`CREATE TABLE a(b INT8, FOREIGN KEY (b) REFERENCES c(x) INITIALLY DEFERRED)`, 31632, `initially deferred`, ``},
-		{`CREATE TABLE a(b INT8, FOREIGN KEY (b) REFERENCES c(x) INITIALLY IMMEDIATE)`, 31632, `initially immediate`, ``},
-		{`CREATE TABLE a(b INT8, FOREIGN KEY (b) REFERENCES c(x) DEFERRABLE INITIALLY DEFERRED)`, 31632, `initially deferred`, ``},
-		{`CREATE TABLE a(b INT8, FOREIGN KEY (b) REFERENCES c(x) DEFERRABLE INITIALLY IMMEDIATE)`, 31632, `initially immediate`, ``},
-		{`CREATE TABLE a(b INT8, UNIQUE (b) DEFERRABLE)`, 31632, `deferrable`, ``},
-		{`CREATE TABLE a(b INT8, CHECK (b > 0) DEFERRABLE)`, 31632, `deferrable`, ``},
+		{`CREATE TABLE a(b INT8, CHECK (b > 0) DEFERRABLE)`, 31632, `deferrable check`, ``},
 
 		{`CREATE TABLE a (LIKE b INCLUDING COMMENTS)`, 47071, `like table`, ``},
 		{`CREATE TABLE a (LIKE b INCLUDING IDENTITY)`, 47071, `like table`, ``},
@@ -481,12 +481,30 @@ func (u *sqlSymUnion) kvOptions() []tree.KVOption {
 func (u *sqlSymUnion) backupOptions() *tree.BackupOptions {
   return u.val.(*tree.BackupOptions)
 }
+func (u *sqlSymUnion) functionOptions() *tree.FunctionOptions {
+  return u.val.(*tree.FunctionOptions)
+}
+func (u *sqlSymUnion) funcParam() tree.FuncParam {
+  return u.val.(tree.FuncParam)
+}
+func (u *sqlSymUnion) funcParams() tree.FuncParams {
+  return u.val.(tree.FuncParams)
+}
+func (u *sqlSymUnion) triggerActionTime() tree.TriggerActionTime {
+  return u.val.(tree.TriggerActionTime)
+}
+func (u *sqlSymUnion) triggerEvents() tree.TriggerEvents {
+  return u.val.(tree.TriggerEvents)
+}
 func (u *sqlSymUnion) transactionModes() tree.TransactionModes {
     return u.val.(tree.TransactionModes)
 }
 func (u *sqlSymUnion) compositeKeyMatchMethod() tree.CompositeKeyMatchMethod {
   return u.val.(tree.CompositeKeyMatchMethod)
 }
+func (u *sqlSymUnion) constraintDeferrability() tree.ConstraintDeferrability {
+  return u.val.(tree.ConstraintDeferrability)
+}
 func (u *sqlSymUnion) referenceAction() tree.ReferenceAction {
     return u.val.(tree.ReferenceAction)
 }
@@ -529,6 +547,9 @@ func (u *sqlSymUnion) typeReference() tree.ResolvableTypeReference {
 func (u *sqlSymUnion) typeReferences() []tree.ResolvableTypeReference {
     return u.val.([]tree.ResolvableTypeReference)
 }
+func (u *sqlSymUnion) cursorStmt() tree.CursorStmt {
+    return u.val.(tree.CursorStmt)
+}
 func (u *sqlSymUnion) alterTypeAddValuePlacement() *tree.AlterTypeAddValuePlacement {
     return u.val.(*tree.AlterTypeAddValuePlacement)
 }
@@ -555,7 +576,7 @@ func (u *sqlSymUnion) alterTypeAddValuePlacement() *tree.AlterTypeAddValuePlacem
 %token <str> ALL ALTER ALWAYS ANALYSE ANALYZE AND AND_AND ANY ANNOTATE_TYPE ARRAY AS ASC
 %token <str> ASYMMETRIC AT ATTRIBUTE AUTHORIZATION AUTOMATIC
 
-%token <str> BACKUP BEFORE BEGIN BETWEEN BIGINT BIGSERIAL BIT
+%token <str> BACKUP BEFORE BEGIN BETWEEN BIGINT BIGSERIAL BINARY BIT
 %token <str> BUCKET_COUNT
 %token <str> BOOLEAN BOTH BUNDLE BY
 
@@ -564,15 +585,15 @@ func (u *sqlSymUnion) alterTypeAddValuePlacement() *tree.AlterTypeAddValuePlacem
 %token <str> CLUSTER COALESCE COLLATE COLLATION COLUMN COLUMNS COMMENT COMMENTS COMMIT
 %token <str> COMMITTED COMPACT COMPLETE CONCAT CONCURRENTLY CONFIGURATION CONFIGURATIONS CONFIGURE
 %token <str> CONFLICT CONSTRAINT CONSTRAINTS CONTAINS CONVERSION COPY COVERING CREATE CREATEROLE
-%token <str> CROSS CUBE CURRENT CURRENT_CATALOG CURRENT_DATE CURRENT_SCHEMA
+%token <str> CROSS CSV CUBE CURRENT CURRENT_CATALOG CURRENT_DATE CURRENT_SCHEMA
 %token <str> CURRENT_ROLE CURRENT_TIME CURRENT_TIMESTAMP
-%token <str> CURRENT_USER CYCLE
+%token <str> CURRENT_USER CURSOR CYCLE
 
 %token <str> DATA DATABASE DATABASES DATE DAY DEC DECIMAL DEFAULT DEFAULTS
-%token <str> DEALLOCATE DECLARE DEFERRABLE DEFERRED DELETE DESC DETACHED
+%token <str> DEALLOCATE DECLARE DEFERRABLE DEFERRED DELETE DELIMITER DESC DETACHED
 %token <str> DISCARD DISTINCT DO DOMAIN DOUBLE DROP
 
-%token <str> ELSE ENCODING ENCRYPTION_PASSPHRASE END ENUM ESCAPE EXCEPT EXCLUDE EXCLUDING
+%token <str> EACH ELSE ENCODING ENCRYPTION_PASSPHRASE END ENUM ESCAPE EXCEPT EXCLUDE EXCLUDING
 %token <str> EXISTS EXECUTE EXECUTION EXPERIMENTAL
 %token <str> EXPERIMENTAL_FINGERPRINTS EXPERIMENTAL_REPLICA
 %token <str> EXPERIMENTAL_AUDIT
@@ -580,18 +601,18 @@ func (u *sqlSymUnion) alterTypeAddValuePlacement() *tree.AlterTypeAddValuePlacem
 
 %token <str> FALSE FAMILY FETCH FETCHVAL FETCHTEXT FETCHVAL_PATH FETCHTEXT_PATH
 %token <str> FILES FILTER
-%token <str> FIRST FLOAT FLOAT4 FLOAT8 FLOORDIV FOLLOWING FOR FORCE_INDEX FOREIGN FROM FULL FUNCTION
+%token <str> FIRST FLOAT FLOAT4 FLOAT8 FLOORDIV FOLLOWING FOR FORCE FORCE_INDEX FOREIGN FORWARD FROM FULL FUNCTION
 
 %token <str> GENERATED GEOGRAPHY GEOMETRY GEOMETRYCOLLECTION
 %token <str> GLOBAL GRANT GRANTS GREATEST GROUP GROUPING GROUPS
 
-%token <str> HAVING HASH HIGH HISTOGRAM HOUR
+%token <str> HAVING HASH HEADER HIGH HISTOGRAM HOLD HOUR
 
 %token <str> IDENTITY
-%token <str> IF IFERROR IFNULL IGNORE_FOREIGN_KEYS ILIKE IMMEDIATE IMPORT IN INCLUDE INCLUDING INCREMENT INCREMENTAL
+%token <str> IF IFERROR IFNULL IGNORE_FOREIGN_KEYS ILIKE IMMEDIATE IMMUTABLE IMPORT IN INCLUDE INCLUDING INCREMENT INCREMENTAL
 %token <str> INET INET_CONTAINED_BY_OR_EQUALS
 %token <str> INET_CONTAINS_OR_EQUALS INDEX INDEXES INJECT INTERLEAVE INITIALLY
-%token <str> INNER INSERT INT INTEGER
+%token <str> INNER INSENSITIVE INSERT INT INTEGER
 %token <str> INTERSECT INTERVAL INTO INVERTED IS ISERROR ISNULL ISOLATION
 
 %token <str> JOB JOBS JOIN JSON JSONB JSON_SOME_EXISTS JSON_ALL_EXISTS
@@ -599,35 +620,35 @@ func (u *sqlSymUnion) alterTypeAddValuePlacement() *tree.AlterTypeAddValuePlacem
 %token <str> KEY KEYS KV
 
 %token <str> LANGUAGE LAST LATERAL LC_CTYPE LC_COLLATE
-%token <str> LEADING LEASE LEAST LEFT LESS LEVEL LIKE LIMIT LINESTRING LIST LOCAL
+%token <str> LEADING LEASE LEAST LEFT LESS LEVEL LIKE LIMIT LINESTRING LIST LISTEN LOCAL
 %token <str> LOCALTIME LOCALTIMESTAMP LOCKED LOGIN LOOKUP LOW LSHIFT
 
-%token <str> MATCH MATERIALIZED MERGE MINVALUE MAXVALUE MINUTE MONTH
+%token <str> MATCH MATERIALIZED MERGE MINVALUE MAXVALUE MINUTE MONTH MOVE
 %token <str> MULTILINESTRING MULTIPOINT MULTIPOLYGON
 
 %token <str> NAN NAME NAMES NATURAL NEVER NEXT NO NOCREATEROLE NOLOGIN NO_INDEX_JOIN
-%token <str> NONE NORMAL NOT NOTHING NOTNULL NOWAIT NULL NULLIF NULLS NUMERIC
+%token <str> NONE NORMAL NOT NOTHING NOTIFY NOTNULL NOWAIT NULL NULLIF NULLS NUMERIC
 
 %token <str> OF OFF OFFSET OID OIDS OIDVECTOR ON ONLY OPT OPTION OPTIONS OR
 %token <str> ORDER ORDINALITY OTHERS OUT OUTER OVER OVERLAPS OVERLAY OWNED OWNER OPERATOR
 
 %token <str> PARENT PARTIAL PARTITION PARTITIONS PASSWORD PAUSE PHYSICAL PLACING
 %token <str> PLAN PLANS POINT POLYGON POSITION PRECEDING PRECISION PREPARE PRESERVE PRIMARY PRIORITY
-%token <str> PROCEDURAL PUBLIC PUBLICATION
+%token <str> PROCEDURAL PROCEDURE PUBLIC PUBLICATION
 
-%token <str> QUERIES QUERY
+%token <str> QUERIES QUERY QUOTE
 
 %token <str> RANGE RANGES READ REAL RECURSIVE RECURRING REF REFERENCES
 %token <str> REGCLASS REGPROC REGPROCEDURE REGNAMESPACE REGTYPE REINDEX
 %token <str> REMOVE_PATH RENAME REPEATABLE REPLACE
-%token <str> RELEASE RESET RESTORE RESTRICT RESUME RETURNING RETRY REVISION_HISTORY REVOKE RIGHT
+%token <str> RELEASE RESET RESTORE RESTRICT RESUME RETURNING RETURNS RETRY REVISION_HISTORY REVOKE RIGHT
 %token <str> ROLE ROLES ROLLBACK ROLLUP ROW ROWS RSHIFT RULE
 
-%token <str> SAVEPOINT SCATTER SCHEDULE SCHEDULES SCHEMA SCHEMAS SCRUB SEARCH SECOND SELECT SEQUENCE SEQUENCES
+%token <str> SAVEPOINT SCATTER SCHEDULE SCHEDULES SCHEMA SCHEMAS SCROLL SCRUB SEARCH SECOND SELECT SEQUENCE SEQUENCES
 %token <str> SERIALIZABLE SERVER SESSION SESSIONS SESSION_USER SET SETTING SETTINGS
 %token <str> SHARE SHOW SIMILAR SIMPLE SKIP SMALLINT SMALLSERIAL SNAPSHOT SOME SPLIT SQL
 
-%token <str> START STATISTICS STATUS STDIN STRICT STRING STORAGE STORE STORED STORING SUBSTRING
+%token <str> STABLE START STATEMENT STATISTICS STATUS STDIN STDOUT STRICT STRING STORAGE STORE STORED STORING SUBSTRING
 %token <str> SYMMETRIC SYNTAX SYSTEM SQRT SUBSCRIPTION
 
 %token <str> TABLE TABLES TEMP TEMPLATE TEMPORARY TENANT TESTING_RELOCATE EXPERIMENTAL_RELOCATE TEXT THEN
@@ -635,10 +656,10 @@ func (u *sqlSymUnion) alterTypeAddValuePlacement() *tree.AlterTypeAddValuePlacem
 %token <str> TRUNCATE TRUSTED TYPE
 %token <str> TRACING
 
-%token <str> UNBOUNDED UNCOMMITTED UNION UNIQUE UNKNOWN UNLOGGED UNSPLIT
+%token <str> UNBOUNDED UNCOMMITTED UNION UNIQUE UNKNOWN UNLISTEN UNLOGGED UNSPLIT
 %token <str> UPDATE UPSERT UNTIL USE USER USERS USING UUID
 
-%token <str> VALID VALIDATE VALUE VALUES VARBIT VARCHAR VARIADIC VIEW VARYING VIRTUAL
+%token <str> VALID VALIDATE VALUE VALUES VARBIT VARCHAR VARIADIC VIEW VARYING VIRTUAL VOLATILE
 
 %token <str> WHEN WHERE WINDOW WITH WITHIN WITHOUT WORK WRITE
 
@@ -736,6 +757,10 @@ func (u *sqlSymUnion) alterTypeAddValuePlacement() *tree.AlterTypeAddValuePlacem
 %type <tree.Statement> comment_stmt
 %type <tree.Statement> commit_stmt
 %type <tree.Statement> copy_from_stmt
+%type <tree.Statement> copy_to_stmt
+%type <[]tree.KVOption> opt_with_copy_options copy_generic_option_list copy_legacy_option_list
+%type <tree.KVOption> copy_generic_option copy_legacy_option
+%type <tree.Expr> copy_generic_option_arg
 
 %type <tree.Statement> create_stmt
 %type <tree.Statement> create_changefeed_stmt
@@ -748,6 +773,8 @@ func (u *sqlSymUnion) alterTypeAddValuePlacement() *tree.AlterTypeAddValuePlacem
 %type <tree.Statement> create_table_stmt
 %type <tree.Statement> create_table_as_stmt
 %type <tree.Statement> create_view_stmt
+%type <tree.Statement> create_func_stmt
+%type <tree.Statement> create_trigger_stmt
 %type <tree.Statement> create_sequence_stmt
 
 %type <tree.Statement> create_stats_stmt
@@ -767,6 +794,8 @@ func (u *sqlSymUnion) alterTypeAddValuePlacement() *tree.AlterTypeAddValuePlacem
 %type <tree.Statement> drop_table_stmt
 %type <tree.Statement> drop_type_stmt
 %type <tree.Statement> drop_view_stmt
+%type <tree.Statement> drop_func_stmt
+%type <tree.Statement> drop_trigger_stmt
 %type <tree.Statement> drop_sequence_stmt
 
 %type <tree.Statement> analyze_stmt
@@ -786,6 +815,7 @@ func (u *sqlSymUnion) alterTypeAddValuePlacement() *tree.AlterTypeAddValuePlacem
 %type <tree.Statement> resume_stmt resume_jobs_stmt resume_schedules_stmt
 %type <tree.Statement> drop_schedule_stmt
 %type <tree.Statement> restore_stmt
+%type <tree.Statement> compact_backup_stmt
 %type <tree.PartitionedBackup> partitioned_backup
 %type <[]tree.PartitionedBackup> partitioned_backup_list
 %type <tree.Statement> revoke_stmt
@@ -798,6 +828,7 @@ func (u *sqlSymUnion) alterTypeAddValuePlacement() *tree.AlterTypeAddValuePlacem
 %type <tree.Statement> set_session_stmt
 %type <tree.Statement> set_csetting_stmt
 %type <tree.Statement> set_transaction_stmt
+%type <tree.Statement> set_constraints_stmt
 %type <tree.Statement> set_exprs_internal
 %type <tree.Statement> generic_set
 %type <tree.Statement> set_rest_more
@@ -845,12 +876,28 @@ func (u *sqlSymUnion) alterTypeAddValuePlacement() *tree.AlterTypeAddValuePlacem
 
 %type <tree.Statement> close_cursor_stmt
 %type <tree.Statement> declare_cursor_stmt
+%type <tree.Statement> fetch_cursor_stmt
+%type <tree.Statement> move_cursor_stmt
+%type <tree.CursorStmt> cursor_movement_specifier
+%type <bool> opt_cursor_insensitive opt_cursor_no_scroll opt_hold
+%type <tree.Statement> listen_stmt
+%type <tree.Statement> notify_stmt
+%type <tree.Statement> unlisten_stmt
 %type <tree.Statement> reindex_stmt
 
 %type <[]string> opt_incremental
 %type <tree.KVOption> kv_option
 %type <[]tree.KVOption> kv_option_list opt_with_options var_set_list opt_with_schedule_options
 %type <*tree.BackupOptions> opt_with_backup_options backup_options backup_options_list
+%type <*tree.FunctionOptions> func_option func_option_list
+%type <tree.FuncParams> opt_func_param_list func_param_list
+%type <tree.FuncParam> func_param
+%type <str> param_name
+%type <*tree.UnresolvedObjectName> func_create_name
+%type <tree.TriggerActionTime> trigger_action_time
+%type <tree.TriggerEvents> trigger_event trigger_events
+%type <bool> opt_trigger_for_each
+%type <tree.Expr> opt_trigger_when
 %type <str> import_format
 %type <tree.StorageParam> storage_parameter
 %type <[]tree.StorageParam> storage_parameter_list opt_table_with
@@ -1017,6 +1064,7 @@ func (u *sqlSymUnion) alterTypeAddValuePlacement() *tree.AlterTypeAddValuePlacem
 %type <*tree.UpdateExpr> single_set_clause
 %type <tree.AsOfClause> as_of_clause opt_as_of_clause
 %type <tree.Expr> opt_changefeed_sink
+%type <tree.SelectStatement> changefeed_select
 
 %type <str> explain_option_name
 %type <[]string> explain_option_list opt_enum_val_list enum_val_list
@@ -1064,6 +1112,7 @@ func (u *sqlSymUnion) alterTypeAddValuePlacement() *tree.AlterTypeAddValuePlacem
 %type <tree.NamedColumnQualification> col_qualification create_as_col_qualification
 %type <tree.ColumnQualification> col_qualification_elem create_as_col_qualification_elem
 %type <tree.CompositeKeyMatchMethod> key_match
+%type <tree.ConstraintDeferrability> opt_deferrable
 %type <tree.ReferenceActions> reference_actions
 %type <tree.ReferenceAction> reference_action reference_on_delete reference_on_update
 
@@ -1186,6 +1235,7 @@ stmt:
 | preparable_stmt   // help texts in sub-rule
 | analyze_stmt      // EXTEND WITH HELP: ANALYZE
 | copy_from_stmt
+| copy_to_stmt
 | comment_stmt
 | execute_stmt      // EXTEND WITH HELP: EXECUTE
 | deallocate_stmt   // EXTEND WITH HELP: DEALLOCATE
@@ -1197,8 +1247,13 @@ stmt:
 | release_stmt      // EXTEND WITH HELP: RELEASE
 | nonpreparable_set_stmt // help texts in sub-rule
 | transaction_stmt  // help texts in sub-rule
-| close_cursor_stmt
-| declare_cursor_stmt
+| close_cursor_stmt // EXTEND WITH HELP: CLOSE
+| declare_cursor_stmt // EXTEND WITH HELP: DECLARE
+| fetch_cursor_stmt // EXTEND WITH HELP: FETCH
+| move_cursor_stmt // EXTEND WITH HELP: MOVE
+| listen_stmt       // EXTEND WITH HELP: LISTEN
+| notify_stmt       // EXTEND WITH HELP: NOTIFY
+| unlisten_stmt     // EXTEND WITH HELP: UNLISTEN
 | reindex_stmt
 | /* EMPTY */
   {
@@ -2180,6 +2235,16 @@ backup_options:
 //     * skip: skip this execution, reschedule it based on RECURRING (or change_capture_period)
 //       expression.
 //     * wait: wait for the previous execution to complete.  This is the default.
+//   * keep_full_backups=INT:
+//     retain only the specified number of most recent full backups, along with their
+//     incremental backups, and delete older ones.
+//   * keep_for=DURATION:
+//     retain the backups needed to restore to any time within the specified duration
+//     (e.g. '720h'), and delete older ones.
+//   If either retention option is specified, each full backup and its incremental backups are
+//   stored in their own subdirectory of the location, a new full backup is started according
+//   to the FULL BACKUP clause (which defaults to ALWAYS), and backups are only deleted once a
+//   newer full backup has completed.
 //
 // %SeeAlso: BACKUP
 create_schedule_for_backup_stmt:
@@ -2293,6 +2358,30 @@ restore_stmt:
   }
 | RESTORE error // SHOW HELP: RESTORE
 
+// %Help: COMPACT BACKUP - merge a chain of backups into a new full backup
+// %Category: CCL
+// %Text:
+// COMPACT BACKUP FROM <location...> TO <location>
+//         [ WITH <option> [= <value>] [, ...] ]
+//
+// The locations of the full backup and of its incremental backups are listed
+// in order, like for RESTORE.
+//
+// Locations:
+//    "[scheme]://[host]/[path to backup]?[parameters]"
+//
+// Options:
+//    ENCRYPTION_PASSPHRASE
+//    DETACHED
+//
+// %SeeAlso: BACKUP, RESTORE
+compact_backup_stmt:
+  COMPACT BACKUP FROM string_or_placeholder_list TO string_or_placeholder opt_with_options
+  {
+    $$.val = &tree.CompactBackup{From: $4.exprs(), To: $6.expr(), Options: $7.kvOptions()}
+  }
+| COMPACT error // SHOW HELP: COMPACT BACKUP
+
 partitioned_backup:
   string_or_placeholder
   {
@@ -2498,6 +2587,154 @@ copy_from_stmt:
     }
   }
 
+copy_to_stmt:
+  COPY table_name opt_column_list TO STDOUT opt_with_copy_options
+  {
+    name := $2.unresolvedObjectName().ToTableName()
+    $$.val = &tree.CopyTo{
+       Table: name,
+       Columns: $3.nameList(),
+       Options: $6.kvOptions(),
+    }
+  }
+| COPY '(' select_stmt ')' TO STDOUT opt_with_copy_options
+  {
+    $$.val = &tree.CopyTo{
+       Query: $3.slct(),
+       Options: $7.kvOptions(),
+    }
+  }
+
+// The options of COPY TO follow the syntax of Postgres, which accepts both a
+// generic option list, e.g. WITH (FORMAT csv, HEADER), and the syntax of
+// Postgres versions before 9.0, e.g. WITH CSV HEADER. The latter is converted
+// to the former.
+opt_with_copy_options:
+  opt_with '(' copy_generic_option_list ')'
+  {
+    $$.val = $3.kvOptions()
+  }
+| opt_with copy_legacy_option_list
+  {
+    $$.val = $2.kvOptions()
+  }
+| /* EMPTY */
+  {
+    $$.val = nil
+  }
+
+copy_generic_option_list:
+  copy_generic_option
+  {
+    $$.val = []tree.KVOption{$1.kvOption()}
+  }
+| copy_generic_option_list ',' copy_generic_option
+  {
+    $$.val = append($1.kvOptions(), $3.kvOption())
+  }
+
+copy_generic_option:
+  unrestricted_name
+  {
+    $$.val = tree.KVOption{Key: tree.Name($1)}
+  }
+| unrestricted_name copy_generic_option_arg
+  {
+    $$.val = tree.KVOption{Key: tree.Name($1), Value: $2.expr()}
+  }
+
+copy_generic_option_arg:
+  non_reserved_word_or_sconst
+  {
+    $$.val = tree.NewStrVal($1)
+  }
+| TRUE
+  {
+    $$.val = tree.NewStrVal("true")
+  }
+| FALSE
+  {
+    $$.val = tree.NewStrVal("false")
+  }
+| ON
+  {
+    $$.val = tree.NewStrVal("on")
+  }
+| '*'
+  {
+    $$.val = tree.UnqualifiedStar{}
+  }
+| '(' name_list ')'
+  {
+    names := $2.nameList()
+    exprs := make(tree.Exprs, len(names))
+    for i := range names {
+      exprs[i] = &tree.UnresolvedName{NumParts: 1, Parts: tree.NameParts{string(names[i])}}
+    }
+    $$.val = &tree.Tuple{Exprs: exprs}
+  }
+
+copy_legacy_option_list:
+  copy_legacy_option
+  {
+    $$.val = []tree.KVOption{$1.kvOption()}
+  }
+| copy_legacy_option_list copy_legacy_option
+  {
+    $$.val = append($1.kvOptions(), $2.kvOption())
+  }
+
+copy_legacy_option:
+  BINARY
+  {
+    $$.val = tree.KVOption{Key: "format", Value: tree.NewStrVal("binary")}
+  }
+| CSV
+  {
+    $$.val = tree.KVOption{Key: "format", Value: tree.NewStrVal("csv")}
+  }
+| HEADER
+  {
+    $$.val = tree.KVOption{Key: "header"}
+  }
+| DELIMITER opt_as SCONST
+  {
+    $$.val = tree.KVOption{Key: "delimiter", Value: tree.NewStrVal($3)}
+  }
+| NULL opt_as SCONST
+  {
+    $$.val = tree.KVOption{Key: "null", Value: tree.NewStrVal($3)}
+  }
+| QUOTE opt_as SCONST
+  {
+    $$.val = tree.KVOption{Key: "quote", Value: tree.NewStrVal($3)}
+  }
+| ESCAPE opt_as SCONST
+  {
+    $$.val = tree.KVOption{Key: "escape", Value: tree.NewStrVal($3)}
+  }
+| ENCODING SCONST
+  {
+    $$.val = tree.KVOption{Key: "encoding", Value: tree.NewStrVal($2)}
+  }
+| FORCE QUOTE '*'
+  {
+    $$.val = tree.KVOption{Key: "force_quote", Value: tree.UnqualifiedStar{}}
+  }
+| FORCE QUOTE name_list
+  {
+    names := $3.nameList()
+    exprs := make(tree.Exprs, len(names))
+    for i := range names {
+      exprs[i] = &tree.UnresolvedName{NumParts: 1, Parts: tree.NameParts{string(names[i])}}
+    }
+    $$.val = tree.KVOption{Key: "force_quote", Value: &tree.Tuple{Exprs: exprs}}
+  }
+
+opt_as:
+  AS {}
+| /* EMPTY */ {}
+
 // %Help: CANCEL
 // %Category: Group
 // %Text: CANCEL JOBS, CANCEL QUERIES, CANCEL SESSIONS
@@ -2646,7 +2883,7 @@ comment_text:
 // %Text:
 // CREATE DATABASE, CREATE TABLE, CREATE INDEX, CREATE TABLE AS,
 // CREATE USER, CREATE VIEW, CREATE SEQUENCE, CREATE STATISTICS,
-// CREATE ROLE, CREATE TYPE
+// CREATE ROLE, CREATE TYPE, CREATE FUNCTION, CREATE TRIGGER
 create_stmt:
   create_role_stmt     // EXTEND WITH HELP: CREATE ROLE
 | create_ddl_stmt      // help texts in sub-rule
@@ -2665,8 +2902,6 @@ create_unsupported:
 | CREATE EXTENSION name error { return unimplemented(sqllex, "create extension " + $3) }
 | CREATE FOREIGN TABLE error { return unimplemented(sqllex, "create foreign table") }
 | CREATE FOREIGN DATA error { return unimplemented(sqllex, "create fdw") }
-| CREATE FUNCTION error { return unimplementedWithIssueDetail(sqllex, 17511, "create function") }
-| CREATE OR REPLACE FUNCTION error { return unimplementedWithIssueDetail(sqllex, 17511, "create function") }
 | CREATE opt_or_replace opt_trusted opt_procedural LANGUAGE name error { return unimplementedWithIssueDetail(sqllex, 17511, "create language " + $6) }
 | CREATE MATERIALIZED VIEW error { return unimplementedWithIssue(sqllex, 41649) }
 | CREATE OPERATOR error { return unimplemented(sqllex, "create operator") }
@@ -2675,7 +2910,6 @@ create_unsupported:
 | CREATE SERVER error { return unimplemented(sqllex, "create server") }
 | CREATE SUBSCRIPTION error { return unimplemented(sqllex, "create subscription") }
 | CREATE TEXT error { return unimplementedWithIssueDetail(sqllex, 7821, "create text") }
-| CREATE TRIGGER error { return unimplementedWithIssueDetail(sqllex, 28296, "create") }
 
 opt_or_replace:
   OR REPLACE {}
@@ -2699,7 +2933,6 @@ drop_unsupported:
 | DROP EXTENSION name error { return unimplemented(sqllex, "drop extension " + $3) }
 | DROP FOREIGN TABLE error { return unimplemented(sqllex, "drop foreign table") }
 | DROP FOREIGN DATA error { return unimplemented(sqllex, "drop fdw") }
-| DROP FUNCTION error { return unimplementedWithIssueDetail(sqllex, 17511, "drop function") }
 | DROP opt_procedural LANGUAGE name error { return unimplementedWithIssueDetail(sqllex, 17511, "drop language " + $4) }
 | DROP OPERATOR error { return unimplemented(sqllex, "drop operator") }
 | DROP PUBLICATION error { return unimplemented(sqllex, "drop publication") }
@@ -2708,15 +2941,16 @@ drop_unsupported:
 | DROP SERVER error { return unimplemented(sqllex, "drop server") }
 | DROP SUBSCRIPTION error { return unimplemented(sqllex, "drop subscription") }
 | DROP TEXT error { return unimplementedWithIssueDetail(sqllex, 7821, "drop text") }
-| DROP TRIGGER error { return unimplementedWithIssueDetail(sqllex, 28296, "drop") }
 
 create_ddl_stmt:
   create_changefeed_stmt
 | create_database_stmt // EXTEND WITH HELP: CREATE DATABASE
+| create_func_stmt     // EXTEND WITH HELP: CREATE FUNCTION
 | create_index_stmt    // EXTEND WITH HELP: CREATE INDEX
 | create_schema_stmt   // EXTEND WITH HELP: CREATE SCHEMA
 | create_table_stmt    // EXTEND WITH HELP: CREATE TABLE
 | create_table_as_stmt // EXTEND WITH HELP: CREATE TABLE
+| create_trigger_stmt  // EXTEND WITH HELP: CREATE TRIGGER
 // Error case for both CREATE TABLE and CREATE TABLE ... AS in one
 | CREATE opt_temp_create_table TABLE error   // SHOW HELP: CREATE TABLE
 | create_type_stmt     // EXTEND WITH HELP: CREATE TYPE
@@ -2835,6 +3069,28 @@ create_changefeed_stmt:
       Options: $5.kvOptions(),
     }
   }
+| CREATE CHANGEFEED opt_changefeed_sink opt_with_options AS changefeed_select
+  {
+    sel := $6.selectStmt().(*tree.SelectClause)
+    tn := sel.From.Tables[0].(*tree.TableName)
+    $$.val = &tree.CreateChangefeed{
+      Targets: tree.TargetList{Tables: tree.TablePatterns{tn}},
+      SinkURI: $3.expr(),
+      Options: $4.kvOptions(),
+      Select:  sel,
+    }
+  }
+| EXPERIMENTAL CHANGEFEED opt_with_options AS changefeed_select
+  {
+    /* SKIP DOC */
+    sel := $5.selectStmt().(*tree.SelectClause)
+    tn := sel.From.Tables[0].(*tree.TableName)
+    $$.val = &tree.CreateChangefeed{
+      Targets: tree.TargetList{Tables: tree.TablePatterns{tn}},
+      Options: $3.kvOptions(),
+      Select:  sel,
+    }
+  }
 
 changefeed_targets:
   single_table_pattern_list
@@ -2856,6 +3112,18 @@ single_table_pattern_list:
     $$.val = append($1.tablePatterns(), $3.unresolvedObjectName().ToUnresolvedName())
   }
 
+// changefeed_select is the query of a changefeed created with the AS SELECT
+// syntax. It projects and filters the rows of a single table.
+changefeed_select:
+  SELECT target_list FROM table_name opt_where_clause
+  {
+    name := $4.unresolvedObjectName().ToTableName()
+    $$.val = &tree.SelectClause{
+      Exprs: $2.selExprs(),
+      From:  tree.From{Tables: tree.TableExprs{&name}},
+      Where: tree.NewWhere(tree.AstWhere, $5.expr()),
+    }
+  }
 
 opt_changefeed_sink:
   INTO string_or_placeholder
@@ -2912,7 +3180,7 @@ discard_stmt:
 // %Category: Group
 // %Text:
 // DROP DATABASE, DROP INDEX, DROP TABLE, DROP VIEW, DROP SEQUENCE,
-// DROP USER, DROP ROLE, DROP TYPE
+// DROP USER, DROP ROLE, DROP TYPE, DROP FUNCTION, DROP TRIGGER
 drop_stmt:
   drop_ddl_stmt      // help texts in sub-rule
 | drop_role_stmt     // EXTEND WITH HELP: DROP ROLE
@@ -2922,12 +3190,54 @@ drop_stmt:
 
 drop_ddl_stmt:
   drop_database_stmt // EXTEND WITH HELP: DROP DATABASE
+| drop_func_stmt     // EXTEND WITH HELP: DROP FUNCTION
 | drop_index_stmt    // EXTEND WITH HELP: DROP INDEX
 | drop_table_stmt    // EXTEND WITH HELP: DROP TABLE
+| drop_trigger_stmt  // EXTEND WITH HELP: DROP TRIGGER
 | drop_view_stmt     // EXTEND WITH HELP: DROP VIEW
 | drop_sequence_stmt // EXTEND WITH HELP: DROP SEQUENCE
 | drop_type_stmt     // EXTEND WITH HELP: DROP TYPE
 
+// %Help: DROP FUNCTION - remove a function
+// %Category: DDL
+// %Text: DROP FUNCTION [IF EXISTS] <name> [, ...] [CASCADE | RESTRICT]
+// %SeeAlso: CREATE FUNCTION
+drop_func_stmt:
+  DROP FUNCTION table_name_list opt_drop_behavior
+  {
+    $$.val = &tree.DropFunction{Names: $3.tableNames(), IfExists: false, DropBehavior: $4.dropBehavior()}
+  }
+| DROP FUNCTION IF EXISTS table_name_list opt_drop_behavior
+  {
+    $$.val = &tree.DropFunction{Names: $5.tableNames(), IfExists: true, DropBehavior: $6.dropBehavior()}
+  }
+| DROP FUNCTION error // SHOW HELP: DROP FUNCTION
+
+// %Help: DROP TRIGGER - remove a trigger
+// %Category: DDL
+// %Text: DROP TRIGGER [IF EXISTS] <name> ON <tablename> [CASCADE | RESTRICT]
+// %SeeAlso: CREATE TRIGGER
+drop_trigger_stmt:
+  DROP TRIGGER name ON table_name opt_drop_behavior
+  {
+    $$.val = &tree.DropTrigger{
+      Name: tree.Name($3),
+      Table: $5.unresolvedObjectName().ToTableName(),
+      IfExists: false,
+      DropBehavior: $6.dropBehavior(),
+    }
+  }
+| DROP TRIGGER IF EXISTS name ON table_name opt_drop_behavior
+  {
+    $$.val = &tree.DropTrigger{
+      Name: tree.Name($5),
+      Table: $7.unresolvedObjectName().ToTableName(),
+      IfExists: true,
+      DropBehavior: $8.dropBehavior(),
+    }
+  }
+| DROP TRIGGER error // SHOW HELP: DROP TRIGGER
+
 // %Help: DROP VIEW - remove a view
 // %Category: DDL
 // %Text: DROP VIEW [IF EXISTS] <tablename> [, ...] [CASCADE | RESTRICT]
@@ -3186,6 +3496,7 @@ preparable_stmt:
   alter_stmt     // help texts in sub-rule
 | backup_stmt    // EXTEND WITH HELP: BACKUP
 | cancel_stmt    // help texts in sub-rule
+| compact_backup_stmt // EXTEND WITH HELP: COMPACT BACKUP
 | create_stmt    // help texts in sub-rule
 | delete_stmt    // EXTEND WITH HELP: DELETE
 | drop_stmt      // help texts in sub-rule
@@ -3331,11 +3642,12 @@ deallocate_stmt:
 //   GRANT <roles...> TO <grantees...> [WITH ADMIN OPTION]
 //
 // Privileges:
-//   CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE
+//   CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE, EXECUTE
 //
 // Targets:
 //   DATABASE <databasename> [, ...]
 //   [TABLE] [<databasename> .] { <tablename> | * } [, ...]
+//   FUNCTION <functionname> [, ...]
 //
 // %SeeAlso: REVOKE, WEBDOCS/grant.html
 grant_stmt:
@@ -3343,6 +3655,10 @@ grant_stmt:
   {
     $$.val = &tree.Grant{Privileges: $2.privilegeList(), Grantees: $6.nameList(), Targets: $4.targetList()}
   }
+| GRANT privileges ON FUNCTION table_name_list TO name_list
+  {
+    $$.val = &tree.Grant{Privileges: $2.privilegeList(), Grantees: $7.nameList(), Targets: tree.TargetList{Functions: $5.tableNames()}}
+  }
 | GRANT privilege_list TO name_list
   {
     $$.val = &tree.GrantRole{Roles: $2.nameList(), Members: $4.nameList(), AdminOption: false}
@@ -3362,11 +3678,12 @@ grant_stmt:
 //   REVOKE [ADMIN OPTION FOR] <roles...> FROM <grantees...>
 //
 // Privileges:
-//   CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE
+//   CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE, EXECUTE
 //
 // Targets:
 //   DATABASE <databasename> [, <databasename>]...
 //   [TABLE] [<databasename> .] { <tablename> | * } [, ...]
+//   FUNCTION <functionname> [, ...]
 //
 // %SeeAlso: GRANT, WEBDOCS/revoke.html
 revoke_stmt:
@@ -3374,6 +3691,10 @@ revoke_stmt:
   {
     $$.val = &tree.Revoke{Privileges: $2.privilegeList(), Grantees: $6.nameList(), Targets: $4.targetList()}
   }
+| REVOKE privileges ON FUNCTION table_name_list FROM name_list
+  {
+    $$.val = &tree.Revoke{Privileges: $2.privilegeList(), Grantees: $7.nameList(), Targets: tree.TargetList{Functions: $5.tableNames()}}
+  }
 | REVOKE privilege_list FROM name_list
   {
     $$.val = &tree.RevokeRole{Roles: $2.nameList(), Members: $4.nameList(), AdminOption: false }
@@ -3465,8 +3786,8 @@ use_stmt:
 // SET remainder, e.g. SET TRANSACTION
 nonpreparable_set_stmt:
   set_transaction_stmt // EXTEND WITH HELP: SET TRANSACTION
+| set_constraints_stmt // EXTEND WITH HELP: SET CONSTRAINTS
 | set_exprs_internal   { /* SKIP DOC */ }
-| SET CONSTRAINTS error { return unimplemented(sqllex, "set constraints") }
 | SET LOCAL error { return unimplementedWithIssue(sqllex, 32562) }
 
 // SET SESSION / SET CLUSTER SETTING
@@ -3649,6 +3970,26 @@ set_transaction_stmt:
   }
 | SET SESSION TRANSACTION error // SHOW HELP: SET TRANSACTION
 
+// %Help: SET CONSTRAINTS - set when the deferrable constraints are checked
+// %Category: Txn
+// %Text: SET CONSTRAINTS ALL { DEFERRED | IMMEDIATE }
+//
+// DEFERRED checks the constraints declared DEFERRABLE when the current
+// transaction commits. IMMEDIATE checks them after each statement, and
+// checks right away the violations found since they were deferred.
+// %SeeAlso: SET TRANSACTION
+set_constraints_stmt:
+  SET CONSTRAINTS ALL DEFERRED
+  {
+    $$.val = &tree.SetConstraints{Deferred: true}
+  }
+| SET CONSTRAINTS ALL IMMEDIATE
+  {
+    $$.val = &tree.SetConstraints{}
+  }
+| SET CONSTRAINTS ALL error // SHOW HELP: SET CONSTRAINTS
+| SET CONSTRAINTS error { return unimplemented(sqllex, "set constraints") }
+
 generic_set:
   var_name to_or_eq var_list
   {
@@ -3868,14 +4209,208 @@ show_stmt:
 | SHOW error                // SHOW HELP: SHOW
 | show_last_query_stats_stmt // EXTEND WITH HELP: SHOW LAST QUERY STATISTICS
 
-// Cursors are not yet supported by CockroachDB. CLOSE ALL is safe to no-op
-// since there will be no open cursors.
+// %Help: CLOSE - close a cursor
+// %Category: Misc
+// %Text: CLOSE { <name> | ALL }
+// %SeeAlso: DECLARE, FETCH, MOVE
 close_cursor_stmt:
-	CLOSE ALL { }
-| CLOSE cursor_name { return unimplementedWithIssue(sqllex, 41412) }
+  CLOSE ALL
+  {
+    $$.val = &tree.CloseCursor{All: true}
+  }
+| CLOSE cursor_name
+  {
+    $$.val = &tree.CloseCursor{Name: tree.Name($2)}
+  }
+| CLOSE error // SHOW HELP: CLOSE
 
+// %Help: DECLARE - define a cursor
+// %Category: Misc
+// %Text:
+// DECLARE <name> [INSENSITIVE] [NO SCROLL] CURSOR [ { WITH | WITHOUT } HOLD ] FOR <selectclause>
+//
+// Cursors are only allowed inside an explicit transaction, unless they are
+// declared WITH HOLD.
+// %SeeAlso: FETCH, MOVE, CLOSE, SELECT
 declare_cursor_stmt:
-	DECLARE { return unimplementedWithIssue(sqllex, 41412) }
+  DECLARE cursor_name opt_cursor_insensitive opt_cursor_no_scroll CURSOR opt_hold FOR select_stmt
+  {
+    $$.val = &tree.DeclareCursor{
+      Name: tree.Name($2),
+      Insensitive: $3.bool(),
+      NoScroll: $4.bool(),
+      Hold: $6.bool(),
+      Select: $8.slct(),
+    }
+  }
+| DECLARE cursor_name BINARY error { return unimplementedWithIssueDetail(sqllex, 41412, "binary") }
+| DECLARE error // SHOW HELP: DECLARE
+
+opt_cursor_insensitive:
+  INSENSITIVE
+  {
+    $$.val = true
+  }
+| /* EMPTY */
+  {
+    $$.val = false
+  }
+
+opt_cursor_no_scroll:
+  NO SCROLL
+  {
+    $$.val = true
+  }
+| SCROLL
+  {
+    return unimplementedWithIssueDetail(sqllex, 41412, "scroll")
+  }
+| /* EMPTY */
+  {
+    $$.val = false
+  }
+
+opt_hold:
+  WITH HOLD
+  {
+    $$.val = true
+  }
+| WITHOUT HOLD
+  {
+    $$.val = false
+  }
+| /* EMPTY */
+  {
+    $$.val = false
+  }
+
+// %Help: FETCH - retrieve rows from a cursor
+// %Category: Misc
+// %Text:
+// FETCH [ <direction> ] [ FROM | IN ] <name>
+//
+// Directions:
+//   NEXT
+//   FORWARD
+//   FORWARD <count>
+//   FORWARD ALL
+//   <count>
+//   ALL
+// %SeeAlso: DECLARE, MOVE, CLOSE
+fetch_cursor_stmt:
+  FETCH cursor_movement_specifier
+  {
+    $$.val = &tree.FetchCursor{CursorStmt: $2.cursorStmt()}
+  }
+| FETCH error // SHOW HELP: FETCH
+
+// %Help: MOVE - reposition a cursor without retrieving rows
+// %Category: Misc
+// %Text:
+// MOVE [ <direction> ] [ FROM | IN ] <name>
+//
+// Directions:
+//   NEXT
+//   FORWARD
+//   FORWARD <count>
+//   FORWARD ALL
+//   <count>
+//   ALL
+// %SeeAlso: DECLARE, FETCH, CLOSE
+move_cursor_stmt:
+  MOVE cursor_movement_specifier
+  {
+    $$.val = &tree.MoveCursor{CursorStmt: $2.cursorStmt()}
+  }
+| MOVE error // SHOW HELP: MOVE
+
+cursor_movement_specifier:
+  cursor_name
+  {
+    $$.val = tree.CursorStmt{Name: tree.Name($1), Count: 1}
+  }
+| from_or_in cursor_name
+  {
+    $$.val = tree.CursorStmt{Name: tree.Name($2), Count: 1}
+  }
+| next_or_forward opt_from_or_in cursor_name
+  {
+    $$.val = tree.CursorStmt{Name: tree.Name($3), Count: 1}
+  }
+| signed_iconst64 opt_from_or_in cursor_name
+  {
+    $$.val = tree.CursorStmt{Name: tree.Name($3), Count: $1.int64()}
+  }
+| FORWARD signed_iconst64 opt_from_or_in cursor_name
+  {
+    $$.val = tree.CursorStmt{Name: tree.Name($4), Count: $2.int64()}
+  }
+| ALL opt_from_or_in cursor_name
+  {
+    $$.val = tree.CursorStmt{Name: tree.Name($3), All: true}
+  }
+| FORWARD ALL opt_from_or_in cursor_name
+  {
+    $$.val = tree.CursorStmt{Name: tree.Name($4), All: true}
+  }
+
+next_or_forward:
+  NEXT {}
+| FORWARD {}
+
+from_or_in:
+  FROM {}
+| IN {}
+
+opt_from_or_in:
+  from_or_in {}
+| /* EMPTY */ {}
+
+// %Help: LISTEN - listen for notifications on a channel
+// %Category: Misc
+// %Text: LISTEN <channel>
+//
+// The session starts receiving the notifications sent on the channel once the
+// current transaction commits.
+// %SeeAlso: NOTIFY, UNLISTEN
+listen_stmt:
+  LISTEN name
+  {
+    $$.val = &tree.Listen{ChannelName: tree.Name($2)}
+  }
+| LISTEN error // SHOW HELP: LISTEN
+
+// %Help: NOTIFY - send a notification to the sessions listening on a channel
+// %Category: Misc
+// %Text: NOTIFY <channel> [, <payload>]
+//
+// The notification is only delivered if the current transaction commits.
+// %SeeAlso: LISTEN, UNLISTEN
+notify_stmt:
+  NOTIFY name
+  {
+    $$.val = &tree.Notify{ChannelName: tree.Name($2)}
+  }
+| NOTIFY name ',' SCONST
+  {
+    $$.val = &tree.Notify{ChannelName: tree.Name($2), Payload: $4}
+  }
+| NOTIFY error // SHOW HELP: NOTIFY
+
+// %Help: UNLISTEN - stop listening for notifications
+// %Category: Misc
+// %Text: UNLISTEN { <channel> | * }
+// %SeeAlso: LISTEN, NOTIFY
+unlisten_stmt:
+  UNLISTEN name
+  {
+    $$.val = &tree.Unlisten{ChannelName: tree.Name($2)}
+  }
+| UNLISTEN '*'
+  {
+    $$.val = &tree.Unlisten{All: true}
+  }
+| UNLISTEN error // SHOW HELP: UNLISTEN
 
 reindex_stmt:
   REINDEX TABLE error
@@ -5302,7 +5837,7 @@ col_qualification_elem:
   {
     $$.val = &tree.ColumnDefault{Expr: $2.expr()}
   }
-| REFERENCES table_name opt_name_parens key_match reference_actions
+| REFERENCES table_name opt_name_parens key_match reference_actions opt_deferrable
  {
     name := $2.unresolvedObjectName().ToTableName()
     $$.val = &tree.ColumnFKConstraint{
@@ -5310,6 +5845,7 @@ col_qualification_elem:
       Col: tree.Name($3),
       Actions: $5.referenceActions(),
       Match: $4.compositeKeyMatchMethod(),
+      Deferrability: $6.constraintDeferrability(),
     }
  }
 | generated_as '(' a_expr ')' STORED
@@ -5395,6 +5931,9 @@ table_constraint:
 constraint_elem:
   CHECK '(' a_expr ')' opt_deferrable
   {
+    if $5.constraintDeferrability() != tree.NotDeferrable {
+      return unimplementedWithIssueDetail(sqllex, 31632, "deferrable check")
+    }
     $$.val = &tree.CheckConstraintTableDef{
       Expr: $3.expr(),
     }
@@ -5409,6 +5948,7 @@ constraint_elem:
         PartitionBy: $7.partitionBy(),
         Predicate: $9.expr(),
       },
+      Deferrability: $8.constraintDeferrability(),
     }
   }
 | PRIMARY KEY '(' index_params ')' opt_hash_sharded opt_interleave
@@ -5432,6 +5972,7 @@ constraint_elem:
       ToCols: $8.nameList(),
       Match: $9.compositeKeyMatchMethod(),
       Actions: $10.referenceActions(),
+      Deferrability: $11.constraintDeferrability(),
     }
   }
 | EXCLUDE USING error
@@ -5541,13 +6082,33 @@ create_as_col_qualification_elem:
     $$.val = tree.PrimaryKeyConstraint{}
   }
 
+// INITIALLY DEFERRED implies DEFERRABLE, and INITIALLY IMMEDIATE alone is the
+// default, like in Postgres.
 opt_deferrable:
-  /* EMPTY */ { /* no error */ }
-| DEFERRABLE { return unimplementedWithIssueDetail(sqllex, 31632, "deferrable") }
-| DEFERRABLE INITIALLY DEFERRED { return unimplementedWithIssueDetail(sqllex, 31632, "def initially deferred") }
-| DEFERRABLE INITIALLY IMMEDIATE { return unimplementedWithIssueDetail(sqllex, 31632, "def initially immediate") }
-| INITIALLY DEFERRED { return unimplementedWithIssueDetail(sqllex, 31632, "initially deferred") }
-| INITIALLY IMMEDIATE { return unimplementedWithIssueDetail(sqllex, 31632, "initially immediate") }
+  /* EMPTY */
+  {
+    $$.val = tree.NotDeferrable
+  }
+| DEFERRABLE
+  {
+    $$.val = tree.DeferrableInitiallyImmediate
+  }
+| DEFERRABLE INITIALLY DEFERRED
+  {
+    $$.val = tree.DeferrableInitiallyDeferred
+  }
+| DEFERRABLE INITIALLY IMMEDIATE
+  {
+    $$.val = tree.DeferrableInitiallyImmediate
+  }
+| INITIALLY DEFERRED
+  {
+    $$.val = tree.DeferrableInitiallyDeferred
+  }
+| INITIALLY IMMEDIATE
+  {
+    $$.val = tree.NotDeferrable
+  }
 
 storing:
   COVERING
@@ -5833,6 +6394,222 @@ role_or_group_or_user:
     $$.val = false
   }
 
+// %Help: CREATE FUNCTION - create a new function
+// %Category: DDL
+// %Text:
+// CREATE [OR REPLACE] FUNCTION <name> ( [ [<argname>] <argtype> [, ...] ] )
+//   RETURNS <rettype>
+//   LANGUAGE SQL
+//   [ IMMUTABLE | STABLE | VOLATILE ]
+//   AS '<select statement>'
+//
+// The body of the function must be a SELECT statement that returns a single
+// column. The arguments are referenced by name or by position ($1, $2, ...).
+// %SeeAlso: DROP FUNCTION
+create_func_stmt:
+  CREATE FUNCTION func_create_name '(' opt_func_param_list ')' RETURNS typename func_option_list
+  {
+    n, err := makeCreateFunction($3.unresolvedObjectName(), false /* replace */, $5.funcParams(), $8.typeReference(), $9.functionOptions())
+    if err != nil {
+      return setErr(sqllex, err)
+    }
+    $$.val = n
+  }
+| CREATE OR REPLACE FUNCTION func_create_name '(' opt_func_param_list ')' RETURNS typename func_option_list
+  {
+    n, err := makeCreateFunction($5.unresolvedObjectName(), true /* replace */, $7.funcParams(), $10.typeReference(), $11.functionOptions())
+    if err != nil {
+      return setErr(sqllex, err)
+    }
+    $$.val = n
+  }
+| CREATE FUNCTION error // SHOW HELP: CREATE FUNCTION
+| CREATE OR REPLACE FUNCTION error // SHOW HELP: CREATE FUNCTION
+
+func_create_name:
+  db_object_name
+
+// %Help: CREATE TRIGGER - create a new trigger
+// %Category: DDL
+// %Text:
+// CREATE TRIGGER <name> { BEFORE | AFTER } <event> [OR ...]
+//   ON <tablename>
+//   [ FOR [EACH] { ROW | STATEMENT } ]
+//   [ WHEN ( <condition> ) ]
+//   EXECUTE { FUNCTION | PROCEDURE } <funcname> ( [<argument> [, ...]] )
+//
+// Events:
+//   INSERT, UPDATE, DELETE
+//
+// The condition and the arguments of row-level triggers can reference the
+// columns of the new and old rows as NEW.<colname> and OLD.<colname>.
+//
+// Triggers are experimental and must be enabled with
+// SET experimental_enable_triggers = on. Unlike in PostgreSQL:
+// - the function is a SQL function, and its arguments are passed
+//   explicitly instead of through NEW and OLD;
+// - a BEFORE row-level trigger skips the row if its function returns
+//   NULL or false, but cannot modify the new row;
+// - the function cannot modify data;
+// - UPSERT and INSERT ... ON CONFLICT DO UPDATE are rejected on tables
+//   with triggers.
+// %SeeAlso: DROP TRIGGER, CREATE FUNCTION
+create_trigger_stmt:
+  CREATE TRIGGER name trigger_action_time trigger_events ON table_name opt_trigger_for_each opt_trigger_when EXECUTE function_or_procedure db_object_name '(' opt_expr_list ')'
+  {
+    $$.val = &tree.CreateTrigger{
+      Name: tree.Name($3),
+      ActionTime: $4.triggerActionTime(),
+      Events: $5.triggerEvents(),
+      Table: $7.unresolvedObjectName().ToTableName(),
+      ForEachRow: $8.bool(),
+      When: $9.expr(),
+      FuncName: $12.unresolvedObjectName().ToTableName(),
+      FuncArgs: $14.exprs(),
+    }
+  }
+| CREATE TRIGGER error // SHOW HELP: CREATE TRIGGER
+
+trigger_action_time:
+  BEFORE
+  {
+    $$.val = tree.TriggerBefore
+  }
+| AFTER
+  {
+    $$.val = tree.TriggerAfter
+  }
+
+trigger_events:
+  trigger_event
+| trigger_events OR trigger_event
+  {
+    if $1.triggerEvents() & $3.triggerEvents() != 0 {
+      sqllex.Error("duplicate trigger events specified")
+      return 1
+    }
+    $$.val = $1.triggerEvents() | $3.triggerEvents()
+  }
+
+trigger_event:
+  INSERT
+  {
+    $$.val = tree.TriggerEventInsert
+  }
+| UPDATE
+  {
+    $$.val = tree.TriggerEventUpdate
+  }
+| UPDATE OF name_list
+  {
+    return unimplementedWithIssueDetail(sqllex, 28296, "update of")
+  }
+| DELETE
+  {
+    $$.val = tree.TriggerEventDelete
+  }
+| TRUNCATE
+  {
+    return unimplementedWithIssueDetail(sqllex, 28296, "truncate")
+  }
+
+// Like in Postgres, triggers are statement-level by default.
+opt_trigger_for_each:
+  FOR opt_each ROW
+  {
+    $$.val = true
+  }
+| FOR opt_each STATEMENT
+  {
+    $$.val = false
+  }
+| /* EMPTY */
+  {
+    $$.val = false
+  }
+
+opt_each:
+  EACH {}
+| /* EMPTY */ {}
+
+opt_trigger_when:
+  WHEN '(' a_expr ')'
+  {
+    $$.val = $3.expr()
+  }
+| /* EMPTY */
+  {
+    $$.val = tree.Expr(nil)
+  }
+
+// PROCEDURE is accepted for compatibility with older versions of Postgres.
+function_or_procedure:
+  FUNCTION {}
+| PROCEDURE {}
+
+opt_func_param_list:
+  func_param_list
+| /* EMPTY */
+  {
+    $$.val = tree.FuncParams(nil)
+  }
+
+func_param_list:
+  func_param
+  {
+    $$.val = tree.FuncParams{$1.funcParam()}
+  }
+| func_param_list ',' func_param
+  {
+    $$.val = append($1.funcParams(), $3.funcParam())
+  }
+
+func_param:
+  param_name typename
+  {
+    $$.val = tree.FuncParam{Name: tree.Name($1), Type: $2.typeReference()}
+  }
+| typename
+  {
+    $$.val = tree.FuncParam{Type: $1.typeReference()}
+  }
+
+param_name:
+  IDENT
+| unreserved_keyword
+
+func_option_list:
+  func_option
+| func_option_list func_option
+  {
+    if err := $1.functionOptions().CombineWith($2.functionOptions()); err != nil {
+      return setErr(sqllex, err)
+    }
+  }
+
+func_option:
+  LANGUAGE non_reserved_word_or_sconst
+  {
+    $$.val = &tree.FunctionOptions{Language: $2}
+  }
+| IMMUTABLE
+  {
+    $$.val = &tree.FunctionOptions{Volatility: tree.VolatilityImmutable}
+  }
+| STABLE
+  {
+    $$.val = &tree.FunctionOptions{Volatility: tree.VolatilityStable}
+  }
+| VOLATILE
+  {
+    $$.val = &tree.FunctionOptions{Volatility: tree.VolatilityVolatile}
+  }
+| AS SCONST
+  {
+    body := $2
+    $$.val = &tree.FunctionOptions{Body: &body}
+  }
+
 // %Help: CREATE VIEW - create a new view
 // %Category: DDL
 // %Text: CREATE [TEMPORARY | TEMP] VIEW <viewname> [( <colnames...> )] AS <source>
@@ -10616,6 +11393,7 @@ unreserved_keyword:
 | BACKUP
 | BEFORE
 | BEGIN
+| BINARY
 | BUCKET_COUNT
 | BUNDLE
 | BY
@@ -10641,8 +11419,10 @@ unreserved_keyword:
 | COPY
 | COVERING
 | CREATEROLE
+| CSV
 | CUBE
 | CURRENT
+| CURSOR
 | CYCLE
 | DATA
 | DATABASE
@@ -10651,6 +11431,7 @@ unreserved_keyword:
 | DEALLOCATE
 | DECLARE
 | DELETE
+| DELIMITER
 | DEFAULTS
 | DEFERRED
 | DETACHED
@@ -10658,6 +11439,7 @@ unreserved_keyword:
 | DOMAIN
 | DOUBLE
 | DROP
+| EACH
 | ENCODING
 | ENCRYPTION_PASSPHRASE
 | ENUM
@@ -10679,7 +11461,9 @@ unreserved_keyword:
 | FILTER
 | FIRST
 | FOLLOWING
+| FORCE
 | FORCE_INDEX
+| FORWARD
 | FUNCTION
 | GENERATED
 | GEOMETRYCOLLECTION
@@ -10687,11 +11471,14 @@ unreserved_keyword:
 | GRANTS
 | GROUPS
 | HASH
+| HEADER
 | HIGH
 | HISTOGRAM
+| HOLD
 | HOUR
 | IDENTITY
 | IMMEDIATE
+| IMMUTABLE
 | IMPORT
 | INCLUDE
 | INCLUDING
@@ -10699,6 +11486,7 @@ unreserved_keyword:
 | INCREMENTAL
 | INDEXES
 | INJECT
+| INSENSITIVE
 | INSERT
 | INTERLEAVE
 | INVERTED
@@ -10718,6 +11506,7 @@ unreserved_keyword:
 | LEVEL
 | LINESTRING
 | LIST
+| LISTEN
 | LOCAL
 | LOCKED
 | LOGIN
@@ -10733,6 +11522,7 @@ unreserved_keyword:
 | MULTIPOINT
 | MULTIPOLYGON
 | MONTH
+| MOVE
 | NAMES
 | NAN
 | NEVER
@@ -10742,6 +11532,7 @@ unreserved_keyword:
 | NO_INDEX_JOIN
 | NOCREATEROLE
 | NOLOGIN
+| NOTIFY
 | NOWAIT
 | NULLS
 | IGNORE_FOREIGN_KEYS
@@ -10770,10 +11561,12 @@ unreserved_keyword:
 | PREPARE
 | PRESERVE
 | PRIORITY
+| PROCEDURE
 | PUBLIC
 | PUBLICATION
 | QUERIES
 | QUERY
+| QUOTE
 | RANGE
 | RANGES
 | READ
@@ -10790,6 +11583,7 @@ unreserved_keyword:
 | RESTRICT
 | RESUME
 | RETRY
+| RETURNS
 | REVISION_HISTORY
 | REVOKE
 | ROLE
@@ -10807,6 +11601,7 @@ unreserved_keyword:
 | SCATTER
 | SCHEMA
 | SCHEMAS
+| SCROLL
 | SCRUB
 | SEARCH
 | SECOND
@@ -10824,9 +11619,12 @@ unreserved_keyword:
 | SNAPSHOT
 | SPLIT
 | SQL
+| STABLE
 | START
+| STATEMENT
 | STATISTICS
 | STDIN
+| STDOUT
 | STORAGE
 | STORE
 | STORED
@@ -10853,6 +11651,7 @@ unreserved_keyword:
 | UNBOUNDED
 | UNCOMMITTED
 | UNKNOWN
+| UNLISTEN
 | UNLOGGED
 | UNSPLIT
 | UNTIL
@@ -10865,6 +11664,7 @@ unreserved_keyword:
 | VALUE
 | VARYING
 | VIEW
+| VOLATILE
 | WITHIN
 | WITHOUT
 | WRITE
@@ -208,6 +208,7 @@ var pgCatalog = virtualSchema{
 		sqlbase.PgCatalogCollationTableID:           pgCatalogCollationTable,
 		sqlbase.PgCatalogConstraintTableID:          pgCatalogConstraintTable,
 		sqlbase.PgCatalogConversionTableID:          pgCatalogConversionTable,
+		sqlbase.PgCatalogCursorsTableID:             pgCatalogCursorsTable,
 		sqlbase.PgCatalogDatabaseTableID:            pgCatalogDatabaseTable,
 		sqlbase.PgCatalogDefaultACLTableID:          pgCatalogDefaultACLTable,
 		sqlbase.PgCatalogDependTableID:              pgCatalogDependTable,
@@ -878,6 +879,8 @@ func populateTableConstraints(
 		consrc := tree.DNull
 		conbin := tree.DNull
 		condef := tree.DNull
+		condeferrable := tree.DBoolFalse
+		condeferred := tree.DBoolFalse
 
 		// Determine constraint kind-specific fields.
 		var err error
@@ -896,6 +899,8 @@ func populateTableConstraints(
 		case sqlbase.ConstraintTypeFK:
 			oid = h.ForeignKeyConstraintOid(db, scName, table.TableDesc(), con.FK)
 			contype = conTypeFK
+			condeferrable = tree.MakeDBool(tree.DBool(con.FK.Deferrable))
+			condeferred = tree.MakeDBool(tree.DBool(con.FK.InitiallyDeferred))
 			// Foreign keys don't have a single linked index. Pick the first one
 			// that matches on the referenced table.
 			referencedTable, err := tableLookup.getTableByID(con.FK.ReferencedTableID)
@@ -942,7 +947,14 @@ func populateTableConstraints(
 			f.WriteString("UNIQUE (")
 			con.Index.ColNamesFormat(f)
 			f.WriteByte(')')
+			if con.Index.InitiallyDeferred {
+				f.WriteString(" DEFERRABLE INITIALLY DEFERRED")
+			} else if con.Index.DeferrableUnique {
+				f.WriteString(" DEFERRABLE INITIALLY IMMEDIATE")
+			}
 			condef = tree.NewDString(f.CloseAndGetString())
+			condeferrable = tree.MakeDBool(tree.DBool(con.Index.DeferrableUnique))
+			condeferred = tree.MakeDBool(tree.DBool(con.Index.InitiallyDeferred))
 
 		case sqlbase.ConstraintTypeCheck:
 			oid = h.CheckConstraintOid(db, scName, table.TableDesc(), con.CheckConstraint)
@@ -960,8 +972,8 @@ func populateTableConstraints(
 			dNameOrNull(conName), // conname
 			namespaceOid,         // connamespace
 			contype,              // contype
-			tree.DBoolFalse,      // condeferrable
-			tree.DBoolFalse,      // condeferred
+			condeferrable,        // condeferrable
+			condeferred,          // condeferred
 			tree.MakeDBool(tree.DBool(!con.Unvalidated)), // convalidated
 			tblOid,         // conrelid
 			oidZero,        // contypid
@@ -1176,6 +1188,42 @@ CREATE TABLE pg_catalog.pg_conversion (
 	},
 }
 
+// pgCatalogCursorsTable implements the pg_cursors table.
+// The statement field contains the formatted version of the DECLARE
+// statement.
+var pgCatalogCursorsTable = virtualSchemaTable{
+	comment: `cursors
+https://www.postgresql.org/docs/9.6/view-pg-cursors.html`,
+	schema: `
+CREATE TABLE pg_catalog.pg_cursors (
+	name TEXT,
+	statement TEXT,
+	is_holdable BOOL,
+	is_binary BOOL,
+	is_scrollable BOOL,
+	creation_time TIMESTAMPTZ
+)`,
+	populate: func(ctx context.Context, p *planner, dbContext *sqlbase.ImmutableDatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		for name, c := range p.sqlCursors.list() {
+			ts, err := tree.MakeDTimestampTZ(c.created, time.Microsecond)
+			if err != nil {
+				return err
+			}
+			if err := addRow(
+				tree.NewDString(string(name)),
+				tree.NewDString(c.statement),
+				tree.MakeDBool(tree.DBool(c.hold)),
+				tree.DBoolFalse,
+				tree.DBoolFalse,
+				ts,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
 var pgCatalogDatabaseTable = virtualSchemaTable{
 	comment: `available databases (incomplete)
 https://www.postgresql.org/docs/9.5/catalog-pg-database.html`,
@@ -1834,7 +1882,7 @@ CREATE TABLE pg_catalog.pg_language (
 }
 
 var pgCatalogLocksTable = virtualSchemaTable{
-	comment: `locks held by active processes (empty - feature does not exist)
+	comment: `locks held by active processes (only advisory locks)
 https://www.postgresql.org/docs/9.6/view-pg-locks.html`,
 	schema: `
 CREATE TABLE pg_catalog.pg_locks (
@@ -1855,6 +1903,33 @@ CREATE TABLE pg_catalog.pg_locks (
   fastpath BOOLEAN
 )`,
 	populate: func(ctx context.Context, p *planner, dbContext *sqlbase.ImmutableDatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		locks, err := listAdvisoryLocks(ctx, p.ExecCfg().DB, p.ExecCfg().Codec)
+		if err != nil {
+			return err
+		}
+		advisory := tree.NewDString("advisory")
+		exclusiveLock := tree.NewDString("ExclusiveLock")
+		for _, l := range locks {
+			if err := addRow(
+				advisory,                                // locktype
+				dbOid(l.dbID),                           // database
+				tree.DNull,                              // relation
+				tree.DNull,                              // page
+				tree.DNull,                              // tuple
+				tree.DNull,                              // virtualxid
+				tree.DNull,                              // transactionid
+				tree.NewDOid(tree.DInt(l.key.ClassID)),  // classid
+				tree.NewDOid(tree.DInt(l.key.ObjID)),    // objid
+				tree.NewDInt(tree.DInt(l.key.ObjSubID)), // objsubid
+				tree.DNull,                              // virtualtransaction
+				tree.NewDInt(tree.DInt(l.pid)),          // pid
+				exclusiveLock,                           // mode
+				tree.DBoolTrue,                          // granted
+				tree.DBoolFalse,                         // fastpath
+			); err != nil {
+				return err
+			}
+		}
 		return nil
 	},
 }
@@ -83,6 +83,10 @@ type commandResult struct {
 	// statements.
 	bufferingDisabled bool
 
+	// copyOut is set for COPY TO STDOUT statements. Their rows are sent using
+	// the Copy-out subprotocol instead of DataRow messages.
+	copyOut *copyOutEncoder
+
 	// released is set when the command result has been released so that its
 	// memory can be reused. It is also used to assert against use-after-free
 	// errors.
@@ -115,6 +119,9 @@ func (r *commandResult) Close(ctx context.Context, t sql.TransactionStatusIndica
 	// Send a completion message, specific to the type of result.
 	switch r.typ {
 	case commandComplete:
+		if r.copyOut != nil {
+			r.copyOut.finish(r.conn)
+		}
 		tag := cookTag(
 			r.cmdCompleteTag, r.conn.writerState.tagBuf[:0], r.stmtType, r.rowsAffected,
 		)
@@ -178,7 +185,13 @@ func (r *commandResult) AddRow(ctx context.Context, row tree.Datums) error {
 	}
 	r.rowsAffected++
 
-	r.conn.bufferRow(ctx, row, r.formatCodes, r.conv, r.types)
+	if r.copyOut != nil {
+		if err := r.copyOut.writeRow(ctx, r.conn, row, r.conv, r.types); err != nil {
+			return err
+		}
+	} else {
+		r.conn.bufferRow(ctx, row, r.formatCodes, r.conv, r.types)
+	}
 	var err error
 	if r.bufferingDisabled {
 		err = r.conn.Flush(r.pos)
@@ -216,7 +229,9 @@ func (r *commandResult) AppendNotice(noticeErr error) {
 func (r *commandResult) SetColumns(ctx context.Context, cols sqlbase.ResultColumns) {
 	r.assertNotReleased()
 	r.conn.writerState.fi.registerCmd(r.pos)
-	if r.descOpt == sql.NeedRowDesc {
+	if r.copyOut != nil {
+		r.copyOut.start(r.conn, cols)
+	} else if r.descOpt == sql.NeedRowDesc {
 		_ /* err */ = r.conn.writeRowDescription(ctx, cols, r.formatCodes, &r.conn.writerState.buf)
 	}
 	r.types = make([]*types.T, len(cols))
@@ -323,6 +338,13 @@ func (c *conn) newCommandResult(
 		descOpt:        descOpt,
 		formatCodes:    formatCodes,
 	}
+	if cp, ok := stmt.(*tree.CopyTo); ok {
+		// Invalid options are reported when the statement is planned, in which
+		// case no rows are produced.
+		if opts, err := tree.ParseCopyOptions(cp.Options); err == nil {
+			r.copyOut = newCopyOutEncoder(opts)
+		}
+	}
 	if limit == 0 {
 		return r
 	}
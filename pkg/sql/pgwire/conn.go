@@ -25,10 +25,12 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/security"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/notify"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirebase"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirecancel"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -88,6 +90,10 @@ type conn struct {
 	readBuf    pgwirebase.ReadBuffer
 	msgBuilder writeBuffer
 
+	// notificationBuilder is used to build NotificationResponse messages,
+	// which are sent from another goroutine than the other messages.
+	notificationBuilder writeBuffer
+
 	sv *settings.Values
 
 	// testingLogEnabled is used in unit tests in this package to
@@ -170,6 +176,7 @@ func newConn(
 	c.writerState.fi.lastFlushed = -1
 	c.writerState.fi.cmdStarts = make(map[sql.CmdPos]int)
 	c.msgBuilder.init(metrics.BytesOutCount)
+	c.notificationBuilder.init(metrics.BytesOutCount)
 
 	return c
 }
@@ -589,6 +596,14 @@ func (c *conn) sendParamStatus(param, value string) error {
 	return c.msgBuilder.finishMsg(c.conn)
 }
 
+func (c *conn) sendBackendKeyData(key pgwirecancel.BackendKeyData) error {
+	processID, secretKey := key.GetPGCompatibleParts()
+	c.msgBuilder.initMsg(pgwirebase.ServerMsgBackendKeyData)
+	c.msgBuilder.putInt32(int32(processID))
+	c.msgBuilder.putInt32(int32(secretKey))
+	return c.msgBuilder.finishMsg(c.conn)
+}
+
 func (c *conn) bufferParamStatus(param, value string) error {
 	c.msgBuilder.initMsg(pgwirebase.ServerMsgParameterStatus)
 	c.msgBuilder.writeTerminatedString(param)
@@ -642,6 +657,12 @@ func (c *conn) sendInitialConnData(
 		return sql.ConnectionHandler{}, err
 	}
 
+	// Send the key that the client can use in a CancelRequest to cancel the
+	// queries running on this session.
+	if err := c.sendBackendKeyData(connHandler.GetQueryCancelKey()); err != nil {
+		return sql.ConnectionHandler{}, err
+	}
+
 	// An initial readyForQuery message is part of the handshake.
 	c.msgBuilder.initMsg(pgwirebase.ServerMsgReady)
 	c.msgBuilder.writeByte(byte(sql.IdleTxnBlock))
@@ -802,6 +823,11 @@ func (c *conn) handleParse(
 		// https://www.postgresql.org/message-id/flat/CAMsr%2BYGvp2wRx9pPSxaKFdaObxX8DzWse%2BOkWk2xpXSvT0rq-g%40mail.gmail.com#CAMsr+YGvp2wRx9pPSxaKFdaObxX8DzWse+OkWk2xpXSvT0rq-g@mail.gmail.com
 		return c.stmtBuf.Push(ctx, sql.SendError{Err: fmt.Errorf("CopyFrom not supported in extended protocol mode")})
 	}
+	if _, ok := stmt.AST.(*tree.CopyTo); ok {
+		// COPY TO would require the Describe messages to report the Copy-out
+		// subprotocol; only the simple protocol is supported for now.
+		return c.stmtBuf.Push(ctx, sql.SendError{Err: fmt.Errorf("CopyTo not supported in extended protocol mode")})
+	}
 
 	return c.stmtBuf.Push(
 		ctx,
@@ -1332,6 +1358,27 @@ func (c *conn) LockCommunication() sql.ClientLock {
 	return (*clientConnLock)(&c.writerState.fi)
 }
 
+// SendNotification is part of the sql.ClientComm interface.
+//
+// The notification is written directly to the network connection, bypassing
+// the buffered results. Postgres clients have to be prepared to receive a
+// NotificationResponse at any point between two other messages.
+func (c *conn) SendNotification(n notify.Notification) error {
+	c.notificationBuilder.initMsg(pgwirebase.ServerMsgNotificationResponse)
+	c.notificationBuilder.putInt32(n.PID)
+	c.notificationBuilder.writeTerminatedString(n.Channel)
+	c.notificationBuilder.writeTerminatedString(n.Payload)
+	return c.notificationBuilder.finishMsg(c.conn)
+}
+
+// SendNotice is part of the sql.ClientComm interface. Like
+// SendNotification, the NoticeResponse is written directly to the network
+// connection.
+func (c *conn) SendNotice(ctx context.Context, noticeErr error) error {
+	c.notificationBuilder.initMsg(pgwirebase.ServerMsgNoticeResponse)
+	return writeErrFields(ctx, c.sv, noticeErr, &c.notificationBuilder, c.conn)
+}
+
 // clientConnLock is the connection's implementation of sql.ClientLock. It lets
 // the sql module lock the flushing of results and find out what has already
 // been flushed.
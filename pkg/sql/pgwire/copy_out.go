@@ -0,0 +1,227 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package pgwire
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirebase"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// binaryCopySignature starts the header of the binary COPY format. It is
+// followed by a 32-bit flags field and a 32-bit header extension length, both
+// of which are always zero.
+//
+// See: https://www.postgresql.org/docs/current/sql-copy.html#id-1.9.3.55.9.4
+var binaryCopySignature = []byte("PGCOPY\n\377\r\n\000")
+
+// copyOutEncoder turns the results of a COPY TO STDOUT statement into the
+// CopyData messages of the Copy-out pgwire subprotocol. Each row is sent in
+// its own CopyData message, like Postgres does.
+//
+// See: https://www.postgresql.org/docs/current/protocol-flow.html#PROTOCOL-COPY
+type copyOutEncoder struct {
+	opts tree.CopyOptions
+	// line accumulates the encoding of a row in the text and CSV formats.
+	line bytes.Buffer
+	// scratch is used to format a single value before it is escaped.
+	scratch writeBuffer
+	// forceQuote is set for the columns whose non-NULL values are always quoted
+	// in the CSV format.
+	forceQuote []bool
+}
+
+func newCopyOutEncoder(opts tree.CopyOptions) *copyOutEncoder {
+	e := &copyOutEncoder{opts: opts}
+	// The scratch buffer is never used to write messages, so it doesn't need to
+	// count bytes.
+	e.scratch.init(nil /* bytecount */)
+	return e
+}
+
+// formatCode returns the format code that applies to all columns.
+func (e *copyOutEncoder) formatCode() pgwirebase.FormatCode {
+	if e.opts.Format == tree.CopyFormatBinary {
+		return pgwirebase.FormatBinary
+	}
+	return pgwirebase.FormatText
+}
+
+// start buffers the CopyOutResponse message that begins the Copy-out
+// subprotocol, followed by the binary header or the CSV header line if
+// requested.
+func (e *copyOutEncoder) start(c *conn, cols sqlbase.ResultColumns) {
+	fmtCode := e.formatCode()
+	c.msgBuilder.initMsg(pgwirebase.ServerMsgCopyOutResponse)
+	c.msgBuilder.writeByte(byte(fmtCode))
+	c.msgBuilder.putInt16(int16(len(cols)))
+	for range cols {
+		c.msgBuilder.putInt16(int16(fmtCode))
+	}
+	c.finishCopyMsg()
+
+	if e.opts.Format == tree.CopyFormatCSV {
+		names := make([]string, len(cols))
+		for i := range cols {
+			names[i] = cols[i].Name
+		}
+		// The FORCE_QUOTE columns were already validated against the result
+		// columns when the statement's result was initialized.
+		e.forceQuote, _ = e.opts.ForceQuoteColumns(names)
+	}
+
+	switch {
+	case e.opts.Format == tree.CopyFormatBinary:
+		c.msgBuilder.initMsg(pgwirebase.ServerMsgCopyData)
+		c.msgBuilder.write(binaryCopySignature)
+		c.msgBuilder.putInt32(0) // flags
+		c.msgBuilder.putInt32(0) // header extension length
+		c.finishCopyMsg()
+	case e.opts.Header:
+		e.line.Reset()
+		for i := range cols {
+			if i > 0 {
+				e.line.WriteByte(e.opts.Delimiter)
+			}
+			e.writeCSVField([]byte(cols[i].Name), false /* force */)
+		}
+		e.line.WriteByte('\n')
+		c.bufferCopyData(e.line.Bytes())
+	}
+}
+
+// writeRow buffers a CopyData message containing the given row.
+func (e *copyOutEncoder) writeRow(
+	ctx context.Context,
+	c *conn,
+	row tree.Datums,
+	conv sessiondata.DataConversionConfig,
+	typs []*types.T,
+) error {
+	if e.opts.Format == tree.CopyFormatBinary {
+		c.msgBuilder.initMsg(pgwirebase.ServerMsgCopyData)
+		c.msgBuilder.putInt16(int16(len(row)))
+		for i, d := range row {
+			c.msgBuilder.writeBinaryDatum(ctx, d, conv.Location, typs[i])
+		}
+		return c.msgBuilder.finishMsg(&c.writerState.buf)
+	}
+
+	e.line.Reset()
+	for i, d := range row {
+		if i > 0 {
+			e.line.WriteByte(e.opts.Delimiter)
+		}
+		if d == tree.DNull {
+			e.line.WriteString(e.opts.Null)
+			continue
+		}
+		e.scratch.reset()
+		e.scratch.writeTextDatum(ctx, d, conv, typs[i])
+		if e.scratch.err != nil {
+			return e.scratch.err
+		}
+		// Skip the length prefix written by writeTextDatum.
+		val := e.scratch.wrapped.Bytes()[4:]
+		if e.opts.Format == tree.CopyFormatCSV {
+			e.writeCSVField(val, e.forceQuote[i])
+		} else {
+			e.writeTextField(val)
+		}
+	}
+	e.line.WriteByte('\n')
+	c.bufferCopyData(e.line.Bytes())
+	return nil
+}
+
+// finish buffers the binary trailer, if needed, and the CopyDone message that
+// ends the Copy-out subprotocol.
+func (e *copyOutEncoder) finish(c *conn) {
+	if e.opts.Format == tree.CopyFormatBinary {
+		c.msgBuilder.initMsg(pgwirebase.ServerMsgCopyData)
+		c.msgBuilder.putInt16(-1)
+		c.finishCopyMsg()
+	}
+	c.msgBuilder.initMsg(pgwirebase.ServerMsgCopyDone)
+	c.finishCopyMsg()
+}
+
+// writeTextField appends a value to the current line, escaping the characters
+// that have a special meaning in the text format.
+func (e *copyOutEncoder) writeTextField(val []byte) {
+	for _, ch := range val {
+		switch ch {
+		case '\\':
+			e.line.WriteString(`\\`)
+		case '\b':
+			e.line.WriteString(`\b`)
+		case '\f':
+			e.line.WriteString(`\f`)
+		case '\n':
+			e.line.WriteString(`\n`)
+		case '\r':
+			e.line.WriteString(`\r`)
+		case '\t':
+			e.line.WriteString(`\t`)
+		case '\v':
+			e.line.WriteString(`\v`)
+		default:
+			if ch == e.opts.Delimiter {
+				e.line.WriteByte('\\')
+			}
+			e.line.WriteByte(ch)
+		}
+	}
+}
+
+// writeCSVField appends a value to the current line, quoting it if force is
+// set or if it could otherwise be mistaken for a delimiter, a line break or a
+// NULL.
+func (e *copyOutEncoder) writeCSVField(val []byte, force bool) {
+	needsQuotes := force || string(val) == e.opts.Null || string(val) == `\.`
+	for _, ch := range val {
+		if ch == e.opts.Delimiter || ch == e.opts.Quote || ch == '\n' || ch == '\r' {
+			needsQuotes = true
+			break
+		}
+	}
+	if !needsQuotes {
+		e.line.Write(val)
+		return
+	}
+	e.line.WriteByte(e.opts.Quote)
+	for _, ch := range val {
+		if ch == e.opts.Quote || ch == e.opts.Escape {
+			e.line.WriteByte(e.opts.Escape)
+		}
+		e.line.WriteByte(ch)
+	}
+	e.line.WriteByte(e.opts.Quote)
+}
+
+// bufferCopyData buffers a CopyData message with the given payload.
+func (c *conn) bufferCopyData(data []byte) {
+	c.msgBuilder.initMsg(pgwirebase.ServerMsgCopyData)
+	c.msgBuilder.write(data)
+	c.finishCopyMsg()
+}
+
+func (c *conn) finishCopyMsg() {
+	if err := c.msgBuilder.finishMsg(&c.writerState.buf); err != nil {
+		panic(errors.AssertionFailedf("unexpected err from buffer: %s", err))
+	}
+}
@@ -13,6 +13,8 @@ package pgwire
 import (
 	"context"
 	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
 )
 
 func (s *Server) DrainImpl(drainWait time.Duration, cancelWait time.Duration) error {
@@ -34,3 +36,9 @@ func (s *Server) OverwriteCancelMap() []context.CancelFunc {
 	}
 	return originalCancels
 }
+
+// SetCancelRequestLimit overrides the number of CancelRequests the server
+// serves at the same time. It must be called before any is received.
+func (s *Server) SetCancelRequestLimit(limit uint64) {
+	s.cancelPool = quotapool.NewIntPool("pgwire-cancel", limit)
+}
@@ -14,6 +14,7 @@ import (
 	"context"
 	gosql "database/sql"
 	"database/sql/driver"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -26,6 +27,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -39,6 +41,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/skip"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/errors"
@@ -1916,12 +1919,249 @@ func TestCancelRequest(t *testing.T) {
 		if _, err := fe.Receive(); err != io.EOF {
 			t.Fatalf("unexpected: %v", err)
 		}
-		if count := telemetry.GetRawFeatureCounts()["pgwire.unimplemented.cancel_request"]; count != 1 {
+		if count := telemetry.GetRawFeatureCounts()["pgwire.cancel_request"]; count != 1 {
 			t.Fatalf("expected 1 cancel request, got %d", count)
 		}
 	})
 }
 
+// TestCancelRequestThrottled checks that a client sending many CancelRequests
+// with invalid keys, e.g. to guess the key of a session, is throttled.
+func TestCancelRequestThrottled(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{Insecure: true})
+	defer s.Stopper().Stop(ctx)
+
+	const limit = 2
+	s.(*server.TestServer).PGServer().SetCancelRequestLimit(limit)
+	_ = telemetry.GetFeatureCounts(telemetry.Raw, telemetry.ResetCounts)
+
+	// Each request uses a different, invalid key. The ones that get through
+	// keep their quota for a while since they did not cancel anything, so most
+	// of them are dropped.
+	const numRequests = 50
+	g := ctxgroup.WithContext(ctx)
+	for i := 0; i < numRequests; i++ {
+		i := i
+		g.GoCtx(func(ctx context.Context) error {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", s.ServingSQLAddr())
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			// A CancelRequest is made of its length, the special version code
+			// and the key.
+			const versionCancel = 80877102
+			var msg [16]byte
+			binary.BigEndian.PutUint32(msg[0:4], uint32(len(msg)))
+			binary.BigEndian.PutUint32(msg[4:8], versionCancel)
+			binary.BigEndian.PutUint32(msg[8:12], uint32(i))
+			binary.BigEndian.PutUint32(msg[12:16], uint32(i))
+			if _, err := conn.Write(msg[:]); err != nil {
+				return err
+			}
+			// The server closes the connection without a reply.
+			if _, err := conn.Read(msg[:]); err != io.EOF {
+				return errors.Errorf("unexpected: %v", err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := telemetry.GetRawFeatureCounts()
+	if count := counts["pgwire.cancel_request"]; count != numRequests {
+		t.Fatalf("expected %d cancel requests, got %d", numRequests, count)
+	}
+	if count := counts["pgwire.cancel_request.success"]; count != 0 {
+		t.Fatalf("expected no successful cancel request, got %d", count)
+	}
+	if count := counts["pgwire.cancel_request.throttled"]; count == 0 {
+		t.Fatal("expected some cancel requests to be throttled")
+	}
+}
+
+// cancelTestDialer sends the first connection it dials to sessionAddr and all
+// the subsequent ones, which lib/pq uses for CancelRequests, to cancelAddr.
+// This mimics a load balancer that routes the cancel request to a different
+// node than the one that owns the session.
+type cancelTestDialer struct {
+	sessionAddr, cancelAddr string
+	dialed                  int32
+}
+
+func (d *cancelTestDialer) addr() string {
+	if atomic.AddInt32(&d.dialed, 1) == 1 {
+		return d.sessionAddr
+	}
+	return d.cancelAddr
+}
+
+func (d *cancelTestDialer) Dial(network, _ string) (net.Conn, error) {
+	return net.Dial(network, d.addr())
+}
+
+func (d *cancelTestDialer) DialTimeout(
+	network, _ string, timeout time.Duration,
+) (net.Conn, error) {
+	return net.DialTimeout(network, d.addr(), timeout)
+}
+
+func TestCancelQueryWithBackendKeyData(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	tc := serverutils.StartTestCluster(t, 2, base.TestClusterArgs{})
+	defer tc.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(tc.ServerConn(0))
+
+	testutils.RunTrueAndFalse(t, "via-other-node", func(t *testing.T, viaOtherNode bool) {
+		pgURL, cleanupFn := sqlutils.PGUrl(
+			t, tc.Server(0).ServingSQLAddr(), t.Name(), url.User(security.RootUser))
+		defer cleanupFn()
+
+		dialer := &cancelTestDialer{
+			sessionAddr: tc.Server(0).ServingSQLAddr(),
+			cancelAddr:  tc.Server(0).ServingSQLAddr(),
+		}
+		if viaOtherNode {
+			dialer.cancelAddr = tc.Server(1).ServingSQLAddr()
+		}
+		conn, err := pq.DialOpen(dialer, pgURL.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		_ = telemetry.GetFeatureCounts(telemetry.Raw, telemetry.ResetCounts)
+
+		// lib/pq sends a CancelRequest, with the key it received in the
+		// BackendKeyData message, when the query's context is canceled.
+		queryCtx, cancel := context.WithCancel(ctx)
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := conn.(driver.ExecerContext).ExecContext(
+				queryCtx, "SELECT pg_sleep(600)", nil /* args */)
+			errCh <- err
+		}()
+
+		const activeQuery = `SELECT count(*) FROM [SHOW CLUSTER QUERIES] WHERE query LIKE 'SELECT pg_sleep(%'`
+		testutils.SucceedsSoon(t, func() error {
+			var count int
+			sqlDB.QueryRow(t, activeQuery).Scan(&count)
+			if count != 1 {
+				return errors.Errorf("expected the query to be running, found %d", count)
+			}
+			return nil
+		})
+		cancel()
+
+		select {
+		case err := <-errCh:
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected query to be canceled, got %v", err)
+			}
+		case <-time.After(45 * time.Second):
+			t.Fatal("query was not canceled")
+		}
+		testutils.SucceedsSoon(t, func() error {
+			var count int
+			sqlDB.QueryRow(t, activeQuery).Scan(&count)
+			if count != 0 {
+				return errors.Errorf("expected the query to be gone, found %d", count)
+			}
+			if count := telemetry.GetRawFeatureCounts()["pgwire.cancel_request.success"]; count != 1 {
+				return errors.Errorf("expected 1 successful cancel request, got %d", count)
+			}
+			return nil
+		})
+
+		// The session survives the cancellation of its query.
+		if _, err := conn.(driver.ExecerContext).ExecContext(ctx, "SELECT 1", nil /* args */); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// TestListenNotify checks that notifications sent with NOTIFY are delivered
+// to the sessions listening on their channel, on any node, once the notifying
+// transaction commits.
+func TestListenNotify(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	tc := serverutils.StartTestCluster(t, 2, base.TestClusterArgs{
+		ServerArgs: base.TestServerArgs{Insecure: true},
+	})
+	defer tc.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(tc.ServerConn(1))
+
+	host, ports, _ := net.SplitHostPort(tc.Server(0).ServingSQLAddr())
+	port, _ := strconv.Atoi(ports)
+	conn, err := pgx.Connect(pgx.ConnConfig{
+		Host:      host,
+		Port:      uint16(port),
+		User:      security.RootUser,
+		TLSConfig: nil, // insecure
+		Logger:    pgxTestLogger{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if err := conn.Listen("events"); err != nil {
+		t.Fatal(err)
+	}
+	waitForNotification := func() *pgx.Notification {
+		t.Helper()
+		waitCtx, cancel := context.WithTimeout(ctx, 45*time.Second)
+		defer cancel()
+		n, err := conn.WaitForNotification(waitCtx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// Notifications sent on other channels or by transactions that rolled back
+	// are not delivered.
+	sqlDB.Exec(t, `NOTIFY other, 'ignored'`)
+	sqlDB.Exec(t, `BEGIN; NOTIFY events, 'rolled back'; ROLLBACK`)
+	sqlDB.Exec(t, `NOTIFY events, 'first'`)
+	sqlDB.Exec(t, `SELECT pg_notify('events', 'second')`)
+
+	for _, expected := range []string{"first", "second"} {
+		n := waitForNotification()
+		if n.Channel != "events" || n.Payload != expected {
+			t.Fatalf("expected notification %q on channel events, got %q on channel %s",
+				expected, n.Payload, n.Channel)
+		}
+		if n.PID == 0 {
+			t.Fatal("expected the notification to carry the PID of the notifying session")
+		}
+	}
+
+	// After UNLISTEN, notifications are no longer delivered.
+	if err := conn.Unlisten("events"); err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Exec(t, `NOTIFY events, 'unlistened'`)
+	waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if n, err := conn.WaitForNotification(waitCtx); err == nil {
+		t.Fatalf("unexpected notification %q", n.Payload)
+	}
+}
+
 func TestFailPrepareFailsTxn(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
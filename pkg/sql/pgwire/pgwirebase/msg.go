@@ -37,15 +37,20 @@ const (
 	ClientMsgTerminate   ClientMessageType = 'X'
 
 	ServerMsgAuth                 ServerMessageType = 'R'
+	ServerMsgBackendKeyData       ServerMessageType = 'K'
 	ServerMsgBindComplete         ServerMessageType = '2'
 	ServerMsgCommandComplete      ServerMessageType = 'C'
 	ServerMsgCloseComplete        ServerMessageType = '3'
+	ServerMsgCopyData             ServerMessageType = 'd'
+	ServerMsgCopyDone             ServerMessageType = 'c'
 	ServerMsgCopyInResponse       ServerMessageType = 'G'
+	ServerMsgCopyOutResponse      ServerMessageType = 'H'
 	ServerMsgDataRow              ServerMessageType = 'D'
 	ServerMsgEmptyQuery           ServerMessageType = 'I'
 	ServerMsgErrorResponse        ServerMessageType = 'E'
 	ServerMsgNoticeResponse       ServerMessageType = 'N'
 	ServerMsgNoData               ServerMessageType = 'n'
+	ServerMsgNotificationResponse ServerMessageType = 'A'
 	ServerMsgParameterDescription ServerMessageType = 't'
 	ServerMsgParameterStatus      ServerMessageType = 'S'
 	ServerMsgParseComplete        ServerMessageType = '1'
@@ -0,0 +1,103 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Package pgwirecancel contains the definition of the BackendKeyData that is
+// sent to clients at connection start and used by them to issue a
+// CancelRequest for a running query.
+package pgwirecancel
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+)
+
+// BackendKeyData is a 64-bit identifier used by the pgwire protocol to cancel
+// queries. It is created at the time of session initialization and sent to the
+// client in the BackendKeyData message. Postgres clients treat it as two
+// separate 32-bit integers, a "process ID" and a "secret key", and send both
+// back in a CancelRequest message.
+//
+// CockroachDB needs to route a CancelRequest to the node that owns the
+// session, since the request may arrive at any node behind a load balancer.
+// To make this possible, the node's SQLInstanceID is embedded in the key when
+// it is small enough:
+//
+// - If the leading bit is 1, the next 11 bits contain the SQLInstanceID and
+//   the remaining 52 bits are random.
+// - If the leading bit is 0, the remaining 63 bits are random and the
+//   request can only be served by the node that receives it.
+type BackendKeyData uint64
+
+const (
+	// leadingBitMask selects the bit that indicates whether a SQLInstanceID is
+	// embedded in the key.
+	leadingBitMask = 1 << 63
+	// sqlInstanceIDBits is the number of bits used to store the SQLInstanceID.
+	sqlInstanceIDBits = 11
+	// randomBits is the number of random bits used when a SQLInstanceID is
+	// embedded.
+	randomBits = 64 - 1 - sqlInstanceIDBits
+	// maxEmbeddableSQLInstanceID is the largest SQLInstanceID that fits in the
+	// key.
+	maxEmbeddableSQLInstanceID = 1<<sqlInstanceIDBits - 1
+	// randomMask selects the random part of a key with an embedded
+	// SQLInstanceID.
+	randomMask = 1<<randomBits - 1
+)
+
+// MakeBackendKeyData creates a new BackendKeyData that contains the given
+// SQLInstanceID, if possible. The rest of the key is filled with
+// cryptographically random bits, since the key is the only thing that
+// authenticates a CancelRequest.
+func MakeBackendKeyData(sqlInstanceID base.SQLInstanceID) BackendKeyData {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic(fmt.Sprintf("unable to generate random cancel key: %v", err))
+	}
+	ret := binary.BigEndian.Uint64(buf[:])
+	if sqlInstanceID > 0 && sqlInstanceID <= maxEmbeddableSQLInstanceID {
+		ret &= randomMask
+		ret |= uint64(sqlInstanceID) << randomBits
+		ret |= leadingBitMask
+	} else {
+		ret &^= leadingBitMask
+	}
+	return BackendKeyData(ret)
+}
+
+// MakeBackendKeyDataFromParts reassembles a BackendKeyData out of the "process
+// ID" and "secret key" sent by a client in a CancelRequest message.
+func MakeBackendKeyDataFromParts(processID, secretKey uint32) BackendKeyData {
+	return BackendKeyData(uint64(processID)<<32 | uint64(secretKey))
+}
+
+// GetSQLInstanceID returns the SQLInstanceID embedded in the key, or zero if
+// there is none.
+func (b BackendKeyData) GetSQLInstanceID() base.SQLInstanceID {
+	if uint64(b)&leadingBitMask == 0 {
+		return 0
+	}
+	return base.SQLInstanceID((uint64(b) &^ leadingBitMask) >> randomBits)
+}
+
+// GetPGCompatibleParts returns the key split into the "process ID" and "secret
+// key" fields of the pgwire BackendKeyData message.
+func (b BackendKeyData) GetPGCompatibleParts() (processID, secretKey uint32) {
+	return uint32(b >> 32), uint32(b)
+}
+
+// String implements the fmt.Stringer interface. The key is a secret, so it is
+// never printed in full.
+func (b BackendKeyData) String() string {
+	return fmt.Sprintf("cancel key (sql instance %d)", b.GetSQLInstanceID())
+}
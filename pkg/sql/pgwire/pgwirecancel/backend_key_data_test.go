@@ -0,0 +1,48 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package pgwirecancel
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackendKeyData(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	for _, tc := range []struct {
+		sqlInstanceID base.SQLInstanceID
+		expected      base.SQLInstanceID
+	}{
+		{sqlInstanceID: 1, expected: 1},
+		{sqlInstanceID: 7, expected: 7},
+		{sqlInstanceID: maxEmbeddableSQLInstanceID, expected: maxEmbeddableSQLInstanceID},
+		// IDs that do not fit are not embedded.
+		{sqlInstanceID: maxEmbeddableSQLInstanceID + 1, expected: 0},
+		{sqlInstanceID: 0, expected: 0},
+	} {
+		for i := 0; i < 100; i++ {
+			key := MakeBackendKeyData(tc.sqlInstanceID)
+			require.Equal(t, tc.expected, key.GetSQLInstanceID())
+
+			// The key survives the round trip through the two 32-bit fields of the
+			// BackendKeyData and CancelRequest messages.
+			processID, secretKey := key.GetPGCompatibleParts()
+			require.Equal(t, key, MakeBackendKeyDataFromParts(processID, secretKey))
+		}
+	}
+
+	// Two keys for the same instance should (almost certainly) differ.
+	require.NotEqual(t, MakeBackendKeyData(1), MakeBackendKeyData(1))
+}
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
@@ -28,6 +29,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirebase"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirecancel"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqltelemetry"
@@ -37,6 +39,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/metric"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
@@ -180,6 +183,10 @@ type Server struct {
 	sqlMemoryPool *mon.BytesMonitor
 	connMonitor   *mon.BytesMonitor
 
+	// cancelPool limits the number of CancelRequests that are being served at
+	// the same time, see handleCancel.
+	cancelPool *quotapool.IntPool
+
 	// testingLogEnabled is used in unit tests in this package to
 	// force-enable conn/auth logging without dancing around the
 	// asynchronicity of cluster settings.
@@ -256,6 +263,8 @@ func MakeServer(
 	server.mu.connCancelMap = make(cancelChanMap)
 	server.mu.Unlock()
 
+	server.cancelPool = quotapool.NewIntPool("pgwire-cancel", maxConcurrentCancelRequests)
+
 	connAuthConf.SetOnChange(&st.SV,
 		func() {
 			loadLocalAuthConfigUponRemoteSettingChange(
@@ -510,12 +519,9 @@ func (s *Server) ServeConn(ctx context.Context, conn net.Conn, socketType Socket
 
 	if version == versionCancel {
 		// The cancel message is rather peculiar: it is sent without
-		// authentication, always over an unencrypted channel.
-		//
-		// Since we don't support this, close the door in the client's
-		// face. Make a note of that use in telemetry.
-		telemetry.Inc(sqltelemetry.CancelRequestCounter)
-		_ = conn.Close()
+		// authentication, usually over an unencrypted channel, and the
+		// connection is closed without a reply.
+		s.handleCancel(ctx, conn, &buf)
 		return nil
 	}
 
@@ -546,6 +552,11 @@ func (s *Server) ServeConn(ctx context.Context, conn net.Conn, socketType Socket
 	case version30:
 		// Normal SQL connection. Proceed normally below.
 
+	case versionCancel:
+		// The client negotiated SSL before sending its CancelRequest.
+		s.handleCancel(ctx, conn, &buf)
+		return nil
+
 	default:
 		// We don't know this protocol.
 		return s.sendErr(ctx, conn,
@@ -589,6 +600,89 @@ func (s *Server) ServeConn(ctx context.Context, conn net.Conn, socketType Socket
 	return nil
 }
 
+const (
+	// maxConcurrentCancelRequests is the number of CancelRequests that a node
+	// serves at the same time. The ones received beyond that are dropped.
+	maxConcurrentCancelRequests = 256
+
+	// cancelFailurePenalty is how long a CancelRequest that did not cancel
+	// anything keeps its quota. CancelRequests are not authenticated, so this
+	// bounds the rate at which a client can guess keys, and the rate of the
+	// status RPCs that the guesses fan out to, at
+	// maxConcurrentCancelRequests per cancelFailurePenalty on every node.
+	cancelFailurePenalty = time.Second
+)
+
+// handleCancel serves a CancelRequest message. The request carries the
+// BackendKeyData that was sent to a client at the start of its session; the
+// queries running on that session get canceled. The session may live on
+// another node if the client connected through a load balancer, so the
+// request goes through the status server which routes it to the node
+// identified by the key.
+//
+// As in Postgres, nothing is ever sent back to the client: the connection is
+// closed whether the cancellation succeeded or not, so as not to leak any
+// information about the existence of sessions.
+func (s *Server) handleCancel(ctx context.Context, conn net.Conn, buf *pgwirebase.ReadBuffer) {
+	telemetry.Inc(sqltelemetry.CancelRequestCounter)
+	_ = conn.Close()
+
+	processID, err := buf.GetUint32()
+	if err != nil {
+		log.Warningf(ctx, "unable to read CancelRequest: %v", err)
+		return
+	}
+	secretKey, err := buf.GetUint32()
+	if err != nil {
+		log.Warningf(ctx, "unable to read CancelRequest: %v", err)
+		return
+	}
+	cancelKey := pgwirecancel.MakeBackendKeyDataFromParts(processID, secretKey)
+
+	alloc, err := s.cancelPool.TryAcquire(ctx, 1)
+	if err != nil {
+		telemetry.Inc(sqltelemetry.CancelRequestThrottledCounter)
+		log.VEventf(ctx, 1, "dropping CancelRequest with %s: too many CancelRequests", cancelKey)
+		return
+	}
+	canceled, err := s.cancelQueryByKey(ctx, cancelKey)
+	if !canceled {
+		time.Sleep(cancelFailurePenalty)
+	}
+	alloc.Release()
+	if err != nil {
+		log.VEventf(ctx, 1, "unable to cancel query with %s: %v", cancelKey, err)
+		return
+	}
+	if canceled {
+		telemetry.Inc(sqltelemetry.CancelRequestSuccessCounter)
+	}
+}
+
+// cancelQueryByKey cancels the queries running on the session identified by
+// the given key, on whichever node owns it.
+func (s *Server) cancelQueryByKey(
+	ctx context.Context, cancelKey pgwirecancel.BackendKeyData,
+) (canceled bool, _ error) {
+	statusServer, err := s.execCfg.StatusServer.OptionalErr()
+	if err != nil {
+		// Without a status server (as is the case on SQL tenant servers), only
+		// the sessions owned by this server can be canceled.
+		return s.execCfg.SessionRegistry.CancelQueryByKey(cancelKey)
+	}
+	resp, err := statusServer.CancelQueryByKey(ctx, &serverpb.CancelQueryByKeyRequest{
+		SQLInstanceID:  cancelKey.GetSQLInstanceID(),
+		CancelQueryKey: cancelKey,
+	})
+	if err != nil {
+		return false, err
+	}
+	if resp.Error != "" {
+		return false, errors.Newf("%s", resp.Error)
+	}
+	return resp.Canceled, nil
+}
+
 // parseClientProvidedSessionParameters reads the incoming k/v pairs
 // in the startup message into a sql.SessionArgs struct.
 func parseClientProvidedSessionParameters(
@@ -147,18 +147,24 @@ var _ planNode = &createDatabaseNode{}
 var _ planNode = &createIndexNode{}
 var _ planNode = &createSequenceNode{}
 var _ planNode = &createStatsNode{}
+var _ planNode = &closeCursorNode{}
+var _ planNode = &createFunctionNode{}
 var _ planNode = &createTableNode{}
+var _ planNode = &createTriggerNode{}
 var _ planNode = &createTypeNode{}
 var _ planNode = &CreateRoleNode{}
 var _ planNode = &createViewNode{}
+var _ planNode = &declareCursorNode{}
 var _ planNode = &delayedNode{}
 var _ planNode = &deleteNode{}
 var _ planNode = &deleteRangeNode{}
 var _ planNode = &distinctNode{}
 var _ planNode = &dropDatabaseNode{}
+var _ planNode = &dropFunctionNode{}
 var _ planNode = &dropIndexNode{}
 var _ planNode = &dropSequenceNode{}
 var _ planNode = &dropTableNode{}
+var _ planNode = &dropTriggerNode{}
 var _ planNode = &dropTypeNode{}
 var _ planNode = &DropRoleNode{}
 var _ planNode = &dropViewNode{}
@@ -166,6 +172,7 @@ var _ planNode = &errorIfRowsNode{}
 var _ planNode = &explainDistSQLNode{}
 var _ planNode = &explainPlanNode{}
 var _ planNode = &explainVecNode{}
+var _ planNode = &fetchCursorNode{}
 var _ planNode = &filterNode{}
 var _ planNode = &GrantRoleNode{}
 var _ planNode = &groupNode{}
@@ -175,7 +182,10 @@ var _ planNode = &insertNode{}
 var _ planNode = &insertFastPathNode{}
 var _ planNode = &joinNode{}
 var _ planNode = &limitNode{}
+var _ planNode = &listenNode{}
 var _ planNode = &max1RowNode{}
+var _ planNode = &moveCursorNode{}
+var _ planNode = &notifyNode{}
 var _ planNode = &ordinalityNode{}
 var _ planNode = &projectSetNode{}
 var _ planNode = &recursiveCTENode{}
@@ -192,6 +202,7 @@ var _ planNode = &scanNode{}
 var _ planNode = &scatterNode{}
 var _ planNode = &serializeNode{}
 var _ planNode = &sequenceSelectNode{}
+var _ planNode = &setConstraintsNode{}
 var _ planNode = &showFingerprintsNode{}
 var _ planNode = &showTraceNode{}
 var _ planNode = &sortNode{}
@@ -209,6 +220,7 @@ var _ planNode = &windowNode{}
 var _ planNode = &zeroNode{}
 
 var _ planNodeFastPath = &deleteRangeNode{}
+var _ planNodeFastPath = &moveCursorNode{}
 var _ planNodeFastPath = &rowCountNode{}
 var _ planNodeFastPath = &serializeNode{}
 var _ planNodeFastPath = &setZoneConfigNode{}
@@ -219,12 +231,16 @@ var _ planNodeReadingOwnWrites = &alterIndexNode{}
 var _ planNodeReadingOwnWrites = &alterSequenceNode{}
 var _ planNodeReadingOwnWrites = &alterTableNode{}
 var _ planNodeReadingOwnWrites = &alterTypeNode{}
+var _ planNodeReadingOwnWrites = &createFunctionNode{}
 var _ planNodeReadingOwnWrites = &createIndexNode{}
 var _ planNodeReadingOwnWrites = &createSequenceNode{}
 var _ planNodeReadingOwnWrites = &createTableNode{}
+var _ planNodeReadingOwnWrites = &createTriggerNode{}
 var _ planNodeReadingOwnWrites = &createTypeNode{}
 var _ planNodeReadingOwnWrites = &createViewNode{}
 var _ planNodeReadingOwnWrites = &changePrivilegesNode{}
+var _ planNodeReadingOwnWrites = &dropFunctionNode{}
+var _ planNodeReadingOwnWrites = &dropTriggerNode{}
 var _ planNodeReadingOwnWrites = &dropTypeNode{}
 var _ planNodeReadingOwnWrites = &setZoneConfigNode{}
 
@@ -396,6 +412,9 @@ type cascadeMetadata struct {
 	// plan for the cascade. This plan is not populated upfront; it is created
 	// only when it needs to run, after the main query (and previous cascades).
 	plan planMaybePhysical
+	// subqueryPlans contains the subqueries of the cascade plan, which are run
+	// before it.
+	subqueryPlans []subquery
 }
 
 // checkPlan is a query tree that is executed after the main one. It can only
@@ -412,6 +431,9 @@ func (p *planComponents) close(ctx context.Context) {
 	}
 	for i := range p.cascades {
 		p.cascades[i].plan.Close(ctx)
+		for j := range p.cascades[i].subqueryPlans {
+			p.cascades[i].subqueryPlans[j].plan.Close(ctx)
+		}
 	}
 	for i := range p.checkPlans {
 		p.checkPlans[i].plan.Close(ctx)
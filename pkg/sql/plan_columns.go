@@ -97,6 +97,8 @@ func getPlanColumns(plan planNode, mut bool) sqlbase.ResultColumns {
 		return n.resultColumns
 	case *invertedJoinNode:
 		return n.columns
+	case *fetchCursorNode:
+		return n.columns
 
 	// Nodes with a fixed schema.
 	case *scrubNode:
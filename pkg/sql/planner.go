@@ -139,6 +139,22 @@ type planner struct {
 
 	preparedStatements preparedStatementsAccessor
 
+	// sqlCursors gives access to the cursors declared in the session.
+	sqlCursors sqlCursors
+
+	// sessionListener tracks the channels the session listens on. It is nil
+	// for planners that are not associated with a session.
+	sessionListener *sessionListener
+
+	// advisoryLocks are the advisory locks held by the session. It is nil for
+	// planners that are not associated with a session.
+	advisoryLocks *advisoryLockSet
+
+	// deferredConstraints contains the constraint checks deferred by the
+	// current transaction. It is nil for planners that are not associated with
+	// a session.
+	deferredConstraints *deferredConstraints
+
 	// avoidCachedDescriptors, when true, instructs all code that
 	// accesses table/view descriptors to force reading the descriptors
 	// within the transaction. This is necessary to read descriptors
@@ -197,6 +213,11 @@ type planner struct {
 	// data structures that can be reused between queries (for efficiency).
 	optPlanningCtx optPlanningCtx
 
+	// functionBodies caches the planned bodies of the user-defined functions
+	// evaluated by the current statement. It is allocated lazily, and shared
+	// with the copies of the planner used to run nested plans.
+	functionBodies *functionBodyCache
+
 	// noticeSender allows the sending of notices.
 	// Do not use this object directly; use the SendClientNotice() method
 	// instead.
@@ -290,6 +311,7 @@ func newInternalPlanner(
 	p.semaCtx = tree.MakeSemaContext()
 	p.semaCtx.SearchPath = sd.SearchPath
 	p.semaCtx.TypeResolver = p
+	p.semaCtx.FunctionResolver = p
 
 	plannerMon := mon.NewUnlimitedMonitor(ctx,
 		fmt.Sprintf("internal-planner.%s.%s", user, opName),
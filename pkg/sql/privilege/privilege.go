@@ -37,12 +37,16 @@ const (
 	DELETE
 	UPDATE
 	ZONECONFIG
+	EXECUTE
 )
 
 // Predefined sets of privileges.
 var (
 	ReadData      = List{GRANT, SELECT}
 	ReadWriteData = List{GRANT, SELECT, INSERT, DELETE, UPDATE}
+	// FunctionPrivileges are the privileges that can be granted on a
+	// function.
+	FunctionPrivileges = List{ALL, DROP, GRANT, EXECUTE}
 )
 
 // Mask returns the bitmask for a given privilege.
@@ -52,7 +56,7 @@ func (k Kind) Mask() uint32 {
 
 // ByValue is just an array of privilege kinds sorted by value.
 var ByValue = [...]Kind{
-	ALL, CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE, ZONECONFIG,
+	ALL, CREATE, DROP, GRANT, SELECT, INSERT, DELETE, UPDATE, ZONECONFIG, EXECUTE,
 }
 
 // ByName is a map of string -> kind value.
@@ -66,6 +70,7 @@ var ByName = map[string]Kind{
 	"DELETE":     DELETE,
 	"UPDATE":     UPDATE,
 	"ZONECONFIG": ZONECONFIG,
+	"EXECUTE":    EXECUTE,
 }
 
 // List is a list of privileges.
@@ -19,10 +19,12 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/resolver"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/cat"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/errors"
@@ -204,6 +206,151 @@ func (p *planner) ResolveTypeByID(ctx context.Context, id uint32) (*types.T, err
 	return desc.MakeTypesT(ctx, name, p)
 }
 
+// ResolveFunction implements the tree.FunctionReferenceResolver interface.
+func (p *planner) ResolveFunction(
+	ctx context.Context, name *tree.UnresolvedName, path sessiondata.SearchPath,
+) (*tree.FunctionDefinition, error) {
+	if name.Star || name.NumParts > 3 || p.txn == nil {
+		return nil, nil
+	}
+	un, err := name.ToUnresolvedObjectName(tree.NoAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	tn := un.ToTableName()
+	desc, err := p.lookupFunction(ctx, &tn, path)
+	if err != nil || desc == nil {
+		return nil, err
+	}
+	if err := p.CheckPrivilege(ctx, desc, privilege.EXECUTE); err != nil {
+		return nil, err
+	}
+	// The cache of the function bodies is allocated during planning, so that
+	// it is shared with the copies of the planner which run nested plans.
+	if p.functionBodies == nil {
+		p.functionBodies = &functionBodyCache{}
+	}
+	return makeFunctionDefinition(&tn, desc)
+}
+
+// lookupFunction looks up the descriptor of the user-defined function with
+// the given name. If the name does not specify a schema, the schemas of the
+// search path are searched in order. If the function is found, the name is
+// qualified with the database and the schema of the function. Nil is returned
+// if there is no such function.
+//
+// The descriptor is leased through the descriptor collection, like the
+// descriptors of the tables and types used by the statement.
+func (p *planner) lookupFunction(
+	ctx context.Context, tn *tree.TableName, path sessiondata.SearchPath,
+) (*sqlbase.ImmutableFunctionDescriptor, error) {
+	for _, candidate := range functionNameCandidates(p, tn, path) {
+		fnDesc, err := p.Descriptors().GetFunctionVersion(
+			ctx, p.txn, &candidate, p.ObjectLookupFlags(false /* required */, false /* requireMutable */),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if fnDesc != nil {
+			*tn = candidate
+			return fnDesc, nil
+		}
+	}
+	return nil, nil
+}
+
+// lookupMutableFunction is like lookupFunction, but returns a mutable
+// descriptor, for the statements which modify the function.
+func (p *planner) lookupMutableFunction(
+	ctx context.Context, tn *tree.TableName, path sessiondata.SearchPath,
+) (*sqlbase.MutableFunctionDescriptor, error) {
+	for _, candidate := range functionNameCandidates(p, tn, path) {
+		fnDesc, err := p.Descriptors().GetMutableFunctionDescriptor(
+			ctx, p.txn, &candidate, p.ObjectLookupFlags(false /* required */, true /* requireMutable */),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if fnDesc != nil {
+			*tn = candidate
+			return fnDesc, nil
+		}
+	}
+	return nil, nil
+}
+
+// functionNameCandidates returns the fully qualified names that a function
+// name can refer to, in the order in which they are searched.
+func functionNameCandidates(
+	p *planner, tn *tree.TableName, path sessiondata.SearchPath,
+) []tree.TableName {
+	dbName := p.CurrentDatabase()
+	if tn.ExplicitCatalog {
+		dbName = tn.Catalog()
+	}
+	if dbName == "" {
+		return nil
+	}
+	if tn.ExplicitSchema {
+		return []tree.TableName{
+			tree.MakeTableNameWithSchema(tree.Name(dbName), tree.Name(tn.Schema()), tree.Name(tn.Object())),
+		}
+	}
+	var res []tree.TableName
+	iter := path.IterWithoutImplicitPGSchemas()
+	for scName, ok := iter.Next(); ok; scName, ok = iter.Next() {
+		res = append(res,
+			tree.MakeTableNameWithSchema(tree.Name(dbName), tree.Name(scName), tree.Name(tn.Object())))
+	}
+	return res
+}
+
+// makeFunctionDefinition returns the definition of a user-defined function.
+// If the function cannot be inlined by the optimizer, it is evaluated by
+// running the query that computes its body as a nested plan of the statement.
+// The body is planned once per statement, see functionBodyCache.
+func makeFunctionDefinition(
+	tn *tree.TableName, desc *sqlbase.ImmutableFunctionDescriptor,
+) (*tree.FunctionDefinition, error) {
+	stmt, err := parser.ParseOne(desc.Body)
+	if err != nil {
+		return nil, errors.NewAssertionErrorWithWrappedErrf(err,
+			"invalid body of function %q", tn.FQString())
+	}
+	body, ok := stmt.AST.(*tree.Select)
+	if !ok {
+		return nil, errors.AssertionFailedf(
+			"invalid body of function %q: %s", tn.FQString(), desc.Body)
+	}
+	info := &functionBodyInfo{
+		id:         desc.ID,
+		version:    desc.Version,
+		query:      sqlbase.MakeFunctionQuery(desc.Params, body),
+		paramTypes: desc.ParamTypes(),
+		returnType: desc.ReturnType,
+	}
+	name := tn.FQString()
+
+	props := &tree.FunctionProperties{
+		// The body is evaluated by the planner of the gateway.
+		DistsqlBlocklist: true,
+		// NULL arguments are passed to the body, like in Postgres.
+		NullableArgs: true,
+		Category:     "User-defined",
+	}
+	overload := &tree.Overload{
+		Types:      info.paramTypes,
+		ReturnType: tree.FixedReturnType(info.returnType),
+		Volatility: desc.TreeVolatility(),
+		Body:       desc.Body,
+		Fn: func(evalCtx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+			return evalFunctionBody(evalCtx, info, args)
+		},
+		Info: fmt.Sprintf("User-defined function %s.", name),
+	}
+	return tree.NewUserDefinedFunctionDefinition(name, props, overload), nil
+}
+
 // ObjectLookupFlags is part of the resolver.SchemaResolver interface.
 func (p *planner) ObjectLookupFlags(required, requireMutable bool) tree.ObjectLookupFlags {
 	return tree.ObjectLookupFlags{
@@ -234,6 +381,23 @@ func getDescriptorsFromTargetList(
 		return descs, nil
 	}
 
+	if targets.Functions != nil {
+		descs := make([]sqlbase.DescriptorInterface, 0, len(targets.Functions))
+		for i := range targets.Functions {
+			tn := targets.Functions[i]
+			fnDesc, err := p.lookupMutableFunction(ctx, &tn, p.CurrentSearchPath())
+			if err != nil {
+				return nil, err
+			}
+			if fnDesc == nil {
+				return nil, pgerror.Newf(pgcode.UndefinedFunction,
+					"function %s does not exist", tree.ErrString(&targets.Functions[i]))
+			}
+			descs = append(descs, fnDesc)
+		}
+		return descs, nil
+	}
+
 	if len(targets.Tables) == 0 {
 		return nil, errNoTable
 	}
@@ -529,6 +693,8 @@ func newInternalLookupCtxFromDescriptors(
 			descs[i] = sqlbase.NewImmutableTypeDescriptor(*t.Type)
 		case *sqlbase.Descriptor_Schema:
 			descs[i] = sqlbase.NewImmutableSchemaDescriptor(*t.Schema)
+		case *sqlbase.Descriptor_Function:
+			descs[i] = sqlbase.NewImmutableFunctionDescriptor(*t.Function)
 		}
 	}
 	return newInternalLookupCtx(descs, prefix)
@@ -120,7 +120,8 @@ func (f *txnKVFetcher) getBatchSizeForIdx(batchIdx int) int64 {
 }
 
 // getKeyLockingStrength returns the configured per-key locking strength to use
-// for key-value scans.
+// for key-value scans. FOR_SHARE is only configured once VersionSharedLocks is
+// active (see planner.scanLockingStrength).
 func (f *txnKVFetcher) getKeyLockingStrength() lock.Strength {
 	switch f.lockStr {
 	case sqlbase.ScanLockingStrength_FOR_NONE:
@@ -130,9 +131,7 @@ func (f *txnKVFetcher) getKeyLockingStrength() lock.Strength {
 		// Promote to FOR_SHARE.
 		fallthrough
 	case sqlbase.ScanLockingStrength_FOR_SHARE:
-		// We currently perform no per-key locking when FOR_SHARE is used
-		// because Shared locks have not yet been implemented.
-		return lock.None
+		return lock.Shared
 
 	case sqlbase.ScanLockingStrength_FOR_NO_KEY_UPDATE:
 		// Promote to FOR_UPDATE.
@@ -0,0 +1,229 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql/lex"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	pbtypes "github.com/gogo/protobuf/types"
+	"github.com/gorhill/cronexpr"
+)
+
+const (
+	// RowLevelTTLExpirationColumnName is the name of the hidden column which
+	// holds the expiration time of the rows of a table with a row-level TTL.
+	RowLevelTTLExpirationColumnName = "crdb_internal_expiration"
+
+	// RowLevelTTLScheduleExecutorName is the name of the scheduled job
+	// executor which creates the row-level TTL jobs.
+	RowLevelTTLScheduleExecutorName = "scheduled-row-level-ttl-executor"
+
+	// defaultRowLevelTTLCron is the default schedule of the row-level TTL job.
+	defaultRowLevelTTLCron = "@hourly"
+
+	rowLevelTTLStorageParamPrefix = "ttl_"
+	ttlExpireAfterStorageParam    = "ttl_expire_after"
+)
+
+// IsRowLevelTTLStorageParam returns whether the given storage parameter
+// configures the row-level TTL of a table.
+func IsRowLevelTTLStorageParam(key string) bool {
+	return strings.HasPrefix(key, rowLevelTTLStorageParamPrefix)
+}
+
+// evalRowLevelTTLStorageParams returns the row-level TTL configuration
+// described by the given storage parameters, or nil if the parameters do not
+// enable a row-level TTL. The parameters must have already been checked by
+// checkStorageParameters.
+func evalRowLevelTTLStorageParams(
+	ctx context.Context,
+	semaCtx *tree.SemaContext,
+	evalCtx *tree.EvalContext,
+	params tree.StorageParams,
+) (*sqlbase.TableDescriptor_RowLevelTTL, error) {
+	var ttl *sqlbase.TableDescriptor_RowLevelTTL
+	var otherParam string
+	for _, sp := range params {
+		k := string(sp.Key)
+		if !IsRowLevelTTLStorageParam(k) {
+			continue
+		}
+		if ttl == nil {
+			ttl = &sqlbase.TableDescriptor_RowLevelTTL{DeletionCron: defaultRowLevelTTLCron}
+		}
+		typedExpr, err := tree.TypeCheckAndRequire(
+			ctx, sp.Value, semaCtx, storageParamSQLTypes[storageParamExpectedTypes[k]], k,
+		)
+		if err != nil {
+			return nil, err
+		}
+		d, err := typedExpr.Eval(evalCtx)
+		if err != nil {
+			return nil, err
+		}
+		if d == tree.DNull {
+			return nil, pgerror.Newf(pgcode.InvalidParameterValue,
+				"storage parameter %q cannot be NULL", k)
+		}
+		switch k {
+		case ttlExpireAfterStorageParam:
+			interval := tree.MustBeDInterval(d)
+			if interval.Duration.Compare(duration.Duration{}) <= 0 {
+				return nil, pgerror.Newf(pgcode.InvalidParameterValue,
+					"value of %q must be a positive interval", k)
+			}
+			ttl.ExpireAfter = interval.Duration.String()
+			continue
+		case `ttl_job_cron`:
+			ttl.DeletionCron = string(tree.MustBeDString(d))
+			if _, err := cronexpr.Parse(ttl.DeletionCron); err != nil {
+				return nil, pgerror.Wrapf(err, pgcode.InvalidParameterValue,
+					"invalid value for %q", k)
+			}
+		default:
+			v := int64(tree.MustBeDInt(d))
+			if v <= 0 {
+				return nil, pgerror.Newf(pgcode.InvalidParameterValue,
+					"value of %q must be positive", k)
+			}
+			switch k {
+			case `ttl_select_batch_size`:
+				ttl.SelectBatchSize = v
+			case `ttl_delete_batch_size`:
+				ttl.DeleteBatchSize = v
+			case `ttl_delete_rate_limit`:
+				ttl.DeleteRateLimit = v
+			}
+		}
+		otherParam = k
+	}
+	if ttl != nil && ttl.ExpireAfter == "" {
+		return nil, pgerror.Newf(pgcode.InvalidParameterValue,
+			"%q requires %q to be set", otherParam, ttlExpireAfterStorageParam)
+	}
+	return ttl, nil
+}
+
+// rowLevelTTLStorageParamsFromDesc returns the storage parameters describing the
+// given row-level TTL configuration, in a stable order.
+func rowLevelTTLStorageParamsFromDesc(ttl *sqlbase.TableDescriptor_RowLevelTTL) tree.StorageParams {
+	params := tree.StorageParams{{
+		Key:   ttlExpireAfterStorageParam,
+		Value: tree.NewStrVal(ttl.ExpireAfter),
+	}}
+	for _, p := range []struct {
+		key string
+		val int64
+	}{
+		{`ttl_select_batch_size`, ttl.SelectBatchSize},
+		{`ttl_delete_batch_size`, ttl.DeleteBatchSize},
+		{`ttl_delete_rate_limit`, ttl.DeleteRateLimit},
+	} {
+		if p.val != 0 {
+			params = append(params, tree.StorageParam{Key: tree.Name(p.key), Value: tree.NewDInt(tree.DInt(p.val))})
+		}
+	}
+	if ttl.DeletionCron != defaultRowLevelTTLCron {
+		params = append(params, tree.StorageParam{Key: `ttl_job_cron`, Value: tree.NewStrVal(ttl.DeletionCron)})
+	}
+	return params
+}
+
+// makeRowLevelTTLExpirationColumn returns the hidden column which holds the
+// expiration time of each row. The expiration time is computed when the row
+// is inserted and can be changed afterwards with an UPDATE.
+func makeRowLevelTTLExpirationColumn(
+	ctx context.Context,
+	semaCtx *tree.SemaContext,
+	evalCtx *tree.EvalContext,
+	ttl *sqlbase.TableDescriptor_RowLevelTTL,
+) (*sqlbase.ColumnDescriptor, error) {
+	defaultExpr, err := parser.ParseExpr(fmt.Sprintf(
+		"current_timestamp() + %s::INTERVAL", lex.EscapeSQLString(ttl.ExpireAfter),
+	))
+	if err != nil {
+		return nil, err
+	}
+	def := &tree.ColumnTableDef{
+		Name: RowLevelTTLExpirationColumnName,
+		Type: types.TimestampTZ,
+	}
+	def.DefaultExpr.Expr = defaultExpr
+	def.Nullable.Nullability = tree.NotNull
+	col, _, _, err := sqlbase.MakeColumnDefDescs(ctx, def, semaCtx, evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	col.Hidden = true
+	return col, nil
+}
+
+// createRowLevelTTLSchedule creates the schedule which periodically runs
+// the row-level TTL job of the given table, and records its ID in the
+// descriptor.
+func (p *planner) createRowLevelTTLSchedule(
+	ctx context.Context, desc *sqlbase.MutableTableDescriptor,
+) error {
+	env := jobSchedulerEnv(p.ExecCfg())
+	sj := jobs.NewScheduledJob(env)
+	sj.SetScheduleName(fmt.Sprintf("row-level-ttl-%d", desc.ID))
+	if err := sj.SetSchedule(desc.RowLevelTTL.DeletionCron); err != nil {
+		return err
+	}
+	// A slow deletion job should not pile up with the next one.
+	sj.SetScheduleDetails(jobspb.ScheduleDetails{
+		Wait:    jobspb.ScheduleDetails_SKIP,
+		OnError: jobspb.ScheduleDetails_RETRY_SCHED,
+	})
+	args, err := pbtypes.MarshalAny(&jobspb.ScheduledRowLevelTTLArgs{TableID: desc.ID})
+	if err != nil {
+		return err
+	}
+	sj.SetExecutionDetails(RowLevelTTLScheduleExecutorName, jobspb.ExecutionArguments{Args: args})
+	if err := sj.Create(ctx, p.ExecCfg().InternalExecutor, p.txn); err != nil {
+		return err
+	}
+	desc.RowLevelTTL.ScheduleID = sj.ScheduleID()
+	return nil
+}
+
+// dropRowLevelTTLSchedule deletes the schedule of the row-level TTL job of
+// the given table, if any.
+func (p *planner) dropRowLevelTTLSchedule(
+	ctx context.Context, desc *sqlbase.MutableTableDescriptor,
+) error {
+	if desc.RowLevelTTL == nil || desc.RowLevelTTL.ScheduleID == 0 {
+		return nil
+	}
+	env := jobSchedulerEnv(p.ExecCfg())
+	_, err := p.ExecCfg().InternalExecutor.ExecEx(
+		ctx,
+		"drop-row-level-ttl-schedule",
+		p.txn,
+		sqlbase.InternalExecutorSessionDataOverride{User: security.RootUser},
+		fmt.Sprintf("DELETE FROM %s WHERE schedule_id = $1", env.ScheduledJobsTableName()),
+		desc.RowLevelTTL.ScheduleID,
+	)
+	return err
+}
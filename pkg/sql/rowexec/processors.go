@@ -270,6 +270,24 @@ func NewProcessor(
 		}
 		return NewRestoreDataProcessor(flowCtx, processorID, *core.RestoreData, inputs[0], outputs[0])
 	}
+	if core.VerifyBackupData != nil {
+		if err := checkNumInOut(inputs, outputs, 0, 1); err != nil {
+			return nil, err
+		}
+		if NewVerifyBackupDataProcessor == nil {
+			return nil, errors.New("VerifyBackupData processor unimplemented")
+		}
+		return NewVerifyBackupDataProcessor(flowCtx, processorID, *core.VerifyBackupData, outputs[0])
+	}
+	if core.CompactBackupData != nil {
+		if err := checkNumInOut(inputs, outputs, 0, 1); err != nil {
+			return nil, err
+		}
+		if NewCompactBackupDataProcessor == nil {
+			return nil, errors.New("CompactBackupData processor unimplemented")
+		}
+		return NewCompactBackupDataProcessor(flowCtx, processorID, *core.CompactBackupData, outputs[0])
+	}
 	if core.CSVWriter != nil {
 		if err := checkNumInOut(inputs, outputs, 1, 1); err != nil {
 			return nil, err
@@ -371,6 +389,12 @@ var NewSplitAndScatterProcessor func(*execinfra.FlowCtx, int32, execinfrapb.Spli
 // NewRestoreDataProcessor is implemented in the non-free (CCL) codebase and then injected here via runtime initialization.
 var NewRestoreDataProcessor func(*execinfra.FlowCtx, int32, execinfrapb.RestoreDataSpec, execinfra.RowSource, execinfra.RowReceiver) (execinfra.Processor, error)
 
+// NewVerifyBackupDataProcessor is implemented in the non-free (CCL) codebase and then injected here via runtime initialization.
+var NewVerifyBackupDataProcessor func(*execinfra.FlowCtx, int32, execinfrapb.VerifyBackupDataSpec, execinfra.RowReceiver) (execinfra.Processor, error)
+
+// NewCompactBackupDataProcessor is implemented in the non-free (CCL) codebase and then injected here via runtime initialization.
+var NewCompactBackupDataProcessor func(*execinfra.FlowCtx, int32, execinfrapb.CompactBackupDataSpec, execinfra.RowReceiver) (execinfra.Processor, error)
+
 // NewCSVWriterProcessor is implemented in the non-free (CCL) codebase and then injected here via runtime initialization.
 var NewCSVWriterProcessor func(*execinfra.FlowCtx, int32, execinfrapb.CSVWriterSpec, execinfra.RowSource, execinfra.RowReceiver) (execinfra.Processor, error)
 
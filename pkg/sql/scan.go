@@ -14,6 +14,7 @@ import (
 	"context"
 	"sync"
 
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
@@ -137,6 +138,22 @@ func (p *planner) Scan() *scanNode {
 	return n
 }
 
+// scanLockingStrength converts the strength of a row-level locking clause to
+// the locking strength of a scan. FOR SHARE and FOR KEY SHARE do not lock
+// anything until VersionSharedLocks is active, as before Shared locks were
+// supported, since a leaseholder running an older version would acquire
+// Exclusive locks instead.
+func (p *planner) scanLockingStrength(s tree.LockingStrength) sqlbase.ScanLockingStrength {
+	str := sqlbase.ToScanLockingStrength(s)
+	switch str {
+	case sqlbase.ScanLockingStrength_FOR_KEY_SHARE, sqlbase.ScanLockingStrength_FOR_SHARE:
+		if !p.ExecCfg().Settings.Version.IsActive(p.EvalContext().Context, clusterversion.VersionSharedLocks) {
+			return sqlbase.ScanLockingStrength_FOR_NONE
+		}
+	}
+	return str
+}
+
 // scanNode implements tree.IndexedVarContainer.
 var _ tree.IndexedVarContainer = &scanNode{}
 
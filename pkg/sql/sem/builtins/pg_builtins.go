@@ -12,6 +12,7 @@ package builtins
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -574,6 +575,37 @@ var pgBuiltins = map[string]builtinDefinition{
 		},
 	),
 
+	// See https://www.postgresql.org/docs/current/sql-notify.html.
+	"pg_notify": makeBuiltin(
+		tree.FunctionProperties{
+			Category:         categorySystemInfo,
+			NullableArgs:     true,
+			DistsqlBlocklist: true,
+		},
+		tree.Overload{
+			Types:      tree.ArgTypes{{"channel", types.String}, {"payload", types.String}},
+			ReturnType: tree.FixedReturnType(types.Unknown),
+			Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				// Like in Postgres, a NULL channel is rejected as an empty one and
+				// a NULL payload is sent as an empty payload.
+				var channel, payload string
+				if args[0] != tree.DNull {
+					channel = string(tree.MustBeDString(args[0]))
+				}
+				if args[1] != tree.DNull {
+					payload = string(tree.MustBeDString(args[1]))
+				}
+				if err := ctx.Planner.SendNotification(ctx.Ctx(), channel, payload); err != nil {
+					return nil, err
+				}
+				return tree.DNull, nil
+			},
+			Info: "Sends a notification with the given payload on the given channel, like NOTIFY. " +
+				"The notification is delivered to the listening sessions once the current transaction commits.",
+			Volatility: tree.VolatilityVolatile,
+		},
+	),
+
 	// See https://www.postgresql.org/docs/9.3/static/catalog-pg-database.html.
 	"pg_encoding_to_char": makeBuiltin(defProps(),
 		tree.Overload{
@@ -969,26 +1001,77 @@ SELECT description
 		},
 	),
 
-	"pg_try_advisory_lock": makeBuiltin(defProps(),
-		tree.Overload{
-			Types:      tree.ArgTypes{{"int", types.Int}},
-			ReturnType: tree.FixedReturnType(types.Bool),
-			Fn: func(_ *tree.EvalContext, _ tree.Datums) (tree.Datum, error) {
-				return tree.DBoolTrue, nil
+	// See https://www.postgresql.org/docs/current/functions-admin.html#FUNCTIONS-ADVISORY-LOCKS.
+	"pg_advisory_lock": makeBuiltin(advisoryLockProps(),
+		makeAdvisoryLockOverloads(
+			types.Unknown,
+			"Acquires an exclusive session-level advisory lock, waiting if necessary. "+
+				"Deadlocks between sessions waiting for each other's advisory locks are not "+
+				"detected; use statement_timeout to limit the wait.",
+			func(ctx *tree.EvalContext, key tree.AdvisoryLockKey) (tree.Datum, error) {
+				_, err := ctx.Planner.AcquireAdvisoryLock(ctx.Ctx(), key, false /* xact */, false /* try */)
+				return tree.DNull, err
 			},
-			Info:       notUsableInfo,
-			Volatility: tree.VolatilityVolatile,
-		},
+		)...,
+	),
+
+	"pg_advisory_xact_lock": makeBuiltin(advisoryLockProps(),
+		makeAdvisoryLockOverloads(
+			types.Unknown,
+			"Acquires an exclusive transaction-level advisory lock, waiting if necessary. "+
+				"Deadlocks between sessions waiting for each other's advisory locks are not "+
+				"detected; use statement_timeout to limit the wait.",
+			func(ctx *tree.EvalContext, key tree.AdvisoryLockKey) (tree.Datum, error) {
+				_, err := ctx.Planner.AcquireAdvisoryLock(ctx.Ctx(), key, true /* xact */, false /* try */)
+				return tree.DNull, err
+			},
+		)...,
+	),
+
+	"pg_try_advisory_lock": makeBuiltin(advisoryLockProps(),
+		makeAdvisoryLockOverloads(
+			types.Bool,
+			"Acquires an exclusive session-level advisory lock if it is available. "+
+				"Returns whether the lock was acquired.",
+			func(ctx *tree.EvalContext, key tree.AdvisoryLockKey) (tree.Datum, error) {
+				ok, err := ctx.Planner.AcquireAdvisoryLock(ctx.Ctx(), key, false /* xact */, true /* try */)
+				return tree.MakeDBool(tree.DBool(ok)), err
+			},
+		)...,
+	),
+
+	"pg_try_advisory_xact_lock": makeBuiltin(advisoryLockProps(),
+		makeAdvisoryLockOverloads(
+			types.Bool,
+			"Acquires an exclusive transaction-level advisory lock if it is available. "+
+				"Returns whether the lock was acquired.",
+			func(ctx *tree.EvalContext, key tree.AdvisoryLockKey) (tree.Datum, error) {
+				ok, err := ctx.Planner.AcquireAdvisoryLock(ctx.Ctx(), key, true /* xact */, true /* try */)
+				return tree.MakeDBool(tree.DBool(ok)), err
+			},
+		)...,
+	),
+
+	"pg_advisory_unlock": makeBuiltin(advisoryLockProps(),
+		makeAdvisoryLockOverloads(
+			types.Bool,
+			"Releases a previously acquired exclusive session-level advisory lock. "+
+				"Returns false if the lock was not held.",
+			func(ctx *tree.EvalContext, key tree.AdvisoryLockKey) (tree.Datum, error) {
+				ok, err := ctx.Planner.ReleaseAdvisoryLock(ctx.Ctx(), key)
+				return tree.MakeDBool(tree.DBool(ok)), err
+			},
+		)...,
 	),
 
-	"pg_advisory_unlock": makeBuiltin(defProps(),
+	"pg_advisory_unlock_all": makeBuiltin(advisoryLockProps(),
 		tree.Overload{
-			Types:      tree.ArgTypes{{"int", types.Int}},
-			ReturnType: tree.FixedReturnType(types.Bool),
-			Fn: func(_ *tree.EvalContext, _ tree.Datums) (tree.Datum, error) {
-				return tree.DBoolTrue, nil
+			Types:      tree.ArgTypes{},
+			ReturnType: tree.FixedReturnType(types.Unknown),
+			Fn: func(ctx *tree.EvalContext, _ tree.Datums) (tree.Datum, error) {
+				return tree.DNull, ctx.Planner.ReleaseAllAdvisoryLocks(ctx.Ctx())
 			},
-			Info:       notUsableInfo,
+			Info:       "Releases all session-level advisory locks held by the current session.",
 			Volatility: tree.VolatilityVolatile,
 		},
 	),
@@ -1893,3 +1976,59 @@ SELECT description
 	}
 	return r[0], nil
 }
+
+// advisoryLockProps returns the properties of the advisory lock builtins,
+// which can only be evaluated by the gateway's session.
+func advisoryLockProps() tree.FunctionProperties {
+	return tree.FunctionProperties{
+		Category:         categorySystemInfo,
+		DistsqlBlocklist: true,
+	}
+}
+
+// makeAdvisoryLockOverloads returns the overloads of an advisory lock builtin.
+// Like in Postgres, a lock is identified either by a single bigint or by two
+// integers in the int4 range, and the two forms never identify the same lock.
+func makeAdvisoryLockOverloads(
+	returnType *types.T,
+	info string,
+	fn func(ctx *tree.EvalContext, key tree.AdvisoryLockKey) (tree.Datum, error),
+) []tree.Overload {
+	return []tree.Overload{
+		{
+			Types:      tree.ArgTypes{{"key", types.Int}},
+			ReturnType: tree.FixedReturnType(returnType),
+			Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				key := int64(tree.MustBeDInt(args[0]))
+				return fn(ctx, tree.AdvisoryLockKey{
+					ClassID:  uint32(key >> 32),
+					ObjID:    uint32(key),
+					ObjSubID: 1,
+				})
+			},
+			Info:       info,
+			Volatility: tree.VolatilityVolatile,
+		},
+		{
+			Types:      tree.ArgTypes{{"key1", types.Int}, {"key2", types.Int}},
+			ReturnType: tree.FixedReturnType(returnType),
+			Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				var keys [2]int32
+				for i := range keys {
+					k := tree.MustBeDInt(args[i])
+					if k < math.MinInt32 || k > math.MaxInt32 {
+						return nil, pgerror.New(pgcode.NumericValueOutOfRange, "integer out of range")
+					}
+					keys[i] = int32(k)
+				}
+				return fn(ctx, tree.AdvisoryLockKey{
+					ClassID:  uint32(keys[0]),
+					ObjID:    uint32(keys[1]),
+					ObjSubID: 2,
+				})
+			},
+			Info:       info,
+			Volatility: tree.VolatilityVolatile,
+		},
+	}
+}
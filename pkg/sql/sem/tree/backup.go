@@ -111,6 +111,27 @@ func (node *Restore) Format(ctx *FmtCtx) {
 	}
 }
 
+// CompactBackup represents a COMPACT BACKUP statement.
+type CompactBackup struct {
+	From    Exprs
+	To      Expr
+	Options KVOptions
+}
+
+var _ Statement = &CompactBackup{}
+
+// Format implements the NodeFormatter interface.
+func (node *CompactBackup) Format(ctx *FmtCtx) {
+	ctx.WriteString("COMPACT BACKUP FROM ")
+	ctx.FormatNode(&node.From)
+	ctx.WriteString(" TO ")
+	ctx.FormatNode(node.To)
+	if node.Options != nil {
+		ctx.WriteString(" WITH ")
+		ctx.FormatNode(&node.Options)
+	}
+}
+
 // KVOption is a key-value option.
 type KVOption struct {
 	Key   Name
@@ -15,6 +15,10 @@ type CreateChangefeed struct {
 	Targets TargetList
 	SinkURI Expr
 	Options KVOptions
+	// Select is the query of a changefeed created with the AS SELECT syntax,
+	// which projects and filters the rows of its only target. It is nil
+	// otherwise.
+	Select *SelectClause
 }
 
 var _ Statement = &CreateChangefeed{}
@@ -28,8 +32,11 @@ func (node *CreateChangefeed) Format(ctx *FmtCtx) {
 		// prefix. They're also still EXPERIMENTAL, so they get marked as such.
 		ctx.WriteString("EXPERIMENTAL ")
 	}
-	ctx.WriteString("CHANGEFEED FOR ")
-	ctx.FormatNode(&node.Targets)
+	ctx.WriteString("CHANGEFEED")
+	if node.Select == nil {
+		ctx.WriteString(" FOR ")
+		ctx.FormatNode(&node.Targets)
+	}
 	if node.SinkURI != nil {
 		ctx.WriteString(" INTO ")
 		ctx.FormatNode(node.SinkURI)
@@ -38,4 +45,8 @@ func (node *CreateChangefeed) Format(ctx *FmtCtx) {
 		ctx.WriteString(" WITH ")
 		ctx.FormatNode(&node.Options)
 	}
+	if node.Select != nil {
+		ctx.WriteString(" AS ")
+		ctx.FormatNode(node.Select)
+	}
 }
@@ -10,6 +10,13 @@
 
 package tree
 
+import (
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+)
+
 // CopyFrom represents a COPY FROM statement.
 type CopyFrom struct {
 	Table   TableName
@@ -36,3 +43,314 @@ func (node *CopyFrom) Format(ctx *FmtCtx) {
 		ctx.FormatNode(&node.Options)
 	}
 }
+
+// CopyTo represents a COPY TO statement.
+type CopyTo struct {
+	// Table and Columns are set when copying out of a table.
+	Table   TableName
+	Columns NameList
+	// Query is set when copying out the results of a query.
+	Query *Select
+	// Options are the options of the statement, in the generic option syntax
+	// of Postgres. The options given with the older syntax of Postgres, e.g.
+	// CSV HEADER, are converted to it by the parser. String and identifier
+	// values are stored as a *StrVal, a list of columns as a *Tuple of names
+	// and a * as UnqualifiedStar.
+	Options KVOptions
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CopyTo) Format(ctx *FmtCtx) {
+	ctx.WriteString("COPY ")
+	if node.Query != nil {
+		ctx.WriteByte('(')
+		ctx.FormatNode(node.Query)
+		ctx.WriteByte(')')
+	} else {
+		ctx.FormatNode(&node.Table)
+		if len(node.Columns) > 0 {
+			ctx.WriteString(" (")
+			ctx.FormatNode(&node.Columns)
+			ctx.WriteString(")")
+		}
+	}
+	ctx.WriteString(" TO STDOUT")
+	if node.Options != nil {
+		ctx.WriteString(" WITH (")
+		for i := range node.Options {
+			if i > 0 {
+				ctx.WriteString(", ")
+			}
+			// The option names are not user-provided identifiers, and some of them
+			// (e.g. null) are reserved keywords that are accepted unquoted as option
+			// names.
+			ctx.WriteString(string(node.Options[i].Key))
+			switch v := node.Options[i].Value.(type) {
+			case nil:
+			case *Tuple:
+				// A list of columns is not formatted as a tuple, since a tuple with
+				// a single element is formatted with a trailing comma.
+				ctx.WriteString(" (")
+				for j := range v.Exprs {
+					if j > 0 {
+						ctx.WriteString(", ")
+					}
+					ctx.FormatNode(v.Exprs[j])
+				}
+				ctx.WriteByte(')')
+			default:
+				ctx.WriteByte(' ')
+				ctx.FormatNode(v)
+			}
+		}
+		ctx.WriteByte(')')
+	}
+}
+
+// CopyFormat identifies the format of the data sent by COPY TO.
+type CopyFormat int
+
+const (
+	// CopyFormatText is the tab-separated text format of Postgres.
+	CopyFormatText CopyFormat = iota
+	// CopyFormatCSV is the comma-separated values format.
+	CopyFormatCSV
+	// CopyFormatBinary is the binary format of Postgres.
+	CopyFormatBinary
+)
+
+// CopyOptions contains the parsed options of a COPY TO statement.
+type CopyOptions struct {
+	Format CopyFormat
+	// Delimiter separates the columns of a row in the text and CSV formats.
+	Delimiter byte
+	// Null is the string written for NULL values in the text and CSV formats.
+	Null string
+	// Header is set if the first line of CSV output should contain the column
+	// names.
+	Header bool
+	// Quote is the character used to quote values in the CSV format.
+	Quote byte
+	// Escape is the character that precedes the quote and escape characters
+	// within a quoted value in the CSV format.
+	Escape byte
+	// ForceQuote lists the columns whose non-NULL values are always quoted in
+	// the CSV format.
+	ForceQuote NameList
+	// ForceQuoteAll is set if all non-NULL values are quoted in the CSV format.
+	ForceQuoteAll bool
+}
+
+// ParseCopyOptions parses the options of a COPY TO statement. The supported
+// options are the ones of Postgres:
+//
+//   format 'text' | 'csv' | 'binary'
+//   delimiter '...'
+//   null '...'
+//   header [boolean]
+//   quote '...'
+//   escape '...'
+//   force_quote (column [, ...]) | *
+//   encoding 'UTF8'
+//
+// See https://www.postgresql.org/docs/current/sql-copy.html.
+func ParseCopyOptions(opts KVOptions) (CopyOptions, error) {
+	res := CopyOptions{Format: CopyFormatText}
+	var delimiter, null, quote, escape *string
+	var forceQuote bool
+	seen := make(map[Name]struct{}, len(opts))
+	for _, opt := range opts {
+		if _, ok := seen[opt.Key]; ok {
+			return res, pgerror.New(pgcode.Syntax, "conflicting or redundant options")
+		}
+		seen[opt.Key] = struct{}{}
+		var val *string
+		if s, ok := opt.Value.(*StrVal); ok {
+			raw := s.RawString()
+			val = &raw
+		}
+		// stringArg checks that the option has a string or identifier value.
+		stringArg := func() error {
+			if val == nil {
+				return pgerror.Newf(pgcode.Syntax, "%s requires a parameter", opt.Key)
+			}
+			return nil
+		}
+		switch opt.Key {
+		case "format":
+			if err := stringArg(); err != nil {
+				return res, err
+			}
+			switch strings.ToLower(*val) {
+			case "text":
+				res.Format = CopyFormatText
+			case "csv":
+				res.Format = CopyFormatCSV
+			case "binary":
+				res.Format = CopyFormatBinary
+			default:
+				return res, pgerror.Newf(pgcode.InvalidParameterValue,
+					"COPY format %q not recognized", *val)
+			}
+		case "delimiter":
+			if err := stringArg(); err != nil {
+				return res, err
+			}
+			delimiter = val
+		case "null":
+			if err := stringArg(); err != nil {
+				return res, err
+			}
+			null = val
+		case "header":
+			switch {
+			case opt.Value == nil:
+				res.Header = true
+			case val != nil:
+				b, err := ParseDBool(*val)
+				if err != nil {
+					return res, pgerror.Newf(pgcode.Syntax, "%s requires a Boolean value", opt.Key)
+				}
+				res.Header = bool(*b)
+			default:
+				return res, pgerror.Newf(pgcode.Syntax, "%s requires a Boolean value", opt.Key)
+			}
+		case "quote":
+			if err := stringArg(); err != nil {
+				return res, err
+			}
+			quote = val
+		case "escape":
+			if err := stringArg(); err != nil {
+				return res, err
+			}
+			escape = val
+		case "force_quote":
+			switch v := opt.Value.(type) {
+			case UnqualifiedStar:
+				res.ForceQuoteAll = true
+			case *Tuple:
+				for _, e := range v.Exprs {
+					n, ok := e.(*UnresolvedName)
+					if !ok || n.NumParts != 1 || n.Star {
+						return res, pgerror.Newf(pgcode.Syntax,
+							"argument to option %q must be a list of column names", opt.Key)
+					}
+					res.ForceQuote = append(res.ForceQuote, Name(n.Parts[0]))
+				}
+			default:
+				return res, pgerror.Newf(pgcode.Syntax,
+					"argument to option %q must be a list of column names", opt.Key)
+			}
+			forceQuote = true
+		case "encoding":
+			if err := stringArg(); err != nil {
+				return res, err
+			}
+			switch strings.ToUpper(*val) {
+			case "UTF8", "UTF-8", "UNICODE":
+			default:
+				return res, pgerror.Newf(pgcode.FeatureNotSupported,
+					"COPY encoding %q is not supported, only UTF8 is", *val)
+			}
+		case "freeze", "force_not_null", "force_null":
+			return res, pgerror.Newf(pgcode.FeatureNotSupported,
+				"COPY %s only available using COPY FROM", strings.ToUpper(string(opt.Key)))
+		default:
+			return res, pgerror.Newf(pgcode.Syntax, "option %q not recognized", opt.Key)
+		}
+	}
+
+	switch res.Format {
+	case CopyFormatText:
+		res.Delimiter, res.Null = '\t', `\N`
+	case CopyFormatCSV:
+		res.Delimiter, res.Null, res.Quote = ',', "", '"'
+	case CopyFormatBinary:
+		if delimiter != nil {
+			return res, pgerror.New(pgcode.Syntax, "cannot specify DELIMITER in BINARY mode")
+		}
+		if null != nil {
+			return res, pgerror.New(pgcode.Syntax, "cannot specify NULL in BINARY mode")
+		}
+	}
+	if res.Header && res.Format != CopyFormatCSV {
+		return res, pgerror.New(pgcode.FeatureNotSupported, "COPY HEADER available only in CSV mode")
+	}
+	if res.Format != CopyFormatCSV {
+		if quote != nil {
+			return res, pgerror.New(pgcode.FeatureNotSupported, "COPY quote available only in CSV mode")
+		}
+		if escape != nil {
+			return res, pgerror.New(pgcode.FeatureNotSupported, "COPY escape available only in CSV mode")
+		}
+		if forceQuote {
+			return res, pgerror.New(pgcode.FeatureNotSupported, "COPY force quote available only in CSV mode")
+		}
+	}
+	if delimiter != nil {
+		if len(*delimiter) != 1 {
+			return res, pgerror.New(pgcode.FeatureNotSupported,
+				"COPY delimiter must be a single one-byte character")
+		}
+		res.Delimiter = (*delimiter)[0]
+		if res.Delimiter == '\r' || res.Delimiter == '\n' || res.Delimiter == '\\' ||
+			(res.Format == CopyFormatCSV && res.Delimiter == '"') {
+			return res, pgerror.Newf(pgcode.InvalidParameterValue,
+				"COPY delimiter cannot be %q", *delimiter)
+		}
+	}
+	if null != nil {
+		res.Null = *null
+		if strings.ContainsAny(res.Null, "\r\n") {
+			return res, pgerror.New(pgcode.InvalidParameterValue,
+				"COPY null representation cannot use newline or carriage return")
+		}
+	}
+	if quote != nil {
+		if len(*quote) != 1 {
+			return res, pgerror.New(pgcode.FeatureNotSupported,
+				"COPY quote must be a single one-byte character")
+		}
+		res.Quote = (*quote)[0]
+	}
+	res.Escape = res.Quote
+	if escape != nil {
+		if len(*escape) != 1 {
+			return res, pgerror.New(pgcode.FeatureNotSupported,
+				"COPY escape must be a single one-byte character")
+		}
+		res.Escape = (*escape)[0]
+	}
+	if res.Format == CopyFormatCSV && res.Delimiter == res.Quote {
+		return res, pgerror.New(pgcode.InvalidParameterValue,
+			"COPY delimiter and quote must be different")
+	}
+	return res, nil
+}
+
+// ForceQuoteColumns returns, for each of the columns of the results of a COPY
+// TO statement, whether its non-NULL values must be quoted. An error is
+// returned if a column listed in force_quote is not part of the results.
+func (o *CopyOptions) ForceQuoteColumns(colNames []string) ([]bool, error) {
+	res := make([]bool, len(colNames))
+	if o.ForceQuoteAll {
+		for i := range res {
+			res[i] = true
+		}
+		return res, nil
+	}
+	for _, name := range o.ForceQuote {
+		found := false
+		for i := range colNames {
+			if colNames[i] == string(name) {
+				res[i], found = true, true
+			}
+		}
+		if !found {
+			return nil, pgerror.Newf(pgcode.InvalidColumnReference,
+				"FORCE_QUOTE column %q not referenced by COPY", string(name))
+		}
+	}
+	return res, nil
+}
@@ -293,6 +293,7 @@ type ColumnTableDef struct {
 		ConstraintName Name
 		Actions        ReferenceActions
 		Match          CompositeKeyMatchMethod
+		Deferrability  ConstraintDeferrability
 	}
 	Computed struct {
 		Computed bool
@@ -414,6 +415,7 @@ func NewColumnTableDef(
 			d.References.ConstraintName = c.Name
 			d.References.Actions = t.Actions
 			d.References.Match = t.Match
+			d.References.Deferrability = t.Deferrability
 		case *ColumnComputedDef:
 			d.Computed.Computed = true
 			d.Computed.Expr = t.Expr
@@ -522,6 +524,7 @@ func (node *ColumnTableDef) Format(ctx *FmtCtx) {
 			ctx.WriteString(node.References.Match.String())
 		}
 		ctx.FormatNode(&node.References.Actions)
+		ctx.FormatNode(&node.References.Deferrability)
 	}
 	if node.IsComputed() {
 		ctx.WriteString(" AS (")
@@ -620,10 +623,11 @@ type ColumnCheckConstraint struct {
 
 // ColumnFKConstraint represents a FK-constaint on a column.
 type ColumnFKConstraint struct {
-	Table   TableName
-	Col     Name // empty-string means use PK
-	Actions ReferenceActions
-	Match   CompositeKeyMatchMethod
+	Table         TableName
+	Col           Name // empty-string means use PK
+	Actions       ReferenceActions
+	Match         CompositeKeyMatchMethod
+	Deferrability ConstraintDeferrability
 }
 
 // ColumnComputedDef represents the description of a computed column.
@@ -705,6 +709,8 @@ func (*CheckConstraintTableDef) constraintTableDef()      {}
 type UniqueConstraintTableDef struct {
 	IndexTableDef
 	PrimaryKey bool
+
+	Deferrability ConstraintDeferrability
 }
 
 // SetName implements the TableDef interface.
@@ -741,6 +747,7 @@ func (node *UniqueConstraintTableDef) Format(ctx *FmtCtx) {
 	if node.PartitionBy != nil {
 		ctx.FormatNode(node.PartitionBy)
 	}
+	ctx.FormatNode(&node.Deferrability)
 	if node.Predicate != nil {
 		ctx.WriteString(" WHERE ")
 		ctx.FormatNode(node.Predicate)
@@ -814,6 +821,37 @@ func (c CompositeKeyMatchMethod) String() string {
 	return compositeKeyMatchMethodName[c]
 }
 
+// ConstraintDeferrability specifies whether a constraint can be checked when
+// the transaction commits instead of after each statement, and whether it is
+// by default. See https://www.postgresql.org/docs/current/sql-set-constraints.html.
+type ConstraintDeferrability int
+
+// The values for ConstraintDeferrability.
+const (
+	NotDeferrable ConstraintDeferrability = iota
+	DeferrableInitiallyImmediate
+	DeferrableInitiallyDeferred
+)
+
+var constraintDeferrabilityName = [...]string{
+	NotDeferrable:                "NOT DEFERRABLE",
+	DeferrableInitiallyImmediate: "DEFERRABLE INITIALLY IMMEDIATE",
+	DeferrableInitiallyDeferred:  "DEFERRABLE INITIALLY DEFERRED",
+}
+
+func (c ConstraintDeferrability) String() string {
+	return constraintDeferrabilityName[c]
+}
+
+// Format implements the NodeFormatter interface. NOT DEFERRABLE, the
+// default, is omitted.
+func (c *ConstraintDeferrability) Format(ctx *FmtCtx) {
+	if *c != NotDeferrable {
+		ctx.WriteByte(' ')
+		ctx.WriteString(c.String())
+	}
+}
+
 // ForeignKeyConstraintTableDef represents a FOREIGN KEY constraint in the AST.
 type ForeignKeyConstraintTableDef struct {
 	Name     Name
@@ -822,6 +860,8 @@ type ForeignKeyConstraintTableDef struct {
 	ToCols   NameList
 	Actions  ReferenceActions
 	Match    CompositeKeyMatchMethod
+
+	Deferrability ConstraintDeferrability
 }
 
 // Format implements the NodeFormatter interface.
@@ -849,6 +889,7 @@ func (node *ForeignKeyConstraintTableDef) Format(ctx *FmtCtx) {
 	}
 
 	ctx.FormatNode(&node.Actions)
+	ctx.FormatNode(&node.Deferrability)
 }
 
 // SetName implements the ConstraintTableDef interface.
@@ -1121,6 +1162,7 @@ func (node *CreateTable) FormatBody(ctx *FmtCtx) {
 			ctx.FormatNode(&node.Defs)
 			ctx.WriteByte(')')
 		}
+		node.formatStorageParams(ctx)
 		ctx.WriteString(" AS ")
 		ctx.FormatNode(node.AsSource)
 	} else {
@@ -1133,8 +1175,25 @@ func (node *CreateTable) FormatBody(ctx *FmtCtx) {
 		if node.PartitionBy != nil {
 			ctx.FormatNode(node.PartitionBy)
 		}
-		// No storage parameters are implemented, so we never list the storage
-		// parameters in the output format.
+		node.formatStorageParams(ctx)
+	}
+}
+
+// formatStorageParams formats the WITH clause of the create table
+// definition. The only storage parameters which are implemented are the ones
+// configuring the row-level TTL of the table, so the other ones are never
+// listed in the output format.
+func (node *CreateTable) formatStorageParams(ctx *FmtCtx) {
+	var params StorageParams
+	for _, p := range node.StorageParams {
+		if strings.HasPrefix(string(p.Key), "ttl_") {
+			params = append(params, p)
+		}
+	}
+	if len(params) > 0 {
+		ctx.WriteString(" WITH (")
+		ctx.FormatNode(&params)
+		ctx.WriteByte(')')
 	}
 }
 
@@ -1190,6 +1249,8 @@ func (node *CreateTable) HoistConstraints() {
 					Name:     col.References.ConstraintName,
 					Actions:  col.References.Actions,
 					Match:    col.References.Match,
+
+					Deferrability: col.References.Deferrability,
 				})
 				col.References.Table = nil
 			}
@@ -1197,6 +1258,215 @@ func (node *CreateTable) HoistConstraints() {
 	}
 }
 
+// FuncParam is a parameter of a function in a CREATE FUNCTION statement.
+type FuncParam struct {
+	Name Name
+	Type ResolvableTypeReference
+}
+
+// FuncParams is a list of FuncParam.
+type FuncParams []FuncParam
+
+// Format implements the NodeFormatter interface.
+func (node *FuncParams) Format(ctx *FmtCtx) {
+	for i := range *node {
+		if i > 0 {
+			ctx.WriteString(", ")
+		}
+		p := &(*node)[i]
+		if p.Name != "" {
+			ctx.FormatNode(&p.Name)
+			ctx.WriteByte(' ')
+		}
+		ctx.FormatTypeReference(p.Type)
+	}
+}
+
+// FunctionOptions represents the options of a CREATE FUNCTION statement.
+type FunctionOptions struct {
+	Language string
+	// Volatility is zero if no volatility marker was specified, in which case
+	// the function is volatile.
+	Volatility Volatility
+	// Body is the SELECT statement that computes the result of the function.
+	Body *string
+}
+
+// Format implements the NodeFormatter interface.
+func (o *FunctionOptions) Format(ctx *FmtCtx) {
+	var addSep bool
+	maybeAddSep := func() {
+		if addSep {
+			ctx.WriteByte(' ')
+		}
+		addSep = true
+	}
+	if o.Language != "" {
+		maybeAddSep()
+		ctx.WriteString("LANGUAGE ")
+		ctx.FormatNameP(&o.Language)
+	}
+	if o.Volatility != 0 {
+		maybeAddSep()
+		ctx.WriteString(strings.ToUpper(o.Volatility.String()))
+	}
+	if o.Body != nil {
+		maybeAddSep()
+		ctx.WriteString("AS ")
+		lex.EncodeSQLStringWithFlags(&ctx.Buffer, *o.Body, ctx.flags.EncodeFlags())
+	}
+}
+
+// CombineWith merges other options into this struct. An error is returned if
+// the same option is specified multiple times.
+func (o *FunctionOptions) CombineWith(other *FunctionOptions) error {
+	if o.Language == "" {
+		o.Language = other.Language
+	} else if other.Language != "" {
+		return pgerror.New(pgcode.Syntax, "conflicting or redundant options")
+	}
+
+	if o.Volatility == 0 {
+		o.Volatility = other.Volatility
+	} else if other.Volatility != 0 {
+		return pgerror.New(pgcode.Syntax, "conflicting or redundant options")
+	}
+
+	if o.Body == nil {
+		o.Body = other.Body
+	} else if other.Body != nil {
+		return pgerror.New(pgcode.Syntax, "conflicting or redundant options")
+	}
+
+	return nil
+}
+
+// CreateFunction represents a CREATE FUNCTION statement.
+type CreateFunction struct {
+	Name       TableName
+	Replace    bool
+	Params     FuncParams
+	ReturnType ResolvableTypeReference
+	Options    FunctionOptions
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CreateFunction) Format(ctx *FmtCtx) {
+	ctx.WriteString("CREATE ")
+	if node.Replace {
+		ctx.WriteString("OR REPLACE ")
+	}
+	ctx.WriteString("FUNCTION ")
+	ctx.FormatNode(&node.Name)
+	ctx.WriteByte('(')
+	ctx.FormatNode(&node.Params)
+	ctx.WriteString(") RETURNS ")
+	ctx.FormatTypeReference(node.ReturnType)
+	ctx.WriteByte(' ')
+	ctx.FormatNode(&node.Options)
+}
+
+// Body returns the body of the function.
+func (node *CreateFunction) Body() string {
+	if node.Options.Body == nil {
+		return ""
+	}
+	return *node.Options.Body
+}
+
+// TriggerActionTime specifies whether a trigger fires before or after the
+// event that fires it.
+type TriggerActionTime int
+
+// TriggerActionTime values.
+const (
+	TriggerBefore TriggerActionTime = iota
+	TriggerAfter
+)
+
+func (t TriggerActionTime) String() string {
+	if t == TriggerAfter {
+		return "AFTER"
+	}
+	return "BEFORE"
+}
+
+// TriggerEvents is a set of events that fire a trigger.
+type TriggerEvents uint8
+
+// TriggerEvents values.
+const (
+	TriggerEventInsert TriggerEvents = 1 << iota
+	TriggerEventUpdate
+	TriggerEventDelete
+)
+
+// Contains returns true if all the events in other are in e.
+func (e TriggerEvents) Contains(other TriggerEvents) bool {
+	return e&other == other
+}
+
+// Format implements the NodeFormatter interface.
+func (e TriggerEvents) Format(ctx *FmtCtx) {
+	sep := ""
+	for _, ev := range []struct {
+		event TriggerEvents
+		name  string
+	}{
+		{TriggerEventInsert, "INSERT"},
+		{TriggerEventUpdate, "UPDATE"},
+		{TriggerEventDelete, "DELETE"},
+	} {
+		if e.Contains(ev.event) {
+			ctx.WriteString(sep)
+			ctx.WriteString(ev.name)
+			sep = " OR "
+		}
+	}
+}
+
+// CreateTrigger represents a CREATE TRIGGER statement.
+type CreateTrigger struct {
+	Name       Name
+	ActionTime TriggerActionTime
+	Events     TriggerEvents
+	Table      TableName
+	// ForEachRow is true for row-level triggers, and false for statement-level
+	// triggers.
+	ForEachRow bool
+	// When is the condition under which the trigger fires, or nil.
+	When     Expr
+	FuncName TableName
+	FuncArgs Exprs
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CreateTrigger) Format(ctx *FmtCtx) {
+	ctx.WriteString("CREATE TRIGGER ")
+	ctx.FormatNode(&node.Name)
+	ctx.WriteByte(' ')
+	ctx.WriteString(node.ActionTime.String())
+	ctx.WriteByte(' ')
+	ctx.FormatNode(node.Events)
+	ctx.WriteString(" ON ")
+	ctx.FormatNode(&node.Table)
+	if node.ForEachRow {
+		ctx.WriteString(" FOR EACH ROW")
+	} else {
+		ctx.WriteString(" FOR EACH STATEMENT")
+	}
+	if node.When != nil {
+		ctx.WriteString(" WHEN (")
+		ctx.FormatNode(node.When)
+		ctx.WriteByte(')')
+	}
+	ctx.WriteString(" EXECUTE FUNCTION ")
+	ctx.FormatNode(&node.FuncName)
+	ctx.WriteByte('(')
+	ctx.FormatNode(&node.FuncArgs)
+	ctx.WriteByte(')')
+}
+
 // CreateSchema represents a CREATE SCHEMA statement.
 type CreateSchema struct {
 	IfNotExists bool
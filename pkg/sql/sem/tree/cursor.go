@@ -0,0 +1,106 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package tree
+
+import "strconv"
+
+// DeclareCursor represents a DECLARE statement.
+type DeclareCursor struct {
+	Name Name
+	// Insensitive is set if the INSENSITIVE option was specified. All cursors
+	// are insensitive, so this only affects formatting.
+	Insensitive bool
+	// NoScroll is set if the NO SCROLL option was specified. Only forward
+	// iteration is supported, so this only affects formatting.
+	NoScroll bool
+	// Hold is set if the cursor was declared WITH HOLD, in which case it
+	// survives the commit of the transaction that created it.
+	Hold   bool
+	Select *Select
+}
+
+// Format implements the NodeFormatter interface.
+func (node *DeclareCursor) Format(ctx *FmtCtx) {
+	ctx.WriteString("DECLARE ")
+	ctx.FormatNode(&node.Name)
+	ctx.WriteByte(' ')
+	if node.Insensitive {
+		ctx.WriteString("INSENSITIVE ")
+	}
+	if node.NoScroll {
+		ctx.WriteString("NO SCROLL ")
+	}
+	ctx.WriteString("CURSOR ")
+	if node.Hold {
+		ctx.WriteString("WITH HOLD ")
+	}
+	ctx.WriteString("FOR ")
+	ctx.FormatNode(node.Select)
+}
+
+// CursorStmt contains the fields shared by the FETCH and MOVE statements.
+type CursorStmt struct {
+	Name Name
+	// Count is the number of rows to fetch or skip. It is ignored if All is
+	// set.
+	Count int64
+	// All is set if all remaining rows should be fetched or skipped.
+	All bool
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CursorStmt) Format(ctx *FmtCtx) {
+	if node.All {
+		ctx.WriteString("ALL ")
+	} else if node.Count != 1 {
+		ctx.WriteString(strconv.FormatInt(node.Count, 10))
+		ctx.WriteByte(' ')
+	}
+	ctx.FormatNode(&node.Name)
+}
+
+// FetchCursor represents a FETCH statement.
+type FetchCursor struct {
+	CursorStmt
+}
+
+// Format implements the NodeFormatter interface.
+func (node *FetchCursor) Format(ctx *FmtCtx) {
+	ctx.WriteString("FETCH ")
+	ctx.FormatNode(&node.CursorStmt)
+}
+
+// MoveCursor represents a MOVE statement.
+type MoveCursor struct {
+	CursorStmt
+}
+
+// Format implements the NodeFormatter interface.
+func (node *MoveCursor) Format(ctx *FmtCtx) {
+	ctx.WriteString("MOVE ")
+	ctx.FormatNode(&node.CursorStmt)
+}
+
+// CloseCursor represents a CLOSE statement.
+type CloseCursor struct {
+	Name Name
+	All  bool
+}
+
+// Format implements the NodeFormatter interface.
+func (node *CloseCursor) Format(ctx *FmtCtx) {
+	ctx.WriteString("CLOSE ")
+	if node.All {
+		ctx.WriteString("ALL")
+	} else {
+		ctx.FormatNode(&node.Name)
+	}
+}
@@ -2877,6 +2877,37 @@ type EvalPlanner interface {
 
 	// EvalSubquery returns the Datum for the given subquery node.
 	EvalSubquery(expr *Subquery) (Datum, error)
+
+	// SendNotification sends a notification on the given channel, which is
+	// delivered to the listening sessions if the current transaction commits.
+	SendNotification(ctx context.Context, channel, payload string) error
+
+	// AcquireAdvisoryLock acquires the given advisory lock in the current
+	// database. A transaction-level lock (xact) is held until the current
+	// transaction ends and a session-level lock until it is released or the
+	// session ends. If try is set and the lock is held by another session, it
+	// returns false instead of waiting for the lock.
+	AcquireAdvisoryLock(ctx context.Context, key AdvisoryLockKey, xact, try bool) (bool, error)
+
+	// ReleaseAdvisoryLock releases a session-level advisory lock held by the
+	// session in the current database. It returns false if the session does
+	// not hold the lock.
+	ReleaseAdvisoryLock(ctx context.Context, key AdvisoryLockKey) (bool, error)
+
+	// ReleaseAllAdvisoryLocks releases all the session-level advisory locks
+	// held by the session.
+	ReleaseAllAdvisoryLocks(ctx context.Context) error
+}
+
+// AdvisoryLockKey identifies an advisory lock within a database. Like in
+// Postgres, a lock identified by a single bigint k has ClassID k>>32, ObjID
+// k&0xffffffff and ObjSubID 1, and a lock identified by two int4 k1 and k2 has
+// ClassID k1, ObjID k2 and ObjSubID 2. These are the values of the classid,
+// objid and objsubid columns of pg_locks.
+type AdvisoryLockKey struct {
+	ClassID  uint32
+	ObjID    uint32
+	ObjSubID uint16
 }
 
 // EvalSessionAccessor is a limited interface to access session variables.
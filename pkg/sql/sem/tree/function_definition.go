@@ -151,6 +151,20 @@ func NewFunctionDefinition(
 	}
 }
 
+// NewUserDefinedFunctionDefinition allocates a FunctionDefinition for a
+// user-defined function with the given overload. Unlike builtins, user-defined
+// functions do not have telemetry counters, since their names are not known
+// in advance.
+func NewUserDefinedFunctionDefinition(
+	name string, props *FunctionProperties, def *Overload,
+) *FunctionDefinition {
+	return &FunctionDefinition{
+		Name:               name,
+		Definition:         []overloadImpl{def},
+		FunctionProperties: *props,
+	}
+}
+
 // FunDefs holds pre-allocated FunctionDefinition instances
 // for every builtin function. Initialized by builtins.init().
 var FunDefs map[string]*FunctionDefinition
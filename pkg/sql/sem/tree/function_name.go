@@ -11,8 +11,11 @@
 package tree
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/errors"
@@ -62,6 +65,41 @@ func (fn *ResolvableFunctionReference) Resolve(
 	}
 }
 
+// FunctionReferenceResolver resolves function names that do not refer to
+// builtins into user-defined functions.
+type FunctionReferenceResolver interface {
+	// ResolveFunction returns the definition of the user-defined function
+	// with the given name, or nil if there is no such function.
+	ResolveFunction(
+		ctx context.Context, name *UnresolvedName, path sessiondata.SearchPath,
+	) (*FunctionDefinition, error)
+}
+
+// ResolveWith is like Resolve, but falls back to the given resolver when the
+// name does not refer to a builtin. User-defined functions are not stored in
+// the reference, so that the name is resolved again every time the
+// expression is planned and changes to the function are picked up.
+func (fn *ResolvableFunctionReference) ResolveWith(
+	ctx context.Context, searchPath sessiondata.SearchPath, resolver FunctionReferenceResolver,
+) (*FunctionDefinition, error) {
+	fd, err := fn.Resolve(searchPath)
+	if err == nil || resolver == nil || pgerror.GetPGCode(err) != pgcode.UndefinedFunction {
+		return fd, err
+	}
+	name, ok := fn.FunctionReference.(*UnresolvedName)
+	if !ok {
+		return nil, err
+	}
+	udf, udfErr := resolver.ResolveFunction(ctx, name, searchPath)
+	if udfErr != nil {
+		return nil, udfErr
+	}
+	if udf == nil {
+		return nil, err
+	}
+	return udf, nil
+}
+
 // WrapFunction creates a new ResolvableFunctionReference
 // holding a pre-resolved function. Helper for grammar rules.
 func WrapFunction(n string) ResolvableFunctionReference {
@@ -72,6 +110,15 @@ func WrapFunction(n string) ResolvableFunctionReference {
 	return ResolvableFunctionReference{fd}
 }
 
+// WrapFunctionOverload is like WrapFunction, but also supports overloads of
+// user-defined functions, which are not present in FunDefs.
+func WrapFunctionOverload(n string, overload *Overload) ResolvableFunctionReference {
+	if overload != nil && overload.IsUDF() {
+		return ResolvableFunctionReference{&FunctionDefinition{Name: n}}
+	}
+	return WrapFunction(n)
+}
+
 // FunctionReference is the common interface to UnresolvedName and QualifiedFunctionName.
 type FunctionReference interface {
 	fmt.Stringer
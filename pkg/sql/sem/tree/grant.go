@@ -38,6 +38,7 @@ type Grant struct {
 type TargetList struct {
 	Databases NameList
 	Tables    TablePatterns
+	Functions TableNames
 	Tenant    roachpb.TenantID
 
 	// ForRoles and Roles are used internally in the parser and not used
@@ -52,6 +53,9 @@ func (tl *TargetList) Format(ctx *FmtCtx) {
 	if tl.Databases != nil {
 		ctx.WriteString("DATABASE ")
 		ctx.FormatNode(&tl.Databases)
+	} else if tl.Functions != nil {
+		ctx.WriteString("FUNCTION ")
+		ctx.FormatNode(&tl.Functions)
 	} else if tl.Tenant != (roachpb.TenantID{}) {
 		ctx.WriteString(fmt.Sprintf("TENANT %d", tl.Tenant.ToUint64()))
 	} else {
@@ -0,0 +1,55 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package tree
+
+// Listen represents a LISTEN statement.
+type Listen struct {
+	ChannelName Name
+}
+
+// Format implements the NodeFormatter interface.
+func (node *Listen) Format(ctx *FmtCtx) {
+	ctx.WriteString("LISTEN ")
+	ctx.FormatNode(&node.ChannelName)
+}
+
+// Unlisten represents an UNLISTEN statement.
+type Unlisten struct {
+	ChannelName Name
+	// All is set for UNLISTEN *, which stops listening on every channel.
+	All bool
+}
+
+// Format implements the NodeFormatter interface.
+func (node *Unlisten) Format(ctx *FmtCtx) {
+	ctx.WriteString("UNLISTEN ")
+	if node.All {
+		ctx.WriteByte('*')
+		return
+	}
+	ctx.FormatNode(&node.ChannelName)
+}
+
+// Notify represents a NOTIFY statement.
+type Notify struct {
+	ChannelName Name
+	Payload     string
+}
+
+// Format implements the NodeFormatter interface.
+func (node *Notify) Format(ctx *FmtCtx) {
+	ctx.WriteString("NOTIFY ")
+	ctx.FormatNode(&node.ChannelName)
+	if node.Payload != "" {
+		ctx.WriteString(", ")
+		ctx.FormatNode(NewStrVal(node.Payload))
+	}
+}
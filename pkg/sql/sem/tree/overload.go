@@ -85,8 +85,16 @@ type Overload struct {
 	// volatility against Postgres's volatility at test time.
 	// This should be used with caution.
 	IgnoreVolatilityCheck bool
+
+	// Body is set for user-defined functions. It is the SELECT statement that
+	// computes the result of the function, in which the arguments are
+	// referenced by placeholders: $1 refers to the first argument, etc.
+	Body string
 }
 
+// IsUDF returns whether the overload is a user-defined function.
+func (b Overload) IsUDF() bool { return b.Body != "" }
+
 // params implements the overloadImpl interface.
 func (b Overload) params() TypeList { return b.Types }
 
@@ -1655,6 +1655,7 @@ func (node *UniqueConstraintTableDef) doc(p *PrettyCfg) pretty.Doc {
 	//    [STORING ( ... )]
 	//    [INTERLEAVE ...]
 	//    [PARTITION BY ...]
+	//    [DEFERRABLE ...]
 	//    [WHERE ...]
 	//
 	// or (no constraint name):
@@ -1663,9 +1664,10 @@ func (node *UniqueConstraintTableDef) doc(p *PrettyCfg) pretty.Doc {
 	//    [STORING ( ... )]
 	//    [INTERLEAVE ...]
 	//    [PARTITION BY ...]
+	//    [DEFERRABLE ...]
 	//    [WHERE ...]
 	//
-	clauses := make([]pretty.Doc, 0, 5)
+	clauses := make([]pretty.Doc, 0, 6)
 	var title pretty.Doc
 	if node.PrimaryKey {
 		title = pretty.Keyword("PRIMARY KEY")
@@ -1692,6 +1694,9 @@ func (node *UniqueConstraintTableDef) doc(p *PrettyCfg) pretty.Doc {
 	if node.PartitionBy != nil {
 		clauses = append(clauses, p.Doc(node.PartitionBy))
 	}
+	if node.Deferrability != NotDeferrable {
+		clauses = append(clauses, pretty.Keyword(node.Deferrability.String()))
+	}
 	if node.Predicate != nil {
 		clauses = append(clauses, p.nestUnder(pretty.Keyword("WHERE"), p.Doc(node.Predicate)))
 	}
@@ -1742,6 +1747,10 @@ func (node *ForeignKeyConstraintTableDef) doc(p *PrettyCfg) pretty.Doc {
 		clauses = append(clauses, actions)
 	}
 
+	if node.Deferrability != NotDeferrable {
+		clauses = append(clauses, pretty.Keyword(node.Deferrability.String()))
+	}
+
 	return p.nestUnder(title, pretty.Group(pretty.Stack(clauses...)))
 }
 
@@ -1859,6 +1868,9 @@ func (node *ColumnTableDef) docRow(p *PrettyCfg) pretty.TableRow {
 		if ref := p.Doc(&node.References.Actions); ref != pretty.Nil {
 			fkDetails = append(fkDetails, ref)
 		}
+		if node.References.Deferrability != NotDeferrable {
+			fkDetails = append(fkDetails, pretty.Keyword(node.References.Deferrability.String()))
+		}
 		fk := fkHead
 		if len(fkDetails) > 0 {
 			fk = p.nestUnder(fk, pretty.Group(pretty.Stack(fkDetails...)))
@@ -1973,6 +1985,18 @@ func (node *Restore) doc(p *PrettyCfg) pretty.Doc {
 	return p.rlTable(items...)
 }
 
+func (node *CompactBackup) doc(p *PrettyCfg) pretty.Doc {
+	items := make([]pretty.TableRow, 0, 4)
+
+	items = append(items, p.row("COMPACT BACKUP", pretty.Nil))
+	items = append(items, p.row("FROM", p.Doc(&node.From)))
+	items = append(items, p.row("TO", p.Doc(node.To)))
+	if node.Options != nil {
+		items = append(items, p.row("WITH", p.Doc(&node.Options)))
+	}
+	return p.rlTable(items...)
+}
+
 func (node *TargetList) doc(p *PrettyCfg) pretty.Doc {
 	return p.unrow(node.docRow(p))
 }
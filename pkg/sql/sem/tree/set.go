@@ -74,6 +74,23 @@ func (node *SetTransaction) Format(ctx *FmtCtx) {
 	node.Modes.Format(ctx)
 }
 
+// SetConstraints represents a SET CONSTRAINTS ALL statement.
+type SetConstraints struct {
+	// Deferred is set for SET CONSTRAINTS ALL DEFERRED, and unset for SET
+	// CONSTRAINTS ALL IMMEDIATE.
+	Deferred bool
+}
+
+// Format implements the NodeFormatter interface.
+func (node *SetConstraints) Format(ctx *FmtCtx) {
+	ctx.WriteString("SET CONSTRAINTS ALL ")
+	if node.Deferred {
+		ctx.WriteString("DEFERRED")
+	} else {
+		ctx.WriteString("IMMEDIATE")
+	}
+}
+
 // SetSessionAuthorizationDefault represents a SET SESSION AUTHORIZATION DEFAULT
 // statement. This can be extended (and renamed) if we ever support names in the
 // last position.
@@ -165,6 +165,7 @@ type CCLOnlyStatement interface {
 var _ CCLOnlyStatement = &Backup{}
 var _ CCLOnlyStatement = &ShowBackup{}
 var _ CCLOnlyStatement = &Restore{}
+var _ CCLOnlyStatement = &CompactBackup{}
 var _ CCLOnlyStatement = &CreateChangefeed{}
 var _ CCLOnlyStatement = &Import{}
 var _ CCLOnlyStatement = &Export{}
@@ -276,6 +277,28 @@ func (*CannedOptPlan) StatementType() StatementType { return Rows }
 // StatementTag returns a short string identifying the type of statement.
 func (*CannedOptPlan) StatementTag() string { return "PREPARE AS OPT PLAN" }
 
+// StatementType implements the Statement interface.
+func (*CloseCursor) StatementType() StatementType { return Ack }
+
+// StatementTag returns a short string identifying the type of statement.
+func (n *CloseCursor) StatementTag() string {
+	// Postgres distinguishes the command tags for these two cases of Close statements.
+	if n.All {
+		return "CLOSE CURSOR ALL"
+	}
+	return "CLOSE CURSOR"
+}
+
+// StatementType implements the Statement interface.
+func (*CompactBackup) StatementType() StatementType { return Rows }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*CompactBackup) StatementTag() string { return "COMPACT BACKUP" }
+
+func (*CompactBackup) cclOnlyStatement() {}
+
+func (*CompactBackup) hiddenFromShowQueries() {}
+
 // StatementType implements the Statement interface.
 func (*CommentOnColumn) StatementType() StatementType { return DDL }
 
@@ -312,6 +335,12 @@ func (*CopyFrom) StatementType() StatementType { return CopyIn }
 // StatementTag returns a short string identifying the type of statement.
 func (*CopyFrom) StatementTag() string { return "COPY" }
 
+// StatementType implements the Statement interface.
+func (*CopyTo) StatementType() StatementType { return Rows }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*CopyTo) StatementTag() string { return "COPY" }
+
 // StatementType implements the Statement interface.
 func (*CreateChangefeed) StatementType() StatementType { return Rows }
 
@@ -337,6 +366,18 @@ func (*CreateIndex) StatementType() StatementType { return DDL }
 // StatementTag returns a short string identifying the type of statement.
 func (*CreateIndex) StatementTag() string { return "CREATE INDEX" }
 
+// StatementType implements the Statement interface.
+func (*CreateFunction) StatementType() StatementType { return DDL }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*CreateFunction) StatementTag() string { return "CREATE FUNCTION" }
+
+// StatementType implements the Statement interface.
+func (*CreateTrigger) StatementType() StatementType { return DDL }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*CreateTrigger) StatementTag() string { return "CREATE TRIGGER" }
+
 // StatementType implements the Statement interface.
 func (n *CreateSchema) StatementType() StatementType { return DDL }
 
@@ -408,6 +449,12 @@ func (n *Deallocate) StatementTag() string {
 	return "DEALLOCATE"
 }
 
+// StatementType implements the Statement interface.
+func (*DeclareCursor) StatementType() StatementType { return Ack }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*DeclareCursor) StatementTag() string { return "DECLARE CURSOR" }
+
 // StatementType implements the Statement interface.
 func (*Discard) StatementType() StatementType { return Ack }
 
@@ -438,6 +485,18 @@ func (*DropTable) StatementType() StatementType { return DDL }
 // StatementTag returns a short string identifying the type of statement.
 func (*DropTable) StatementTag() string { return "DROP TABLE" }
 
+// StatementType implements the Statement interface.
+func (*DropFunction) StatementType() StatementType { return DDL }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*DropFunction) StatementTag() string { return "DROP FUNCTION" }
+
+// StatementType implements the Statement interface.
+func (*DropTrigger) StatementType() StatementType { return DDL }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*DropTrigger) StatementTag() string { return "DROP TRIGGER" }
+
 // StatementType implements the Statement interface.
 func (*DropView) StatementType() StatementType { return DDL }
 
@@ -492,6 +551,12 @@ func (*Export) cclOnlyStatement() {}
 // StatementTag returns a short string identifying the type of statement.
 func (*Export) StatementTag() string { return "EXPORT" }
 
+// StatementType implements the Statement interface.
+func (*FetchCursor) StatementType() StatementType { return Rows }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*FetchCursor) StatementTag() string { return "FETCH" }
+
 // StatementType implements the Statement interface.
 func (*Grant) StatementType() StatementType { return DDL }
 
@@ -518,6 +583,24 @@ func (*Import) StatementTag() string { return "IMPORT" }
 
 func (*Import) cclOnlyStatement() {}
 
+// StatementType implements the Statement interface.
+func (*Listen) StatementType() StatementType { return Ack }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*Listen) StatementTag() string { return "LISTEN" }
+
+// StatementType implements the Statement interface.
+func (*MoveCursor) StatementType() StatementType { return RowsAffected }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*MoveCursor) StatementTag() string { return "MOVE" }
+
+// StatementType implements the Statement interface.
+func (*Notify) StatementType() StatementType { return Ack }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*Notify) StatementTag() string { return "NOTIFY" }
+
 // StatementType implements the Statement interface.
 func (*ParenSelect) StatementType() StatementType { return Rows }
 
@@ -654,6 +737,12 @@ func (*SetClusterSetting) StatementType() StatementType { return Ack }
 // StatementTag returns a short string identifying the type of statement.
 func (*SetClusterSetting) StatementTag() string { return "SET CLUSTER SETTING" }
 
+// StatementType implements the Statement interface.
+func (*SetConstraints) StatementType() StatementType { return Ack }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*SetConstraints) StatementTag() string { return "SET CONSTRAINTS" }
+
 // StatementType implements the Statement interface.
 func (*SetTransaction) StatementType() StatementType { return Ack }
 
@@ -895,6 +984,12 @@ func (*Split) StatementType() StatementType { return Rows }
 // StatementTag returns a short string identifying the type of statement.
 func (*Split) StatementTag() string { return "SPLIT" }
 
+// StatementType implements the Statement interface.
+func (*Unlisten) StatementType() StatementType { return Ack }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*Unlisten) StatementTag() string { return "UNLISTEN" }
+
 // StatementType implements the Statement interface.
 func (*Unsplit) StatementType() StatementType { return Rows }
 
@@ -951,26 +1046,34 @@ func (c *ControlSchedules) String() string               { return AsString(c) }
 func (n *CancelQueries) String() string                  { return AsString(n) }
 func (n *CancelSessions) String() string                 { return AsString(n) }
 func (n *CannedOptPlan) String() string                  { return AsString(n) }
+func (n *CloseCursor) String() string                    { return AsString(n) }
+func (n *CompactBackup) String() string                  { return AsString(n) }
 func (n *CommentOnColumn) String() string                { return AsString(n) }
 func (n *CommentOnDatabase) String() string              { return AsString(n) }
 func (n *CommentOnIndex) String() string                 { return AsString(n) }
 func (n *CommentOnTable) String() string                 { return AsString(n) }
 func (n *CommitTransaction) String() string              { return AsString(n) }
 func (n *CopyFrom) String() string                       { return AsString(n) }
+func (n *CopyTo) String() string                         { return AsString(n) }
 func (n *CreateChangefeed) String() string               { return AsString(n) }
 func (n *CreateDatabase) String() string                 { return AsString(n) }
+func (n *CreateFunction) String() string                 { return AsString(n) }
 func (n *CreateIndex) String() string                    { return AsString(n) }
 func (n *CreateRole) String() string                     { return AsString(n) }
 func (n *CreateTable) String() string                    { return AsString(n) }
 func (n *CreateSchema) String() string                   { return AsString(n) }
 func (n *CreateSequence) String() string                 { return AsString(n) }
 func (n *CreateStats) String() string                    { return AsString(n) }
+func (n *CreateTrigger) String() string                  { return AsString(n) }
 func (n *CreateView) String() string                     { return AsString(n) }
 func (n *Deallocate) String() string                     { return AsString(n) }
+func (n *DeclareCursor) String() string                  { return AsString(n) }
 func (n *Delete) String() string                         { return AsString(n) }
 func (n *DropDatabase) String() string                   { return AsString(n) }
+func (n *DropFunction) String() string                   { return AsString(n) }
 func (n *DropIndex) String() string                      { return AsString(n) }
 func (n *DropTable) String() string                      { return AsString(n) }
+func (n *DropTrigger) String() string                    { return AsString(n) }
 func (n *DropType) String() string                       { return AsString(n) }
 func (n *DropView) String() string                       { return AsString(n) }
 func (n *DropSequence) String() string                   { return AsString(n) }
@@ -979,10 +1082,14 @@ func (n *Execute) String() string                        { return AsString(n) }
 func (n *Explain) String() string                        { return AsString(n) }
 func (n *ExplainAnalyzeDebug) String() string            { return AsString(n) }
 func (n *Export) String() string                         { return AsString(n) }
+func (n *FetchCursor) String() string                    { return AsString(n) }
 func (n *Grant) String() string                          { return AsString(n) }
 func (n *GrantRole) String() string                      { return AsString(n) }
 func (n *Insert) String() string                         { return AsString(n) }
 func (n *Import) String() string                         { return AsString(n) }
+func (n *Listen) String() string                         { return AsString(n) }
+func (n *MoveCursor) String() string                     { return AsString(n) }
+func (n *Notify) String() string                         { return AsString(n) }
 func (n *ParenSelect) String() string                    { return AsString(n) }
 func (n *Prepare) String() string                        { return AsString(n) }
 func (n *ReleaseSavepoint) String() string               { return AsString(n) }
@@ -1006,6 +1113,7 @@ func (n *SetClusterSetting) String() string              { return AsString(n) }
 func (n *SetZoneConfig) String() string                  { return AsString(n) }
 func (n *SetSessionAuthorizationDefault) String() string { return AsString(n) }
 func (n *SetSessionCharacteristics) String() string      { return AsString(n) }
+func (n *SetConstraints) String() string                 { return AsString(n) }
 func (n *SetTransaction) String() string                 { return AsString(n) }
 func (n *SetTracing) String() string                     { return AsString(n) }
 func (n *SetVar) String() string                         { return AsString(n) }
@@ -1042,6 +1150,7 @@ func (n *ShowVar) String() string                        { return AsString(n) }
 func (n *ShowZoneConfig) String() string                 { return AsString(n) }
 func (n *ShowFingerprints) String() string               { return AsString(n) }
 func (n *Split) String() string                          { return AsString(n) }
+func (n *Unlisten) String() string                       { return AsString(n) }
 func (n *Unsplit) String() string                        { return AsString(n) }
 func (n *Truncate) String() string                       { return AsString(n) }
 func (n *UnionClause) String() string                    { return AsString(n) }
@@ -49,6 +49,10 @@ type SemaContext struct {
 	// TypeResolver manages resolving type names into *types.T's.
 	TypeResolver TypeReferenceResolver
 
+	// FunctionResolver resolves the names of user-defined functions. It may be
+	// nil, in which case only builtin functions can be used.
+	FunctionResolver FunctionReferenceResolver
+
 	// AsOfTimestamp denotes the explicit AS OF SYSTEM TIME timestamp for the
 	// query, if any. If the query is not an AS OF SYSTEM TIME query,
 	// AsOfTimestamp is nil.
@@ -885,10 +889,12 @@ func (expr *FuncExpr) TypeCheck(
 	ctx context.Context, semaCtx *SemaContext, desired *types.T,
 ) (TypedExpr, error) {
 	var searchPath sessiondata.SearchPath
+	var resolver FunctionReferenceResolver
 	if semaCtx != nil {
 		searchPath = semaCtx.SearchPath
+		resolver = semaCtx.FunctionResolver
 	}
-	def, err := expr.Func.Resolve(searchPath)
+	def, err := expr.Func.ResolveWith(ctx, searchPath, resolver)
 	if err != nil {
 		return nil, err
 	}
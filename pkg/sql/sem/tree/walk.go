@@ -805,6 +805,47 @@ func (stmt *Backup) walkStmt(v Visitor) Statement {
 	return ret
 }
 
+// copyNode makes a copy of this Statement without recursing in any child Statements.
+func (stmt *CompactBackup) copyNode() *CompactBackup {
+	stmtCopy := *stmt
+	stmtCopy.From = append(Exprs(nil), stmt.From...)
+	stmtCopy.Options = append(KVOptions(nil), stmt.Options...)
+	return &stmtCopy
+}
+
+// walkStmt is part of the walkableStmt interface.
+func (stmt *CompactBackup) walkStmt(v Visitor) Statement {
+	ret := stmt
+	for i, expr := range stmt.From {
+		e, changed := WalkExpr(v, expr)
+		if changed {
+			if ret == stmt {
+				ret = stmt.copyNode()
+			}
+			ret.From[i] = e
+		}
+	}
+	if stmt.To != nil {
+		e, changed := WalkExpr(v, stmt.To)
+		if changed {
+			if ret == stmt {
+				ret = stmt.copyNode()
+			}
+			ret.To = e
+		}
+	}
+	{
+		opts, changed := walkKVOptions(v, stmt.Options)
+		if changed {
+			if ret == stmt {
+				ret = stmt.copyNode()
+			}
+			ret.Options = opts
+		}
+	}
+	return ret
+}
+
 // copyNode makes a copy of this Statement without recursing in any child Statements.
 func (stmt *Delete) copyNode() *Delete {
 	stmtCopy := *stmt
@@ -1404,6 +1445,7 @@ func (stmt *BeginTransaction) walkStmt(v Visitor) Statement {
 
 var _ walkableStmt = &CreateTable{}
 var _ walkableStmt = &Backup{}
+var _ walkableStmt = &CompactBackup{}
 var _ walkableStmt = &Delete{}
 var _ walkableStmt = &Explain{}
 var _ walkableStmt = &Insert{}
@@ -127,6 +127,8 @@ type SessionData struct {
 	TempTablesEnabled bool
 	// HashShardedIndexesEnabled indicates whether hash sharded indexes can be created.
 	HashShardedIndexesEnabled bool
+	// TriggersEnabled indicates whether triggers can be created.
+	TriggersEnabled bool
 	// ImplicitSelectForUpdate is true if FOR UPDATE locking may be used during
 	// the row-fetch phase of mutation statements.
 	ImplicitSelectForUpdate bool
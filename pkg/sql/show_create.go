@@ -142,7 +142,13 @@ func ShowCreateTable(
 		if idx.ID != desc.PrimaryIndex.ID && includeInterleaveClause {
 			// Showing the primary index is handled above.
 			f.WriteString(",\n\t")
-			f.WriteString(idx.SQLString(&sqlbase.AnonymousTable))
+			if idx.DeferrableUnique {
+				// A DEFERRABLE UNIQUE constraint can only be declared as a
+				// constraint. Its deferrability follows the PARTITION BY clause.
+				showDeferrableUniqueConstraint(idx, f)
+			} else {
+				f.WriteString(idx.SQLString(&sqlbase.AnonymousTable))
+			}
 			// Showing the INTERLEAVE and PARTITION BY for the primary index are
 			// handled last.
 
@@ -158,6 +164,11 @@ func ShowCreateTable(
 			); err != nil {
 				return "", err
 			}
+			if idx.InitiallyDeferred {
+				f.WriteString(" DEFERRABLE INITIALLY DEFERRED")
+			} else if idx.DeferrableUnique {
+				f.WriteString(" DEFERRABLE INITIALLY IMMEDIATE")
+			}
 		}
 	}
 
@@ -176,6 +187,17 @@ func ShowCreateTable(
 		return "", err
 	}
 
+	if desc.RowLevelTTL != nil {
+		f.WriteString(" WITH (")
+		params := rowLevelTTLStorageParamsFromDesc(desc.RowLevelTTL)
+		f.FormatNode(&params)
+		f.WriteString(")")
+	}
+
+	if err := showTriggers(desc, &f.Buffer); err != nil {
+		return "", err
+	}
+
 	if !displayOptions.IgnoreComments {
 		if err := showComments(desc, selectComment(ctx, p, desc.ID), &f.Buffer); err != nil {
 			return "", err
@@ -185,6 +207,26 @@ func ShowCreateTable(
 	return f.CloseAndGetString(), nil
 }
 
+// showDeferrableUniqueConstraint writes the declaration of the DEFERRABLE
+// UNIQUE constraint backed by the given index, up to its STORING clause.
+func showDeferrableUniqueConstraint(idx *sqlbase.IndexDescriptor, f *tree.FmtCtx) {
+	f.WriteString("CONSTRAINT ")
+	f.FormatNameP(&idx.Name)
+	f.WriteString(" UNIQUE (")
+	idx.ColNamesFormat(f)
+	f.WriteByte(')')
+	if len(idx.StoreColumnNames) > 0 {
+		f.WriteString(" STORING (")
+		for i := range idx.StoreColumnNames {
+			if i > 0 {
+				f.WriteString(", ")
+			}
+			f.FormatNameP(&idx.StoreColumnNames[i])
+		}
+		f.WriteByte(')')
+	}
+}
+
 // formatQuoteNames quotes and adds commas between names.
 func formatQuoteNames(buf *bytes.Buffer, names ...string) {
 	f := tree.NewFmtCtx(tree.FmtSimple)
@@ -17,6 +17,7 @@ import (
 	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemaexpr"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -152,6 +153,44 @@ func showComments(
 	return nil
 }
 
+// showTriggers writes a CREATE TRIGGER statement for each trigger of the
+// table to buf.
+func showTriggers(table *sqlbase.ImmutableTableDescriptor, buf *bytes.Buffer) error {
+	f := tree.NewFmtCtx(tree.FmtSimple)
+	for i := range table.Triggers {
+		t := &table.Triggers[i]
+		n := &tree.CreateTrigger{
+			Name:       tree.Name(t.Name),
+			ActionTime: tree.TriggerAfter,
+			Events:     tree.TriggerEvents(t.Events),
+			Table:      tree.MakeUnqualifiedTableName(tree.Name(table.Name)),
+			ForEachRow: t.ForEachRow,
+		}
+		if t.Before {
+			n.ActionTime = tree.TriggerBefore
+		}
+		if t.WhenExpr != "" {
+			when, err := parser.ParseExpr(t.WhenExpr)
+			if err != nil {
+				return err
+			}
+			n.When = when
+		}
+		fnName, err := parser.ParseQualifiedTableName(t.FunctionName)
+		if err != nil {
+			return err
+		}
+		n.FuncName = *fnName
+		if n.FuncArgs, err = parser.ParseExprs(t.Args); err != nil {
+			return err
+		}
+		f.WriteString(";\n")
+		f.FormatNode(n)
+	}
+	buf.WriteString(f.CloseAndGetString())
+	return nil
+}
+
 // showForeignKeyConstraint returns a valid SQL representation of a FOREIGN KEY
 // clause for a given index.
 func showForeignKeyConstraint(
@@ -211,6 +250,11 @@ func showForeignKeyConstraint(
 		buf.WriteString(" ON UPDATE ")
 		buf.WriteString(fk.OnUpdate.String())
 	}
+	if fk.InitiallyDeferred {
+		buf.WriteString(" DEFERRABLE INITIALLY DEFERRED")
+	} else if fk.Deferrable {
+		buf.WriteString(" DEFERRABLE INITIALLY IMMEDIATE")
+	}
 	return nil
 }
 
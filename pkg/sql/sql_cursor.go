@@ -0,0 +1,495 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// sqlCursor is a cursor created with DECLARE. The results of the cursor's
+// query are materialized into a row container when the cursor is declared;
+// FETCH and MOVE then iterate over them. This makes every cursor insensitive
+// to changes made after its declaration, like Postgres' INSENSITIVE cursors.
+// The row container spills to temporary storage once the cursor's memory
+// budget, sql.distsql.temp_storage.workmem, is exhausted.
+type sqlCursor struct {
+	// statement is the DECLARE statement that created the cursor. It is shown
+	// in pg_cursors.
+	statement string
+	// columns describes the results of the cursor's query.
+	columns sqlbase.ResultColumns
+	// rows holds the results of the cursor's query.
+	rows *rowcontainer.DiskBackedIndexedRowContainer
+	// memMon and diskMon account for the memory and the temporary storage used
+	// by rows.
+	memMon  *mon.BytesMonitor
+	diskMon *mon.BytesMonitor
+	// pos is the position of the cursor. Zero means that the cursor is before
+	// the first row, rows.Len()+1 means that it is after the last row and any
+	// other value points to the row at index pos-1, which was the last row
+	// fetched.
+	pos int
+	// hold is set if the cursor was declared WITH HOLD.
+	hold bool
+	// committed is set once the transaction that declared the cursor commits.
+	// Only cursors declared WITH HOLD can be committed; the others are closed
+	// at the end of their transaction.
+	committed bool
+	// savepointDepth is the number of savepoints that were active when the
+	// cursor was declared. Rolling back to a savepoint closes the cursors that
+	// were declared after it, like in Postgres.
+	savepointDepth int
+	// created is the time at which the cursor was declared.
+	created time.Time
+}
+
+// next advances the cursor by one row and returns that row. The returned row
+// is nil if the cursor has moved past the last row.
+func (c *sqlCursor) next(ctx context.Context) (tree.Datums, error) {
+	if c.pos >= c.rows.Len() {
+		c.pos = c.rows.Len() + 1
+		return nil, nil
+	}
+	c.pos++
+	return c.row(ctx, c.pos-1)
+}
+
+// current returns the row the cursor is positioned on, i.e. the last row
+// fetched. The returned row is nil if the cursor is positioned before the
+// first row or after the last row.
+func (c *sqlCursor) current(ctx context.Context) (tree.Datums, error) {
+	if c.pos < 1 || c.pos > c.rows.Len() {
+		return nil, nil
+	}
+	return c.row(ctx, c.pos-1)
+}
+
+// row returns the row at index idx of the cursor's results.
+func (c *sqlCursor) row(ctx context.Context, idx int) (tree.Datums, error) {
+	row, err := c.rows.GetRow(ctx, idx)
+	if err != nil {
+		return nil, err
+	}
+	return row.GetDatums(0, len(c.columns))
+}
+
+// close releases the memory and the temporary storage held by the cursor.
+func (c *sqlCursor) close(ctx context.Context) {
+	c.rows.Close(ctx)
+	c.diskMon.Stop(ctx)
+	c.memMon.Stop(ctx)
+}
+
+// sqlCursors gives a planner access to the cursors of a session.
+type sqlCursors interface {
+	// getCursor returns the open cursor with the given name.
+	getCursor(name tree.Name) (*sqlCursor, error)
+	// addCursor registers a new cursor under the given name. An error is
+	// returned if a cursor with that name already exists.
+	addCursor(name tree.Name, c *sqlCursor) error
+	// closeCursor closes and removes the cursor with the given name.
+	closeCursor(ctx context.Context, name tree.Name) error
+	// closeAll closes and removes all open cursors.
+	closeAll(ctx context.Context)
+	// list returns all open cursors as a map keyed by name. The map itself is
+	// a copy of the cursors.
+	list() map[tree.Name]*sqlCursor
+	// memMonitor returns the monitor against which the results of cursors are
+	// accounted. Since cursors declared WITH HOLD outlive the transaction that
+	// declared them, this is a session-scoped monitor.
+	memMonitor() *mon.BytesMonitor
+}
+
+// cursorMap is the collection of a session's open cursors.
+type cursorMap struct {
+	cursors map[tree.Name]*sqlCursor
+}
+
+func (m *cursorMap) getCursor(name tree.Name) (*sqlCursor, error) {
+	c, ok := m.cursors[name]
+	if !ok {
+		return nil, pgerror.Newf(pgcode.InvalidCursorName, "cursor %q does not exist", name)
+	}
+	return c, nil
+}
+
+func (m *cursorMap) addCursor(name tree.Name, c *sqlCursor) error {
+	if _, ok := m.cursors[name]; ok {
+		return pgerror.Newf(pgcode.DuplicateCursor, "cursor %q already exists", name)
+	}
+	if m.cursors == nil {
+		m.cursors = make(map[tree.Name]*sqlCursor)
+	}
+	m.cursors[name] = c
+	return nil
+}
+
+func (m *cursorMap) closeCursor(ctx context.Context, name tree.Name) error {
+	c, err := m.getCursor(name)
+	if err != nil {
+		return err
+	}
+	c.close(ctx)
+	delete(m.cursors, name)
+	return nil
+}
+
+func (m *cursorMap) closeAll(ctx context.Context) {
+	for name, c := range m.cursors {
+		c.close(ctx)
+		delete(m.cursors, name)
+	}
+}
+
+// onTxnFinish updates the cursors when a transaction commits, rolls back or
+// restarts. A commit closes the cursors that were not declared WITH HOLD and
+// turns the others into committed cursors that survive until the session
+// closes them. A rollback or restart closes all cursors declared by the
+// transaction, including the ones declared WITH HOLD.
+func (m *cursorMap) onTxnFinish(ctx context.Context, ev txnEvent) {
+	for name, c := range m.cursors {
+		if c.committed {
+			continue
+		}
+		if ev == txnCommit && c.hold {
+			c.committed = true
+			continue
+		}
+		c.close(ctx)
+		delete(m.cursors, name)
+	}
+}
+
+// onRollbackToSavepoint closes the cursors that were declared after the
+// savepoint at index idx of the transaction's savepoint stack was created.
+// Committed cursors belong to earlier transactions and are left alone.
+func (m *cursorMap) onRollbackToSavepoint(ctx context.Context, idx int) {
+	for name, c := range m.cursors {
+		if c.committed || c.savepointDepth <= idx {
+			continue
+		}
+		c.close(ctx)
+		delete(m.cursors, name)
+	}
+}
+
+// connExCursorAccessor is an implementation of sqlCursors that gives access
+// to a connExecutor's cursors.
+type connExCursorAccessor struct {
+	ex *connExecutor
+}
+
+var _ sqlCursors = connExCursorAccessor{}
+
+// getCursor is part of the sqlCursors interface.
+func (a connExCursorAccessor) getCursor(name tree.Name) (*sqlCursor, error) {
+	return a.ex.extraTxnState.sqlCursors.getCursor(name)
+}
+
+// addCursor is part of the sqlCursors interface.
+func (a connExCursorAccessor) addCursor(name tree.Name, c *sqlCursor) error {
+	c.savepointDepth = len(a.ex.extraTxnState.savepoints)
+	return a.ex.extraTxnState.sqlCursors.addCursor(name, c)
+}
+
+// closeCursor is part of the sqlCursors interface.
+func (a connExCursorAccessor) closeCursor(ctx context.Context, name tree.Name) error {
+	return a.ex.extraTxnState.sqlCursors.closeCursor(ctx, name)
+}
+
+// closeAll is part of the sqlCursors interface.
+func (a connExCursorAccessor) closeAll(ctx context.Context) {
+	a.ex.extraTxnState.sqlCursors.closeAll(ctx)
+}
+
+// list is part of the sqlCursors interface.
+func (a connExCursorAccessor) list() map[tree.Name]*sqlCursor {
+	// Return a copy of the data, to prevent modification of the map.
+	cursors := a.ex.extraTxnState.sqlCursors.cursors
+	ret := make(map[tree.Name]*sqlCursor, len(cursors))
+	for name, c := range cursors {
+		ret[name] = c
+	}
+	return ret
+}
+
+// memMonitor is part of the sqlCursors interface.
+func (a connExCursorAccessor) memMonitor() *mon.BytesMonitor {
+	return a.ex.sessionMon
+}
+
+// DeclareCursor implements the DECLARE statement.
+// See https://www.postgresql.org/docs/current/sql-declare.html for details.
+func (p *planner) DeclareCursor(ctx context.Context, s *tree.DeclareCursor) (planNode, error) {
+	return &declareCursorNode{n: s}, nil
+}
+
+// declareCursorNode runs the query of a DECLARE statement and stores its
+// results in a new cursor.
+type declareCursorNode struct {
+	n *tree.DeclareCursor
+}
+
+func (n *declareCursorNode) startExec(params runParams) error {
+	p := params.p
+	if !n.n.Hold && p.EvalContext().TxnImplicit {
+		return pgerror.Newf(pgcode.NoActiveSQLTransaction,
+			"DECLARE CURSOR can only be used in transaction blocks")
+	}
+	if _, err := p.sqlCursors.getCursor(n.n.Name); err == nil {
+		return pgerror.Newf(pgcode.DuplicateCursor, "cursor %q already exists", n.n.Name)
+	}
+	if p.stmt != nil && p.stmt.NumPlaceholders > 0 {
+		return unimplemented.NewWithIssueDetail(41412, "placeholders",
+			"DECLARE CURSOR with placeholders is not supported")
+	}
+
+	// The query is planned and run by a copy of the planner, in the current
+	// transaction and with the session's settings, so it sees the same data
+	// and objects as a plain SELECT would.
+	stmt, err := parser.ParseOne(tree.AsStringWithFlags(n.n.Select, tree.FmtParsable))
+	if err != nil {
+		return err
+	}
+	plannerCopy := *p
+	plannerCopy.stmt = &Statement{Statement: stmt}
+	plannerCopy.semaCtx.Annotations = tree.MakeAnnotations(stmt.NumAnnotations)
+	plannerCopy.extendedEvalCtx.Annotations = &plannerCopy.semaCtx.Annotations
+	plannerCopy.optPlanningCtx.init(&plannerCopy)
+	if err := plannerCopy.makeOptimizerPlan(params.ctx); err != nil {
+		return err
+	}
+	defer plannerCopy.curPlan.close(params.ctx)
+	cols := plannerCopy.curPlan.main.planColumns()
+
+	// The rows are streamed into the cursor's row container as the query
+	// produces them. The container is accounted against the session, since
+	// cursors declared WITH HOLD outlive their transaction.
+	distSQLCfg := &p.execCfg.DistSQLSrv.ServerConfig
+	memMon := execinfra.NewLimitedMonitor(
+		params.ctx, p.sqlCursors.memMonitor(), distSQLCfg, "cursor-limited",
+	)
+	diskMon := execinfra.NewMonitor(params.ctx, distSQLCfg.DiskMonitor, "cursor-disk")
+	colTypes := make([]*types.T, len(cols))
+	for i := range cols {
+		colTypes[i] = cols[i].Typ
+	}
+	cursor := &sqlCursor{
+		statement: n.n.String(),
+		columns:   cols,
+		rows: rowcontainer.NewDiskBackedIndexedRowContainer(
+			nil, /* ordering */
+			colTypes,
+			p.EvalContext(),
+			distSQLCfg.TempStorage,
+			memMon,
+			diskMon,
+			0, /* rowCapacity */
+		),
+		memMon:  memMon,
+		diskMon: diskMon,
+		hold:    n.n.Hold,
+		created: timeutil.Now(),
+	}
+	encRow := make(sqlbase.EncDatumRow, len(cols))
+	rw := newCallbackResultWriter(func(ctx context.Context, row tree.Datums) error {
+		for i := range row {
+			encRow[i] = sqlbase.DatumToEncDatum(colTypes[i], row[i])
+		}
+		return cursor.rows.AddRow(ctx, encRow)
+	})
+	if err := runPlanInsidePlan(
+		runParams{ctx: params.ctx, extendedEvalCtx: &plannerCopy.extendedEvalCtx, p: &plannerCopy},
+		&plannerCopy.curPlan, rw,
+	); err != nil {
+		cursor.close(params.ctx)
+		return err
+	}
+	if err := p.sqlCursors.addCursor(n.n.Name, cursor); err != nil {
+		cursor.close(params.ctx)
+		return err
+	}
+	return nil
+}
+
+func (n *declareCursorNode) Next(runParams) (bool, error) { return false, nil }
+func (n *declareCursorNode) Values() tree.Datums          { return nil }
+func (n *declareCursorNode) Close(context.Context)        {}
+
+// errBackwardScan is returned when a FETCH or MOVE statement tries to move a
+// cursor backwards.
+var errBackwardScan = errors.WithHint(
+	pgerror.New(pgcode.ObjectNotInPrerequisiteState, "cursor can only scan forward"),
+	"Declare it with SCROLL option to enable backward scan.",
+)
+
+// FetchCursor implements the FETCH statement.
+// See https://www.postgresql.org/docs/current/sql-fetch.html for details.
+func (p *planner) FetchCursor(ctx context.Context, s *tree.FetchCursor) (planNode, error) {
+	if !s.All && s.Count < 0 {
+		return nil, errBackwardScan
+	}
+	cursor, err := p.sqlCursors.getCursor(s.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &fetchCursorNode{n: s.CursorStmt, columns: cursor.columns}, nil
+}
+
+// fetchCursorNode returns rows from a cursor and advances it.
+type fetchCursorNode struct {
+	n       tree.CursorStmt
+	columns sqlbase.ResultColumns
+
+	run struct {
+		cursor *sqlCursor
+		// fetched is the number of rows returned so far.
+		fetched int64
+		row     tree.Datums
+	}
+}
+
+func (n *fetchCursorNode) startExec(params runParams) error {
+	// The cursor is looked up again since it may have been closed between
+	// planning and execution of a prepared FETCH statement.
+	cursor, err := params.p.sqlCursors.getCursor(n.n.Name)
+	if err != nil {
+		return err
+	}
+	n.run.cursor = cursor
+	return nil
+}
+
+func (n *fetchCursorNode) Next(params runParams) (bool, error) {
+	if err := params.p.cancelChecker.Check(); err != nil {
+		return false, err
+	}
+	if !n.n.All && n.n.Count == 0 {
+		// FETCH 0 returns the current row without moving the cursor.
+		if n.run.fetched > 0 {
+			return false, nil
+		}
+		n.run.fetched++
+		var err error
+		n.run.row, err = n.run.cursor.current(params.ctx)
+		return n.run.row != nil, err
+	}
+	if !n.n.All && n.run.fetched >= n.n.Count {
+		return false, nil
+	}
+	var err error
+	n.run.row, err = n.run.cursor.next(params.ctx)
+	if err != nil || n.run.row == nil {
+		return false, err
+	}
+	n.run.fetched++
+	return true, nil
+}
+
+func (n *fetchCursorNode) Values() tree.Datums { return n.run.row }
+
+func (n *fetchCursorNode) Close(context.Context) {}
+
+// MoveCursor implements the MOVE statement.
+// See https://www.postgresql.org/docs/current/sql-move.html for details.
+func (p *planner) MoveCursor(ctx context.Context, s *tree.MoveCursor) (planNode, error) {
+	if !s.All && s.Count < 0 {
+		return nil, errBackwardScan
+	}
+	return &moveCursorNode{n: s.CursorStmt}, nil
+}
+
+// moveCursorNode advances a cursor without returning any rows. Like in
+// Postgres, the number of rows skipped is reported as the number of rows
+// affected.
+type moveCursorNode struct {
+	n tree.CursorStmt
+
+	run struct {
+		moved int
+	}
+}
+
+// FastPathResults implements the planNodeFastPath interface.
+func (n *moveCursorNode) FastPathResults() (int, bool) {
+	return n.run.moved, true
+}
+
+func (n *moveCursorNode) startExec(params runParams) error {
+	cursor, err := params.p.sqlCursors.getCursor(n.n.Name)
+	if err != nil {
+		return err
+	}
+	if !n.n.All && n.n.Count == 0 {
+		// MOVE 0 does not move the cursor, but reports whether it is positioned
+		// on a row.
+		row, err := cursor.current(params.ctx)
+		if row != nil {
+			n.run.moved = 1
+		}
+		return err
+	}
+	for n.n.All || int64(n.run.moved) < n.n.Count {
+		if err := params.p.cancelChecker.Check(); err != nil {
+			return err
+		}
+		row, err := cursor.next(params.ctx)
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			break
+		}
+		n.run.moved++
+	}
+	return nil
+}
+
+func (n *moveCursorNode) Next(runParams) (bool, error) { return false, nil }
+func (n *moveCursorNode) Values() tree.Datums          { return nil }
+func (n *moveCursorNode) Close(context.Context)        {}
+
+// CloseCursor implements the CLOSE statement.
+// See https://www.postgresql.org/docs/current/sql-close.html for details.
+func (p *planner) CloseCursor(ctx context.Context, s *tree.CloseCursor) (planNode, error) {
+	return &closeCursorNode{n: s}, nil
+}
+
+// closeCursorNode closes one or all cursors.
+type closeCursorNode struct {
+	n *tree.CloseCursor
+}
+
+func (n *closeCursorNode) startExec(params runParams) error {
+	if n.n.All {
+		params.p.sqlCursors.closeAll(params.ctx)
+		return nil
+	}
+	return params.p.sqlCursors.closeCursor(params.ctx, n.n.Name)
+}
+
+func (n *closeCursorNode) Next(runParams) (bool, error) { return false, nil }
+func (n *closeCursorNode) Values() tree.Datums          { return nil }
+func (n *closeCursorNode) Close(context.Context)        {}
@@ -149,6 +149,8 @@ func MakeObjectAlreadyExistsError(collidingObject *Descriptor, name string) erro
 	case *Descriptor_Schema:
 		// TODO(ajwerner): Add a case for an existing schema object.
 		return errors.AssertionFailedf("schema exists with name %v", name)
+	case *Descriptor_Function:
+		return NewFunctionAlreadyExistsError(name)
 	default:
 		return errors.AssertionFailedf("unknown type %T exists with name %v", collidingObject.Union, name)
 	}
@@ -164,6 +166,11 @@ func NewTypeAlreadyExistsError(name string) error {
 	return pgerror.Newf(pgcode.DuplicateObject, "type %q already exists", name)
 }
 
+// NewFunctionAlreadyExistsError creates an error for a preexisting function.
+func NewFunctionAlreadyExistsError(name string) error {
+	return pgerror.Newf(pgcode.DuplicateFunction, "function %q already exists", name)
+}
+
 // IsRelationAlreadyExistsError checks whether this is an error for a preexisting relation.
 func IsRelationAlreadyExistsError(err error) bool {
 	return errHasCode(err, pgcode.DuplicateRelation)
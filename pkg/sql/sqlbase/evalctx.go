@@ -110,6 +110,30 @@ func (ep *DummyEvalPlanner) EvalSubquery(expr *tree.Subquery) (tree.Datum, error
 	return nil, errors.WithStack(errEvalPlanner)
 }
 
+// SendNotification is part of the tree.EvalPlanner interface.
+func (ep *DummyEvalPlanner) SendNotification(ctx context.Context, channel, payload string) error {
+	return errors.WithStack(errEvalPlanner)
+}
+
+// AcquireAdvisoryLock is part of the tree.EvalPlanner interface.
+func (ep *DummyEvalPlanner) AcquireAdvisoryLock(
+	ctx context.Context, key tree.AdvisoryLockKey, xact, try bool,
+) (bool, error) {
+	return false, errors.WithStack(errEvalPlanner)
+}
+
+// ReleaseAdvisoryLock is part of the tree.EvalPlanner interface.
+func (ep *DummyEvalPlanner) ReleaseAdvisoryLock(
+	ctx context.Context, key tree.AdvisoryLockKey,
+) (bool, error) {
+	return false, errors.WithStack(errEvalPlanner)
+}
+
+// ReleaseAllAdvisoryLocks is part of the tree.EvalPlanner interface.
+func (ep *DummyEvalPlanner) ReleaseAllAdvisoryLocks(ctx context.Context) error {
+	return errors.WithStack(errEvalPlanner)
+}
+
 // DummyPrivilegedAccessor implements the tree.PrivilegedAccessor interface by returning errors.
 type DummyPrivilegedAccessor struct{}
 
@@ -0,0 +1,297 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sqlbase
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/errors"
+)
+
+// FunctionDescriptorInterface will eventually be called functiondesc.Descriptor.
+// It is implemented by ImmutableFunctionDescriptor.
+type FunctionDescriptorInterface interface {
+	BaseDescriptorInterface
+	FunctionDesc() *FunctionDescriptor
+}
+
+var _ FunctionDescriptorInterface = (*ImmutableFunctionDescriptor)(nil)
+var _ FunctionDescriptorInterface = (*MutableFunctionDescriptor)(nil)
+
+// ImmutableFunctionDescriptor wraps a Function descriptor and provides methods
+// on it.
+type ImmutableFunctionDescriptor struct {
+	FunctionDescriptor
+}
+
+// MutableFunctionDescriptor is a mutable reference to a FunctionDescriptor.
+type MutableFunctionDescriptor struct {
+	ImmutableFunctionDescriptor
+
+	ClusterVersion *ImmutableFunctionDescriptor
+}
+
+// NewMutableExistingFunctionDescriptor returns a MutableFunctionDescriptor from
+// the given function descriptor with the cluster version also set to the
+// descriptor. This is for functions that already exist.
+func NewMutableExistingFunctionDescriptor(desc FunctionDescriptor) *MutableFunctionDescriptor {
+	return &MutableFunctionDescriptor{
+		ImmutableFunctionDescriptor: makeImmutableFunctionDescriptor(*protoutil.Clone(&desc).(*FunctionDescriptor)),
+		ClusterVersion:              NewImmutableFunctionDescriptor(desc),
+	}
+}
+
+// NewImmutableFunctionDescriptor makes a new Function descriptor.
+func NewImmutableFunctionDescriptor(desc FunctionDescriptor) *ImmutableFunctionDescriptor {
+	m := makeImmutableFunctionDescriptor(desc)
+	return &m
+}
+
+func makeImmutableFunctionDescriptor(desc FunctionDescriptor) ImmutableFunctionDescriptor {
+	return ImmutableFunctionDescriptor{FunctionDescriptor: desc}
+}
+
+// NewMutableCreatedFunctionDescriptor returns a MutableFunctionDescriptor from
+// the given FunctionDescriptor with the cluster version being the zero
+// function. This is for a function that is created within the current
+// transaction.
+func NewMutableCreatedFunctionDescriptor(desc FunctionDescriptor) *MutableFunctionDescriptor {
+	return &MutableFunctionDescriptor{
+		ImmutableFunctionDescriptor: makeImmutableFunctionDescriptor(desc),
+	}
+}
+
+// SetDrainingNames implements the MutableDescriptor interface.
+func (desc *MutableFunctionDescriptor) SetDrainingNames(names []NameInfo) {
+	desc.DrainingNames = names
+}
+
+// GetAuditMode implements the DescriptorProto interface.
+func (desc *ImmutableFunctionDescriptor) GetAuditMode() TableDescriptor_AuditMode {
+	return TableDescriptor_DISABLED
+}
+
+// TypeName implements the DescriptorProto interface.
+func (desc *ImmutableFunctionDescriptor) TypeName() string {
+	return "function"
+}
+
+// DatabaseDesc implements the ObjectDescriptor interface.
+func (desc *ImmutableFunctionDescriptor) DatabaseDesc() *DatabaseDescriptor {
+	return nil
+}
+
+// SchemaDesc implements the ObjectDescriptor interface.
+func (desc *ImmutableFunctionDescriptor) SchemaDesc() *SchemaDescriptor {
+	return nil
+}
+
+// TableDesc implements the ObjectDescriptor interface.
+func (desc *ImmutableFunctionDescriptor) TableDesc() *TableDescriptor {
+	return nil
+}
+
+// TypeDesc implements the ObjectDescriptor interface.
+func (desc *ImmutableFunctionDescriptor) TypeDesc() *TypeDescriptor {
+	return nil
+}
+
+// FunctionDesc implements the FunctionDescriptorInterface interface.
+func (desc *ImmutableFunctionDescriptor) FunctionDesc() *FunctionDescriptor {
+	return &desc.FunctionDescriptor
+}
+
+// Adding implements the BaseDescriptorInterface interface.
+func (desc *ImmutableFunctionDescriptor) Adding() bool {
+	return false
+}
+
+// Dropped implements the BaseDescriptorInterface interface.
+func (desc *FunctionDescriptor) Dropped() bool {
+	return desc.State == FunctionDescriptor_DROP
+}
+
+// Offline implements the BaseDescriptorInterface interface.
+func (desc *ImmutableFunctionDescriptor) Offline() bool {
+	return false
+}
+
+// GetOfflineReason implements the BaseDescriptorInterface interface.
+func (desc *ImmutableFunctionDescriptor) GetOfflineReason() string {
+	return ""
+}
+
+// DescriptorProto wraps a FunctionDescriptor in a Descriptor.
+func (desc *ImmutableFunctionDescriptor) DescriptorProto() *Descriptor {
+	return &Descriptor{
+		Union: &Descriptor_Function{
+			Function: &desc.FunctionDescriptor,
+		},
+	}
+}
+
+// NameResolutionResult implements the ObjectDescriptor interface.
+func (desc *ImmutableFunctionDescriptor) NameResolutionResult() {}
+
+// ParamTypes returns the types of the parameters of the function.
+func (desc *ImmutableFunctionDescriptor) ParamTypes() tree.ArgTypes {
+	args := make(tree.ArgTypes, len(desc.Params))
+	for i := range desc.Params {
+		args[i] = tree.ArgType{Name: desc.Params[i].Name, Typ: desc.Params[i].Type}
+	}
+	return args
+}
+
+// TreeVolatility returns the tree.Volatility corresponding to the volatility
+// marker of the function.
+func (desc *ImmutableFunctionDescriptor) TreeVolatility() tree.Volatility {
+	switch desc.Volatility {
+	case FunctionDescriptor_IMMUTABLE:
+		return tree.VolatilityImmutable
+	case FunctionDescriptor_STABLE:
+		return tree.VolatilityStable
+	default:
+		return tree.VolatilityVolatile
+	}
+}
+
+// FunctionVolatilityFromTree returns the volatility marker of a function given
+// its tree.Volatility.
+func FunctionVolatilityFromTree(v tree.Volatility) (FunctionDescriptor_Volatility, error) {
+	switch v {
+	case tree.VolatilityImmutable:
+		return FunctionDescriptor_IMMUTABLE, nil
+	case tree.VolatilityStable:
+		return FunctionDescriptor_STABLE, nil
+	case tree.VolatilityVolatile:
+		return FunctionDescriptor_VOLATILE, nil
+	default:
+		return 0, errors.AssertionFailedf("unsupported function volatility %s", v)
+	}
+}
+
+// MakeFunctionQuery returns the query that evaluates the body of a
+// user-defined function with the given parameters. The arguments are supplied
+// as placeholders, which the body can reference either directly or through
+// the names of the parameters:
+//
+//   SELECT udf_body.* FROM (SELECT $1 AS a, ...) AS udf_params,
+//     LATERAL (<body>) AS udf_body
+//
+// Like in Postgres, the columns of the tables referenced by the body take
+// precedence over parameters with the same name.
+func MakeFunctionQuery(params []FunctionDescriptor_Param, body *tree.Select) *tree.Select {
+	var paramExprs tree.SelectExprs
+	for i := range params {
+		if params[i].Name == "" {
+			continue
+		}
+		paramExprs = append(paramExprs, tree.SelectExpr{
+			Expr: &tree.Placeholder{Idx: tree.PlaceholderIdx(i)},
+			As:   tree.UnrestrictedName(params[i].Name),
+		})
+	}
+	if len(paramExprs) == 0 {
+		return body
+	}
+	return &tree.Select{
+		Select: &tree.SelectClause{
+			Exprs: tree.SelectExprs{{
+				Expr: &tree.UnresolvedName{NumParts: 2, Star: true, Parts: tree.NameParts{"", "udf_body"}},
+			}},
+			From: tree.From{
+				Tables: tree.TableExprs{
+					&tree.AliasedTableExpr{
+						Expr: &tree.Subquery{Select: &tree.ParenSelect{Select: &tree.Select{
+							Select: &tree.SelectClause{Exprs: paramExprs},
+						}}},
+						As: tree.AliasClause{Alias: "udf_params"},
+					},
+					&tree.AliasedTableExpr{
+						Expr:    &tree.Subquery{Select: &tree.ParenSelect{Select: body}},
+						Lateral: true,
+						As:      tree.AliasClause{Alias: "udf_body"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Validate performs some basic validation of the function descriptor.
+func (desc *ImmutableFunctionDescriptor) Validate() error {
+	if err := validateName(desc.Name, "function"); err != nil {
+		return err
+	}
+	if desc.ID == InvalidID {
+		return errors.AssertionFailedf("invalid function ID %d", errors.Safe(desc.ID))
+	}
+	if desc.ParentID == InvalidID {
+		return errors.AssertionFailedf("invalid parentID %d", errors.Safe(desc.ParentID))
+	}
+	if desc.ReturnType == nil {
+		return errors.AssertionFailedf("function %q has no return type", desc.Name)
+	}
+	for i := range desc.Params {
+		if desc.Params[i].Type == nil {
+			return errors.AssertionFailedf("parameter %d of function %q has no type", i+1, desc.Name)
+		}
+	}
+	if desc.Privileges == nil {
+		return errors.AssertionFailedf("function %q has no privileges", desc.Name)
+	}
+	return desc.Privileges.Validate(desc.ID)
+}
+
+// MaybeIncrementVersion implements the MutableDescriptor interface.
+func (desc *MutableFunctionDescriptor) MaybeIncrementVersion() {
+	// Already incremented, no-op.
+	if desc.ClusterVersion == nil || desc.Version == desc.ClusterVersion.Version+1 {
+		return
+	}
+	desc.Version++
+	desc.ModificationTime = hlc.Timestamp{}
+}
+
+// OriginalName implements the MutableDescriptor interface.
+func (desc *MutableFunctionDescriptor) OriginalName() string {
+	if desc.ClusterVersion == nil {
+		return ""
+	}
+	return desc.ClusterVersion.Name
+}
+
+// OriginalID implements the MutableDescriptor interface.
+func (desc *MutableFunctionDescriptor) OriginalID() ID {
+	if desc.ClusterVersion == nil {
+		return InvalidID
+	}
+	return desc.ClusterVersion.ID
+}
+
+// OriginalVersion implements the MutableDescriptor interface.
+func (desc *MutableFunctionDescriptor) OriginalVersion() DescriptorVersion {
+	if desc.ClusterVersion == nil {
+		return 0
+	}
+	return desc.ClusterVersion.Version
+}
+
+// Immutable implements the MutableDescriptor interface.
+func (desc *MutableFunctionDescriptor) Immutable() DescriptorInterface {
+	return NewImmutableFunctionDescriptor(*protoutil.Clone(desc.FunctionDesc()).(*FunctionDescriptor))
+}
+
+// IsNew implements the MutableDescriptor interface.
+func (desc *MutableFunctionDescriptor) IsNew() bool {
+	return desc.ClusterVersion == nil
+}
@@ -51,6 +51,8 @@ func wrapDescriptor(descriptor protoutil.Message) *Descriptor {
 		desc.Union = &Descriptor_Type{Type: t}
 	case *SchemaDescriptor:
 		desc.Union = &Descriptor_Schema{Schema: t}
+	case *FunctionDescriptor:
+		desc.Union = &Descriptor_Function{Function: t}
 	default:
 		panic(errors.AssertionFailedf("unknown descriptor type: %T", descriptor))
 	}
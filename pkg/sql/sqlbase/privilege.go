@@ -137,6 +137,31 @@ func (p *PrivilegeDescriptor) Grant(user string, privList privilege.List) {
 
 // Revoke removes privileges from this descriptor for a given list of users.
 func (p *PrivilegeDescriptor) Revoke(user string, privList privilege.List) {
+	p.revoke(user, privList, func(k privilege.Kind) bool {
+		// EXECUTE only applies to functions.
+		return k != privilege.EXECUTE
+	})
+}
+
+// RevokeFunction removes privileges from this function descriptor for a given
+// list of users.
+func (p *PrivilegeDescriptor) RevokeFunction(user string, privList privilege.List) {
+	p.revoke(user, privList, func(k privilege.Kind) bool {
+		for _, f := range privilege.FunctionPrivileges {
+			if k == f {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// revoke removes privileges from this descriptor for a given list of users.
+// If the user holds the ALL privilege, it is first replaced by the privileges
+// for which applies returns true.
+func (p *PrivilegeDescriptor) revoke(
+	user string, privList privilege.List, applies func(privilege.Kind) bool,
+) {
 	userPriv, ok := p.findUser(user)
 	if !ok || userPriv.Privileges == 0 {
 		// Removing privileges from a user without privileges is a no-op.
@@ -157,7 +182,7 @@ func (p *PrivilegeDescriptor) Revoke(user string, privList privilege.List) {
 		// all other privileges one.
 		userPriv.Privileges = 0
 		for _, v := range privilege.ByValue {
-			if v != privilege.ALL {
+			if v != privilege.ALL && applies(v) {
 				userPriv.Privileges |= v.Mask()
 			}
 		}
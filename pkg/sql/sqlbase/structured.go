@@ -3866,6 +3866,8 @@ func (desc *Descriptor) GetID() ID {
 		return t.Type.ID
 	case *Descriptor_Schema:
 		return t.Schema.ID
+	case *Descriptor_Function:
+		return t.Function.ID
 	default:
 		panic(errors.AssertionFailedf("GetID: unknown Descriptor type %T", t))
 	}
@@ -3882,6 +3884,8 @@ func (desc *Descriptor) GetName() string {
 		return t.Type.Name
 	case *Descriptor_Schema:
 		return t.Schema.Name
+	case *Descriptor_Function:
+		return t.Function.Name
 	default:
 		panic(errors.AssertionFailedf("GetName: unknown Descriptor type %T", t))
 	}
@@ -3898,6 +3902,8 @@ func (desc *Descriptor) GetVersion() DescriptorVersion {
 		return t.Type.Version
 	case *Descriptor_Schema:
 		return t.Schema.Version
+	case *Descriptor_Function:
+		return t.Function.Version
 	default:
 		panic(errors.AssertionFailedf("GetVersion: unknown Descriptor type %T", t))
 	}
@@ -3914,6 +3920,8 @@ func (desc *Descriptor) GetModificationTime() hlc.Timestamp {
 		return t.Type.ModificationTime
 	case *Descriptor_Schema:
 		return t.Schema.ModificationTime
+	case *Descriptor_Function:
+		return t.Function.ModificationTime
 	default:
 		debug.PrintStack()
 		panic(errors.AssertionFailedf("GetModificationTime: unknown Descriptor type %T", t))
@@ -3930,6 +3938,8 @@ func (desc *Descriptor) Dropped() bool {
 		return t.Table.Dropped()
 	case *Descriptor_Type:
 		return t.Type.Dropped()
+	case *Descriptor_Function:
+		return t.Function.Dropped()
 	case *Descriptor_Database, *Descriptor_Schema:
 		return false
 	default:
@@ -3946,7 +3956,7 @@ func (desc *Descriptor) Offline() bool {
 	switch t := desc.Union.(type) {
 	case *Descriptor_Table:
 		return t.Table.Offline()
-	case *Descriptor_Database, *Descriptor_Type, *Descriptor_Schema:
+	case *Descriptor_Database, *Descriptor_Type, *Descriptor_Schema, *Descriptor_Function:
 		return false
 	default:
 		debug.PrintStack()
@@ -3965,6 +3975,8 @@ func (desc *Descriptor) setModificationTime(ts hlc.Timestamp) {
 		t.Type.ModificationTime = ts
 	case *Descriptor_Schema:
 		t.Schema.ModificationTime = ts
+	case *Descriptor_Function:
+		t.Function.ModificationTime = ts
 	default:
 		panic(errors.AssertionFailedf("setModificationTime: unknown Descriptor type %T", t))
 	}
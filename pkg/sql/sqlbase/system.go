@@ -43,6 +43,8 @@ func ShouldSplitAtDesc(rawDesc *roachpb.Value) bool {
 		return false
 	case *Descriptor_Schema:
 		return false
+	case *Descriptor_Function:
+		return false
 	default:
 		panic(errors.AssertionFailedf("unexpected descriptor type %#v", &desc))
 	}
@@ -331,6 +333,21 @@ CREATE TABLE system.scheduled_jobs (
        schedule_details, executor_type, execution_args, schedule_changes 
     )
 )`
+
+	// NotificationsTableSchema is the schema of the table through which the
+	// notifications published by NOTIFY are delivered to the listening
+	// sessions of every node.
+	NotificationsTableSchema = `
+CREATE TABLE system.notifications (
+    id      INT8 NOT NULL DEFAULT unique_rowid(),
+    channel STRING NOT NULL,
+    payload STRING NOT NULL,
+    pid     INT8 NOT NULL,
+    created TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT "primary" PRIMARY KEY (id),
+    FAMILY "primary" (id, channel, payload, pid, created)
+)`
 )
 
 func pk(name string) IndexDescriptor {
@@ -386,6 +403,7 @@ var SystemAllowedPrivileges = map[ID]privilege.List{
 	keys.StatementDiagnosticsRequestsTableID:  privilege.ReadWriteData,
 	keys.StatementDiagnosticsTableID:          privilege.ReadWriteData,
 	keys.ScheduledJobsTableID:                 privilege.ReadWriteData,
+	keys.NotificationsTableID:                 privilege.ReadWriteData,
 }
 
 // Helpers used to make some of the TableDescriptor literals below more concise.
@@ -1644,6 +1662,37 @@ var (
 		FormatVersion:  InterleavedFormatVersion,
 		NextMutationID: 1,
 	})
+
+	// NotificationsTable is the descriptor for the notifications table.
+	NotificationsTable = NewImmutableTableDescriptor(TableDescriptor{
+		Name:                    "notifications",
+		ID:                      keys.NotificationsTableID,
+		ParentID:                keys.SystemDatabaseID,
+		UnexposedParentSchemaID: keys.PublicSchemaID,
+		Version:                 1,
+		Columns: []ColumnDescriptor{
+			{Name: "id", ID: 1, Type: types.Int, DefaultExpr: &uniqueRowIDString, Nullable: false},
+			{Name: "channel", ID: 2, Type: types.String, Nullable: false},
+			{Name: "payload", ID: 3, Type: types.String, Nullable: false},
+			{Name: "pid", ID: 4, Type: types.Int, Nullable: false},
+			{Name: "created", ID: 5, Type: types.TimestampTZ, DefaultExpr: &nowTZString, Nullable: false},
+		},
+		NextColumnID: 6,
+		Families: []ColumnFamilyDescriptor{
+			{
+				Name:        "primary",
+				ID:          0,
+				ColumnNames: []string{"id", "channel", "payload", "pid", "created"},
+				ColumnIDs:   []ColumnID{1, 2, 3, 4, 5},
+			},
+		},
+		NextFamilyID:   1,
+		PrimaryIndex:   pk("id"),
+		NextIndexID:    2,
+		Privileges:     NewCustomSuperuserPrivilegeDescriptor(SystemAllowedPrivileges[keys.NotificationsTableID]),
+		FormatVersion:  InterleavedFormatVersion,
+		NextMutationID: 1,
+	})
 )
 
 // addSystemDescriptorsToSchema populates the supplied MetadataSchema
@@ -1706,6 +1755,7 @@ func addSystemDescriptorsToSchema(target *MetadataSchema) {
 	// Tables introduced in 20.2.
 
 	target.AddDescriptor(keys.SystemDatabaseID, ScheduledJobsTable)
+	target.AddDescriptor(keys.SystemDatabaseID, NotificationsTable)
 }
 
 // addSplitIDs adds a split point for each of the PseudoTableIDs to the supplied
@@ -415,7 +415,7 @@ func (desc *TableDescriptor) collectConstraintInfo(
 			detail.Columns = index.ColumnNames
 			detail.Index = index
 			info[index.Name] = detail
-		} else if index.Unique {
+		} else if index.Unique || index.DeferrableUnique {
 			if _, ok := info[index.Name]; ok {
 				return nil, pgerror.Newf(pgcode.DuplicateObject,
 					"duplicate constraint name: %q", index.Name)
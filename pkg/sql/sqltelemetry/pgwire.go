@@ -19,7 +19,15 @@ import (
 
 // CancelRequestCounter is to be incremented every time a pgwire-level
 // cancel request is received from a client.
-var CancelRequestCounter = telemetry.GetCounterOnce("pgwire.unimplemented.cancel_request")
+var CancelRequestCounter = telemetry.GetCounterOnce("pgwire.cancel_request")
+
+// CancelRequestSuccessCounter is to be incremented every time a pgwire-level
+// cancel request results in the cancellation of a query.
+var CancelRequestSuccessCounter = telemetry.GetCounterOnce("pgwire.cancel_request.success")
+
+// CancelRequestThrottledCounter is to be incremented every time a pgwire-level
+// cancel request is dropped because too many are being served.
+var CancelRequestThrottledCounter = telemetry.GetCounterOnce("pgwire.cancel_request.throttled")
 
 // UnimplementedClientStatusParameterCounter is to be incremented
 // every time a client attempts to configure a status parameter
@@ -19,6 +19,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
 )
 
 // expressionCarrier handles visiting sub-expressions.
@@ -113,6 +114,10 @@ type tableWriterBase struct {
 	// rows contains the accumulated result rows if rowsNeeded is set on the
 	// corresponding tableWriter.
 	rows *rowcontainer.RowContainer
+	// uniqueChecks contains the checks of the DEFERRABLE UNIQUE constraints of
+	// the table. The keys written to their indexes are recorded in deferred.
+	uniqueChecks []*deferrableUniqueCheck
+	deferred     *deferredConstraints
 }
 
 func (tb *tableWriterBase) init(txn *kv.Txn, tableDesc *sqlbase.ImmutableTableDescriptor) {
@@ -123,6 +128,58 @@ func (tb *tableWriterBase) init(txn *kv.Txn, tableDesc *sqlbase.ImmutableTableDe
 
 // flushAndStartNewBatch shares the common flushAndStartNewBatch() code between
 // tableWriters.
+// initDeferrableUniqueChecks prepares the tableWriter to record the keys
+// written to the indexes of the DEFERRABLE UNIQUE constraints of the table in
+// d. It must be called after init.
+func (tb *tableWriterBase) initDeferrableUniqueChecks(d *deferredConstraints) error {
+	tb.uniqueChecks = makeDeferrableUniqueChecks(tb.desc)
+	if len(tb.uniqueChecks) > 0 && d == nil {
+		return errors.AssertionFailedf(
+			"table %q with DEFERRABLE UNIQUE constraints modified outside of a session", tb.desc.Name)
+	}
+	tb.deferred = d
+	return nil
+}
+
+// recordUniqueKeys records the keys written by a row to the indexes of the
+// DEFERRABLE UNIQUE constraints of the table. colIDtoRowIndex maps the column
+// IDs to the ordinals of their values in the row. If updatedCols is not nil,
+// only the keys containing one of the updated columns are recorded. Keys
+// containing NULLs cannot be duplicated, so they are not recorded.
+func (tb *tableWriterBase) recordUniqueKeys(
+	values tree.Datums, colIDtoRowIndex, updatedCols map[sqlbase.ColumnID]int,
+) {
+	for _, c := range tb.uniqueChecks {
+		if updatedCols != nil {
+			updated := false
+			for _, id := range c.columnIDs {
+				if _, ok := updatedCols[id]; ok {
+					updated = true
+					break
+				}
+			}
+			if !updated {
+				continue
+			}
+		}
+		keyVals := make(tree.Datums, len(c.columnIDs))
+		for i, id := range c.columnIDs {
+			if ord, ok := colIDtoRowIndex[id]; ok {
+				keyVals[i] = values[ord]
+			} else {
+				keyVals[i] = tree.DNull
+			}
+			if keyVals[i] == tree.DNull {
+				keyVals = nil
+				break
+			}
+		}
+		if keyVals != nil {
+			tb.deferred.addUniqueKey(c, keyVals)
+		}
+	}
+}
+
 func (tb *tableWriterBase) flushAndStartNewBatch(ctx context.Context) error {
 	if err := tb.txn.Run(ctx, tb.b); err != nil {
 		return row.ConvertBatchError(ctx, tb.desc, tb.b)
@@ -135,7 +192,9 @@ func (tb *tableWriterBase) flushAndStartNewBatch(ctx context.Context) error {
 
 // finalize shares the common finalize() code between tableWriters.
 func (tb *tableWriterBase) finalize(ctx context.Context) (err error) {
-	if tb.autoCommit == autoCommitEnabled {
+	// The keys written to the indexes of DEFERRABLE UNIQUE constraints are
+	// checked after the statement, so the transaction cannot be committed yet.
+	if tb.autoCommit == autoCommitEnabled && len(tb.uniqueChecks) == 0 {
 		log.Event(ctx, "autocommit enabled")
 		// An auto-txn can commit the transaction with the batch. This is an
 		// optimization to avoid an extra round-trip to the transaction
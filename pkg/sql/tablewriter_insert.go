@@ -41,7 +41,11 @@ func (ti *tableInserter) row(
 	ctx context.Context, values tree.Datums, pm row.PartialIndexUpdateHelper, traceKV bool,
 ) error {
 	ti.currentBatchSize++
-	return ti.ri.InsertRow(ctx, ti.b, values, pm, false /* overwrite */, traceKV)
+	if err := ti.ri.InsertRow(ctx, ti.b, values, pm, false /* overwrite */, traceKV); err != nil {
+		return err
+	}
+	ti.recordUniqueKeys(values, ti.ri.InsertColIDtoRowIndex, nil /* updatedCols */)
+	return nil
 }
 
 // tableDesc is part of the tableWriter interface.
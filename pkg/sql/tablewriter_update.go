@@ -54,7 +54,12 @@ func (tu *tableUpdater) rowForUpdate(
 	traceKV bool,
 ) (tree.Datums, error) {
 	tu.currentBatchSize++
-	return tu.ru.UpdateRow(ctx, tu.b, oldValues, updateValues, pm, traceKV)
+	newValues, err := tu.ru.UpdateRow(ctx, tu.b, oldValues, updateValues, pm, traceKV)
+	if err != nil {
+		return nil, err
+	}
+	tu.recordUniqueKeys(newValues, tu.ru.FetchColIDtoRowIndex, tu.ru.UpdateColIDtoRowIndex)
+	return newValues, nil
 }
 
 // tableDesc is part of the tableWriter interface.
@@ -246,6 +246,7 @@ func (tu *optTableUpserter) insertNonConflictingRow(
 	if err := tu.ri.InsertRow(ctx, b, insertRow, pm, overwrite, traceKV); err != nil {
 		return err
 	}
+	tu.recordUniqueKeys(insertRow, tu.ri.InsertColIDtoRowIndex, nil /* updatedCols */)
 
 	if !tu.collectRows {
 		return nil
@@ -306,10 +307,11 @@ func (tu *optTableUpserter) updateConflictingRow(
 	// Queue the update in KV. This also returns an "update row"
 	// containing the updated values for every column in the
 	// table. This is useful for RETURNING, which we collect below.
-	_, err := tu.ru.UpdateRow(ctx, b, fetchRow, updateValues, pm, traceKV)
+	newValues, err := tu.ru.UpdateRow(ctx, b, fetchRow, updateValues, pm, traceKV)
 	if err != nil {
 		return err
 	}
+	tu.recordUniqueKeys(newValues, tu.ru.FetchColIDtoRowIndex, tu.ru.UpdateColIDtoRowIndex)
 
 	// We only need a result row if we're collecting rows.
 	if !tu.collectRows {
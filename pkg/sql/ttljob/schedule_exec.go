@@ -0,0 +1,98 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package ttljob
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/scheduledjobs"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	pbtypes "github.com/gogo/protobuf/types"
+)
+
+// rowLevelTTLExecutor is the scheduled job executor which creates the
+// row-level TTL job of a table.
+type rowLevelTTLExecutor struct{}
+
+var _ jobs.ScheduledJobExecutor = &rowLevelTTLExecutor{}
+
+// ExecuteJob implements the jobs.ScheduledJobExecutor interface.
+func (e *rowLevelTTLExecutor) ExecuteJob(
+	ctx context.Context,
+	cfg *scheduledjobs.JobExecutionConfig,
+	env scheduledjobs.JobSchedulerEnv,
+	sj *jobs.ScheduledJob,
+	txn *kv.Txn,
+) error {
+	if !jobEnabled.Get(&cfg.Settings.SV) {
+		log.Infof(ctx, "row-level TTL jobs are disabled, skipping schedule %d", sj.ScheduleID())
+		return nil
+	}
+	args := &jobspb.ScheduledRowLevelTTLArgs{}
+	if err := pbtypes.UnmarshalAny(sj.ExecutionArgs().Args, args); err != nil {
+		return errors.Wrap(err, "un-marshaling args")
+	}
+
+	hook, cleanup := cfg.PlanHookMaker("exec-row-level-ttl", txn, security.RootUser)
+	defer cleanup()
+	p := hook.(sql.PlanHookState)
+
+	desc, err := sqlbase.GetTableDescFromID(ctx, txn, p.ExecCfg().Codec, args.TableID)
+	if err != nil {
+		return err
+	}
+	record := jobs.Record{
+		Description:   fmt.Sprintf("row-level TTL for table %s", desc.Name),
+		Username:      security.RootUser,
+		DescriptorIDs: sqlbase.IDs{args.TableID},
+		Details: jobspb.RowLevelTTLDetails{
+			TableID: args.TableID,
+		},
+		Progress: jobspb.RowLevelTTLProgress{},
+		CreatedBy: &jobs.CreatedByInfo{
+			Name: jobs.CreatedByScheduledJobs,
+			ID:   sj.ScheduleID(),
+		},
+	}
+	_, err = p.ExecCfg().JobRegistry.CreateAdoptableJobWithTxn(ctx, record, txn)
+	return err
+}
+
+// NotifyJobTermination implements the jobs.ScheduledJobExecutor interface.
+func (e *rowLevelTTLExecutor) NotifyJobTermination(
+	ctx context.Context,
+	cfg *scheduledjobs.JobExecutionConfig,
+	env scheduledjobs.JobSchedulerEnv,
+	md *jobs.JobMetadata,
+	sj *jobs.ScheduledJob,
+	txn *kv.Txn,
+) error {
+	if md.Status == jobs.StatusFailed {
+		jobs.DefaultHandleFailedRun(sj, md.ID, errors.Newf("%s", md.Payload.Error))
+	}
+	return nil
+}
+
+func init() {
+	jobs.RegisterScheduledJobExecutorFactory(
+		sql.RowLevelTTLScheduleExecutorName,
+		func() (jobs.ScheduledJobExecutor, error) {
+			return &rowLevelTTLExecutor{}, nil
+		})
+}
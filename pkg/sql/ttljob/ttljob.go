@@ -0,0 +1,430 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Package ttljob implements the row-level TTL job, which periodically deletes
+// the expired rows of the tables created with the ttl_expire_after storage
+// parameter.
+package ttljob
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/limit"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"golang.org/x/time/rate"
+)
+
+var (
+	defaultSelectBatchSize = settings.RegisterPositiveIntSetting(
+		"sql.ttl.default_select_batch_size",
+		"default number of expired rows read at a time by a row-level TTL job",
+		500,
+	)
+	defaultDeleteBatchSize = settings.RegisterPositiveIntSetting(
+		"sql.ttl.default_delete_batch_size",
+		"default number of expired rows deleted in a single transaction by a row-level TTL job",
+		100,
+	)
+	defaultDeleteRateLimit = settings.RegisterNonNegativeIntSetting(
+		"sql.ttl.default_delete_rate_limit",
+		"default maximum number of rows deleted per second by a row-level TTL job; 0 means unlimited",
+		0,
+	)
+	jobEnabled = settings.RegisterBoolSetting(
+		"sql.ttl.job.enabled",
+		"if false, the schedules of the row-level TTL jobs do not start new jobs",
+		true,
+	)
+)
+
+type rowLevelTTLResumer struct {
+	job *jobs.Job
+	st  *cluster.Settings
+}
+
+var _ jobs.Resumer = &rowLevelTTLResumer{}
+
+// Resume is part of the jobs.Resumer interface.
+//
+// The primary index of the table is processed one range at a time. The
+// expired rows of a range are read in batches with a historical read at the
+// cutoff of the job, and deleted in smaller batches, each in its own
+// transaction. The progress of the job is persisted after each range, so
+// that a paused job resumes from the first range which was not completely
+// processed.
+//
+// The cutoff is the time at which the job starts or resumes, rather than the
+// time at which it was created: a job which was paused for longer than the GC
+// TTL of the table could not read the table at its creation time anymore.
+func (r *rowLevelTTLResumer) Resume(
+	ctx context.Context, phs interface{}, _ chan<- tree.Datums,
+) error {
+	p := phs.(sql.PlanHookState)
+	execCfg := p.ExecCfg()
+	details := r.job.Details().(jobspb.RowLevelTTLDetails)
+	var progress jobspb.RowLevelTTLProgress
+	if prog := r.job.Progress().GetRowLevelTTL(); prog != nil {
+		progress = *prog
+	}
+
+	var desc *sqlbase.TableDescriptor
+	if err := execCfg.DB.Txn(ctx, func(ctx context.Context, txn *kv.Txn) (err error) {
+		desc, err = sqlbase.GetTableDescFromID(ctx, txn, execCfg.Codec, details.TableID)
+		return err
+	}); err != nil {
+		if errors.Is(err, sqlbase.ErrDescriptorNotFound) {
+			return nil
+		}
+		return err
+	}
+	if desc.Dropped() || desc.RowLevelTTL == nil {
+		// The table was dropped since the job was created.
+		return nil
+	}
+
+	d, err := makeDeleter(execCfg, desc, execCfg.Clock.Now(), r.st)
+	if err != nil {
+		return err
+	}
+	if m, ok := execCfg.JobRegistry.MetricsStruct().RowLevelTTL.(*Metrics); ok {
+		d.metrics = m
+	}
+
+	spans, err := rangeSpans(ctx, execCfg, desc.PrimaryIndexSpan(execCfg.Codec), progress.ResumeKey)
+	if err != nil {
+		return err
+	}
+	for i, span := range spans {
+		var start, end tree.Datums
+		if d.useRangeBounds {
+			start = d.keyToPKPrefix(span.Key)
+			end = d.keyToPKPrefix(span.EndKey)
+		}
+		n, err := d.deleteExpiredRows(ctx, start, end)
+		progress.RowCount += n
+		if err != nil {
+			return err
+		}
+		progress.ResumeKey = span.EndKey
+		if err := r.job.FractionProgressed(ctx, func(
+			ctx context.Context, details jobspb.ProgressDetails,
+		) float32 {
+			*details.(*jobspb.Progress_RowLevelTTL).RowLevelTTL = progress
+			return float32(i+1) / float32(len(spans))
+		}); err != nil {
+			return err
+		}
+	}
+	log.Infof(ctx, "row-level TTL job deleted %d expired rows of table %d", progress.RowCount, desc.ID)
+	return nil
+}
+
+// OnFailOrCancel is part of the jobs.Resumer interface.
+func (r *rowLevelTTLResumer) OnFailOrCancel(context.Context, interface{}) error {
+	return nil
+}
+
+// rangeSpans returns the spans of the ranges overlapping the given span of
+// a table, clamped to it and starting at the resume key if there is one.
+func rangeSpans(
+	ctx context.Context, execCfg *sql.ExecutorConfig, span roachpb.Span, resumeKey roachpb.Key,
+) ([]roachpb.Span, error) {
+	if len(resumeKey) > 0 && resumeKey.Compare(span.Key) > 0 {
+		span.Key = resumeKey
+	}
+	if span.Key.Compare(span.EndKey) >= 0 {
+		return nil, nil
+	}
+	// Secondary tenants cannot read the range descriptors, so they process
+	// the whole span at once.
+	if !execCfg.Codec.ForSystemTenant() {
+		return []roachpb.Span{span}, nil
+	}
+	var kvs []kv.KeyValue
+	if err := execCfg.DB.Txn(ctx, func(ctx context.Context, txn *kv.Txn) (err error) {
+		kvs, err = sql.ScanMetaKVs(ctx, txn, span)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	spans := make([]roachpb.Span, 0, len(kvs))
+	for i := range kvs {
+		var rangeDesc roachpb.RangeDescriptor
+		if err := kvs[i].ValueProto(&rangeDesc); err != nil {
+			return nil, err
+		}
+		s := roachpb.Span{Key: rangeDesc.StartKey.AsRawKey(), EndKey: rangeDesc.EndKey.AsRawKey()}
+		if s.Key.Compare(span.Key) < 0 {
+			s.Key = span.Key
+		}
+		if s.EndKey.Compare(span.EndKey) > 0 {
+			s.EndKey = span.EndKey
+		}
+		spans = append(spans, s)
+	}
+	return spans, nil
+}
+
+// deleter deletes the expired rows of a table.
+type deleter struct {
+	ie      *sql.InternalExecutor
+	codec   keys.SQLCodec
+	desc    *sqlbase.TableDescriptor
+	metrics *Metrics
+	limiter *limit.LimiterBurstDisabled
+
+	pkColNames []string
+	pkTypes    []*types.T
+	// useRangeBounds is false if the boundaries of the ranges cannot be
+	// converted to bounds on the primary key, in which case the whole table is
+	// processed as a single span.
+	useRangeBounds bool
+	alloc          sqlbase.DatumAlloc
+
+	// asOf is the AS OF SYSTEM TIME clause of the queries selecting the
+	// expired rows, and cutoff the expiration time below which rows are
+	// deleted. Both correspond to the time at which the job started or
+	// resumed.
+	asOf            string
+	cutoff          tree.Datum
+	selectBatchSize int64
+	deleteBatchSize int64
+}
+
+func makeDeleter(
+	execCfg *sql.ExecutorConfig,
+	desc *sqlbase.TableDescriptor,
+	cutoff hlc.Timestamp,
+	st *cluster.Settings,
+) (*deleter, error) {
+	cutoffDatum, err := tree.MakeDTimestampTZ(cutoff.GoTime(), time.Microsecond)
+	if err != nil {
+		return nil, err
+	}
+	d := &deleter{
+		ie:              execCfg.InternalExecutor,
+		codec:           execCfg.Codec,
+		desc:            desc,
+		asOf:            cutoff.AsOfSystemTime(),
+		cutoff:          cutoffDatum,
+		selectBatchSize: desc.RowLevelTTL.SelectBatchSize,
+		deleteBatchSize: desc.RowLevelTTL.DeleteBatchSize,
+		useRangeBounds:  !desc.IsInterleaved(),
+	}
+	if d.selectBatchSize == 0 {
+		d.selectBatchSize = defaultSelectBatchSize.Get(&st.SV)
+	}
+	if d.deleteBatchSize == 0 {
+		d.deleteBatchSize = defaultDeleteBatchSize.Get(&st.SV)
+	}
+	rateLimit := desc.RowLevelTTL.DeleteRateLimit
+	if rateLimit == 0 {
+		rateLimit = defaultDeleteRateLimit.Get(&st.SV)
+	}
+	if rateLimit > 0 {
+		d.limiter = limit.NewLimiter(rate.Limit(rateLimit))
+	}
+	for i, id := range desc.PrimaryIndex.ColumnIDs {
+		col, err := desc.FindColumnByID(id)
+		if err != nil {
+			return nil, err
+		}
+		d.pkColNames = append(d.pkColNames, tree.NameString(col.Name))
+		d.pkTypes = append(d.pkTypes, col.Type)
+		if desc.PrimaryIndex.ColumnDirections[i] != sqlbase.IndexDescriptor_ASC {
+			d.useRangeBounds = false
+		}
+	}
+	return d, nil
+}
+
+// keyToPKPrefix decodes the values of the leading primary key columns
+// encoded in the given key. Range boundaries are not necessarily row
+// boundaries, so only the columns which are fully encoded in the key are
+// returned. A nil result means that the key does not bound the primary index.
+func (d *deleter) keyToPKPrefix(key roachpb.Key) tree.Datums {
+	rest, err := d.codec.StripTenantPrefix(key)
+	if err != nil {
+		return nil
+	}
+	rest, tableID, indexID, err := sqlbase.DecodePartialTableIDIndexID(rest)
+	if err != nil || tableID != d.desc.ID || indexID != d.desc.PrimaryIndex.ID {
+		return nil
+	}
+	var datums tree.Datums
+	for _, typ := range d.pkTypes {
+		if len(rest) == 0 {
+			break
+		}
+		var ed sqlbase.EncDatum
+		ed, rest, err = sqlbase.EncDatumFromBuffer(typ, sqlbase.DatumEncoding_ASCENDING_KEY, rest)
+		if err != nil {
+			break
+		}
+		if err := ed.EnsureDecoded(typ, &d.alloc); err != nil {
+			break
+		}
+		datums = append(datums, ed.Datum)
+	}
+	return datums
+}
+
+// deleteExpiredRows deletes the expired rows whose primary key is between
+// the given bounds, which are prefixes of primary keys. The start bound is
+// inclusive and the end bound exclusive; an empty bound is unbounded. It
+// returns the number of deleted rows.
+func (d *deleter) deleteExpiredRows(
+	ctx context.Context, start, end tree.Datums,
+) (deleted int64, _ error) {
+	startInclusive := true
+	for {
+		var buf bytes.Buffer
+		args := []interface{}{d.cutoff}
+		fmt.Fprintf(&buf, "SELECT %s FROM [%d AS t] AS OF SYSTEM TIME %s WHERE %s <= $1",
+			d.pkList(len(d.pkColNames)), d.desc.ID, d.asOf, sql.RowLevelTTLExpirationColumnName)
+		if len(start) > 0 {
+			op := ">"
+			if startInclusive {
+				op = ">="
+			}
+			d.writeBound(&buf, &args, op, start)
+		}
+		if len(end) > 0 {
+			d.writeBound(&buf, &args, "<", end)
+		}
+		fmt.Fprintf(&buf, " ORDER BY %s LIMIT %d", d.pkList(len(d.pkColNames)), d.selectBatchSize)
+
+		selectStart := timeutil.Now()
+		rows, err := d.ie.QueryEx(ctx, "ttl-select", nil, /* txn */
+			sqlbase.InternalExecutorSessionDataOverride{User: security.RootUser},
+			buf.String(), args...,
+		)
+		if err != nil {
+			return deleted, err
+		}
+		if d.metrics != nil {
+			d.metrics.SelectDuration.RecordValue(timeutil.Since(selectStart).Nanoseconds())
+			d.metrics.RowsSelected.Inc(int64(len(rows)))
+		}
+
+		for i := 0; i < len(rows); i += int(d.deleteBatchSize) {
+			batch := rows[i:]
+			if len(batch) > int(d.deleteBatchSize) {
+				batch = batch[:d.deleteBatchSize]
+			}
+			if d.limiter != nil {
+				if err := d.limiter.WaitN(ctx, len(batch)); err != nil {
+					return deleted, err
+				}
+			}
+			n, err := d.deleteBatch(ctx, batch)
+			deleted += n
+			if err != nil {
+				return deleted, err
+			}
+		}
+
+		if int64(len(rows)) < d.selectBatchSize {
+			return deleted, nil
+		}
+		// The next batch starts after the last selected row.
+		start, startInclusive = rows[len(rows)-1], false
+	}
+}
+
+// deleteBatch deletes the given rows, identified by their primary key, in
+// a single transaction. Rows whose expiration time was changed since they
+// were selected are not deleted.
+func (d *deleter) deleteBatch(ctx context.Context, pks []tree.Datums) (int64, error) {
+	var buf bytes.Buffer
+	args := make([]interface{}, 0, 1+len(pks)*len(d.pkColNames))
+	args = append(args, d.cutoff)
+	fmt.Fprintf(&buf, "DELETE FROM [%d AS t] WHERE %s <= $1 AND (%s) IN (",
+		d.desc.ID, sql.RowLevelTTLExpirationColumnName, d.pkList(len(d.pkColNames)))
+	for i, pk := range pks {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		d.writeTuple(&buf, &args, pk)
+	}
+	buf.WriteString(")")
+
+	deleteStart := timeutil.Now()
+	n, err := d.ie.ExecEx(ctx, "ttl-delete", nil, /* txn */
+		sqlbase.InternalExecutorSessionDataOverride{User: security.RootUser},
+		buf.String(), args...,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if d.metrics != nil {
+		d.metrics.DeleteDuration.RecordValue(timeutil.Since(deleteStart).Nanoseconds())
+		d.metrics.RowsDeleted.Inc(int64(n))
+	}
+	return int64(n), nil
+}
+
+// pkList returns the comma-separated list of the first n primary key
+// columns.
+func (d *deleter) pkList(n int) string {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(d.pkColNames[i])
+	}
+	return buf.String()
+}
+
+// writeBound writes a condition comparing the leading primary key columns
+// to the given values.
+func (d *deleter) writeBound(buf *bytes.Buffer, args *[]interface{}, op string, vals tree.Datums) {
+	fmt.Fprintf(buf, " AND (%s) %s ", d.pkList(len(vals)), op)
+	d.writeTuple(buf, args, vals)
+}
+
+// writeTuple writes a tuple of placeholders for the given values, which are
+// appended to args.
+func (d *deleter) writeTuple(buf *bytes.Buffer, args *[]interface{}, vals tree.Datums) {
+	buf.WriteString("(")
+	for i, v := range vals {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		*args = append(*args, v)
+		fmt.Fprintf(buf, "$%d::%s", len(*args), d.pkTypes[i].SQLString())
+	}
+	buf.WriteString(")")
+}
+
+func init() {
+	jobs.RegisterConstructor(jobspb.TypeRowLevelTTL, func(job *jobs.Job, settings *cluster.Settings) jobs.Resumer {
+		return &rowLevelTTLResumer{job: job, st: settings}
+	})
+}
@@ -35,8 +35,29 @@ import (
 func (p *planner) writeTypeChange(
 	ctx context.Context, typeDesc *sqlbase.MutableTypeDescriptor, jobDesc string,
 ) error {
-	// Check if there is an active job for this type, otherwise create one.
-	job, jobExists := p.extendedEvalCtx.SchemaChangeJobCache[typeDesc.ID]
+	return p.writeDescChangeWithTypeSchemaChangeJob(ctx, typeDesc, jobDesc)
+}
+
+// writeFunctionChange should be called on a mutated user-defined function
+// descriptor to ensure that the descriptor gets written to a batch, as well as
+// ensuring that a job is created to wait for the leases on the previous
+// version of the function to be released and, if the function was dropped,
+// to remove its descriptor.
+func (p *planner) writeFunctionChange(
+	ctx context.Context, fnDesc *sqlbase.MutableFunctionDescriptor, jobDesc string,
+) error {
+	return p.writeDescChangeWithTypeSchemaChangeJob(ctx, fnDesc, jobDesc)
+}
+
+// writeDescChangeWithTypeSchemaChangeJob implements writeTypeChange and
+// writeFunctionChange. The type schema change job handles both kinds of
+// descriptors.
+func (p *planner) writeDescChangeWithTypeSchemaChangeJob(
+	ctx context.Context, desc catalog.MutableDescriptor, jobDesc string,
+) error {
+	id := desc.GetID()
+	// Check if there is an active job for this descriptor, otherwise create one.
+	job, jobExists := p.extendedEvalCtx.SchemaChangeJobCache[id]
 	if jobExists {
 		// Update it.
 		if err := job.WithTxn(p.txn).SetDescription(ctx,
@@ -46,15 +67,15 @@ func (p *planner) writeTypeChange(
 		); err != nil {
 			return err
 		}
-		log.Infof(ctx, "job %d: updated with type change for type %d", *job.ID(), typeDesc.ID)
+		log.Infof(ctx, "job %d: updated with %s change for %s %d", *job.ID(), desc.TypeName(), desc.TypeName(), id)
 	} else {
 		// Or, create a new job.
 		jobRecord := jobs.Record{
 			Description:   jobDesc,
 			Username:      p.User(),
-			DescriptorIDs: sqlbase.IDs{typeDesc.ID},
+			DescriptorIDs: sqlbase.IDs{id},
 			Details: jobspb.TypeSchemaChangeDetails{
-				TypeID: typeDesc.ID,
+				TypeID: id,
 			},
 			Progress: jobspb.TypeSchemaChangeProgress{},
 			// Type change jobs are not cancellable.
@@ -64,19 +85,19 @@ func (p *planner) writeTypeChange(
 		if err != nil {
 			return err
 		}
-		p.extendedEvalCtx.SchemaChangeJobCache[typeDesc.ID] = newJob
-		log.Infof(ctx, "queued new type change job %d for type %d", *newJob.ID(), typeDesc.ID)
+		p.extendedEvalCtx.SchemaChangeJobCache[id] = newJob
+		log.Infof(ctx, "queued new %s change job %d for %s %d", desc.TypeName(), *newJob.ID(), desc.TypeName(), id)
 	}
 
-	// Maybe increment the type's version.
-	typeDesc.MaybeIncrementVersion()
+	// Maybe increment the descriptor's version.
+	desc.MaybeIncrementVersion()
 
 	// Add the modified descriptor to the descriptor collection.
-	if err := p.Descriptors().AddUncommittedDescriptor(typeDesc); err != nil {
+	if err := p.Descriptors().AddUncommittedDescriptor(desc); err != nil {
 		return err
 	}
 
-	// Write the type out to a batch.
+	// Write the descriptor out to a batch.
 	b := p.txn.NewBatch()
 	if err := catalogkv.WriteDescToBatch(
 		ctx,
@@ -84,8 +105,8 @@ func (p *planner) writeTypeChange(
 		p.ExecCfg().Settings,
 		b,
 		p.ExecCfg().Codec,
-		typeDesc.ID,
-		typeDesc,
+		id,
+		desc,
 	); err != nil {
 		return err
 	}
@@ -107,21 +128,26 @@ type TypeSchemaChangerTestingKnobs struct {
 // ModuleTestingKnobs implements the ModuleTestingKnobs interface.
 func (TypeSchemaChangerTestingKnobs) ModuleTestingKnobs() {}
 
-func (t *typeSchemaChanger) getTypeDescFromStore(
-	ctx context.Context,
-) (*sqlbase.ImmutableTypeDescriptor, error) {
-	var typeDesc *sqlbase.ImmutableTypeDescriptor
+// getDescFromStore reads the descriptor the schema change operates on. This
+// is usually a type descriptor, but the job is also used to wait for the
+// leases on a modified user-defined function to be released, and to remove the
+// descriptor of a dropped function once they are.
+func (t *typeSchemaChanger) getDescFromStore(ctx context.Context) (catalog.Descriptor, error) {
+	var desc catalog.Descriptor
 	if err := t.execCfg.DB.Txn(ctx, func(ctx context.Context, txn *kv.Txn) error {
 		var err error
-		typeDesc, err = sqlbase.GetTypeDescFromID(ctx, txn, t.execCfg.Codec, t.typeID)
+		desc, err = catalogkv.GetDescriptorByID(ctx, txn, t.execCfg.Codec, t.typeID)
 		if err != nil {
 			return err
 		}
+		if desc == nil {
+			return sqlbase.ErrDescriptorNotFound
+		}
 		return nil
 	}); err != nil {
 		return nil, err
 	}
-	return typeDesc, nil
+	return desc, nil
 }
 
 // exec is the entry point for the type schema change process.
@@ -135,13 +161,13 @@ func (t *typeSchemaChanger) exec(ctx context.Context) error {
 	leaseMgr := t.execCfg.LeaseManager
 	codec := t.execCfg.Codec
 
-	typeDesc, err := t.getTypeDescFromStore(ctx)
+	desc, err := t.getDescFromStore(ctx)
 	if err != nil {
 		return err
 	}
 
 	// If there are any names to drain, then do so.
-	if len(typeDesc.DrainingNames) > 0 {
+	if len(desc.GetDrainingNames()) > 0 {
 		if err := drainNamesForDescriptor(
 			ctx,
 			t.typeID,
@@ -154,7 +180,8 @@ func (t *typeSchemaChanger) exec(ctx context.Context) error {
 	}
 
 	// If there are any read only enum members, promote them to writeable.
-	if typeDesc.Kind == sqlbase.TypeDescriptor_ENUM {
+	if typeDesc, ok := desc.(*sqlbase.ImmutableTypeDescriptor); ok &&
+		typeDesc.Kind == sqlbase.TypeDescriptor_ENUM {
 		hasNonPublic := false
 		for _, member := range typeDesc.EnumMembers {
 			if member.Capability == sqlbase.TypeDescriptor_EnumMember_READ_ONLY {
@@ -199,11 +226,11 @@ func (t *typeSchemaChanger) exec(ctx context.Context) error {
 		return err
 	}
 
-	// If the type is being dropped, remove the descriptor here.
-	if typeDesc.Dropped() {
+	// If the type or function is being dropped, remove the descriptor here.
+	if desc.Dropped() {
 		if err := t.execCfg.DB.Txn(ctx, func(ctx context.Context, txn *kv.Txn) error {
 			b := txn.NewBatch()
-			b.Del(sqlbase.MakeDescMetadataKey(codec, typeDesc.ID))
+			b.Del(sqlbase.MakeDescMetadataKey(codec, desc.GetID()))
 			return txn.Run(ctx, b)
 		}); err != nil {
 			return err
@@ -270,11 +297,11 @@ func (t *typeChangeResumer) OnFailOrCancel(ctx context.Context, phs interface{})
 		typeID:  t.job.Details().(jobspb.TypeSchemaChangeDetails).TypeID,
 		execCfg: phs.(*planner).ExecCfg(),
 	}
-	typeDesc, err := tc.getTypeDescFromStore(ctx)
+	desc, err := tc.getDescFromStore(ctx)
 	if err != nil {
 		return err
 	}
-	if len(typeDesc.DrainingNames) > 0 {
+	if len(desc.GetDrainingNames()) > 0 {
 		if err := drainNamesForDescriptor(
 			ctx,
 			tc.typeID,
@@ -131,7 +131,10 @@ func (u *updateNode) startExec(params runParams) error {
 			params.EvalContext().Mon.MakeBoundAccount(),
 			sqlbase.ColTypeInfoFromResCols(u.columns), 0)
 	}
-	return u.run.tu.init(params.ctx, params.p.txn, params.EvalContext())
+	if err := u.run.tu.init(params.ctx, params.p.txn, params.EvalContext()); err != nil {
+		return err
+	}
+	return u.run.tu.initDeferrableUniqueChecks(params.p.deferredConstraints)
 }
 
 // Next is required because batchedPlanNode inherits from planNode, but
@@ -57,7 +57,10 @@ func (n *upsertNode) startExec(params runParams) error {
 	// cache traceKV during execution, to avoid re-evaluating it for every row.
 	n.run.traceKV = params.p.ExtendedEvalContext().Tracing.KVTracingEnabled()
 
-	return n.run.tw.init(params.ctx, params.p.txn, params.EvalContext())
+	if err := n.run.tw.init(params.ctx, params.p.txn, params.EvalContext()); err != nil {
+		return err
+	}
+	return n.run.tw.initDeferrableUniqueChecks(params.p.deferredConstraints)
 }
 
 // Next is required because batchedPlanNode inherits from planNode, but
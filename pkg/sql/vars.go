@@ -1101,6 +1101,25 @@ var varGen = map[string]sessionVar{
 		},
 	},
 
+	// CockroachDB extension.
+	`experimental_enable_triggers`: {
+		GetStringVal: makePostgresBoolGetStringValFn(`experimental_enable_triggers`),
+		Set: func(_ context.Context, m *sessionDataMutator, s string) error {
+			b, err := parseBoolVar("experimental_enable_triggers", s)
+			if err != nil {
+				return err
+			}
+			m.SetTriggersEnabled(b)
+			return nil
+		},
+		Get: func(evalCtx *extendedEvalContext) string {
+			return formatBoolAsPostgresSetting(evalCtx.SessionData.TriggersEnabled)
+		},
+		GlobalDefault: func(sv *settings.Values) string {
+			return formatBoolAsPostgresSetting(triggersEnabledClusterMode.Get(sv))
+		},
+	},
+
 	// CockroachDB extension.
 	`experimental_enable_hash_sharded_indexes`: {
 		GetStringVal: makePostgresBoolGetStringValFn(`experimental_enable_hash_sharded_indexes`),
@@ -674,6 +674,11 @@ func (v *planVisitor) visitInternal(plan planNode, name string) {
 			v.observer.attr(name, "query", n.viewQuery)
 		}
 
+	case *createFunctionNode:
+		if v.observer.attr != nil {
+			v.observer.attr(name, "body", n.body)
+		}
+
 	case *setVarNode:
 		if v.observer.expr != nil {
 			for i, texpr := range n.typedValues {
@@ -926,22 +931,28 @@ var planNodeNames = map[reflect.Type]string{
 	reflect.TypeOf(&controlJobsNode{}):       "control jobs",
 	reflect.TypeOf(&controlSchedulesNode{}):  "control schedules",
 	reflect.TypeOf(&createDatabaseNode{}):    "create database",
+	reflect.TypeOf(&createFunctionNode{}):    "create function",
 	reflect.TypeOf(&createIndexNode{}):       "create index",
 	reflect.TypeOf(&createSequenceNode{}):    "create sequence",
 	reflect.TypeOf(&createSchemaNode{}):      "create schema",
 	reflect.TypeOf(&createStatsNode{}):       "create statistics",
+	reflect.TypeOf(&closeCursorNode{}):       "close cursor",
 	reflect.TypeOf(&createTableNode{}):       "create table",
+	reflect.TypeOf(&createTriggerNode{}):     "create trigger",
 	reflect.TypeOf(&createTypeNode{}):        "create type",
 	reflect.TypeOf(&CreateRoleNode{}):        "create user/role",
 	reflect.TypeOf(&createViewNode{}):        "create view",
+	reflect.TypeOf(&declareCursorNode{}):     "declare cursor",
 	reflect.TypeOf(&delayedNode{}):           "virtual table",
 	reflect.TypeOf(&deleteNode{}):            "delete",
 	reflect.TypeOf(&deleteRangeNode{}):       "delete range",
 	reflect.TypeOf(&distinctNode{}):          "distinct",
 	reflect.TypeOf(&dropDatabaseNode{}):      "drop database",
+	reflect.TypeOf(&dropFunctionNode{}):      "drop function",
 	reflect.TypeOf(&dropIndexNode{}):         "drop index",
 	reflect.TypeOf(&dropSequenceNode{}):      "drop sequence",
 	reflect.TypeOf(&dropTableNode{}):         "drop table",
+	reflect.TypeOf(&dropTriggerNode{}):       "drop trigger",
 	reflect.TypeOf(&dropTypeNode{}):          "drop type",
 	reflect.TypeOf(&DropRoleNode{}):          "drop user/role",
 	reflect.TypeOf(&dropViewNode{}):          "drop view",
@@ -949,6 +960,7 @@ var planNodeNames = map[reflect.Type]string{
 	reflect.TypeOf(&explainDistSQLNode{}):    "explain distsql",
 	reflect.TypeOf(&explainPlanNode{}):       "explain plan",
 	reflect.TypeOf(&explainVecNode{}):        "explain vectorized",
+	reflect.TypeOf(&fetchCursorNode{}):       "fetch cursor",
 	reflect.TypeOf(&exportNode{}):            "export",
 	reflect.TypeOf(&filterNode{}):            "filter",
 	reflect.TypeOf(&GrantRoleNode{}):         "grant role",
@@ -962,8 +974,11 @@ var planNodeNames = map[reflect.Type]string{
 	reflect.TypeOf(&invertedJoinNode{}):      "inverted-join",
 	reflect.TypeOf(&joinNode{}):              "join",
 	reflect.TypeOf(&limitNode{}):             "limit",
+	reflect.TypeOf(&listenNode{}):            "listen",
 	reflect.TypeOf(&lookupJoinNode{}):        "lookup-join",
 	reflect.TypeOf(&max1RowNode{}):           "max1row",
+	reflect.TypeOf(&moveCursorNode{}):        "move cursor",
+	reflect.TypeOf(&notifyNode{}):            "notify",
 	reflect.TypeOf(&ordinalityNode{}):        "ordinality",
 	reflect.TypeOf(&projectSetNode{}):        "project set",
 	reflect.TypeOf(&recursiveCTENode{}):      "recursive cte node",
@@ -984,6 +999,7 @@ var planNodeNames = map[reflect.Type]string{
 	reflect.TypeOf(&sequenceSelectNode{}):    "sequence select",
 	reflect.TypeOf(&serializeNode{}):         "run",
 	reflect.TypeOf(&setClusterSettingNode{}): "set cluster setting",
+	reflect.TypeOf(&setConstraintsNode{}):    "set constraints",
 	reflect.TypeOf(&setVarNode{}):            "set",
 	reflect.TypeOf(&setZoneConfigNode{}):     "configure zone",
 	reflect.TypeOf(&showFingerprintsNode{}):  "showFingerprints",
@@ -333,6 +333,13 @@ var backwardCompatibleMigrations = []migrationDescriptor{
 		includedInBootstrap: clusterversion.VersionByKey(clusterversion.VersionAddScheduledJobsTable),
 		newDescriptorIDs:    staticIDs(keys.ScheduledJobsTableID),
 	},
+	{
+		// Introduced in v20.2.
+		name:                "create new system.notifications table",
+		workFn:              createNotificationsTable,
+		includedInBootstrap: clusterversion.VersionByKey(clusterversion.VersionNotificationsTable),
+		newDescriptorIDs:    staticIDs(keys.NotificationsTableID),
+	},
 }
 
 func staticIDs(
@@ -1893,3 +1900,7 @@ STORING (status)
 func createScheduledJobsTable(ctx context.Context, r runner) error {
 	return createSystemTable(ctx, r, sqlbase.ScheduledJobsTable)
 }
+
+func createNotificationsTable(ctx context.Context, r runner) error {
+	return createSystemTable(ctx, r, sqlbase.NotificationsTable)
+}
@@ -59,6 +59,14 @@ type ExternalStorage interface {
 	// allowed to contain globs-patterns when the explicit patternSuffix is "".
 	ListFiles(ctx context.Context, patternSuffix string) ([]string, error)
 
+	// ListFilesWithPrefix returns all the files whose path relative to the base
+	// path starts with prefix, including the files in any subdirectory. Unlike
+	// ListFiles, the prefix is not a pattern. The results are relative to the
+	// base path. On storage that has directories, the directories are returned
+	// as well, after the files they contain, so that deleting all the results
+	// in order leaves nothing behind.
+	ListFilesWithPrefix(ctx context.Context, prefix string) ([]string, error)
+
 	// Delete removes the named file from the store.
 	Delete(ctx context.Context, basename string) error
 
@@ -150,6 +150,24 @@ func (s *azureStorage) ListFiles(ctx context.Context, patternSuffix string) ([]s
 	return fileList, nil
 }
 
+func (s *azureStorage) ListFilesWithPrefix(ctx context.Context, prefix string) ([]string, error) {
+	var fileList []string
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		response, err := s.container.ListBlobsFlatSegment(ctx,
+			marker,
+			azblob.ListBlobsSegmentOptions{Prefix: joinPrefix(s.prefix, prefix)},
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to list files for specified blob")
+		}
+		for _, blob := range response.Segment.BlobItems {
+			fileList = append(fileList, strings.TrimPrefix(strings.TrimPrefix(blob.Name, s.prefix), "/"))
+		}
+		marker = response.NextMarker
+	}
+	return fileList, nil
+}
+
 func (s *azureStorage) Delete(ctx context.Context, basename string) error {
 	err := contextutil.RunWithTimeout(ctx, "delete azure file", timeoutSetting.Get(&s.settings.SV),
 		func(ctx context.Context) error {
@@ -433,6 +433,50 @@ func testListFiles(t *testing.T, storeURI, user string, ie *sql.InternalExecutor
 		}
 	})
 
+	t.Run("ListFilesWithPrefix", func(t *testing.T) {
+		s := storeFromURI(ctx, t, storeURI, clientFactory, user, ie, kvDB)
+		defer s.Close()
+
+		for _, tc := range []struct {
+			name       string
+			prefix     string
+			resultList []string
+		}{
+			{"list-all", "", fileNames},
+			{"list-dir", "file/", fileNames},
+			{"list-subdir", "file/letters/", dataLetterFiles},
+			{"list-partial-name", "file/letters/dataA", []string{"file/letters/dataA.csv"}},
+			{"list-partial-dir", "file/n", dataNumberFiles},
+			{"list-not-a-pattern", "file/numbers/data[0-9]", nil},
+			{"list-no-matches", "missing/", nil},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				filesList, err := s.ListFilesWithPrefix(ctx, tc.prefix)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// Storage that has directories also lists them, after their contents.
+				var files []string
+				for i, got := range filesList {
+					for _, other := range filesList[i+1:] {
+						if strings.HasPrefix(other, got+"/") {
+							t.Fatalf("%s is listed before %s, which it contains", got, other)
+						}
+					}
+					isDir := false
+					for _, other := range filesList[:i] {
+						isDir = isDir || strings.HasPrefix(other, got+"/")
+					}
+					if !isDir {
+						files = append(files, got)
+					}
+				}
+				require.Equal(t, tc.resultList, files, "listed %v", filesList)
+			})
+		}
+	})
+
 	for _, fileName := range fileNames {
 		file := storeFromURI(ctx, t, storeURI, clientFactory, user, ie, kvDB)
 		if err := file.Delete(ctx, fileName); err != nil {
@@ -0,0 +1,90 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+package cloudimpltests
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/cloud"
+	"github.com/cockroachdb/cockroach/pkg/storage/cloudimpl"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/skip"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecryptGCP(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	credentialsFile := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if credentialsFile == "" {
+		skip.IgnoreLint(t, "GOOGLE_APPLICATION_CREDENTIALS env var must be set")
+	}
+	// The resource name of the CryptoKey, i.e.
+	// projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>.
+	keyName := os.Getenv("GOOGLE_KMS_KEY_NAME")
+	if keyName == "" {
+		skip.IgnoreLint(t, "GOOGLE_KMS_KEY_NAME env var must be set")
+	}
+
+	t.Run("auth-empty-no-cred", func(t *testing.T) {
+		uri := fmt.Sprintf("gs:///%s", keyName)
+		_, err := cloud.KMSFromURI(uri, &testKMSEnv{})
+		require.EqualError(t, err, fmt.Sprintf(
+			`%s is set to '%s', but %s is not set`,
+			cloudimpl.AuthParam,
+			cloudimpl.AuthParamSpecified,
+			cloudimpl.CredentialsParam,
+		))
+	})
+
+	t.Run("auth-implicit", func(t *testing.T) {
+		q := make(url.Values)
+		q.Set(cloudimpl.AuthParam, cloudimpl.AuthParamImplicit)
+
+		uri := fmt.Sprintf("gs:///%s?%s", keyName, q.Encode())
+		testEncryptDecrypt(t, uri, testKMSEnv{
+			cluster.NoSettings, &base.ExternalIODirConfig{},
+		})
+	})
+
+	t.Run("auth-specified", func(t *testing.T) {
+		credentials, err := ioutil.ReadFile(credentialsFile)
+		require.NoError(t, err)
+
+		q := make(url.Values)
+		q.Set(cloudimpl.AuthParam, cloudimpl.AuthParamSpecified)
+		q.Set(cloudimpl.CredentialsParam, base64.StdEncoding.EncodeToString(credentials))
+
+		uri := fmt.Sprintf("gs:///%s?%s", keyName, q.Encode())
+		testEncryptDecrypt(t, uri, testKMSEnv{
+			cluster.NoSettings, &base.ExternalIODirConfig{},
+		})
+	})
+}
+
+func TestGCPKMSDisallowImplicitCredentials(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	q := make(url.Values)
+	q.Set(cloudimpl.AuthParam, cloudimpl.AuthParamImplicit)
+
+	uri := fmt.Sprintf("gs:///projects/p/locations/l/keyRings/r/cryptoKeys/k?%s", q.Encode())
+	_, err := cloud.KMSFromURI(uri, &testKMSEnv{cluster.NoSettings,
+		&base.ExternalIODirConfig{DisableImplicitCredentials: true}})
+	require.True(t, testutils.IsError(err, "implicit credentials disallowed"))
+}
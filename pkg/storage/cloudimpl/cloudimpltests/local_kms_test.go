@@ -0,0 +1,135 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+package cloudimpltests
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/cloud"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecryptLocalKMS(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+	keysDir := filepath.Join(dir, "keys")
+	ioDir := filepath.Join(dir, "extern")
+	require.NoError(t, os.Mkdir(keysDir, 0700))
+	require.NoError(t, os.Mkdir(ioDir, 0755))
+
+	// The key files are written to the keys directory, and the paths of the
+	// URIs are relative to it.
+	writeKey := func(name, contents string) string {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(keysDir, name), []byte(contents), 0600))
+		return "/" + name
+	}
+	settings := cluster.MakeTestingClusterSettings()
+	settings.ExternalIODir = ioDir
+	env := testKMSEnv{settings, &base.ExternalIODirConfig{LocalKMSKeysDir: keysDir}}
+
+	keyPath := writeKey("master.key",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f\n")
+	uri := fmt.Sprintf("local-kms://%s", keyPath)
+
+	t.Run("encrypt-decrypt", func(t *testing.T) {
+		testEncryptDecrypt(t, uri, env)
+	})
+
+	t.Run("master-key-id", func(t *testing.T) {
+		kms, err := cloud.KMSFromURI(uri, &env)
+		require.NoError(t, err)
+		id, err := kms.MasterKeyID()
+		require.NoError(t, err)
+		require.Equal(t, keyPath, id)
+	})
+
+	t.Run("wrong-key", func(t *testing.T) {
+		ctx := context.Background()
+		kms, err := cloud.KMSFromURI(uri, &env)
+		require.NoError(t, err)
+		ciphertext, err := kms.Encrypt(ctx, []byte("hello world"))
+		require.NoError(t, err)
+
+		otherPath := writeKey("other.key",
+			"1f1e1d1c1b1a191817161514131211100f0e0d0c0b0a09080706050403020100")
+		other, err := cloud.KMSFromURI(fmt.Sprintf("local-kms://%s", otherPath), &env)
+		require.NoError(t, err)
+		_, err = other.Decrypt(ctx, ciphertext)
+		require.True(t, testutils.IsError(err, "decrypting with local kms master key"))
+	})
+
+	t.Run("invalid-key", func(t *testing.T) {
+		for _, tc := range []struct {
+			name     string
+			contents string
+			err      string
+		}{
+			{"not-hex.key", "not a hex key", "decoding local kms master key"},
+			{"short.key", "0001020304050607", "invalid local kms master key"},
+		} {
+			p := writeKey(tc.name, tc.contents)
+			_, err := cloud.KMSFromURI(fmt.Sprintf("local-kms://%s", p), &env)
+			require.True(t, testutils.IsError(err, tc.err), "%s: %v", tc.name, err)
+		}
+	})
+
+	t.Run("missing-key", func(t *testing.T) {
+		_, err := cloud.KMSFromURI(
+			fmt.Sprintf("local-kms://%s", "/missing.key"), &env)
+		require.True(t, testutils.IsError(err, "reading local kms master key"))
+	})
+
+	t.Run("outside-keys-dir", func(t *testing.T) {
+		_, err := cloud.KMSFromURI(
+			fmt.Sprintf("local-kms://%s", "/../extern/master.key"), &env)
+		require.True(t, testutils.IsError(err, "is not allowed"), "%v", err)
+	})
+
+	t.Run("keys-dir-not-set", func(t *testing.T) {
+		noKeysDir := testKMSEnv{settings, &base.ExternalIODirConfig{}}
+		_, err := cloud.KMSFromURI(uri, &noKeysDir)
+		require.True(t, testutils.IsError(err,
+			"local-kms is disabled since --local-kms-keys-dir is not set"), "%v", err)
+	})
+
+	t.Run("keys-dir-in-external-io-dir", func(t *testing.T) {
+		// nodelocal storage could read the keys if they were inside the external
+		// IO directory.
+		for _, externalIODir := range []string{dir, keysDir} {
+			st := cluster.MakeTestingClusterSettings()
+			st.ExternalIODir = externalIODir
+			reachable := testKMSEnv{st, &base.ExternalIODirConfig{LocalKMSKeysDir: keysDir}}
+			_, err := cloud.KMSFromURI(uri, &reachable)
+			require.True(t, testutils.IsError(err,
+				"local-kms is disabled since --local-kms-keys-dir is inside --external-io-dir"),
+				"%s: %v", externalIODir, err)
+		}
+	})
+
+	t.Run("implicit-credentials-disabled", func(t *testing.T) {
+		noImplicit := testKMSEnv{settings, &base.ExternalIODirConfig{
+			DisableImplicitCredentials: true, LocalKMSKeysDir: keysDir,
+		}}
+		_, err := cloud.KMSFromURI(uri, &noImplicit)
+		require.True(t, testutils.IsError(err,
+			"local-kms disallowed due to --external-io-disable-implicit-credentials flag"), "%v", err)
+	})
+}
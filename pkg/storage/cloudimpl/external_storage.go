@@ -401,6 +401,16 @@ func isResumableHTTPError(err error) bool {
 		sysutil.IsErrConnectionRefused(err)
 }
 
+// joinPrefix returns the prefix of the objects of a bucket whose path relative
+// to base starts with prefix. It is not cleaned like path.Join, so that a
+// trailing slash is kept.
+func joinPrefix(base, prefix string) string {
+	if base == "" {
+		return prefix
+	}
+	return strings.TrimSuffix(base, "/") + "/" + prefix
+}
+
 func getPrefixBeforeWildcard(p string) string {
 	globIndex := strings.IndexAny(p, "*?[")
 	if globIndex < 0 {
@@ -259,6 +259,29 @@ func (f *fileTableStorage) ListFiles(ctx context.Context, patternSuffix string)
 	return fileList, nil
 }
 
+// ListFilesWithPrefix implements the ExternalStorage interface and lists the
+// files stored in the user scoped FileToTableSystem whose path starts with
+// prefix.
+func (f *fileTableStorage) ListFilesWithPrefix(
+	ctx context.Context, prefix string,
+) ([]string, error) {
+	fullPrefix := joinPrefix(f.prefix, prefix)
+	matches, err := f.fs.ListFiles(ctx, fullPrefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list files")
+	}
+	var fileList []string
+	for _, match := range matches {
+		// The file table matches the prefix with LIKE, which treats _ and % as
+		// wildcards.
+		if !strings.HasPrefix(match, fullPrefix) {
+			continue
+		}
+		fileList = append(fileList, strings.TrimPrefix(strings.TrimPrefix(match, f.prefix), "/"))
+	}
+	return fileList, nil
+}
+
 // Delete implements the ExternalStorage interface and deletes the file from the
 // user scoped FileToTableSystem.
 func (f *fileTableStorage) Delete(ctx context.Context, basename string) error {
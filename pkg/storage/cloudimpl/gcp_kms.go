@@ -0,0 +1,148 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package cloudimpl
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"strings"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"github.com/cockroachdb/cockroach/pkg/storage/cloud"
+	"github.com/cockroachdb/errors"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+)
+
+const gcpScheme = "gs"
+
+type gcpKMS struct {
+	kms                 *kms.KeyManagementClient
+	customerMasterKeyID string
+}
+
+var _ cloud.KMS = &gcpKMS{}
+
+func init() {
+	cloud.RegisterKMSFromURIFactory(MakeGCPKMS, gcpScheme)
+}
+
+type gcpKMSURIParams struct {
+	credentials string
+	auth        string
+}
+
+func resolveGCPKMSURIParams(kmsURI url.URL) gcpKMSURIParams {
+	return gcpKMSURIParams{
+		credentials: kmsURI.Query().Get(CredentialsParam),
+		auth:        kmsURI.Query().Get(AuthParam),
+	}
+}
+
+// MakeGCPKMS is the factory method which returns a configured, ready-to-use
+// GCP KMS object. The path of the URI is expected to be the resource name of
+// the CryptoKey, i.e.
+// projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>.
+func MakeGCPKMS(uri string, env cloud.KMSEnv) (cloud.KMS, error) {
+	kmsURI, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract the URI parameters required to setup the GCP KMS session.
+	kmsURIParams := resolveGCPKMSURIParams(*kmsURI)
+	ctx := context.Background()
+	opts := []option.ClientOption{option.WithScopes(kms.DefaultAuthScopes()...)}
+
+	// "specified": the JSON object for authentication is given by the CREDENTIALS param.
+	// "implicit": only use the environment data.
+	// "": default to `specified`.
+	switch kmsURIParams.auth {
+	case "", AuthParamSpecified:
+		if kmsURIParams.credentials == "" {
+			return nil, errors.Errorf(
+				"%s is set to '%s', but %s is not set",
+				AuthParam,
+				AuthParamSpecified,
+				CredentialsParam,
+			)
+		}
+		decodedKey, err := base64.StdEncoding.DecodeString(kmsURIParams.credentials)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding value of %s", CredentialsParam)
+		}
+		source, err := google.JWTConfigFromJSON(decodedKey, kms.DefaultAuthScopes()...)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating GCP KMS oauth token source from specified credentials")
+		}
+		opts = append(opts, option.WithTokenSource(source.TokenSource(ctx)))
+	case AuthParamImplicit:
+		if env.KMSConfig().DisableImplicitCredentials {
+			return nil, errors.New(
+				"implicit credentials disallowed for gs due to --external-io-implicit-credentials flag")
+		}
+		// Do nothing; use implicit params:
+		// https://godoc.org/golang.org/x/oauth2/google#FindDefaultCredentials
+	default:
+		return nil, errors.Errorf("unsupported value %s for %s", kmsURIParams.auth, AuthParam)
+	}
+
+	kmc, err := kms.NewKeyManagementClient(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create google cloud kms client")
+	}
+	return &gcpKMS{
+		kms:                 kmc,
+		customerMasterKeyID: strings.TrimPrefix(kmsURI.Path, "/"),
+	}, nil
+}
+
+// MasterKeyID implements the KMS interface.
+func (k *gcpKMS) MasterKeyID() (string, error) {
+	return k.customerMasterKeyID, nil
+}
+
+// Encrypt implements the KMS interface.
+func (k *gcpKMS) Encrypt(ctx context.Context, data []byte) ([]byte, error) {
+	encryptInput := &kmspb.EncryptRequest{
+		Name:      k.customerMasterKeyID,
+		Plaintext: data,
+	}
+
+	encryptOutput, err := k.kms.Encrypt(ctx, encryptInput)
+	if err != nil {
+		return nil, err
+	}
+
+	return encryptOutput.Ciphertext, nil
+}
+
+// Decrypt implements the KMS interface.
+func (k *gcpKMS) Decrypt(ctx context.Context, data []byte) ([]byte, error) {
+	decryptInput := &kmspb.DecryptRequest{
+		Name:       k.customerMasterKeyID,
+		Ciphertext: data,
+	}
+
+	decryptOutput, err := k.kms.Decrypt(ctx, decryptInput)
+	if err != nil {
+		return nil, err
+	}
+
+	return decryptOutput.Plaintext, nil
+}
+
+// Close implements the KMS interface.
+func (k *gcpKMS) Close() error {
+	return k.kms.Close()
+}
@@ -290,6 +290,24 @@ func (g *gcsStorage) ListFiles(ctx context.Context, patternSuffix string) ([]str
 	return fileList, nil
 }
 
+func (g *gcsStorage) ListFilesWithPrefix(ctx context.Context, prefix string) ([]string, error) {
+	var fileList []string
+	it := g.bucket.Objects(ctx, &gcs.Query{
+		Prefix: joinPrefix(g.prefix, prefix),
+	})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to list files in gcs bucket")
+		}
+		fileList = append(fileList, strings.TrimPrefix(strings.TrimPrefix(attrs.Name, g.prefix), "/"))
+	}
+	return fileList, nil
+}
+
 func (g *gcsStorage) Delete(ctx context.Context, basename string) error {
 	return contextutil.RunWithTimeout(ctx, "delete gcs file",
 		timeoutSetting.Get(&g.settings.SV),
@@ -266,6 +266,10 @@ func (h *httpStorage) ListFiles(_ context.Context, _ string) ([]string, error) {
 	return nil, errors.Mark(errors.New("http storage does not support listing"), ErrListingUnsupported)
 }
 
+func (h *httpStorage) ListFilesWithPrefix(_ context.Context, _ string) ([]string, error) {
+	return nil, errors.Mark(errors.New("http storage does not support listing"), ErrListingUnsupported)
+}
+
 func (h *httpStorage) Delete(ctx context.Context, basename string) error {
 	return contextutil.RunWithTimeout(ctx, fmt.Sprintf("DELETE %s", basename),
 		timeoutSetting.Get(&h.settings.SV), func(ctx context.Context) error {
@@ -0,0 +1,172 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package cloudimpl
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	crypto_rand "crypto/rand"
+	"encoding/hex"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/blobs"
+	"github.com/cockroachdb/cockroach/pkg/storage/cloud"
+	"github.com/cockroachdb/errors"
+)
+
+const localKMSScheme = "local-kms"
+
+// localKMS is a KMS which wraps data keys with a master key read from a file
+// in the local KMS keys directory of the node, see --local-kms-keys-dir. It
+// allows encrypted backups to be taken and restored without depending on a
+// cloud KMS service, e.g. for on-prem deployments and in tests. The same key
+// file must be present at the same path on every node which may need to
+// encrypt or decrypt.
+type localKMS struct {
+	gcm         cipher.AEAD
+	masterKeyID string
+}
+
+var _ cloud.KMS = &localKMS{}
+
+func init() {
+	cloud.RegisterKMSFromURIFactory(MakeLocalKMS, localKMSScheme)
+}
+
+// MakeLocalKMS is the factory method which returns a configured, ready-to-use
+// local KMS object. The path of the URI is the path, relative to the local KMS
+// keys directory, of a file containing a hex-encoded 128, 192 or 256 bit AES
+// master key, for example as generated by `openssl rand -hex 32`.
+//
+// The key file is found in the environment of the node rather than provided
+// by the user, so it is disallowed along with implicit credentials.
+func MakeLocalKMS(uri string, env cloud.KMSEnv) (cloud.KMS, error) {
+	if env.KMSConfig().DisableImplicitCredentials {
+		return nil, errors.New(
+			"local-kms disallowed due to --external-io-disable-implicit-credentials flag")
+	}
+	kmsURI, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	if kmsURI.Host != "" {
+		return nil, errors.Errorf(
+			"%s URI must not specify a host, expected %s:///path/to/key", localKMSScheme, localKMSScheme)
+	}
+	if kmsURI.Path == "" {
+		return nil, errors.Errorf("%s URI must specify the path of the master key file", localKMSScheme)
+	}
+
+	keysDir := env.KMSConfig().LocalKMSKeysDir
+	if keysDir == "" {
+		return nil, errors.New("local-kms is disabled since --local-kms-keys-dir is not set")
+	}
+	// The keys must not be readable by users through nodelocal storage, which is
+	// confined to the external IO directory.
+	if inExternalIODir, err := isInDir(keysDir, env.ClusterSettings().ExternalIODir); err != nil {
+		return nil, err
+	} else if inExternalIODir {
+		return nil, errors.New("local-kms is disabled since --local-kms-keys-dir is inside --external-io-dir")
+	}
+	// The local storage confines the key file to the keys directory.
+	localStorage, err := blobs.NewLocalStorage(keysDir)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := readLocalKMSMasterKey(localStorage, kmsURI.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading local kms master key")
+	}
+	key, err := hex.DecodeString(string(bytes.TrimSpace(contents)))
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding local kms master key")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid local kms master key")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &localKMS{
+		gcm:         gcm,
+		masterKeyID: kmsURI.Path,
+	}, nil
+}
+
+func readLocalKMSMasterKey(localStorage *blobs.LocalStorage, path string) ([]byte, error) {
+	f, err := localStorage.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+// isInDir returns true if path is dir or one of its descendants. It returns
+// false if dir is empty.
+func isInDir(path, dir string) (bool, error) {
+	if dir == "" {
+		return false, nil
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return false, err
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
+
+// MasterKeyID implements the KMS interface.
+func (k *localKMS) MasterKeyID() (string, error) {
+	return k.masterKeyID, nil
+}
+
+// Encrypt implements the KMS interface. The returned ciphertext is prefixed
+// with the randomly chosen nonce used to seal it.
+func (k *localKMS) Encrypt(ctx context.Context, data []byte) ([]byte, error) {
+	nonce := make([]byte, k.gcm.NonceSize(), k.gcm.NonceSize()+len(data)+k.gcm.Overhead())
+	if _, err := crypto_rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return k.gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// Decrypt implements the KMS interface.
+func (k *localKMS) Decrypt(ctx context.Context, data []byte) ([]byte, error) {
+	if len(data) < k.gcm.NonceSize() {
+		return nil, errors.New("invalid local kms ciphertext: too short")
+	}
+	nonce, ciphertext := data[:k.gcm.NonceSize()], data[k.gcm.NonceSize():]
+	plaintext, err := k.gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting with local kms master key")
+	}
+	return plaintext, nil
+}
+
+// Close implements the KMS interface.
+func (k *localKMS) Close() error {
+	return nil
+}
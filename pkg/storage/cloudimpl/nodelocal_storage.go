@@ -128,6 +128,43 @@ func (l *localFileStorage) ListFiles(ctx context.Context, patternSuffix string)
 	return fileList, nil
 }
 
+// globEscaper escapes the characters that have a special meaning in glob
+// patterns, so that a path only matches itself.
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`)
+
+func (l *localFileStorage) ListFilesWithPrefix(ctx context.Context, prefix string) ([]string, error) {
+	var fileList []string
+	// The blob client only lists by glob pattern, which does not descend into
+	// subdirectories, so every match is listed in turn. Listing the contents of
+	// a file returns nothing.
+	var walk func(pattern string) error
+	walk = func(pattern string) error {
+		matches, err := l.blobClient.List(ctx, pattern)
+		if err != nil {
+			return errors.Wrap(err, "unable to list files")
+		}
+		for _, fileName := range matches {
+			if err := walk(globEscaper.Replace(fileName) + "/*"); err != nil {
+				return err
+			}
+			if !strings.HasPrefix(fileName, l.base) {
+				return errors.Errorf("listed file outside of base path %q", l.base)
+			}
+			fileList = append(fileList, strings.TrimPrefix(strings.TrimPrefix(fileName, l.base), "/"))
+		}
+		return nil
+	}
+	pattern := globEscaper.Replace(joinRelativePath(l.base, prefix))
+	// joinRelativePath drops the trailing slash, which is part of the prefix.
+	if prefix == "" || strings.HasSuffix(prefix, "/") {
+		pattern += "/"
+	}
+	if err := walk(pattern + "*"); err != nil {
+		return nil, err
+	}
+	return fileList, nil
+}
+
 func (l *localFileStorage) Delete(ctx context.Context, basename string) error {
 	return l.blobClient.Delete(ctx, joinRelativePath(l.base, basename))
 }
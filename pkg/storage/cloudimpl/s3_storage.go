@@ -243,6 +243,27 @@ func (s *s3Storage) ListFiles(ctx context.Context, patternSuffix string) ([]stri
 	return fileList, nil
 }
 
+func (s *s3Storage) ListFilesWithPrefix(ctx context.Context, prefix string) ([]string, error) {
+	var fileList []string
+	err := s.s3.ListObjectsPagesWithContext(
+		ctx,
+		&s3.ListObjectsInput{
+			Bucket: s.bucket,
+			Prefix: aws.String(joinPrefix(s.prefix, prefix)),
+		},
+		func(page *s3.ListObjectsOutput, lastPage bool) bool {
+			for _, fileObject := range page.Contents {
+				fileList = append(fileList, strings.TrimPrefix(strings.TrimPrefix(*fileObject.Key, s.prefix), "/"))
+			}
+			return !lastPage
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to list s3 bucket`)
+	}
+	return fileList, nil
+}
+
 func (s *s3Storage) Delete(ctx context.Context, basename string) error {
 	return contextutil.RunWithTimeout(ctx, "delete s3 object",
 		timeoutSetting.Get(&s.settings.SV),
@@ -94,6 +94,10 @@ func (s *workloadStorage) ListFiles(_ context.Context, _ string) ([]string, erro
 	return nil, errors.Errorf(`workload storage does not support listing files`)
 }
 
+func (s *workloadStorage) ListFilesWithPrefix(_ context.Context, _ string) ([]string, error) {
+	return nil, errors.Errorf(`workload storage does not support listing files`)
+}
+
 func (s *workloadStorage) Delete(_ context.Context, _ string) error {
 	return errors.Errorf(`workload storage does not support deletes`)
 }
@@ -1680,6 +1680,19 @@ var charts = []sectionDescription{
 			},
 		},
 	},
+	{
+		Organization: [][]string{{SQLLayer, "Notifications"}},
+		Charts: []chartDescription{
+			{
+				Title: "Delivery",
+				Metrics: []string{
+					"sql.notifications.delivered",
+					"sql.notifications.dropped",
+				},
+				AxisLabel: "Notifications",
+			},
+		},
+	},
 	{
 		Organization: [][]string{{SQLLayer, "Temporary Objects Cleanup"}},
 		Charts: []chartDescription{
@@ -1697,6 +1710,27 @@ var charts = []sectionDescription{
 			},
 		},
 	},
+	{
+		Organization: [][]string{{SQLLayer, "Row-Level TTL"}},
+		Charts: []chartDescription{
+			{
+				Title: "Processed Rows",
+				Metrics: []string{
+					"jobs.row_level_ttl.rows_selected",
+					"jobs.row_level_ttl.rows_deleted",
+				},
+				AxisLabel: "Rows",
+			},
+			{
+				Title: "Latency",
+				Metrics: []string{
+					"jobs.row_level_ttl.select_duration",
+					"jobs.row_level_ttl.delete_duration",
+				},
+				AxisLabel: "Latency",
+			},
+		},
+	},
 	{
 		Organization: [][]string{{SQLLayer, "SQL"}},
 		Charts: []chartDescription{
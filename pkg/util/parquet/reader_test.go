@@ -0,0 +1,498 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"math/bits"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/require"
+)
+
+// This file implements a reader for the Parquet files written by the Writer,
+// which is used to check that they can be read back. It is written against
+// parquet-format rather than the Writer: it only shares the Thrift field IDs
+// and the enum values with it, and interprets the schema, the levels and the
+// values of the file on its own. It only supports the features of the format
+// that the Writer uses, but checks that the file does not use the others.
+
+// The enum values of parquet.thrift used by the reader.
+const (
+	readerTypeBoolean   = 0
+	readerTypeInt32     = 1
+	readerTypeInt64     = 2
+	readerTypeFloat     = 4
+	readerTypeDouble    = 5
+	readerTypeByteArray = 6
+
+	readerConvertedUTF8            = 0
+	readerConvertedList            = 3
+	readerConvertedDecimal         = 5
+	readerConvertedDate            = 6
+	readerConvertedTimeMicros      = 8
+	readerConvertedTimestampMicros = 10
+
+	readerRepetitionRequired = 0
+	readerRepetitionRepeated = 2
+
+	readerCodecUncompressed = 0
+	readerCodecSnappy       = 1
+	readerCodecGZIP         = 2
+
+	readerEncodingPlain = 0
+	readerEncodingRLE   = 3
+	readerPageTypeData  = 0
+)
+
+// thriftFields are the fields of a decoded Thrift struct, indexed by field ID.
+type thriftFields map[int16]interface{}
+
+// thriftReader decodes values encoded with the Thrift compact protocol.
+type thriftReader struct {
+	t *testing.T
+	b []byte
+}
+
+func (r *thriftReader) next(n int) []byte {
+	require.GreaterOrEqual(r.t, len(r.b), n, "unexpected end of Thrift data")
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	require.Greater(r.t, n, 0, "invalid varint")
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) readStruct() thriftFields {
+	s := make(thriftFields)
+	var id int16
+	for {
+		header := r.next(1)[0]
+		typ := header & 0x0f
+		if typ == 0 {
+			return s
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(r.zigzag())
+		}
+		switch typ {
+		case 1, 2:
+			// The value of a boolean field is stored in its type.
+			s[id] = typ == 1
+		default:
+			s[id] = r.readValue(typ)
+		}
+	}
+}
+
+func (r *thriftReader) readValue(typ byte) interface{} {
+	switch typ {
+	case 1, 2:
+		return r.next(1)[0] == 1
+	case 3:
+		return int8(r.next(1)[0])
+	case 4:
+		return int16(r.zigzag())
+	case 5:
+		return int32(r.zigzag())
+	case 6:
+		return r.zigzag()
+	case 7:
+		return math.Float64frombits(binary.LittleEndian.Uint64(r.next(8)))
+	case 8:
+		return r.next(int(r.uvarint()))
+	case 9, 10:
+		header := r.next(1)[0]
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		l := make([]interface{}, n)
+		for i := range l {
+			l[i] = r.readValue(header & 0x0f)
+		}
+		return l
+	case 12:
+		return r.readStruct()
+	}
+	r.t.Fatalf("unsupported Thrift type %d", typ)
+	return nil
+}
+
+func (s thriftFields) i32(t *testing.T, id int16) int32 {
+	v, ok := s[id].(int32)
+	require.True(t, ok, "missing i32 field %d in %v", id, s)
+	return v
+}
+
+// optI32 returns the value of an optional i32 field, or -1 if it is not set.
+func (s thriftFields) optI32(t *testing.T, id int16) int32 {
+	if _, ok := s[id]; !ok {
+		return -1
+	}
+	return s.i32(t, id)
+}
+
+func (s thriftFields) i64(t *testing.T, id int16) int64 {
+	v, ok := s[id].(int64)
+	require.True(t, ok, "missing i64 field %d in %v", id, s)
+	return v
+}
+
+func (s thriftFields) str(t *testing.T, id int16) string {
+	v, ok := s[id].([]byte)
+	require.True(t, ok, "missing binary field %d in %v", id, s)
+	return string(v)
+}
+
+func (s thriftFields) list(t *testing.T, id int16) []interface{} {
+	v, ok := s[id].([]interface{})
+	require.True(t, ok, "missing list field %d in %v", id, s)
+	return v
+}
+
+func (s thriftFields) strct(t *testing.T, id int16) thriftFields {
+	v, ok := s[id].(thriftFields)
+	require.True(t, ok, "missing struct field %d in %v", id, s)
+	return v
+}
+
+// schemaNode is an element of the schema of a Parquet file.
+type schemaNode struct {
+	name       string
+	repetition int32
+	// physical is -1 for groups.
+	physical  int32
+	converted int32
+	scale     int32
+	children  []*schemaNode
+}
+
+// readSchema rebuilds the tree of the schema from its flattened elements, in
+// depth-first order.
+func readSchema(t *testing.T, elems []interface{}) *schemaNode {
+	var parse func() *schemaNode
+	parse = func() *schemaNode {
+		require.NotEmpty(t, elems, "truncated schema")
+		e := elems[0].(thriftFields)
+		elems = elems[1:]
+		n := &schemaNode{
+			name:       e.str(t, 4),
+			repetition: e.optI32(t, 3),
+			physical:   e.optI32(t, 1),
+			converted:  e.optI32(t, 6),
+			scale:      e.optI32(t, 7),
+		}
+		for i := int32(0); i < e.optI32(t, 5); i++ {
+			n.children = append(n.children, parse())
+		}
+		return n
+	}
+	root := parse()
+	require.Empty(t, elems, "unexpected schema elements after the root")
+	return root
+}
+
+// readColumn is a top-level column of a Parquet file, as read by readFile.
+type readColumn struct {
+	name string
+	// list is set if the column is a 3-level LIST.
+	list bool
+	leaf *schemaNode
+	// path, maxDef and maxRep are those of the leaf values.
+	path           []string
+	maxDef, maxRep int
+}
+
+func newReadColumn(t *testing.T, n *schemaNode) readColumn {
+	c := readColumn{name: n.name, path: []string{n.name}, leaf: n}
+	levels := func(n *schemaNode) {
+		if n.repetition != readerRepetitionRequired {
+			c.maxDef++
+		}
+		if n.repetition == readerRepetitionRepeated {
+			c.maxRep++
+		}
+	}
+	levels(n)
+	if n.converted == readerConvertedList {
+		require.Len(t, n.children, 1, "LIST %s", n.name)
+		repeated := n.children[0]
+		require.Equal(t, int32(readerRepetitionRepeated), repeated.repetition, "LIST %s", n.name)
+		require.Len(t, repeated.children, 1, "LIST %s", n.name)
+		levels(repeated)
+		c.list, c.leaf = true, repeated.children[0]
+		levels(c.leaf)
+		c.path = append(c.path, repeated.name, c.leaf.name)
+	}
+	require.Empty(t, c.leaf.children, "unsupported nested column %s", n.name)
+	return c
+}
+
+// readFile reads a Parquet file. It returns the names of its columns and its
+// rows. NULLs are returned as nil, and LISTs as []interface{}. UTF8 values are
+// returned as strings, DECIMAL values as strings formatted with their scale,
+// DATE and TIMESTAMP_MICROS values as UTC time.Times and TIME_MICROS values as
+// time.Durations. The other values are returned as the Go type of their
+// physical type.
+func readFile(t *testing.T, b []byte) ([]string, [][]interface{}) {
+	require.True(t, bytes.HasPrefix(b, []byte("PAR1")), "missing leading magic")
+	require.True(t, bytes.HasSuffix(b, []byte("PAR1")), "missing trailing magic")
+	footerLen := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	footerReader := thriftReader{t: t, b: b[len(b)-8-footerLen : len(b)-8]}
+	meta := footerReader.readStruct()
+	require.Empty(t, footerReader.b, "trailing bytes after the footer")
+
+	root := readSchema(t, meta.list(t, 2))
+	numRows := int(meta.i64(t, 3))
+	names := make([]string, len(root.children))
+	rows := make([][]interface{}, numRows)
+	for i := range rows {
+		rows[i] = make([]interface{}, len(root.children))
+	}
+
+	rowGroups := meta.list(t, 4)
+	if numRows == 0 {
+		require.Empty(t, rowGroups)
+	} else {
+		require.Len(t, rowGroups, 1)
+	}
+	for _, rg := range rowGroups {
+		rg := rg.(thriftFields)
+		require.Equal(t, int64(numRows), rg.i64(t, 3))
+		chunks := rg.list(t, 1)
+		require.Len(t, chunks, len(root.children))
+		for i, chunk := range chunks {
+			col := newReadColumn(t, root.children[i])
+			values := readColumnChunk(t, b, col, chunk.(thriftFields).strct(t, 3))
+			require.Len(t, values, numRows, "column %s", col.name)
+			for j := range values {
+				rows[j][i] = values[j]
+			}
+		}
+	}
+	for i, n := range root.children {
+		names[i] = n.name
+	}
+	return names, rows
+}
+
+// readColumnChunk reads the values of a column chunk, which must be made of a
+// single data page.
+func readColumnChunk(t *testing.T, file []byte, col readColumn, meta thriftFields) []interface{} {
+	require.Equal(t, col.leaf.physical, meta.i32(t, 1), "column %s", col.name)
+	var path []string
+	for _, p := range meta.list(t, 3) {
+		path = append(path, string(p.([]byte)))
+	}
+	require.Equal(t, col.path, path)
+	numLevels := int(meta.i64(t, 5))
+
+	r := thriftReader{t: t, b: file[meta.i64(t, 9):]}
+	pageHeader := r.readStruct()
+	require.Equal(t, int32(readerPageTypeData), pageHeader.i32(t, 1))
+	dataPageHeader := pageHeader.strct(t, 5)
+	require.Equal(t, int32(numLevels), dataPageHeader.i32(t, 1))
+	require.Equal(t, int32(readerEncodingPlain), dataPageHeader.i32(t, 2))
+	require.Equal(t, int32(readerEncodingRLE), dataPageHeader.i32(t, 3))
+	require.Equal(t, int32(readerEncodingRLE), dataPageHeader.i32(t, 4))
+
+	page := r.next(int(pageHeader.i32(t, 3)))
+	switch meta.i32(t, 4) {
+	case readerCodecUncompressed:
+	case readerCodecSnappy:
+		var err error
+		page, err = snappy.Decode(nil, page)
+		require.NoError(t, err)
+	case readerCodecGZIP:
+		gz, err := gzip.NewReader(bytes.NewReader(page))
+		require.NoError(t, err)
+		page, err = ioutil.ReadAll(gz)
+		require.NoError(t, err)
+	default:
+		t.Fatalf("unsupported codec %d", meta.i32(t, 4))
+	}
+	require.Len(t, page, int(pageHeader.i32(t, 2)))
+
+	var repLevels []int
+	if col.maxRep > 0 {
+		repLevels, page = readLevels(t, page, col.maxRep, numLevels)
+	}
+	defLevels, page := readLevels(t, page, col.maxDef, numLevels)
+	var numValues int
+	for _, def := range defLevels {
+		if def == col.maxDef {
+			numValues++
+		}
+	}
+	leafValues := readPlainValues(t, page, col.leaf, numValues)
+
+	// Assemble the values of the rows from the levels of the leaf values.
+	var values []interface{}
+	for i, def := range defLevels {
+		var v interface{}
+		if def == col.maxDef {
+			v, leafValues = leafValues[0], leafValues[1:]
+		}
+		if !col.list {
+			values = append(values, v)
+			continue
+		}
+		if repLevels[i] == 0 {
+			// A new row starts. It is NULL if the LIST is not defined, and an
+			// empty array if the repeated group is not defined.
+			switch {
+			case def == 0:
+				values = append(values, nil)
+				continue
+			case def == 1:
+				values = append(values, []interface{}{})
+				continue
+			}
+			values = append(values, []interface{}(nil))
+		}
+		last := &values[len(values)-1]
+		*last = append((*last).([]interface{}), v)
+	}
+	return values
+}
+
+// readLevels reads the levels of a data page, which are encoded with the
+// RLE/bit-packing hybrid encoding and prefixed with their length.
+func readLevels(t *testing.T, page []byte, maxLevel int, n int) ([]int, []byte) {
+	require.GreaterOrEqual(t, len(page), 4)
+	length := int(binary.LittleEndian.Uint32(page))
+	require.GreaterOrEqual(t, len(page), 4+length)
+	r := thriftReader{t: t, b: page[4 : 4+length]}
+	bitWidth := bits.Len(uint(maxLevel))
+	var levels []int
+	for len(levels) < n {
+		header := r.uvarint()
+		if header&1 == 0 {
+			// RLE run: the repeated value is stored on ceil(bitWidth/8) bytes.
+			var v int
+			for i, b := range r.next((bitWidth + 7) / 8) {
+				v |= int(b) << (8 * uint(i))
+			}
+			for i := uint64(0); i < header>>1; i++ {
+				levels = append(levels, v)
+			}
+		} else {
+			// Bit-packed run of groups of 8 values.
+			packed := r.next(int(header>>1) * bitWidth)
+			for i := 0; i < int(header>>1)*8; i++ {
+				var v int
+				for j := 0; j < bitWidth; j++ {
+					bit := i*bitWidth + j
+					v |= int(packed[bit/8]>>uint(bit%8)&1) << uint(j)
+				}
+				levels = append(levels, v)
+			}
+		}
+	}
+	require.Empty(t, r.b, "trailing bytes after the levels")
+	for _, l := range levels[n:] {
+		// Bit-packed runs are padded with zeros.
+		require.Zero(t, l)
+	}
+	return levels[:n], page[4+length:]
+}
+
+// readPlainValues reads PLAIN-encoded leaf values.
+func readPlainValues(t *testing.T, page []byte, leaf *schemaNode, n int) []interface{} {
+	r := thriftReader{t: t, b: page}
+	values := make([]interface{}, n)
+	for i := range values {
+		switch leaf.physical {
+		case readerTypeBoolean:
+			values[i] = page[i/8]>>uint(i%8)&1 == 1
+		case readerTypeInt32:
+			values[i] = int32(binary.LittleEndian.Uint32(r.next(4)))
+		case readerTypeInt64:
+			values[i] = int64(binary.LittleEndian.Uint64(r.next(8)))
+		case readerTypeFloat:
+			values[i] = math.Float32frombits(binary.LittleEndian.Uint32(r.next(4)))
+		case readerTypeDouble:
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(r.next(8)))
+		case readerTypeByteArray:
+			values[i] = r.next(int(binary.LittleEndian.Uint32(r.next(4))))
+		default:
+			t.Fatalf("unsupported physical type %d", leaf.physical)
+		}
+		values[i] = convertValue(t, values[i], leaf)
+	}
+	if leaf.physical == readerTypeBoolean {
+		require.Len(t, page, (n+7)/8)
+	} else {
+		require.Empty(t, r.b, "trailing bytes after the values")
+	}
+	return values
+}
+
+// convertValue converts a physical value to the Go value of its converted
+// type.
+func convertValue(t *testing.T, v interface{}, leaf *schemaNode) interface{} {
+	switch leaf.converted {
+	case readerConvertedUTF8:
+		return string(v.([]byte))
+	case readerConvertedDecimal:
+		// The unscaled value is stored in big-endian two's complement.
+		b := v.([]byte)
+		unscaled := new(big.Int).SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+		}
+		return formatDecimal(unscaled, int(leaf.scale))
+	case readerConvertedDate:
+		return time.Unix(int64(v.(int32))*24*60*60, 0).UTC()
+	case readerConvertedTimestampMicros:
+		return time.Unix(0, v.(int64)*1000).UTC()
+	case readerConvertedTimeMicros:
+		return time.Duration(v.(int64)) * time.Microsecond
+	}
+	return v
+}
+
+// formatDecimal formats an unscaled decimal value with the given scale.
+func formatDecimal(unscaled *big.Int, scale int) string {
+	digits := new(big.Int).Abs(unscaled).String()
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	s := digits
+	if scale > 0 {
+		s = digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	}
+	if unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
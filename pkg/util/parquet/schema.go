@@ -0,0 +1,203 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Package parquet writes SQL rows to Apache Parquet files.
+//
+// Only the subset of the format needed to export rows is implemented. The
+// rows of a file are buffered in memory and written as a single row group
+// when the file is closed, with one data page per column that stores the
+// values with the PLAIN encoding. The columns are typed, except for the SQL
+// types that have no Parquet equivalent, whose values are written as strings
+// in the format used by EXPORT (see leafKind). JSON values are written as
+// strings as well. Arrays are written as LISTs of their elements.
+//
+// See https://github.com/apache/parquet-format for the specification of the
+// format.
+package parquet
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// physicalType is the type used to store the values of a column. It is the
+// Type enum of parquet.thrift.
+type physicalType int32
+
+const (
+	typeBoolean   physicalType = 0
+	typeInt32     physicalType = 1
+	typeInt64     physicalType = 2
+	typeFloat     physicalType = 4
+	typeDouble    physicalType = 5
+	typeByteArray physicalType = 6
+)
+
+// convertedType is the logical type of a column. It is the ConvertedType enum
+// of parquet.thrift.
+type convertedType int32
+
+const (
+	// convertedNone is not part of the enum: it means that the converted_type
+	// field is not set.
+	convertedNone            convertedType = -1
+	convertedUTF8            convertedType = 0
+	convertedList            convertedType = 3
+	convertedDecimal         convertedType = 5
+	convertedDate            convertedType = 6
+	convertedTimeMicros      convertedType = 8
+	convertedTimestampMicros convertedType = 10
+	convertedInt16           convertedType = 16
+	convertedInt32           convertedType = 17
+	convertedInt64           convertedType = 18
+)
+
+// repetitionType is the FieldRepetitionType enum of parquet.thrift.
+type repetitionType int32
+
+const (
+	repetitionOptional repetitionType = 1
+	repetitionRepeated repetitionType = 2
+)
+
+// leafKind is the way the datums of a column, or the elements of the arrays
+// of an array column, are converted to Parquet values.
+type leafKind int
+
+const (
+	kindBool leafKind = iota
+	kindInt16
+	kindInt32
+	kindInt64
+	kindFloat32
+	kindFloat64
+	// kindDecimal is used for the decimals with a fixed precision and scale,
+	// which are stored as the big-endian two's complement representation of
+	// their unscaled value. The other decimals are written as strings.
+	kindDecimal
+	kindBytes
+	// kindString is used for the string-like types, and for all the types
+	// that have no Parquet equivalent.
+	kindString
+	// kindDate stores the number of days since the Unix epoch.
+	kindDate
+	// kindTimestamp stores the number of microseconds since the Unix epoch.
+	kindTimestamp
+	// kindTime stores the number of microseconds since midnight.
+	kindTime
+)
+
+// column describes how the datums of a column are stored.
+type column struct {
+	name string
+	typ  *types.T
+	// list is true if the column is an array, which is stored as a LIST of
+	// its elements.
+	list bool
+	// kind, physical, converted, scale and precision describe the leaf values
+	// of the column, i.e. either the datums or the elements of the arrays.
+	kind      leafKind
+	physical  physicalType
+	converted convertedType
+	// scale and precision are only set for kindDecimal.
+	scale, precision int32
+}
+
+// path returns the path of the leaf values of the column in the schema.
+func (c *column) path() []string {
+	if c.list {
+		return []string{c.name, "list", "element"}
+	}
+	return []string{c.name}
+}
+
+// maxDefinitionLevel returns the definition level of the non-NULL leaf
+// values of the column. Every optional or repeated element of the path
+// increments it.
+func (c *column) maxDefinitionLevel() uint8 {
+	if c.list {
+		return 3
+	}
+	return 1
+}
+
+// maxRepetitionLevel returns the highest repetition level of the leaf values
+// of the column. Every repeated element of the path increments it.
+func (c *column) maxRepetitionLevel() uint8 {
+	if c.list {
+		return 1
+	}
+	return 0
+}
+
+// Schema describes the columns of the rows written to a Parquet file. All
+// the columns are nullable.
+type Schema struct {
+	columns []column
+}
+
+// NewSchema returns the schema of the rows with the given column names and
+// types.
+func NewSchema(columnNames []string, columnTypes []*types.T) (*Schema, error) {
+	if len(columnNames) != len(columnTypes) {
+		return nil, errors.AssertionFailedf(
+			"%d column names for %d column types", len(columnNames), len(columnTypes))
+	}
+	s := &Schema{columns: make([]column, len(columnNames))}
+	for i, typ := range columnTypes {
+		c := &s.columns[i]
+		c.name, c.typ = columnNames[i], typ
+		leafType := typ
+		if typ.Family() == types.ArrayFamily {
+			c.list = true
+			leafType = typ.ArrayContents()
+		}
+		c.kind, c.physical, c.converted = leafKindForType(leafType)
+		if c.kind == kindDecimal {
+			c.precision, c.scale = leafType.Precision(), leafType.Scale()
+		}
+	}
+	return s, nil
+}
+
+// leafKindForType returns the way the datums of the given type are stored.
+func leafKindForType(typ *types.T) (leafKind, physicalType, convertedType) {
+	switch typ.Family() {
+	case types.BoolFamily:
+		return kindBool, typeBoolean, convertedNone
+	case types.IntFamily:
+		switch typ.Width() {
+		case 16:
+			return kindInt16, typeInt32, convertedInt16
+		case 32:
+			return kindInt32, typeInt32, convertedInt32
+		default:
+			return kindInt64, typeInt64, convertedInt64
+		}
+	case types.FloatFamily:
+		if typ.Width() == 32 {
+			return kindFloat32, typeFloat, convertedNone
+		}
+		return kindFloat64, typeDouble, convertedNone
+	case types.DecimalFamily:
+		if typ.Precision() > 0 {
+			return kindDecimal, typeByteArray, convertedDecimal
+		}
+	case types.BytesFamily:
+		return kindBytes, typeByteArray, convertedNone
+	case types.DateFamily:
+		return kindDate, typeInt32, convertedDate
+	case types.TimestampFamily, types.TimestampTZFamily:
+		return kindTimestamp, typeInt64, convertedTimestampMicros
+	case types.TimeFamily:
+		return kindTime, typeInt64, convertedTimeMicros
+	}
+	return kindString, typeByteArray, convertedUTF8
+}
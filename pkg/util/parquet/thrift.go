@@ -0,0 +1,109 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package parquet
+
+import "encoding/binary"
+
+// The types of the fields of the Thrift compact protocol.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structs with the Thrift compact protocol, which is
+// used for the page headers and the footer of Parquet files. The fields of a
+// struct must be written in increasing order of their IDs.
+type thriftWriter struct {
+	buf []byte
+	// lastFieldID is the ID of the last field written to the current struct,
+	// and lastFieldIDs those of the enclosing structs.
+	lastFieldID  int16
+	lastFieldIDs []int16
+}
+
+func (w *thriftWriter) uvarint(v uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(scratch[:], v)
+	w.buf = append(w.buf, scratch[:n]...)
+}
+
+// varint writes a zigzag-encoded integer.
+func (w *thriftWriter) varint(v int64) {
+	w.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (w *thriftWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - w.lastFieldID; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.varint(int64(id))
+	}
+	w.lastFieldID = id
+}
+
+func (w *thriftWriter) i32Field(id int16, v int32) {
+	w.fieldHeader(id, thriftI32)
+	w.varint(int64(v))
+}
+
+func (w *thriftWriter) i64Field(id int16, v int64) {
+	w.fieldHeader(id, thriftI64)
+	w.varint(v)
+}
+
+func (w *thriftWriter) stringField(id int16, s string) {
+	w.fieldHeader(id, thriftBinary)
+	w.string(s)
+}
+
+// structField starts a struct field, which must be terminated by endStruct.
+func (w *thriftWriter) structField(id int16) {
+	w.fieldHeader(id, thriftStruct)
+	w.beginStruct()
+}
+
+// listField starts a list field of n elements of the given type, which must
+// be written immediately after.
+func (w *thriftWriter) listField(id int16, elemType byte, n int) {
+	w.fieldHeader(id, thriftList)
+	if n < 15 {
+		w.buf = append(w.buf, byte(n)<<4|elemType)
+	} else {
+		w.buf = append(w.buf, 0xf0|elemType)
+		w.uvarint(uint64(n))
+	}
+}
+
+// beginStruct starts a struct that is not a field, i.e. the top-level struct
+// or an element of a list.
+func (w *thriftWriter) beginStruct() {
+	w.lastFieldIDs = append(w.lastFieldIDs, w.lastFieldID)
+	w.lastFieldID = 0
+}
+
+func (w *thriftWriter) endStruct() {
+	w.buf = append(w.buf, 0)
+	w.lastFieldID = w.lastFieldIDs[len(w.lastFieldIDs)-1]
+	w.lastFieldIDs = w.lastFieldIDs[:len(w.lastFieldIDs)-1]
+}
+
+func (w *thriftWriter) i32(v int32) {
+	w.varint(int64(v))
+}
+
+func (w *thriftWriter) string(s string) {
+	w.uvarint(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
@@ -0,0 +1,508 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/big"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/golang/snappy"
+)
+
+// magic starts and ends every Parquet file.
+const magic = "PAR1"
+
+const createdBy = "CockroachDB"
+
+// CompressionCodec is the codec used to compress the pages of a Parquet
+// file. It is the CompressionCodec enum of parquet.thrift.
+type CompressionCodec int32
+
+const (
+	// CompressionNone leaves the pages uncompressed.
+	CompressionNone CompressionCodec = 0
+	// CompressionSnappy compresses the pages with Snappy.
+	CompressionSnappy CompressionCodec = 1
+	// CompressionGZIP compresses the pages with gzip.
+	CompressionGZIP CompressionCodec = 2
+)
+
+// The values of the Encoding and PageType enums of parquet.thrift that are
+// used by the Writer.
+const (
+	encodingPlain  = 0
+	encodingRLE    = 3
+	pageTypeData   = 0
+	fileVersionOne = 1
+)
+
+// Writer writes rows to a Parquet file. The rows are buffered in memory
+// until the Writer is closed.
+//
+// A Writer is not safe for concurrent use, and must not be used anymore once
+// AddRow returns an error.
+type Writer struct {
+	sch         *Schema
+	sink        io.Writer
+	compression CompressionCodec
+	chunks      []columnChunk
+	numRows     int64
+	fmtCtx      *tree.FmtCtx
+}
+
+// columnChunk buffers the values of a column.
+type columnChunk struct {
+	col *column
+	// defLevels and repLevels hold the definition and repetition levels of
+	// the leaf values, including the NULL ones. repLevels is nil if the
+	// column is not repeated.
+	defLevels, repLevels []uint8
+	// values holds the PLAIN encoding of the non-NULL leaf values, except
+	// for booleans, which are bit-packed when the page is written.
+	values []byte
+	bools  []bool
+}
+
+// NewWriter returns a Writer that writes a Parquet file with the given schema
+// to the sink.
+func NewWriter(sch *Schema, sink io.Writer, compression CompressionCodec) *Writer {
+	w := &Writer{
+		sch:         sch,
+		sink:        sink,
+		compression: compression,
+		chunks:      make([]columnChunk, len(sch.columns)),
+		fmtCtx:      tree.NewFmtCtx(tree.FmtExport),
+	}
+	for i := range w.chunks {
+		w.chunks[i].col = &sch.columns[i]
+	}
+	return w
+}
+
+// AddRow buffers a row. Its datums must match the columns of the schema.
+func (w *Writer) AddRow(datums tree.Datums) error {
+	if len(datums) != len(w.chunks) {
+		return errors.AssertionFailedf(
+			"expected %d datums, got %d", len(w.chunks), len(datums))
+	}
+	for i := range w.chunks {
+		if err := w.chunks[i].add(datums[i], w.fmtCtx); err != nil {
+			return errors.Wrapf(err, "column %q", w.chunks[i].col.name)
+		}
+	}
+	w.numRows++
+	return nil
+}
+
+// NumRows returns the number of rows added to the Writer.
+func (w *Writer) NumRows() int64 {
+	return w.numRows
+}
+
+// BufferedBytes returns an estimate of the size of the buffered rows.
+func (w *Writer) BufferedBytes() int64 {
+	var size int
+	for i := range w.chunks {
+		c := &w.chunks[i]
+		size += len(c.defLevels) + len(c.repLevels) + len(c.values) + len(c.bools)/8
+	}
+	return int64(size)
+}
+
+// Close writes the buffered rows to the sink, followed by the metadata of
+// the file. It does not close the sink.
+func (w *Writer) Close() error {
+	defer w.fmtCtx.Close()
+	out := countingWriter{w: w.sink}
+	if _, err := out.Write([]byte(magic)); err != nil {
+		return err
+	}
+	metas := make([]chunkMeta, len(w.chunks))
+	if w.numRows > 0 {
+		for i := range w.chunks {
+			var err error
+			if metas[i], err = w.chunks[i].writePage(&out, w.compression); err != nil {
+				return err
+			}
+		}
+	}
+	footer := w.footer(metas)
+	if _, err := out.Write(footer); err != nil {
+		return err
+	}
+	var footerLen [4]byte
+	binary.LittleEndian.PutUint32(footerLen[:], uint32(len(footer)))
+	if _, err := out.Write(footerLen[:]); err != nil {
+		return err
+	}
+	_, err := out.Write([]byte(magic))
+	return err
+}
+
+// add buffers the value of the column in a row.
+func (c *columnChunk) add(d tree.Datum, fmtCtx *tree.FmtCtx) error {
+	if d == tree.DNull {
+		c.addLevels(0 /* rep */, 0 /* def */)
+		return nil
+	}
+	if !c.col.list {
+		c.addLevels(0 /* rep */, 1 /* def */)
+		return c.addValue(d, fmtCtx)
+	}
+	arr, ok := tree.AsDArray(d)
+	if !ok {
+		return errors.AssertionFailedf("expected array, got %T", d)
+	}
+	// An empty array is defined up to the LIST, and a NULL element up to the
+	// repeated group.
+	if len(arr.Array) == 0 {
+		c.addLevels(0 /* rep */, 1 /* def */)
+		return nil
+	}
+	for i, elem := range arr.Array {
+		var rep uint8
+		if i > 0 {
+			rep = 1
+		}
+		if elem == tree.DNull {
+			c.addLevels(rep, 2 /* def */)
+			continue
+		}
+		c.addLevels(rep, 3 /* def */)
+		if err := c.addValue(elem, fmtCtx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *columnChunk) addLevels(rep, def uint8) {
+	if c.col.maxRepetitionLevel() > 0 {
+		c.repLevels = append(c.repLevels, rep)
+	}
+	c.defLevels = append(c.defLevels, def)
+}
+
+// addValue buffers a non-NULL leaf value.
+func (c *columnChunk) addValue(d tree.Datum, fmtCtx *tree.FmtCtx) error {
+	d = tree.UnwrapDatum(nil /* evalCtx */, d)
+	var err error
+	switch c.col.kind {
+	case kindBool:
+		c.bools = append(c.bools, bool(*d.(*tree.DBool)))
+	case kindInt16, kindInt32:
+		c.values = appendUint32(c.values, uint32(*d.(*tree.DInt)))
+	case kindInt64:
+		c.values = appendUint64(c.values, uint64(*d.(*tree.DInt)))
+	case kindFloat32:
+		c.values = appendUint32(c.values, math.Float32bits(float32(*d.(*tree.DFloat))))
+	case kindFloat64:
+		c.values = appendUint64(c.values, math.Float64bits(float64(*d.(*tree.DFloat))))
+	case kindDecimal:
+		c.values, err = appendDecimal(c.values, &d.(*tree.DDecimal).Decimal, c.col.scale)
+	case kindBytes:
+		c.values = appendByteArray(c.values, string(*d.(*tree.DBytes)))
+	case kindString:
+		c.values = appendByteArray(c.values, formatString(d, fmtCtx))
+	case kindDate:
+		date := d.(*tree.DDate).Date
+		if !date.IsFinite() {
+			return errors.Errorf("cannot write infinite date %s to Parquet", date)
+		}
+		c.values = appendUint32(c.values, uint32(date.UnixEpochDays()))
+	case kindTimestamp:
+		switch t := d.(type) {
+		case *tree.DTimestamp:
+			c.values = appendUint64(c.values, uint64(t.Unix()*1e6+int64(t.Nanosecond()/1e3)))
+		case *tree.DTimestampTZ:
+			c.values = appendUint64(c.values, uint64(t.Unix()*1e6+int64(t.Nanosecond()/1e3)))
+		}
+	case kindTime:
+		c.values = appendUint64(c.values, uint64(*d.(*tree.DTime)))
+	default:
+		return errors.AssertionFailedf("unexpected leaf kind %d", c.col.kind)
+	}
+	return err
+}
+
+// formatString returns the value of the datums stored as strings.
+func formatString(d tree.Datum, fmtCtx *tree.FmtCtx) string {
+	switch t := d.(type) {
+	case *tree.DString:
+		return string(*t)
+	case *tree.DCollatedString:
+		return t.Contents
+	}
+	defer fmtCtx.Reset()
+	fmtCtx.FormatNode(d)
+	return fmtCtx.String()
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var scratch [4]byte
+	binary.LittleEndian.PutUint32(scratch[:], v)
+	return append(b, scratch[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var scratch [8]byte
+	binary.LittleEndian.PutUint64(scratch[:], v)
+	return append(b, scratch[:]...)
+}
+
+// appendByteArray appends the PLAIN encoding of a BYTE_ARRAY value: its
+// length followed by its bytes.
+func appendByteArray(b []byte, v string) []byte {
+	b = appendUint32(b, uint32(len(v)))
+	return append(b, v...)
+}
+
+// appendDecimal appends a decimal rounded to the given scale, as the
+// big-endian two's complement representation of its unscaled value.
+func appendDecimal(b []byte, d *apd.Decimal, scale int32) ([]byte, error) {
+	if d.Form != apd.Finite {
+		return nil, errors.Errorf("cannot write %s to a Parquet DECIMAL column", d)
+	}
+	var rounded apd.Decimal
+	if _, err := tree.HighPrecisionCtx.Quantize(&rounded, d, -scale); err != nil {
+		return nil, err
+	}
+	unscaled := &rounded.Coeff
+	var v []byte
+	if rounded.Negative && unscaled.Sign() != 0 {
+		// The two's complement of -x on n bytes is 2^(8n) - x, where n is the
+		// smallest number of bytes such that x <= 2^(8n-1).
+		n := new(big.Int).Sub(unscaled, big.NewInt(1)).BitLen()/8 + 1
+		complement := new(big.Int).Lsh(big.NewInt(1), uint(8*n))
+		v = complement.Sub(complement, unscaled).Bytes()
+	} else {
+		v = unscaled.Bytes()
+		if len(v) == 0 || v[0]&0x80 != 0 {
+			v = append([]byte{0}, v...)
+		}
+	}
+	return appendByteArray(b, string(v)), nil
+}
+
+// appendLevels appends the levels of a data page, prefixed with their length.
+// They are encoded with the RLE/bit-packing hybrid encoding, using only RLE
+// runs. The levels of the Writer are at most 3, so their values fit in a
+// byte.
+func appendLevels(b []byte, levels []uint8) []byte {
+	start := len(b)
+	b = append(b, 0, 0, 0, 0)
+	var scratch [binary.MaxVarintLen64]byte
+	for i := 0; i < len(levels); {
+		j := i + 1
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		n := binary.PutUvarint(scratch[:], uint64(j-i)<<1)
+		b = append(b, scratch[:n]...)
+		b = append(b, levels[i])
+		i = j
+	}
+	binary.LittleEndian.PutUint32(b[start:], uint32(len(b)-start-4))
+	return b
+}
+
+// appendBools appends the PLAIN encoding of booleans: one bit per value,
+// starting with the least significant bit.
+func appendBools(b []byte, bools []bool) []byte {
+	for i := 0; i < len(bools); i += 8 {
+		var packed byte
+		for j := 0; j < 8 && i+j < len(bools); j++ {
+			if bools[i+j] {
+				packed |= 1 << uint(j)
+			}
+		}
+		b = append(b, packed)
+	}
+	return b
+}
+
+// chunkMeta describes a column chunk written to the file.
+type chunkMeta struct {
+	offset                           int64
+	numValues                        int64
+	uncompressedSize, compressedSize int64
+}
+
+// writePage writes the buffered values of the column as a single data page.
+func (c *columnChunk) writePage(out *countingWriter, codec CompressionCodec) (chunkMeta, error) {
+	var page []byte
+	if c.col.maxRepetitionLevel() > 0 {
+		page = appendLevels(page, c.repLevels)
+	}
+	page = appendLevels(page, c.defLevels)
+	if c.col.kind == kindBool {
+		page = appendBools(page, c.bools)
+	} else {
+		page = append(page, c.values...)
+	}
+
+	compressed := page
+	switch codec {
+	case CompressionSnappy:
+		compressed = snappy.Encode(nil, page)
+	case CompressionGZIP:
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(page); err != nil {
+			return chunkMeta{}, err
+		}
+		if err := gz.Close(); err != nil {
+			return chunkMeta{}, err
+		}
+		compressed = buf.Bytes()
+	}
+
+	var header thriftWriter
+	header.beginStruct()
+	header.i32Field(1, pageTypeData)
+	header.i32Field(2, int32(len(page)))
+	header.i32Field(3, int32(len(compressed)))
+	header.structField(5)
+	header.i32Field(1, int32(len(c.defLevels)))
+	header.i32Field(2, encodingPlain)
+	header.i32Field(3, encodingRLE)
+	header.i32Field(4, encodingRLE)
+	header.endStruct()
+	header.endStruct()
+
+	meta := chunkMeta{
+		offset:           out.n,
+		numValues:        int64(len(c.defLevels)),
+		uncompressedSize: int64(len(header.buf) + len(page)),
+		compressedSize:   int64(len(header.buf) + len(compressed)),
+	}
+	if _, err := out.Write(header.buf); err != nil {
+		return chunkMeta{}, err
+	}
+	if _, err := out.Write(compressed); err != nil {
+		return chunkMeta{}, err
+	}
+	return meta, nil
+}
+
+// footer returns the FileMetaData of the file.
+func (w *Writer) footer(metas []chunkMeta) []byte {
+	var t thriftWriter
+	t.beginStruct()
+	t.i32Field(1, fileVersionOne)
+
+	// The schema is the flattened tree of the columns, whose root is a group
+	// of all the columns. An array column is a LIST group, which holds a
+	// repeated group of the elements.
+	numElements := 1
+	for i := range w.sch.columns {
+		numElements++
+		if w.sch.columns[i].list {
+			numElements += 2
+		}
+	}
+	t.listField(2, thriftStruct, numElements)
+	t.beginStruct()
+	t.stringField(4, "schema")
+	t.i32Field(5, int32(len(w.sch.columns)))
+	t.endStruct()
+	for i := range w.sch.columns {
+		c := &w.sch.columns[i]
+		if c.list {
+			t.beginStruct()
+			t.i32Field(3, int32(repetitionOptional))
+			t.stringField(4, c.name)
+			t.i32Field(5, 1)
+			t.i32Field(6, int32(convertedList))
+			t.endStruct()
+			t.beginStruct()
+			t.i32Field(3, int32(repetitionRepeated))
+			t.stringField(4, "list")
+			t.i32Field(5, 1)
+			t.endStruct()
+		}
+		path := c.path()
+		t.beginStruct()
+		t.i32Field(1, int32(c.physical))
+		t.i32Field(3, int32(repetitionOptional))
+		t.stringField(4, path[len(path)-1])
+		if c.converted != convertedNone {
+			t.i32Field(6, int32(c.converted))
+		}
+		if c.kind == kindDecimal {
+			t.i32Field(7, c.scale)
+			t.i32Field(8, c.precision)
+		}
+		t.endStruct()
+	}
+
+	t.i64Field(3, w.numRows)
+
+	// The rows are written as a single row group, unless there are none.
+	if w.numRows == 0 {
+		t.listField(4, thriftStruct, 0)
+	} else {
+		t.listField(4, thriftStruct, 1)
+		t.beginStruct()
+		t.listField(1, thriftStruct, len(w.sch.columns))
+		var totalSize int64
+		for i := range w.sch.columns {
+			c, m := &w.sch.columns[i], metas[i]
+			totalSize += m.uncompressedSize
+			t.beginStruct()
+			t.i64Field(2, m.offset)
+			t.structField(3)
+			t.i32Field(1, int32(c.physical))
+			t.listField(2, thriftI32, 2)
+			t.i32(encodingPlain)
+			t.i32(encodingRLE)
+			path := c.path()
+			t.listField(3, thriftBinary, len(path))
+			for _, p := range path {
+				t.string(p)
+			}
+			t.i32Field(4, int32(w.compression))
+			t.i64Field(5, m.numValues)
+			t.i64Field(6, m.uncompressedSize)
+			t.i64Field(7, m.compressedSize)
+			t.i64Field(9, m.offset)
+			t.endStruct()
+			t.endStruct()
+		}
+		t.i64Field(2, totalSize)
+		t.i64Field(3, w.numRows)
+		t.endStruct()
+	}
+
+	t.stringField(6, createdBy)
+	t.endStruct()
+	return t.buf
+}
+
+// countingWriter counts the bytes written to a writer, which are the offsets
+// of the column chunks in the file.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,236 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil/pgdate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendDecimal(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	for _, tc := range []struct {
+		d        string
+		scale    int32
+		expected []byte
+	}{
+		{d: "0", scale: 2, expected: []byte{0x00}},
+		{d: "12.5", scale: 2, expected: []byte{0x04, 0xe2}},
+		{d: "1.005", scale: 2, expected: []byte{0x65}},
+		{d: "255", scale: 0, expected: []byte{0x00, 0xff}},
+		{d: "-0.01", scale: 2, expected: []byte{0xff}},
+		{d: "-128", scale: 0, expected: []byte{0x80}},
+		{d: "-128.00", scale: 2, expected: []byte{0xce, 0x00}},
+	} {
+		t.Run(fmt.Sprintf("%s/%d", tc.d, tc.scale), func(t *testing.T) {
+			d, _, err := apd.NewFromString(tc.d)
+			require.NoError(t, err)
+			b, err := appendDecimal(nil, d, tc.scale)
+			require.NoError(t, err)
+			require.Equal(t, appendByteArray(nil, string(tc.expected)), b)
+		})
+	}
+
+	_, err := appendDecimal(nil, &apd.Decimal{Form: apd.Infinite}, 2)
+	require.EqualError(t, err, "cannot write Infinity to a Parquet DECIMAL column")
+}
+
+func TestAppendLevels(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	require.Equal(t, []byte{0, 0, 0, 0}, appendLevels(nil, nil))
+	require.Equal(t,
+		[]byte{6, 0, 0, 0, 3 << 1, 1, 1 << 1, 0, 1 << 1, 3},
+		appendLevels(nil, []uint8{1, 1, 1, 0, 3}))
+}
+
+func TestWriter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	sch, err := NewSchema(
+		[]string{"a", "b", "c"},
+		[]*types.T{types.Int, types.String, types.IntArray},
+	)
+	require.NoError(t, err)
+
+	arr := tree.NewDArray(types.Int)
+	require.NoError(t, arr.Append(tree.NewDInt(1)))
+	require.NoError(t, arr.Append(tree.DNull))
+	rows := []tree.Datums{
+		{tree.NewDInt(1), tree.NewDString("cat"), arr},
+		{tree.NewDInt(2), tree.DNull, tree.DNull},
+	}
+
+	for _, codec := range []CompressionCodec{CompressionNone, CompressionSnappy, CompressionGZIP} {
+		t.Run(fmt.Sprint(codec), func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewWriter(sch, &buf, codec)
+			for _, row := range rows {
+				require.NoError(t, w.AddRow(row))
+			}
+			require.EqualError(t, w.AddRow(tree.Datums{tree.DNull}), "expected 3 datums, got 1")
+			require.Equal(t, int64(len(rows)), w.NumRows())
+			require.NotZero(t, w.BufferedBytes())
+			require.NoError(t, w.Close())
+
+			b := buf.Bytes()
+			require.True(t, bytes.HasPrefix(b, []byte(magic)))
+			require.True(t, bytes.HasSuffix(b, []byte(magic)))
+			footerLen := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+			require.Less(t, footerLen, len(b)-12)
+			footer := b[len(b)-8-footerLen : len(b)-8]
+			for _, name := range []string{"a", "b", "c", "list", "element", createdBy} {
+				require.Contains(t, string(footer), name)
+			}
+			if codec == CompressionNone {
+				require.Contains(t, string(b), "cat")
+			}
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewWriter(sch, &buf, CompressionSnappy)
+		require.NoError(t, w.Close())
+		b := buf.Bytes()
+		footerLen := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+		require.Equal(t, len(b), len(magic)+footerLen+4+len(magic))
+	})
+
+	t.Run("infinite date", func(t *testing.T) {
+		sch, err := NewSchema([]string{"d"}, []*types.T{types.Date})
+		require.NoError(t, err)
+		w := NewWriter(sch, &bytes.Buffer{}, CompressionNone)
+		require.EqualError(t,
+			w.AddRow(tree.Datums{tree.NewDDate(pgdate.PosInfDate)}),
+			`column "d": cannot write infinite date infinity to Parquet`)
+	})
+}
+
+// TestWriterRoundTrip checks that the files written by the Writer are read
+// back with the values of the rows (see readFile).
+func TestWriterRoundTrip(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	names := []string{
+		"i", "i4", "i2", "f", "f4", "b", "s", "by", "d", "du", "da", "ts", "tz", "t", "j", "u", "a",
+	}
+	sch, err := NewSchema(names, []*types.T{
+		types.Int, types.Int4, types.Int2, types.Float, types.Float4, types.Bool,
+		types.String, types.Bytes, types.MakeDecimal(10, 2), types.Decimal, types.Date,
+		types.Timestamp, types.TimestampTZ, types.Time, types.Jsonb, types.Uuid, types.IntArray,
+	})
+	require.NoError(t, err)
+
+	mustDatum := func(d tree.Datum, err error) tree.Datum {
+		require.NoError(t, err)
+		return d
+	}
+	decimal := func(s string) tree.Datum {
+		return mustDatum(tree.ParseDDecimal(s))
+	}
+	array := func(elems ...tree.Datum) tree.Datum {
+		arr := tree.NewDArray(types.Int)
+		for _, e := range elems {
+			require.NoError(t, arr.Append(e))
+		}
+		return arr
+	}
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	beforeEpoch := time.Date(1969, 12, 31, 23, 59, 59, 999999000, time.UTC)
+	date := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	dayBeforeEpoch := time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	rows := []tree.Datums{
+		{
+			tree.NewDInt(1), tree.NewDInt(2), tree.NewDInt(3),
+			tree.NewDFloat(1.5), tree.NewDFloat(2.5), tree.DBoolTrue,
+			tree.NewDString("cat"), tree.NewDBytes("\x00\xff"),
+			decimal("12.5"), decimal("1.005"),
+			mustDatum(tree.NewDDateFromTime(date)),
+			mustDatum(tree.MakeDTimestamp(ts, time.Microsecond)),
+			mustDatum(tree.MakeDTimestampTZ(ts, time.Microsecond)),
+			tree.MakeDTime(timeofday.New(3, 4, 5, 123456)),
+			mustDatum(tree.ParseDJSON(`{"a": [1, true]}`)),
+			mustDatum(tree.ParseDUuidFromString("63616665-6630-3064-6465-616462656566")),
+			array(tree.NewDInt(1), tree.DNull, tree.NewDInt(3)),
+		},
+		{
+			tree.NewDInt(-1), tree.NewDInt(-2), tree.NewDInt(-3),
+			tree.NewDFloat(-0.25), tree.NewDFloat(-0.5), tree.DBoolFalse,
+			tree.NewDString(""), tree.NewDBytes(""),
+			decimal("-0.01"), decimal("-2.5"),
+			mustDatum(tree.NewDDateFromTime(dayBeforeEpoch)),
+			mustDatum(tree.MakeDTimestamp(beforeEpoch, time.Microsecond)),
+			mustDatum(tree.MakeDTimestampTZ(beforeEpoch, time.Microsecond)),
+			tree.MakeDTime(timeofday.Min),
+			mustDatum(tree.ParseDJSON(`"str"`)),
+			tree.NewDUuid(tree.DUuid{}),
+			array(),
+		},
+		{
+			tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull,
+			tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull,
+			tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull,
+		},
+	}
+	expected := [][]interface{}{
+		{
+			int64(1), int32(2), int32(3), float64(1.5), float32(2.5), true,
+			"cat", []byte("\x00\xff"), "12.50", "1.005", date, ts, ts,
+			3*time.Hour + 4*time.Minute + 5*time.Second + 123456*time.Microsecond,
+			`{"a": [1, true]}`, "63616665-6630-3064-6465-616462656566",
+			[]interface{}{int64(1), nil, int64(3)},
+		},
+		{
+			int64(-1), int32(-2), int32(-3), float64(-0.25), float32(-0.5), false,
+			"", []byte{}, "-0.01", "-2.5", dayBeforeEpoch, beforeEpoch, beforeEpoch,
+			time.Duration(0), `"str"`, "00000000-0000-0000-0000-000000000000",
+			[]interface{}{},
+		},
+		make([]interface{}, len(names)),
+	}
+
+	for _, codec := range []CompressionCodec{CompressionNone, CompressionSnappy, CompressionGZIP} {
+		t.Run(fmt.Sprint(codec), func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewWriter(sch, &buf, codec)
+			for _, row := range rows {
+				require.NoError(t, w.AddRow(row))
+			}
+			require.NoError(t, w.Close())
+
+			readNames, readRows := readFile(t, buf.Bytes())
+			require.Equal(t, names, readNames)
+			require.Equal(t, expected, readRows)
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, NewWriter(sch, &buf, CompressionSnappy).Close())
+		readNames, readRows := readFile(t, buf.Bytes())
+		require.Equal(t, names, readNames)
+		require.Empty(t, readRows)
+	})
+}